@@ -5,22 +5,34 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/log"
-	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	"github.com/openshift/hypershift-toolkit/pkg/cmd/cpoperator"
+	"github.com/openshift/hypershift-toolkit/pkg/cmd/frontend"
+	"github.com/openshift/hypershift-toolkit/pkg/cmd/ignition"
 	"github.com/openshift/hypershift-toolkit/pkg/controllers/autoapprover"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/awsinfra"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/certrotation"
 	"github.com/openshift/hypershift-toolkit/pkg/controllers/clusteroperator"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/clusterstatus"
 	"github.com/openshift/hypershift-toolkit/pkg/controllers/clusterversion"
 	"github.com/openshift/hypershift-toolkit/pkg/controllers/cmca"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/hostedcluster"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/ingresscert"
 	"github.com/openshift/hypershift-toolkit/pkg/controllers/kubeadminpwd"
 	"github.com/openshift/hypershift-toolkit/pkg/controllers/kubelet_serving_ca"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/nodegc"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/nodeupdater"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/oauthendpoint"
 	"github.com/openshift/hypershift-toolkit/pkg/controllers/openshift_apiserver"
 	"github.com/openshift/hypershift-toolkit/pkg/controllers/openshift_controller_manager"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/tunnelhealth"
+	"github.com/openshift/hypershift-toolkit/pkg/logging"
+	"github.com/openshift/hypershift-toolkit/pkg/pki/util"
 )
 
 const (
@@ -29,7 +41,6 @@ const (
 )
 
 func main() {
-	log.SetLogger(zap.New(zap.UseDevMode(true)))
 	setupLog := ctrl.Log.WithName("setup")
 	if err := newControlPlaneOperatorCommand().Execute(); err != nil {
 		setupLog.Error(err, "Operator failed")
@@ -45,6 +56,23 @@ var controllerFuncs = map[string]cpoperator.ControllerSetupFunc{
 	"kubelet-serving-ca":           kubelet_serving_ca.Setup,
 	"openshift-apiserver":          openshift_apiserver.Setup,
 	"openshift-controller-manager": openshift_controller_manager.Setup,
+	"aws-infra":                    awsinfra.Setup,
+	"aws-machine-targets":          awsinfra.SetupMachineTargets,
+	"aws-ignition-urls":            awsinfra.SetupIgnitionURLs,
+	"node-gc":                      nodegc.Setup,
+	"node-updater":                 nodeupdater.Setup,
+	"oauth-endpoint":               oauthendpoint.Setup,
+	"ingress-default-cert":         ingresscert.Setup,
+	"hosted-cluster":               hostedcluster.Setup,
+	"cert-rotation":                certrotation.Setup,
+	"cluster-status":               clusterstatus.Setup,
+	"tunnel-health":                tunnelhealth.Setup,
+}
+
+// managementControllers manage resources across the management cluster rather than
+// a single control plane, so they do not require a control plane namespace
+var managementControllers = map[string]bool{
+	"hosted-cluster": true,
 }
 
 type ControlPlaneOperator struct {
@@ -54,18 +82,43 @@ type ControlPlaneOperator struct {
 	// TargetKubeconfig is a kubeconfig to access the target cluster.
 	TargetKubeconfig string
 
+	// ManagementKubeconfig is a kubeconfig to access the management cluster. The --kubeconfig
+	// flag or the in-cluster config are used when it is not set.
+	ManagementKubeconfig string
+
 	// InitialCAFile is a file containing the initial contents of the Kube controller manager CA.
 	InitialCAFile string
 
 	// Controllers is the list of controllers that the operator should start
 	Controllers []string
 
+	// ControllersConfigMap is a configmap in the namespace that changes the controllers to
+	// run at runtime
+	ControllersConfigMap string
+
 	// ReleaseVersion is the OpenShift version for the release
 	ReleaseVersion string
 
 	// KubernetesVersion is the kubernetes version included in the release
 	KubernetesVersion string
 
+	// CertValidity is the validity of the certificates issued for the control plane
+	CertValidity time.Duration
+
+	// KubeletServingCASyncInterval is how often the kubelet serving CA bundle of the target
+	// cluster is compared with the CAs of the control plane
+	KubeletServingCASyncInterval time.Duration
+
+	// MetricsAddr is the address that the operator serves metrics on
+	MetricsAddr string
+
+	// LeaderElect enables leader election, which is required when the operator runs with
+	// several replicas
+	LeaderElect bool
+
+	// HealthAddr is the address that the operator serves its liveness and readiness checks on
+	HealthAddr string
+
 	initialCA []byte
 }
 
@@ -84,17 +137,32 @@ func newControlPlaneOperatorCommand() *cobra.Command {
 			return cpo.Run()
 		},
 	}
+	logging.AddPersistentFlags(cmd)
 	flags := cmd.Flags()
 	flags.AddGoFlagSet(flag.CommandLine)
-	flags.StringVar(&cpo.Namespace, "namespace", cpo.Namespace, "Namespace for control plane components on management cluster")
+	flags.StringVar(&cpo.Namespace, "namespace", cpo.Namespace, "Namespace for control plane components on management cluster. Not required by the hosted-cluster controller, which only uses it for leader election.")
 	flags.StringVar(&cpo.TargetKubeconfig, "target-kubeconfig", cpo.TargetKubeconfig, "Kubeconfig for target cluster")
+	flags.StringVar(&cpo.ManagementKubeconfig, "management-kubeconfig", cpo.ManagementKubeconfig, "Kubeconfig for the management cluster, which defaults to --kubeconfig or the in-cluster config")
 	flags.StringVar(&cpo.InitialCAFile, "initial-ca-file", cpo.InitialCAFile, "Path to controller manager initial CA file")
+	flags.DurationVar(&cpo.CertValidity, "cert-validity", cpo.CertValidity, "Validity of rotated control plane certificates")
+	flags.DurationVar(&cpo.KubeletServingCASyncInterval, "kubelet-serving-ca-sync-interval", cpo.KubeletServingCASyncInterval, "Interval between syncs of the kubelet serving CA bundle of the target cluster")
+	flags.StringVar(&cpo.MetricsAddr, "metrics-addr", cpo.MetricsAddr, "Address to serve metrics on, or 0 to disable metrics")
+	flags.BoolVar(&cpo.LeaderElect, "leader-elect", cpo.LeaderElect, "Elect a leader among the replicas of the operator before running controllers")
+	flags.StringVar(&cpo.HealthAddr, "health-addr", cpo.HealthAddr, "Address to serve the /healthz and /readyz checks on, or 0 to disable them")
 	flags.StringSliceVar(&cpo.Controllers, "controllers", cpo.Controllers, "Controllers to run with this operator")
+	flags.StringVar(&cpo.ControllersConfigMap, "controllers-configmap", cpo.ControllersConfigMap, "ConfigMap in the namespace whose controllers key changes the controllers to run at runtime, where * stands for the controllers of --controllers, name enables a controller and -name disables it")
+	cmd.AddCommand(ignition.NewIgnitionServerCommand())
+	cmd.AddCommand(frontend.NewAPIServerFrontendCommand())
 	return cmd
 }
 
 func newControlPlaneOperator() *ControlPlaneOperator {
 	return &ControlPlaneOperator{
+		CertValidity:                 util.ValidityOneYear,
+		KubeletServingCASyncInterval: kubelet_serving_ca.DefaultSyncInterval,
+		MetricsAddr:                  ":8080",
+		HealthAddr:                   ":8081",
+		LeaderElect:                  true,
 		Controllers: []string{
 			"controller-manager-ca",
 			"cluster-operator",
@@ -110,13 +178,34 @@ func (o *ControlPlaneOperator) Validate() error {
 	if len(o.Controllers) == 0 {
 		return fmt.Errorf("at least one controller is required")
 	}
-	if len(o.Namespace) == 0 {
-		return fmt.Errorf("the namespace for control plane components is required")
+	if o.KubeletServingCASyncInterval <= 0 {
+		return fmt.Errorf("the kubelet serving CA sync interval must be positive")
+	}
+	if len(o.ControllersConfigMap) > 0 && len(o.Namespace) == 0 {
+		return fmt.Errorf("the namespace is required by the controllers configmap")
+	}
+	for _, controller := range o.Controllers {
+		if len(o.Namespace) == 0 && !managementControllers[controller] {
+			return fmt.Errorf("the namespace for control plane components is required by controller %s", controller)
+		}
 	}
 	return nil
 }
 
 func (o *ControlPlaneOperator) Complete() error {
+	files := []struct{ flag, path string }{
+		{flag: "initial-ca-file", path: o.InitialCAFile},
+		{flag: "target-kubeconfig", path: o.TargetKubeconfig},
+		{flag: "management-kubeconfig", path: o.ManagementKubeconfig},
+	}
+	for _, file := range files {
+		if len(file.path) == 0 {
+			continue
+		}
+		if _, err := os.Stat(file.path); err != nil {
+			return fmt.Errorf("cannot read the --%s file: %v", file.flag, err)
+		}
+	}
 	var err error
 	if len(o.InitialCAFile) > 0 {
 		o.initialCA, err = ioutil.ReadFile(o.InitialCAFile)
@@ -142,10 +231,17 @@ func (o *ControlPlaneOperator) Run() error {
 	}
 	cfg := cpoperator.NewControlPlaneOperatorConfig(
 		o.TargetKubeconfig,
+		o.ManagementKubeconfig,
 		o.Namespace,
 		o.initialCA,
 		versions,
+		o.CertValidity,
+		o.KubeletServingCASyncInterval,
+		o.MetricsAddr,
+		o.HealthAddr,
+		o.LeaderElect,
 		o.Controllers,
+		o.ControllersConfigMap,
 		controllerFuncs,
 	)
 	return cfg.Start()
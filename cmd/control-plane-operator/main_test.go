@@ -0,0 +1,37 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cpo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "initial-ca.crt")
+	if err := ioutil.WriteFile(caFile, []byte("ca"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cpo := newControlPlaneOperator()
+	cpo.InitialCAFile = caFile
+	if err := cpo.Complete(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(cpo.initialCA) != "ca" {
+		t.Errorf("expected the initial CA to be read from --initial-ca-file, got %q", cpo.initialCA)
+	}
+
+	cpo = newControlPlaneOperator()
+	cpo.InitialCAFile = caFile
+	cpo.ManagementKubeconfig = filepath.Join(dir, "missing")
+	if err := cpo.Complete(); err == nil || !strings.Contains(err.Error(), "--management-kubeconfig") {
+		t.Errorf("expected an error about the missing management kubeconfig, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/openshift/hypershift-toolkit/pkg/cmd/config"
 	"github.com/openshift/hypershift-toolkit/pkg/cmd/ignition"
 	"github.com/openshift/hypershift-toolkit/pkg/cmd/pki"
 	"github.com/openshift/hypershift-toolkit/pkg/cmd/render"
@@ -13,6 +14,7 @@ func main() {
 	rootCmd.AddCommand(pki.NewPKICommand())
 	rootCmd.AddCommand(render.NewRenderManifestsCommand())
 	rootCmd.AddCommand(ignition.NewIgnitionCommand())
+	rootCmd.AddCommand(config.NewConfigCommand())
 	rootCmd.Execute()
 }
 
@@ -1,10 +1,22 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/openshift/hypershift-toolkit/contrib/pkg/aws"
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/config"
+	"github.com/openshift/hypershift-toolkit/pkg/logging"
 )
 
 func main() {
@@ -17,38 +29,158 @@ func newHypershiftAWSCommand() *cobra.Command {
 		Use:   "hypershift-aws",
 		Short: "An AWS implementation of the Hypershift pattern",
 	}
+	logging.AddPersistentFlags(cmd)
 	cmd.AddCommand(newInstallCommand())
 	cmd.AddCommand(newUninstallCommand())
+	cmd.AddCommand(newInstallFrontendCommand())
+	cmd.AddCommand(newUpgradeCommand())
+	cmd.AddCommand(newScaleCommand())
+	cmd.AddCommand(newHibernateCommand())
+	cmd.AddCommand(newResumeCommand())
+	cmd.AddCommand(newExportCommand())
+	cmd.AddCommand(newStatusCommand())
+	cmd.AddCommand(newDumpCommand())
+	cmd.AddCommand(newConsolePasswordCommand())
+	cmd.AddCommand(newAuditCommand())
 	return cmd
 }
 
 func newInstallCommand() *cobra.Command {
 	releaseImage := ""
 	dhParamsFile := ""
+	infraCredentialsFile := ""
 	waitForClusterReady := true
+	waitOptions := &waitFlags{}
+	highAvailability := false
+	private := false
+	ignitionBucket := false
+	clusterUser := false
+	skipPrivilegedSCC := false
+	skipCapacityCheck := false
+	dryRun := false
+	output := ""
+	workers := aws.WorkerConfig{Count: aws.DefaultWorkerCount}
+	network := aws.NetworkConfig{}
+	nodePoolsFile := ""
+	configFile := ""
+	batchFile := ""
+	parallelism := 0
+	stateDir := ""
+	kubeVirt := aws.KubeVirtConfig{}
+	awsCredentials := aws.CredentialsConfig{}
 	cmd := &cobra.Command{
-		Use:   "install NAME",
+		Use:   "install NAME | -f CLUSTERS_FILE",
 		Short: "Creates the necessary infrastructure and installs a hypershift instance on an existing OCP 4 cluster running on AWS",
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) != 1 {
+			if len(batchFile) > 0 {
+				if len(args) != 0 {
+					log.Fatalf("The clusters to install are specified by %s, a cluster name cannot be specified as well", batchFile)
+				}
+				if dryRun {
+					log.Fatalf("A dry run installs a single cluster and cannot be used with --filename")
+				}
+				if len(output) > 0 {
+					log.Fatalf("The result of an install is written for a single cluster and --output cannot be used with --filename")
+				}
+			} else if len(args) != 1 || len(args[0]) == 0 {
 				log.Fatalf("You must specify the name of the cluster you want to install")
 			}
+			validateOutput(output)
+			waitConfig := waitOptions.waitConfig()
+			if len(nodePoolsFile) > 0 {
+				pools, err := installer.ReadNodePools(nodePoolsFile)
+				if err != nil {
+					log.Fatalf("Cannot read node pools: %v", err)
+				}
+				workers.NodePools = pools
+			}
+			if len(kubeVirt.Image) > 0 {
+				workers.KubeVirt = &kubeVirt
+			}
+			if len(batchFile) > 0 {
+				batch, err := aws.ReadBatch(batchFile)
+				if err != nil {
+					log.Fatalf("Cannot read clusters: %v", err)
+				}
+				if err = aws.InstallClusters(batch, parallelism, os.Stdout, releaseImage, dhParamsFile, infraCredentialsFile, stateDir, configFile, awsCredentials, workers, network, waitForClusterReady, waitConfig, highAvailability, private, ignitionBucket, clusterUser, skipPrivilegedSCC, skipCapacityCheck); err != nil {
+					log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to install clusters")
+					os.Exit(installerrors.ExitCode(err))
+				}
+				return
+			}
 			name := args[0]
-			if len(name) == 0 {
-				log.Fatalf("You must specify the name of the cluster you want to install")
+			var clusterConfig *api.ClusterParams
+			if len(configFile) > 0 {
+				var err error
+				if clusterConfig, err = config.ReadFrom(configFile); err != nil {
+					log.Fatalf("Cannot read cluster configuration: %v", err)
+				}
 			}
-			if err := aws.InstallCluster(name, releaseImage, dhParamsFile, waitForClusterReady); err != nil {
-				log.WithError(err).Fatalf("Failed to install cluster")
+			var result *installer.Result
+			var diff bytes.Buffer
+			out := io.Writer(os.Stdout)
+			if output == outputJSON {
+				result = installer.NewResult("install", name)
+				out = &diff
+			}
+			err := aws.InstallCluster(name, releaseImage, dhParamsFile, infraCredentialsFile, stateDir, clusterConfig, awsCredentials, workers, network, waitForClusterReady, waitConfig, highAvailability, private, ignitionBucket, clusterUser, skipPrivilegedSCC, skipCapacityCheck, dryRun, out, result)
+			if result != nil {
+				result.Diff = diff.String()
+				writeResult(result, err)
+			}
+			if err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to install cluster")
+				os.Exit(installerrors.ExitCode(err))
 			}
 		},
 	}
 	cmd.Flags().StringVar(&releaseImage, "release-image", "", "[optional] Specify the release image to use for the new cluster. Defaults to same as parent cluster.")
 	cmd.Flags().StringVar(&dhParamsFile, "dh-params", "", "[optional][dev-only] Specifies an existing file with DH params for the VPN so it doesn't get re-generated.")
+	cmd.Flags().StringVar(&infraCredentialsFile, "infra-credentials-file", "", "[optional] Specifies an AWS shared credentials file with credentials scoped to the cluster's resources. When set, the control plane operator uses them to verify and repair the cluster's AWS infrastructure.")
 	cmd.Flags().BoolVar(&waitForClusterReady, "wait-for-cluster-ready", waitForClusterReady, "Waits for cluster to be available before command ends, fails with an error if cluster does not come up within a given amount of time.")
+	addWaitFlags(cmd, waitOptions)
+	cmd.Flags().BoolVar(&highAvailability, "ha", highAvailability, "[optional] Runs 3 replicas of each control plane component, spread across the zones of the management cluster workers.")
+	cmd.Flags().BoolVar(&private, "private", private, "[optional] Creates internal load balancers and registers DNS records in a private zone, so that the cluster is only reachable from within the VPC of the management cluster.")
+	cmd.Flags().BoolVar(&ignitionBucket, "ignition-bucket", ignitionBucket, "[optional] Serves the worker ignition config from a private S3 bucket through pre-signed URLs instead of an ignition server in the control plane namespace. Requires --infra-credentials-file.")
+	cmd.Flags().BoolVar(&clusterUser, "cluster-iam-user", clusterUser, "[optional] Creates an IAM user for the new cluster, with a policy limited to its volumes and an S3 bucket for its image registry, whose credentials the cloud provider, CSI driver and image registry of the cluster use.")
+	cmd.Flags().BoolVar(&skipPrivilegedSCC, "skip-privileged-scc", skipPrivilegedSCC, "[optional] Do not allow the VPN service accounts of the new cluster to use the privileged SCC. They must be allowed to use it otherwise.")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", dryRun, "[optional] Renders the manifests of the new cluster and prints a diff of the changes that applying them would make to the existing cluster, validated by a server-side dry run, without creating anything on the existing cluster or AWS. The install state is not changed.")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "[optional] Writes the result of the install to standard output in this format instead of only logging it. The only format is json, a document with the API and console URLs, the secrets with the admin credentials, the IDs of the AWS resources and the timings of the install, and the diff of a dry run.")
+	cmd.Flags().BoolVar(&skipCapacityCheck, "skip-capacity-check", skipCapacityCheck, "[optional] Do not verify that the schedulable nodes of the existing cluster have the free CPU and memory that the control plane of the new cluster requests with its sizing profile.")
+	cmd.Flags().StringVar(&network.VPC, "vpc-id", "", "[optional] Specify an existing VPC for the load balancers of the new cluster. Requires --subnet-ids. Defaults to the VPC of the management cluster.")
+	cmd.Flags().StringSliceVar(&network.Subnets, "subnet-ids", nil, "[optional] Specify the subnets of the load balancers of the new cluster, in the VPC given by --vpc-id. Only subnets in zones with management cluster workers are used.")
+	cmd.Flags().StringVar(&network.SecurityGroup, "security-group-id", "", "[optional] Specify the security group of the management cluster workers that allows access to node ports from the load balancers. Defaults to the workers security group of the management cluster.")
+	cmd.Flags().BoolVar(&network.PrivateZone, "private-zone", false, "[optional] Registers the DNS records of the new cluster in a private zone associated with the VPC in addition to the public zone. Implied by --private.")
+	cmd.Flags().BoolVar(&network.ExternalDNS, "external-dns", false, "[optional] Creates an ExternalName service annotated for external-dns in the cluster namespace for each DNS record of the new cluster instead of registering the records in Route53. Cannot be used with --private or --private-zone.")
+	cmd.Flags().BoolVar(&network.ServiceLoadBalancers, "service-load-balancers", false, "[optional] Changes the API, OAuth and ignition server services of the new cluster, and the router service of KubeVirt workers, to LoadBalancer services whose load balancers are provisioned by the existing cluster instead of the installer. Cannot be used with --private or --vpc-id.")
+	cmd.Flags().BoolVar(&network.Routes, "routes", false, "[optional] Exposes the API, OAuth and ignition server of the new cluster through passthrough routes of the existing cluster instead of load balancers. Cannot be used with --private or --service-load-balancers.")
+	cmd.Flags().BoolVar(&network.SharedIngress, "shared-ingress", false, "[optional] Exposes the API, OAuth and ignition server of the new cluster through the API server frontend of the existing cluster, which is shared by all clusters and installed with install-frontend, instead of load balancers of the new cluster. Cannot be used with --private, --routes or --service-load-balancers.")
+	cmd.Flags().IntVar(&network.RouterHTTPNodePort, "router-http-node-port", 0, "[optional] HTTP node port of the router on the new workers, which the router load balancer targets. Free node ports of the existing cluster are allocated unless both router node ports are specified.")
+	cmd.Flags().IntVar(&network.RouterHTTPSNodePort, "router-https-node-port", 0, "[optional] HTTPS node port of the router on the new workers.")
+	cmd.Flags().StringVar(&network.NetworkType, "network-type", "", "[optional] Specify the network plugin of the new cluster, OpenShiftSDN, OVNKubernetes or None for a third-party plugin installed with the userManifestsDir of --config. Defaults to the network type of --config, or else OpenShiftSDN.")
+	cmd.Flags().BoolVar(&network.DirectRouting, "direct-routing", false, "[optional] The API server of the new cluster reaches the kubelets, pods and services of its workers through the network of the existing cluster instead of an OpenVPN tunnel. The nodes and pods of the new cluster must be routable from the existing cluster.")
+	cmd.Flags().IntVar(&workers.Count, "workers", workers.Count, "[optional] Specify the number of worker nodes of the new cluster.")
+	cmd.Flags().StringVar(&workers.InstanceType, "instance-type", "", "[optional] Specify the EC2 instance type of the worker nodes. Defaults to the instance type of the management cluster workers.")
+	cmd.Flags().IntVar(&workers.RootVolumeSize, "root-volume-size", 0, "[optional] Specify the size in GiB of the root volume of the worker nodes. Defaults to the root volume size of the management cluster workers.")
+	cmd.Flags().StringVar(&workers.Arch, "arch", "", "[optional] Specify the architecture of the worker nodes (amd64, arm64, ppc64le or s390x), which selects the images of multi-arch release images. Defaults to amd64.")
+	cmd.Flags().StringVar(&workers.AMI, "worker-ami", "", "[optional] Specify the AMI of the worker nodes. Defaults to the AMI of the release in the region of the management cluster, found in the boot image metadata of the release image or --rhcos-stream-url, or else the AMI of the management cluster workers.")
+	cmd.Flags().StringVar(&workers.CoreOSStreamURL, "rhcos-stream-url", "", "[optional] URL of RHCOS stream metadata (or the rhcos.json of the installer) to find the AMI of the worker nodes in, for releases whose image has no boot image metadata.")
+	cmd.Flags().StringVar(&stateDir, "state-dir", "", "[optional] Specifies the directory that keeps the PKI, manifests and progress of the install, so that a failed install is resumed when it is run again. Defaults to ~/.hypershift/aws/NAME. With --filename, the state of each cluster is kept in a directory named after it in this directory.")
+	cmd.Flags().StringVarP(&batchFile, "filename", "f", "", "[optional] Specifies a clusters.yaml file with the names and cluster.yaml files of several clusters to install concurrently with the other flags. A summary of the installs is printed once all of them have finished.")
+	cmd.Flags().IntVar(&parallelism, "parallelism", 0, fmt.Sprintf("[optional] Specifies the number of clusters of --filename that are installed at the same time. Defaults to the parallelism of the file, or %d.", aws.DefaultBatchParallelism))
+	cmd.Flags().StringVar(&configFile, "config", "", "[optional] Specifies a cluster.yaml file with the parameters of the new cluster, such as the one written by the export command. The release image, architecture and node pools of the file are used unless set by flags, and the names, addresses, CIDRs and node ports of the cluster are discovered or allocated by the installer.")
+	cmd.Flags().StringVar(&nodePoolsFile, "node-pools-file", "", "[optional] Specifies a YAML file with a list of named worker node pools. Each pool gets its own machineset; --workers is ignored and --instance-type is the default instance type of the pools.")
+	cmd.Flags().StringVar(&kubeVirt.Image, "kubevirt-image", "", "[optional] Runs the worker nodes as KubeVirt virtual machines in the control plane namespace, booted from this container disk image of RHCOS. Requires KubeVirt on the management cluster.")
+	cmd.Flags().IntVar(&kubeVirt.Cores, "kubevirt-cores", 0, "[optional] Specify the CPU cores of the KubeVirt worker nodes. Defaults to 4.")
+	cmd.Flags().StringVar(&kubeVirt.Memory, "kubevirt-memory", "", "[optional] Specify the memory of the KubeVirt worker nodes. Defaults to 16Gi.")
+	addCredentialsFlags(cmd, &awsCredentials)
 	return cmd
 }
 
 func newUninstallCommand() *cobra.Command {
+	dryRun := false
+	output := ""
+	awsCredentials := aws.CredentialsConfig{}
 	cmd := &cobra.Command{
 		Use:   "uninstall NAME",
 		Short: "Removes artifacts from an existing hypershift instance on an AWS cluster",
@@ -56,12 +188,303 @@ func newUninstallCommand() *cobra.Command {
 			if len(args) != 1 || len(args[0]) == 0 {
 				log.Fatalf("You must specify the name of the cluster you want to uninstall")
 			}
+			validateOutput(output)
+			name := args[0]
+			var result *installer.Result
+			out := io.Writer(os.Stdout)
+			if output == outputJSON {
+				result = installer.NewResult("uninstall", name)
+				result.DryRun = dryRun
+				out = ioutil.Discard
+			}
+			err := aws.UninstallCluster(name, awsCredentials, dryRun, out, result)
+			if result != nil {
+				writeResult(result, err)
+			}
+			if err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to uninstall cluster")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", dryRun, "List the AWS and management cluster resources of the cluster that would be removed without removing them")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "[optional] Writes the result of the uninstall to standard output in this format instead of only logging it. The only format is json, a document with the removed resources, or the resources that a dry run would remove, and the duration of the uninstall.")
+	addCredentialsFlags(cmd, &awsCredentials)
+	return cmd
+
+}
+
+// outputJSON is the format of --output that writes the result of a command as JSON
+const outputJSON = "json"
+
+func validateOutput(output string) {
+	if len(output) > 0 && output != outputJSON {
+		log.Fatalf("Unsupported output format %q, the only format is %s", output, outputJSON)
+	}
+	if output == outputJSON {
+		// Standard output is reserved for the result
+		installer.ApplyOutput = os.Stderr
+	}
+}
+
+// writeResult writes the result of a command, finished with err, to standard output
+func writeResult(result *installer.Result, err error) {
+	result.Finish(err)
+	if err := result.Write(os.Stdout); err != nil {
+		log.Fatalf("Cannot write result: %v", err)
+	}
+}
+
+func newInstallFrontendCommand() *cobra.Command {
+	image := ""
+	replicas := 0
+	cmd := &cobra.Command{
+		Use:   "install-frontend",
+		Short: "Installs the API server frontend that clusters installed with --shared-ingress share on an existing OCP 4 cluster running on AWS",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := aws.InstallAPIServerFrontend(image, replicas); err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to install the API server frontend")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	cmd.Flags().StringVar(&image, "image", "", "[optional] Specify the control plane operator image that runs the frontend. Defaults to the control plane operator image of new clusters.")
+	cmd.Flags().IntVar(&replicas, "replicas", 0, "[optional] Specify the number of replicas of the frontend. Defaults to 2.")
+	return cmd
+}
+
+func newUpgradeCommand() *cobra.Command {
+	releaseImage := ""
+	waitForClusterReady := true
+	waitOptions := &waitFlags{}
+	cmd := &cobra.Command{
+		Use:   "upgrade NAME",
+		Short: "Upgrades the control plane of an existing hypershift instance on an AWS cluster to a new release",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 || len(args[0]) == 0 {
+				log.Fatalf("You must specify the name of the cluster you want to upgrade")
+			}
+			name := args[0]
+			if err := aws.UpgradeCluster(name, releaseImage, waitForClusterReady, waitOptions.waitConfig()); err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to upgrade cluster")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	cmd.Flags().StringVar(&releaseImage, "release-image", "", "Specify the release image to upgrade the cluster to.")
+	cmd.Flags().BoolVar(&waitForClusterReady, "wait-for-cluster-ready", waitForClusterReady, "Waits for the control plane to roll out and cluster operators to be available before command ends, fails with an error if they are not within a given amount of time.")
+	addWaitFlags(cmd, waitOptions)
+	return cmd
+}
+
+func newScaleCommand() *cobra.Command {
+	replicas := -1
+	nodePool := ""
+	waitForNodesReady := true
+	waitOptions := &waitFlags{}
+	cmd := &cobra.Command{
+		Use:   "scale NAME",
+		Short: "Sets the number of worker nodes of an existing hypershift instance on an AWS cluster",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 || len(args[0]) == 0 {
+				log.Fatalf("You must specify the name of the cluster you want to scale")
+			}
+			if replicas < 0 {
+				log.Fatalf("You must specify the number of worker nodes with --replicas")
+			}
+			name := args[0]
+			if err := aws.ScaleCluster(name, nodePool, replicas, waitForNodesReady, waitOptions.waitConfig()); err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to scale cluster")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	cmd.Flags().IntVar(&replicas, "replicas", replicas, "Specify the number of worker nodes of the cluster, spread across its worker machinesets.")
+	cmd.Flags().StringVar(&nodePool, "node-pool", "", "[optional] Specify the node pool to scale. The replicas are spread across the machinesets of the pool. Defaults to all worker machinesets of the cluster.")
+	cmd.Flags().BoolVar(&waitForNodesReady, "wait-for-nodes-ready", waitForNodesReady, "Waits for the worker nodes to be ready before command ends, fails with an error if they are not within a given amount of time.")
+	addWaitFlags(cmd, waitOptions)
+	return cmd
+}
+
+func newHibernateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hibernate NAME",
+		Short: "Scales the control plane and worker nodes of an existing hypershift instance on an AWS cluster to zero, keeping its etcd volumes",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 || len(args[0]) == 0 {
+				log.Fatalf("You must specify the name of the cluster you want to hibernate")
+			}
+			if err := aws.HibernateCluster(args[0]); err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to hibernate cluster")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	return cmd
+}
+
+func newResumeCommand() *cobra.Command {
+	waitForNodesReady := true
+	waitOptions := &waitFlags{}
+	cmd := &cobra.Command{
+		Use:   "resume NAME",
+		Short: "Scales the control plane and worker nodes of a hibernated hypershift instance on an AWS cluster back up",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 || len(args[0]) == 0 {
+				log.Fatalf("You must specify the name of the cluster you want to resume")
+			}
+			if err := aws.ResumeCluster(args[0], waitForNodesReady, waitOptions.waitConfig()); err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to resume cluster")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&waitForNodesReady, "wait-for-nodes-ready", waitForNodesReady, "Waits for the worker nodes to be ready before command ends, fails with an error if they are not within a given amount of time.")
+	addWaitFlags(cmd, waitOptions)
+	return cmd
+}
+
+func newExportCommand() *cobra.Command {
+	outputFile := ""
+	cmd := &cobra.Command{
+		Use:   "export NAME",
+		Short: "Writes the parameters and node pools of an existing hypershift instance on an AWS cluster as a cluster.yaml template for new clusters",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 || len(args[0]) == 0 {
+				log.Fatalf("You must specify the name of the cluster you want to export")
+			}
+			out := os.Stdout
+			if len(outputFile) > 0 {
+				f, err := os.Create(outputFile)
+				if err != nil {
+					log.Fatalf("Cannot create %s: %v", outputFile, err)
+				}
+				defer f.Close()
+				out = f
+			}
+			if err := aws.ExportCluster(args[0], out); err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to export cluster")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	cmd.Flags().StringVar(&outputFile, "output", "", "[optional] Specifies the file to write the cluster.yaml to. Defaults to standard output.")
+	return cmd
+}
+
+func newStatusCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status NAME",
+		Short: "Shows the status of the control plane, nodes, cluster operators and certificates of an existing hypershift instance on an AWS cluster",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 || len(args[0]) == 0 {
+				log.Fatalf("You must specify the name of the cluster")
+			}
+			if err := aws.ClusterStatus(args[0], os.Stdout); err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to get cluster status")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	return cmd
+}
+
+func newDumpCommand() *cobra.Command {
+	outputFile := ""
+	cmd := &cobra.Command{
+		Use:   "dump NAME",
+		Short: "Collects the control plane objects, logs and events, the parameters and the cluster operators of an existing hypershift instance on an AWS cluster into an archive for support cases",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 || len(args[0]) == 0 {
+				log.Fatalf("You must specify the name of the cluster you want to dump")
+			}
 			name := args[0]
-			if err := aws.UninstallCluster(name); err != nil {
-				log.WithError(err).Fatalf("Failed to uninstall cluster")
+			fileName := outputFile
+			if len(fileName) == 0 {
+				fileName = fmt.Sprintf("%s-dump-%s.tar.gz", name, time.Now().UTC().Format("20060102-150405"))
+			}
+			f, err := os.Create(fileName)
+			if err != nil {
+				log.Fatalf("Cannot create %s: %v", fileName, err)
 			}
+			defer f.Close()
+			if err = aws.DumpCluster(name, f); err != nil {
+				f.Close()
+				os.Remove(fileName)
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to dump cluster")
+				os.Exit(installerrors.ExitCode(err))
+			}
+			log.Infof("Diagnostics of cluster %s written to %s", name, fileName)
 		},
 	}
+	cmd.Flags().StringVar(&outputFile, "output", "", "[optional] Specifies the file to write the archive to. Defaults to NAME-dump-TIMESTAMP.tar.gz in the current directory.")
 	return cmd
+}
+
+func newConsolePasswordCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "console-password NAME",
+		Short: "Prints the console URL and kubeadmin password of an existing hypershift instance on an AWS cluster",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 || len(args[0]) == 0 {
+				log.Fatalf("You must specify the name of the cluster")
+			}
+			if err := aws.ConsolePassword(args[0], os.Stdout); err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to get console password")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	return cmd
+}
+
+func newAuditCommand() *cobra.Command {
+	deleteOrphans := false
+	awsCredentials := aws.CredentialsConfig{}
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Lists the AWS resources of hypershift instances that no longer exist on the AWS cluster, and the load balancers of existing instances that no longer exist in AWS",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := aws.AuditClusters(awsCredentials, deleteOrphans, os.Stdout); err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to audit clusters")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&deleteOrphans, "delete", deleteOrphans, "Remove the AWS resources of hypershift instances that no longer exist")
+	addCredentialsFlags(cmd, &awsCredentials)
+	return cmd
+}
+
+// addCredentialsFlags adds the flags that select the AWS credentials of a command
+// waitFlags are the flags that configure how long a command waits for a cluster
+type waitFlags struct {
+	config        installer.WaitConfig
+	phaseTimeouts map[string]string
+}
+
+func addWaitFlags(cmd *cobra.Command, flags *waitFlags) {
+	cmd.Flags().DurationVar(&flags.config.Timeout, "wait-timeout", 0, "[optional] Specify how long to wait for each phase of the wait for the cluster, such as 30m. Defaults to 5 to 15 minutes depending on the phase.")
+	cmd.Flags().StringToStringVar(&flags.phaseTimeouts, "phase-timeout", nil, "[optional] Specify how long to wait for a phase of the wait for the cluster, overriding --wait-timeout, such as operators=45m. The phases are api, bootstrap, nodes, operators and rollout.")
+	cmd.Flags().DurationVar(&flags.config.Interval, "wait-interval", 0, "[optional] Specify how often to check the cluster while waiting for it. Defaults to 10s.")
+}
+
+// waitConfig returns the wait configuration of the flags, and exits if it is invalid
+func (f *waitFlags) waitConfig() installer.WaitConfig {
+	phaseTimeouts, err := installer.ParsePhaseTimeouts(f.phaseTimeouts)
+	if err != nil {
+		log.Fatalf("Invalid --phase-timeout: %v", err)
+	}
+	config := f.config
+	config.PhaseTimeouts = phaseTimeouts
+	if err = config.Validate(); err != nil {
+		log.Fatalf("Invalid wait configuration: %v", err)
+	}
+	return config
+}
 
+func addCredentialsFlags(cmd *cobra.Command, config *aws.CredentialsConfig) {
+	cmd.Flags().StringVar(&config.RoleARN, "role-arn", "", "[optional] Specify an IAM role that is assumed through STS with the credentials of the environment or instance profile, instead of using the static keys of the kube-system/aws-creds secret.")
+	cmd.Flags().StringVar(&config.ExternalID, "external-id", "", "[optional] Specify the external ID required to assume the role given by --role-arn.")
+	cmd.Flags().StringVar(&config.WebIdentityTokenFile, "web-identity-token-file", "", "[optional] Specify a file with an OIDC token, such as a projected service account token, that the role given by --role-arn is assumed with.")
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/gcp"
+	"github.com/openshift/hypershift-toolkit/pkg/logging"
+)
+
+func main() {
+	rootCmd := newHypershiftGCPCommand()
+	rootCmd.Execute()
+}
+
+func newHypershiftGCPCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hypershift-gcp",
+		Short: "A GCP implementation of the Hypershift pattern",
+	}
+	logging.AddPersistentFlags(cmd)
+	cmd.AddCommand(newInstallCommand())
+	cmd.AddCommand(newUninstallCommand())
+	return cmd
+}
+
+func newInstallCommand() *cobra.Command {
+	releaseImage := ""
+	dhParamsFile := ""
+	waitForClusterReady := true
+	skipPrivilegedSCC := false
+	cmd := &cobra.Command{
+		Use:   "install NAME",
+		Short: "Creates the necessary infrastructure and installs a hypershift instance on an existing OCP 4 cluster running on GCP",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				log.Fatalf("You must specify the name of the cluster you want to install")
+			}
+			name := args[0]
+			if len(name) == 0 {
+				log.Fatalf("You must specify the name of the cluster you want to install")
+			}
+			if err := gcp.InstallCluster(name, releaseImage, dhParamsFile, waitForClusterReady, skipPrivilegedSCC); err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to install cluster")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	cmd.Flags().StringVar(&releaseImage, "release-image", "", "[optional] Specify the release image to use for the new cluster. Defaults to same as parent cluster.")
+	cmd.Flags().StringVar(&dhParamsFile, "dh-params", "", "[optional][dev-only] Specifies an existing file with DH params for the VPN so it doesn't get re-generated.")
+	cmd.Flags().BoolVar(&waitForClusterReady, "wait-for-cluster-ready", waitForClusterReady, "Waits for cluster to be available before command ends, fails with an error if cluster does not come up within a given amount of time.")
+	cmd.Flags().BoolVar(&skipPrivilegedSCC, "skip-privileged-scc", skipPrivilegedSCC, "[optional] Do not allow the VPN service accounts of the new cluster to use the privileged SCC. They must be allowed to use it otherwise.")
+	return cmd
+}
+
+func newUninstallCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "uninstall NAME",
+		Short: "Removes artifacts from an existing hypershift instance on a GCP cluster",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 || len(args[0]) == 0 {
+				log.Fatalf("You must specify the name of the cluster you want to uninstall")
+			}
+			name := args[0]
+			if err := gcp.UninstallCluster(name); err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to uninstall cluster")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	return cmd
+
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/ibmcloud"
+	"github.com/openshift/hypershift-toolkit/pkg/logging"
+)
+
+func main() {
+	rootCmd := newHypershiftIBMCloudCommand()
+	rootCmd.Execute()
+}
+
+func newHypershiftIBMCloudCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hypershift-ibmcloud",
+		Short: "An IBM Cloud implementation of the Hypershift pattern",
+	}
+	logging.AddPersistentFlags(cmd)
+	cmd.AddCommand(newInstallCommand())
+	cmd.AddCommand(newUninstallCommand())
+	return cmd
+}
+
+func newInstallCommand() *cobra.Command {
+	releaseImage := ""
+	dhParamsFile := ""
+	waitForClusterReady := true
+	config := &ibmcloud.Config{WorkerCount: 2}
+	cmd := &cobra.Command{
+		Use:   "install NAME",
+		Short: "Creates the necessary infrastructure and installs a hypershift instance on an existing OCP 4 cluster running on IBM Cloud",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				log.Fatalf("You must specify the name of the cluster you want to install")
+			}
+			name := args[0]
+			if len(name) == 0 {
+				log.Fatalf("You must specify the name of the cluster you want to install")
+			}
+			if err := ibmcloud.InstallCluster(name, releaseImage, dhParamsFile, config, waitForClusterReady); err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to install cluster")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	cmd.Flags().StringVar(&releaseImage, "release-image", "", "[optional] Specify the release image to use for the new cluster. Defaults to same as parent cluster.")
+	cmd.Flags().StringVar(&dhParamsFile, "dh-params", "", "[optional][dev-only] Specifies an existing file with DH params for the VPN so it doesn't get re-generated.")
+	cmd.Flags().BoolVar(&waitForClusterReady, "wait-for-cluster-ready", waitForClusterReady, "Waits for cluster to be available before command ends, fails with an error if cluster does not come up within a given amount of time.")
+	cmd.Flags().BoolVar(&config.SkipPrivilegedSCC, "skip-privileged-scc", false, "[optional] Do not allow the VPN service accounts of the new cluster to use the privileged SCC. They must be allowed to use it otherwise.")
+	cmd.Flags().StringVar(&config.SubnetID, "subnet", "", "ID of the VPC subnet of the load balancers and workers of the new cluster.")
+	cmd.Flags().StringVar(&config.SecurityGroupID, "security-group", "", "[optional] ID of a security group of the existing workers that is opened to node ports and assigned to the new workers. Defaults to the default security group of the VPC for the new workers.")
+	cmd.Flags().StringVar(&config.DNSInstanceID, "dns-instance", "", "ID of the DNS Services instance of the zone of the new cluster's records.")
+	cmd.Flags().StringVar(&config.DNSZoneID, "dns-zone", "", "ID of the DNS Services zone of the new cluster's records. The cluster's domain is NAME.<zone name>.")
+	cmd.Flags().StringVar(&config.WorkerImageID, "worker-image", "", "ID of the RHCOS VPC image of the new workers.")
+	cmd.Flags().StringVar(&config.WorkerProfile, "worker-profile", "", "[optional] Instance profile of the new workers. Defaults to bx2-4x16.")
+	cmd.Flags().IntVar(&config.WorkerCount, "worker-count", config.WorkerCount, "Number of workers of the new cluster.")
+	cmd.Flags().StringVar(&config.SSHKeyID, "ssh-key", "", "[optional] ID of a VPC SSH key added to the new workers.")
+	cmd.Flags().IntVar(&config.RouterHTTPNodePort, "router-http-node-port", 0, "[optional] HTTP node port of the router on the new workers. Free node ports of the existing cluster are allocated unless both router node ports are specified.")
+	cmd.Flags().IntVar(&config.RouterHTTPSNodePort, "router-https-node-port", 0, "[optional] HTTPS node port of the router on the new workers.")
+	return cmd
+}
+
+func newUninstallCommand() *cobra.Command {
+	dnsInstanceID := ""
+	dnsZoneID := ""
+	cmd := &cobra.Command{
+		Use:   "uninstall NAME",
+		Short: "Removes artifacts from an existing hypershift instance on an IBM Cloud cluster",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 || len(args[0]) == 0 {
+				log.Fatalf("You must specify the name of the cluster you want to uninstall")
+			}
+			name := args[0]
+			if err := ibmcloud.UninstallCluster(name, dnsInstanceID, dnsZoneID); err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to uninstall cluster")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	cmd.Flags().StringVar(&dnsInstanceID, "dns-instance", "", "ID of the DNS Services instance given when installing the cluster.")
+	cmd.Flags().StringVar(&dnsZoneID, "dns-zone", "", "ID of the DNS Services zone given when installing the cluster.")
+	return cmd
+}
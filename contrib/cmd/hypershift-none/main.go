@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/none"
+	"github.com/openshift/hypershift-toolkit/pkg/logging"
+)
+
+func main() {
+	rootCmd := newHypershiftNoneCommand()
+	rootCmd.Execute()
+}
+
+func newHypershiftNoneCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hypershift-none",
+		Short: "An implementation of the Hypershift pattern that does not use any cloud provider",
+	}
+	logging.AddPersistentFlags(cmd)
+	cmd.AddCommand(newInstallCommand())
+	cmd.AddCommand(newUninstallCommand())
+	return cmd
+}
+
+func newInstallCommand() *cobra.Command {
+	releaseImage := ""
+	dhParamsFile := ""
+	waitForClusterReady := true
+	config := &none.Config{NodePortBase: 30600, WorkerCount: 2}
+	cmd := &cobra.Command{
+		Use:   "install NAME",
+		Short: "Installs a hypershift instance on an existing OCP 4 cluster and prints the DNS records to create",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				log.Fatalf("You must specify the name of the cluster you want to install")
+			}
+			name := args[0]
+			if len(name) == 0 {
+				log.Fatalf("You must specify the name of the cluster you want to install")
+			}
+			if len(config.OutputDir) == 0 {
+				config.OutputDir = name
+			}
+			if err := none.InstallCluster(name, releaseImage, dhParamsFile, config, waitForClusterReady, os.Stdout); err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to install cluster")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	cmd.Flags().StringVar(&releaseImage, "release-image", "", "[optional] Specify the release image to use for the new cluster. Defaults to same as parent cluster.")
+	cmd.Flags().StringVar(&dhParamsFile, "dh-params", "", "[optional][dev-only] Specifies an existing file with DH params for the VPN so it doesn't get re-generated.")
+	cmd.Flags().BoolVar(&waitForClusterReady, "wait-for-cluster-ready", waitForClusterReady, "Waits for cluster to be available before command ends, fails with an error if cluster does not come up within a given amount of time.")
+	cmd.Flags().BoolVar(&config.SkipPrivilegedSCC, "skip-privileged-scc", false, "[optional] Do not allow the VPN service accounts of the new cluster to use the privileged SCC. They must be allowed to use it otherwise.")
+	cmd.Flags().StringVar(&config.BaseDomain, "base-domain", "", "Parent domain of the new cluster's records. The cluster's domain is NAME.<base domain>.")
+	cmd.Flags().StringSliceVar(&config.Addresses, "address", nil, "[optional] Address of a management cluster node that the API and VPN records resolve to. Can be repeated. Defaults to the internal IPs of the existing workers.")
+	cmd.Flags().IntVar(&config.NodePortBase, "node-port-base", config.NodePortBase, "First of the four consecutive node ports of the API, OAuth, ignition and VPN services of the new cluster. Must not be used by other clusters.")
+	cmd.Flags().IntVar(&config.WorkerCount, "worker-count", config.WorkerCount, "Number of workers of the new cluster to wait for.")
+	cmd.Flags().StringVar(&config.OutputDir, "output-dir", "", "[optional] Directory that the PKI, manifests and worker ignition config are written to. Defaults to ./NAME.")
+	return cmd
+}
+
+func newUninstallCommand() *cobra.Command {
+	baseDomain := ""
+	cmd := &cobra.Command{
+		Use:   "uninstall NAME",
+		Short: "Removes artifacts from an existing hypershift instance and prints the DNS records to remove",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 || len(args[0]) == 0 {
+				log.Fatalf("You must specify the name of the cluster you want to uninstall")
+			}
+			name := args[0]
+			if err := none.UninstallCluster(name, baseDomain, os.Stdout); err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Error("Failed to uninstall cluster")
+				os.Exit(installerrors.ExitCode(err))
+			}
+		},
+	}
+	cmd.Flags().StringVar(&baseDomain, "base-domain", "", "Base domain given when installing the cluster.")
+	return cmd
+}
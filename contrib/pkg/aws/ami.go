@@ -0,0 +1,41 @@
+package aws
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/release"
+)
+
+// workerAMI returns the AMI of the boot image of the workers of a release in a region. The
+// boot images are read from the CoreOS stream metadata of the release image, or fetched from
+// streamURL for releases without it. When neither has an AMI, amd64 workers use the AMI of
+// the management cluster's workers, which is returned as nothing.
+func workerAMI(releaseImage, arch, originReleasePrefix, pullSecretFile string, imageContentSources []api.ImageContentSource, streamURL, region string) (string, error) {
+	if len(arch) == 0 {
+		arch = release.DefaultArch
+	}
+	info, err := release.GetReleaseInfo(releaseImage, arch, originReleasePrefix, pullSecretFile, imageContentSources)
+	if err != nil {
+		return "", installerrors.Render(err, "cannot read release image %s", releaseImage)
+	}
+	stream := info.BootImages
+	if len(stream) == 0 && len(streamURL) > 0 {
+		if stream, err = release.GetCoreOSStream(streamURL); err != nil {
+			return "", installerrors.Precondition(err, "cannot fetch CoreOS stream metadata")
+		}
+	}
+	if len(stream) > 0 {
+		ami, err := release.AWSBootImage(stream, arch, region)
+		if err == nil {
+			return ami, nil
+		}
+		log.WithError(err).Warn("Cannot find the AMI of the workers in CoreOS stream metadata")
+	}
+	if arch != release.DefaultArch {
+		return "", installerrors.Precondition(nil, "cannot find the %s AMI of the workers in region %s, specify it with --worker-ami", arch, region)
+	}
+	log.Info("Using the AMI of the management cluster workers")
+	return "", nil
+}
@@ -0,0 +1,218 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	log "github.com/sirupsen/logrus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/awsinfra"
+)
+
+// AuditClusters writes a table to out of the AWS resources tagged for hosted clusters whose
+// namespaces no longer exist on the management cluster, and of the load balancers of hosted
+// clusters on the management cluster that no longer exist in AWS. With deleteOrphans, the
+// resources of the clusters whose namespaces no longer exist are removed. The installer's
+// AWS credentials are selected by awsCredentials.
+func AuditClusters(awsCredentials CredentialsConfig, deleteOrphans bool, out io.Writer) error {
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
+	}
+	infraName, region, err := getInfrastructureInfo(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain infrastructure info for cluster")
+	}
+	dnsZoneID, parentDomain, err := installer.GetDNSZoneInfo(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain public zone information")
+	}
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+	creds, err := getAWSCredentials(client, awsCredentials, region)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain AWS credentials")
+	}
+	helper, err := NewAWSHelper(creds, region, infraName, "")
+	if err != nil {
+		return installerrors.Precondition(err, "cannot create an AWS client")
+	}
+
+	frontendDNSName, err := apiServerFrontendDNSName(client)
+	if err != nil {
+		return installerrors.Apply(err, "failed to get the API server frontend")
+	}
+	taggedNames, err := helper.taggedClusterNames()
+	if err != nil {
+		return cloudProviderError(err, "cannot list AWS resources of hosted clusters")
+	}
+	namespaces, err := client.CoreV1().Namespaces().List(metav1.ListOptions{})
+	if err != nil {
+		return installerrors.Apply(err, "failed to list namespaces")
+	}
+	namespaceNames := sets.NewString()
+	for _, ns := range namespaces.Items {
+		namespaceNames.Insert(ns.Name)
+	}
+	orphans := map[string]*clusterResources{}
+	for _, name := range taggedNames.Difference(namespaceNames).List() {
+		resources, err := helper.forCluster(name).findClusterResources(dnsZoneID, fmt.Sprintf("%s.%s", name, parentDomain), frontendDNSName)
+		if err != nil {
+			return cloudProviderError(err, "cannot find AWS resources of cluster %s", name)
+		}
+		orphans[name] = resources
+	}
+	missing, err := missingLoadBalancers(client, helper)
+	if err != nil {
+		return err
+	}
+
+	w := newResourceWriter(out, "CLUSTER", "STATUS")
+	for _, name := range sets.StringKeySet(orphans).List() {
+		orphans[name].print(w, fmt.Sprintf("%s\tOrphaned\t", name))
+	}
+	for _, name := range sets.StringKeySet(missing).List() {
+		for _, lbName := range missing[name] {
+			fmt.Fprintf(w, "%s\tMissing\tLoad balancer\t\t%s\n", name, lbName)
+		}
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+
+	if !deleteOrphans {
+		return nil
+	}
+	for _, name := range sets.StringKeySet(orphans).List() {
+		log.Infof("Removing orphaned AWS resources of cluster %s", name)
+		clusterHelper := helper.forCluster(name)
+		if err = orphans[name].remove(clusterHelper); err != nil {
+			return err
+		}
+		if err = orphans[name].removeBuckets(clusterHelper); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forCluster returns a copy of the helper that manages the resources of another hosted cluster
+func (h *AWSHelper) forCluster(clusterName string) *AWSHelper {
+	clusterHelper := *h
+	clusterHelper.clusterName = clusterName
+	return &clusterHelper
+}
+
+// taggedClusterNames returns the names of the hosted clusters that AWS resources owned by
+// the management cluster are tagged with
+func (h *AWSHelper) taggedClusterNames() (sets.String, error) {
+	names := sets.NewString()
+	addTags := func(tags map[string]map[string]string) {
+		for _, resourceTags := range h.ownedTags(tags) {
+			if name := resourceTags[ClusterTagKey]; len(name) > 0 {
+				names.Insert(name)
+			}
+		}
+	}
+	lbs, err := h.listLoadBalancers()
+	if err != nil {
+		return nil, err
+	}
+	tgs, err := h.listTargetGroups()
+	if err != nil {
+		return nil, err
+	}
+	elbTags, err := h.elbTags(append(lbs, tgs...))
+	if err != nil {
+		return nil, err
+	}
+	addTags(elbTags)
+	zones, err := h.listPrivateZones()
+	if err != nil {
+		return nil, err
+	}
+	zoneTags, err := h.zoneTags(zones)
+	if err != nil {
+		return nil, err
+	}
+	addTags(zoneTags)
+	bucketTags, err := h.bucketTags()
+	if err != nil {
+		return nil, err
+	}
+	addTags(bucketTags)
+	userTags, err := h.userTags()
+	if err != nil {
+		return nil, err
+	}
+	addTags(userTags)
+	addresses, err := h.listOwnedAddresses(&ec2.Filter{
+		Name:   aws.String("tag-key"),
+		Values: aws.StringSlice([]string{ClusterTagKey}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, address := range addresses {
+		for _, tag := range address.Tags {
+			if aws.StringValue(tag.Key) == ClusterTagKey {
+				names.Insert(aws.StringValue(tag.Value))
+			}
+		}
+	}
+	return names, nil
+}
+
+// missingLoadBalancers returns the names of the load balancers in the AWS infrastructure
+// configuration of the hosted clusters on the management cluster that do not exist in AWS,
+// by cluster
+func missingLoadBalancers(client kubeclient.Interface, helper *AWSHelper) (map[string][]string, error) {
+	configMaps, err := client.CoreV1().ConfigMaps("").List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", awsinfra.InfraConfigMapName).String(),
+	})
+	if err != nil {
+		return nil, installerrors.Apply(err, "failed to list AWS infrastructure configmaps")
+	}
+	lbs, err := helper.listLoadBalancers()
+	if err != nil {
+		return nil, cloudProviderError(err, "cannot list load balancers")
+	}
+	lbNames := sets.NewString()
+	for _, lb := range lbs {
+		lbNames.Insert(lb.name)
+	}
+	result := map[string][]string{}
+	for _, cm := range configMaps.Items {
+		if len(cm.Data[awsinfra.InfraConfigKey]) == 0 {
+			continue
+		}
+		infra := &awsinfra.InfraConfig{}
+		if err := json.Unmarshal([]byte(cm.Data[awsinfra.InfraConfigKey]), infra); err != nil {
+			log.WithError(err).Warnf("Ignoring invalid AWS infrastructure configuration of cluster %s", cm.Namespace)
+			continue
+		}
+		for _, lb := range infra.LoadBalancers {
+			if !lbNames.Has(lb.Name) {
+				result[cm.Namespace] = append(result[cm.Namespace], lb.Name)
+			}
+		}
+	}
+	return result, nil
+}
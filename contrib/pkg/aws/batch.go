@@ -0,0 +1,232 @@
+package aws
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/config"
+)
+
+// DefaultBatchParallelism is the number of clusters of a batch that are installed at the
+// same time, unless the batch or the caller specify it
+const DefaultBatchParallelism = 3
+
+// Batch is a list of clusters that are installed together on the management cluster, read
+// from a clusters.yaml file
+type Batch struct {
+	// Parallelism is the number of clusters that are installed at the same time
+	Parallelism int `json:"parallelism,omitempty"`
+	// Clusters are the clusters of the batch
+	Clusters []BatchCluster `json:"clusters"`
+}
+
+// BatchCluster is a cluster of a batch
+type BatchCluster struct {
+	// Name is the name of the cluster
+	Name string `json:"name"`
+	// Config is a cluster.yaml file with the parameters of the cluster. A relative path is
+	// relative to the directory of the clusters.yaml file.
+	Config string `json:"config,omitempty"`
+}
+
+// ReadBatch reads a clusters.yaml file, with the paths of the cluster.yaml files of its
+// clusters resolved
+func ReadBatch(fileName string) (*Batch, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	batch := &Batch{}
+	if err = yaml.Unmarshal(b, batch); err != nil {
+		return nil, fmt.Errorf("cannot parse clusters in %s: %v", fileName, err)
+	}
+	if err = batch.validate(); err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(fileName)
+	for i := range batch.Clusters {
+		cluster := &batch.Clusters[i]
+		if len(cluster.Config) > 0 && !filepath.IsAbs(cluster.Config) {
+			cluster.Config = filepath.Join(dir, cluster.Config)
+		}
+	}
+	return batch, nil
+}
+
+// validate verifies that the clusters of a batch have distinct names
+func (b *Batch) validate() error {
+	if b.Parallelism < 0 {
+		return fmt.Errorf("the parallelism cannot be negative, got %d", b.Parallelism)
+	}
+	if len(b.Clusters) == 0 {
+		return fmt.Errorf("no clusters are specified")
+	}
+	names := sets.NewString()
+	for _, cluster := range b.Clusters {
+		if len(cluster.Name) == 0 {
+			return fmt.Errorf("a cluster has no name")
+		}
+		if names.Has(cluster.Name) {
+			return fmt.Errorf("cluster %s is specified more than once", cluster.Name)
+		}
+		names.Insert(cluster.Name)
+	}
+	return nil
+}
+
+// batchResult is the outcome of the install of a cluster of a batch
+type batchResult struct {
+	name     string
+	err      error
+	duration time.Duration
+}
+
+// InstallClusters installs the clusters of a batch on the management cluster, up to
+// parallelism at the same time. A parallelism of 0 is that of the batch, or
+// DefaultBatchParallelism. The management cluster is discovered once for all of the
+// clusters, which are installed as described by InstallCluster with the same options.
+// Clusters without a cluster.yaml use the one in configFile, if any. Unless skipCapacityCheck
+// is true, the free capacity of the management cluster is checked for all of the clusters
+// that are not installed yet before any install starts. The install state of
+// each cluster is kept in a directory named after it in stateDir. The failure of a cluster
+// does not stop the others; a summary of the installs is written to out once all of them
+// have finished.
+func InstallClusters(batch *Batch, parallelism int, out io.Writer, releaseImage, dhParamsFile, infraCredentialsFile, stateDir, configFile string, awsCredentials CredentialsConfig, workers WorkerConfig, network NetworkConfig, waitForReady bool, waitConfig installer.WaitConfig, highAvailability, private, ignitionBucket, clusterUser, skipPrivilegedSCC, skipCapacityCheck bool) error {
+	if err := batch.validate(); err != nil {
+		return installerrors.Precondition(err, "invalid batch of clusters")
+	}
+	if parallelism == 0 {
+		parallelism = batch.Parallelism
+	}
+	if parallelism == 0 {
+		parallelism = DefaultBatchParallelism
+	}
+	if parallelism < 0 {
+		return installerrors.Precondition(nil, "the parallelism cannot be negative, got %d", parallelism)
+	}
+	// The cluster.yaml files are read before any install starts, so that a missing or
+	// invalid file does not leave a partially installed batch. Each cluster gets its own
+	// copy of the parameters, since an install changes them.
+	configs := make([]*api.ClusterParams, len(batch.Clusters))
+	for i, cluster := range batch.Clusters {
+		fileName := cluster.Config
+		if len(fileName) == 0 {
+			fileName = configFile
+		}
+		if len(fileName) == 0 {
+			continue
+		}
+		clusterConfig, err := config.ReadFrom(fileName)
+		if err != nil {
+			return installerrors.Precondition(err, "cannot read the cluster configuration of cluster %s", cluster.Name)
+		}
+		configs[i] = clusterConfig
+	}
+
+	mc, err := discoverManagementCluster(awsCredentials, network)
+	if err != nil {
+		return err
+	}
+
+	// The capacity of the management cluster is checked for all of the new clusters at once,
+	// since each install would only find the capacity that the others take as well
+	if !skipCapacityCheck {
+		var footprint []installer.PodRequest
+		for i, cluster := range batch.Clusters {
+			_, err := mc.client.CoreV1().Namespaces().Get(cluster.Name, metav1.GetOptions{})
+			if err == nil {
+				continue
+			}
+			if !errors.IsNotFound(err) {
+				return installerrors.Precondition(err, "unexpected error getting namespaces from management cluster")
+			}
+			pods, err := controlPlaneFootprint(cluster.Name, configs[i], highAvailability, network.DirectRouting)
+			if err != nil {
+				return installerrors.Precondition(err, "cannot estimate the resources of the control plane of cluster %s", cluster.Name)
+			}
+			footprint = append(footprint, pods...)
+		}
+		if len(footprint) > 0 {
+			if err = checkCapacity(mc.client, footprint); err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Infof("Installing %d clusters, %d at a time", len(batch.Clusters), parallelism)
+	results := make([]batchResult, len(batch.Clusters))
+	slots := make(chan struct{}, parallelism)
+	var lock sync.Mutex
+	finished := 0
+	var wg sync.WaitGroup
+	for i := range batch.Clusters {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			name := batch.Clusters[i].Name
+			clusterStateDir := ""
+			if len(stateDir) > 0 {
+				clusterStateDir = filepath.Join(stateDir, name)
+			}
+			log.Infof("Starting install of cluster %s", name)
+			start := time.Now()
+			err := installCluster(mc, name, releaseImage, dhParamsFile, infraCredentialsFile, clusterStateDir, configs[i], workers, network, waitForReady, waitConfig, highAvailability, private, ignitionBucket, clusterUser, skipPrivilegedSCC, true, false, nil, nil)
+			results[i] = batchResult{name: name, err: err, duration: time.Since(start)}
+
+			lock.Lock()
+			defer lock.Unlock()
+			finished++
+			if err != nil {
+				log.WithError(err).WithField("retryable", installerrors.IsRetryable(err)).Errorf("Failed to install cluster %s (%d/%d finished)", name, finished, len(batch.Clusters))
+			} else {
+				log.Infof("Installed cluster %s in %s (%d/%d finished)", name, results[i].duration.Round(time.Second), finished, len(batch.Clusters))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	failed := writeBatchResults(out, results)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d clusters failed to install", failed, len(results))
+	}
+	return nil
+}
+
+// writeBatchResults writes a table of the outcome of the installs of a batch and returns
+// the number of clusters that failed to install
+func writeBatchResults(out io.Writer, results []batchResult) int {
+	failed := 0
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tRESULT\tDURATION\tERROR")
+	for _, result := range results {
+		status, message := "installed", ""
+		if result.err != nil {
+			failed++
+			status, message = "failed", result.err.Error()
+			if installerrors.IsRetryable(result.err) {
+				status = "failed (retryable)"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.name, status, result.duration.Round(time.Second), message)
+	}
+	w.Flush()
+	return failed
+}
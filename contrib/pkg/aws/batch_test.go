@@ -0,0 +1,99 @@
+package aws
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadBatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name        string
+		content     string
+		expected    *Batch
+		expectError bool
+	}{
+		{
+			name:    "clusters",
+			content: "parallelism: 2\nclusters:\n- name: dev-1\n  config: dev.yaml\n- name: dev-2\n  config: /etc/hypershift/prod.yaml\n- name: dev-3\n",
+			expected: &Batch{
+				Parallelism: 2,
+				Clusters: []BatchCluster{
+					{Name: "dev-1", Config: filepath.Join(dir, "dev.yaml")},
+					{Name: "dev-2", Config: "/etc/hypershift/prod.yaml"},
+					{Name: "dev-3"},
+				},
+			},
+		},
+		{
+			name:        "no clusters",
+			content:     "parallelism: 2\n",
+			expectError: true,
+		},
+		{
+			name:        "duplicate name",
+			content:     "clusters:\n- name: dev-1\n- name: dev-1\n",
+			expectError: true,
+		},
+		{
+			name:        "missing name",
+			content:     "clusters:\n- config: dev.yaml\n",
+			expectError: true,
+		},
+		{
+			name:        "negative parallelism",
+			content:     "parallelism: -1\nclusters:\n- name: dev-1\n",
+			expectError: true,
+		},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := filepath.Join(dir, fmt.Sprintf("clusters-%d.yaml", i))
+			if err := ioutil.WriteFile(fileName, []byte(test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			batch, err := ReadBatch(fileName)
+			if test.expectError {
+				if err == nil {
+					t.Errorf("expected an error, got %v", batch)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(batch, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, batch)
+			}
+		})
+	}
+}
+
+func TestWriteBatchResults(t *testing.T) {
+	out := &bytes.Buffer{}
+	failed := writeBatchResults(out, []batchResult{
+		{name: "dev-1"},
+		{name: "dev-2", err: fmt.Errorf("no free node ports")},
+	})
+	if failed != 1 {
+		t.Errorf("expected 1 failed cluster, got %d", failed)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and 2 clusters, got %q", out.String())
+	}
+	if !strings.Contains(lines[1], "installed") || !strings.Contains(lines[2], "failed") || !strings.Contains(lines[2], "no free node ports") {
+		t.Errorf("unexpected results %q", out.String())
+	}
+}
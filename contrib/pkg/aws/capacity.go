@@ -0,0 +1,62 @@
+package aws
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+// controlPlaneFootprint returns the replicas of the control plane of a cluster that run on
+// the management cluster, sized by its cluster.yaml, if any
+func controlPlaneFootprint(name string, clusterConfig *api.ClusterParams, highAvailability, directRouting bool) ([]installer.PodRequest, error) {
+	params := api.NewClusterParams()
+	if clusterConfig != nil {
+		configured := *clusterConfig
+		params = &configured
+	}
+	params.DirectRouting = params.DirectRouting || directRouting
+	if highAvailability || params.Replicas == fmt.Sprintf("%d", haControlPlaneReplicas) {
+		params.Replicas = fmt.Sprintf("%d", haControlPlaneReplicas)
+	} else {
+		params.Replicas = "1"
+	}
+	return installer.ControlPlaneFootprint(name, params)
+}
+
+// checkCapacity verifies that the schedulable nodes of the management cluster have enough
+// free capacity for the replicas of control planes. It fails if the total free capacity of
+// the nodes is less than the replicas request, and warns with the nodes that have the most
+// free capacity if some replicas do not fit on the nodes next to the others.
+func checkCapacity(client kubeclient.Interface, pods []installer.PodRequest) error {
+	nodes, err := installer.FreeNodeCapacity(client)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot determine the free capacity of the management cluster")
+	}
+	report := installer.CheckCapacity(nodes, pods)
+	log.Infof("The control plane requests %s CPU and %s memory, the %d schedulable management cluster nodes have %s CPU and %s memory free",
+		report.CPU.String(), report.Memory.String(), len(nodes), report.FreeCPU.String(), report.FreeMemory.String())
+	if !report.Sufficient() {
+		return installerrors.Precondition(nil, "the management cluster does not have enough free capacity: the control plane requests %s CPU and %s memory, but its schedulable nodes have %s CPU and %s memory free; add workers to the management cluster or use a smaller sizing profile",
+			report.CPU.String(), report.Memory.String(), report.FreeCPU.String(), report.FreeMemory.String())
+	}
+	if len(report.Unplaced) == 0 {
+		return nil
+	}
+	for _, pod := range report.Unplaced {
+		log.Warnf("A replica of %s of cluster %s, requesting %s CPU and %s memory, does not fit on a management cluster node next to the other control plane replicas",
+			pod.Component, pod.Cluster, pod.CPU.String(), pod.Memory.String())
+	}
+	for _, node := range report.Nodes {
+		log.Warnf("Node %s has %s CPU and %s memory left after the control plane is placed", node.Name, node.CPU.String(), node.Memory.String())
+	}
+	largest := report.Unplaced[0]
+	log.Warnf("Some control plane pods may stay pending; add a management cluster worker with at least %s CPU and %s memory free, or use a smaller sizing profile",
+		largest.CPU.String(), largest.Memory.String())
+	return nil
+}
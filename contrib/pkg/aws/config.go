@@ -0,0 +1,107 @@
+package aws
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+// defaultControlPlaneOperatorControllers are the controllers of the control plane operator
+// of clusters whose cluster.yaml does not list them
+var defaultControlPlaneOperatorControllers = []string{
+	"controller-manager-ca",
+	"auto-approver",
+	"kubeadmin-password",
+	"cluster-operator",
+	"cluster-version",
+	"kubelet-serving-ca",
+	"openshift-apiserver",
+	"openshift-controller-manager",
+	"cert-rotation",
+	"cluster-status",
+	"node-gc",
+	"node-updater",
+	"tunnel-health",
+	"oauth-endpoint",
+	"ingress-default-cert",
+}
+
+// applyClusterConfig sets the release image, architecture and node pools of an install from
+// a cluster.yaml unless they are set by flags, and returns whether the control plane is
+// highly available. The replicas of a cluster.yaml can only be those of the --ha flag.
+func applyClusterConfig(params *api.ClusterParams, releaseImage *string, workers *WorkerConfig, highAvailability bool) (bool, error) {
+	if len(*releaseImage) == 0 {
+		*releaseImage = params.ReleaseImage
+	}
+	if len(workers.Arch) == 0 {
+		workers.Arch = params.Arch
+	}
+	if len(workers.NodePools) == 0 {
+		workers.NodePools = params.NodePools
+	}
+	switch params.Replicas {
+	case "", "1":
+		return highAvailability, nil
+	case fmt.Sprintf("%d", haControlPlaneReplicas):
+		return true, nil
+	default:
+		return false, fmt.Errorf("the control plane runs 1 or %d replicas, got %s", haControlPlaneReplicas, params.Replicas)
+	}
+}
+
+// warnOverriddenClusterConfig warns about the parameters of a cluster.yaml that the
+// installer replaced: the names, addresses, CIDRs and node ports it allocates for each
+// cluster, and the ports and service type that its load balancers depend on
+func warnOverriddenClusterConfig(configured, params *api.ClusterParams) {
+	overridden := []struct {
+		name       string
+		configured interface{}
+		actual     interface{}
+	}{
+		{"namespace", configured.Namespace, params.Namespace},
+		{"externalAPIDNSName", configured.ExternalAPIDNSName, params.ExternalAPIDNSName},
+		{"externalAPIAddress", configured.ExternalAPIIPAddress, params.ExternalAPIIPAddress},
+		{"externalAPIPort", configured.ExternalAPIPort, params.ExternalAPIPort},
+		{"internalAPIPort", configured.InternalAPIPort, params.InternalAPIPort},
+		{"externalVPNDNSName", configured.ExternalOpenVPNDNSName, params.ExternalOpenVPNDNSName},
+		{"externalVPNPort", configured.ExternalOpenVPNPort, params.ExternalOpenVPNPort},
+		{"externalOauthPort", configured.ExternalOauthPort, params.ExternalOauthPort},
+		{"externalIgnitionPort", configured.ExternalIgnitionPort, params.ExternalIgnitionPort},
+		{"serviceCIDR", configured.ServiceCIDR, params.ServiceCIDR},
+		{"podCIDR", configured.PodCIDR, params.PodCIDR},
+		{"baseDomain", configured.BaseDomain, params.BaseDomain},
+		{"ingressSubdomain", configured.IngressSubdomain, params.IngressSubdomain},
+		{"routerServiceType", configured.RouterServiceType, params.RouterServiceType},
+		{"routerNodePortHTTP", configured.RouterNodePortHTTP, params.RouterNodePortHTTP},
+		{"routerNodePortHTTPS", configured.RouterNodePortHTTPS, params.RouterNodePortHTTPS},
+		{"openVPNNodePort", configured.OpenVPNNodePort, params.OpenVPNNodePort},
+		{"apiNodePort", configured.APINodePort, params.APINodePort},
+	}
+	for _, o := range overridden {
+		value := fmt.Sprint(o.configured)
+		if value == "" || value == "0" || value == fmt.Sprint(o.actual) {
+			continue
+		}
+		log.Warnf("Ignoring %s %s of the cluster configuration, the installer sets it to %v", o.name, value, o.actual)
+	}
+}
+
+// setControllers returns a list of controllers with the given controllers added to the end
+// if they are enabled, or removed if they are not
+func setControllers(controllers []string, enabled bool, names ...string) []string {
+	removed := sets.NewString(names...)
+	result := []string{}
+	for _, controller := range controllers {
+		if !removed.Has(controller) {
+			result = append(result, controller)
+		}
+	}
+	if enabled {
+		result = append(result, names...)
+	}
+	return result
+}
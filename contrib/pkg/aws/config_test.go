@@ -0,0 +1,72 @@
+package aws
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestApplyClusterConfig(t *testing.T) {
+	pools := []api.NodePool{{Name: "general", Replicas: 2}}
+	tests := []struct {
+		name                 string
+		params               api.ClusterParams
+		releaseImage         string
+		workers              WorkerConfig
+		highAvailability     bool
+		expectedImage        string
+		expectedPools        int
+		expectedAvailability bool
+		expectError          bool
+	}{
+		{
+			name:          "config values",
+			params:        api.ClusterParams{ReleaseImage: "release:4.4", NodePools: pools, Replicas: "3"},
+			expectedImage: "release:4.4", expectedPools: 1, expectedAvailability: true,
+		},
+		{
+			name:             "flags take precedence",
+			params:           api.ClusterParams{ReleaseImage: "release:4.4", NodePools: pools, Replicas: "1"},
+			releaseImage:     "release:4.5",
+			workers:          WorkerConfig{NodePools: append(pools, api.NodePool{Name: "gpu", Replicas: 1})},
+			highAvailability: true,
+			expectedImage:    "release:4.5", expectedPools: 2, expectedAvailability: true,
+		},
+		{
+			name:        "unsupported replicas",
+			params:      api.ClusterParams{Replicas: "2"},
+			expectError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			releaseImage := test.releaseImage
+			workers := test.workers
+			ha, err := applyClusterConfig(&test.params, &releaseImage, &workers, test.highAvailability)
+			if test.expectError {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if releaseImage != test.expectedImage || len(workers.NodePools) != test.expectedPools || ha != test.expectedAvailability {
+				t.Errorf("unexpected release image %s, node pools %v or high availability %t", releaseImage, workers.NodePools, ha)
+			}
+		})
+	}
+}
+
+func TestSetControllers(t *testing.T) {
+	controllers := []string{"cluster-status", "aws-infra", "aws-machine-targets", "cert-rotation"}
+	if actual := setControllers(controllers, false, "aws-infra", "aws-machine-targets"); !reflect.DeepEqual(actual, []string{"cluster-status", "cert-rotation"}) {
+		t.Errorf("expected the AWS controllers to be removed, got %v", actual)
+	}
+	expected := []string{"cluster-status", "cert-rotation", "aws-infra", "aws-machine-targets"}
+	if actual := setControllers(controllers, true, "aws-infra", "aws-machine-targets"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
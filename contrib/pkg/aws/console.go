@@ -0,0 +1,43 @@
+package aws
+
+import (
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+// ConsolePassword writes the console URL and kubeadmin password of the cluster named
+// name to out
+func ConsolePassword(name string, out io.Writer) error {
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+	password, err := installer.GetKubeadminPassword(client, name)
+	if errors.IsNotFound(err) {
+		return installerrors.Precondition(err, "did not find the kubeadmin password of cluster %s", name)
+	}
+	if err != nil {
+		return installerrors.Apply(err, "failed to read the kubeadmin password of cluster %s", name)
+	}
+	params, err := installer.GetClusterParams(client, name)
+	if err != nil && !errors.IsNotFound(err) {
+		return installerrors.Apply(err, "failed to read the parameters of cluster %s", name)
+	}
+	// Clusters installed without stored parameters have no known ingress subdomain
+	if err == nil && len(params.IngressSubdomain) > 0 {
+		fmt.Fprintf(out, "Console URL: %s\n", installer.ConsoleURL(params.IngressSubdomain))
+	}
+	fmt.Fprintf(out, "Username:    kubeadmin\n")
+	fmt.Fprintf(out, "Password:    %s\n", password)
+	return nil
+}
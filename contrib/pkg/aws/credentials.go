@@ -0,0 +1,136 @@
+package aws
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeclient "k8s.io/client-go/kubernetes"
+)
+
+const (
+	// roleSessionName is the name of the STS sessions of the installer
+	roleSessionName = "hypershift-installer"
+
+	// webIdentityExpiryWindow is how long before they expire that web identity
+	// credentials are renewed
+	webIdentityExpiryWindow = 5 * time.Minute
+)
+
+// CredentialsConfig selects the AWS credentials of the installer. Without a role, the
+// static keys of the kube-system/aws-creds secret of the management cluster are used.
+type CredentialsConfig struct {
+	// RoleARN is a role that is assumed through STS, with the credentials of the
+	// environment or the instance profile, or with a web identity token
+	RoleARN string
+	// ExternalID is passed when assuming the role with the credentials of the environment
+	ExternalID string
+	// WebIdentityTokenFile is a file with an OIDC token, such as a projected service
+	// account token, that the role is assumed with instead
+	WebIdentityTokenFile string
+}
+
+// validate verifies that the credentials configuration is consistent
+func (c CredentialsConfig) validate() error {
+	if len(c.RoleARN) == 0 {
+		if len(c.ExternalID) > 0 || len(c.WebIdentityTokenFile) > 0 {
+			return fmt.Errorf("an external ID or web identity token file requires a role ARN")
+		}
+		return nil
+	}
+	if len(c.ExternalID) > 0 && len(c.WebIdentityTokenFile) > 0 {
+		return fmt.Errorf("an external ID cannot be used to assume a role with a web identity")
+	}
+	return nil
+}
+
+// getAWSCredentials returns the AWS credentials of the installer in the given region
+func getAWSCredentials(client kubeclient.Interface, config CredentialsConfig, region string) (*credentials.Credentials, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if len(config.RoleARN) == 0 {
+		key, secretKey, err := getStaticAWSCredentials(client)
+		if err != nil {
+			return nil, err
+		}
+		return credentials.NewStaticCredentials(key, secretKey, ""), nil
+	}
+	// The session that the role is assumed with has the credentials of the environment,
+	// shared credentials file or instance profile
+	s, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return nil, err
+	}
+	if len(config.WebIdentityTokenFile) > 0 {
+		return credentials.NewCredentials(&webIdentityProvider{
+			client:    sts.New(s),
+			roleARN:   config.RoleARN,
+			tokenFile: config.WebIdentityTokenFile,
+		}), nil
+	}
+	return stscreds.NewCredentials(s, config.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+		p.RoleSessionName = roleSessionName
+		if len(config.ExternalID) > 0 {
+			p.ExternalID = aws.String(config.ExternalID)
+		}
+	}), nil
+}
+
+// getStaticAWSCredentials returns the static keys of the management cluster's AWS
+// credentials secret
+func getStaticAWSCredentials(client kubeclient.Interface) (string, string, error) {
+	secret, err := client.CoreV1().Secrets("kube-system").Get("aws-creds", metav1.GetOptions{})
+	if err != nil {
+		return "", "", err
+	}
+	key, ok := secret.Data["aws_access_key_id"]
+	if !ok {
+		return "", "", fmt.Errorf("did not find an AWS access key")
+	}
+	secretKey, ok := secret.Data["aws_secret_access_key"]
+	if !ok {
+		return "", "", fmt.Errorf("did not find an AWS secret access key")
+	}
+	return string(key), string(secretKey), nil
+}
+
+// webIdentityProvider retrieves the credentials of a role assumed with a web identity
+// token. The token file is read on every retrieval, so that rotated tokens are used.
+type webIdentityProvider struct {
+	credentials.Expiry
+	client    *sts.STS
+	roleARN   string
+	tokenFile string
+}
+
+// Retrieve assumes the role with the current token of the token file
+func (p *webIdentityProvider) Retrieve() (credentials.Value, error) {
+	token, err := ioutil.ReadFile(p.tokenFile)
+	if err != nil {
+		return credentials.Value{}, fmt.Errorf("cannot read web identity token file %s: %v", p.tokenFile, err)
+	}
+	output, err := p.client.AssumeRoleWithWebIdentity(&sts.AssumeRoleWithWebIdentityInput{
+		RoleArn:          aws.String(p.roleARN),
+		RoleSessionName:  aws.String(roleSessionName),
+		WebIdentityToken: aws.String(strings.TrimSpace(string(token))),
+	})
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	p.SetExpiration(aws.TimeValue(output.Credentials.Expiration), webIdentityExpiryWindow)
+	return credentials.Value{
+		AccessKeyID:     aws.StringValue(output.Credentials.AccessKeyId),
+		SecretAccessKey: aws.StringValue(output.Credentials.SecretAccessKey),
+		SessionToken:    aws.StringValue(output.Credentials.SessionToken),
+		ProviderName:    "WebIdentityProvider",
+	}, nil
+}
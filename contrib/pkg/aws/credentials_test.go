@@ -0,0 +1,85 @@
+package aws
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+func TestCredentialsConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      CredentialsConfig
+		expectError bool
+	}{
+		{name: "static keys", config: CredentialsConfig{}},
+		{name: "role", config: CredentialsConfig{RoleARN: "arn:aws:iam::123456789012:role/installer", ExternalID: "id"}},
+		{name: "web identity", config: CredentialsConfig{RoleARN: "arn:aws:iam::123456789012:role/installer", WebIdentityTokenFile: "/var/run/token"}},
+		{name: "external ID without role", config: CredentialsConfig{ExternalID: "id"}, expectError: true},
+		{name: "token without role", config: CredentialsConfig{WebIdentityTokenFile: "/var/run/token"}, expectError: true},
+		{name: "web identity with external ID", config: CredentialsConfig{RoleARN: "arn:aws:iam::123456789012:role/installer", ExternalID: "id", WebIdentityTokenFile: "/var/run/token"}, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.config.validate(); test.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", test.expectError, err)
+			}
+		})
+	}
+}
+
+func TestWebIdentityProvider(t *testing.T) {
+	tokenFile, err := ioutil.TempFile("", "token")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(tokenFile.Name())
+	fmt.Fprintln(tokenFile, "first-token")
+	tokenFile.Close()
+
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		tokens = append(tokens, r.Form.Get("WebIdentityToken"))
+		fmt.Fprint(w, `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><AssumeRoleWithWebIdentityResult>`+
+			`<Credentials><AccessKeyId>key</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken><Expiration>2000-01-01T00:00:00Z</Expiration></Credentials>`+
+			`</AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`)
+	}))
+	defer server.Close()
+	s := session.Must(session.NewSession(&aws.Config{
+		Region:     aws.String("us-east-1"),
+		Endpoint:   aws.String(server.URL),
+		MaxRetries: aws.Int(0),
+	}))
+	creds := credentials.NewCredentials(&webIdentityProvider{
+		client:    sts.New(s),
+		roleARN:   "arn:aws:iam::123456789012:role/installer",
+		tokenFile: tokenFile.Name(),
+	})
+	value, err := creds.Get()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value.AccessKeyID != "key" || value.SecretAccessKey != "secret" || value.SessionToken != "session" {
+		t.Errorf("unexpected credentials: %#v", value)
+	}
+
+	// The credentials have expired, so they are retrieved with the rotated token
+	if err = ioutil.WriteFile(tokenFile.Name(), []byte("second-token"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = creds.Get(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tokens) != 2 || tokens[0] != "first-token" || tokens[1] != "second-token" {
+		t.Errorf("expected the role to be assumed with each token, got %v", tokens)
+	}
+}
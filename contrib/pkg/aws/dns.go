@@ -0,0 +1,112 @@
+package aws
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+const (
+	externalDNSHostnameAnnotation = "external-dns.alpha.kubernetes.io/hostname"
+	externalDNSTTLAnnotation      = "external-dns.alpha.kubernetes.io/ttl"
+
+	// externalDNSLabel marks the services that the installer creates for external-dns
+	externalDNSLabel = "hypershift.openshift.io/external-dns"
+)
+
+// DNSProvider registers the DNS records of the endpoints of a hosted cluster
+type DNSProvider interface {
+	// EnsureCNameRecord ensures that dnsName is a CNAME of target
+	EnsureCNameRecord(dnsName, target string) error
+	// RemoveCNameRecord removes the record of dnsName if it exists
+	RemoveCNameRecord(dnsName string) error
+}
+
+// route53DNSProvider registers records in Route53 zones
+type route53DNSProvider struct {
+	helper  *AWSHelper
+	zoneIDs []string
+}
+
+var _ DNSProvider = &route53DNSProvider{}
+
+func (p *route53DNSProvider) EnsureCNameRecord(dnsName, target string) error {
+	for _, zoneID := range p.zoneIDs {
+		if err := p.helper.EnsureCNameRecord(zoneID, dnsName, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *route53DNSProvider) RemoveCNameRecord(dnsName string) error {
+	for _, zoneID := range p.zoneIDs {
+		if err := p.helper.RemoveCNameRecord(zoneID, dnsName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// externalDNSProvider leaves the records to an external-dns deployment of the management
+// cluster. Each record is an ExternalName service in the control plane namespace that is
+// annotated with the name of the record, which external-dns registers as a CNAME of the
+// external name of the service in whichever DNS it manages.
+type externalDNSProvider struct {
+	client    kubeclient.Interface
+	namespace string
+}
+
+var _ DNSProvider = &externalDNSProvider{}
+
+func (p *externalDNSProvider) EnsureCNameRecord(dnsName, target string) error {
+	services := p.client.CoreV1().Services(p.namespace)
+	svc, err := services.Get(externalDNSServiceName(dnsName), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = services.Create(externalDNSService(dnsName, target))
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	expected := externalDNSService(dnsName, target)
+	svc.Labels = expected.Labels
+	svc.Annotations = expected.Annotations
+	svc.Spec.ExternalName = target
+	_, err = services.Update(svc)
+	return err
+}
+
+func (p *externalDNSProvider) RemoveCNameRecord(dnsName string) error {
+	err := p.client.CoreV1().Services(p.namespace).Delete(externalDNSServiceName(dnsName), &metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// externalDNSService returns the ExternalName service of a record for external-dns
+func externalDNSService(dnsName, target string) *corev1.Service {
+	svc := &corev1.Service{}
+	svc.Name = externalDNSServiceName(dnsName)
+	svc.Labels = map[string]string{externalDNSLabel: "true"}
+	svc.Annotations = map[string]string{
+		externalDNSHostnameAnnotation: strings.TrimSuffix(dnsName, "."),
+		externalDNSTTLAnnotation:      "30",
+	}
+	svc.Spec.Type = corev1.ServiceTypeExternalName
+	svc.Spec.ExternalName = target
+	return svc
+}
+
+// externalDNSServiceName returns the name of the service of a record, such as
+// wildcard-apps-example-mydomain-com-dns for *.apps.example.mydomain.com
+func externalDNSServiceName(dnsName string) string {
+	base := strings.NewReplacer("*", "wildcard", ".", "-").Replace(strings.ToLower(strings.TrimSuffix(dnsName, ".")))
+	return installer.GetName(base, "dns", 63)
+}
@@ -0,0 +1,27 @@
+package aws
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestExternalDNSService(t *testing.T) {
+	svc := externalDNSService("*.apps.example.mydomain.com.", "router-1234.elb.us-east-1.amazonaws.com")
+	if svc.Name != "wildcard-apps-example-mydomain-com-dns" {
+		t.Errorf("unexpected service name %s", svc.Name)
+	}
+	if svc.Spec.Type != corev1.ServiceTypeExternalName || svc.Spec.ExternalName != "router-1234.elb.us-east-1.amazonaws.com" {
+		t.Errorf("unexpected service spec %#v", svc.Spec)
+	}
+	if hostname := svc.Annotations[externalDNSHostnameAnnotation]; hostname != "*.apps.example.mydomain.com" {
+		t.Errorf("unexpected hostname annotation %s", hostname)
+	}
+}
+
+func TestExternalDNSServiceName(t *testing.T) {
+	name := externalDNSServiceName("api.a-hosted-cluster-with-a-very-long-name.a-long-parent-domain.example.com")
+	if len(name) > 63 {
+		t.Errorf("service name %s is longer than 63 characters", name)
+	}
+}
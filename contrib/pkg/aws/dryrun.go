@@ -0,0 +1,134 @@
+package aws
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+// dryRunNetworkInfo returns the network of the load balancers of a dry run without
+// describing AWS resources. The zones are those of the management cluster workers, and the
+// VPC and subnets are those of the network configuration, which are not verified.
+func dryRunNetworkInfo(client dynamic.Interface, infraName string, network NetworkConfig) (*LBInfo, error) {
+	machineGroupVersion, err := schema.ParseGroupVersion("machine.openshift.io/v1beta1")
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.Resource(machineGroupVersion.WithResource("machines")).Namespace("openshift-machine-api").List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	zones := sets.NewString()
+	for i := range list.Items {
+		zone, _, _ := unstructured.NestedString(list.Items[i].Object, "spec", "providerSpec", "value", "placement", "availabilityZone")
+		if len(zone) > 0 && isWorkerInZones(list.Items[i].GetName(), infraName, []string{zone}) {
+			zones.Insert(zone)
+		}
+	}
+	if zones.Len() == 0 {
+		return nil, fmt.Errorf("cannot find a zone with workers in it")
+	}
+	result := &LBInfo{VPC: network.VPC, Zones: zones.List(), Subnets: network.Subnets}
+	result.Zone = result.Zones[0]
+	if len(result.Subnets) > 0 {
+		result.Subnet = result.Subnets[0]
+	}
+	return result, nil
+}
+
+// dryRunAPIEndpoint returns the API endpoint that EnsureAPIEndpoint would create. The
+// addresses of load balancers are only known once they are created.
+func (p *awsProvider) dryRunAPIEndpoint(ports installer.APIEndpointPorts) *installer.Endpoint {
+	switch {
+	case p.serviceLoadBalancers:
+		p.oauthDNSName = p.dnsName("oauth")
+		if ports.Ignition != 0 {
+			p.ignitionDNSName = p.dnsName("ignition")
+		}
+		log.Infof("Would expose the API, OAuth and ignition server services through load balancers")
+		return &installer.Endpoint{DNSName: p.dnsName("api")}
+	case p.routes:
+		p.oauthDNSName = installer.RouteHost("oauth", p.clusterName, p.ingressDomain)
+		if ports.Ignition != 0 {
+			p.ignitionDNSName = installer.RouteHost("ignition", p.clusterName, p.ingressDomain)
+		}
+		log.Infof("Would create the passthrough routes of the API, OAuth and ignition server")
+		return &installer.Endpoint{DNSName: installer.RouteHost("api", p.clusterName, p.ingressDomain), Address: p.machineIPs[0]}
+	case len(p.sharedIngressDNSName) > 0:
+		log.Infof("Would create DNS record %s for the API server frontend %s", p.dnsName("api"), p.sharedIngressDNSName)
+		return &installer.Endpoint{DNSName: p.dnsName("api"), Address: p.machineIPs[0]}
+	}
+	log.Infof("Would create API load balancer %s and DNS record %s", p.lbName("api"), p.dnsName("api"))
+	return &installer.Endpoint{DNSName: p.dnsName("api")}
+}
+
+// dryRunIngressEndpoint returns the router endpoint that EnsureIngressEndpoint would create
+func (p *awsProvider) dryRunIngressEndpoint() *installer.Endpoint {
+	log.Infof("Would create router load balancer %s and DNS record %s", p.lbName("apps"), p.dnsName("*.apps"))
+	return &installer.Endpoint{DNSName: p.dnsName("*.apps")}
+}
+
+// dryRunVPNEndpoint returns the VPN endpoint that EnsureVPNEndpoint would create
+func (p *awsProvider) dryRunVPNEndpoint() *installer.Endpoint {
+	log.Infof("Would create VPN load balancer %s and DNS record %s", p.lbName("vpn"), p.dnsName("vpn"))
+	return &installer.Endpoint{DNSName: p.dnsName("vpn")}
+}
+
+// dryRunIgnitionStorage returns the URL of the ignition file that EnsureIgnitionStorage
+// would upload, without the pre-signed query of the URL
+func (p *awsProvider) dryRunIgnitionStorage() string {
+	p.ignitionBucket = generateBucketName(p.infraName, p.clusterName, "ign")
+	log.Infof("Would upload the ignition file to bucket %s", p.ignitionBucket)
+	return fmt.Sprintf("https://%s.s3.amazonaws.com/worker.ign", p.ignitionBucket)
+}
+
+// dryRunNodePort reports a service that a dry run does not create, whose node port is
+// only known once it is created
+func dryRunNodePort(service string) (int, error) {
+	log.Infof("Would create the %s service, its node port is allocated when it is created", service)
+	return 0, nil
+}
+
+// dryRunWorkingDir returns a temporary working directory for a dry run, so that the PKI
+// and manifests of the state directory are left as they are. The PKI of a resumed install
+// is copied, so that the manifests are rendered with the certificates already applied.
+func dryRunWorkingDir(stateDir string, resumed bool) (string, error) {
+	dir, err := ioutil.TempDir("", "hypershift-dry-run")
+	if err != nil {
+		return "", err
+	}
+	if !resumed {
+		return dir, nil
+	}
+	pkiDir := filepath.Join(stateDir, "pki")
+	files, err := ioutil.ReadDir(pkiDir)
+	if os.IsNotExist(err) {
+		return dir, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if err = os.Mkdir(filepath.Join(dir, "pki"), 0700); err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if err = installer.CopyFile(filepath.Join(pkiDir, file.Name()), filepath.Join(dir, "pki", file.Name())); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
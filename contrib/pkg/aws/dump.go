@@ -0,0 +1,40 @@
+package aws
+
+import (
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+// DumpCluster writes the diagnostics of the cluster named name for a support case to out
+// as a gzipped tarball, as described by installer.DumpCluster
+func DumpCluster(name string, out io.Writer) error {
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
+	}
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+	if _, err = client.CoreV1().Namespaces().Get(name, metav1.GetOptions{}); err != nil {
+		if errors.IsNotFound(err) {
+			return installerrors.Precondition(err, "cluster %s does not exist", name)
+		}
+		return installerrors.Precondition(err, "unexpected error getting namespaces from management cluster")
+	}
+	if err = installer.DumpCluster(client, dynamicClient, name, out); err != nil {
+		return installerrors.Render(err, "failed to write the diagnostics of cluster %s", name)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package aws
+
+import (
+	"io"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/config"
+)
+
+// ExportCluster writes the parameters of the cluster named name to out as a cluster.yaml
+// file, without the values that the installer allocates for each cluster. The node pools
+// of the cluster have the current replicas of their machinesets, and the on-demand fallback
+// pools of spot pools are left out, since they are added again for the spot pools.
+func ExportCluster(name string, out io.Writer) error {
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
+	}
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+	params, err := installer.GetClusterParams(client, name)
+	if errors.IsNotFound(err) {
+		return installerrors.Precondition(err, "cluster %s was not installed with stored parameters and cannot be exported", name)
+	}
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain the parameters of cluster %s", name)
+	}
+	_, machineSets, err := workerMachineSets(dynamicClient, name)
+	if err != nil {
+		return err
+	}
+	exported := installer.ExportClusterParams(params)
+	exported.NodePools = exportNodePools(params.NodePools, machineSets)
+	if err = config.WriteTo(out, exported); err != nil {
+		return installerrors.Render(err, "failed to write the parameters of cluster %s", name)
+	}
+	return nil
+}
+
+// exportNodePools returns the node pools of a cluster with the replicas of their
+// machinesets, which are the replicas recorded by the hibernation of hibernated clusters.
+// On-demand fallback pools are left out.
+func exportNodePools(pools []api.NodePool, machineSets []*unstructured.Unstructured) []api.NodePool {
+	replicas := map[string]int{}
+	for _, machineSet := range machineSets {
+		pool := machineSet.GetLabels()[nodePoolLabel]
+		if len(pool) == 0 {
+			continue
+		}
+		count, _, _ := unstructured.NestedInt64(machineSet.Object, "spec", "replicas")
+		if value, ok := machineSet.GetAnnotations()[installer.HibernatedReplicasAnnotation]; ok {
+			count, _ = strconv.ParseInt(value, 10, 64)
+		}
+		replicas[pool] += int(count)
+	}
+	fallbackPools := map[string]bool{}
+	for _, pool := range pools {
+		if pool.SpotMarketOptions != nil && pool.SpotMarketOptions.OnDemandFallbackReplicas > 0 {
+			fallbackPools[onDemandFallbackPoolName(pool.Name)] = true
+		}
+	}
+	var exported []api.NodePool
+	for _, pool := range pools {
+		if fallbackPools[pool.Name] {
+			continue
+		}
+		if count, ok := replicas[pool.Name]; ok {
+			pool.Replicas = count
+		}
+		exported = append(exported, pool)
+	}
+	return exported
+}
@@ -0,0 +1,44 @@
+package aws
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestExportNodePools(t *testing.T) {
+	machineSet := func(pool string, replicas int64, hibernated string) *unstructured.Unstructured {
+		m := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		m.SetLabels(map[string]string{nodePoolLabel: pool})
+		if len(hibernated) > 0 {
+			m.SetAnnotations(map[string]string{installer.HibernatedReplicasAnnotation: hibernated})
+		}
+		unstructured.SetNestedField(m.Object, replicas, "spec", "replicas")
+		return m
+	}
+	pools := []api.NodePool{
+		{Name: "general", Replicas: 2},
+		{Name: "spot", Replicas: 3, SpotMarketOptions: &api.SpotMarketOptions{OnDemandFallbackReplicas: 1}},
+		{Name: "spot-on-demand", Replicas: 1},
+		{Name: "gpu", Replicas: 1},
+	}
+	machineSets := []*unstructured.Unstructured{
+		machineSet("general", 2, ""),
+		machineSet("general", 3, ""),
+		machineSet("spot", 0, "4"),
+		machineSet("spot-on-demand", 1, ""),
+	}
+	exported := exportNodePools(pools, machineSets)
+	expected := map[string]int{"general": 5, "spot": 4, "gpu": 1}
+	if len(exported) != len(expected) {
+		t.Fatalf("expected pools %v, got %+v", expected, exported)
+	}
+	for _, pool := range exported {
+		if replicas, ok := expected[pool.Name]; !ok || pool.Replicas != replicas {
+			t.Errorf("unexpected pool %s with %d replicas", pool.Name, pool.Replicas)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package aws
+
+import (
+	"io/ioutil"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/render"
+)
+
+const (
+	// APIServerFrontendNamespace is the namespace of the API server frontend of the
+	// management cluster
+	APIServerFrontendNamespace = "hypershift-apiserver-frontend"
+
+	// apiServerFrontendServiceName is the load balancer service of the frontend
+	apiServerFrontendServiceName = "apiserver-frontend"
+
+	// defaultAPIServerFrontendReplicas is the number of replicas of the frontend
+	defaultAPIServerFrontendReplicas = 2
+)
+
+// InstallAPIServerFrontend installs the API server frontend on the management cluster. The
+// frontend forwards the API, OAuth and ignition connections of the clusters that are
+// installed with a shared ingress to their control plane namespaces by the server name of
+// the connections, so that all of those clusters share its network load balancer instead
+// of getting load balancers of their own. It runs replicas of the control plane operator
+// image, which defaults to the image of the control plane operator of new clusters.
+func InstallAPIServerFrontend(image string, replicas int) error {
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
+	}
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+	_, parentDomain, err := installer.GetDNSZoneInfo(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain public zone information")
+	}
+	if len(image) == 0 {
+		image = os.Getenv("CONTROL_PLANE_OPERATOR_IMAGE_OVERRIDE")
+	}
+	if len(image) == 0 {
+		image = defaultControlPlaneOperatorImage
+	}
+	if replicas == 0 {
+		replicas = defaultAPIServerFrontendReplicas
+	}
+	if replicas < 0 {
+		return installerrors.Precondition(nil, "the number of replicas cannot be negative, got %d", replicas)
+	}
+
+	manifestsDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return installerrors.Render(err, "failed to create a temporary directory for the frontend manifests")
+	}
+	defer os.RemoveAll(manifestsDir)
+	params := &api.APIServerFrontendParams{
+		Namespace: APIServerFrontendNamespace,
+		Image:     image,
+		Replicas:  replicas,
+		Domain:    parentDomain,
+		ServiceAnnotations: map[string]string{
+			awsLoadBalancerTypeAnnotation: "nlb",
+		},
+	}
+	if err = render.RenderAPIServerFrontend(params, manifestsDir); err != nil {
+		return installerrors.Render(err, "failed to render the API server frontend manifests")
+	}
+	if err = installer.ApplyManifests(cfg, APIServerFrontendNamespace, manifestsDir, nil, ""); err != nil {
+		return installerrors.Apply(err, "failed to apply the API server frontend manifests")
+	}
+	lbDNS, err := installer.EnsureLoadBalancerService(client, APIServerFrontendNamespace, apiServerFrontendServiceName, params.ServiceAnnotations)
+	if err != nil {
+		return installerrors.Timeout(err, "the load balancer of the API server frontend was not provisioned")
+	}
+	log.Infof("The API server frontend for api.NAME.%s is reachable at %s", parentDomain, lbDNS)
+	return nil
+}
+
+// apiServerFrontendDNSName returns the name of the load balancer of the API server frontend
+// of the management cluster, or an empty string if the frontend is not installed or its load
+// balancer is not provisioned yet
+func apiServerFrontendDNSName(client kubeclient.Interface) (string, error) {
+	svc, err := client.CoreV1().Services(APIServerFrontendNamespace).Get(apiServerFrontendServiceName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return loadBalancerHostname(svc), nil
+}
+
+// loadBalancerHostname returns the name of the load balancer of a service, if any
+func loadBalancerHostname(svc *corev1.Service) string {
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if len(ingress.Hostname) > 0 {
+			return ingress.Hostname
+		}
+	}
+	return ""
+}
+
+// ensureSharedAPIEndpoint exposes the API, OAuth and ignition server through the API server
+// frontend of the management cluster, with a record of the API name of the cluster for the
+// load balancer of the frontend. No load balancer or elastic IP is created for them. The
+// address of the API endpoint is the first management cluster worker, where the workers of
+// the cluster reach the API service on its node port, since connections to an address
+// carry no server name that the frontend could forward them by.
+func (p *awsProvider) ensureSharedAPIEndpoint() (*installer.Endpoint, error) {
+	dnsName := p.dnsName("api")
+	if err := p.dnsProvider().EnsureCNameRecord(dnsName, p.sharedIngressDNSName); err != nil {
+		return nil, cloudProviderError(err, "cannot create API DNS record")
+	}
+	log.Infof("Created DNS record for API name %s with the API server frontend %s", dnsName, p.sharedIngressDNSName)
+	if err := p.helper.EnsureWorkersAllowNodePortAccess(p.securityGroup, p.network.VPCCIDR); err != nil {
+		return nil, cloudProviderError(err, "cannot setup security group for worker nodes")
+	}
+	log.Infof("Ensured that node ports on workers are accessible")
+	return &installer.Endpoint{DNSName: dnsName, Address: p.machineIPs[0]}, nil
+}
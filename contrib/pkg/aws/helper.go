@@ -6,39 +6,66 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/awsinfra"
 )
 
+// ClusterTagKey is the key of the tag that identifies the AWS resources of a hosted cluster.
+// Its value is the name of the cluster; resources are also tagged as owned by the
+// management cluster.
+const ClusterTagKey = "hypershift.openshift.io/hosted-cluster"
+
 type LBInfo struct {
 	VPC    string
 	Zone   string
 	Subnet string
+
+	// VPCCIDR is the CIDR of the VPC, if known
+	VPCCIDR string
+
+	// Zones and Subnets list all zones that contain worker machines and their
+	// subnets, starting with Zone and Subnet
+	Zones   []string
+	Subnets []string
 }
 
 type AWSHelper struct {
 	elbClient     *elbv2.ELBV2
 	ec2Client     *ec2.EC2
+	iamClient     *iam.IAM
 	route53Client *route53.Route53
 	s3Client      *s3.S3
 	s3Uploader    *s3manager.Uploader
 	infraName     string
+
+	// clusterName is the name of the hosted cluster, that the resources created by the
+	// helper are tagged with
+	clusterName string
 }
 
 // NewAWSHelper creates an instance of the AWS helper with clients for each of the required services
-func NewAWSHelper(key string, secret string, region string, infraName string) (*AWSHelper, error) {
+// that manages the resources of the hosted cluster clusterName
+func NewAWSHelper(creds *credentials.Credentials, region, infraName, clusterName string) (*AWSHelper, error) {
 	awsConfig := &aws.Config{
 		Region:      aws.String(region),
-		Credentials: credentials.NewStaticCredentials(key, secret, ""),
+		Credentials: creds,
 	}
 	s, err := session.NewSession(awsConfig)
 	if err != nil {
@@ -47,15 +74,17 @@ func NewAWSHelper(key string, secret string, region string, infraName string) (*
 	return &AWSHelper{
 		elbClient:     elbv2.New(s),
 		ec2Client:     ec2.New(s),
+		iamClient:     iam.New(s),
 		route53Client: route53.New(s),
 		s3Client:      s3.New(s),
 		s3Uploader:    s3manager.NewUploader(s),
 		infraName:     infraName,
+		clusterName:   clusterName,
 	}, nil
 }
 
-// LoadBalancerInfo returns load balancer information for one of the zones that
-// contains worker machines
+// LoadBalancerInfo returns load balancer information for the zones that
+// contain worker machines
 func (h *AWSHelper) LoadBalancerInfo(machineNames []string) (*LBInfo, error) {
 	result := &LBInfo{}
 	output, err := h.elbClient.DescribeLoadBalancers(&elbv2.DescribeLoadBalancersInput{
@@ -65,32 +94,83 @@ func (h *AWSHelper) LoadBalancerInfo(machineNames []string) (*LBInfo, error) {
 		return nil, err
 	}
 	if len(output.LoadBalancers) == 0 {
-		return nil, fmt.Errorf("no load balancers found")
+		return nil, errors.New("no load balancers found")
 	}
 	lb := output.LoadBalancers[0]
 	result.VPC = aws.StringValue(lb.VpcId)
 
-	found := false
 	for _, az := range lb.AvailabilityZones {
 		zoneName := aws.StringValue(az.ZoneName)
-		for _, m := range machineNames {
-			if strings.HasPrefix(m, fmt.Sprintf("%s-worker-%s", h.infraName, zoneName)) {
-				found = true
-				result.Zone = zoneName
-				result.Subnet = aws.StringValue(az.SubnetId)
-				break
-			}
+		if h.hasWorkers(zoneName, machineNames) {
+			result.Zones = append(result.Zones, zoneName)
+			result.Subnets = append(result.Subnets, aws.StringValue(az.SubnetId))
 		}
-		if found {
-			break
+	}
+	if len(result.Zones) == 0 {
+		return nil, errors.New("cannot find a suitable zone with workers in it")
+	}
+	result.Zone = result.Zones[0]
+	result.Subnet = result.Subnets[0]
+	return result, nil
+}
+
+// NetworkInfo returns load balancer information for an existing VPC and subnets, keeping
+// the subnets in zones that contain worker machines
+func (h *AWSHelper) NetworkInfo(vpc string, subnetIDs []string, machineNames []string) (*LBInfo, error) {
+	result := &LBInfo{VPC: vpc}
+	vpcOutput, err := h.ec2Client.DescribeVpcs(&ec2.DescribeVpcsInput{
+		VpcIds: []*string{aws.String(vpc)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(vpcOutput.Vpcs) == 0 {
+		return nil, errors.Errorf("VPC %s not found", vpc)
+	}
+	result.VPCCIDR = aws.StringValue(vpcOutput.Vpcs[0].CidrBlock)
+
+	output, err := h.ec2Client.DescribeSubnets(&ec2.DescribeSubnetsInput{
+		SubnetIds: aws.StringSlice(subnetIDs),
+	})
+	if err != nil {
+		return nil, err
+	}
+	subnets := map[string]*ec2.Subnet{}
+	for _, subnet := range output.Subnets {
+		subnets[aws.StringValue(subnet.SubnetId)] = subnet
+	}
+	for _, id := range subnetIDs {
+		subnet, ok := subnets[id]
+		if !ok {
+			return nil, errors.Errorf("subnet %s not found", id)
+		}
+		if aws.StringValue(subnet.VpcId) != vpc {
+			return nil, errors.Errorf("subnet %s is not in VPC %s", id, vpc)
+		}
+		zoneName := aws.StringValue(subnet.AvailabilityZone)
+		if h.hasWorkers(zoneName, machineNames) {
+			result.Zones = append(result.Zones, zoneName)
+			result.Subnets = append(result.Subnets, id)
 		}
 	}
-	if !found {
-		return nil, fmt.Errorf("cannot find a suitable zone with workers in it")
+	if len(result.Zones) == 0 {
+		return nil, errors.New("none of the subnets is in a zone with workers in it")
 	}
+	result.Zone = result.Zones[0]
+	result.Subnet = result.Subnets[0]
 	return result, nil
 }
 
+// hasWorkers returns whether one of the machines is a worker of the management cluster in the zone
+func (h *AWSHelper) hasWorkers(zoneName string, machineNames []string) bool {
+	for _, m := range machineNames {
+		if strings.HasPrefix(m, fmt.Sprintf("%s-worker-%s", h.infraName, zoneName)) {
+			return true
+		}
+	}
+	return false
+}
+
 // EnsureEIP ensures that an EIP is allocated with the given name
 func (h *AWSHelper) EnsureEIP(name string) (string, string, error) {
 	allocID := ""
@@ -125,6 +205,7 @@ func (h *AWSHelper) EnsureEIP(name string) (string, string, error) {
 				Value: aws.String(name),
 			},
 			ownedTag(h.infraName),
+			clusterTag(h.clusterName),
 		},
 	})
 	if err != nil {
@@ -134,18 +215,18 @@ func (h *AWSHelper) EnsureEIP(name string) (string, string, error) {
 	return allocID, addressIP, nil
 }
 
-func (h *AWSHelper) RemoveEIP(name string) error {
+// RemoveEIP releases an elastic IP, waiting for it to be disassociated from the load
+// balancer it was attached to
+func (h *AWSHelper) RemoveEIP(allocationID string) error {
 	notFound := false
-	allocationID := ""
 	err := wait.PollImmediate(15*time.Second, 4*time.Minute, func() (bool, error) {
 		output, err := h.ec2Client.DescribeAddresses(&ec2.DescribeAddressesInput{
-			Filters: []*ec2.Filter{
-				{
-					Name:   aws.String("tag:Name"),
-					Values: []*string{aws.String(name)},
-				},
-			},
+			AllocationIds: []*string{aws.String(allocationID)},
 		})
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "InvalidAllocationID.NotFound" {
+			notFound = true
+			return true, nil
+		}
 		if err != nil {
 			return false, err
 		}
@@ -153,12 +234,7 @@ func (h *AWSHelper) RemoveEIP(name string) error {
 			notFound = true
 			return true, nil
 		}
-		address := output.Addresses[0]
-		allocationID = aws.StringValue(address.AllocationId)
-		if address.NetworkInterfaceId == nil || aws.StringValue(address.NetworkInterfaceId) == "" {
-			return true, nil
-		}
-		return false, nil
+		return aws.StringValue(output.Addresses[0].NetworkInterfaceId) == "", nil
 	})
 	if err != nil {
 		return err
@@ -166,18 +242,16 @@ func (h *AWSHelper) RemoveEIP(name string) error {
 	if notFound {
 		return nil
 	}
-	if allocationID == "" {
-		return fmt.Errorf("did not find allocation ID for EIP %s", name)
-	}
 	_, err = h.ec2Client.ReleaseAddress(&ec2.ReleaseAddressInput{
 		AllocationId: aws.String(allocationID),
 	})
 	return err
 }
 
-// EnsureNLB ensures that a network load balancer exists with the given subnet. If an EIP allocation
-// ID is passed, it assigns it to the NLB subnet mappings.
-func (h *AWSHelper) EnsureNLB(nlbName, subnet, eipAllocID string) (string, string, error) {
+// EnsureNLB ensures that a network load balancer exists with the given subnets. If an EIP allocation
+// ID is passed, it assigns it to the mapping of the first subnet. An internal load balancer is only
+// reachable from within the VPC.
+func (h *AWSHelper) EnsureNLB(nlbName string, subnets []string, eipAllocID string, internal bool) (string, string, error) {
 	output, err := h.elbClient.DescribeLoadBalancers(&elbv2.DescribeLoadBalancersInput{
 		Names: []*string{aws.String(nlbName)},
 	})
@@ -197,23 +271,31 @@ func (h *AWSHelper) EnsureNLB(nlbName, subnet, eipAllocID string) (string, strin
 		return aws.StringValue(lb.LoadBalancerArn), aws.StringValue(lb.DNSName), nil
 	}
 
+	scheme := elbv2.LoadBalancerSchemeEnumInternetFacing
+	if internal {
+		scheme = elbv2.LoadBalancerSchemeEnumInternal
+	}
 	input := &elbv2.CreateLoadBalancerInput{
 		Name:   aws.String(nlbName),
-		Scheme: aws.String(elbv2.LoadBalancerSchemeEnumInternetFacing),
+		Scheme: aws.String(scheme),
 		Type:   aws.String(elbv2.LoadBalancerTypeEnumNetwork),
 		Tags: []*elbv2.Tag{
 			ownedLBTag(h.infraName),
+			clusterLBTag(h.clusterName),
 		},
 	}
 	if len(eipAllocID) > 0 {
-		input.SubnetMappings = []*elbv2.SubnetMapping{
-			{
-				SubnetId:     aws.String(subnet),
-				AllocationId: aws.String(eipAllocID),
-			},
+		for i, subnet := range subnets {
+			mapping := &elbv2.SubnetMapping{
+				SubnetId: aws.String(subnet),
+			}
+			if i == 0 {
+				mapping.AllocationId = aws.String(eipAllocID)
+			}
+			input.SubnetMappings = append(input.SubnetMappings, mapping)
 		}
 	} else {
-		input.Subnets = []*string{aws.String(subnet)}
+		input.Subnets = aws.StringSlice(subnets)
 	}
 	nlbResult, err := h.elbClient.CreateLoadBalancer(input)
 	if err != nil {
@@ -223,27 +305,52 @@ func (h *AWSHelper) EnsureNLB(nlbName, subnet, eipAllocID string) (string, strin
 	return aws.StringValue(lb.LoadBalancerArn), aws.StringValue(lb.DNSName), nil
 }
 
-// RemoveNLB removes an existing load balancer
-func (h *AWSHelper) RemoveNLB(nlbName string) error {
-	output, err := h.elbClient.DescribeLoadBalancers(&elbv2.DescribeLoadBalancersInput{
-		Names: []*string{aws.String(nlbName)},
-	})
-	notFound := false
-	if err != nil {
-		if awsErr, ok := err.(awserr.Error); ok {
-			if awsErr.Code() == elbv2.ErrCodeLoadBalancerNotFoundException {
-				notFound = true
-			}
+// LoadBalancerPrivateIP returns the private IP address of the network interface of a load
+// balancer in the given subnet, waiting for the interface to be created
+func (h *AWSHelper) LoadBalancerPrivateIP(lbARN, subnet string) (string, error) {
+	// Network interfaces of a load balancer are described as "ELB net/<name>/<id>"
+	parts := strings.SplitN(lbARN, ":loadbalancer/", 2)
+	if len(parts) != 2 {
+		return "", errors.Errorf("unexpected load balancer ARN %s", lbARN)
+	}
+	description := fmt.Sprintf("ELB %s", parts[1])
+	address := ""
+	err := wait.PollImmediate(10*time.Second, 5*time.Minute, func() (bool, error) {
+		output, err := h.ec2Client.DescribeNetworkInterfaces(&ec2.DescribeNetworkInterfacesInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("description"),
+					Values: []*string{aws.String(description)},
+				},
+				{
+					Name:   aws.String("subnet-id"),
+					Values: []*string{aws.String(subnet)},
+				},
+			},
+		})
+		if err != nil {
+			return false, err
 		}
-		if notFound {
-			return nil
+		if len(output.NetworkInterfaces) == 0 {
+			return false, nil
 		}
-		return err
+		address = aws.StringValue(output.NetworkInterfaces[0].PrivateIpAddress)
+		return len(address) > 0, nil
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot find the private IP address of load balancer %s", lbARN)
 	}
-	arn := aws.StringValue(output.LoadBalancers[0].LoadBalancerArn)
-	_, err = h.elbClient.DeleteLoadBalancer(&elbv2.DeleteLoadBalancerInput{
-		LoadBalancerArn: &arn,
+	return address, nil
+}
+
+// RemoveNLB removes an existing load balancer by ARN
+func (h *AWSHelper) RemoveNLB(lbARN string) error {
+	_, err := h.elbClient.DeleteLoadBalancer(&elbv2.DeleteLoadBalancerInput{
+		LoadBalancerArn: aws.String(lbARN),
 	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == elbv2.ErrCodeLoadBalancerNotFoundException {
+		return nil
+	}
 	return err
 }
 
@@ -291,59 +398,67 @@ func (h *AWSHelper) EnsureTargetGroup(vpc, tgName string, port int) (string, err
 	if err != nil {
 		return "", err
 	}
-	return aws.StringValue(tgResult.TargetGroups[0].TargetGroupArn), nil
+	return h.tagTargetGroup(tgResult.TargetGroups[0].TargetGroupArn)
 }
 
-func (h *AWSHelper) EnsureTarget(targetGroupARN string, targetID string) error {
+// EnsureTargets ensures that the targets of a target group are exactly the given targets,
+// registering missing targets and deregistering any other target
+func (h *AWSHelper) EnsureTargets(targetGroupARN string, targetIDs []string) error {
 	output, err := h.elbClient.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{
 		TargetGroupArn: aws.String(targetGroupARN),
 	})
 	if err != nil {
 		return err
 	}
+	desired := sets.NewString(targetIDs...)
+	registered := sets.NewString()
+	stale := []*elbv2.TargetDescription{}
 	for _, hd := range output.TargetHealthDescriptions {
-		if aws.StringValue(hd.Target.Id) == targetID {
-			return nil
+		id := aws.StringValue(hd.Target.Id)
+		registered.Insert(id)
+		if !desired.Has(id) {
+			stale = append(stale, &elbv2.TargetDescription{Id: hd.Target.Id})
 		}
+	}
+	if len(stale) > 0 {
 		_, err := h.elbClient.DeregisterTargets(&elbv2.DeregisterTargetsInput{
 			TargetGroupArn: aws.String(targetGroupARN),
-			Targets:        []*elbv2.TargetDescription{{Id: hd.Target.Id}},
+			Targets:        stale,
 		})
 		if err != nil {
 			return err
 		}
 	}
+	missing := []*elbv2.TargetDescription{}
+	for _, id := range desired.Difference(registered).List() {
+		missing = append(missing, &elbv2.TargetDescription{Id: aws.String(id)})
+	}
+	if len(missing) == 0 {
+		return nil
+	}
 	_, err = h.elbClient.RegisterTargets(&elbv2.RegisterTargetsInput{
 		TargetGroupArn: aws.String(targetGroupARN),
-		Targets: []*elbv2.TargetDescription{
-			{
-				Id: aws.String(targetID),
-			},
-		},
+		Targets:        missing,
 	})
 	return err
 }
 
-// RemoveTargetGroup removes a target group by name
-func (h *AWSHelper) RemoveTargetGroup(tgName string) error {
-	output, err := h.elbClient.DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{
-		Names: []*string{aws.String(tgName)},
-	})
-	notFound := false
-	if err != nil {
+// RemoveTargetGroup removes a target group by ARN. A target group cannot be removed while
+// the listeners of a load balancer that is being removed still forward to it.
+func (h *AWSHelper) RemoveTargetGroup(tgARN string) error {
+	err := wait.PollImmediate(5*time.Second, 2*time.Minute, func() (bool, error) {
+		_, err := h.elbClient.DeleteTargetGroup(&elbv2.DeleteTargetGroupInput{
+			TargetGroupArn: aws.String(tgARN),
+		})
 		if awsErr, ok := err.(awserr.Error); ok {
-			if awsErr.Code() == elbv2.ErrCodeTargetGroupNotFoundException {
-				notFound = true
+			switch awsErr.Code() {
+			case elbv2.ErrCodeTargetGroupNotFoundException:
+				return true, nil
+			case elbv2.ErrCodeResourceInUseException:
+				return false, nil
 			}
 		}
-		if notFound {
-			return nil
-		}
-		return err
-	}
-	tgARN := aws.StringValue(output.TargetGroups[0].TargetGroupArn)
-	_, err = h.elbClient.DeleteTargetGroup(&elbv2.DeleteTargetGroupInput{
-		TargetGroupArn: aws.String(tgARN),
+		return err == nil, err
 	})
 	return err
 }
@@ -393,7 +508,20 @@ func (h *AWSHelper) EnsureUDPTargetGroup(vpc, tgName string, port, healthCheckPo
 	if err != nil {
 		return "", err
 	}
-	return aws.StringValue(tgResult.TargetGroups[0].TargetGroupArn), nil
+	return h.tagTargetGroup(tgResult.TargetGroups[0].TargetGroupArn)
+}
+
+// tagTargetGroup tags a new target group as a resource of the cluster and returns its ARN.
+// Target groups cannot be tagged when they are created.
+func (h *AWSHelper) tagTargetGroup(arn *string) (string, error) {
+	_, err := h.elbClient.AddTags(&elbv2.AddTagsInput{
+		ResourceArns: []*string{arn},
+		Tags:         []*elbv2.Tag{ownedLBTag(h.infraName), clusterLBTag(h.clusterName)},
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to tag target group %s", aws.StringValue(arn))
+	}
+	return aws.StringValue(arn), nil
 }
 
 func (h *AWSHelper) EnsureListener(lbARN, tgARN string, port int, udp bool) error {
@@ -506,20 +634,108 @@ func (h *AWSHelper) RemoveCNameRecord(zoneID, dnsName string) error {
 	return nil
 }
 
-func (h *AWSHelper) EnsureWorkersAllowNodePortAccess() error {
-	result, err := h.ec2Client.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{
+// FindPrivateZone returns the id of the private hosted zone with the given domain name, or an
+// empty string if it does not exist
+func (h *AWSHelper) FindPrivateZone(domain string) (string, error) {
+	output, err := h.route53Client.ListHostedZonesByName(&route53.ListHostedZonesByNameInput{
+		DNSName: aws.String(domain),
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, zone := range output.HostedZones {
+		if aws.StringValue(zone.Name) != fqdn(domain) {
+			continue
+		}
+		if zone.Config != nil && aws.BoolValue(zone.Config.PrivateZone) {
+			return strings.TrimPrefix(aws.StringValue(zone.Id), "/hostedzone/"), nil
+		}
+	}
+	return "", nil
+}
+
+// EnsurePrivateZone ensures that a private hosted zone with the given domain name exists and
+// is associated with the VPC
+func (h *AWSHelper) EnsurePrivateZone(domain, vpc string) (string, error) {
+	zoneID, err := h.FindPrivateZone(domain)
+	if err != nil || len(zoneID) > 0 {
+		return zoneID, err
+	}
+	output, err := h.route53Client.CreateHostedZone(&route53.CreateHostedZoneInput{
+		Name:            aws.String(domain),
+		CallerReference: aws.String(fmt.Sprintf("%s-%d", domain, time.Now().Unix())),
+		HostedZoneConfig: &route53.HostedZoneConfig{
+			Comment:     aws.String(fmt.Sprintf("Private zone of hosted cluster %s", domain)),
+			PrivateZone: aws.Bool(true),
+		},
+		VPC: &route53.VPC{
+			VPCId:     aws.String(vpc),
+			VPCRegion: h.ec2Client.Config.Region,
+		},
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create private zone %s", domain)
+	}
+	zoneID = strings.TrimPrefix(aws.StringValue(output.HostedZone.Id), "/hostedzone/")
+	_, err = h.route53Client.ChangeTagsForResource(&route53.ChangeTagsForResourceInput{
+		ResourceType: aws.String(route53.TagResourceTypeHostedzone),
+		ResourceId:   aws.String(zoneID),
+		AddTags: []*route53.Tag{
+			{
+				Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", h.infraName)),
+				Value: aws.String("owned"),
+			},
+			{
+				Key:   aws.String(ClusterTagKey),
+				Value: aws.String(h.clusterName),
+			},
+		},
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to tag private zone %s", domain)
+	}
+	return zoneID, nil
+}
+
+// RemovePrivateZone removes a private hosted zone. Its records other than the SOA and NS
+// records must have been removed.
+func (h *AWSHelper) RemovePrivateZone(zoneID string) error {
+	_, err := h.route53Client.DeleteHostedZone(&route53.DeleteHostedZoneInput{
+		Id: aws.String(zoneID),
+	})
+	if awsErr, ok := err.(awserr.Error); ok {
+		if awsErr.Code() == route53.ErrCodeNoSuchHostedZone {
+			return nil
+		}
+	}
+	return err
+}
+
+// EnsureWorkersAllowNodePortAccess ensures that the security group of the workers allows
+// access to node ports from the VPC CIDR over TCP and from anywhere over UDP. If no security
+// group ID is passed, the workers security group of the management cluster is used, and if
+// no VPC CIDR is passed, the default CIDR of the management cluster's VPC.
+func (h *AWSHelper) EnsureWorkersAllowNodePortAccess(securityGroupID, vpcCIDR string) error {
+	input := &ec2.DescribeSecurityGroupsInput{
 		Filters: []*ec2.Filter{
 			{
 				Name:   aws.String("tag:Name"),
 				Values: []*string{aws.String(fmt.Sprintf("%s-worker-sg", h.infraName))},
 			},
 		},
-	})
+	}
+	if len(securityGroupID) > 0 {
+		input = &ec2.DescribeSecurityGroupsInput{GroupIds: []*string{aws.String(securityGroupID)}}
+	}
+	if len(vpcCIDR) == 0 {
+		vpcCIDR = "10.0.0.0/16"
+	}
+	result, err := h.ec2Client.DescribeSecurityGroups(input)
 	if err != nil {
 		return err
 	}
 	if len(result.SecurityGroups) == 0 {
-		return fmt.Errorf("could not find the workers security group")
+		return errors.New("could not find the workers security group")
 	}
 	sg := result.SecurityGroups[0]
 	foundTCPRule := false
@@ -528,7 +744,7 @@ func (h *AWSHelper) EnsureWorkersAllowNodePortAccess() error {
 		if aws.Int64Value(permission.FromPort) == 30000 && aws.Int64Value(permission.ToPort) == 32767 {
 			if aws.StringValue(permission.IpProtocol) == "tcp" {
 				for _, ipRange := range permission.IpRanges {
-					if aws.StringValue(ipRange.CidrIp) == "10.0.0.0/16" {
+					if aws.StringValue(ipRange.CidrIp) == vpcCIDR {
 						foundTCPRule = true
 						break
 					}
@@ -553,7 +769,7 @@ func (h *AWSHelper) EnsureWorkersAllowNodePortAccess() error {
 			IpProtocol: aws.String("tcp"),
 			FromPort:   aws.Int64(30000),
 			ToPort:     aws.Int64(32767),
-			CidrIp:     aws.String("10.0.0.0/16"),
+			CidrIp:     aws.String(vpcCIDR),
 		})
 		if err != nil {
 			return err
@@ -575,9 +791,35 @@ func (h *AWSHelper) EnsureWorkersAllowNodePortAccess() error {
 	return nil
 }
 
-// EnsureIgnitionBucket ensures that a bucket with the given name exists and that it contains
-// a file with the contents of the ignition filename passed.
+// EnsureIgnitionBucket ensures that a private bucket with the given name exists and that it
+// contains a file with the contents of the ignition filename passed. The bucket blocks public
+// access and its objects are encrypted at rest, so the file can only be fetched with
+// credentials or a pre-signed URL.
 func (h *AWSHelper) EnsureIgnitionBucket(name, fileName string) error {
+	if err := h.EnsurePrivateBucket(name); err != nil {
+		return err
+	}
+	ign, err := os.Open(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "cannot open ignition file %s", fileName)
+	}
+	defer ign.Close()
+	_, err = h.s3Uploader.Upload(&s3manager.UploadInput{
+		ACL:                  aws.String(s3.ObjectCannedACLPrivate),
+		Bucket:               aws.String(name),
+		Key:                  aws.String("worker.ign"),
+		Body:                 ign,
+		ServerSideEncryption: aws.String(s3.ServerSideEncryptionAes256),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to upload ignition file")
+	}
+	return nil
+}
+
+// EnsurePrivateBucket ensures that a bucket with the given name exists, that it blocks public
+// access and encrypts its objects at rest, and that it is tagged for the cluster
+func (h *AWSHelper) EnsurePrivateBucket(name string) error {
 	_, err := h.s3Client.GetBucketLocation(&s3.GetBucketLocationInput{
 		Bucket: aws.String(name),
 	})
@@ -585,43 +827,66 @@ func (h *AWSHelper) EnsureIgnitionBucket(name, fileName string) error {
 		// Bucket likely doesn't exist, create it
 		_, err = h.s3Client.CreateBucket(&s3.CreateBucketInput{
 			Bucket: aws.String(name),
-			ACL:    aws.String("public-read"),
+			ACL:    aws.String(s3.BucketCannedACLPrivate),
 		})
 		if err != nil {
-			return fmt.Errorf("failed to create bucket %s: %v", name, err)
+			return errors.Wrapf(err, "failed to create bucket %s", name)
 		}
 	}
-	_, err = h.s3Client.PutBucketTagging(&s3.PutBucketTaggingInput{
+	_, err = h.s3Client.PutPublicAccessBlock(&s3.PutPublicAccessBlockInput{
 		Bucket: aws.String(name),
-		Tagging: &s3.Tagging{
-			TagSet: []*s3.Tag{
+		PublicAccessBlockConfiguration: &s3.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       aws.Bool(true),
+			BlockPublicPolicy:     aws.Bool(true),
+			IgnorePublicAcls:      aws.Bool(true),
+			RestrictPublicBuckets: aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to block public access to bucket %s", name)
+	}
+	_, err = h.s3Client.PutBucketEncryption(&s3.PutBucketEncryptionInput{
+		Bucket: aws.String(name),
+		ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
+			Rules: []*s3.ServerSideEncryptionRule{
 				{
-					Key:   aws.String(fmt.Sprintf("kubernetes/cluster/%s", h.infraName)),
-					Value: aws.String("owned"),
+					ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
+						SSEAlgorithm: aws.String(s3.ServerSideEncryptionAes256),
+					},
 				},
 			},
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("failed to tag bucket %s: %v", name, err)
-	}
-	ign, err := os.Open(fileName)
-	if err != nil {
-		return fmt.Errorf("cannot open ignition file %s: %v", fileName, err)
+		return errors.Wrapf(err, "failed to enable encryption of bucket %s", name)
 	}
-	defer ign.Close()
-	_, err = h.s3Uploader.Upload(&s3manager.UploadInput{
-		ACL:    aws.String("public-read"),
+	_, err = h.s3Client.PutBucketTagging(&s3.PutBucketTaggingInput{
 		Bucket: aws.String(name),
-		Key:    aws.String("worker.ign"),
-		Body:   ign,
+		Tagging: &s3.Tagging{
+			TagSet: []*s3.Tag{
+				{
+					Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", h.infraName)),
+					Value: aws.String("owned"),
+				},
+				{
+					Key:   aws.String(ClusterTagKey),
+					Value: aws.String(h.clusterName),
+				},
+			},
+		},
 	})
 	if err != nil {
-		return fmt.Errorf("failed to upload ignition file: %v", err)
+		return errors.Wrapf(err, "failed to tag bucket %s", name)
 	}
 	return nil
 }
 
+// PresignIgnitionURL returns a URL of the ignition file in the given bucket that expires
+// after awsinfra.IgnitionURLExpiry
+func (h *AWSHelper) PresignIgnitionURL(name string) (string, error) {
+	return awsinfra.PresignIgnitionURL(h.s3Client, name, "worker.ign")
+}
+
 func (h *AWSHelper) RemoveIgnitionBucket(name string) error {
 	var deleteErr error
 	_, err := h.s3Client.GetBucketLocation(&s3.GetBucketLocationInput{
@@ -663,6 +928,14 @@ func (h *AWSHelper) RemoveIgnitionBucket(name string) error {
 
 }
 
+// fqdn returns the fully qualified form of a DNS name, with a trailing dot
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
 func ownedTag(infraName string) *ec2.Tag {
 	return &ec2.Tag{
 		Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", infraName)),
@@ -676,3 +949,25 @@ func ownedLBTag(infraName string) *elbv2.Tag {
 		Value: aws.String("owned"),
 	}
 }
+
+func clusterTag(clusterName string) *ec2.Tag {
+	return &ec2.Tag{
+		Key:   aws.String(ClusterTagKey),
+		Value: aws.String(clusterName),
+	}
+}
+
+func clusterLBTag(clusterName string) *elbv2.Tag {
+	return &elbv2.Tag{
+		Key:   aws.String(ClusterTagKey),
+		Value: aws.String(clusterName),
+	}
+}
+
+// cloudProviderError wraps an error returned by the AWS API, flagging it as
+// retryable if the AWS SDK considers it a transient failure.
+func cloudProviderError(err error, format string, args ...interface{}) error {
+	cause := errors.Cause(err)
+	retryable := request.IsErrorRetryable(cause) || request.IsErrorThrottle(cause)
+	return installerrors.CloudProvider(err, retryable, format, args...)
+}
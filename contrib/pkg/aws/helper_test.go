@@ -0,0 +1,242 @@
+package aws
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/pkg/errors"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestCloudProviderErrorRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "throttled", err: awserr.New("Throttling", "rate exceeded", nil), expected: true},
+		{name: "wrapped throttled", err: errors.Wrap(awserr.New("Throttling", "rate exceeded", nil), "failed to create bucket"), expected: true},
+		{name: "wrapped transient", err: errors.Wrap(awserr.New("RequestError", "send request failed", nil), "failed to upload ignition file"), expected: true},
+		{name: "access denied", err: errors.Wrap(awserr.New("AccessDenied", "denied", nil), "failed to tag bucket"), expected: false},
+		{name: "untyped", err: fmt.Errorf("failed"), expected: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := cloudProviderError(test.err, "cannot create ignition bucket")
+			if actual := installerrors.IsRetryable(err); actual != test.expected {
+				t.Errorf("expected retryable %t, got %t", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMachineSetReplicas(t *testing.T) {
+	tests := []struct {
+		total    int
+		count    int
+		expected []int
+	}{
+		{total: 3, count: 1, expected: []int{3}},
+		{total: 3, count: 3, expected: []int{1, 1, 1}},
+		{total: 5, count: 3, expected: []int{2, 2, 1}},
+		{total: 1, count: 3, expected: []int{1, 0, 0}},
+		{total: 0, count: 2, expected: []int{0, 0}},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d across %d", test.total, test.count), func(t *testing.T) {
+			for i, expected := range test.expected {
+				if actual := machineSetReplicas(test.total, test.count, i); actual != expected {
+					t.Errorf("expected %d replicas for machineset %d, got %d", expected, i, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestSetWorkerProviderSpec(t *testing.T) {
+	machineSet := func(blockDevices ...interface{}) map[string]interface{} {
+		value := map[string]interface{}{"instanceType": "m4.large"}
+		if len(blockDevices) > 0 {
+			value["blockDevices"] = blockDevices
+		}
+		return map[string]interface{}{
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"providerSpec": map[string]interface{}{"value": value},
+					},
+				},
+			},
+		}
+	}
+	rootDevice := func(size int64) map[string]interface{} {
+		return map[string]interface{}{"ebs": map[string]interface{}{"volumeSize": size, "volumeType": "gp2"}}
+	}
+	dataDevice := map[string]interface{}{"deviceName": "/dev/xvdb", "ebs": map[string]interface{}{"volumeSize": int64(500)}}
+	tests := []struct {
+		name                 string
+		object               map[string]interface{}
+		workers              WorkerConfig
+		expectedInstanceType string
+		expectedDevices      []interface{}
+		expectedAMI          interface{}
+	}{
+		{
+			name:                 "defaults",
+			object:               machineSet(rootDevice(120)),
+			expectedInstanceType: "m4.large",
+			expectedDevices:      []interface{}{rootDevice(120)},
+		},
+		{
+			name:                 "instance type and root volume",
+			object:               machineSet(rootDevice(120), dataDevice),
+			workers:              WorkerConfig{InstanceType: "m5.2xlarge", RootVolumeSize: 250},
+			expectedInstanceType: "m5.2xlarge",
+			expectedDevices:      []interface{}{rootDevice(250), dataDevice},
+		},
+		{
+			name:                 "root volume without block devices",
+			object:               machineSet(),
+			workers:              WorkerConfig{RootVolumeSize: 200},
+			expectedInstanceType: "m4.large",
+			expectedDevices:      []interface{}{rootDevice(200)},
+		},
+		{
+			name:                 "arm64 AMI",
+			object:               machineSet(rootDevice(120)),
+			workers:              WorkerConfig{InstanceType: "m6g.xlarge", AMI: "ami-0123"},
+			expectedInstanceType: "m6g.xlarge",
+			expectedDevices:      []interface{}{rootDevice(120)},
+			expectedAMI:          map[string]interface{}{"id": "ami-0123"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := setWorkerProviderSpec(test.object, test.workers); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			value := test.object["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})["providerSpec"].(map[string]interface{})["value"].(map[string]interface{})
+			if value["instanceType"] != test.expectedInstanceType {
+				t.Errorf("expected instance type %s, got %v", test.expectedInstanceType, value["instanceType"])
+			}
+			if !reflect.DeepEqual(value["blockDevices"], test.expectedDevices) {
+				t.Errorf("expected block devices %v, got %v", test.expectedDevices, value["blockDevices"])
+			}
+			if !reflect.DeepEqual(value["ami"], test.expectedAMI) {
+				t.Errorf("expected AMI %v, got %v", test.expectedAMI, value["ami"])
+			}
+		})
+	}
+}
+
+func TestWorkerConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		workers     WorkerConfig
+		expectError bool
+	}{
+		{name: "defaults", workers: WorkerConfig{Count: DefaultWorkerCount}},
+		{name: "custom", workers: WorkerConfig{Count: 10, InstanceType: "m5.xlarge", RootVolumeSize: 200}},
+		{name: "no workers", workers: WorkerConfig{}, expectError: true},
+		{name: "negative root volume size", workers: WorkerConfig{Count: 3, RootVolumeSize: -1}, expectError: true},
+		{name: "kubevirt", workers: WorkerConfig{Count: 3, KubeVirt: &KubeVirtConfig{Image: "rhcos", Memory: "8Gi"}}},
+		{name: "kubevirt without image", workers: WorkerConfig{Count: 3, KubeVirt: &KubeVirtConfig{}}, expectError: true},
+		{name: "kubevirt invalid memory", workers: WorkerConfig{Count: 3, KubeVirt: &KubeVirtConfig{Image: "rhcos", Memory: "lots"}}, expectError: true},
+		{name: "arm64", workers: WorkerConfig{Count: 3, Arch: "arm64", InstanceType: "m6g.xlarge", AMI: "ami-0123"}},
+		{name: "arm64 without AMI", workers: WorkerConfig{Count: 3, Arch: "arm64", InstanceType: "m6g.xlarge"}},
+		{name: "arm64 without instance type", workers: WorkerConfig{Count: 3, Arch: "arm64", AMI: "ami-0123"}, expectError: true},
+		{name: "unsupported arch", workers: WorkerConfig{Count: 3, Arch: "mips"}, expectError: true},
+		{name: "kubevirt arm64", workers: WorkerConfig{Count: 3, Arch: "arm64", InstanceType: "m6g.xlarge", AMI: "ami-0123", KubeVirt: &KubeVirtConfig{Image: "rhcos"}}, expectError: true},
+		{name: "kubevirt spot pool", workers: WorkerConfig{NodePools: []api.NodePool{{Name: "spot", Replicas: 1, SpotMarketOptions: &api.SpotMarketOptions{}}}, KubeVirt: &KubeVirtConfig{Image: "rhcos"}}, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.workers.validate(); test.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", test.expectError, err)
+			}
+		})
+	}
+}
+
+func TestNetworkConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		network     NetworkConfig
+		expectError bool
+	}{
+		{name: "defaults", network: NetworkConfig{}},
+		{name: "existing VPC", network: NetworkConfig{VPC: "vpc-1", Subnets: []string{"subnet-1", "subnet-2"}, SecurityGroup: "sg-1"}},
+		{name: "security group only", network: NetworkConfig{SecurityGroup: "sg-1"}},
+		{name: "VPC without subnets", network: NetworkConfig{VPC: "vpc-1"}, expectError: true},
+		{name: "subnets without VPC", network: NetworkConfig{Subnets: []string{"subnet-1"}}, expectError: true},
+		{name: "external-dns", network: NetworkConfig{ExternalDNS: true}},
+		{name: "external-dns with private zone", network: NetworkConfig{ExternalDNS: true, PrivateZone: true}, expectError: true},
+		{name: "service load balancers", network: NetworkConfig{ServiceLoadBalancers: true}},
+		{name: "service load balancers in existing VPC", network: NetworkConfig{ServiceLoadBalancers: true, VPC: "vpc-1", Subnets: []string{"subnet-1"}}, expectError: true},
+		{name: "routes", network: NetworkConfig{Routes: true}},
+		{name: "routes with service load balancers", network: NetworkConfig{Routes: true, ServiceLoadBalancers: true}, expectError: true},
+		{name: "router node ports", network: NetworkConfig{RouterHTTPNodePort: 31080, RouterHTTPSNodePort: 31443}},
+		{name: "HTTP router node port only", network: NetworkConfig{RouterHTTPNodePort: 31080}, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.network.validate(); test.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", test.expectError, err)
+			}
+		})
+	}
+}
+
+func TestIsWorkerInZones(t *testing.T) {
+	if !isWorkerInZones("infra-worker-us-east-1b-x7k2p", "infra", []string{"us-east-1a", "us-east-1b"}) {
+		t.Errorf("expected a worker in zone us-east-1b")
+	}
+	if isWorkerInZones("infra-master-0", "infra", []string{"us-east-1a"}) {
+		t.Errorf("expected a master not to be a worker")
+	}
+}
+
+func TestFindPrivateZone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<?xml version="1.0"?><ListHostedZonesByNameResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/"><HostedZones>`+
+			`<HostedZone><Id>/hostedzone/ZPUBLIC</Id><Name>test.example.com.</Name><CallerReference>a</CallerReference><Config><PrivateZone>false</PrivateZone></Config></HostedZone>`+
+			`<HostedZone><Id>/hostedzone/ZPRIVATE</Id><Name>test.example.com.</Name><CallerReference>b</CallerReference><Config><PrivateZone>true</PrivateZone></Config></HostedZone>`+
+			`<HostedZone><Id>/hostedzone/ZOTHER</Id><Name>test2.example.com.</Name><CallerReference>c</CallerReference><Config><PrivateZone>true</PrivateZone></Config></HostedZone>`+
+			`</HostedZones><IsTruncated>false</IsTruncated><MaxItems>100</MaxItems></ListHostedZonesByNameResponse>`)
+	}))
+	defer server.Close()
+	s := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("key", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+	h := &AWSHelper{route53Client: route53.New(s)}
+	tests := []struct {
+		domain   string
+		expected string
+	}{
+		{domain: "test.example.com", expected: "ZPRIVATE"},
+		{domain: "test2.example.com.", expected: "ZOTHER"},
+		{domain: "test3.example.com", expected: ""},
+	}
+	for _, test := range tests {
+		zoneID, err := h.FindPrivateZone(test.domain)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if zoneID != test.expected {
+			t.Errorf("expected zone %q for %s, got %q", test.expected, test.domain, zoneID)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package aws
+
+import (
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/render"
+)
+
+// HibernateCluster stops the cluster named name: its worker machinesets are scaled to zero
+// and the deployments and statefulsets of its control plane are scaled to zero, keeping the
+// persistent volumes of etcd. The replicas of each are recorded in an annotation, so that
+// ResumeCluster restores them. The load balancers and DNS records of the cluster are kept.
+func HibernateCluster(name string) error {
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
+	}
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+	params, err := installer.GetClusterParams(client, name)
+	if errors.IsNotFound(err) {
+		return installerrors.Precondition(err, "cluster %s was not installed with stored parameters and cannot be hibernated", name)
+	}
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain the parameters of cluster %s", name)
+	}
+	// The members of an EtcdCluster store their data in the pods, which would be lost
+	if params.Etcd.Mode != render.EtcdModeSimple && len(params.EtcdEndpoints) == 0 {
+		return installerrors.Precondition(nil, "cluster %s runs etcd with the etcd operator, whose members have no persistent volumes; only clusters with etcd in simple mode or an external etcd can be hibernated", name)
+	}
+
+	machineSets, existing, err := workerMachineSets(dynamicClient, name)
+	if err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		return installerrors.Precondition(nil, "did not find worker machinesets for cluster %s", name)
+	}
+	for _, machineSet := range existing {
+		annotations := machineSet.GetAnnotations()
+		if _, ok := annotations[installer.HibernatedReplicasAnnotation]; ok {
+			continue
+		}
+		replicas, _, _ := unstructured.NestedInt64(machineSet.Object, "spec", "replicas")
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[installer.HibernatedReplicasAnnotation] = strconv.FormatInt(replicas, 10)
+		machineSet.SetAnnotations(annotations)
+		if err = unstructured.SetNestedField(machineSet.Object, int64(0), "spec", "replicas"); err != nil {
+			return installerrors.Apply(err, "failed to set replicas of worker machineset %s", machineSet.GetName())
+		}
+		if _, err = machineSets.Update(machineSet, metav1.UpdateOptions{}); err != nil {
+			return installerrors.Apply(err, "failed to scale down worker machineset %s", machineSet.GetName())
+		}
+		log.Infof("Scaled down worker machineset %s", machineSet.GetName())
+	}
+
+	if err = installer.HibernateControlPlane(client, name); err != nil {
+		return installerrors.Apply(err, "failed to scale down the control plane of cluster %s", name)
+	}
+	log.Infof("Cluster %s is hibernated", name)
+	return nil
+}
+
+// ResumeCluster starts the cluster named name after HibernateCluster: the control plane is
+// scaled up and, once it is rolled out, the nodes of the deleted workers are removed and
+// the worker machinesets are scaled up. It optionally waits for the new nodes to be ready.
+func ResumeCluster(name string, waitForReady bool, waitConfig installer.WaitConfig) error {
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
+	}
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+	machineSets, existing, err := workerMachineSets(dynamicClient, name)
+	if err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		return installerrors.Precondition(nil, "did not find worker machinesets for cluster %s", name)
+	}
+	var hibernated []*unstructured.Unstructured
+	for _, machineSet := range existing {
+		if _, ok := machineSet.GetAnnotations()[installer.HibernatedReplicasAnnotation]; ok {
+			hibernated = append(hibernated, machineSet)
+		}
+	}
+
+	resumed, err := installer.ResumeControlPlane(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to scale up the control plane of cluster %s", name)
+	}
+	if !resumed && len(hibernated) == 0 {
+		return installerrors.Precondition(nil, "cluster %s is not hibernated", name)
+	}
+	log.Infof("Waiting up to %s for the control plane to roll out", waitConfig.PhaseTimeout(installer.WaitPhaseRollout))
+	if err = installer.WaitForDeploymentsRolledOut(client, name, waitConfig); err != nil {
+		return installerrors.Timeout(err, "failed waiting for the control plane of cluster %s to roll out", name)
+	}
+
+	targetClusterCfg, err := installer.GetTargetClusterConfigFromSecret(client, name)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot create target cluster client config")
+	}
+	targetClient, err := kubeclient.NewForConfig(targetClusterCfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot create target cluster client")
+	}
+	// The machines of the workers were deleted when the cluster was hibernated, so its
+	// nodes would never be ready again
+	if len(hibernated) > 0 && len(hibernated) == len(existing) {
+		nodes, err := targetClient.CoreV1().Nodes().List(metav1.ListOptions{})
+		if err != nil {
+			return installerrors.Apply(err, "failed to list the nodes of cluster %s", name)
+		}
+		for _, node := range nodes.Items {
+			if err = targetClient.CoreV1().Nodes().Delete(node.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+				return installerrors.Apply(err, "failed to remove node %s of a hibernated worker", node.Name)
+			}
+			log.Infof("Removed node %s of a hibernated worker", node.Name)
+		}
+	}
+
+	expectedNodes := 0
+	for _, machineSet := range existing {
+		annotations := machineSet.GetAnnotations()
+		value, ok := annotations[installer.HibernatedReplicasAnnotation]
+		if !ok {
+			replicas, _, _ := unstructured.NestedInt64(machineSet.Object, "spec", "replicas")
+			expectedNodes += int(replicas)
+			continue
+		}
+		replicas, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || replicas < 0 {
+			return installerrors.Precondition(err, "invalid %s annotation %q of worker machineset %s", installer.HibernatedReplicasAnnotation, value, machineSet.GetName())
+		}
+		delete(annotations, installer.HibernatedReplicasAnnotation)
+		machineSet.SetAnnotations(annotations)
+		if err = unstructured.SetNestedField(machineSet.Object, replicas, "spec", "replicas"); err != nil {
+			return installerrors.Apply(err, "failed to set replicas of worker machineset %s", machineSet.GetName())
+		}
+		if _, err = machineSets.Update(machineSet, metav1.UpdateOptions{}); err != nil {
+			return installerrors.Apply(err, "failed to scale up worker machineset %s", machineSet.GetName())
+		}
+		log.Infof("Scaled up worker machineset %s to %d replicas", machineSet.GetName(), replicas)
+		expectedNodes += int(replicas)
+	}
+
+	if waitForReady {
+		log.Infof("Waiting up to %s for nodes to be ready.", waitConfig.PhaseTimeout(installer.WaitPhaseNodesReady))
+		if err = installer.WaitForNodesReady(targetClient, expectedNodes, waitConfig); err != nil {
+			return installerrors.Timeout(err, "failed to wait for nodes ready")
+		}
+		log.Infof("Nodes (%d) are ready", expectedNodes)
+	}
+	log.Infof("Cluster %s is resumed", name)
+	return nil
+}
+
+// workerMachineSets returns the machineset client of the management cluster and the
+// existing worker machinesets of a cluster, which KubeVirt workers do not have
+func workerMachineSets(client dynamic.Interface, name string) (dynamic.ResourceInterface, []*unstructured.Unstructured, error) {
+	infraName, _, err := getInfrastructureInfo(client)
+	if err != nil {
+		return nil, nil, installerrors.Precondition(err, "failed to obtain infrastructure info for cluster")
+	}
+	machineSets, err := machineSetClient(client)
+	if err != nil {
+		return nil, nil, installerrors.Precondition(err, "cannot obtain machineset client")
+	}
+	names, err := workerMachineSetNames(machineSets, infraName, name)
+	if err != nil {
+		return nil, nil, installerrors.Apply(err, "failed to list worker machinesets of cluster %s", name)
+	}
+	var existing []*unstructured.Unstructured
+	for _, machineSetName := range names {
+		machineSet, err := machineSets.Get(machineSetName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, installerrors.Apply(err, "failed to fetch worker machineset %s", machineSetName)
+		}
+		existing = append(existing, machineSet)
+	}
+	return machineSets, existing, nil
+}
@@ -0,0 +1,317 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+const (
+	// clusterCredentialsSecretName is the secret of the control plane namespace with the
+	// credentials of the cluster's IAM user
+	clusterCredentialsSecretName = "aws-cluster-credentials"
+
+	// clusterPolicyName is the name of the inline policy of the cluster's IAM user
+	clusterPolicyName = "hypershift-cluster"
+)
+
+// policyDocument is an IAM policy
+type policyDocument struct {
+	Version   string
+	Statement []policyStatement
+}
+
+type policyStatement struct {
+	Effect    string
+	Action    []string
+	Resource  []string
+	Condition map[string]map[string]interface{} `json:",omitempty"`
+}
+
+// clusterPolicy returns the policy of the IAM user of a hosted cluster. It allows the
+// cloud provider and CSI driver of the cluster to look up instances and to manage the EBS
+// volumes and snapshots tagged for the cluster on the workers of the management cluster,
+// and the image registry to use the registry bucket of the cluster.
+func clusterPolicy(infraName, clusterName, registryBucket string) (string, error) {
+	clusterTag := map[string]interface{}{fmt.Sprintf("aws:RequestTag/%s", ClusterTagKey): clusterName}
+	clusterResourceTag := map[string]interface{}{fmt.Sprintf("ec2:ResourceTag/%s", ClusterTagKey): clusterName}
+	ownedInstanceTag := map[string]interface{}{fmt.Sprintf("ec2:ResourceTag/kubernetes.io/cluster/%s", infraName): "owned"}
+	policy := policyDocument{
+		Version: "2012-10-17",
+		Statement: []policyStatement{
+			{
+				Effect: "Allow",
+				Action: []string{
+					"ec2:DescribeAvailabilityZones",
+					"ec2:DescribeInstances",
+					"ec2:DescribeRegions",
+					"ec2:DescribeSecurityGroups",
+					"ec2:DescribeSnapshots",
+					"ec2:DescribeSubnets",
+					"ec2:DescribeTags",
+					"ec2:DescribeVolumes",
+					"ec2:DescribeVolumesModifications",
+					"ec2:DescribeVpcs",
+					"elasticloadbalancing:DescribeLoadBalancers",
+				},
+				Resource: []string{"*"},
+			},
+			{
+				Effect:    "Allow",
+				Action:    []string{"ec2:CreateVolume", "ec2:CreateSnapshot"},
+				Resource:  []string{"*"},
+				Condition: map[string]map[string]interface{}{"StringEquals": clusterTag},
+			},
+			{
+				Effect:   "Allow",
+				Action:   []string{"ec2:CreateTags"},
+				Resource: []string{"arn:aws:ec2:*:*:volume/*", "arn:aws:ec2:*:*:snapshot/*"},
+				Condition: map[string]map[string]interface{}{
+					"StringEquals": {"ec2:CreateAction": []string{"CreateVolume", "CreateSnapshot"}},
+				},
+			},
+			{
+				Effect: "Allow",
+				Action: []string{
+					"ec2:AttachVolume",
+					"ec2:DetachVolume",
+					"ec2:DeleteVolume",
+					"ec2:ModifyVolume",
+					"ec2:DeleteSnapshot",
+				},
+				Resource:  []string{"arn:aws:ec2:*:*:volume/*", "arn:aws:ec2:*:*:snapshot/*"},
+				Condition: map[string]map[string]interface{}{"StringEquals": clusterResourceTag},
+			},
+			{
+				Effect:    "Allow",
+				Action:    []string{"ec2:AttachVolume", "ec2:DetachVolume"},
+				Resource:  []string{"arn:aws:ec2:*:*:instance/*"},
+				Condition: map[string]map[string]interface{}{"StringEquals": ownedInstanceTag},
+			},
+			{
+				Effect:   "Allow",
+				Action:   []string{"s3:*"},
+				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s", registryBucket), fmt.Sprintf("arn:aws:s3:::%s/*", registryBucket)},
+			},
+		},
+	}
+	policyBytes, err := json.Marshal(policy)
+	if err != nil {
+		return "", err
+	}
+	return string(policyBytes), nil
+}
+
+// EnsureClusterUser ensures that an IAM user with the given inline policy exists, tagged
+// for the cluster, and returns a new access key of the user. Existing access keys of the
+// user, which are left behind by an interrupted install, are removed.
+func (h *AWSHelper) EnsureClusterUser(userName, policy string) (credentials.Value, error) {
+	_, err := h.iamClient.CreateUser(&iam.CreateUserInput{
+		UserName: aws.String(userName),
+		Tags: []*iam.Tag{
+			{
+				Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", h.infraName)),
+				Value: aws.String("owned"),
+			},
+			{
+				Key:   aws.String(ClusterTagKey),
+				Value: aws.String(h.clusterName),
+			},
+		},
+	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == iam.ErrCodeEntityAlreadyExistsException {
+		err = nil
+	}
+	if err != nil {
+		return credentials.Value{}, errors.Wrapf(err, "failed to create IAM user %s", userName)
+	}
+	_, err = h.iamClient.PutUserPolicy(&iam.PutUserPolicyInput{
+		UserName:       aws.String(userName),
+		PolicyName:     aws.String(clusterPolicyName),
+		PolicyDocument: aws.String(policy),
+	})
+	if err != nil {
+		return credentials.Value{}, errors.Wrapf(err, "failed to set the policy of IAM user %s", userName)
+	}
+	if err = h.removeAccessKeys(userName); err != nil {
+		return credentials.Value{}, err
+	}
+	output, err := h.iamClient.CreateAccessKey(&iam.CreateAccessKeyInput{
+		UserName: aws.String(userName),
+	})
+	if err != nil {
+		return credentials.Value{}, errors.Wrapf(err, "failed to create an access key of IAM user %s", userName)
+	}
+	return credentials.Value{
+		AccessKeyID:     aws.StringValue(output.AccessKey.AccessKeyId),
+		SecretAccessKey: aws.StringValue(output.AccessKey.SecretAccessKey),
+	}, nil
+}
+
+// findClusterUser returns the IAM user with the given name if it is tagged for the cluster
+func (h *AWSHelper) findClusterUser(userName string) ([]string, error) {
+	output, err := h.iamClient.GetUser(&iam.GetUserInput{UserName: aws.String(userName)})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == iam.ErrCodeNoSuchEntityException {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	tags := map[string]string{}
+	for _, tag := range output.User.Tags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	if !isClusterResource(tags, h.infraName, h.clusterName) {
+		return nil, nil
+	}
+	return []string{userName}, nil
+}
+
+// userTags returns the tags of the IAM users with the name prefix of the management cluster
+// by name
+func (h *AWSHelper) userTags() (map[string]map[string]string, error) {
+	names := []string{}
+	err := h.iamClient.ListUsersPages(&iam.ListUsersInput{}, func(output *iam.ListUsersOutput, lastPage bool) bool {
+		for _, user := range output.Users {
+			if name := aws.StringValue(user.UserName); strings.HasPrefix(name, h.infraName+"-") {
+				names = append(names, name)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]map[string]string{}
+	for _, name := range names {
+		output, err := h.iamClient.ListUserTags(&iam.ListUserTagsInput{UserName: aws.String(name)})
+		if err != nil {
+			return nil, err
+		}
+		tags := map[string]string{}
+		for _, tag := range output.Tags {
+			tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+		result[name] = tags
+	}
+	return result, nil
+}
+
+// RemoveClusterUser removes an IAM user with its access keys and inline policy
+func (h *AWSHelper) RemoveClusterUser(userName string) error {
+	if err := h.removeAccessKeys(userName); err != nil {
+		return err
+	}
+	_, err := h.iamClient.DeleteUserPolicy(&iam.DeleteUserPolicyInput{
+		UserName:   aws.String(userName),
+		PolicyName: aws.String(clusterPolicyName),
+	})
+	if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != iam.ErrCodeNoSuchEntityException {
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete the policy of IAM user %s", userName)
+		}
+	}
+	_, err = h.iamClient.DeleteUser(&iam.DeleteUserInput{UserName: aws.String(userName)})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == iam.ErrCodeNoSuchEntityException {
+		return nil
+	}
+	return err
+}
+
+// removeAccessKeys removes the access keys of an IAM user
+func (h *AWSHelper) removeAccessKeys(userName string) error {
+	output, err := h.iamClient.ListAccessKeys(&iam.ListAccessKeysInput{UserName: aws.String(userName)})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == iam.ErrCodeNoSuchEntityException {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the access keys of IAM user %s", userName)
+	}
+	for _, key := range output.AccessKeyMetadata {
+		_, err = h.iamClient.DeleteAccessKey(&iam.DeleteAccessKeyInput{
+			UserName:    aws.String(userName),
+			AccessKeyId: key.AccessKeyId,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete access key %s of IAM user %s", aws.StringValue(key.AccessKeyId), userName)
+		}
+	}
+	return nil
+}
+
+// ensureClusterCredentials ensures the registry bucket and the IAM user of the cluster, and
+// returns the credentials of the user. The credentials are stored in the control plane
+// namespace when the user is created, so that a resumed install reuses them instead of
+// replacing the access key that the cluster may already use.
+func ensureClusterCredentials(client kubeclient.Interface, h *AWSHelper, namespace, registryBucket string) (credentials.Value, error) {
+	existing, err := getClusterCredentials(client, namespace)
+	if err != nil {
+		return credentials.Value{}, installerrors.Apply(err, "cannot get the cluster credentials secret")
+	}
+	if existing != nil {
+		return *existing, nil
+	}
+	if err = h.EnsurePrivateBucket(registryBucket); err != nil {
+		return credentials.Value{}, cloudProviderError(err, "failed to create the image registry bucket")
+	}
+	policy, err := clusterPolicy(h.infraName, h.clusterName, registryBucket)
+	if err != nil {
+		return credentials.Value{}, installerrors.Render(err, "failed to generate the policy of the cluster's IAM user")
+	}
+	creds, err := h.EnsureClusterUser(generateUserName(h.infraName, h.clusterName), policy)
+	if err != nil {
+		return credentials.Value{}, cloudProviderError(err, "failed to create the cluster's IAM user")
+	}
+	if err = createClusterCredentialsSecret(client, namespace, creds); err != nil {
+		return credentials.Value{}, installerrors.Apply(err, "failed to store the cluster credentials")
+	}
+	return creds, nil
+}
+
+// getClusterCredentials returns the credentials of the cluster's IAM user that were
+// stored in the control plane namespace, if any
+func getClusterCredentials(client kubeclient.Interface, namespace string) (*credentials.Value, error) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(clusterCredentialsSecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &credentials.Value{
+		AccessKeyID:     string(secret.Data["aws_access_key_id"]),
+		SecretAccessKey: string(secret.Data["aws_secret_access_key"]),
+	}, nil
+}
+
+// createClusterCredentialsSecret stores the credentials of the cluster's IAM user in the
+// control plane namespace
+func createClusterCredentialsSecret(client kubeclient.Interface, namespace string, creds credentials.Value) error {
+	secret := &corev1.Secret{}
+	secret.Name = clusterCredentialsSecretName
+	secret.Data = map[string][]byte{
+		"aws_access_key_id":     []byte(creds.AccessKeyID),
+		"aws_secret_access_key": []byte(creds.SecretAccessKey),
+	}
+	_, err := client.CoreV1().Secrets(namespace).Create(secret)
+	return err
+}
+
+// generateUserName returns the name of the IAM user of a cluster
+func generateUserName(infraName, clusterName string) string {
+	return installer.GetName(fmt.Sprintf("%s-%s", infraName, clusterName), "cluster", 64)
+}
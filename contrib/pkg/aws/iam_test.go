@@ -0,0 +1,51 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestClusterPolicy(t *testing.T) {
+	policy, err := clusterPolicy("mgmt-abc", "example", "mgmt-abc-example-registry")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	document := &policyDocument{}
+	if err = json.Unmarshal([]byte(policy), document); err != nil {
+		t.Fatalf("invalid policy document: %v", err)
+	}
+	if document.Version != "2012-10-17" {
+		t.Errorf("unexpected version %s", document.Version)
+	}
+	foundBucket := false
+	for _, statement := range document.Statement {
+		if statement.Effect != "Allow" {
+			t.Errorf("unexpected effect %s", statement.Effect)
+		}
+		for _, action := range statement.Action {
+			switch action {
+			case "ec2:CreateVolume", "ec2:DeleteVolume", "ec2:AttachVolume":
+				if len(statement.Condition) == 0 {
+					t.Errorf("action %s is not limited to the cluster's resources", action)
+				}
+			case "s3:*":
+				for _, resource := range statement.Resource {
+					if resource != "arn:aws:s3:::mgmt-abc-example-registry" && resource != "arn:aws:s3:::mgmt-abc-example-registry/*" {
+						t.Errorf("S3 access is not limited to the registry bucket: %s", resource)
+					}
+				}
+				foundBucket = true
+			}
+		}
+	}
+	if !foundBucket {
+		t.Errorf("the policy does not allow access to the registry bucket")
+	}
+}
+
+func TestGenerateUserName(t *testing.T) {
+	name := generateUserName("mgmt-abcde", "a-very-long-hosted-cluster-name-that-exceeds-the-limit-of-iam-user-names")
+	if len(name) > 64 {
+		t.Errorf("user name %s is longer than 64 characters", name)
+	}
+}
@@ -1,821 +1,1314 @@
 package aws
 
 import (
-	crand "crypto/rand"
 	"encoding/base64"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"hash/fnv"
 	"io"
 	"io/ioutil"
-	"math/big"
-	"math/rand"
-	"net"
 	"os"
-	"os/user"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
-	gocidr "github.com/apparentlymart/go-cidr/cidr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/elbv2"
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
 
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/runtime/serializer"
-	"k8s.io/apimachinery/pkg/util/intstr"
-	"k8s.io/apimachinery/pkg/util/sets"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 	kubeclient "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/retry"
 
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
 	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/config"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/autoapprover"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/awsinfra"
 	"github.com/openshift/hypershift-toolkit/pkg/ignition"
 	"github.com/openshift/hypershift-toolkit/pkg/pki"
+	"github.com/openshift/hypershift-toolkit/pkg/release"
 	"github.com/openshift/hypershift-toolkit/pkg/render"
 )
 
 const (
-	routerNodePortHTTP    = 31080
-	routerNodePortHTTPS   = 31443
-	externalOauthPort     = 8443
-	workerMachineSetCount = 3
+	externalOauthPort = 8443
+
+	// externalIgnitionPort is the port of the ignition server on the API load balancer,
+	// the port of the machine config server of standalone clusters
+	externalIgnitionPort = 22623
+
+	// machineSetClusterLabel identifies the worker machinesets of a cluster
+	machineSetClusterLabel = autoapprover.MachineSetClusterLabel
+
+	// haControlPlaneReplicas is the number of replicas of each control plane
+	// component of a highly available cluster
+	haControlPlaneReplicas = 3
 
 	defaultControlPlaneOperatorImage = "registry.svc.ci.openshift.org/hypershift-toolkit/hypershift-4.4:control-plane-operator"
-)
 
-var (
-	excludeManifests = []string{
-		"kube-apiserver-service.yaml",
-		"openshift-apiserver-service.yaml",
-		"openvpn-server-service.yaml",
-		"v4-0-config-system-branding.yaml",
-		"oauth-server-service.yaml",
-	}
-	coreScheme = runtime.NewScheme()
-	coreCodecs = serializer.NewCodecFactory(coreScheme)
+	// DefaultWorkerCount is the default number of worker nodes of a cluster
+	DefaultWorkerCount = 3
 )
 
-func init() {
-	if err := corev1.AddToScheme(coreScheme); err != nil {
-		panic(err)
+// WorkerConfig is the worker node footprint of a cluster. Empty fields keep the
+// settings of the management cluster's worker machinesets.
+type WorkerConfig struct {
+	// Count is the number of worker nodes, spread across the worker machinesets
+	Count int
+	// InstanceType is the EC2 instance type of the workers
+	InstanceType string
+	// RootVolumeSize is the size in GiB of the root volume of the workers
+	RootVolumeSize int
+	// NodePools are the named groups of workers of the cluster. When set, Count is
+	// ignored and InstanceType is the default instance type of the pools.
+	NodePools []api.NodePool
+	// KubeVirt runs the workers as KubeVirt virtual machines on the management cluster
+	// when set. InstanceType and RootVolumeSize are ignored.
+	KubeVirt *KubeVirtConfig
+	// Arch is the architecture of the workers, and of the images of the cluster's release.
+	// Workers of another architecture than amd64 require an instance type of that
+	// architecture.
+	Arch string
+	// AMI is the image of the workers. When empty, it is the AMI of the boot image of the
+	// release in the region of the management cluster, or the image of the management
+	// cluster's workers if the release has no boot image metadata.
+	AMI string
+	// CoreOSStreamURL is the URL of CoreOS stream metadata to find the AMI of the workers in,
+	// for releases without boot image metadata
+	CoreOSStreamURL string
+}
+
+// validate verifies that the worker configuration can be installed
+func (w WorkerConfig) validate() error {
+	if len(w.NodePools) > 0 {
+		if err := validateNodePools(w.NodePools); err != nil {
+			return err
+		}
+	} else if w.Count < 1 {
+		return fmt.Errorf("the number of workers must be at least 1, got %d", w.Count)
+	}
+	if w.RootVolumeSize < 0 {
+		return fmt.Errorf("the root volume size cannot be negative, got %d", w.RootVolumeSize)
+	}
+	if err := release.ValidateArch(w.Arch); err != nil {
+		return err
+	}
+	if len(w.Arch) > 0 && w.Arch != release.DefaultArch {
+		if w.KubeVirt != nil {
+			return fmt.Errorf("KubeVirt workers run on the management cluster and cannot be %s workers", w.Arch)
+		}
+		if len(w.InstanceType) == 0 {
+			return fmt.Errorf("%s workers require an instance type of that architecture", w.Arch)
+		}
 	}
+	if w.KubeVirt != nil {
+		if err := w.KubeVirt.validate(); err != nil {
+			return err
+		}
+		return validateKubeVirtNodePools(w.NodePools)
+	}
+	return nil
 }
 
-func InstallCluster(name, releaseImage, dhParamsFile string, waitForReady bool) error {
+// NetworkConfig is an existing network to create the cluster's load balancers in. Empty
+// fields are derived from the management cluster's infrastructure.
+type NetworkConfig struct {
+	// VPC is the id of the VPC of the load balancers and their target groups
+	VPC string
+	// Subnets are the ids of the subnets of the load balancers
+	Subnets []string
+	// SecurityGroup is the id of the security group of the management cluster workers
+	// that load balancer traffic is allowed through
+	SecurityGroup string
+	// PrivateZone registers the records of a public cluster in a private zone of the
+	// cluster's domain, associated with the VPC, in addition to the public zone, so that
+	// the cluster's names resolve within the VPC without relying on the public zone
+	PrivateZone bool
+	// ExternalDNS leaves the records of the cluster to an external-dns deployment of the
+	// management cluster, which registers the services that the installer creates for them
+	ExternalDNS bool
+	// ServiceLoadBalancers exposes the API, OAuth and ignition server, and the routers of
+	// KubeVirt workers, through LoadBalancer services that the cloud provider of the
+	// management cluster provisions load balancers for, instead of load balancers that the
+	// installer creates. It cannot be used with an existing VPC.
+	ServiceLoadBalancers bool
+	// Routes exposes the API, OAuth and ignition server through passthrough routes of the
+	// management cluster's routers, in the domain of its routes, instead of load balancers.
+	// The workers of the cluster reach the API on the node port of its service.
+	Routes bool
+	// SharedIngress exposes the API, OAuth and ignition server through the API server
+	// frontend of the management cluster, which forwards them by SNI, instead of load
+	// balancers of the cluster. The frontend must be installed with install-frontend. The
+	// workers of the cluster reach the API on the node port of its service.
+	SharedIngress bool
+	// RouterHTTPNodePort and RouterHTTPSNodePort are the node ports of the router of the
+	// cluster on its workers, which the router load balancer targets. Free node ports of the
+	// management cluster are allocated when both are 0.
+	RouterHTTPNodePort  int
+	RouterHTTPSNodePort int
+	// NetworkType is the network plugin of the cluster, OpenShiftSDN, OVNKubernetes or None.
+	// Defaults to the network type of the cluster.yaml, or else OpenShiftSDN.
+	NetworkType string
+	// DirectRouting has the API server of the cluster reach the kubelets, pods and services
+	// of its workers through the network of the management cluster instead of a VPN, which
+	// requires the nodes and pods of the cluster to be routable from the management cluster.
+	// The VPN server, its load balancer and its privileged SCC are not created.
+	DirectRouting bool
+}
 
-	// First, ensure that we can access the host cluster
-	cfg, err := loadConfig()
-	if err != nil {
-		return fmt.Errorf("cannot access existing cluster; make sure a connection to host cluster is available: %v", err)
+// validate verifies that the network configuration is complete
+func (n NetworkConfig) validate() error {
+	if err := api.ValidateNetworkType(n.NetworkType); err != nil {
+		return err
 	}
-
-	dynamicClient, err := dynamic.NewForConfig(cfg)
-	if err != nil {
-		return fmt.Errorf("cannot obtain dynamic client: %v", err)
+	if len(n.VPC) > 0 && len(n.Subnets) == 0 {
+		return fmt.Errorf("subnets are required when a VPC is specified")
 	}
-	// Extract config information from management cluster
-	sshKey, err := getSSHPublicKey(dynamicClient)
-	if err != nil {
-		return fmt.Errorf("failed to fetch an SSH public key from existing cluster: %v", err)
+	if len(n.Subnets) > 0 && len(n.VPC) == 0 {
+		return fmt.Errorf("a VPC is required when subnets are specified")
 	}
-	log.Debugf("The SSH public key is: %s", string(sshKey))
-
-	client, err := kubeclient.NewForConfig(cfg)
-	if err != nil {
-		return fmt.Errorf("failed to obtain a kubernetes client from existing configuration: %v", err)
+	if n.ExternalDNS && n.PrivateZone {
+		return fmt.Errorf("a private zone cannot be used with external-dns")
 	}
-	awsKey, awsSecretKey, err := getAWSCredentials(client)
-	if err != nil {
-		return fmt.Errorf("failed to obtain AWS credentials from host cluster: %v", err)
+	if n.ServiceLoadBalancers && len(n.VPC) > 0 {
+		return fmt.Errorf("the load balancers of services are created in the VPC of the management cluster")
 	}
-	log.Debugf("AWS key: %s, secret: %s", awsKey, awsSecretKey)
-
-	if releaseImage == "" {
-		releaseImage, err = getReleaseImage(dynamicClient)
-		if err != nil {
-			return fmt.Errorf("failed to obtain release image from host cluster: %v", err)
-		}
+	if n.Routes && n.ServiceLoadBalancers {
+		return fmt.Errorf("the API cannot be exposed through both routes and the load balancers of services")
 	}
-
-	pullSecret, err := getPullSecret(client)
-	if err != nil {
-		return fmt.Errorf("failed to obtain a pull secret from cluster: %v", err)
+	if n.SharedIngress && (n.Routes || n.ServiceLoadBalancers) {
+		return fmt.Errorf("the API cannot be exposed through both a shared ingress and routes or the load balancers of services")
 	}
-	log.Debugf("The pull secret is: %v", pullSecret)
-
-	infraName, region, err := getInfrastructureInfo(dynamicClient)
-	if err != nil {
-		return fmt.Errorf("failed to obtain infrastructure info for cluster: %v", err)
+	if (n.RouterHTTPNodePort == 0) != (n.RouterHTTPSNodePort == 0) {
+		return fmt.Errorf("both or neither of the router node ports must be specified")
 	}
-	log.Debugf("The management cluster infra name is: %s", infraName)
-	log.Debugf("The management cluster AWS region is: %s", region)
+	return nil
+}
 
-	serviceCIDR, podCIDR, err := getNetworkInfo(dynamicClient)
+// InstallCluster installs a hosted control plane named name on the management cluster.
+// If infraCredentialsFile is not empty, it is an AWS shared credentials file with the
+// credentials that the control plane operator uses to verify the AWS resources of the
+// cluster. These should be limited to the cluster's resources; the management cluster's
+// credentials are never handed to the control plane. The workers of the cluster are
+// created as described by workers, and its load balancers in the network described by
+// network. A private cluster uses internal load balancers and
+// a private hosted zone, so that it is only reachable from within the VPC. Workers fetch
+// their ignition config from an ignition server in the control plane namespace, or if
+// ignitionBucket is true, from a private S3 bucket through pre-signed URLs that the control
+// plane operator refreshes with the infrastructure credentials.
+// The progress of the install is recorded in stateDir, which defaults to a directory for
+// the cluster in the home directory, so that a failed install is resumed where it stopped
+// when it is run again. The installer's own AWS credentials are selected by awsCredentials.
+// If clusterUser is true, the cloud provider, CSI driver and image registry of the cluster
+// use the credentials of an IAM user that is created for the cluster, with a policy that is
+// limited to the cluster's volumes and its S3 registry bucket. Unless skipCapacityCheck is
+// true, the install fails before it creates the namespace of the cluster if the management
+// cluster does not have the free capacity that the control plane requests. If dryRun is
+// true, the manifests of the cluster are rendered and applied with a server-side dry run,
+// and a diff of the changes to the management cluster is written to out, without creating
+// anything on the management cluster or AWS. The install state is not changed. The URLs,
+// AWS resources and phase timings of the install are recorded in result, if it is not nil.
+func InstallCluster(name, releaseImage, dhParamsFile, infraCredentialsFile, stateDir string, clusterConfig *api.ClusterParams, awsCredentials CredentialsConfig, workers WorkerConfig, network NetworkConfig, waitForReady bool, waitConfig installer.WaitConfig, highAvailability, private, ignitionBucket, clusterUser, skipPrivilegedSCC, skipCapacityCheck, dryRun bool, out io.Writer, result *installer.Result) error {
+	result.StartPhase("management-cluster")
+	mc, err := discoverManagementCluster(awsCredentials, network)
 	if err != nil {
-		return fmt.Errorf("failed to obtain network info for cluster: %v", err)
+		return err
 	}
+	return installCluster(mc, name, releaseImage, dhParamsFile, infraCredentialsFile, stateDir, clusterConfig, workers, network, waitForReady, waitConfig, highAvailability, private, ignitionBucket, clusterUser, skipPrivilegedSCC, skipCapacityCheck, dryRun, out, result)
+}
 
-	dnsZoneID, parentDomain, err := getDNSZoneInfo(dynamicClient)
-	if err != nil {
-		return fmt.Errorf("failed to obtain public zone information: %v", err)
-	}
-	log.Debugf("Using public DNS Zone: %s and parent suffix: %s", dnsZoneID, parentDomain)
+// managementCluster is the information about the management cluster that installs need,
+// which the installs of a batch of clusters discover once and share
+type managementCluster struct {
+	cfg             *rest.Config
+	client          kubeclient.Interface
+	dynamicClient   dynamic.Interface
+	sshKey          []byte
+	pullSecret      string
+	ignitionVersion string
+	infraName       string
+	region          string
+	creds           *credentials.Credentials
+	serviceCIDR     string
+	podCIDR         string
+	dnsZoneID       string
+	parentDomain    string
+	ingressDomain   string
+	machineNames    []string
+
+	// frontendDNSName is the load balancer of the API server frontend
+	frontendDNSName string
+}
 
-	machineNames, err := getMachineNames(dynamicClient)
+// discoverManagementCluster connects to the management cluster and fetches the information
+// about it that installs need. The ingress domain of its routes and its API server frontend
+// are only fetched if the network exposes the API through them.
+func discoverManagementCluster(awsCredentials CredentialsConfig, network NetworkConfig) (*managementCluster, error) {
+	// First, ensure that we can access the host cluster
+	cfg, err := installer.LoadConfig()
 	if err != nil {
-		return fmt.Errorf("failed to fetch machine names for cluster: %v", err)
+		return nil, installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
 	}
+	mc := &managementCluster{cfg: cfg}
 
-	// Start creating resources on management cluster
-	_, err = client.CoreV1().Namespaces().Get(name, metav1.GetOptions{})
-	if err == nil {
-		return fmt.Errorf("target namespace %s already exists on management cluster", name)
+	if mc.dynamicClient, err = dynamic.NewForConfig(cfg); err != nil {
+		return nil, installerrors.Precondition(err, "cannot obtain dynamic client")
 	}
-	if !errors.IsNotFound(err) {
-		return fmt.Errorf("unexpected error getting namespaces from management cluster: %v", err)
+	// Extract config information from management cluster
+	if mc.sshKey, err = installer.GetSSHPublicKey(mc.dynamicClient); err != nil {
+		return nil, installerrors.Precondition(err, "failed to fetch an SSH public key from existing cluster")
 	}
-	log.Infof("Creating namespace %s", name)
-	ns := &corev1.Namespace{}
-	ns.Name = name
-	_, err = client.CoreV1().Namespaces().Create(ns)
-	if err != nil {
-		return fmt.Errorf("failed to create namespace %s: %v", name, err)
+	log.Debugf("The SSH public key is: %s", string(mc.sshKey))
+
+	if mc.client, err = kubeclient.NewForConfig(cfg); err != nil {
+		return nil, installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
 	}
 
-	// Ensure that we can run privileged pods
-	if err = ensurePrivilegedSCC(dynamicClient, name); err != nil {
-		return fmt.Errorf("failed to ensure privileged SCC for the new namespace: %v", err)
+	if mc.pullSecret, err = installer.GetPullSecret(mc.client); err != nil {
+		return nil, installerrors.Precondition(err, "failed to obtain a pull secret from cluster")
 	}
+	log.Debugf("The pull secret is: %v", mc.pullSecret)
 
-	// Create pull secret
-	log.Infof("Creating pull secret")
-	if err := createPullSecret(client, name, pullSecret); err != nil {
-		return fmt.Errorf("failed to create pull secret: %v", err)
+	if mc.ignitionVersion, err = installer.GetIgnitionVersion(mc.client); err != nil {
+		return nil, installerrors.Precondition(err, "failed to obtain the ignition version of the management cluster workers")
 	}
+	log.Debugf("The ignition version of the workers is: %s", mc.ignitionVersion)
 
-	// Create Kube APIServer service
-	log.Infof("Creating Kube API service")
-	apiNodePort, err := createKubeAPIServerService(client, name)
-	if err != nil {
-		return fmt.Errorf("failed to create kube apiserver service: %v", err)
+	if mc.infraName, mc.region, err = getInfrastructureInfo(mc.dynamicClient); err != nil {
+		return nil, installerrors.Precondition(err, "failed to obtain infrastructure info for cluster")
 	}
-	log.Infof("Created Kube API service with NodePort %d", apiNodePort)
+	log.Debugf("The management cluster infra name is: %s", mc.infraName)
+	log.Debugf("The management cluster AWS region is: %s", mc.region)
 
-	log.Infof("Creating VPN service")
-	vpnNodePort, err := createVPNServerService(client, name)
-	if err != nil {
-		return fmt.Errorf("failed to create vpn server service: %v", err)
+	if mc.creds, err = getAWSCredentials(mc.client, awsCredentials, mc.region); err != nil {
+		return nil, installerrors.Precondition(err, "failed to obtain AWS credentials")
 	}
-	log.Infof("Created VPN service with NodePort %d", vpnNodePort)
 
-	log.Infof("Creating Openshift API service")
-	openshiftClusterIP, err := createOpenshiftService(client, name)
-	if err != nil {
-		return fmt.Errorf("failed to create openshift server service: %v", err)
+	if mc.serviceCIDR, mc.podCIDR, err = installer.GetNetworkInfo(mc.dynamicClient); err != nil {
+		return nil, installerrors.Precondition(err, "failed to obtain network info for cluster")
 	}
-	log.Infof("Created Openshift API service with cluster IP: %s", openshiftClusterIP)
 
-	oauthNodePort, err := createOauthService(client, name)
-	if err != nil {
-		return fmt.Errorf("failed to create Oauth server service: %v", err)
+	if mc.dnsZoneID, mc.parentDomain, err = installer.GetDNSZoneInfo(mc.dynamicClient); err != nil {
+		return nil, installerrors.Precondition(err, "failed to obtain public zone information")
 	}
-	log.Infof("Created Oauth server service with NodePort: %d", oauthNodePort)
+	log.Debugf("Using public DNS Zone: %s and parent suffix: %s", mc.dnsZoneID, mc.parentDomain)
 
-	// Fetch AWS cloud data
-	aws, err := NewAWSHelper(awsKey, awsSecretKey, region, infraName)
-	if err != nil {
-		return fmt.Errorf("cannot create an AWS client: %v", err)
+	if network.Routes {
+		if mc.ingressDomain, err = installer.GetIngressDomain(mc.dynamicClient); err != nil {
+			return nil, installerrors.Precondition(err, "failed to obtain the ingress domain of the management cluster")
+		}
+		log.Debugf("Using management cluster ingress domain: %s", mc.ingressDomain)
 	}
 
-	lbInfo, err := aws.LoadBalancerInfo(machineNames)
-	if err != nil {
-		return fmt.Errorf("cannot get load balancer info: %v", err)
+	if network.SharedIngress {
+		if mc.frontendDNSName, err = apiServerFrontendDNSName(mc.client); err != nil {
+			return nil, installerrors.Precondition(err, "failed to obtain the API server frontend of the management cluster")
+		}
+		if len(mc.frontendDNSName) == 0 {
+			return nil, installerrors.Precondition(nil, "the API server frontend is not installed on the management cluster or has no load balancer; install it with install-frontend")
+		}
+		log.Debugf("Using API server frontend: %s", mc.frontendDNSName)
 	}
-	log.Infof("Using VPC: %s, Zone: %s, Subnet: %s", lbInfo.VPC, lbInfo.Zone, lbInfo.Subnet)
 
-	machineID, machineIP, err := getMachineInfo(dynamicClient, machineNames, fmt.Sprintf("%s-worker-%s", infraName, lbInfo.Zone))
-	if err != nil {
-		return fmt.Errorf("cannot get machine info: %v", err)
+	if mc.machineNames, err = installer.GetMachineNames(mc.dynamicClient); err != nil {
+		return nil, installerrors.Precondition(err, "failed to fetch machine names for cluster")
 	}
-	log.Infof("Using management machine with ID: %s and IP: %s", machineID, machineIP)
+	return mc, nil
+}
 
-	apiLBName := generateLBResourceName(infraName, name, "api")
-	apiAllocID, apiPublicIP, err := aws.EnsureEIP(apiLBName)
+// installCluster installs a hosted control plane named name on the discovered management
+// cluster, as described by InstallCluster
+func installCluster(mc *managementCluster, name, releaseImage, dhParamsFile, infraCredentialsFile, stateDir string, clusterConfig *api.ClusterParams, workers WorkerConfig, network NetworkConfig, waitForReady bool, waitConfig installer.WaitConfig, highAvailability, private, ignitionBucket, clusterUser, skipPrivilegedSCC, skipCapacityCheck, dryRun bool, out io.Writer, result *installer.Result) error {
+	if clusterConfig != nil {
+		var err error
+		if highAvailability, err = applyClusterConfig(clusterConfig, &releaseImage, &workers, highAvailability); err != nil {
+			return installerrors.Precondition(err, "invalid cluster configuration")
+		}
+		skipPrivilegedSCC = skipPrivilegedSCC || clusterConfig.PodSecurity.SkipPrivilegedSCC
+		network.DirectRouting = network.DirectRouting || clusterConfig.DirectRouting
+	}
+	if ignitionBucket && len(infraCredentialsFile) == 0 {
+		return installerrors.Precondition(nil, "an ignition bucket requires infrastructure credentials to refresh its pre-signed URLs")
+	}
+	if ignitionBucket && workers.KubeVirt != nil {
+		return installerrors.Precondition(nil, "KubeVirt workers fetch their ignition config from the ignition server and cannot use an ignition bucket")
+	}
+	if err := workers.validate(); err != nil {
+		return installerrors.Precondition(err, "invalid worker configuration")
+	}
+	if err := network.validate(); err != nil {
+		return installerrors.Precondition(err, "invalid network configuration")
+	}
+	if private && network.ExternalDNS {
+		return installerrors.Precondition(nil, "a private cluster registers its records in a private zone and cannot use external-dns")
+	}
+	if private && network.ServiceLoadBalancers {
+		return installerrors.Precondition(nil, "a private cluster has internal load balancers that the installer creates and cannot use the load balancers of services")
+	}
+	if private && network.Routes {
+		return installerrors.Precondition(nil, "a private cluster has an internal API load balancer and cannot be exposed through the routes of the management cluster")
+	}
+	if private && network.SharedIngress {
+		return installerrors.Precondition(nil, "a private cluster has an internal API load balancer and cannot be exposed through the API server frontend of the management cluster")
+	}
+	state, err := loadInstallState(name, stateDir)
 	if err != nil {
-		return fmt.Errorf("cannot allocate API load balancer EIP: %v", err)
+		return installerrors.Precondition(err, "cannot load install state")
+	}
+	resumed := state.Started()
+	if resumed {
+		log.Infof("Resuming install of cluster %s from %s", name, state.Dir())
+	}
+	if dryRun {
+		log.Infof("Dry run of the install of cluster %s, nothing is created", name)
+		state.SetDryRun()
+		if result != nil {
+			result.DryRun = true
+		}
 	}
-	log.Infof("Allocated EIP with ID: %s, and IP: %s", apiAllocID, apiPublicIP)
 
-	apiLBARN, apiLBDNS, err := aws.EnsureNLB(apiLBName, lbInfo.Subnet, apiAllocID)
-	if err != nil {
-		return fmt.Errorf("cannot create network load balancer: %v", err)
+	if workers.KubeVirt != nil {
+		if err = checkKubeVirtInstalled(mc.dynamicClient); err != nil {
+			return installerrors.Precondition(err, "cannot run KubeVirt workers")
+		}
 	}
-	log.Infof("Created API load balancer with ARN: %s, DNS: %s", apiLBARN, apiLBDNS)
 
-	apiTGARN, err := aws.EnsureTargetGroup(lbInfo.VPC, apiLBName, apiNodePort)
-	if err != nil {
-		return fmt.Errorf("cannot create API target group: %v", err)
+	var infraCredentials credentials.Value
+	if len(infraCredentialsFile) > 0 {
+		infraCredentials, err = credentials.NewSharedCredentials(infraCredentialsFile, "").Get()
+		if err != nil {
+			return installerrors.Precondition(err, "cannot read AWS infrastructure credentials from %s", infraCredentialsFile)
+		}
 	}
-	log.Infof("Created API target group ARN: %s", apiTGARN)
 
-	oauthTGName := generateLBResourceName(infraName, name, "oauth")
-	oauthTGARN, err := aws.EnsureTargetGroup(lbInfo.VPC, oauthTGName, oauthNodePort)
+	// A resumed install keeps the release image that it was started with
+	releaseImage, err = state.StringValue("release-image", func() (string, error) {
+		if releaseImage != "" {
+			return releaseImage, nil
+		}
+		return installer.GetReleaseImage(mc.dynamicClient)
+	})
 	if err != nil {
-		return fmt.Errorf("cannot create OAuth target group: %v", err)
+		return installerrors.Precondition(err, "failed to obtain release image from host cluster")
 	}
 
-	if err = aws.EnsureTarget(apiTGARN, machineIP); err != nil {
-		return fmt.Errorf("cannot create API load balancer target: %v", err)
-	}
-	log.Infof("Created API load balancer target to %s", machineIP)
+	cfg, client, dynamicClient := mc.cfg, mc.client, mc.dynamicClient
+	sshKey, pullSecret, ignitionVersion := mc.sshKey, mc.pullSecret, mc.ignitionVersion
+	infraName, region, creds := mc.infraName, mc.region, mc.creds
+	serviceCIDR, podCIDR := mc.serviceCIDR, mc.podCIDR
+	dnsZoneID, parentDomain, ingressDomain := mc.dnsZoneID, mc.parentDomain, mc.ingressDomain
+	machineNames := mc.machineNames
 
-	if err = aws.EnsureTarget(oauthTGARN, machineIP); err != nil {
-		return fmt.Errorf("cannot create OAuth load balancer target: %v", err)
+	// Over-committed management clusters would otherwise only show pending pods at the end
+	if !skipCapacityCheck && !state.Done("namespace") {
+		footprint, err := controlPlaneFootprint(name, clusterConfig, highAvailability, network.DirectRouting)
+		if err != nil {
+			return installerrors.Precondition(err, "cannot estimate the resources of the control plane")
+		}
+		if err = checkCapacity(client, footprint); err != nil {
+			return err
+		}
 	}
-	log.Infof("Created OAuth load balancer target to %s", machineIP)
 
-	err = aws.EnsureListener(apiLBARN, apiTGARN, 6443, false)
+	// Start creating resources on management cluster
+	err = state.Step("namespace", func() error {
+		_, err := client.CoreV1().Namespaces().Get(name, metav1.GetOptions{})
+		if err == nil {
+			return installerrors.Precondition(nil, "target namespace %s already exists on management cluster", name)
+		}
+		if !errors.IsNotFound(err) {
+			return installerrors.Precondition(err, "unexpected error getting namespaces from management cluster")
+		}
+		if dryRun {
+			log.Infof("Would create namespace %s", name)
+			return nil
+		}
+		log.Infof("Creating namespace %s", name)
+		ns := &corev1.Namespace{}
+		ns.Name = name
+		if _, err = client.CoreV1().Namespaces().Create(ns); err != nil {
+			return installerrors.Apply(err, "failed to create namespace %s", name)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("cannot create API listener: %v", err)
+		return err
 	}
-	log.Infof("Created API load balancer listener")
 
-	err = aws.EnsureListener(apiLBARN, oauthTGARN, externalOauthPort, false)
-	if err != nil {
-		return fmt.Errorf("cannot create OAuth listener: %v", err)
+	// Ensure that the VPN pods can run privileged
+	if network.DirectRouting {
+		log.Infof("Direct routing, the control plane has no VPN pods that run privileged")
+	} else if dryRun {
+		log.Infof("Would allow the VPN service accounts of namespace %s to use the privileged SCC", name)
+	} else if err = installer.EnsureControlPlaneSCC(dynamicClient, name, skipPrivilegedSCC); err != nil {
+		return installerrors.Apply(err, "failed to ensure privileged SCC for the new namespace")
 	}
-	log.Infof("Created OAuth load balancer listener")
 
-	apiDNSName := fmt.Sprintf("api.%s.%s", name, parentDomain)
-	err = aws.EnsureCNameRecord(dnsZoneID, apiDNSName, apiLBDNS)
+	// Create pull secret
+	log.Infof("Creating pull secret")
+	err = state.Step("pull-secret", func() error {
+		if dryRun {
+			return nil
+		}
+		return installer.CreatePullSecret(client, name, pullSecret)
+	})
 	if err != nil {
-		return fmt.Errorf("cannot create API DNS record: %v", err)
+		return installerrors.Apply(err, "failed to create pull secret")
 	}
-	log.Infof("Created DNS record for API name: %s", apiDNSName)
 
-	routerLBName := generateLBResourceName(infraName, name, "apps")
-	routerLBARN, routerLBDNS, err := aws.EnsureNLB(routerLBName, lbInfo.Subnet, "")
+	// Create Kube APIServer service
+	log.Infof("Creating Kube API service")
+	apiNodePort, err := state.IntValue("api-node-port", func() (int, error) {
+		if dryRun {
+			return dryRunNodePort("Kube API")
+		}
+		return installer.CreateKubeAPIServerService(client, name)
+	})
 	if err != nil {
-		return fmt.Errorf("cannot create router load balancer: %v", err)
+		return installerrors.Apply(err, "failed to create kube apiserver service")
 	}
-	log.Infof("Created router load balancer with ARN: %s, DNS: %s", routerLBARN, routerLBDNS)
+	log.Infof("Created Kube API service with NodePort %d", apiNodePort)
 
-	routerHTTPTGName := generateLBResourceName(infraName, name, "http")
-	routerHTTPARN, err := aws.EnsureTargetGroup(lbInfo.VPC, routerHTTPTGName, routerNodePortHTTP)
-	if err != nil {
-		return fmt.Errorf("cannot create router HTTP target group: %v", err)
+	vpnNodePort := 0
+	if !network.DirectRouting {
+		log.Infof("Creating VPN service")
+		vpnNodePort, err = state.IntValue("vpn-node-port", func() (int, error) {
+			if dryRun {
+				return dryRunNodePort("VPN")
+			}
+			return installer.CreateVPNServerService(client, name)
+		})
+		if err != nil {
+			return installerrors.Apply(err, "failed to create vpn server service")
+		}
+		log.Infof("Created VPN service with NodePort %d", vpnNodePort)
 	}
-	log.Infof("Created router HTTP target group ARN: %s", routerHTTPARN)
 
-	err = aws.EnsureListener(routerLBARN, routerHTTPARN, 80, false)
+	log.Infof("Creating Openshift API service")
+	openshiftClusterIP, err := state.StringValue("openshift-api-cluster-ip", func() (string, error) {
+		if dryRun {
+			log.Infof("Would create the Openshift API service, its cluster IP is allocated when it is created")
+			return "", nil
+		}
+		return installer.CreateOpenshiftService(client, name)
+	})
 	if err != nil {
-		return fmt.Errorf("cannot create router HTTP listener: %v", err)
+		return installerrors.Apply(err, "failed to create openshift server service")
 	}
-	log.Infof("Created router HTTP load balancer listener")
+	log.Infof("Created Openshift API service with cluster IP: %s", openshiftClusterIP)
 
-	routerHTTPSTGName := generateLBResourceName(infraName, name, "https")
-	routerHTTPSARN, err := aws.EnsureTargetGroup(lbInfo.VPC, routerHTTPSTGName, routerNodePortHTTPS)
+	oauthNodePort, err := state.IntValue("oauth-node-port", func() (int, error) {
+		if dryRun {
+			return dryRunNodePort("Oauth server")
+		}
+		return installer.CreateOauthService(client, name)
+	})
 	if err != nil {
-		return fmt.Errorf("cannot create router HTTPS target group: %v", err)
+		return installerrors.Apply(err, "failed to create Oauth server service")
 	}
-	log.Infof("Created router HTTPS target group ARN: %s", routerHTTPSARN)
+	log.Infof("Created Oauth server service with NodePort: %d", oauthNodePort)
 
-	err = aws.EnsureListener(routerLBARN, routerHTTPSARN, 443, false)
-	if err != nil {
-		return fmt.Errorf("cannot create router HTTPS listener: %v", err)
+	ignitionNodePort := 0
+	if !ignitionBucket {
+		ignitionNodePort, err = state.IntValue("ignition-node-port", func() (int, error) {
+			if dryRun {
+				return dryRunNodePort("ignition server")
+			}
+			return installer.CreateIgnitionServerService(client, name)
+		})
+		if err != nil {
+			return installerrors.Apply(err, "failed to create ignition server service")
+		}
+		log.Infof("Created ignition server service with NodePort: %d", ignitionNodePort)
 	}
-	log.Infof("Created router HTTPS load balancer listener")
 
-	routerDNSName := fmt.Sprintf("*.apps.%s.%s", name, parentDomain)
-	err = aws.EnsureCNameRecord(dnsZoneID, routerDNSName, routerLBDNS)
+	routerNodePortHTTP, routerNodePortHTTPS, err := routerNodePorts(state, client, network)
 	if err != nil {
-		return fmt.Errorf("cannot create router DNS record: %v", err)
+		return installerrors.Precondition(err, "invalid router node ports")
 	}
-	log.Infof("Created DNS record for router name: %s", routerDNSName)
+	log.Infof("Using router NodePorts: %d, %d", routerNodePortHTTP, routerNodePortHTTPS)
 
-	vpnLBName := generateLBResourceName(infraName, name, "vpn")
-	vpnLBARN, vpnLBDNS, err := aws.EnsureNLB(vpnLBName, lbInfo.Subnet, "")
-	if err != nil {
-		return fmt.Errorf("cannot create vpn load balancer: %v", err)
+	// The router load balancer targets the router node ports of machine workers, or the
+	// node ports of the management cluster service of KubeVirt workers
+	routerHTTPNodePort, routerHTTPSNodePort := routerNodePortHTTP, routerNodePortHTTPS
+	if workers.KubeVirt != nil && dryRun {
+		log.Infof("Would create the KubeVirt router service")
+	} else if workers.KubeVirt != nil {
+		if routerHTTPNodePort, routerHTTPSNodePort, err = ensureKubeVirtRouterService(client, name, routerNodePortHTTP, routerNodePortHTTPS); err != nil {
+			return installerrors.Apply(err, "failed to create KubeVirt router service")
+		}
+		log.Infof("Created KubeVirt router service with NodePorts: %d, %d", routerHTTPNodePort, routerHTTPSNodePort)
 	}
-	log.Infof("Created VPN load balancer with ARN: %s and DNS: %s", vpnLBARN, vpnLBDNS)
 
-	vpnTGARN, err := aws.EnsureUDPTargetGroup(lbInfo.VPC, vpnLBName, vpnNodePort, apiNodePort)
+	// Fetch AWS cloud data
+	result.StartPhase("cloud-infrastructure")
+	aws, err := NewAWSHelper(creds, region, infraName, name)
 	if err != nil {
-		return fmt.Errorf("cannot create VPN target group: %v", err)
+		return installerrors.Precondition(err, "cannot create an AWS client")
 	}
-	log.Infof("Created VPN target group ARN: %s", vpnTGARN)
 
-	if err = aws.EnsureTarget(vpnTGARN, machineID); err != nil {
-		return fmt.Errorf("cannot create VPN load balancer target: %v", err)
+	var lbInfo *LBInfo
+	if dryRun {
+		lbInfo, err = dryRunNetworkInfo(dynamicClient, infraName, network)
+	} else if len(network.VPC) > 0 {
+		lbInfo, err = aws.NetworkInfo(network.VPC, network.Subnets, machineNames)
+	} else {
+		lbInfo, err = aws.LoadBalancerInfo(machineNames)
 	}
-	log.Infof("Created VPN load balancer target to %s", machineID)
-
-	err = aws.EnsureListener(vpnLBARN, vpnTGARN, 1194, true)
 	if err != nil {
-		return fmt.Errorf("cannot create VPN listener: %v", err)
+		return cloudProviderError(err, "cannot get load balancer info")
 	}
-	log.Infof("Created VPN load balancer listener")
+	log.Infof("Using VPC: %s, Zone: %s, Subnet: %s", lbInfo.VPC, lbInfo.Zone, lbInfo.Subnet)
 
-	vpnDNSName := fmt.Sprintf("vpn.%s.%s", name, parentDomain)
-	err = aws.EnsureCNameRecord(dnsZoneID, vpnDNSName, vpnLBDNS)
+	// Load balancers span all zones with workers and target all of their workers, so
+	// that the loss of a single worker or zone does not take down the cluster. A highly
+	// available control plane runs a replica of each component in a different zone.
+	zones := []string{lbInfo.Zone}
+	if highAvailability {
+		if len(lbInfo.Zones) < haControlPlaneReplicas {
+			return installerrors.Precondition(nil, "a highly available control plane requires workers in %d zones, found %d", haControlPlaneReplicas, len(lbInfo.Zones))
+		}
+		zones = lbInfo.Zones
+	}
+	pools, err := nodePools(workers, zones, lbInfo.Zones)
 	if err != nil {
-		return fmt.Errorf("cannot create router DNS record: %v", err)
+		return installerrors.Precondition(err, "invalid node pools")
 	}
-	log.Infof("Created DNS record for VPN: %s", vpnDNSName)
 
-	err = aws.EnsureWorkersAllowNodePortAccess()
+	machineIDs, machineIPs, err := getWorkerMachines(dynamicClient, infraName, lbInfo.Zones)
 	if err != nil {
-		return fmt.Errorf("cannot setup security group for worker nodes: %v", err)
+		return installerrors.Precondition(err, "cannot get machine info")
+	}
+	for i := range machineIDs {
+		log.Infof("Using management machine with ID: %s and IP: %s", machineIDs[i], machineIPs[i])
 	}
-	log.Infof("Ensured that node ports on workers are accessible")
 
-	_, serviceCIDRNet, err := net.ParseCIDR(serviceCIDR)
+	provider := &awsProvider{
+		helper:         aws,
+		dynamicClient:  dynamicClient,
+		infraName:      infraName,
+		clusterName:    name,
+		parentDomain:   parentDomain,
+		dnsZoneID:      dnsZoneID,
+		network:        lbInfo,
+		securityGroup:  network.SecurityGroup,
+		machineIDs:     machineIDs,
+		machineIPs:     machineIPs,
+		private:        private,
+		privateZone:    network.PrivateZone,
+		rootVolumeSize: workers.RootVolumeSize,
+		workerAMI:      workers.AMI,
+		kubeVirt:       workers.KubeVirt,
+		dryRun:         dryRun,
+
+		serviceLoadBalancers: network.ServiceLoadBalancers,
+		client:               client,
+		routes:               network.Routes,
+		ingressDomain:        ingressDomain,
+		sharedIngressDNSName: mc.frontendDNSName,
+	}
+	if network.ExternalDNS {
+		provider.dns = &externalDNSProvider{client: client, namespace: name}
+	}
+	apiEndpoint, err := provider.EnsureAPIEndpoint(installer.APIEndpointPorts{
+		API:      apiNodePort,
+		OAuth:    oauthNodePort,
+		Ignition: ignitionNodePort,
+	})
 	if err != nil {
-		return fmt.Errorf("cannot parse service CIDR %s: %v", serviceCIDR, err)
+		return err
 	}
-
-	_, podCIDRNet, err := net.ParseCIDR(podCIDR)
+	routerEndpoint, err := provider.EnsureIngressEndpoint(routerHTTPNodePort, routerHTTPSNodePort)
 	if err != nil {
-		return fmt.Errorf("cannot parse pod CIDR %s: %v", podCIDR, err)
+		return err
+	}
+	vpnEndpoint := &installer.Endpoint{}
+	if !network.DirectRouting {
+		if vpnEndpoint, err = provider.EnsureVPNEndpoint(vpnNodePort, apiNodePort); err != nil {
+			return err
+		}
 	}
+	apiDNSName := apiEndpoint.DNSName
+	dnsZoneID = provider.dnsZoneID
 
-	serviceCIDRPrefixLen, _ := serviceCIDRNet.Mask.Size()
-	clusterServiceCIDR, exceedsMax := gocidr.NextSubnet(serviceCIDRNet, serviceCIDRPrefixLen)
-	if exceedsMax {
-		return fmt.Errorf("cluster service CIDR exceeds max address space")
+	// The AWS resources are looked up by name when an install is resumed; their IDs are
+	// recorded for troubleshooting
+	provider.record("dns-zone-id", dnsZoneID)
+	if len(provider.privateZoneID) > 0 {
+		provider.record("private-dns-zone-id", provider.privateZoneID)
 	}
+	if err = state.Record(provider.resources); err != nil {
+		return installerrors.Render(err, "failed to record AWS resources")
+	}
+	result.SetCloudResources(provider.resources)
 
-	podCIDRPrefixLen, _ := podCIDRNet.Mask.Size()
-	clusterPodCIDR, exceedsMax := gocidr.NextSubnet(podCIDRNet, podCIDRPrefixLen)
-	if exceedsMax {
-		return fmt.Errorf("cluster pod CIDR exceeds max address space")
+	// The CIDRs of the cluster overlap neither those of the management cluster nor those
+	// of the other clusters installed on it
+	cidrs, err := installer.AllocateClusterCIDRs(client, name, serviceCIDR, podCIDR, dryRun)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot allocate the service and pod CIDRs of the cluster")
 	}
+	log.Infof("The service CIDR of the cluster is %s and its pod CIDR is %s", cidrs.ServiceCIDR, cidrs.PodCIDR)
 
 	params := api.NewClusterParams()
+	if clusterConfig != nil {
+		configured := *clusterConfig
+		params = &configured
+	}
 	params.Namespace = name
+	params.PodSecurity.SkipPrivilegedSCC = skipPrivilegedSCC
 	params.ExternalAPIDNSName = apiDNSName
 	params.ExternalAPIPort = 6443
-	params.ExternalAPIIPAddress = apiPublicIP
-	params.ExternalOpenVPNDNSName = vpnDNSName
-	params.ExternalOpenVPNPort = 1194
+	params.ExternalAPIIPAddress = apiEndpoint.Address
+	params.DirectRouting = network.DirectRouting
+	if !network.DirectRouting {
+		params.ExternalOpenVPNDNSName = vpnEndpoint.DNSName
+		params.ExternalOpenVPNPort = 1194
+		params.OpenVPNNodePort = fmt.Sprintf("%d", vpnNodePort)
+	}
 	params.ExternalOauthPort = externalOauthPort
 	params.APINodePort = uint(apiNodePort)
-	params.ServiceCIDR = clusterServiceCIDR.String()
-	params.PodCIDR = clusterPodCIDR.String()
+	params.ServiceCIDR = cidrs.ServiceCIDR
+	params.PodCIDR = cidrs.PodCIDR
 	params.ReleaseImage = releaseImage
+	params.Arch = workers.Arch
 	params.IngressSubdomain = fmt.Sprintf("apps.%s.%s", name, parentDomain)
 	params.OpenShiftAPIClusterIP = openshiftClusterIP
-	params.OpenVPNNodePort = fmt.Sprintf("%d", vpnNodePort)
 	params.BaseDomain = fmt.Sprintf("%s.%s", name, parentDomain)
 	params.CloudProvider = "AWS"
 	params.InternalAPIPort = 6443
 	params.EtcdClientName = "etcd-client"
-	params.NetworkType = "OpenShiftSDN"
-	params.ImageRegistryHTTPSecret = generateImageRegistrySecret()
+	if len(network.NetworkType) > 0 {
+		params.NetworkType = network.NetworkType
+	}
+	if len(params.NetworkType) == 0 {
+		params.NetworkType = api.NetworkTypeOpenShiftSDN
+	}
+	params.ImageRegistryHTTPSecret, _ = state.StringValue("image-registry-http-secret", func() (string, error) {
+		return installer.GenerateImageRegistrySecret(), nil
+	})
 	params.RouterNodePortHTTP = fmt.Sprintf("%d", routerNodePortHTTP)
 	params.RouterNodePortHTTPS = fmt.Sprintf("%d", routerNodePortHTTPS)
 	params.RouterServiceType = "NodePort"
+	params.APIServiceType = ""
+	params.APIEndpointReconcilerType = ""
+	if network.ServiceLoadBalancers {
+		params.APIServiceType = "LoadBalancer"
+		params.ExternalOauthDNSName = provider.oauthDNSName
+		params.ExternalIgnitionDNSName = provider.ignitionDNSName
+	}
+	// The routers of the management cluster listen on 443. The API server advertises the
+	// address of a management cluster worker, where its secure port is not reachable, so
+	// the endpoints of the kubernetes service are the node port of the API service instead.
+	if network.Routes {
+		params.ExternalAPIPort = 443
+		params.APIEndpointReconcilerType = "none"
+		params.ExternalOauthDNSName = provider.oauthDNSName
+		params.ExternalIgnitionDNSName = provider.ignitionDNSName
+	}
+	// The API server frontend forwards the ports of the API load balancer, so only the
+	// kubernetes service needs the node port, as with routes
+	if network.SharedIngress {
+		params.APIEndpointReconcilerType = "none"
+	}
+	params.NodePools = pools
+	params.IgnitionVersion = ignitionVersion
+	if !ignitionBucket {
+		params.ExternalIgnitionPort = externalIgnitionPort
+		if params.IgnitionServerToken, err = state.StringValue("ignition-server-token", installer.GenerateIgnitionServerToken); err != nil {
+			return installerrors.Render(err, "failed to generate ignition server token")
+		}
+	} else {
+		params.ExternalIgnitionPort = 0
+	}
 	params.Replicas = "1"
-	params.ControlPlaneOperatorControllers = []string{
-		"controller-manager-ca",
-		"auto-approver",
-		"kubeadmin-password",
-		"cluster-operator",
-		"cluster-version",
-		"kubelet-serving-ca",
-		"openshift-apiserver",
-		"openshift-controller-manager",
+	if highAvailability {
+		params.Replicas = fmt.Sprintf("%d", haControlPlaneReplicas)
 	}
+	// The controllers of a cluster.yaml replace the default controllers, but the controllers
+	// of the AWS infrastructure of the cluster follow the flags of the install
+	if len(params.ControlPlaneOperatorControllers) == 0 {
+		params.ControlPlaneOperatorControllers = defaultControlPlaneOperatorControllers
+	}
+	params.ControlPlaneOperatorControllers = setControllers(params.ControlPlaneOperatorControllers, len(infraCredentials.AccessKeyID) > 0, "aws-infra", "aws-machine-targets")
+	params.ControlPlaneOperatorControllers = setControllers(params.ControlPlaneOperatorControllers, ignitionBucket, "aws-ignition-urls")
 	cpOperatorImage := os.Getenv("CONTROL_PLANE_OPERATOR_IMAGE_OVERRIDE")
-	if cpOperatorImage == "" {
-		params.ControlPlaneOperatorImage = defaultControlPlaneOperatorImage
-	} else {
+	if cpOperatorImage != "" {
 		params.ControlPlaneOperatorImage = cpOperatorImage
+	} else if params.ControlPlaneOperatorImage == "" {
+		params.ControlPlaneOperatorImage = defaultControlPlaneOperatorImage
+	}
+	if clusterConfig != nil {
+		warnOverriddenClusterConfig(clusterConfig, params)
+		if errs := config.Validate(params); len(errs) > 0 {
+			return installerrors.Precondition(errs.ToAggregate(), "invalid cluster parameters")
+		}
+	}
+	var clusterCredentials credentials.Value
+	if clusterUser && dryRun {
+		log.Info("Would create the IAM user and image registry bucket of the cluster")
+		params.ImageRegistryS3Bucket = generateBucketName(infraName, name, "registry")
+		params.ImageRegistryS3Region = region
+	} else if clusterUser {
+		log.Info("Creating the IAM user and image registry bucket of the cluster")
+		registryBucket := generateBucketName(infraName, name, "registry")
+		if clusterCredentials, err = ensureClusterCredentials(client, aws, name, registryBucket); err != nil {
+			return err
+		}
+		params.ImageRegistryS3Bucket = registryBucket
+		params.ImageRegistryS3Region = region
 	}
 
-	workingDir, err := ioutil.TempDir("", "")
-	if err != nil {
-		return err
+	result.StartPhase("render")
+	// The PKI is kept in the state directory, so that a resumed install uses the
+	// certificates of the manifests that were already applied
+	workingDir := state.Dir()
+	if dryRun {
+		if workingDir, err = dryRunWorkingDir(state.Dir(), resumed); err != nil {
+			return installerrors.Render(err, "cannot create a working directory for the dry run")
+		}
+		defer os.RemoveAll(workingDir)
 	}
 	log.Infof("The working directory is %s", workingDir)
 	pkiDir := filepath.Join(workingDir, "pki")
-	if err = os.Mkdir(pkiDir, 0755); err != nil {
-		return fmt.Errorf("cannot create temporary PKI directory: %v", err)
+	if !resumed && !dryRun {
+		// Leave nothing of a previous install of a cluster with the same name
+		if err = os.RemoveAll(pkiDir); err != nil {
+			return installerrors.Render(err, "cannot remove PKI of a previous install")
+		}
+	}
+	if err = os.MkdirAll(pkiDir, 0700); err != nil {
+		return installerrors.Render(err, "cannot create PKI directory")
 	}
 	log.Info("Generating PKI")
-	if len(dhParamsFile) > 0 {
-		if err = copyFile(dhParamsFile, filepath.Join(pkiDir, "openvpn-dh.pem")); err != nil {
-			return fmt.Errorf("cannot copy dh parameters file %s: %v", dhParamsFile, err)
+	if len(dhParamsFile) > 0 && !network.DirectRouting {
+		if err = installer.CopyFile(dhParamsFile, filepath.Join(pkiDir, "openvpn-dh.pem")); err != nil {
+			return installerrors.Render(err, "cannot copy dh parameters file %s", dhParamsFile)
 		}
 	}
 	if err := pki.GeneratePKI(params, pkiDir); err != nil {
-		return fmt.Errorf("failed to generate PKI assets: %v", err)
+		return installerrors.Render(err, "failed to generate PKI assets")
 	}
 	manifestsDir := filepath.Join(workingDir, "manifests")
+	if err = os.RemoveAll(manifestsDir); err != nil {
+		return installerrors.Render(err, "cannot remove manifests of a previous run")
+	}
 	if err = os.Mkdir(manifestsDir, 0755); err != nil {
-		return fmt.Errorf("cannot create temporary manifests directory: %v", err)
+		return installerrors.Render(err, "cannot create manifests directory")
 	}
 	pullSecretFile := filepath.Join(workingDir, "pull-secret")
 	if err = ioutil.WriteFile(pullSecretFile, []byte(pullSecret), 0644); err != nil {
-		return fmt.Errorf("failed to create temporary pull secret file: %v", err)
+		return installerrors.Render(err, "failed to create temporary pull secret file")
+	}
+	if len(workers.AMI) == 0 && workers.KubeVirt == nil {
+		if provider.workerAMI, err = workerAMI(releaseImage, workers.Arch, params.OriginReleasePrefix, pullSecretFile, params.ImageContentSources, workers.CoreOSStreamURL, region); err != nil {
+			return err
+		}
 	}
 	log.Info("Generating ignition for workers")
 	if err = ignition.GenerateIgnition(params, sshKey, pullSecretFile, pkiDir, workingDir); err != nil {
-		return fmt.Errorf("cannot generate ignition file for workers: %v", err)
+		return installerrors.Render(err, "cannot generate ignition file for workers")
+	}
+	// Workers fetch the ignition file from either the ignition server, which trusts the
+	// root CA of the cluster, or an S3 bucket
+	var ignitionURL string
+	var ignitionCA []byte
+	if ignitionBucket {
+		if ignitionURL, err = provider.EnsureIgnitionStorage(filepath.Join(workingDir, "bootstrap.ign")); err != nil {
+			return err
+		}
+	} else {
+		if err = installer.GenerateIgnitionServerConfigSecret(filepath.Join(workingDir, "bootstrap.ign"), filepath.Join(manifestsDir, "ignition-server-config-secret.json")); err != nil {
+			return installerrors.Render(err, "failed to generate ignition server config secret")
+		}
+		if ignitionCA, err = ioutil.ReadFile(filepath.Join(pkiDir, "root-ca.crt")); err != nil {
+			return installerrors.Render(err, "cannot read root CA")
+		}
+		if network.ServiceLoadBalancers || network.Routes {
+			ignitionURL = ignition.ServerURL(provider.ignitionDNSName, 443, params.IgnitionServerToken)
+		} else {
+			ignitionURL = ignition.ServerURL(apiDNSName, externalIgnitionPort, params.IgnitionServerToken)
+		}
 	}
-	// Ensure that S3 bucket with ignition file in it exists
-	bucketName := generateBucketName(infraName, name, "ign")
-	log.Infof("Ensuring ignition bucket exists")
-	if err = aws.EnsureIgnitionBucket(bucketName, filepath.Join(workingDir, "bootstrap.ign")); err != nil {
-		return fmt.Errorf("failed to ensure ignition bucket exists: %v", err)
+
+	// Record the AWS resources of the cluster so that the control plane operator can verify them
+	var ignitionURLSecrets []string
+	if ignitionBucket {
+		for _, pool := range params.NodePools {
+			ignitionURLSecrets = append(ignitionURLSecrets, installer.NodePoolUserDataSecretName(name, pool.Name))
+		}
+	}
+	apiLBName := provider.lbName("api")
+	oauthTGName := provider.lbName("oauth")
+	ignitionTGName := provider.lbName("ign")
+	routerLBName := provider.lbName("apps")
+	routerHTTPTGName := provider.lbName("http")
+	routerHTTPSTGName := provider.lbName("https")
+	vpnLBName := provider.lbName("vpn")
+	apiListeners := []awsinfra.Listener{
+		infraListener(6443, elbv2.ProtocolEnumTcp, apiLBName, apiNodePort, elbv2.TargetTypeEnumIp, "", machineIPs...),
+		infraListener(externalOauthPort, elbv2.ProtocolEnumTcp, oauthTGName, oauthNodePort, elbv2.TargetTypeEnumIp, "", machineIPs...),
+	}
+	if !ignitionBucket {
+		apiListeners = append(apiListeners, infraListener(externalIgnitionPort, elbv2.ProtocolEnumTcp, ignitionTGName, ignitionNodePort, elbv2.TargetTypeEnumIp, "", machineIPs...))
+	}
+	var routerTargets []string
+	if workers.KubeVirt != nil {
+		routerTargets = machineIPs
+	}
+	// The load balancers of services are managed by the cloud provider of the management
+	// cluster, routes and a shared ingress have no load balancer of the cluster, and the
+	// records that external-dns registers are not verified
+	var loadBalancers []awsinfra.LoadBalancer
+	var dnsRecords []awsinfra.DNSRecord
+	if !network.ServiceLoadBalancers && !network.Routes && !network.SharedIngress {
+		loadBalancers = append(loadBalancers, awsinfra.LoadBalancer{
+			Name:      apiLBName,
+			Listeners: apiListeners,
+		})
+		dnsRecords = append(dnsRecords, awsinfra.DNSRecord{Name: apiDNSName, LoadBalancer: apiLBName})
+	}
+	if !network.ServiceLoadBalancers || workers.KubeVirt == nil {
+		loadBalancers = append(loadBalancers, awsinfra.LoadBalancer{
+			Name: routerLBName,
+			Listeners: []awsinfra.Listener{
+				infraListener(80, elbv2.ProtocolEnumTcp, routerHTTPTGName, routerHTTPNodePort, elbv2.TargetTypeEnumIp, "", routerTargets...),
+				infraListener(443, elbv2.ProtocolEnumTcp, routerHTTPSTGName, routerHTTPSNodePort, elbv2.TargetTypeEnumIp, "", routerTargets...),
+			},
+		})
+		dnsRecords = append(dnsRecords, awsinfra.DNSRecord{Name: routerEndpoint.DNSName, LoadBalancer: routerLBName})
+	}
+	if !network.DirectRouting {
+		loadBalancers = append(loadBalancers, awsinfra.LoadBalancer{
+			Name: vpnLBName,
+			Listeners: []awsinfra.Listener{
+				infraListener(1194, "UDP", vpnLBName, vpnNodePort, elbv2.TargetTypeEnumInstance, fmt.Sprintf("%d", apiNodePort), machineIDs...),
+			},
+		})
+		dnsRecords = append(dnsRecords, awsinfra.DNSRecord{Name: vpnEndpoint.DNSName, LoadBalancer: vpnLBName})
+	}
+	if network.ExternalDNS {
+		dnsRecords = nil
+	}
+	infra := &awsinfra.InfraConfig{
+		Region:                region,
+		VPC:                   lbInfo.VPC,
+		DNSZoneID:             dnsZoneID,
+		LoadBalancers:         loadBalancers,
+		DNSRecords:            dnsRecords,
+		IgnitionBucket:        provider.ignitionBucket,
+		IgnitionKey:           "worker.ign",
+		IgnitionURLSecrets:    ignitionURLSecrets,
+		WorkerMachinePrefixes: workerMachinePrefixes(infraName, lbInfo.Zones),
+		Tags: map[string]string{
+			fmt.Sprintf("kubernetes.io/cluster/%s", infraName): "owned",
+			ClusterTagKey: name,
+		},
+		Repair: true,
+	}
+	log.Infof("Creating AWS infrastructure configmap")
+	err = state.Step("aws-infra-configmap", func() error {
+		if dryRun {
+			return nil
+		}
+		return createAWSInfraConfigMap(client, name, infra)
+	})
+	if err != nil {
+		return installerrors.Apply(err, "failed to create AWS infrastructure configmap")
+	}
+	if len(infraCredentials.AccessKeyID) > 0 {
+		err = state.Step("aws-infra-credentials", func() error {
+			if dryRun {
+				return nil
+			}
+			return createAWSInfraCredentialsSecret(client, name, infraCredentials)
+		})
+		if err != nil {
+			return installerrors.Apply(err, "failed to create AWS infrastructure credentials secret")
+		}
+	} else {
+		log.Info("No AWS infrastructure credentials given, infrastructure verification is disabled")
+	}
+	// The auto-approver validates the node names of CSRs against the worker machines, and
+	// the node garbage collector deletes the nodes of removed machines
+	err = state.Step("machine-reader-role", func() error {
+		if dryRun {
+			return nil
+		}
+		return createMachineReaderRole(client, name, ignitionURLSecrets)
+	})
+	if err != nil {
+		return installerrors.Apply(err, "failed to allow the control plane operator to read machines")
 	}
 
 	log.Info("Rendering Manifests")
-	render.RenderPKISecrets(pkiDir, manifestsDir, true, true, true)
+	if err := render.RenderPKISecrets(pkiDir, manifestsDir, true, !params.DirectRouting, false, true, render.CertRotationEnabled(params), params.ExternalIgnitionPort != 0, params.EtcdEncryption.Provider != "", params.Monitoring.Enabled); err != nil {
+		return installerrors.Render(err, "failed to render PKI secrets")
+	}
+	if err := render.RenderNamedCertsSecret(params, pkiDir, manifestsDir); err != nil {
+		return installerrors.Render(err, "failed to render named certificates secret")
+	}
 	caBytes, err := ioutil.ReadFile(filepath.Join(pkiDir, "combined-ca.crt"))
 	if err != nil {
-		return fmt.Errorf("failed to render PKI secrets: %v", err)
+		return installerrors.Render(err, "failed to render PKI secrets")
 	}
 	params.OpenshiftAPIServerCABundle = base64.StdEncoding.EncodeToString(caBytes)
-	if err = render.RenderClusterManifests(params, pullSecretFile, manifestsDir, true, true, true, true); err != nil {
-		return fmt.Errorf("failed to render manifests for cluster: %v", err)
+	if err = render.RenderClusterManifests(params, pullSecretFile, manifestsDir, true, !params.DirectRouting, false, true, true); err != nil {
+		return installerrors.Render(err, "failed to render manifests for cluster")
+	}
+	err = state.Step("cluster-params", func() error {
+		if dryRun {
+			return nil
+		}
+		return installer.CreateClusterParamsSecret(client, name, params)
+	})
+	if err != nil {
+		return installerrors.Apply(err, "failed to store the parameters of the cluster")
 	}
 
 	// Create a nodeport service for the router
-	if err = generateRouterService(filepath.Join(manifestsDir, "router-service.json")); err != nil {
-		return fmt.Errorf("failed to generate router service: %v", err)
+	if err = installer.GenerateRouterService(routerNodePortHTTP, routerNodePortHTTPS, filepath.Join(manifestsDir, "router-service.json")); err != nil {
+		return installerrors.Render(err, "failed to generate router service")
 	}
 
-	// Create a machineset for the new cluster's worker nodes
-	if err = generateWorkerMachineset(dynamicClient, infraName, lbInfo.Zone, name, routerLBName, filepath.Join(manifestsDir, "machineset.json")); err != nil {
-		return fmt.Errorf("failed to generate worker machineset: %v", err)
+	// Create a machineset and user data secret for each node pool of the new cluster, or
+	// the virtual machines of the pool and their user data secret
+	if workers.KubeVirt != nil {
+		if provider.userData, err = ignition.UserData(ignitionURL, ignitionCA, params.IgnitionVersion); err != nil {
+			return installerrors.Render(err, "failed to generate user data for KubeVirt workers")
+		}
 	}
-	if err = generateUserDataSecret(name, bucketName, filepath.Join(manifestsDir, "machine-user-data.json")); err != nil {
-		return fmt.Errorf("failed to generate user data secret: %v", err)
+	for i, pool := range params.NodePools {
+		if err = provider.EnsureWorkerPool(pool, filepath.Join(manifestsDir, fmt.Sprintf("machineset-%d.json", i))); err != nil {
+			return err
+		}
+		if workers.KubeVirt != nil {
+			continue
+		}
+		if err = installer.GenerateNodePoolUserDataSecret(name, pool.Name, ignitionURL, ignitionCA, params.IgnitionVersion, filepath.Join(manifestsDir, fmt.Sprintf("machine-user-data-%d.json", i))); err != nil {
+			return installerrors.Render(err, "failed to generate user data secret for node pool %s", pool.Name)
+		}
 	}
-	kubeadminPassword, err := generateKubeadminPassword()
+	kubeadminPassword, err := state.StringValue("kubeadmin-password", installer.GenerateKubeadminPassword)
 	if err != nil {
-		return fmt.Errorf("failed to generate kubeadmin password: %v", err)
+		return installerrors.Render(err, "failed to generate kubeadmin password")
 	}
-	if err = generateKubeadminPasswordTargetSecret(kubeadminPassword, filepath.Join(manifestsDir, "kubeadmin-secret.json")); err != nil {
-		return fmt.Errorf("failed to create kubeadmin secret manifest for target cluster: %v", err)
+	if err = installer.GenerateKubeadminPasswordTargetSecret(kubeadminPassword, filepath.Join(manifestsDir, "kubeadmin-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create kubeadmin secret manifest for target cluster")
 	}
-	if err = generateKubeadminPasswordSecret(kubeadminPassword, filepath.Join(manifestsDir, "kubeadmin-host-secret.json")); err != nil {
-		return fmt.Errorf("failed to create kubeadmin secret manifest for management cluster: %v", err)
+	if err = installer.GenerateKubeadminPasswordSecret(kubeadminPassword, filepath.Join(manifestsDir, "kubeadmin-host-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create kubeadmin secret manifest for management cluster")
 	}
-	if err = generateKubeconfigSecret(filepath.Join(pkiDir, "admin.kubeconfig"), filepath.Join(manifestsDir, "kubeconfig-secret.json")); err != nil {
-		return fmt.Errorf("failed to create kubeconfig secret manifest for management cluster: %v", err)
+	if err = installer.GenerateKubeconfigSecret(filepath.Join(pkiDir, "admin.kubeconfig"), filepath.Join(manifestsDir, "kubeconfig-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create kubeconfig secret manifest for management cluster")
 	}
-	if err = generateTargetPullSecret([]byte(pullSecret), filepath.Join(manifestsDir, "user-pull-secret.json")); err != nil {
-		return fmt.Errorf("failed to create pull secret manifest for target cluster: %v", err)
+	if err = installer.GenerateTargetPullSecret([]byte(pullSecret), filepath.Join(manifestsDir, "user-pull-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create pull secret manifest for target cluster")
+	}
+	if network.Routes || network.SharedIngress {
+		if err = installer.GenerateKubernetesEndpointsTarget(machineIPs, apiNodePort, filepath.Join(manifestsDir, "kubernetes-endpoints.json")); err != nil {
+			return installerrors.Render(err, "failed to create kubernetes endpoints manifest for target cluster")
+		}
+	}
+	if clusterUser {
+		if err = installer.GenerateAWSCredentialsTargetSecret(clusterCredentials.AccessKeyID, clusterCredentials.SecretAccessKey, filepath.Join(manifestsDir, "aws-creds-secret.json")); err != nil {
+			return installerrors.Render(err, "failed to create AWS credentials secret manifest for target cluster")
+		}
+		if err = installer.GenerateImageRegistryS3TargetSecret(clusterCredentials.AccessKeyID, clusterCredentials.SecretAccessKey, filepath.Join(manifestsDir, "image-registry-s3-secret.json")); err != nil {
+			return installerrors.Render(err, "failed to create image registry credentials secret manifest for target cluster")
+		}
 	}
 
+	result.StartPhase("apply")
 	// Create the system branding manifest (cannot be applied because it's too large)
-	if err = createBrandingSecret(client, name, filepath.Join(manifestsDir, "v4-0-config-system-branding.yaml")); err != nil {
-		return fmt.Errorf("failed to create oauth branding secret: %v", err)
+	if !dryRun {
+		if err = installer.CreateBrandingSecret(client, name, filepath.Join(manifestsDir, "v4-0-config-system-branding.yaml")); err != nil {
+			return installerrors.Apply(err, "failed to create oauth branding secret")
+		}
 	}
 
 	excludedDir, err := ioutil.TempDir("", "")
 	if err != nil {
-		return fmt.Errorf("failed to create a temporary directory for excluded manifests")
+		return installerrors.Render(err, "failed to create a temporary directory for excluded manifests")
 	}
 	log.Infof("Excluded manifests directory: %s", excludedDir)
-	if err = applyManifests(cfg, name, manifestsDir, excludeManifests, excludedDir); err != nil {
-		return fmt.Errorf("failed to apply manifests: %v", err)
+	if dryRun {
+		if err = installer.DiffManifests(cfg, name, manifestsDir, installer.ExcludeManifests, excludedDir, out); err != nil {
+			return installerrors.Apply(err, "failed to dry run manifests")
+		}
+		log.Infof("Dry run of the install of cluster %s complete, nothing was created", name)
+		return nil
+	}
+	if err = installer.ApplyManifests(cfg, name, manifestsDir, installer.ExcludeManifests, excludedDir); err != nil {
+		return installerrors.Apply(err, "failed to apply manifests")
 	}
 	log.Infof("Cluster resources applied")
 
 	if waitForReady {
-		log.Infof("Waiting up to 10 minutes for API endpoint to be available.")
-		if err = waitForAPIEndpoint(pkiDir, apiDNSName); err != nil {
-			return fmt.Errorf("failed to access API endpoint: %v", err)
+		result.StartPhase("wait")
+		log.Infof("Waiting up to %s for API endpoint to be available.", waitConfig.PhaseTimeout(installer.WaitPhaseAPIEndpoint))
+		if err = installer.WaitForAPIEndpoint(pkiDir, apiDNSName, int(params.ExternalAPIPort), waitConfig); err != nil {
+			gatherDiagnostics(client, nil, name, state.Dir(), result)
+			return installerrors.Timeout(err, "failed to access API endpoint")
 		}
-		log.Infof("API is available at %s", fmt.Sprintf("https://%s:6443", apiDNSName))
+		log.Infof("API is available at %s", fmt.Sprintf("https://%s:%d", apiDNSName, params.ExternalAPIPort))
 
-		log.Infof("Waiting up to 5 minutes for bootstrap pod to complete.")
-		if err = waitForBootstrapPod(client, name); err != nil {
-			return fmt.Errorf("failed to wait for bootstrap pod to complete: %v", err)
+		log.Infof("Waiting up to %s for bootstrap pod to complete.", waitConfig.PhaseTimeout(installer.WaitPhaseBootstrapPod))
+		if err = installer.WaitForBootstrapPod(client, name, waitConfig); err != nil {
+			gatherDiagnostics(client, nil, name, state.Dir(), result)
+			return installerrors.Timeout(err, "failed to wait for bootstrap pod to complete")
 		}
 		log.Infof("Bootstrap pod has completed.")
 
-		targetClusterCfg, err := getTargetClusterConfig(pkiDir)
+		targetClusterCfg, err := installer.GetTargetClusterConfig(pkiDir)
 		if err != nil {
-			return fmt.Errorf("cannot create target cluster client config: %v", err)
+			return installerrors.Precondition(err, "cannot create target cluster client config")
 		}
 		targetClient, err := kubeclient.NewForConfig(targetClusterCfg)
 		if err != nil {
-			return fmt.Errorf("cannot create target cluster client: %v", err)
+			return installerrors.Precondition(err, "cannot create target cluster client")
 		}
 
-		log.Infof("Waiting up to 10 minutes for nodes to be ready.")
-		if err = waitForNodesReady(targetClient, workerMachineSetCount); err != nil {
-			return fmt.Errorf("failed to wait for nodes ready: %v", err)
+		log.Infof("Waiting up to %s for nodes to be ready.", waitConfig.PhaseTimeout(installer.WaitPhaseNodesReady))
+		if err = installer.WaitForNodesReady(targetClient, nodePoolReplicas(params.NodePools), waitConfig); err != nil {
+			gatherDiagnostics(client, targetClusterCfg, name, state.Dir(), result)
+			return installerrors.Timeout(err, "failed to wait for nodes ready")
 		}
-		log.Infof("Nodes (%d) are ready", workerMachineSetCount)
+		log.Infof("Nodes (%d) are ready", nodePoolReplicas(params.NodePools))
 
-		log.Infof("Waiting up to 15 minutes for cluster operators to be ready.")
-		if err = waitForClusterOperators(targetClusterCfg); err != nil {
-			return fmt.Errorf("failed to wait for cluster operators: %v", err)
+		log.Infof("Waiting up to %s for cluster operators to be ready.", waitConfig.PhaseTimeout(installer.WaitPhaseClusterOperators))
+		if err = installer.WaitForClusterOperators(targetClusterCfg, waitConfig); err != nil {
+			gatherDiagnostics(client, targetClusterCfg, name, state.Dir(), result)
+			return installerrors.Timeout(err, "failed to wait for cluster operators")
 		}
 	}
 
-	log.Infof("Cluster API URL: %s", fmt.Sprintf("https://%s:6443", apiDNSName))
-	log.Infof("Kubeconfig is available in secret %q in the %s namespace", "admin-kubeconfig", name)
-	log.Infof("Console URL:  %s", fmt.Sprintf("https://console-openshift-console.%s", params.IngressSubdomain))
-	log.Infof("kubeadmin password is available in secret %q in the %s namespace", "kubeadmin-password", name)
+	if err = state.Complete(); err != nil {
+		return installerrors.Render(err, "failed to complete install state")
+	}
+	apiURL := fmt.Sprintf("https://%s:%d", apiDNSName, params.ExternalAPIPort)
+	result.Installed(apiURL, installer.ConsoleURL(params.IngressSubdomain), name)
+	log.Infof("Cluster API URL: %s", apiURL)
+	log.Infof("Kubeconfig is available in secret %q in the %s namespace", installer.AdminKubeconfigSecretName, name)
+	log.Infof("Console URL:  %s", installer.ConsoleURL(params.IngressSubdomain))
+	log.Infof("kubeadmin password is available in secret %q in the %s namespace, or with: hypershift-aws console-password %s", installer.KubeadminPasswordSecretName, name, name)
 	return nil
 }
 
-func applyManifests(cfg *rest.Config, namespace, directory string, exclude []string, excludedDir string) error {
-	for _, f := range exclude {
-		name := filepath.Join(directory, f)
-		targetName := filepath.Join(excludedDir, f)
-		if err := os.Rename(name, targetName); err != nil {
-			return fmt.Errorf("cannot move %s: %v", name, err)
-		}
-	}
-	backoff := wait.Backoff{
-		Steps:    3,
-		Duration: 10 * time.Second,
-		Factor:   1.0,
-		Jitter:   0.1,
-	}
-	attempt := 0
-	err := retry.OnError(backoff, func(err error) bool { return true }, func() error {
-		attempt++
-		log.Infof("Applying Manifests. Attempt %d/3", attempt)
-		applier := NewApplier(cfg, namespace)
-		return applier.ApplyFile(directory)
-	})
+// gatherDiagnostics collects the diagnostics of a cluster that failed to become ready in
+// dir, so that the failure can be investigated once the install returns
+func gatherDiagnostics(client kubeclient.Interface, targetCfg *rest.Config, name, dir string, result *installer.Result) {
+	log.Info("Gathering diagnostics of the cluster")
+	fileName, err := installer.GatherDiagnostics(client, targetCfg, name, dir)
 	if err != nil {
-		return fmt.Errorf("Failed to apply manifests: %v", err)
+		log.WithError(err).Warn("Failed to gather diagnostics of the cluster")
+		return
 	}
-	return nil
+	result.SetDiagnostics(fileName)
+	log.Infof("Diagnostics of the cluster are in %s", fileName)
 }
 
-func createBrandingSecret(client kubeclient.Interface, namespace, fileName string) error {
-	objBytes, err := ioutil.ReadFile(fileName)
+// routerNodePorts returns the router node ports recorded in the install state, or else the
+// node ports of the network configuration, which are allocated unless they are specified
+func routerNodePorts(state *installer.InstallState, client kubeclient.Interface, network NetworkConfig) (int, int, error) {
+	httpNodePort, err := state.IntValue("router-http-node-port", func() (int, error) {
+		httpNodePort, httpsNodePort, err := installer.RouterNodePorts(client, network.RouterHTTPNodePort, network.RouterHTTPSNodePort)
+		if err != nil {
+			return 0, err
+		}
+		return httpNodePort, state.Record(map[string]string{"router-https-node-port": strconv.Itoa(httpsNodePort)})
+	})
 	if err != nil {
-		return err
+		return 0, 0, err
 	}
-	requiredObj, err := runtime.Decode(coreCodecs.UniversalDecoder(corev1.SchemeGroupVersion), objBytes)
+	httpsNodePort, err := state.IntValue("router-https-node-port", func() (int, error) {
+		return 0, fmt.Errorf("the HTTPS router node port was not recorded")
+	})
 	if err != nil {
-		return err
-	}
-	secret, ok := requiredObj.(*corev1.Secret)
-	if !ok {
-		return fmt.Errorf("object in %s is not a secret", fileName)
+		return 0, 0, err
 	}
-	_, err = client.CoreV1().Secrets(namespace).Create(secret)
-	return err
+	return httpNodePort, httpsNodePort, nil
 }
 
-func createKubeAPIServerService(client kubeclient.Interface, namespace string) (int, error) {
-	svc := &corev1.Service{}
-	svc.Name = "kube-apiserver"
-	svc.Spec.Selector = map[string]string{"app": "kube-apiserver"}
-	svc.Spec.Type = corev1.ServiceTypeNodePort
-	svc.Spec.Ports = []corev1.ServicePort{
-		{
-			Port:       6443,
-			Protocol:   corev1.ProtocolTCP,
-			TargetPort: intstr.FromInt(6443),
-		},
+// loadInstallState loads the install state of the cluster named name from stateDir, or
+// from the default state directory of the cluster if stateDir is empty
+func loadInstallState(name, stateDir string) (*installer.InstallState, error) {
+	if len(stateDir) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		stateDir = filepath.Join(home, ".hypershift", "aws", name)
 	}
-	svc, err := client.CoreV1().Services(namespace).Create(svc)
-	if err != nil {
-		return 0, err
+	if err := os.MkdirAll(stateDir, 0700); err != nil {
+		return nil, err
 	}
-	return int(svc.Spec.Ports[0].NodePort), nil
+	return installer.LoadInstallState(stateDir)
 }
 
-func createVPNServerService(client kubeclient.Interface, namespace string) (int, error) {
-	svc := &corev1.Service{}
-	svc.Name = "openvpn-server"
-	svc.Spec.Selector = map[string]string{"app": "openvpn-server"}
-	svc.Spec.Type = corev1.ServiceTypeNodePort
-	svc.Spec.Ports = []corev1.ServicePort{
-		{
-			Port:       1194,
-			Protocol:   corev1.ProtocolUDP,
-			TargetPort: intstr.FromInt(1194),
-		},
-	}
-	svc, err := client.CoreV1().Services(namespace).Create(svc)
+func createAWSInfraConfigMap(client kubeclient.Interface, namespace string, infra *awsinfra.InfraConfig) error {
+	infraBytes, err := json.Marshal(infra)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	return int(svc.Spec.Ports[0].NodePort), nil
+	configMap := &corev1.ConfigMap{}
+	configMap.Name = awsinfra.InfraConfigMapName
+	configMap.Data = map[string]string{awsinfra.InfraConfigKey: string(infraBytes)}
+	_, err = client.CoreV1().ConfigMaps(namespace).Create(configMap)
+	return err
 }
 
-func createOpenshiftService(client kubeclient.Interface, namespace string) (string, error) {
-	svc := &corev1.Service{}
-	svc.Name = "openshift-apiserver"
-	svc.Spec.Selector = map[string]string{"app": "openshift-apiserver"}
-	svc.Spec.Type = corev1.ServiceTypeClusterIP
-	svc.Spec.Ports = []corev1.ServicePort{
-		{
-			Name:       "https",
-			Port:       443,
-			Protocol:   corev1.ProtocolTCP,
-			TargetPort: intstr.FromInt(8443),
-		},
-	}
-	svc, err := client.CoreV1().Services(namespace).Create(svc)
-	if err != nil {
-		return "", err
+// createAWSInfraCredentialsSecret creates the secret with the AWS credentials used by
+// the control plane operator to verify the cluster's AWS resources.
+func createAWSInfraCredentialsSecret(client kubeclient.Interface, namespace string, creds credentials.Value) error {
+	secret := &corev1.Secret{}
+	secret.Name = awsinfra.CredentialsSecretName
+	secret.Data = map[string][]byte{
+		"aws_access_key_id":     []byte(creds.AccessKeyID),
+		"aws_secret_access_key": []byte(creds.SecretAccessKey),
 	}
-	return svc.Spec.ClusterIP, nil
+	_, err := client.CoreV1().Secrets(namespace).Create(secret)
+	return err
 }
 
-func createOauthService(client kubeclient.Interface, namespace string) (int, error) {
-	svc := &corev1.Service{}
-	svc.Name = "oauth-openshift"
-	svc.Spec.Selector = map[string]string{"app": "oauth-openshift"}
-	svc.Spec.Type = corev1.ServiceTypeNodePort
-	svc.Spec.Ports = []corev1.ServicePort{
-		{
-			Name:       "https",
-			Port:       443,
-			Protocol:   corev1.ProtocolTCP,
-			TargetPort: intstr.FromInt(6443),
+// createMachineReaderRole allows the control plane operator of a cluster to watch the
+// machines and machinesets of the management cluster, so that it can keep load balancer
+// targets in sync and validate the CSRs of the workers, and to update the given user data
+// secrets, so that it can refresh their ignition URLs
+func createMachineReaderRole(client kubeclient.Interface, namespace string, userDataSecrets []string) error {
+	role := &rbacv1.Role{
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{awsinfra.MachineResource.Group},
+				Resources: []string{awsinfra.MachineResource.Resource, "machinesets"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
 		},
 	}
-	svc, err := client.CoreV1().Services(namespace).Create(svc)
-	if err != nil {
-		return 0, err
-	}
-	return int(svc.Spec.Ports[0].NodePort), nil
-}
-
-func createPullSecret(client kubeclient.Interface, namespace, data string) error {
-	secret := &corev1.Secret{}
-	secret.Name = "pull-secret"
-	secret.Data = map[string][]byte{".dockerconfigjson": []byte(data)}
-	secret.Type = corev1.SecretTypeDockerConfigJson
-	_, err := client.CoreV1().Secrets(namespace).Create(secret)
-	if err != nil {
+	if len(userDataSecrets) > 0 {
+		role.Rules = append(role.Rules, rbacv1.PolicyRule{
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			ResourceNames: userDataSecrets,
+			Verbs:         []string{"get", "update"},
+		})
+	}
+	role.Name = machineReaderRoleName(namespace)
+	role.Namespace = awsinfra.MachineNamespace
+	if _, err := client.RbacV1().Roles(awsinfra.MachineNamespace).Create(role); err != nil && !errors.IsAlreadyExists(err) {
 		return err
 	}
-	retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		sa, err := client.CoreV1().ServiceAccounts(namespace).Get("default", metav1.GetOptions{})
-		if err != nil {
-			return err
-		}
-		sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: "pull-secret"})
-		_, err = client.CoreV1().ServiceAccounts(namespace).Update(sa)
+	binding := &rbacv1.RoleBinding{
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     role.Name,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      "control-plane-operator",
+				Namespace: namespace,
+			},
+		},
+	}
+	binding.Name = role.Name
+	binding.Namespace = awsinfra.MachineNamespace
+	if _, err := client.RbacV1().RoleBindings(awsinfra.MachineNamespace).Create(binding); err != nil && !errors.IsAlreadyExists(err) {
 		return err
-	})
+	}
 	return nil
 }
 
-func generateTargetPullSecret(data []byte, fileName string) error {
-	secret := &corev1.Secret{}
-	secret.Name = "pull-secret"
-	secret.Namespace = "openshift-config"
-	secret.Data = map[string][]byte{".dockerconfigjson": data}
-	secret.Type = corev1.SecretTypeDockerConfigJson
-	secretBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), secret)
-	if err != nil {
+// removeMachineReaderRole removes the role that allows the control plane operator of a
+// cluster to watch the machines of the management cluster
+func removeMachineReaderRole(client kubeclient.Interface, namespace string) error {
+	name := machineReaderRoleName(namespace)
+	if err := client.RbacV1().RoleBindings(awsinfra.MachineNamespace).Delete(name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
 		return err
 	}
-	configMap := &corev1.ConfigMap{}
-	configMap.APIVersion = "v1"
-	configMap.Kind = "ConfigMap"
-	configMap.Name = "user-manifest-pullsecret"
-	configMap.Data = map[string]string{"data": string(secretBytes)}
-	configMapBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), configMap)
-	if err != nil {
+	if err := client.RbacV1().Roles(awsinfra.MachineNamespace).Delete(name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
 		return err
 	}
-	return ioutil.WriteFile(fileName, configMapBytes, 0644)
+	return nil
 }
 
-func getPullSecret(client kubeclient.Interface) (string, error) {
-	secret, err := client.CoreV1().Secrets("openshift-config").Get("pull-secret", metav1.GetOptions{})
-	if err != nil {
-		return "", err
-	}
-	pullSecret, ok := secret.Data[".dockerconfigjson"]
-	if !ok {
-		return "", fmt.Errorf("did not find pull secret data in secret")
-	}
-	return string(pullSecret), nil
+func machineReaderRoleName(namespace string) string {
+	return fmt.Sprintf("hypershift-%s-machine-reader", namespace)
 }
 
-func getAWSCredentials(client kubeclient.Interface) (string, string, error) {
-
-	secret, err := client.CoreV1().Secrets("kube-system").Get("aws-creds", metav1.GetOptions{})
-	if err != nil {
-		return "", "", err
-	}
-	key, ok := secret.Data["aws_access_key_id"]
-	if !ok {
-		return "", "", fmt.Errorf("did not find an AWS access key")
-	}
-	secretKey, ok := secret.Data["aws_secret_access_key"]
-	if !ok {
-		return "", "", fmt.Errorf("did not find an AWS secret access key")
+func infraListener(port int, protocol, tgName string, tgPort int, targetType, healthCheckPort string, targets ...string) awsinfra.Listener {
+	return awsinfra.Listener{
+		Port:     int64(port),
+		Protocol: protocol,
+		TargetGroup: awsinfra.TargetGroup{
+			Name:            tgName,
+			Port:            int64(tgPort),
+			Protocol:        protocol,
+			TargetType:      targetType,
+			HealthCheckPort: healthCheckPort,
+			Targets:         targets,
+		},
 	}
-	return string(key), string(secretKey), nil
 }
 
-func getMachineNames(client dynamic.Interface) ([]string, error) {
+// getWorkerMachines returns the instance IDs and internal IPs of the management cluster
+// workers in the given zones. Machines that are not provisioned yet are skipped.
+func getWorkerMachines(client dynamic.Interface, infraName string, zones []string) ([]string, []string, error) {
 	machineGroupVersion, err := schema.ParseGroupVersion("machine.openshift.io/v1beta1")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	machineGroupVersionResource := machineGroupVersion.WithResource("machines")
 	list, err := client.Resource(machineGroupVersionResource).Namespace("openshift-machine-api").List(metav1.ListOptions{})
 	if err != nil {
-		return nil, err
-	}
-	names := []string{}
-	for _, m := range list.Items {
-		names = append(names, m.GetName())
-	}
-	return names, nil
-}
-
-func getMachineInfo(client dynamic.Interface, machineNames []string, prefix string) (string, string, error) {
-	name := ""
-	for _, machineName := range machineNames {
-		if strings.HasPrefix(machineName, prefix) {
-			name = machineName
-			break
-		}
-	}
-	if name == "" {
-		return "", "", fmt.Errorf("did not find machine with prefix %s", prefix)
-	}
-	machineGroupVersion, err := schema.ParseGroupVersion("machine.openshift.io/v1beta1")
-	if err != nil {
-		return "", "", err
-	}
-	machineGroupVersionResource := machineGroupVersion.WithResource("machines")
-	machine, err := client.Resource(machineGroupVersionResource).Namespace("openshift-machine-api").Get(name, metav1.GetOptions{})
-	if err != nil {
-		return "", "", err
-	}
-	instanceID, exists, err := unstructured.NestedString(machine.Object, "status", "providerStatus", "instanceId")
-	if !exists || err != nil {
-		return "", "", fmt.Errorf("did not find instanceId on machine object: %v", err)
-	}
-	addresses, exists, err := unstructured.NestedSlice(machine.Object, "status", "addresses")
-	if !exists || err != nil {
-		return "", "", fmt.Errorf("did not find addresses on machine object: %v", err)
+		return nil, nil, err
 	}
-	machineIP := ""
-	for _, addr := range addresses {
-		addrType, _, err := unstructured.NestedString(addr.(map[string]interface{}), "type")
-		if err != nil {
-			return "", "", fmt.Errorf("cannot get address type: %v", err)
-		}
-		if addrType != "InternalIP" {
+	var instanceIDs, machineIPs []string
+	for i := range list.Items {
+		machine := &list.Items[i]
+		if !isWorkerInZones(machine.GetName(), infraName, zones) {
 			continue
 		}
-		machineIP, _, err = unstructured.NestedString(addr.(map[string]interface{}), "address")
-		if err != nil {
-			return "", "", fmt.Errorf("cannot get machine address: %v", err)
+		instanceID, machineIP := awsinfra.MachineInstance(machine)
+		if len(instanceID) == 0 || len(machineIP) == 0 {
+			log.Debugf("Skipping machine %s that is not provisioned", machine.GetName())
+			continue
 		}
+		instanceIDs = append(instanceIDs, instanceID)
+		machineIPs = append(machineIPs, machineIP)
 	}
-	if machineIP == "" {
-		return "", "", fmt.Errorf("could not find machine internal IP")
+	if len(instanceIDs) == 0 {
+		return nil, nil, fmt.Errorf("did not find provisioned worker machines in zones %s", strings.Join(zones, ", "))
 	}
-	return instanceID, machineIP, nil
+	return instanceIDs, machineIPs, nil
 }
 
-func getSSHPublicKey(client dynamic.Interface) ([]byte, error) {
-	machineConfigGroupVersion, err := schema.ParseGroupVersion("machineconfiguration.openshift.io/v1")
-	if err != nil {
-		return nil, err
-	}
-	machineConfigGroupVersionResource := machineConfigGroupVersion.WithResource("machineconfigs")
-	obj, err := client.Resource(machineConfigGroupVersionResource).Get("99-master-ssh", metav1.GetOptions{})
-	if err != nil {
-		return nil, err
-	}
-	obj.GetName()
-	users, exists, err := unstructured.NestedSlice(obj.Object, "spec", "config", "passwd", "users")
-	if !exists || err != nil {
-		return nil, fmt.Errorf("could not find users slice in ssh machine config: %v", err)
+// isWorkerInZones returns whether a machine is a worker of the management cluster in one of the zones
+func isWorkerInZones(machineName, infraName string, zones []string) bool {
+	for _, prefix := range workerMachinePrefixes(infraName, zones) {
+		if strings.HasPrefix(machineName, prefix) {
+			return true
+		}
 	}
-	keys, exists, err := unstructured.NestedStringSlice(users[0].(map[string]interface{}), "sshAuthorizedKeys")
-	if !exists || err != nil {
-		return nil, fmt.Errorf("could not find authorized keys for machine config: %v", err)
+	return false
+}
+
+// workerMachinePrefixes returns the name prefixes of the management cluster workers in the zones
+func workerMachinePrefixes(infraName string, zones []string) []string {
+	prefixes := []string{}
+	for _, zone := range zones {
+		prefixes = append(prefixes, fmt.Sprintf("%s-worker-%s", infraName, zone))
 	}
-	return []byte(keys[0]), nil
+	return prefixes
 }
 
 func getInfrastructureInfo(client dynamic.Interface) (string, string, error) {
@@ -840,103 +1333,19 @@ func getInfrastructureInfo(client dynamic.Interface) (string, string, error) {
 	return infraName, region, nil
 }
 
-func getDNSZoneInfo(client dynamic.Interface) (string, string, error) {
-	configGroupVersion, err := schema.ParseGroupVersion("config.openshift.io/v1")
-	if err != nil {
-		return "", "", err
-	}
-	dnsGroupVersionResource := configGroupVersion.WithResource("dnses")
-	obj, err := client.Resource(dnsGroupVersionResource).Get("cluster", metav1.GetOptions{})
-	if err != nil {
-		return "", "", err
-	}
-	publicZoneID, exists, err := unstructured.NestedString(obj.Object, "spec", "publicZone", "id")
-	if !exists || err != nil {
-		return "", "", fmt.Errorf("could not find the dns public zone id in the dns resource: %v", err)
-	}
-	domain, exists, err := unstructured.NestedString(obj.Object, "spec", "baseDomain")
-	if !exists || err != nil {
-		return "", "", fmt.Errorf("could not find the dns base domain in the dns resource: %v", err)
-	}
-	parts := strings.Split(domain, ".")
-	baseDomain := strings.Join(parts[1:], ".")
-
-	return publicZoneID, baseDomain, nil
-}
-
-// loadConfig loads a REST Config as per the rules specified in GetConfig
-func loadConfig() (*rest.Config, error) {
-	if len(os.Getenv("KUBECONFIG")) > 0 {
-		return clientcmd.BuildConfigFromFlags("", os.Getenv("KUBECONFIG"))
-	}
-	if c, err := rest.InClusterConfig(); err == nil {
-		return c, nil
-	}
-	if usr, err := user.Current(); err == nil {
-		if c, err := clientcmd.BuildConfigFromFlags(
-			"", filepath.Join(usr.HomeDir, ".kube", "config")); err == nil {
-			return c, nil
-		}
-	}
-	return nil, fmt.Errorf("could not locate a kubeconfig")
-}
-
-func getReleaseImage(client dynamic.Interface) (string, error) {
-	configGroupVersion, err := schema.ParseGroupVersion("config.openshift.io/v1")
-	if err != nil {
-		return "", err
-	}
-	clusterVersionGVR := configGroupVersion.WithResource("clusterversions")
-	obj, err := client.Resource(clusterVersionGVR).Get("version", metav1.GetOptions{})
-	if err != nil {
-		return "", err
-	}
-	releaseImage, exists, err := unstructured.NestedString(obj.Object, "status", "desired", "image")
-	if !exists || err != nil {
-		return "", fmt.Errorf("cannot find release image in cluster version resource")
-	}
-	return releaseImage, nil
-}
-
-func getNetworkInfo(client dynamic.Interface) (string, string, error) {
-	configGroupVersion, err := schema.ParseGroupVersion("config.openshift.io/v1")
-	if err != nil {
-		return "", "", err
-	}
-	networkGroupVersionResource := configGroupVersion.WithResource("networks")
-	obj, err := client.Resource(networkGroupVersionResource).Get("cluster", metav1.GetOptions{})
-	if err != nil {
-		return "", "", err
-	}
-	serviceNetworks, exists, err := unstructured.NestedSlice(obj.Object, "status", "serviceNetwork")
-	if !exists || err != nil || len(serviceNetworks) == 0 {
-		return "", "", fmt.Errorf("could not find service networks in the network status: %v", err)
-	}
-	serviceCIDR := serviceNetworks[0].(string)
-
-	podNetworks, exists, err := unstructured.NestedSlice(obj.Object, "status", "clusterNetwork")
-	if !exists || err != nil || len(podNetworks) == 0 {
-		return "", "", fmt.Errorf("could not find cluster networks in the network status: %v", err)
-	}
-	podCIDR, exists, err := unstructured.NestedString(podNetworks[0].(map[string]interface{}), "cidr")
-	if !exists || err != nil {
-		return "", "", fmt.Errorf("cannot find cluster network cidr: %v", err)
-	}
-	return serviceCIDR, podCIDR, nil
-}
-
-func generateWorkerMachineset(client dynamic.Interface, infraName, zone, namespace, lbName, fileName string) error {
+// generateWorkerMachineset generates a machineset for a node pool of the cluster, based on
+// the management cluster's worker machineset in the zone of the pool
+func generateWorkerMachineset(client dynamic.Interface, infraName, namespace, lbName, workerName string, pool api.NodePool, rootVolumeSize int, ami string, fileName string) error {
 	machineGV, err := schema.ParseGroupVersion("machine.openshift.io/v1beta1")
 	if err != nil {
 		return err
 	}
 	machineSetGVR := machineGV.WithResource("machinesets")
-	obj, err := client.Resource(machineSetGVR).Namespace("openshift-machine-api").Get(fmt.Sprintf("%s-worker-%s", infraName, zone), metav1.GetOptions{})
+	obj, err := client.Resource(machineSetGVR).Namespace("openshift-machine-api").Get(fmt.Sprintf("%s-worker-%s", infraName, pool.Zone), metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
 
-	workerName := generateMachineSetName(infraName, namespace, "worker")
 	object := obj.Object
 
 	unstructured.RemoveNestedField(object, "status")
@@ -947,199 +1356,79 @@ func generateWorkerMachineset(client dynamic.Interface, infraName, zone, namespa
 	unstructured.RemoveNestedField(object, "metadata", "uid")
 	unstructured.RemoveNestedField(object, "spec", "template", "spec", "metadata")
 	unstructured.RemoveNestedField(object, "spec", "template", "spec", "providerSpec", "value", "publicIp")
-	unstructured.SetNestedField(object, int64(workerMachineSetCount), "spec", "replicas")
+	unstructured.RemoveNestedField(object, "metadata", "labels")
+	unstructured.SetNestedField(object, int64(pool.Replicas), "spec", "replicas")
 	unstructured.SetNestedField(object, workerName, "metadata", "name")
+	unstructured.SetNestedField(object, namespace, "metadata", "labels", machineSetClusterLabel)
+	unstructured.SetNestedField(object, pool.Name, "metadata", "labels", nodePoolLabel)
 	unstructured.SetNestedField(object, workerName, "spec", "selector", "matchLabels", "machine.openshift.io/cluster-api-machineset")
 	unstructured.SetNestedField(object, workerName, "spec", "template", "metadata", "labels", "machine.openshift.io/cluster-api-machineset")
-	unstructured.SetNestedField(object, fmt.Sprintf("%s-user-data", namespace), "spec", "template", "spec", "providerSpec", "value", "userDataSecret", "name")
+	unstructured.SetNestedField(object, installer.NodePoolUserDataSecretName(namespace, pool.Name), "spec", "template", "spec", "providerSpec", "value", "userDataSecret", "name")
 	loadBalancer := map[string]interface{}{}
 	unstructured.SetNestedField(loadBalancer, lbName, "name")
 	unstructured.SetNestedField(loadBalancer, "network", "type")
 	loadBalancers := []interface{}{loadBalancer}
 	unstructured.SetNestedSlice(object, loadBalancers, "spec", "template", "spec", "providerSpec", "value", "loadBalancers")
-
-	machineSetBytes, err := json.Marshal(object)
-	if err != nil {
-		return err
-	}
-	return ioutil.WriteFile(fileName, machineSetBytes, 0644)
-}
-
-func generateUserDataSecret(namespace, bucketName, fileName string) error {
-	secret := &corev1.Secret{}
-	secret.Kind = "Secret"
-	secret.APIVersion = "v1"
-	secret.Name = fmt.Sprintf("%s-user-data", namespace)
-	secret.Namespace = "openshift-machine-api"
-
-	disableTemplatingValue := []byte(base64.StdEncoding.EncodeToString([]byte("true")))
-	userDataValue := []byte(fmt.Sprintf(`{"ignition":{"config":{"append":[{"source":"https://%s.s3.amazonaws.com/worker.ign","verification":{}}]},"security":{},"timeouts":{},"version":"2.2.0"},"networkd":{},"passwd":{},"storage":{},"systemd":{}}`, bucketName))
-
-	secret.Data = map[string][]byte{
-		"disableTemplating": disableTemplatingValue,
-		"userData":          userDataValue,
-	}
-
-	secretBytes, err := json.Marshal(secret)
-	if err != nil {
-		return err
-	}
-	return ioutil.WriteFile(fileName, secretBytes, 0644)
-}
-
-func copyFile(src, dest string) error {
-	in, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer in.Close()
-	out, err := os.Create(dest)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-	_, err = io.Copy(out, in)
-	return err
-}
-
-func ensurePrivilegedSCC(client dynamic.Interface, namespace string) error {
-	securityGV, err := schema.ParseGroupVersion("security.openshift.io/v1")
-	if err != nil {
+	if err = setWorkerProviderSpec(object, WorkerConfig{InstanceType: pool.InstanceType, RootVolumeSize: rootVolumeSize, AMI: ami}); err != nil {
 		return err
 	}
-	sccGVR := securityGV.WithResource("securitycontextconstraints")
-	obj, err := client.Resource(sccGVR).Get("privileged", metav1.GetOptions{})
-	if err != nil {
+	if err = setNodePoolMetadata(object, pool); err != nil {
 		return err
 	}
-	users, exists, err := unstructured.NestedStringSlice(obj.Object, "users")
-	if err != nil {
+	if err = setSpotMarketOptions(object, pool); err != nil {
 		return err
 	}
-	userSet := sets.NewString()
-	if exists {
-		userSet.Insert(users...)
-	}
-	svcAccount := fmt.Sprintf("system:serviceaccount:%s:default", namespace)
-	if userSet.Has(svcAccount) {
-		// No need to update anything, service account already has privileged SCC
-		return nil
-	}
-	userSet.Insert(svcAccount)
 
-	if err = unstructured.SetNestedStringSlice(obj.Object, userSet.List(), "users"); err != nil {
-		return err
-	}
-
-	_, err = client.Resource(sccGVR).Update(obj, metav1.UpdateOptions{})
-	return err
-}
-
-func generateKubeadminPasswordTargetSecret(password string, fileName string) error {
-	secret := &corev1.Secret{}
-	secret.APIVersion = "v1"
-	secret.Kind = "Secret"
-	secret.Name = "kubeadmin"
-	secret.Namespace = "kube-system"
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return err
-	}
-	secret.Data = map[string][]byte{"kubeadmin": passwordHash}
-
-	secretBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), secret)
-	if err != nil {
-		return err
-	}
-	configMap := &corev1.ConfigMap{}
-	configMap.APIVersion = "v1"
-	configMap.Kind = "ConfigMap"
-	configMap.Name = "user-manifest-kubeadmin-password"
-	configMap.Data = map[string]string{"data": string(secretBytes)}
-	configMapBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), configMap)
+	machineSetBytes, err := json.Marshal(object)
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(fileName, configMapBytes, 0644)
+	return ioutil.WriteFile(fileName, machineSetBytes, 0644)
 }
 
-func generateRouterService(fileName string) error {
-	svc := &corev1.Service{}
-	svc.APIVersion = "v1"
-	svc.Kind = "Service"
-	svc.Name = "router-default"
-	svc.Namespace = "openshift-ingress"
-	svc.Labels = map[string]string{
-		"app":    "router",
-		"router": "router-default",
-	}
-	svc.Spec.Ports = []corev1.ServicePort{
-		{
-			Name:       "http",
-			NodePort:   routerNodePortHTTP,
-			Port:       80,
-			Protocol:   corev1.ProtocolTCP,
-			TargetPort: intstr.FromString("http"),
-		},
-		{
-			Name:       "https",
-			NodePort:   routerNodePortHTTPS,
-			Port:       443,
-			Protocol:   corev1.ProtocolTCP,
-			TargetPort: intstr.FromString("https"),
-		},
+// setWorkerProviderSpec sets the instance type, AMI and root volume size of a worker
+// machineset when they are configured
+func setWorkerProviderSpec(object map[string]interface{}, workers WorkerConfig) error {
+	providerSpec := []string{"spec", "template", "spec", "providerSpec", "value"}
+	if len(workers.InstanceType) > 0 {
+		if err := unstructured.SetNestedField(object, workers.InstanceType, append(providerSpec, "instanceType")...); err != nil {
+			return err
+		}
 	}
-	svc.Spec.Selector = map[string]string{
-		"ingresscontroller.operator.openshift.io/deployment-ingresscontroller": "default",
+	if len(workers.AMI) > 0 {
+		if err := unstructured.SetNestedField(object, map[string]interface{}{"id": workers.AMI}, append(providerSpec, "ami")...); err != nil {
+			return err
+		}
 	}
-	svc.Spec.SessionAffinity = corev1.ServiceAffinityNone
-	svc.Spec.Type = corev1.ServiceTypeNodePort
-
-	svcBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), svc)
-	if err != nil {
-		return err
+	if workers.RootVolumeSize == 0 {
+		return nil
 	}
-
-	configMap := &corev1.ConfigMap{}
-	configMap.APIVersion = "v1"
-	configMap.Kind = "ConfigMap"
-	configMap.Name = "user-manifest-router-service"
-	configMap.Data = map[string]string{"data": string(svcBytes)}
-	configMapBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), configMap)
+	blockDevices, _, err := unstructured.NestedSlice(object, append(providerSpec, "blockDevices")...)
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(fileName, configMapBytes, 0644)
-}
-
-func generateKubeadminPasswordSecret(password string, fileName string) error {
-	secret := &corev1.Secret{}
-	secret.APIVersion = "v1"
-	secret.Kind = "Secret"
-	secret.Name = "kubeadmin-password"
-	secret.Data = map[string][]byte{"password": []byte(password)}
-	secretBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), secret)
-	if err != nil {
-		return err
-	}
-	return ioutil.WriteFile(fileName, secretBytes, 0644)
-}
-
-func generateKubeconfigSecret(kubeconfigFile, manifestFilename string) error {
-	secret := &corev1.Secret{}
-	secret.APIVersion = "v1"
-	secret.Kind = "Secret"
-	secret.Name = "admin-kubeconfig"
-	kubeconfigBytes, err := ioutil.ReadFile(kubeconfigFile)
-	if err != nil {
-		return err
+	found := false
+	for _, device := range blockDevices {
+		device, ok := device.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("unexpected block device in worker machineset: %v", device)
+		}
+		// The block device without a device name is the root volume
+		if _, hasName := device["deviceName"]; hasName {
+			continue
+		}
+		if err := unstructured.SetNestedField(device, int64(workers.RootVolumeSize), "ebs", "volumeSize"); err != nil {
+			return err
+		}
+		found = true
 	}
-	secret.Data = map[string][]byte{"kubeconfig": kubeconfigBytes}
-	secretBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), secret)
-	if err != nil {
-		return err
+	if !found {
+		root := map[string]interface{}{}
+		unstructured.SetNestedField(root, int64(workers.RootVolumeSize), "ebs", "volumeSize")
+		unstructured.SetNestedField(root, "gp2", "ebs", "volumeType")
+		blockDevices = append(blockDevices, root)
 	}
-	return ioutil.WriteFile(manifestFilename, secretBytes, 0644)
+	return unstructured.SetNestedSlice(object, blockDevices, append(providerSpec, "blockDevices")...)
 }
 
 func updateOAuthDeployment(client kubeclient.Interface, namespace string) error {
@@ -1157,113 +1446,14 @@ func updateOAuthDeployment(client kubeclient.Interface, namespace string) error
 	return err
 }
 
-func generateImageRegistrySecret() string {
-	num := make([]byte, 64)
-	rand.Read(num)
-	return hex.EncodeToString(num)
-}
-
-func generateKubeadminPassword() (string, error) {
-	const (
-		lowerLetters = "abcdefghijkmnopqrstuvwxyz"
-		upperLetters = "ABCDEFGHIJKLMNPQRSTUVWXYZ"
-		digits       = "23456789"
-		all          = lowerLetters + upperLetters + digits
-		length       = 23
-	)
-	var password string
-	for i := 0; i < length; i++ {
-		n, err := crand.Int(crand.Reader, big.NewInt(int64(len(all))))
-		if err != nil {
-			return "", err
-		}
-		newchar := string(all[n.Int64()])
-		if password == "" {
-			password = newchar
-		}
-		if i < length-1 {
-			n, err = crand.Int(crand.Reader, big.NewInt(int64(len(password)+1)))
-			if err != nil {
-				return "", err
-			}
-			j := n.Int64()
-			password = password[0:j] + newchar + password[j:]
-		}
-	}
-	pw := []rune(password)
-	for _, replace := range []int{5, 11, 17} {
-		pw[replace] = '-'
-	}
-	return string(pw), nil
-}
-
-func getTargetClusterConfig(pkiDir string) (*rest.Config, error) {
-	return clientcmd.BuildConfigFromFlags("", filepath.Join(pkiDir, "admin.kubeconfig"))
-}
-
 func generateLBResourceName(infraName, clusterName, suffix string) string {
-	return getName(fmt.Sprintf("%s-%s", infraName, clusterName), suffix, 32)
+	return installer.GetName(fmt.Sprintf("%s-%s", infraName, clusterName), suffix, 32)
 }
 
 func generateBucketName(infraName, clusterName, suffix string) string {
-	return getName(fmt.Sprintf("%s-%s", infraName, clusterName), suffix, 63)
+	return installer.GetName(fmt.Sprintf("%s-%s", infraName, clusterName), suffix, 63)
 }
 
 func generateMachineSetName(infraName, clusterName, suffix string) string {
-	return getName(fmt.Sprintf("%s-%s", infraName, clusterName), suffix, 43)
-}
-
-// getName returns a name given a base ("deployment-5") and a suffix ("deploy")
-// It will first attempt to join them with a dash. If the resulting name is longer
-// than maxLength: if the suffix is too long, it will truncate the base name and add
-// an 8-character hash of the [base]-[suffix] string.  If the suffix is not too long,
-// it will truncate the base, add the hash of the base and return [base]-[hash]-[suffix]
-func getName(base, suffix string, maxLength int) string {
-	if maxLength <= 0 {
-		return ""
-	}
-	name := fmt.Sprintf("%s-%s", base, suffix)
-	if len(name) <= maxLength {
-		return name
-	}
-
-	baseLength := maxLength - 10 /*length of -hash-*/ - len(suffix)
-
-	// if the suffix is too long, ignore it
-	if baseLength < 0 {
-		prefix := base[0:min(len(base), max(0, maxLength-9))]
-		// Calculate hash on initial base-suffix string
-		shortName := fmt.Sprintf("%s-%s", prefix, hash(name))
-		return shortName[:min(maxLength, len(shortName))]
-	}
-
-	prefix := base[0:baseLength]
-	// Calculate hash on initial base-suffix string
-	return fmt.Sprintf("%s-%s-%s", prefix, hash(base), suffix)
-}
-
-// max returns the greater of its 2 inputs
-func max(a, b int) int {
-	if b > a {
-		return b
-	}
-	return a
-}
-
-// min returns the lesser of its 2 inputs
-func min(a, b int) int {
-	if b < a {
-		return b
-	}
-	return a
-}
-
-// hash calculates the hexadecimal representation (8-chars)
-// of the hash of the passed in string using the FNV-a algorithm
-func hash(s string) string {
-	hash := fnv.New32a()
-	hash.Write([]byte(s))
-	intHash := hash.Sum32()
-	result := fmt.Sprintf("%08x", intHash)
-	return result
+	return installer.GetName(fmt.Sprintf("%s-%s", infraName, clusterName), suffix, 43)
 }
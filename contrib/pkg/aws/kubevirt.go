@@ -0,0 +1,216 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+const (
+	kubeVirtRouterServiceName = "kubevirt-router"
+
+	defaultKubeVirtCores  = 4
+	defaultKubeVirtMemory = "16Gi"
+)
+
+var virtualMachineGVR = schema.GroupVersionResource{Group: "kubevirt.io", Version: "v1alpha3", Resource: "virtualmachines"}
+
+// KubeVirtConfig is the configuration of workers that run as KubeVirt virtual machines in
+// the control plane namespace of the management cluster, instead of as machines of its
+// Machine API
+type KubeVirtConfig struct {
+	// Image is a container disk image with the RHCOS OpenStack disk image, which reads its
+	// ignition config from the config drive of the virtual machine
+	Image string
+	// Cores is the number of CPU cores of each virtual machine
+	Cores int
+	// Memory is the memory of each virtual machine, as a Kubernetes quantity
+	Memory string
+}
+
+// validate verifies that the KubeVirt configuration can be installed
+func (c *KubeVirtConfig) validate() error {
+	if len(c.Image) == 0 {
+		return fmt.Errorf("a container disk image is required for KubeVirt workers")
+	}
+	if c.Cores < 0 {
+		return fmt.Errorf("the cores of KubeVirt workers cannot be negative, got %d", c.Cores)
+	}
+	if len(c.Memory) > 0 {
+		if _, err := resource.ParseQuantity(c.Memory); err != nil {
+			return fmt.Errorf("invalid memory %q of KubeVirt workers: %v", c.Memory, err)
+		}
+	}
+	return nil
+}
+
+// validateKubeVirtNodePools verifies that the node pools only use settings that apply to
+// virtual machines. Node labels and taints are applied by the Machine API, which does not
+// manage the virtual machines.
+func validateKubeVirtNodePools(pools []api.NodePool) error {
+	for _, pool := range pools {
+		if pool.SpotMarketOptions != nil {
+			return fmt.Errorf("node pool %s cannot use spot instances with KubeVirt workers", pool.Name)
+		}
+		if len(pool.Labels) > 0 || len(pool.Taints) > 0 {
+			return fmt.Errorf("node pool %s cannot have labels or taints with KubeVirt workers", pool.Name)
+		}
+	}
+	return nil
+}
+
+// checkKubeVirtInstalled verifies that the virtual machine API of KubeVirt is served by the
+// management cluster
+func checkKubeVirtInstalled(client dynamic.Interface) error {
+	_, err := client.Resource(virtualMachineGVR).Namespace(metav1.NamespaceDefault).List(metav1.ListOptions{Limit: 1})
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("KubeVirt is not installed on the management cluster")
+	}
+	return err
+}
+
+// ensureKubeVirtRouterService ensures a node port service in the control plane namespace
+// that forwards to the HTTP and HTTPS router node ports of the KubeVirt workers, and returns
+// its own HTTP and HTTPS node ports. The router load balancer targets the management
+// cluster workers on these node ports.
+func ensureKubeVirtRouterService(client kubeclient.Interface, namespace string, routerHTTPNodePort, routerHTTPSNodePort int) (int, int, error) {
+	svc, err := client.CoreV1().Services(namespace).Get(kubeVirtRouterServiceName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		svc = &corev1.Service{}
+		svc.Name = kubeVirtRouterServiceName
+		svc.Spec.Selector = map[string]string{machineSetClusterLabel: namespace}
+		svc.Spec.Type = corev1.ServiceTypeNodePort
+		svc.Spec.Ports = []corev1.ServicePort{
+			{
+				Name:       "http",
+				Port:       80,
+				Protocol:   corev1.ProtocolTCP,
+				TargetPort: intstr.FromInt(routerHTTPNodePort),
+			},
+			{
+				Name:       "https",
+				Port:       443,
+				Protocol:   corev1.ProtocolTCP,
+				TargetPort: intstr.FromInt(routerHTTPSNodePort),
+			},
+		}
+		svc, err = client.CoreV1().Services(namespace).Create(svc)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(svc.Spec.Ports[0].NodePort), int(svc.Spec.Ports[1].NodePort), nil
+}
+
+// generateKubeVirtWorkers generates a list with the user data secret and the virtual
+// machines of a node pool of the cluster. The virtual machines boot the container disk
+// image of the configuration with the user data on their config drive.
+func generateKubeVirtWorkers(namespace, vmPrefix string, pool api.NodePool, config *KubeVirtConfig, userData []byte, fileName string) error {
+	secretName := installer.NodePoolUserDataSecretName(namespace, pool.Name)
+	secret := &corev1.Secret{}
+	secret.Kind = "Secret"
+	secret.APIVersion = "v1"
+	secret.Name = secretName
+	secret.Namespace = namespace
+	secret.Labels = map[string]string{machineSetClusterLabel: namespace}
+	secret.Data = map[string][]byte{"userdata": userData}
+	secretObject, err := toUnstructured(secret)
+	if err != nil {
+		return err
+	}
+
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{"kind": "List", "apiVersion": "v1"}}
+	list.Items = append(list.Items, *secretObject)
+	for i := 0; i < pool.Replicas; i++ {
+		vm, err := kubeVirtVirtualMachine(namespace, fmt.Sprintf("%s-%d", vmPrefix, i), secretName, pool, config)
+		if err != nil {
+			return err
+		}
+		list.Items = append(list.Items, *vm)
+	}
+	listBytes, err := list.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, listBytes, 0644)
+}
+
+// kubeVirtVirtualMachine returns a running virtual machine of a node pool
+func kubeVirtVirtualMachine(namespace, name, userDataSecret string, pool api.NodePool, config *KubeVirtConfig) (*unstructured.Unstructured, error) {
+	cores := config.Cores
+	if cores == 0 {
+		cores = defaultKubeVirtCores
+	}
+	memory := config.Memory
+	if len(memory) == 0 {
+		memory = defaultKubeVirtMemory
+	}
+	labels := map[string]interface{}{
+		machineSetClusterLabel: namespace,
+		nodePoolLabel:          pool.Name,
+	}
+
+	vm := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	vm.SetAPIVersion(virtualMachineGVR.GroupVersion().String())
+	vm.SetKind("VirtualMachine")
+	vm.SetName(name)
+	vm.SetNamespace(namespace)
+	object := vm.Object
+	fields := []struct {
+		value interface{}
+		path  []string
+	}{
+		{labels, []string{"metadata", "labels"}},
+		{true, []string{"spec", "running"}},
+		{labels, []string{"spec", "template", "metadata", "labels"}},
+		{int64(cores), []string{"spec", "template", "spec", "domain", "cpu", "cores"}},
+		{memory, []string{"spec", "template", "spec", "domain", "resources", "requests", "memory"}},
+		{[]interface{}{
+			map[string]interface{}{"name": "rootdisk", "disk": map[string]interface{}{"bus": "virtio"}},
+			map[string]interface{}{"name": "configdrive", "disk": map[string]interface{}{"bus": "virtio"}},
+		}, []string{"spec", "template", "spec", "domain", "devices", "disks"}},
+		{[]interface{}{
+			map[string]interface{}{"name": "default", "masquerade": map[string]interface{}{}},
+		}, []string{"spec", "template", "spec", "domain", "devices", "interfaces"}},
+		{[]interface{}{
+			map[string]interface{}{"name": "default", "pod": map[string]interface{}{}},
+		}, []string{"spec", "template", "spec", "networks"}},
+		{[]interface{}{
+			map[string]interface{}{"name": "rootdisk", "containerDisk": map[string]interface{}{"image": config.Image}},
+			map[string]interface{}{"name": "configdrive", "cloudInitConfigDrive": map[string]interface{}{
+				"secretRef": map[string]interface{}{"name": userDataSecret},
+			}},
+		}, []string{"spec", "template", "spec", "volumes"}},
+	}
+	for _, field := range fields {
+		if err := unstructured.SetNestedField(object, field.value, field.path...); err != nil {
+			return nil, err
+		}
+	}
+	return vm, nil
+}
+
+func toUnstructured(obj interface{}) (*unstructured.Unstructured, error) {
+	objBytes, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	object := &unstructured.Unstructured{}
+	if err = object.UnmarshalJSON(objBytes); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
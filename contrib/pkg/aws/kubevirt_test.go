@@ -0,0 +1,62 @@
+package aws
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestGenerateKubeVirtWorkers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubevirt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "workers.json")
+	pool := api.NodePool{Name: "worker", Replicas: 2}
+	if err = generateKubeVirtWorkers("test", "worker", pool, &KubeVirtConfig{Image: "rhcos", Cores: 2}, []byte("{}"), fileName); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	listBytes, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	list := &unstructured.UnstructuredList{}
+	if err = list.UnmarshalJSON(listBytes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list.Items) != 3 {
+		t.Fatalf("expected a secret and 2 virtual machines, got %d items", len(list.Items))
+	}
+	if kind := list.Items[0].GetKind(); kind != "Secret" {
+		t.Errorf("expected the user data secret first, got %s", kind)
+	}
+	for i, name := range []string{"worker-0", "worker-1"} {
+		vm := list.Items[i+1]
+		if vm.GetKind() != "VirtualMachine" || vm.GetName() != name || vm.GetNamespace() != "test" {
+			t.Errorf("unexpected virtual machine %s/%s of kind %s", vm.GetNamespace(), vm.GetName(), vm.GetKind())
+		}
+		cores, _, _ := unstructured.NestedInt64(vm.Object, "spec", "template", "spec", "domain", "cpu", "cores")
+		memory, _, _ := unstructured.NestedString(vm.Object, "spec", "template", "spec", "domain", "resources", "requests", "memory")
+		if cores != 2 || memory != defaultKubeVirtMemory {
+			t.Errorf("unexpected cores %d and memory %s of %s", cores, memory, name)
+		}
+		labels, _, _ := unstructured.NestedStringMap(vm.Object, "spec", "template", "metadata", "labels")
+		if labels[machineSetClusterLabel] != "test" {
+			t.Errorf("expected the pods of %s to be selected by the router service, got labels %v", name, labels)
+		}
+		volumes, _, _ := unstructured.NestedSlice(vm.Object, "spec", "template", "spec", "volumes")
+		if len(volumes) != 2 {
+			t.Fatalf("expected 2 volumes, got %v", volumes)
+		}
+		secret, _, _ := unstructured.NestedString(volumes[1].(map[string]interface{}), "cloudInitConfigDrive", "secretRef", "name")
+		if secret != list.Items[0].GetName() {
+			t.Errorf("expected the config drive of %s to use the user data secret, got %q", name, secret)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package aws
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+const (
+	// nodePoolLabel identifies the node pool of a worker machineset
+	nodePoolLabel = "hypershift.openshift.io/node-pool"
+)
+
+// validateNodePools verifies that the node pools have unique names that can be used in
+// resource names, and valid replicas, spot market options, labels and taints
+func validateNodePools(pools []api.NodePool) error {
+	names := sets.NewString()
+	total := 0
+	for _, pool := range pools {
+		if errs := validation.IsDNS1123Label(pool.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid node pool name %q: %s", pool.Name, strings.Join(errs, ", "))
+		}
+		if names.Has(pool.Name) {
+			return fmt.Errorf("duplicate node pool %s", pool.Name)
+		}
+		names.Insert(pool.Name)
+		if pool.Replicas < 0 {
+			return fmt.Errorf("the replicas of node pool %s cannot be negative, got %d", pool.Name, pool.Replicas)
+		}
+		total += pool.Replicas
+		if spot := pool.SpotMarketOptions; spot != nil {
+			if len(spot.MaxPrice) > 0 {
+				if price, err := strconv.ParseFloat(spot.MaxPrice, 64); err != nil || price <= 0 {
+					return fmt.Errorf("invalid spot max price %q of node pool %s", spot.MaxPrice, pool.Name)
+				}
+			}
+			if spot.OnDemandFallbackReplicas < 0 {
+				return fmt.Errorf("the on-demand fallback replicas of node pool %s cannot be negative, got %d", pool.Name, spot.OnDemandFallbackReplicas)
+			}
+			if spot.OnDemandFallbackReplicas > 0 {
+				fallback := onDemandFallbackPoolName(pool.Name)
+				if errs := validation.IsDNS1123Label(fallback); len(errs) > 0 {
+					return fmt.Errorf("invalid on-demand fallback pool name %q: %s", fallback, strings.Join(errs, ", "))
+				}
+				if names.Has(fallback) {
+					return fmt.Errorf("duplicate node pool %s", fallback)
+				}
+				names.Insert(fallback)
+				total += spot.OnDemandFallbackReplicas
+			}
+		}
+		for key, value := range pool.Labels {
+			if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+				return fmt.Errorf("invalid label %q of node pool %s: %s", key, pool.Name, strings.Join(errs, ", "))
+			}
+			if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+				return fmt.Errorf("invalid value of label %s of node pool %s: %s", key, pool.Name, strings.Join(errs, ", "))
+			}
+		}
+		for _, taint := range pool.Taints {
+			if errs := validation.IsQualifiedName(taint.Key); len(errs) > 0 {
+				return fmt.Errorf("invalid taint %q of node pool %s: %s", taint.Key, pool.Name, strings.Join(errs, ", "))
+			}
+			switch corev1.TaintEffect(taint.Effect) {
+			case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+			default:
+				return fmt.Errorf("invalid effect %q of taint %s of node pool %s", taint.Effect, taint.Key, pool.Name)
+			}
+		}
+	}
+	if total < 1 {
+		return fmt.Errorf("the node pools must have at least 1 worker")
+	}
+	return nil
+}
+
+// nodePools returns the node pools of a cluster. Without configured pools, the workers are
+// spread across the zones of the control plane in a pool per zone, named as the machinesets
+// of earlier versions of the installer. Pools without an instance type or zone get the
+// instance type of the worker configuration and the first zone of the control plane.
+// Pools can only be placed in zones with management cluster workers. Spot pools with
+// on-demand fallback replicas are followed by their on-demand fallback pool.
+func nodePools(workers WorkerConfig, zones, workerZones []string) ([]api.NodePool, error) {
+	if len(workers.NodePools) == 0 {
+		pools := make([]api.NodePool, 0, len(zones))
+		for i, zone := range zones {
+			name := "worker"
+			if len(zones) > 1 {
+				name = fmt.Sprintf("worker-%s", zone)
+			}
+			pools = append(pools, api.NodePool{
+				Name:         name,
+				InstanceType: workers.InstanceType,
+				Replicas:     machineSetReplicas(workers.Count, len(zones), i),
+				Zone:         zone,
+			})
+		}
+		return pools, nil
+	}
+	available := sets.NewString(workerZones...)
+	pools := make([]api.NodePool, 0, len(workers.NodePools))
+	for _, pool := range workers.NodePools {
+		if len(pool.InstanceType) == 0 {
+			pool.InstanceType = workers.InstanceType
+		}
+		if len(pool.Zone) == 0 {
+			pool.Zone = zones[0]
+		}
+		if !available.Has(pool.Zone) {
+			return nil, fmt.Errorf("zone %s of node pool %s has no management cluster workers, available zones: %s", pool.Zone, pool.Name, strings.Join(available.List(), ", "))
+		}
+		pools = append(pools, pool)
+		if pool.SpotMarketOptions != nil && pool.SpotMarketOptions.OnDemandFallbackReplicas > 0 {
+			fallback := pool
+			fallback.Name = onDemandFallbackPoolName(pool.Name)
+			fallback.Replicas = pool.SpotMarketOptions.OnDemandFallbackReplicas
+			fallback.SpotMarketOptions = nil
+			pools = append(pools, fallback)
+		}
+	}
+	return pools, nil
+}
+
+func onDemandFallbackPoolName(pool string) string {
+	return fmt.Sprintf("%s-on-demand", pool)
+}
+
+// nodePoolReplicas returns the total number of nodes of the pools
+func nodePoolReplicas(pools []api.NodePool) int {
+	total := 0
+	for _, pool := range pools {
+		total += pool.Replicas
+	}
+	return total
+}
+
+// setSpotMarketOptions requests spot instances for the machines of a spot pool, and
+// on-demand instances for the machines of other pools
+func setSpotMarketOptions(object map[string]interface{}, pool api.NodePool) error {
+	providerSpec := []string{"spec", "template", "spec", "providerSpec", "value", "spotMarketOptions"}
+	if pool.SpotMarketOptions == nil {
+		unstructured.RemoveNestedField(object, providerSpec...)
+		return nil
+	}
+	options := map[string]interface{}{}
+	if len(pool.SpotMarketOptions.MaxPrice) > 0 {
+		options["maxPrice"] = pool.SpotMarketOptions.MaxPrice
+	}
+	return unstructured.SetNestedMap(object, options, providerSpec...)
+}
+
+// setNodePoolMetadata sets the labels and taints of the nodes of a pool in its machineset
+func setNodePoolMetadata(object map[string]interface{}, pool api.NodePool) error {
+	if len(pool.Labels) > 0 {
+		labels := map[string]interface{}{}
+		for key, value := range pool.Labels {
+			labels[key] = value
+		}
+		if err := unstructured.SetNestedMap(object, labels, "spec", "template", "spec", "metadata", "labels"); err != nil {
+			return err
+		}
+	}
+	if len(pool.Taints) == 0 {
+		return nil
+	}
+	taints := make([]interface{}, 0, len(pool.Taints))
+	for _, taint := range pool.Taints {
+		value := map[string]interface{}{"key": taint.Key, "effect": taint.Effect}
+		if len(taint.Value) > 0 {
+			value["value"] = taint.Value
+		}
+		taints = append(taints, value)
+	}
+	return unstructured.SetNestedSlice(object, taints, "spec", "template", "spec", "taints")
+}
@@ -0,0 +1,186 @@
+package aws
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestValidateNodePools(t *testing.T) {
+	tests := []struct {
+		name        string
+		pools       []api.NodePool
+		expectError bool
+	}{
+		{
+			name: "infra and compute pools",
+			pools: []api.NodePool{
+				{
+					Name:     "infra",
+					Replicas: 2,
+					Labels:   map[string]string{"node-role.kubernetes.io/infra": ""},
+					Taints:   []api.NodePoolTaint{{Key: "node-role.kubernetes.io/infra", Effect: "NoSchedule"}},
+				},
+				{Name: "compute", Replicas: 3, InstanceType: "m5.2xlarge"},
+			},
+		},
+		{name: "empty pool", pools: []api.NodePool{{Name: "compute", Replicas: 2}, {Name: "gpu"}}},
+		{name: "no workers", pools: []api.NodePool{{Name: "compute"}}, expectError: true},
+		{name: "invalid name", pools: []api.NodePool{{Name: "Compute_1", Replicas: 1}}, expectError: true},
+		{name: "duplicate name", pools: []api.NodePool{{Name: "compute", Replicas: 1}, {Name: "compute", Replicas: 1}}, expectError: true},
+		{name: "negative replicas", pools: []api.NodePool{{Name: "compute", Replicas: -1}}, expectError: true},
+		{name: "invalid label", pools: []api.NodePool{{Name: "compute", Replicas: 1, Labels: map[string]string{"a/b/c": ""}}}, expectError: true},
+		{name: "invalid label value", pools: []api.NodePool{{Name: "compute", Replicas: 1, Labels: map[string]string{"role": "a b"}}}, expectError: true},
+		{name: "spot pool", pools: []api.NodePool{{Name: "compute", Replicas: 3, SpotMarketOptions: &api.SpotMarketOptions{MaxPrice: "0.05"}}}},
+		{name: "spot pool with only fallback workers", pools: []api.NodePool{{Name: "compute", SpotMarketOptions: &api.SpotMarketOptions{OnDemandFallbackReplicas: 1}}}},
+		{name: "invalid spot max price", pools: []api.NodePool{{Name: "compute", Replicas: 1, SpotMarketOptions: &api.SpotMarketOptions{MaxPrice: "cheap"}}}, expectError: true},
+		{name: "negative fallback replicas", pools: []api.NodePool{{Name: "compute", Replicas: 1, SpotMarketOptions: &api.SpotMarketOptions{OnDemandFallbackReplicas: -1}}}, expectError: true},
+		{
+			name: "fallback pool name taken",
+			pools: []api.NodePool{
+				{Name: "compute", Replicas: 1, SpotMarketOptions: &api.SpotMarketOptions{OnDemandFallbackReplicas: 1}},
+				{Name: "compute-on-demand", Replicas: 1},
+			},
+			expectError: true,
+		},
+		{name: "invalid taint effect", pools: []api.NodePool{{Name: "compute", Replicas: 1, Taints: []api.NodePoolTaint{{Key: "dedicated", Effect: "Never"}}}}, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := validateNodePools(test.pools); test.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", test.expectError, err)
+			}
+		})
+	}
+}
+
+func TestNodePools(t *testing.T) {
+	workerZones := []string{"us-east-1a", "us-east-1b", "us-east-1c"}
+	tests := []struct {
+		name        string
+		workers     WorkerConfig
+		zones       []string
+		expected    []api.NodePool
+		expectError bool
+	}{
+		{
+			name:     "default single zone",
+			workers:  WorkerConfig{Count: 3, InstanceType: "m5.large"},
+			zones:    []string{"us-east-1a"},
+			expected: []api.NodePool{{Name: "worker", InstanceType: "m5.large", Replicas: 3, Zone: "us-east-1a"}},
+		},
+		{
+			name:    "default multiple zones",
+			workers: WorkerConfig{Count: 4},
+			zones:   workerZones,
+			expected: []api.NodePool{
+				{Name: "worker-us-east-1a", Replicas: 2, Zone: "us-east-1a"},
+				{Name: "worker-us-east-1b", Replicas: 1, Zone: "us-east-1b"},
+				{Name: "worker-us-east-1c", Replicas: 1, Zone: "us-east-1c"},
+			},
+		},
+		{
+			name: "configured pools",
+			workers: WorkerConfig{Count: 3, InstanceType: "m5.large", NodePools: []api.NodePool{
+				{Name: "infra", Replicas: 2, Zone: "us-east-1b"},
+				{Name: "compute", Replicas: 5, InstanceType: "m5.2xlarge"},
+			}},
+			zones: []string{"us-east-1a"},
+			expected: []api.NodePool{
+				{Name: "infra", InstanceType: "m5.large", Replicas: 2, Zone: "us-east-1b"},
+				{Name: "compute", InstanceType: "m5.2xlarge", Replicas: 5, Zone: "us-east-1a"},
+			},
+		},
+		{
+			name: "spot pool with on-demand fallback",
+			workers: WorkerConfig{NodePools: []api.NodePool{
+				{Name: "compute", Replicas: 3, Labels: map[string]string{"role": "compute"}, SpotMarketOptions: &api.SpotMarketOptions{MaxPrice: "0.05", OnDemandFallbackReplicas: 1}},
+			}},
+			zones: []string{"us-east-1a"},
+			expected: []api.NodePool{
+				{Name: "compute", Replicas: 3, Zone: "us-east-1a", Labels: map[string]string{"role": "compute"}, SpotMarketOptions: &api.SpotMarketOptions{MaxPrice: "0.05", OnDemandFallbackReplicas: 1}},
+				{Name: "compute-on-demand", Replicas: 1, Zone: "us-east-1a", Labels: map[string]string{"role": "compute"}},
+			},
+		},
+		{
+			name:        "zone without management workers",
+			workers:     WorkerConfig{NodePools: []api.NodePool{{Name: "compute", Replicas: 1, Zone: "us-east-1d"}}},
+			zones:       []string{"us-east-1a"},
+			expectError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pools, err := nodePools(test.workers, test.zones, workerZones)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", pools)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(pools, test.expected) {
+				t.Errorf("expected pools %v, got %v", test.expected, pools)
+			}
+		})
+	}
+}
+
+func TestSetNodePoolMetadata(t *testing.T) {
+	object := map[string]interface{}{}
+	pool := api.NodePool{
+		Name:   "infra",
+		Labels: map[string]string{"node-role.kubernetes.io/infra": ""},
+		Taints: []api.NodePoolTaint{{Key: "dedicated", Value: "infra", Effect: "NoSchedule"}},
+	}
+	if err := setNodePoolMetadata(object, pool); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spec := object["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	expectedLabels := map[string]interface{}{"node-role.kubernetes.io/infra": ""}
+	if labels := spec["metadata"].(map[string]interface{})["labels"]; !reflect.DeepEqual(labels, expectedLabels) {
+		t.Errorf("expected labels %v, got %v", expectedLabels, labels)
+	}
+	expectedTaints := []interface{}{map[string]interface{}{"key": "dedicated", "value": "infra", "effect": "NoSchedule"}}
+	if !reflect.DeepEqual(spec["taints"], expectedTaints) {
+		t.Errorf("expected taints %v, got %v", expectedTaints, spec["taints"])
+	}
+}
+
+func TestSetSpotMarketOptions(t *testing.T) {
+	machineSet := func(value map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"providerSpec": map[string]interface{}{"value": value},
+					},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name     string
+		value    map[string]interface{}
+		pool     api.NodePool
+		expected interface{}
+	}{
+		{name: "on-demand", value: map[string]interface{}{}, pool: api.NodePool{Name: "compute"}},
+		{name: "on-demand from spot management workers", value: map[string]interface{}{"spotMarketOptions": map[string]interface{}{}}, pool: api.NodePool{Name: "compute"}},
+		{name: "spot at on-demand price", value: map[string]interface{}{}, pool: api.NodePool{Name: "compute", SpotMarketOptions: &api.SpotMarketOptions{}}, expected: map[string]interface{}{}},
+		{name: "spot with max price", value: map[string]interface{}{}, pool: api.NodePool{Name: "compute", SpotMarketOptions: &api.SpotMarketOptions{MaxPrice: "0.05"}}, expected: map[string]interface{}{"maxPrice": "0.05"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := setSpotMarketOptions(machineSet(test.value), test.pool); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(test.value["spotMarketOptions"], test.expected) {
+				t.Errorf("expected spot market options %v, got %v", test.expected, test.value["spotMarketOptions"])
+			}
+		})
+	}
+}
@@ -0,0 +1,385 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+// awsProvider is the cloud provider of hosted clusters on an AWS management cluster. The
+// endpoints of a cluster are network load balancers that target the management cluster
+// workers, with CNAME records in the public zone or, for a private cluster, in a private
+// zone of the cluster. Public clusters may register their records in a private zone too,
+// or leave them to external-dns. Workers are machines of the management cluster's Machine
+// API, or KubeVirt virtual machines in the control plane namespace.
+type awsProvider struct {
+	helper        *AWSHelper
+	dynamicClient dynamic.Interface
+	infraName     string
+	clusterName   string
+	parentDomain  string
+
+	// dnsZoneID is the zone of the records of the cluster. It is the public zone of the
+	// management cluster until the private zone of a private cluster is created.
+	dnsZoneID string
+
+	// privateZoneID is the private zone of the cluster's domain, once it is created
+	privateZoneID string
+
+	// network is the network of the load balancers and workers
+	network *LBInfo
+
+	// securityGroup is the security group of the workers, which defaults to the workers
+	// security group of the management cluster
+	securityGroup string
+
+	// machineIDs and machineIPs are the instance IDs and IPs of the load balancer targets
+	machineIDs []string
+	machineIPs []string
+
+	// private clusters have internal load balancers and records in a private zone
+	private bool
+
+	// privateZone registers the records of a public cluster in a private zone as well
+	privateZone bool
+
+	// dns registers the records of the cluster instead of the Route53 zones, when set
+	dns DNSProvider
+
+	// serviceLoadBalancers exposes the API, OAuth and ignition server services, and the
+	// router service of KubeVirt workers, through load balancers that the cloud provider of
+	// the management cluster provisions for them. client updates the services.
+	serviceLoadBalancers bool
+	client               kubeclient.Interface
+
+	// routes exposes the API, OAuth and ignition server services through passthrough
+	// routes in ingressDomain, the domain of the routes of the management cluster
+	routes        bool
+	ingressDomain string
+
+	// sharedIngressDNSName is the load balancer of the API server frontend of the
+	// management cluster, which the API name of a cluster with a shared ingress points to
+	sharedIngressDNSName string
+
+	// oauthDNSName and ignitionDNSName are the names of the load balancers, or the hosts of
+	// the routes, of the OAuth and ignition server services
+	oauthDNSName    string
+	ignitionDNSName string
+
+	// rootVolumeSize is the root volume size of workers, the size of the management
+	// cluster's workers when 0
+	rootVolumeSize int
+	// workerAMI is the AMI of workers, the AMI of the management cluster's workers when
+	// empty
+	workerAMI string
+
+	// resources are the IDs of the created resources, recorded for troubleshooting
+	resources map[string]string
+
+	// ignitionBucket is the name of the bucket that stores the ignition file
+	ignitionBucket string
+
+	// kubeVirt is the configuration of KubeVirt workers, which boot with userData
+	kubeVirt *KubeVirtConfig
+	userData []byte
+
+	// dryRun returns the endpoints of the cluster without creating AWS resources
+	dryRun bool
+
+	// removed are the AWS resources that Teardown removed
+	removed *clusterResources
+}
+
+var _ installer.CloudProvider = &awsProvider{}
+
+func (p *awsProvider) lbName(suffix string) string {
+	return generateLBResourceName(p.infraName, p.clusterName, suffix)
+}
+
+func (p *awsProvider) dnsName(prefix string) string {
+	return fmt.Sprintf("%s.%s.%s", prefix, p.clusterName, p.parentDomain)
+}
+
+func (p *awsProvider) record(key, value string) {
+	if p.resources == nil {
+		p.resources = map[string]string{}
+	}
+	p.resources[key] = value
+}
+
+// ensureDNSZone ensures that the records of a private cluster, or of a cluster with a
+// private zone, are registered in a private zone for the cluster's domain, associated
+// with the VPC of the management cluster
+func (p *awsProvider) ensureDNSZone() error {
+	if !(p.private || p.privateZone) || len(p.privateZoneID) > 0 {
+		return nil
+	}
+	zoneID, err := p.helper.EnsurePrivateZone(fmt.Sprintf("%s.%s", p.clusterName, p.parentDomain), p.network.VPC)
+	if err != nil {
+		return cloudProviderError(err, "cannot create private DNS zone")
+	}
+	log.Infof("Using private DNS Zone: %s", zoneID)
+	p.privateZoneID = zoneID
+	if p.private {
+		p.dnsZoneID = zoneID
+	}
+	return nil
+}
+
+// dnsProvider returns the provider of the records of the cluster: external-dns, if set, or
+// its zone and its private zone, if the records of a public cluster are registered there as well
+func (p *awsProvider) dnsProvider() DNSProvider {
+	if p.dns != nil {
+		return p.dns
+	}
+	zoneIDs := []string{p.dnsZoneID}
+	if len(p.privateZoneID) > 0 && p.privateZoneID != p.dnsZoneID {
+		zoneIDs = append(zoneIDs, p.privateZoneID)
+	}
+	return &route53DNSProvider{helper: p.helper, zoneIDs: zoneIDs}
+}
+
+// ensureTargetGroup ensures that a TCP target group exists for a node port with the given
+// targets, and that a listener of the load balancer forwards to it
+func (p *awsProvider) ensureTargetGroup(lbARN, tgName string, port, nodePort int, targets []string, description string) (string, error) {
+	tgARN, err := p.helper.EnsureTargetGroup(p.network.VPC, tgName, nodePort)
+	if err != nil {
+		return "", cloudProviderError(err, "cannot create %s target group", description)
+	}
+	log.Infof("Created %s target group ARN: %s", description, tgARN)
+	if len(targets) > 0 {
+		if err = p.helper.EnsureTargets(tgARN, targets); err != nil {
+			return "", cloudProviderError(err, "cannot create %s load balancer targets", description)
+		}
+		log.Infof("Created %s load balancer targets to %s", description, strings.Join(targets, ", "))
+	}
+	if err = p.helper.EnsureListener(lbARN, tgARN, port, false); err != nil {
+		return "", cloudProviderError(err, "cannot create %s listener", description)
+	}
+	log.Infof("Created %s load balancer listener", description)
+	return tgARN, nil
+}
+
+// EnsureAPIEndpoint ensures the API load balancer, with an elastic IP unless the cluster is
+// private, and that the workers accept traffic to node ports from the load balancers
+func (p *awsProvider) EnsureAPIEndpoint(ports installer.APIEndpointPorts) (*installer.Endpoint, error) {
+	if p.dryRun {
+		return p.dryRunAPIEndpoint(ports), nil
+	}
+	if err := p.ensureDNSZone(); err != nil {
+		return nil, err
+	}
+	if p.serviceLoadBalancers {
+		return p.ensureServiceAPIEndpoint(ports)
+	}
+	if p.routes {
+		return p.ensureRouteAPIEndpoint(ports)
+	}
+	if len(p.sharedIngressDNSName) > 0 {
+		return p.ensureSharedAPIEndpoint()
+	}
+	apiLBName := p.lbName("api")
+	allocID, ip := "", ""
+	var err error
+	if !p.private {
+		allocID, ip, err = p.helper.EnsureEIP(apiLBName)
+		if err != nil {
+			return nil, cloudProviderError(err, "cannot allocate API load balancer EIP")
+		}
+		log.Infof("Allocated EIP with ID: %s, and IP: %s", allocID, ip)
+		p.record("api-eip-allocation", allocID)
+	}
+	lbARN, lbDNS, err := p.helper.EnsureNLB(apiLBName, p.network.Subnets, allocID, p.private)
+	if err != nil {
+		return nil, cloudProviderError(err, "cannot create network load balancer")
+	}
+	log.Infof("Created API load balancer with ARN: %s, DNS: %s", lbARN, lbDNS)
+	p.record("api-lb-arn", lbARN)
+	if p.private {
+		ip, err = p.helper.LoadBalancerPrivateIP(lbARN, p.network.Subnets[0])
+		if err != nil {
+			return nil, cloudProviderError(err, "cannot get API load balancer private IP")
+		}
+		log.Infof("Using API load balancer private IP: %s", ip)
+	}
+
+	apiTGARN, err := p.ensureTargetGroup(lbARN, apiLBName, 6443, ports.API, p.machineIPs, "API")
+	if err != nil {
+		return nil, err
+	}
+	p.record("api-target-group-arn", apiTGARN)
+	if _, err = p.ensureTargetGroup(lbARN, p.lbName("oauth"), externalOauthPort, ports.OAuth, p.machineIPs, "OAuth"); err != nil {
+		return nil, err
+	}
+	if ports.Ignition != 0 {
+		if _, err = p.ensureTargetGroup(lbARN, p.lbName("ign"), externalIgnitionPort, ports.Ignition, p.machineIPs, "ignition server"); err != nil {
+			return nil, err
+		}
+	}
+
+	dnsName := p.dnsName("api")
+	if err = p.dnsProvider().EnsureCNameRecord(dnsName, lbDNS); err != nil {
+		return nil, cloudProviderError(err, "cannot create API DNS record")
+	}
+	log.Infof("Created DNS record for API name: %s", dnsName)
+
+	if err = p.helper.EnsureWorkersAllowNodePortAccess(p.securityGroup, p.network.VPCCIDR); err != nil {
+		return nil, cloudProviderError(err, "cannot setup security group for worker nodes")
+	}
+	log.Infof("Ensured that node ports on workers are accessible")
+	return &installer.Endpoint{DNSName: dnsName, Address: ip}, nil
+}
+
+// EnsureIngressEndpoint ensures the router load balancer. The routers of the cluster run on
+// its workers, which the machinesets of the cluster register as targets. KubeVirt workers
+// are reached through a node port service of the management cluster, so the load balancer
+// targets the management cluster workers.
+func (p *awsProvider) EnsureIngressEndpoint(httpNodePort, httpsNodePort int) (*installer.Endpoint, error) {
+	if p.dryRun {
+		return p.dryRunIngressEndpoint(), nil
+	}
+	if err := p.ensureDNSZone(); err != nil {
+		return nil, err
+	}
+	if p.serviceLoadBalancers && p.kubeVirt != nil {
+		return p.ensureServiceIngressEndpoint()
+	}
+	lbARN, lbDNS, err := p.helper.EnsureNLB(p.lbName("apps"), p.network.Subnets, "", p.private)
+	if err != nil {
+		return nil, cloudProviderError(err, "cannot create router load balancer")
+	}
+	log.Infof("Created router load balancer with ARN: %s, DNS: %s", lbARN, lbDNS)
+	p.record("router-lb-arn", lbARN)
+
+	// The targets of machine workers are registered by the machinesets of the cluster
+	var targets []string
+	if p.kubeVirt != nil {
+		targets = p.machineIPs
+	}
+	if _, err = p.ensureTargetGroup(lbARN, p.lbName("http"), 80, httpNodePort, targets, "router HTTP"); err != nil {
+		return nil, err
+	}
+	if _, err = p.ensureTargetGroup(lbARN, p.lbName("https"), 443, httpsNodePort, targets, "router HTTPS"); err != nil {
+		return nil, err
+	}
+
+	dnsName := p.dnsName("*.apps")
+	if err = p.dnsProvider().EnsureCNameRecord(dnsName, lbDNS); err != nil {
+		return nil, cloudProviderError(err, "cannot create router DNS record")
+	}
+	log.Infof("Created DNS record for router name: %s", dnsName)
+	return &installer.Endpoint{DNSName: dnsName}, nil
+}
+
+// EnsureVPNEndpoint ensures the VPN load balancer, with a UDP target group that targets the
+// instances of the management cluster workers
+func (p *awsProvider) EnsureVPNEndpoint(vpnNodePort, healthCheckNodePort int) (*installer.Endpoint, error) {
+	if p.dryRun {
+		return p.dryRunVPNEndpoint(), nil
+	}
+	if err := p.ensureDNSZone(); err != nil {
+		return nil, err
+	}
+	vpnLBName := p.lbName("vpn")
+	lbARN, lbDNS, err := p.helper.EnsureNLB(vpnLBName, p.network.Subnets, "", p.private)
+	if err != nil {
+		return nil, cloudProviderError(err, "cannot create vpn load balancer")
+	}
+	log.Infof("Created VPN load balancer with ARN: %s and DNS: %s", lbARN, lbDNS)
+	p.record("vpn-lb-arn", lbARN)
+
+	tgARN, err := p.helper.EnsureUDPTargetGroup(p.network.VPC, vpnLBName, vpnNodePort, healthCheckNodePort)
+	if err != nil {
+		return nil, cloudProviderError(err, "cannot create VPN target group")
+	}
+	log.Infof("Created VPN target group ARN: %s", tgARN)
+	p.record("vpn-target-group-arn", tgARN)
+	if err = p.helper.EnsureTargets(tgARN, p.machineIDs); err != nil {
+		return nil, cloudProviderError(err, "cannot create VPN load balancer targets")
+	}
+	log.Infof("Created VPN load balancer targets to %s", strings.Join(p.machineIDs, ", "))
+	if err = p.helper.EnsureListener(lbARN, tgARN, 1194, true); err != nil {
+		return nil, cloudProviderError(err, "cannot create VPN listener")
+	}
+	log.Infof("Created VPN load balancer listener")
+
+	dnsName := p.dnsName("vpn")
+	if err = p.dnsProvider().EnsureCNameRecord(dnsName, lbDNS); err != nil {
+		return nil, cloudProviderError(err, "cannot create VPN DNS record")
+	}
+	log.Infof("Created DNS record for VPN: %s", dnsName)
+	return &installer.Endpoint{DNSName: dnsName}, nil
+}
+
+// EnsureIgnitionStorage uploads the ignition file to an S3 bucket of the cluster and returns
+// a pre-signed URL of the file
+func (p *awsProvider) EnsureIgnitionStorage(fileName string) (string, error) {
+	if p.dryRun {
+		return p.dryRunIgnitionStorage(), nil
+	}
+	p.ignitionBucket = generateBucketName(p.infraName, p.clusterName, "ign")
+	log.Infof("Ensuring ignition bucket exists")
+	if err := p.helper.EnsureIgnitionBucket(p.ignitionBucket, fileName); err != nil {
+		return "", cloudProviderError(err, "failed to ensure ignition bucket exists")
+	}
+	url, err := p.helper.PresignIgnitionURL(p.ignitionBucket)
+	if err != nil {
+		return "", cloudProviderError(err, "failed to pre-sign ignition URL")
+	}
+	return url, nil
+}
+
+// EnsureWorkerPool writes a machineset for the node pool, based on the management cluster's
+// worker machineset in the zone of the pool, or the KubeVirt virtual machines of the pool
+func (p *awsProvider) EnsureWorkerPool(pool api.NodePool, fileName string) error {
+	if p.kubeVirt != nil {
+		if err := generateKubeVirtWorkers(p.clusterName, pool.Name, pool, p.kubeVirt, p.userData, fileName); err != nil {
+			return installerrors.Render(err, "failed to generate virtual machines for node pool %s", pool.Name)
+		}
+		return nil
+	}
+	machineSetName := generateMachineSetName(p.infraName, p.clusterName, pool.Name)
+	if err := generateWorkerMachineset(p.dynamicClient, p.infraName, p.clusterName, p.lbName("apps"), machineSetName, pool, p.rootVolumeSize, p.workerAMI, fileName); err != nil {
+		return installerrors.Render(err, "failed to generate worker machineset for node pool %s", pool.Name)
+	}
+	return nil
+}
+
+// Teardown removes the worker machinesets of the cluster and the AWS resources tagged for
+// the cluster. The records of the cluster are looked up in dnsZoneID, the public zone,
+// including the API record of a cluster with a shared ingress, which points to
+// sharedIngressDNSName.
+func (p *awsProvider) Teardown() error {
+	log.Info("Finding AWS resources of the cluster")
+	resources, err := p.helper.findClusterResources(p.dnsZoneID, fmt.Sprintf("%s.%s", p.clusterName, p.parentDomain), p.sharedIngressDNSName)
+	if err != nil {
+		return cloudProviderError(err, "cannot find AWS resources of cluster %s", p.clusterName)
+	}
+
+	log.Infof("Removing %d DNS records, %d load balancers, %d target groups, %d elastic IPs and %d private DNS zones",
+		len(resources.records), len(resources.loadBalancers), len(resources.targetGroups), len(resources.addresses), len(resources.privateZones))
+	if err = resources.remove(p.helper); err != nil {
+		return err
+	}
+	p.removed = resources
+
+	log.Infof("Removing worker machinesets")
+	machineSets, err := machineSetClient(p.dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain machineset client")
+	}
+	if err = removeWorkerMachineset(machineSets, p.infraName, p.clusterName); err != nil {
+		return installerrors.Apply(err, "failed to remove worker machinesets")
+	}
+
+	log.Infof("Removing %d S3 buckets", len(resources.buckets))
+	return resources.removeBuckets(p.helper)
+}
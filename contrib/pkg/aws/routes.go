@@ -0,0 +1,44 @@
+package aws
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+// ensureRoute ensures a passthrough route of the management cluster to a service of the
+// cluster and returns its host
+func (p *awsProvider) ensureRoute(name, serviceName string, targetPort int, description string) (string, error) {
+	host := installer.RouteHost(name, p.clusterName, p.ingressDomain)
+	if err := installer.EnsurePassthroughRoute(p.dynamicClient, p.clusterName, name, host, serviceName, targetPort); err != nil {
+		return "", installerrors.Apply(err, "cannot create %s route", description)
+	}
+	log.Infof("Created %s route with host: %s", description, host)
+	return host, nil
+}
+
+// ensureRouteAPIEndpoint exposes the API, OAuth and ignition server through passthrough
+// routes of the management cluster, whose wildcard record already resolves their hosts to
+// its routers. No load balancer, elastic IP or record is created for them. The address
+// of the API endpoint is the first management cluster worker, where the workers of the
+// cluster reach the API service on its node port.
+func (p *awsProvider) ensureRouteAPIEndpoint(ports installer.APIEndpointPorts) (*installer.Endpoint, error) {
+	dnsName, err := p.ensureRoute("api", "kube-apiserver", 6443, "API")
+	if err != nil {
+		return nil, err
+	}
+	if p.oauthDNSName, err = p.ensureRoute("oauth", "oauth-openshift", 6443, "OAuth"); err != nil {
+		return nil, err
+	}
+	if ports.Ignition != 0 {
+		if p.ignitionDNSName, err = p.ensureRoute("ignition", "ignition-server", 8443, "ignition server"); err != nil {
+			return nil, err
+		}
+	}
+	if err = p.helper.EnsureWorkersAllowNodePortAccess(p.securityGroup, p.network.VPCCIDR); err != nil {
+		return nil, cloudProviderError(err, "cannot setup security group for worker nodes")
+	}
+	log.Infof("Ensured that node ports on workers are accessible")
+	return &installer.Endpoint{DNSName: dnsName, Address: p.machineIPs[0]}, nil
+}
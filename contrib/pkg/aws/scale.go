@@ -0,0 +1,138 @@
+package aws
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+// ScaleCluster sets the number of worker nodes of the cluster named name to replicas,
+// spreading them across its worker machinesets, and optionally waits for the nodes
+// to be ready. If pool is not empty, only the machinesets of that node pool are scaled.
+func ScaleCluster(name, pool string, replicas int, waitForReady bool, waitConfig installer.WaitConfig) error {
+	if replicas < 0 {
+		return installerrors.Precondition(nil, "the number of replicas cannot be negative")
+	}
+
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
+	}
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+	infraName, _, err := getInfrastructureInfo(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain infrastructure info for cluster")
+	}
+
+	machineSets, err := machineSetClient(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain machineset client")
+	}
+	names, err := workerMachineSetNames(machineSets, infraName, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to list worker machinesets of cluster %s", name)
+	}
+	var existing []*unstructured.Unstructured
+	// expectedNodes counts the nodes of the machinesets that are not scaled
+	expectedNodes := replicas
+	for _, machineSetName := range names {
+		machineSet, err := machineSets.Get(machineSetName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return installerrors.Apply(err, "failed to fetch worker machineset %s", machineSetName)
+		}
+		if len(pool) > 0 && machineSet.GetLabels()[nodePoolLabel] != pool {
+			count, _, _ := unstructured.NestedInt64(machineSet.Object, "spec", "replicas")
+			expectedNodes += int(count)
+			continue
+		}
+		existing = append(existing, machineSet)
+	}
+	if len(existing) == 0 && len(pool) > 0 {
+		return installerrors.Precondition(nil, "did not find worker machinesets for node pool %s of cluster %s", pool, name)
+	}
+	if len(existing) == 0 {
+		return installerrors.Precondition(nil, "did not find worker machinesets for cluster %s", name)
+	}
+
+	for i, machineSet := range existing {
+		count := machineSetReplicas(replicas, len(existing), i)
+		if err = unstructured.SetNestedField(machineSet.Object, int64(count), "spec", "replicas"); err != nil {
+			return installerrors.Apply(err, "failed to set replicas of worker machineset %s", machineSet.GetName())
+		}
+		if _, err = machineSets.Update(machineSet, metav1.UpdateOptions{}); err != nil {
+			return installerrors.Apply(err, "failed to scale worker machineset %s", machineSet.GetName())
+		}
+		log.Infof("Scaled worker machineset %s to %d replicas", machineSet.GetName(), count)
+	}
+
+	if waitForReady {
+		targetClusterCfg, err := installer.GetTargetClusterConfigFromSecret(client, name)
+		if err != nil {
+			return installerrors.Precondition(err, "cannot create target cluster client config")
+		}
+		targetClient, err := kubeclient.NewForConfig(targetClusterCfg)
+		if err != nil {
+			return installerrors.Precondition(err, "cannot create target cluster client")
+		}
+		log.Infof("Waiting up to %s for nodes to be ready.", waitConfig.PhaseTimeout(installer.WaitPhaseNodesReady))
+		if err = installer.WaitForNodesReady(targetClient, expectedNodes, waitConfig); err != nil {
+			return installerrors.Timeout(err, "failed to wait for nodes ready")
+		}
+		log.Infof("Nodes (%d) are ready", expectedNodes)
+	}
+	return nil
+}
+
+// machineSetReplicas returns the replicas of the i-th of count machinesets that share
+// total replicas, giving the remainder to the first machinesets
+func machineSetReplicas(total, count, i int) int {
+	replicas := total / count
+	if i < total%count {
+		replicas++
+	}
+	return replicas
+}
+
+func machineSetClient(client dynamic.Interface) (dynamic.ResourceInterface, error) {
+	machineGV, err := schema.ParseGroupVersion("machine.openshift.io/v1beta1")
+	if err != nil {
+		return nil, err
+	}
+	return client.Resource(machineGV.WithResource("machinesets")).Namespace("openshift-machine-api"), nil
+}
+
+// workerMachineSetNames returns the sorted names of the worker machinesets of a cluster:
+// the ones labeled with the cluster name and the machineset name used by clusters
+// installed before machinesets were labeled, which may not exist
+func workerMachineSetNames(machineSets dynamic.ResourceInterface, infraName, namespace string) ([]string, error) {
+	names := sets.NewString(generateMachineSetName(infraName, namespace, "worker"))
+	list, err := machineSets.List(metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", machineSetClusterLabel, namespace)})
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range list.Items {
+		names.Insert(item.GetName())
+	}
+	return names.List(), nil
+}
@@ -0,0 +1,110 @@
+package aws
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+const (
+	awsLoadBalancerTypeAnnotation = "service.beta.kubernetes.io/aws-load-balancer-type"
+	awsLoadBalancerTagsAnnotation = "service.beta.kubernetes.io/aws-load-balancer-additional-resource-tags"
+
+	// serviceLoadBalancerResolveTimeout is how long the name of the API load balancer of a
+	// service may take to resolve after it is provisioned
+	serviceLoadBalancerResolveTimeout = 5 * time.Minute
+)
+
+// serviceLoadBalancerAnnotations returns the annotations of the services of the cluster
+// with load balancers. The load balancers are network load balancers, tagged for the
+// cluster so that they and their records are found when the cluster is uninstalled.
+func serviceLoadBalancerAnnotations(clusterName string) map[string]string {
+	return map[string]string{
+		awsLoadBalancerTypeAnnotation: "nlb",
+		awsLoadBalancerTagsAnnotation: fmt.Sprintf("%s=%s", ClusterTagKey, clusterName),
+	}
+}
+
+// ensureServiceLoadBalancer ensures that a service of the cluster has a load balancer and a
+// record with the name prefix for it, and returns the name of the record and the name of
+// the load balancer
+func (p *awsProvider) ensureServiceLoadBalancer(serviceName, prefix, description string) (string, string, error) {
+	lbDNS, err := installer.EnsureLoadBalancerService(p.client, p.clusterName, serviceName, serviceLoadBalancerAnnotations(p.clusterName))
+	if err != nil {
+		return "", "", installerrors.Apply(err, "cannot create %s load balancer service", description)
+	}
+	log.Infof("Created %s load balancer service with DNS: %s", description, lbDNS)
+	dnsName := p.dnsName(prefix)
+	if err = p.dnsProvider().EnsureCNameRecord(dnsName, lbDNS); err != nil {
+		return "", "", cloudProviderError(err, "cannot create %s DNS record", description)
+	}
+	log.Infof("Created DNS record for %s name: %s", description, dnsName)
+	return dnsName, lbDNS, nil
+}
+
+// ensureServiceAPIEndpoint exposes the API, OAuth and ignition server services through
+// load balancers of services, with records of their own. The address of the API endpoint
+// is the first address that the name of its load balancer resolves to.
+func (p *awsProvider) ensureServiceAPIEndpoint(ports installer.APIEndpointPorts) (*installer.Endpoint, error) {
+	dnsName, lbDNS, err := p.ensureServiceLoadBalancer("kube-apiserver", "api", "API")
+	if err != nil {
+		return nil, err
+	}
+	if p.oauthDNSName, _, err = p.ensureServiceLoadBalancer("oauth-openshift", "oauth", "OAuth"); err != nil {
+		return nil, err
+	}
+	if ports.Ignition != 0 {
+		if p.ignitionDNSName, _, err = p.ensureServiceLoadBalancer("ignition-server", "ignition", "ignition server"); err != nil {
+			return nil, err
+		}
+	}
+	address, err := resolveAddress(lbDNS, serviceLoadBalancerResolveTimeout)
+	if err != nil {
+		return nil, installerrors.Timeout(err, "cannot resolve the API load balancer %s", lbDNS)
+	}
+	log.Infof("Using API load balancer IP: %s", address)
+
+	// The router and VPN load balancers still target node ports of the workers
+	if err = p.helper.EnsureWorkersAllowNodePortAccess(p.securityGroup, p.network.VPCCIDR); err != nil {
+		return nil, cloudProviderError(err, "cannot setup security group for worker nodes")
+	}
+	log.Infof("Ensured that node ports on workers are accessible")
+	return &installer.Endpoint{DNSName: dnsName, Address: address}, nil
+}
+
+// ensureServiceIngressEndpoint exposes the router service of KubeVirt workers through a
+// load balancer of the service
+func (p *awsProvider) ensureServiceIngressEndpoint() (*installer.Endpoint, error) {
+	dnsName, _, err := p.ensureServiceLoadBalancer(kubeVirtRouterServiceName, "*.apps", "router")
+	if err != nil {
+		return nil, err
+	}
+	return &installer.Endpoint{DNSName: dnsName}, nil
+}
+
+// resolveAddress returns the first IPv4 address of a name, waiting for the name to resolve
+func resolveAddress(name string, timeout time.Duration) (string, error) {
+	address := ""
+	err := wait.PollImmediate(10*time.Second, timeout, func() (bool, error) {
+		ips, err := net.LookupIP(name)
+		if err != nil {
+			log.Debugf("Cannot resolve %s yet: %v", name, err)
+			return false, nil
+		}
+		for _, ip := range ips {
+			if ip.To4() != nil {
+				address = ip.String()
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	return address, err
+}
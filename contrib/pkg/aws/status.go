@@ -0,0 +1,32 @@
+package aws
+
+import (
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+// ClusterStatus writes the status of the control plane of the cluster named name, and of
+// the API, nodes, cluster operators and certificates of the cluster, as a table to out
+func ClusterStatus(name string, out io.Writer) error {
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+	rows, err := installer.GetClusterStatus(client, name)
+	if errors.IsNotFound(err) {
+		return installerrors.Precondition(err, "cluster %s does not exist", name)
+	}
+	if err != nil {
+		return installerrors.Apply(err, "failed to get status of cluster %s", name)
+	}
+	return installer.PrintClusterStatus(out, rows)
+}
@@ -0,0 +1,553 @@
+package aws
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+const (
+	// elbTagsBatchSize is the maximum number of resources of an ELB DescribeTags call
+	elbTagsBatchSize = 20
+
+	// zoneTagsBatchSize is the maximum number of zones of a Route53 ListTagsForResources call
+	zoneTagsBatchSize = 10
+)
+
+// clusterResources are the AWS resources of a hosted cluster, found by their tags. Resources
+// of clusters installed before resources were tagged with ClusterTagKey are found by the
+// names that the installer generates for them.
+type clusterResources struct {
+	records       []dnsRecord
+	loadBalancers []namedResource
+	targetGroups  []namedResource
+	addresses     []namedResource
+	privateZones  []namedResource
+	buckets       []string
+	users         []string
+}
+
+// namedResource is an AWS resource identified by id, usually an ARN
+type namedResource struct {
+	id   string
+	name string
+}
+
+// dnsRecord is a CNAME record that points to a load balancer of the cluster
+type dnsRecord struct {
+	zoneID string
+	name   string
+	value  string
+	ttl    int64
+}
+
+// findClusterResources returns the AWS resources of the cluster of the helper. Records are
+// looked up in the public zone and in the private zones of the cluster. The API record of a
+// cluster with a shared ingress points to the load balancer of the API server frontend,
+// frontendDNSName, which is not a resource of the cluster.
+func (h *AWSHelper) findClusterResources(publicZoneID, privateDomain, frontendDNSName string) (*clusterResources, error) {
+	resources := &clusterResources{}
+	var err error
+	legacyLBNames := sets.NewString()
+	for _, suffix := range []string{"api", "apps", "vpn"} {
+		legacyLBNames.Insert(generateLBResourceName(h.infraName, h.clusterName, suffix))
+	}
+	if resources.loadBalancers, err = h.findLoadBalancers(legacyLBNames); err != nil {
+		return nil, errors.Wrap(err, "cannot list load balancers")
+	}
+	legacyTGNames := sets.NewString(legacyLBNames.List()...)
+	for _, suffix := range []string{"oauth", "ign", "http", "https"} {
+		legacyTGNames.Insert(generateLBResourceName(h.infraName, h.clusterName, suffix))
+	}
+	if resources.targetGroups, err = h.findTargetGroups(legacyTGNames); err != nil {
+		return nil, errors.Wrap(err, "cannot list target groups")
+	}
+	if resources.addresses, err = h.findAddresses(generateLBResourceName(h.infraName, h.clusterName, "api")); err != nil {
+		return nil, errors.Wrap(err, "cannot list elastic IPs")
+	}
+	if resources.privateZones, err = h.findPrivateZones(privateDomain); err != nil {
+		return nil, errors.Wrap(err, "cannot list private DNS zones")
+	}
+	if resources.buckets, err = h.findBuckets(generateBucketName(h.infraName, h.clusterName, "ign")); err != nil {
+		return nil, errors.Wrap(err, "cannot list S3 buckets")
+	}
+	if resources.users, err = h.findClusterUser(generateUserName(h.infraName, h.clusterName)); err != nil {
+		return nil, errors.Wrap(err, "cannot get IAM user")
+	}
+	lbDNSNames := sets.NewString()
+	for _, lb := range resources.loadBalancers {
+		lbDNSNames.Insert(lb.name)
+	}
+	zoneIDs := []string{publicZoneID}
+	for _, zone := range resources.privateZones {
+		zoneIDs = append(zoneIDs, zone.id)
+	}
+	for _, zoneID := range zoneIDs {
+		records, err := h.findRecords(zoneID, lbDNSNames)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot list DNS records of zone %s", zoneID)
+		}
+		resources.records = append(resources.records, records...)
+		if len(frontendDNSName) == 0 {
+			continue
+		}
+		sharedRecords, err := h.findRecords(zoneID, sets.NewString(frontendDNSName))
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot list DNS records of zone %s", zoneID)
+		}
+		resources.records = append(resources.records, apiRecords(sharedRecords, privateDomain)...)
+	}
+	return resources, nil
+}
+
+// isClusterResource returns true if the tags mark a resource as created for the hosted cluster
+// clusterName by the management cluster infraName
+func isClusterResource(tags map[string]string, infraName, clusterName string) bool {
+	return tags[ClusterTagKey] == clusterName && tags[fmt.Sprintf("kubernetes.io/cluster/%s", infraName)] == "owned"
+}
+
+// ownedTags returns the tags of the resources that are owned by the management cluster
+// and tagged with a cluster name, by resource id
+func (h *AWSHelper) ownedTags(tags map[string]map[string]string) map[string]map[string]string {
+	result := map[string]map[string]string{}
+	for id, resourceTags := range tags {
+		if resourceTags[fmt.Sprintf("kubernetes.io/cluster/%s", h.infraName)] == "owned" {
+			result[id] = resourceTags
+		}
+	}
+	return result
+}
+
+// elbResource is a load balancer or target group
+type elbResource struct {
+	arn     string
+	name    string
+	dnsName string
+}
+
+// listLoadBalancers returns all the load balancers of the account in the region
+func (h *AWSHelper) listLoadBalancers() ([]elbResource, error) {
+	result := []elbResource{}
+	err := h.elbClient.DescribeLoadBalancersPages(&elbv2.DescribeLoadBalancersInput{}, func(output *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
+		for _, lb := range output.LoadBalancers {
+			result = append(result, elbResource{
+				arn:     aws.StringValue(lb.LoadBalancerArn),
+				name:    aws.StringValue(lb.LoadBalancerName),
+				dnsName: aws.StringValue(lb.DNSName),
+			})
+		}
+		return true
+	})
+	return result, err
+}
+
+// listTargetGroups returns all the target groups of the account in the region
+func (h *AWSHelper) listTargetGroups() ([]elbResource, error) {
+	result := []elbResource{}
+	err := h.elbClient.DescribeTargetGroupsPages(&elbv2.DescribeTargetGroupsInput{}, func(output *elbv2.DescribeTargetGroupsOutput, lastPage bool) bool {
+		for _, tg := range output.TargetGroups {
+			result = append(result, elbResource{
+				arn:  aws.StringValue(tg.TargetGroupArn),
+				name: aws.StringValue(tg.TargetGroupName),
+			})
+		}
+		return true
+	})
+	return result, err
+}
+
+// elbTags returns the tags of load balancers or target groups by ARN
+func (h *AWSHelper) elbTags(resources []elbResource) (map[string]map[string]string, error) {
+	result := map[string]map[string]string{}
+	for start := 0; start < len(resources); start += elbTagsBatchSize {
+		end := start + elbTagsBatchSize
+		if end > len(resources) {
+			end = len(resources)
+		}
+		arns := []*string{}
+		for _, resource := range resources[start:end] {
+			arns = append(arns, aws.String(resource.arn))
+		}
+		output, err := h.elbClient.DescribeTags(&elbv2.DescribeTagsInput{ResourceArns: arns})
+		if err != nil {
+			return nil, err
+		}
+		for _, description := range output.TagDescriptions {
+			tags := map[string]string{}
+			for _, tag := range description.Tags {
+				tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+			result[aws.StringValue(description.ResourceArn)] = tags
+		}
+	}
+	return result, nil
+}
+
+// findLoadBalancers returns the load balancers of the cluster, named by their DNS names
+func (h *AWSHelper) findLoadBalancers(legacyNames sets.String) ([]namedResource, error) {
+	lbs, err := h.listLoadBalancers()
+	if err != nil {
+		return nil, err
+	}
+	return h.clusterELBResources(lbs, legacyNames, func(lb elbResource) string { return lb.dnsName })
+}
+
+// findTargetGroups returns the target groups of the cluster, named by their names
+func (h *AWSHelper) findTargetGroups(legacyNames sets.String) ([]namedResource, error) {
+	tgs, err := h.listTargetGroups()
+	if err != nil {
+		return nil, err
+	}
+	return h.clusterELBResources(tgs, legacyNames, func(tg elbResource) string { return tg.name })
+}
+
+// clusterELBResources returns the load balancers or target groups that are tagged as
+// resources of the cluster, or that are owned by the management cluster and have one of
+// the legacy names of the cluster
+func (h *AWSHelper) clusterELBResources(resources []elbResource, legacyNames sets.String, nameOf func(elbResource) string) ([]namedResource, error) {
+	tags, err := h.elbTags(resources)
+	if err != nil {
+		return nil, err
+	}
+	owned := h.ownedTags(tags)
+	result := []namedResource{}
+	for _, resource := range resources {
+		resourceTags, ok := owned[resource.arn]
+		if !ok {
+			continue
+		}
+		if resourceTags[ClusterTagKey] == h.clusterName || legacyNames.Has(resource.name) {
+			result = append(result, namedResource{id: resource.arn, name: nameOf(resource)})
+		}
+	}
+	return result, nil
+}
+
+// findAddresses returns the elastic IPs of the cluster, named by their public IPs
+func (h *AWSHelper) findAddresses(legacyName string) ([]namedResource, error) {
+	result := []namedResource{}
+	found := sets.NewString()
+	for _, filter := range []*ec2.Filter{
+		{Name: aws.String("tag:" + ClusterTagKey), Values: aws.StringSlice([]string{h.clusterName})},
+		{Name: aws.String("tag:Name"), Values: aws.StringSlice([]string{legacyName})},
+	} {
+		addresses, err := h.listOwnedAddresses(filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range addresses {
+			id := aws.StringValue(address.AllocationId)
+			if found.Has(id) {
+				continue
+			}
+			found.Insert(id)
+			result = append(result, namedResource{id: id, name: aws.StringValue(address.PublicIp)})
+		}
+	}
+	return result, nil
+}
+
+// listOwnedAddresses returns the elastic IPs owned by the management cluster that match
+// the filter
+func (h *AWSHelper) listOwnedAddresses(filter *ec2.Filter) ([]*ec2.Address, error) {
+	output, err := h.ec2Client.DescribeAddresses(&ec2.DescribeAddressesInput{
+		Filters: []*ec2.Filter{
+			filter,
+			{
+				Name:   aws.String(fmt.Sprintf("tag:kubernetes.io/cluster/%s", h.infraName)),
+				Values: aws.StringSlice([]string{"owned"}),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Addresses, nil
+}
+
+// listPrivateZones returns the domains of all the private hosted zones of the account by id
+func (h *AWSHelper) listPrivateZones() (map[string]string, error) {
+	names := map[string]string{}
+	err := h.route53Client.ListHostedZonesPages(&route53.ListHostedZonesInput{}, func(output *route53.ListHostedZonesOutput, lastPage bool) bool {
+		for _, zone := range output.HostedZones {
+			if zone.Config != nil && aws.BoolValue(zone.Config.PrivateZone) {
+				names[strings.TrimPrefix(aws.StringValue(zone.Id), "/hostedzone/")] = aws.StringValue(zone.Name)
+			}
+		}
+		return true
+	})
+	return names, err
+}
+
+// zoneTags returns the tags of hosted zones by id
+func (h *AWSHelper) zoneTags(zones map[string]string) (map[string]map[string]string, error) {
+	ids := sets.NewString()
+	for id := range zones {
+		ids.Insert(id)
+	}
+	all := ids.List()
+	result := map[string]map[string]string{}
+	for start := 0; start < len(all); start += zoneTagsBatchSize {
+		end := start + zoneTagsBatchSize
+		if end > len(all) {
+			end = len(all)
+		}
+		output, err := h.route53Client.ListTagsForResources(&route53.ListTagsForResourcesInput{
+			ResourceType: aws.String(route53.TagResourceTypeHostedzone),
+			ResourceIds:  aws.StringSlice(all[start:end]),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, tagSet := range output.ResourceTagSets {
+			tags := map[string]string{}
+			for _, tag := range tagSet.Tags {
+				tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+			result[aws.StringValue(tagSet.ResourceId)] = tags
+		}
+	}
+	return result, nil
+}
+
+// findPrivateZones returns the private zones tagged with the cluster name and the private
+// zone of the legacy domain of the cluster, named by their domains
+func (h *AWSHelper) findPrivateZones(legacyDomain string) ([]namedResource, error) {
+	zones, err := h.listPrivateZones()
+	if err != nil {
+		return nil, err
+	}
+	tags, err := h.zoneTags(zones)
+	if err != nil {
+		return nil, err
+	}
+	ids := sets.NewString()
+	for id := range zones {
+		ids.Insert(id)
+	}
+	result := []namedResource{}
+	for _, id := range ids.List() {
+		if isClusterResource(tags[id], h.infraName, h.clusterName) || zones[id] == fqdn(legacyDomain) {
+			result = append(result, namedResource{id: id, name: zones[id]})
+		}
+	}
+	return result, nil
+}
+
+// bucketTags returns the tags of the S3 buckets with the name prefix of the management
+// cluster by name. Buckets without tags or in other regions have no tags.
+func (h *AWSHelper) bucketTags() (map[string]map[string]string, error) {
+	output, err := h.s3Client.ListBuckets(&s3.ListBucketsInput{})
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]map[string]string{}
+	for _, bucket := range output.Buckets {
+		name := aws.StringValue(bucket.Name)
+		if !strings.HasPrefix(name, h.infraName+"-") {
+			continue
+		}
+		tags := map[string]string{}
+		tagging, err := h.s3Client.GetBucketTagging(&s3.GetBucketTaggingInput{Bucket: aws.String(name)})
+		if err == nil {
+			for _, tag := range tagging.TagSet {
+				tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+		}
+		result[name] = tags
+	}
+	return result, nil
+}
+
+// findBuckets returns the S3 buckets tagged with the cluster name and the legacy bucket of
+// the cluster. Only buckets with the name prefix of the management cluster are considered.
+func (h *AWSHelper) findBuckets(legacyName string) ([]string, error) {
+	tags, err := h.bucketTags()
+	if err != nil {
+		return nil, err
+	}
+	names := sets.NewString()
+	for name, bucketTags := range tags {
+		if name == legacyName || isClusterResource(bucketTags, h.infraName, h.clusterName) {
+			names.Insert(name)
+		}
+	}
+	return names.List(), nil
+}
+
+// findRecords returns the CNAME records of a zone that point to one of the given load
+// balancer DNS names
+func (h *AWSHelper) findRecords(zoneID string, lbDNSNames sets.String) ([]dnsRecord, error) {
+	result := []dnsRecord{}
+	if lbDNSNames.Len() == 0 {
+		return result, nil
+	}
+	err := h.route53Client.ListResourceRecordSetsPages(&route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID),
+	}, func(output *route53.ListResourceRecordSetsOutput, lastPage bool) bool {
+		for _, r := range output.ResourceRecordSets {
+			if aws.StringValue(r.Type) != route53.RRTypeCname || len(r.ResourceRecords) == 0 {
+				continue
+			}
+			value := aws.StringValue(r.ResourceRecords[0].Value)
+			if recordTargets(value, lbDNSNames) {
+				result = append(result, dnsRecord{zoneID: zoneID, name: aws.StringValue(r.Name), value: value, ttl: aws.Int64Value(r.TTL)})
+			}
+		}
+		return true
+	})
+	return result, err
+}
+
+// apiRecords returns the records named after the API of the cluster with domain
+func apiRecords(records []dnsRecord, domain string) []dnsRecord {
+	result := []dnsRecord{}
+	for _, record := range records {
+		if recordTargets(record.name, sets.NewString("api."+domain)) {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// recordTargets returns true if a CNAME value is one of the given DNS names, ignoring case
+// and the trailing dot of fully qualified names
+func recordTargets(value string, dnsNames sets.String) bool {
+	value = strings.TrimSuffix(strings.ToLower(value), ".")
+	for name := range dnsNames {
+		if strings.TrimSuffix(strings.ToLower(name), ".") == value {
+			return true
+		}
+	}
+	return false
+}
+
+// removeRecord removes a CNAME record found by findRecords
+func (h *AWSHelper) removeRecord(record dnsRecord) error {
+	_, err := h.route53Client.ChangeResourceRecordSets(&route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(record.zoneID),
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: []*route53.Change{
+				{
+					Action: aws.String(route53.ChangeActionDelete),
+					ResourceRecordSet: &route53.ResourceRecordSet{
+						Name:            aws.String(record.name),
+						Type:            aws.String(route53.RRTypeCname),
+						TTL:             aws.Int64(record.ttl),
+						ResourceRecords: []*route53.ResourceRecord{{Value: aws.String(record.value)}},
+					},
+				},
+			},
+		},
+	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == route53.ErrCodeInvalidChangeBatch {
+		// The record was removed since it was found
+		return nil
+	}
+	return err
+}
+
+// remove removes the resources in dependency order: records before the load balancers they
+// point to, load balancers before their target groups and elastic IPs, and the records of
+// private zones before the zones. The IAM user of the cluster is removed after the
+// resources that its components may still use.
+func (r *clusterResources) remove(h *AWSHelper) error {
+	for _, record := range r.records {
+		if err := h.removeRecord(record); err != nil {
+			return cloudProviderError(err, "cannot delete DNS record %s", record.name)
+		}
+	}
+	for _, lb := range r.loadBalancers {
+		if err := h.RemoveNLB(lb.id); err != nil {
+			return cloudProviderError(err, "cannot delete load balancer %s", lb.id)
+		}
+	}
+	for _, tg := range r.targetGroups {
+		if err := h.RemoveTargetGroup(tg.id); err != nil {
+			return cloudProviderError(err, "cannot delete target group %s", tg.name)
+		}
+	}
+	for _, address := range r.addresses {
+		if err := h.RemoveEIP(address.id); err != nil {
+			return cloudProviderError(err, "cannot release elastic IP %s", address.name)
+		}
+	}
+	for _, zone := range r.privateZones {
+		if err := h.RemovePrivateZone(zone.id); err != nil {
+			return cloudProviderError(err, "cannot delete private DNS zone %s", zone.name)
+		}
+	}
+	for _, user := range r.users {
+		if err := h.RemoveClusterUser(user); err != nil {
+			return cloudProviderError(err, "cannot delete IAM user %s", user)
+		}
+	}
+	return nil
+}
+
+// removeBuckets removes the S3 buckets. They are removed last, because the machines that
+// are removed with the cluster's machinesets may still read the ignition file.
+func (r *clusterResources) removeBuckets(h *AWSHelper) error {
+	for _, bucket := range r.buckets {
+		if err := h.RemoveIgnitionBucket(bucket); err != nil {
+			return cloudProviderError(err, "cannot delete S3 bucket %s", bucket)
+		}
+	}
+	return nil
+}
+
+// print writes the resources as rows of a table of resources, starting each row with prefix
+func (r *clusterResources) print(w io.Writer, prefix string) {
+	for _, resource := range r.list() {
+		fmt.Fprintf(w, "%s%s\t%s\t%s\n", prefix, resource.Kind, resource.ID, resource.Name)
+	}
+}
+
+// list returns the kind, id and name of each resource. The id of a DNS record is its name,
+// and its name is the id of its zone.
+func (r *clusterResources) list() []installer.Resource {
+	var resources []installer.Resource
+	for _, record := range r.records {
+		resources = append(resources, installer.Resource{Kind: "DNS record", ID: record.name, Name: record.zoneID})
+	}
+	for _, lb := range r.loadBalancers {
+		resources = append(resources, installer.Resource{Kind: "Load balancer", ID: lb.id, Name: lb.name})
+	}
+	for _, tg := range r.targetGroups {
+		resources = append(resources, installer.Resource{Kind: "Target group", ID: tg.id, Name: tg.name})
+	}
+	for _, address := range r.addresses {
+		resources = append(resources, installer.Resource{Kind: "Elastic IP", ID: address.id, Name: address.name})
+	}
+	for _, zone := range r.privateZones {
+		resources = append(resources, installer.Resource{Kind: "Private DNS zone", ID: zone.id, Name: zone.name})
+	}
+	for _, bucket := range r.buckets {
+		resources = append(resources, installer.Resource{Kind: "S3 bucket", ID: bucket})
+	}
+	for _, user := range r.users {
+		resources = append(resources, installer.Resource{Kind: "IAM user", ID: user})
+	}
+	return resources
+}
+
+// newResourceWriter returns a writer of a table of resources with a header. The columns
+// come before the kind, id and name of the resources.
+func newResourceWriter(out io.Writer, columns ...string) *tabwriter.Writer {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(append(columns, "KIND", "ID", "NAME"), "\t"))
+	return w
+}
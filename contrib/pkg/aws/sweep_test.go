@@ -0,0 +1,116 @@
+package aws
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestIsClusterResource(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     map[string]string
+		expected bool
+	}{
+		{name: "cluster resource", tags: map[string]string{ClusterTagKey: "example", "kubernetes.io/cluster/mgmt-abc": "owned"}, expected: true},
+		{name: "other cluster", tags: map[string]string{ClusterTagKey: "other", "kubernetes.io/cluster/mgmt-abc": "owned"}},
+		{name: "other management cluster", tags: map[string]string{ClusterTagKey: "example", "kubernetes.io/cluster/mgmt-def": "owned"}},
+		{name: "not owned", tags: map[string]string{ClusterTagKey: "example", "kubernetes.io/cluster/mgmt-abc": "shared"}},
+		{name: "untagged", tags: map[string]string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := isClusterResource(test.tags, "mgmt-abc", "example"); actual != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRecordTargets(t *testing.T) {
+	lbs := sets.NewString("mgmt-abc-example-api-123.elb.us-east-1.amazonaws.com")
+	tests := []struct {
+		value    string
+		expected bool
+	}{
+		{value: "mgmt-abc-example-api-123.elb.us-east-1.amazonaws.com", expected: true},
+		{value: "mgmt-abc-example-api-123.elb.us-east-1.amazonaws.com.", expected: true},
+		{value: "MGMT-ABC-EXAMPLE-API-123.elb.us-east-1.amazonaws.com", expected: true},
+		{value: "mgmt-abc-other-api-456.elb.us-east-1.amazonaws.com"},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			if actual := recordTargets(test.value, lbs); actual != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestAPIRecords(t *testing.T) {
+	records := []dnsRecord{
+		{name: "api.example.clusters.com.", value: "frontend.elb.us-east-1.amazonaws.com"},
+		{name: "api.other.clusters.com.", value: "frontend.elb.us-east-1.amazonaws.com"},
+		{name: "oauth.example.clusters.com.", value: "frontend.elb.us-east-1.amazonaws.com"},
+	}
+	actual := apiRecords(records, "example.clusters.com")
+	if !reflect.DeepEqual(actual, records[:1]) {
+		t.Errorf("expected only the API record of the cluster, got %v", actual)
+	}
+}
+
+func TestFindLoadBalancers(t *testing.T) {
+	const response = `<?xml version="1.0"?><%[1]sResponse xmlns="http://elasticloadbalancing.amazonaws.com/doc/2015-12-01/"><%[1]sResult>%[2]s</%[1]sResult></%[1]sResponse>`
+	tag := func(key, value string) string {
+		return fmt.Sprintf("<member><Key>%s</Key><Value>%s</Value></member>", key, value)
+	}
+	owned := tag("kubernetes.io/cluster/mgmt-abc", "owned")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action := r.PostFormValue("Action")
+		switch action {
+		case "DescribeLoadBalancers":
+			lbs := ""
+			for _, name := range []string{"tagged", "legacy", "other", "unowned"} {
+				lbs += fmt.Sprintf("<member><LoadBalancerArn>arn:%[1]s</LoadBalancerArn><LoadBalancerName>%[1]s</LoadBalancerName><DNSName>%[1]s.elb.amazonaws.com</DNSName></member>", name)
+			}
+			fmt.Fprintf(w, response, action, "<LoadBalancers>"+lbs+"</LoadBalancers>")
+		case "DescribeTags":
+			fmt.Fprintf(w, response, action, "<TagDescriptions>"+
+				"<member><ResourceArn>arn:tagged</ResourceArn><Tags>"+owned+tag(ClusterTagKey, "example")+"</Tags></member>"+
+				"<member><ResourceArn>arn:legacy</ResourceArn><Tags>"+owned+"</Tags></member>"+
+				"<member><ResourceArn>arn:other</ResourceArn><Tags>"+owned+tag(ClusterTagKey, "other")+"</Tags></member>"+
+				"<member><ResourceArn>arn:unowned</ResourceArn><Tags>"+tag(ClusterTagKey, "example")+"</Tags></member>"+
+				"</TagDescriptions>")
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	}))
+	defer server.Close()
+	s := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("key", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+	h := &AWSHelper{elbClient: elbv2.New(s), infraName: "mgmt-abc", clusterName: "example"}
+	lbs, err := h.findLoadBalancers(sets.NewString("legacy"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []namedResource{
+		{id: "arn:tagged", name: "tagged.elb.amazonaws.com"},
+		{id: "arn:legacy", name: "legacy.elb.amazonaws.com"},
+	}
+	if !reflect.DeepEqual(lbs, expected) {
+		t.Errorf("expected load balancers %v, got %v", expected, lbs)
+	}
+}
@@ -1,156 +1,285 @@
 package aws
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/awsinfra"
 )
 
-func UninstallCluster(name string) error {
+// UninstallCluster removes the cluster named name and its AWS resources, which are found by
+// their cluster tag. With dryRun, the resources that would be removed are written as a table
+// to out and nothing is changed. The installer's AWS credentials are selected by
+// awsCredentials. The resources that are removed, or would be removed, are recorded in
+// result, if it is not nil.
+func UninstallCluster(name string, awsCredentials CredentialsConfig, dryRun bool, out io.Writer, result *installer.Result) error {
 	// First, ensure that we can access the host cluster
-	cfg, err := loadConfig()
+	cfg, err := installer.LoadConfig()
 	if err != nil {
-		return fmt.Errorf("cannot access existing cluster; make sure a connection to host cluster is available: %v", err)
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
 	}
 
 	dynamicClient, err := dynamic.NewForConfig(cfg)
 	if err != nil {
-		return fmt.Errorf("cannot obtain dynamic client: %v", err)
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
 	}
 
 	infraName, region, err := getInfrastructureInfo(dynamicClient)
 	if err != nil {
-		return fmt.Errorf("failed to obtain infrastructure info for cluster: %v", err)
+		return installerrors.Precondition(err, "failed to obtain infrastructure info for cluster")
 	}
 	log.Debugf("The management cluster infra name is: %s", infraName)
 	log.Debugf("The management cluster AWS region is: %s", region)
 
-	dnsZoneID, parentDomain, err := getDNSZoneInfo(dynamicClient)
+	dnsZoneID, parentDomain, err := installer.GetDNSZoneInfo(dynamicClient)
 	if err != nil {
-		return fmt.Errorf("failed to obtain public zone information: %v", err)
+		return installerrors.Precondition(err, "failed to obtain public zone information")
 	}
 	log.Debugf("Using public DNS Zone: %s and parent suffix: %s", dnsZoneID, parentDomain)
 
 	client, err := kubeclient.NewForConfig(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to obtain a kubernetes client from existing configuration: %v", err)
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
 	}
-	awsKey, awsSecretKey, err := getAWSCredentials(client)
+	creds, err := getAWSCredentials(client, awsCredentials, region)
 	if err != nil {
-		return fmt.Errorf("failed to obtain AWS credentials from host cluster: %v", err)
+		return installerrors.Precondition(err, "failed to obtain AWS credentials")
 	}
 	// Fetch AWS cloud data
-	aws, err := NewAWSHelper(awsKey, awsSecretKey, region, infraName)
+	aws, err := NewAWSHelper(creds, region, infraName, name)
 	if err != nil {
-		return fmt.Errorf("cannot create an AWS client: %v", err)
+		return installerrors.Precondition(err, "cannot create an AWS client")
 	}
-
-	log.Infof("Removing API DNS record")
-	apiDNSName := fmt.Sprintf("api.%s.%s.", name, parentDomain)
-	if err = aws.RemoveCNameRecord(dnsZoneID, apiDNSName); err != nil {
-		return fmt.Errorf("cannot delete API DNS resource record: %v", err)
+	frontendDNSName, err := apiServerFrontendDNSName(client)
+	if err != nil {
+		return installerrors.Apply(err, "failed to get the API server frontend")
 	}
-
-	log.Infof("Removing API load balancer")
-	apiLBName := generateLBResourceName(infraName, name, "api")
-	if err = aws.RemoveNLB(apiLBName); err != nil {
-		return fmt.Errorf("cannot delete API load balancer: %v", err)
+	if dryRun {
+		machineSets, err := machineSetClient(dynamicClient)
+		if err != nil {
+			return installerrors.Precondition(err, "cannot obtain machineset client")
+		}
+		resources, err := aws.findClusterResources(dnsZoneID, fmt.Sprintf("%s.%s", name, parentDomain), frontendDNSName)
+		if err != nil {
+			return cloudProviderError(err, "cannot find AWS resources of cluster %s", name)
+		}
+		machineSetNames, err := existingMachineSetNames(machineSets, infraName, name)
+		if err != nil {
+			return installerrors.Apply(err, "failed to list worker machinesets")
+		}
+		secretNames, err := userDataSecretNames(client, name)
+		if err != nil {
+			return installerrors.Apply(err, "failed to list worker user data secrets")
+		}
+		removed := append(resources.list(), clusterObjects(name, machineSetNames, secretNames)...)
+		w := newResourceWriter(out)
+		for _, resource := range removed {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", resource.Kind, resource.ID, resource.Name)
+			result.AddRemovedResource(resource.Kind, resource.ID, resource.Name)
+		}
+		return w.Flush()
 	}
 
-	log.Infof("Removing API target group")
-	if err = aws.RemoveTargetGroup(apiLBName); err != nil {
-		return fmt.Errorf("cannot delete API target group: %v", err)
+	// The control plane operator re-creates drifted resources, so it must not be
+	// running while the cluster's AWS resources are found and removed
+	log.Info("Stopping AWS infrastructure repair")
+	if err = stopInfraRepair(client, name); err != nil {
+		return err
 	}
 
-	log.Infof("Removing OAuth target group")
-	oauthTGName := generateLBResourceName(infraName, name, "oauth")
-	if err = aws.RemoveTargetGroup(oauthTGName); err != nil {
-		return fmt.Errorf("cannot delete OAuth target group: %v", err)
-	}
+	provider := &awsProvider{
+		helper:        aws,
+		dynamicClient: dynamicClient,
+		infraName:     infraName,
+		clusterName:   name,
+		parentDomain:  parentDomain,
+		dnsZoneID:     dnsZoneID,
 
-	log.Infof("Removing API elastic IP")
-	if err = aws.RemoveEIP(apiLBName); err != nil {
-		return fmt.Errorf("cannot delete EIP for API load balancer: %v", err)
+		sharedIngressDNSName: frontendDNSName,
 	}
-
-	log.Infof("Removing VPN DNS record")
-	vpnDNSName := fmt.Sprintf("vpn.%s.%s.", name, parentDomain)
-	if err = aws.RemoveCNameRecord(dnsZoneID, vpnDNSName); err != nil {
-		return fmt.Errorf("cannot delete VPN DNS resource record: %v", err)
+	if err = provider.Teardown(); err != nil {
+		return err
 	}
-
-	log.Infof("Removing VPN load balancer")
-	vpnLBName := generateLBResourceName(infraName, name, "vpn")
-	if err = aws.RemoveNLB(vpnLBName); err != nil {
-		return fmt.Errorf("cannot delete VPN load balancer: %v", err)
+	for _, resource := range provider.removed.list() {
+		result.AddRemovedResource(resource.Kind, resource.ID, resource.Name)
 	}
 
-	log.Infof("Removing VPN target group")
-	if err = aws.RemoveTargetGroup(vpnLBName); err != nil {
-		return fmt.Errorf("cannot delete VPN target group: %v", err)
+	log.Infof("Removing worker user data secrets")
+	if err = removeUserDataSecrets(client, name); err != nil {
+		return installerrors.Apply(err, "failed to remove worker user data secrets")
 	}
 
-	log.Infof("Removing router DNS record")
-	routerDNSName := fmt.Sprintf("\\052.apps.%s.%s.", name, parentDomain)
-	if err = aws.RemoveCNameRecord(dnsZoneID, routerDNSName); err != nil {
-		return fmt.Errorf("cannot delete router DNS resource record: %v", err)
+	log.Info("Removing machine reader role")
+	if err = removeMachineReaderRole(client, name); err != nil {
+		return installerrors.Apply(err, "failed to remove machine reader role")
 	}
 
-	log.Infof("Removing router load balancer")
-	routerLBName := generateLBResourceName(infraName, name, "apps")
-	if err = aws.RemoveNLB(routerLBName); err != nil {
-		return fmt.Errorf("cannot delete router load balancer: %v", err)
+	log.Info("Removing cluster namespace")
+	if err = client.CoreV1().Namespaces().Delete(name, &metav1.DeleteOptions{}); err != nil {
+		if !errors.IsNotFound(err) {
+			return installerrors.Apply(err, "failed to delete namespace %s", name)
+		}
 	}
+	result.AddRemovedResource("Namespace", name, "")
 
-	log.Infof("Removing router HTTP target group")
-	httpTGName := generateLBResourceName(infraName, name, "http")
-	if err = aws.RemoveTargetGroup(httpTGName); err != nil {
-		return fmt.Errorf("cannot delete router HTTP target group: %v", err)
+	log.Info("Releasing the CIDRs of the cluster")
+	if err = installer.ReleaseClusterCIDRs(client, name); err != nil {
+		return installerrors.Apply(err, "failed to release the CIDRs of the cluster")
 	}
 
-	log.Infof("Removing router HTTPS target group")
-	httpsTGName := generateLBResourceName(infraName, name, "https")
-	if err = aws.RemoveTargetGroup(httpsTGName); err != nil {
-		return fmt.Errorf("cannot delete router HTTPS target group: %v", err)
-	}
+	return nil
+}
 
-	log.Infof("Removing worker machineset")
-	if err = removeWorkerMachineset(dynamicClient, infraName, name); err != nil {
-		return fmt.Errorf("failed to remove worker machineset: %v", err)
+// clusterObjects returns the management cluster objects of the cluster that an uninstall
+// removes, other than the objects in its namespace
+func clusterObjects(name string, machineSetNames, secretNames []string) []installer.Resource {
+	var objects []installer.Resource
+	for _, machineSetName := range machineSetNames {
+		objects = append(objects, installer.Resource{Kind: "MachineSet", ID: fmt.Sprintf("%s/%s", awsinfra.MachineNamespace, machineSetName)})
 	}
+	for _, secretName := range secretNames {
+		objects = append(objects, installer.Resource{Kind: "Secret", ID: fmt.Sprintf("%s/%s", awsinfra.MachineNamespace, secretName)})
+	}
+	return append(objects, installer.Resource{Kind: "Namespace", ID: name})
+}
 
-	log.Infof("Removing bootstrap ignition bucket")
-	bucketName := generateBucketName(infraName, name, "ign")
-	if err = aws.RemoveIgnitionBucket(bucketName); err != nil {
-		return fmt.Errorf("cannot delete ignition bucket: %v", err)
+// removeWorkerMachineset removes the worker machinesets of the cluster, including the
+// single machineset created by earlier versions of the installer
+func removeWorkerMachineset(machineSets dynamic.ResourceInterface, infraName, namespace string) error {
+	names, err := workerMachineSetNames(machineSets, infraName, namespace)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err = machineSets.Delete(name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
 	}
+	return nil
+}
 
-	log.Info("Removing cluster namespace")
-	if err = client.CoreV1().Namespaces().Delete(name, &metav1.DeleteOptions{}); err != nil {
-		if !errors.IsNotFound(err) {
-			return fmt.Errorf("failed to delete namespace %s: %v", name, err)
+// existingMachineSetNames returns the names of the worker machinesets of the cluster that exist
+func existingMachineSetNames(machineSets dynamic.ResourceInterface, infraName, namespace string) ([]string, error) {
+	names, err := workerMachineSetNames(machineSets, infraName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for _, name := range names {
+		_, err := machineSets.Get(name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
 		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, name)
 	}
+	return result, nil
+}
 
+// removeUserDataSecrets removes the user data secrets of the node pools of the cluster,
+// including the single secret created by earlier versions of the installer
+func removeUserDataSecrets(client kubeclient.Interface, namespace string) error {
+	names, err := userDataSecretNames(client, namespace)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err = client.CoreV1().Secrets(awsinfra.MachineNamespace).Delete(name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
 	return nil
 }
 
-func removeWorkerMachineset(client dynamic.Interface, infraName, namespace string) error {
-	name := generateMachineSetName(infraName, namespace, "worker")
-	machineGV, err := schema.ParseGroupVersion("machine.openshift.io/v1beta1")
+// userDataSecretNames returns the names of the existing user data secrets of the node pools
+// of the cluster
+func userDataSecretNames(client kubeclient.Interface, namespace string) ([]string, error) {
+	secrets := client.CoreV1().Secrets(awsinfra.MachineNamespace)
+	list, err := secrets.List(metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", machineSetClusterLabel, namespace)})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	names := sets.NewString()
+	for _, item := range list.Items {
+		names.Insert(item.Name)
+	}
+	legacyName := fmt.Sprintf("%s-user-data", namespace)
+	_, err = secrets.Get(legacyName, metav1.GetOptions{})
+	if err == nil {
+		names.Insert(legacyName)
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+	return names.List(), nil
+}
+
+// stopInfraRepair disables repair in the AWS infrastructure configmap of the cluster and
+// scales down the control plane operator, waiting for its pods to terminate. A missing
+// or empty infrastructure configuration means there is no repair to stop.
+func stopInfraRepair(client kubeclient.Interface, namespace string) error {
+	cm, err := client.CoreV1().ConfigMaps(namespace).Get(awsinfra.InfraConfigMapName, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return installerrors.Apply(err, "failed to fetch AWS infrastructure configuration")
+	}
+	if err == nil && len(cm.Data[awsinfra.InfraConfigKey]) > 0 {
+		infra := &awsinfra.InfraConfig{}
+		if err = json.Unmarshal([]byte(cm.Data[awsinfra.InfraConfigKey]), infra); err != nil {
+			return installerrors.Precondition(err, "invalid AWS infrastructure configuration")
+		}
+		if infra.Repair {
+			infra.Repair = false
+			infraBytes, err := json.Marshal(infra)
+			if err != nil {
+				return installerrors.Apply(err, "failed to serialize AWS infrastructure configuration")
+			}
+			cm.Data[awsinfra.InfraConfigKey] = string(infraBytes)
+			if _, err = client.CoreV1().ConfigMaps(namespace).Update(cm); err != nil {
+				return installerrors.Apply(err, "failed to disable AWS infrastructure repair")
+			}
+		}
 	}
-	machineSetGVR := machineGV.WithResource("machinesets")
-	err = client.Resource(machineSetGVR).Namespace("openshift-machine-api").Delete(name, &metav1.DeleteOptions{})
+
+	deployment, err := client.AppsV1().Deployments(namespace).Get("control-plane-operator", metav1.GetOptions{})
 	if errors.IsNotFound(err) {
 		return nil
 	}
-	return err
+	if err != nil {
+		return installerrors.Apply(err, "failed to fetch control plane operator deployment")
+	}
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != 0 {
+		replicas := int32(0)
+		deployment.Spec.Replicas = &replicas
+		if _, err = client.AppsV1().Deployments(namespace).Update(deployment); err != nil {
+			return installerrors.Apply(err, "failed to scale down control plane operator")
+		}
+	}
+	err = wait.PollImmediate(5*time.Second, 5*time.Minute, func() (bool, error) {
+		deployment, err := client.AppsV1().Deployments(namespace).Get("control-plane-operator", metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return deployment.Status.Replicas == 0, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return installerrors.Timeout(err, "timed out waiting for the control plane operator to stop")
+	}
+	if err != nil {
+		return installerrors.Apply(err, "failed to wait for the control plane operator to stop")
+	}
+	return nil
 }
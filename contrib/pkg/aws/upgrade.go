@@ -0,0 +1,131 @@
+package aws
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/render"
+)
+
+// UpgradeCluster upgrades the hosted control plane named name to releaseImage. The control
+// plane manifests are rendered again from the parameters stored when the cluster was
+// installed, with the images of the new release, and applied to the management cluster.
+// PKI secrets and the manifests that the installer creates directly are left unchanged.
+func UpgradeCluster(name, releaseImage string, waitForReady bool, waitConfig installer.WaitConfig) error {
+	if releaseImage == "" {
+		return installerrors.Precondition(nil, "a release image is required to upgrade a cluster")
+	}
+
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+
+	params, err := installer.GetClusterParams(client, name)
+	if errors.IsNotFound(err) {
+		return installerrors.Precondition(err, "cluster %s was not installed with stored parameters and cannot be upgraded", name)
+	}
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain the parameters of cluster %s", name)
+	}
+	// Applying the manifests would scale up the control plane without its workers
+	hibernated, err := installer.ControlPlaneHibernated(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to determine whether cluster %s is hibernated", name)
+	}
+	if hibernated {
+		return installerrors.Precondition(nil, "cluster %s is hibernated; resume it before upgrading it", name)
+	}
+	if params.ReleaseImage == releaseImage {
+		log.Infof("Cluster %s already uses release image %s, applying its manifests again", name, releaseImage)
+	} else {
+		log.Infof("Upgrading cluster %s from %s to %s", name, params.ReleaseImage, releaseImage)
+	}
+	params.ReleaseImage = releaseImage
+
+	pullSecret, err := installer.GetPullSecret(client)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a pull secret from cluster")
+	}
+
+	workingDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return installerrors.Render(err, "cannot create temporary working directory")
+	}
+	defer os.RemoveAll(workingDir)
+	manifestsDir := filepath.Join(workingDir, "manifests")
+	excludedDir := filepath.Join(workingDir, "excluded")
+	for _, dir := range []string{manifestsDir, excludedDir} {
+		if err = os.Mkdir(dir, 0755); err != nil {
+			return installerrors.Render(err, "cannot create temporary directory %s", dir)
+		}
+	}
+	pullSecretFile := filepath.Join(workingDir, "pull-secret")
+	if err = ioutil.WriteFile(pullSecretFile, []byte(pullSecret), 0644); err != nil {
+		return installerrors.Render(err, "failed to create temporary pull secret file")
+	}
+
+	// Clusters installed before the components had service accounts of their own allowed the
+	// default service account of their namespace to use the privileged SCC instead
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
+	}
+	if params.DirectRouting {
+		log.Infof("Direct routing, the control plane has no VPN pods that run privileged")
+	} else if err = installer.EnsureControlPlaneSCC(dynamicClient, name, params.PodSecurity.SkipPrivilegedSCC); err != nil {
+		return installerrors.Apply(err, "failed to ensure privileged SCC for the cluster namespace")
+	}
+
+	log.Info("Rendering Manifests")
+	// The user manifests are applied once by the bootstrapper when the cluster is installed,
+	// and their directory may not exist where the cluster is upgraded
+	params.UserManifestsDir = ""
+	if err = render.RenderClusterManifests(params, pullSecretFile, manifestsDir, true, !params.DirectRouting, false, true, true); err != nil {
+		return installerrors.Render(err, "failed to render manifests for release %s", releaseImage)
+	}
+	brandingFile := filepath.Join(manifestsDir, "v4-0-config-system-branding.yaml")
+	if err = installer.CreateBrandingSecret(client, name, brandingFile); err != nil {
+		return installerrors.Apply(err, "failed to update oauth branding secret")
+	}
+	if err = installer.ApplyManifests(cfg, name, manifestsDir, installer.UpgradeExcludeManifests, excludedDir); err != nil {
+		return installerrors.Apply(err, "failed to apply manifests")
+	}
+	if err = installer.UpdateClusterParamsSecret(client, name, params); err != nil {
+		return installerrors.Apply(err, "failed to store the parameters of the cluster")
+	}
+	log.Infof("Cluster resources applied")
+
+	if waitForReady {
+		log.Infof("Waiting up to %s for the control plane to roll out.", waitConfig.PhaseTimeout(installer.WaitPhaseRollout))
+		if err = installer.WaitForDeploymentsRolledOut(client, name, waitConfig); err != nil {
+			return installerrors.Timeout(err, "failed to wait for the control plane to roll out")
+		}
+		log.Infof("Control plane rolled out.")
+
+		targetClusterCfg, err := installer.GetTargetClusterConfigFromSecret(client, name)
+		if err != nil {
+			return installerrors.Precondition(err, "cannot create target cluster client config")
+		}
+		log.Infof("Waiting up to %s for cluster operators to be ready.", waitConfig.PhaseTimeout(installer.WaitPhaseClusterOperators))
+		if err = installer.WaitForClusterOperators(targetClusterCfg, waitConfig); err != nil {
+			return installerrors.Timeout(err, "failed to wait for cluster operators")
+		}
+	}
+
+	log.Infof("Cluster %s upgraded to %s", name, releaseImage)
+	return nil
+}
@@ -0,0 +1,217 @@
+package azure
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	armEndpoint   = "https://management.azure.com"
+	loginEndpoint = "https://login.microsoftonline.com"
+
+	provisioningTimeout = 10 * time.Minute
+)
+
+// pollInterval is the interval between checks of the provisioning state of a resource
+var pollInterval = 5 * time.Second
+
+// Credentials are the service principal credentials and location of the
+// management cluster on Azure
+type Credentials struct {
+	ClientID       string
+	ClientSecret   string
+	TenantID       string
+	SubscriptionID string
+	ResourceGroup  string
+	Region         string
+}
+
+// armError is an error returned by the Azure Resource Manager API
+type armError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *armError) Error() string {
+	return fmt.Sprintf("azure API returned %d (%s): %s", e.StatusCode, e.Code, e.Message)
+}
+
+// armClient is a minimal client of the Azure Resource Manager REST API
+type armClient struct {
+	credentials   *Credentials
+	httpClient    *http.Client
+	endpoint      string
+	loginEndpoint string
+
+	tokenLock   sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func newARMClient(credentials *Credentials) *armClient {
+	return &armClient{
+		credentials:   credentials,
+		httpClient:    &http.Client{Timeout: 60 * time.Second},
+		endpoint:      armEndpoint,
+		loginEndpoint: loginEndpoint,
+	}
+}
+
+// resourceID returns the ID of a resource in the resource group of the management cluster
+func (c *armClient) resourceID(provider, resourceType, name string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/%s/%s/%s", c.credentials.SubscriptionID, c.credentials.ResourceGroup, provider, resourceType, name)
+}
+
+// authorize returns a bearer token for the management API, obtaining a new one
+// with the client credentials grant when the current one is about to expire
+func (c *armClient) authorize() (string, error) {
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+	if c.token != "" && time.Now().Add(time.Minute).Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", c.credentials.ClientID)
+	form.Set("client_secret", c.credentials.ClientSecret)
+	form.Set("resource", armEndpoint+"/")
+	resp, err := c.httpClient.PostForm(fmt.Sprintf("%s/%s/oauth2/token", c.loginEndpoint, c.credentials.TenantID), form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", &armError{StatusCode: resp.StatusCode, Code: "AuthenticationFailed", Message: string(body)}
+	}
+	result := struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}{}
+	if err = json.Unmarshal(body, &result); err != nil {
+		return "", errors.Wrap(err, "cannot decode token response")
+	}
+	expiresIn, err := result.ExpiresIn.Int64()
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid token expiration %q", result.ExpiresIn)
+	}
+	c.token = result.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	return c.token, nil
+}
+
+// do sends a request for the resource with the given ID and decodes the response into out
+func (c *armClient) do(method, id, apiVersion string, in, out interface{}) error {
+	token, err := c.authorize()
+	if err != nil {
+		return err
+	}
+	var body *bytes.Reader
+	if in != nil {
+		inBytes, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(inBytes)
+	} else {
+		body = bytes.NewReader(nil)
+	}
+	separator := "?"
+	if strings.Contains(id, "?") {
+		separator = "&"
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("%s%s%sapi-version=%s", c.endpoint, id, separator, apiVersion), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		result := struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}{}
+		json.Unmarshal(respBytes, &result)
+		return &armError{StatusCode: resp.StatusCode, Code: result.Error.Code, Message: result.Error.Message}
+	}
+	if out != nil && len(respBytes) > 0 {
+		return json.Unmarshal(respBytes, out)
+	}
+	return nil
+}
+
+// ensureResource creates or updates a resource and waits for its provisioning to complete
+func (c *armClient) ensureResource(id, apiVersion string, resource interface{}) (map[string]interface{}, error) {
+	if err := c.do(http.MethodPut, id, apiVersion, resource, nil); err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	err := wait.PollImmediate(pollInterval, provisioningTimeout, func() (bool, error) {
+		result = map[string]interface{}{}
+		if err := c.do(http.MethodGet, id, apiVersion, nil, &result); err != nil {
+			return false, err
+		}
+		properties, _ := result["properties"].(map[string]interface{})
+		switch state, _ := properties["provisioningState"].(string); state {
+		case "", "Succeeded":
+			return true, nil
+		case "Failed", "Canceled":
+			return false, errors.Errorf("provisioning of %s ended in state %s", id, state)
+		}
+		return false, nil
+	})
+	return result, err
+}
+
+// removeResource deletes a resource if it exists and waits for it to be gone
+func (c *armClient) removeResource(id, apiVersion string) error {
+	err := c.do(http.MethodDelete, id, apiVersion, nil, nil)
+	if isNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return wait.PollImmediate(pollInterval, provisioningTimeout, func() (bool, error) {
+		err := c.do(http.MethodGet, id, apiVersion, nil, nil)
+		if isNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+func isNotFound(err error) bool {
+	if armErr, ok := errors.Cause(err).(*armError); ok {
+		return armErr.StatusCode == http.StatusNotFound
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+)
+
+const testResourceID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/publicIPAddresses/ip"
+
+// fakeARM is a fake of the token and resource manager endpoints. Each GET of the
+// resource returns the next provisioning state in states.
+type fakeARM struct {
+	sync.Mutex
+	states      []string
+	tokens      int
+	puts        int
+	errorStatus int
+}
+
+func (f *fakeARM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	if r.URL.Path == "/tenant/oauth2/token" {
+		f.tokens++
+		fmt.Fprint(w, `{"access_token": "token", "expires_in": "3600"}`)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if f.errorStatus != 0 {
+		w.WriteHeader(f.errorStatus)
+		fmt.Fprint(w, `{"error": {"code": "SomeCode", "message": "some message"}}`)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		f.puts++
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet:
+		state := f.states[0]
+		if len(f.states) > 1 {
+			f.states = f.states[1:]
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"properties": map[string]interface{}{"provisioningState": state},
+		})
+	}
+}
+
+func newTestClient(fake *fakeARM) (*armClient, *httptest.Server) {
+	server := httptest.NewServer(fake)
+	client := newARMClient(&Credentials{TenantID: "tenant"})
+	client.endpoint = server.URL
+	client.loginEndpoint = server.URL
+	return client, server
+}
+
+func TestEnsureResource(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+	tests := []struct {
+		name        string
+		states      []string
+		expectError bool
+	}{
+		{name: "succeeded immediately", states: []string{"Succeeded"}},
+		{name: "no provisioning state", states: []string{""}},
+		{name: "succeeded after polling", states: []string{"Updating", "Updating", "Succeeded"}},
+		{name: "failed", states: []string{"Updating", "Failed"}, expectError: true},
+		{name: "canceled", states: []string{"Canceled"}, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := &fakeARM{states: test.states}
+			client, server := newTestClient(fake)
+			defer server.Close()
+			result, err := client.ensureResource(testResourceID, "2019-11-01", map[string]string{"location": "eastus"})
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fake.puts != 1 {
+				t.Errorf("expected 1 PUT request, got %d", fake.puts)
+			}
+			if len(fake.states) != 1 {
+				t.Errorf("expected all states to be polled, %d remaining", len(fake.states))
+			}
+			if _, ok := result["properties"]; !ok {
+				t.Errorf("expected the resource to be returned, got %v", result)
+			}
+			if fake.tokens != 1 {
+				t.Errorf("expected the token to be requested once, got %d", fake.tokens)
+			}
+		})
+	}
+}
+
+func TestErrorDecoding(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		retryable bool
+		notFound  bool
+	}{
+		{name: "throttled", status: http.StatusTooManyRequests, retryable: true},
+		{name: "server error", status: http.StatusServiceUnavailable, retryable: true},
+		{name: "bad request", status: http.StatusBadRequest},
+		{name: "not found", status: http.StatusNotFound, notFound: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, server := newTestClient(&fakeARM{errorStatus: test.status})
+			defer server.Close()
+			err := client.do(http.MethodGet, testResourceID, "2019-11-01", nil, nil)
+			armErr, ok := err.(*armError)
+			if !ok {
+				t.Fatalf("expected an armError, got %#v", err)
+			}
+			if armErr.StatusCode != test.status || armErr.Code != "SomeCode" || armErr.Message != "some message" {
+				t.Errorf("unexpected error decoded: %#v", armErr)
+			}
+			wrapped := errors.Wrap(err, "failed to create public IP")
+			if actual := installerrors.IsRetryable(cloudProviderError(wrapped, "cannot create API public IP")); actual != test.retryable {
+				t.Errorf("expected retryable %t, got %t", test.retryable, actual)
+			}
+			if actual := isNotFound(wrapped); actual != test.notFound {
+				t.Errorf("expected not found %t, got %t", test.notFound, actual)
+			}
+		})
+	}
+}
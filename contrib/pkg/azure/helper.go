@@ -0,0 +1,363 @@
+package azure
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+)
+
+const (
+	networkAPIVersion = "2020-06-01"
+	dnsAPIVersion     = "2018-05-01"
+	computeAPIVersion = "2019-07-01"
+	storageAPIVersion = "2019-06-01"
+
+	ignitionContainer = "ignition"
+)
+
+// LBRule describes a load balancing rule and the health probe of its backend port
+type LBRule struct {
+	Name         string
+	Protocol     string
+	FrontendPort int
+	BackendPort  int
+	ProbePort    int
+}
+
+// ScaleSetSpec describes the virtual machines of a worker scale set
+type ScaleSetSpec struct {
+	Capacity           int
+	VMSize             string
+	ImageID            string
+	SubnetID           string
+	BackendPoolID      string
+	DiskSizeGB         int64
+	StorageAccountType string
+	SSHKey             string
+	CustomData         string
+}
+
+type AzureHelper struct {
+	client    *armClient
+	infraName string
+}
+
+// NewAzureHelper creates an instance of the Azure helper for the resource group
+// of the management cluster
+func NewAzureHelper(credentials *Credentials, infraName string) *AzureHelper {
+	return &AzureHelper{
+		client:    newARMClient(credentials),
+		infraName: infraName,
+	}
+}
+
+// EnsurePublicIP ensures that a static public IP with the given name exists and returns its ID and address
+func (h *AzureHelper) EnsurePublicIP(name string) (string, string, error) {
+	id := h.client.resourceID("Microsoft.Network", "publicIPAddresses", name)
+	result, err := h.client.ensureResource(id, networkAPIVersion, map[string]interface{}{
+		"location": h.client.credentials.Region,
+		"tags":     h.ownedTags(),
+		"sku":      map[string]interface{}{"name": "Standard"},
+		"properties": map[string]interface{}{
+			"publicIPAllocationMethod": "Static",
+			"publicIPAddressVersion":   "IPv4",
+		},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	properties, _ := result["properties"].(map[string]interface{})
+	address, _ := properties["ipAddress"].(string)
+	if address == "" {
+		return "", "", errors.Errorf("public IP %s has no address assigned", name)
+	}
+	return id, address, nil
+}
+
+// RemovePublicIP removes a public IP
+func (h *AzureHelper) RemovePublicIP(name string) error {
+	return h.client.removeResource(h.client.resourceID("Microsoft.Network", "publicIPAddresses", name), networkAPIVersion)
+}
+
+// EnsureLoadBalancer ensures that a standard load balancer with the given rules exists
+// in front of a public IP. If backend addresses are passed, the backend pool of the load
+// balancer targets those addresses in vnetID, otherwise it is populated with the network
+// interfaces that reference it. It returns the ID of the backend pool.
+func (h *AzureHelper) EnsureLoadBalancer(name, publicIPID, vnetID string, rules []LBRule, backendAddresses ...string) (string, error) {
+	id := h.client.resourceID("Microsoft.Network", "loadBalancers", name)
+	frontendID := id + "/frontendIPConfigurations/frontend"
+	backendPoolID := id + "/backendAddressPools/backend"
+
+	addresses := []interface{}{}
+	for i, address := range backendAddresses {
+		addresses = append(addresses, map[string]interface{}{
+			"name": fmt.Sprintf("address-%d", i),
+			"properties": map[string]interface{}{
+				"ipAddress":      address,
+				"virtualNetwork": map[string]interface{}{"id": vnetID},
+			},
+		})
+	}
+	backendPool := map[string]interface{}{"name": "backend"}
+	if len(addresses) > 0 {
+		backendPool["properties"] = map[string]interface{}{"loadBalancerBackendAddresses": addresses}
+	}
+
+	probes := []interface{}{}
+	lbRules := []interface{}{}
+	for _, rule := range rules {
+		probeName := rule.Name + "-probe"
+		probes = append(probes, map[string]interface{}{
+			"name": probeName,
+			"properties": map[string]interface{}{
+				"protocol":          "Tcp",
+				"port":              rule.ProbePort,
+				"intervalInSeconds": 10,
+				"numberOfProbes":    3,
+			},
+		})
+		lbRules = append(lbRules, map[string]interface{}{
+			"name": rule.Name,
+			"properties": map[string]interface{}{
+				"frontendIPConfiguration": map[string]interface{}{"id": frontendID},
+				"backendAddressPool":      map[string]interface{}{"id": backendPoolID},
+				"probe":                   map[string]interface{}{"id": id + "/probes/" + probeName},
+				"protocol":                rule.Protocol,
+				"frontendPort":            rule.FrontendPort,
+				"backendPort":             rule.BackendPort,
+				"loadDistribution":        "Default",
+			},
+		})
+	}
+
+	_, err := h.client.ensureResource(id, networkAPIVersion, map[string]interface{}{
+		"location": h.client.credentials.Region,
+		"tags":     h.ownedTags(),
+		"sku":      map[string]interface{}{"name": "Standard"},
+		"properties": map[string]interface{}{
+			"frontendIPConfigurations": []interface{}{
+				map[string]interface{}{
+					"name": "frontend",
+					"properties": map[string]interface{}{
+						"publicIPAddress": map[string]interface{}{"id": publicIPID},
+					},
+				},
+			},
+			"backendAddressPools": []interface{}{backendPool},
+			"probes":              probes,
+			"loadBalancingRules":  lbRules,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return backendPoolID, nil
+}
+
+// RemoveLoadBalancer removes a load balancer
+func (h *AzureHelper) RemoveLoadBalancer(name string) error {
+	return h.client.removeResource(h.client.resourceID("Microsoft.Network", "loadBalancers", name), networkAPIVersion)
+}
+
+// EnsureARecord ensures that an A record for dnsName in the given zone points to address
+func (h *AzureHelper) EnsureARecord(zoneID, dnsName, address string) error {
+	_, err := h.client.ensureResource(recordSetID(zoneID, dnsName), dnsAPIVersion, map[string]interface{}{
+		"properties": map[string]interface{}{
+			"TTL":      300,
+			"ARecords": []interface{}{map[string]interface{}{"ipv4Address": address}},
+		},
+	})
+	return err
+}
+
+// RemoveARecord removes the A record for dnsName in the given zone
+func (h *AzureHelper) RemoveARecord(zoneID, dnsName string) error {
+	return h.client.removeResource(recordSetID(zoneID, dnsName), dnsAPIVersion)
+}
+
+// EnsureNodePortRule ensures that the network security group of the management cluster
+// has a rule that allows inbound traffic with the given protocol (Tcp or Udp) to the
+// given node ports of the workers
+func (h *AzureHelper) EnsureNodePortRule(name, protocol string, priority int, ports []int) error {
+	portRanges := []string{}
+	for _, port := range ports {
+		portRanges = append(portRanges, fmt.Sprintf("%d", port))
+	}
+	_, err := h.client.ensureResource(h.securityRuleID(name), networkAPIVersion, map[string]interface{}{
+		"properties": map[string]interface{}{
+			"protocol":                 protocol,
+			"sourcePortRange":          "*",
+			"destinationPortRanges":    portRanges,
+			"sourceAddressPrefix":      "Internet",
+			"destinationAddressPrefix": "VirtualNetwork",
+			"access":                   "Allow",
+			"priority":                 priority,
+			"direction":                "Inbound",
+		},
+	})
+	return err
+}
+
+// RemoveNodePortRule removes a rule created by EnsureNodePortRule
+func (h *AzureHelper) RemoveNodePortRule(name string) error {
+	return h.client.removeResource(h.securityRuleID(name), networkAPIVersion)
+}
+
+func (h *AzureHelper) securityRuleID(name string) string {
+	nsgID := h.client.resourceID("Microsoft.Network", "networkSecurityGroups", fmt.Sprintf("%s-nsg", h.infraName))
+	return fmt.Sprintf("%s/securityRules/%s", nsgID, name)
+}
+
+// EnsureIgnitionStorage ensures that a storage account with the given name exists and that it
+// contains a publicly readable blob with the contents of the ignition file passed. It returns
+// the URL of the blob.
+func (h *AzureHelper) EnsureIgnitionStorage(accountName, fileName string) (string, error) {
+	accountID := h.client.resourceID("Microsoft.Storage", "storageAccounts", accountName)
+	_, err := h.client.ensureResource(accountID, storageAPIVersion, map[string]interface{}{
+		"location": h.client.credentials.Region,
+		"tags":     h.ownedTags(),
+		"kind":     "StorageV2",
+		"sku":      map[string]interface{}{"name": "Standard_LRS"},
+		"properties": map[string]interface{}{
+			"allowBlobPublicAccess":    true,
+			"supportsHttpsTrafficOnly": true,
+		},
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create storage account %s", accountName)
+	}
+	_, err = h.client.ensureResource(accountID+"/blobServices/default/containers/"+ignitionContainer, storageAPIVersion, map[string]interface{}{
+		"properties": map[string]interface{}{"publicAccess": "Blob"},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create ignition container")
+	}
+	keys := struct {
+		Keys []struct {
+			Value string `json:"value"`
+		} `json:"keys"`
+	}{}
+	if err = h.client.do(http.MethodPost, accountID+"/listKeys", storageAPIVersion, nil, &keys); err != nil {
+		return "", errors.Wrapf(err, "failed to list keys of storage account %s", accountName)
+	}
+	if len(keys.Keys) == 0 {
+		return "", errors.Errorf("storage account %s has no keys", accountName)
+	}
+	blobURL, err := uploadBlob(h.client.httpClient, accountName, keys.Keys[0].Value, ignitionContainer, "worker.ign", fileName)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to upload ignition file")
+	}
+	return blobURL, nil
+}
+
+// RemoveIgnitionStorage removes the storage account that holds the ignition file
+func (h *AzureHelper) RemoveIgnitionStorage(accountName string) error {
+	return h.client.removeResource(h.client.resourceID("Microsoft.Storage", "storageAccounts", accountName), storageAPIVersion)
+}
+
+// EnsureScaleSet ensures that a virtual machine scale set with the given name exists
+func (h *AzureHelper) EnsureScaleSet(name string, spec *ScaleSetSpec) error {
+	ipConfiguration := map[string]interface{}{
+		"subnet": map[string]interface{}{"id": spec.SubnetID},
+	}
+	if spec.BackendPoolID != "" {
+		ipConfiguration["loadBalancerBackendAddressPools"] = []interface{}{
+			map[string]interface{}{"id": spec.BackendPoolID},
+		}
+	}
+	_, err := h.client.ensureResource(h.client.resourceID("Microsoft.Compute", "virtualMachineScaleSets", name), computeAPIVersion, map[string]interface{}{
+		"location": h.client.credentials.Region,
+		"tags":     h.ownedTags(),
+		"sku": map[string]interface{}{
+			"name":     spec.VMSize,
+			"capacity": spec.Capacity,
+		},
+		"properties": map[string]interface{}{
+			"overprovision": false,
+			"upgradePolicy": map[string]interface{}{"mode": "Manual"},
+			"virtualMachineProfile": map[string]interface{}{
+				"osProfile": map[string]interface{}{
+					"computerNamePrefix": name,
+					"adminUsername":      "core",
+					"customData":         spec.CustomData,
+					"linuxConfiguration": map[string]interface{}{
+						"disablePasswordAuthentication": true,
+						"ssh": map[string]interface{}{
+							"publicKeys": []interface{}{
+								map[string]interface{}{
+									"path":    "/home/core/.ssh/authorized_keys",
+									"keyData": spec.SSHKey,
+								},
+							},
+						},
+					},
+				},
+				"storageProfile": map[string]interface{}{
+					"imageReference": map[string]interface{}{"id": spec.ImageID},
+					"osDisk": map[string]interface{}{
+						"createOption": "FromImage",
+						"diskSizeGB":   spec.DiskSizeGB,
+						"managedDisk": map[string]interface{}{
+							"storageAccountType": spec.StorageAccountType,
+						},
+					},
+				},
+				"networkProfile": map[string]interface{}{
+					"networkInterfaceConfigurations": []interface{}{
+						map[string]interface{}{
+							"name": "nic",
+							"properties": map[string]interface{}{
+								"primary": true,
+								"ipConfigurations": []interface{}{
+									map[string]interface{}{
+										"name":       "ipconfig",
+										"properties": ipConfiguration,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	return err
+}
+
+// RemoveScaleSet removes a virtual machine scale set and its virtual machines
+func (h *AzureHelper) RemoveScaleSet(name string) error {
+	return h.client.removeResource(h.client.resourceID("Microsoft.Compute", "virtualMachineScaleSets", name), computeAPIVersion)
+}
+
+func (h *AzureHelper) ownedTags() map[string]string {
+	return map[string]string{
+		fmt.Sprintf("kubernetes.io_cluster.%s", h.infraName): "owned",
+	}
+}
+
+// recordSetID returns the ID of the A record set of dnsName in the zone with the given ID
+func recordSetID(zoneID, dnsName string) string {
+	zoneName := zoneID[strings.LastIndex(zoneID, "/")+1:]
+	relativeName := strings.TrimSuffix(strings.TrimSuffix(dnsName, "."), "."+zoneName)
+	return fmt.Sprintf("%s/A/%s", zoneID, relativeName)
+}
+
+// cloudProviderError wraps an error returned by the Azure API, flagging it as
+// retryable if it is a throttling, server or network failure.
+func cloudProviderError(err error, format string, args ...interface{}) error {
+	retryable := false
+	switch e := errors.Cause(err).(type) {
+	case *armError:
+		retryable = e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+	case *storageError:
+		retryable = e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+	case net.Error:
+		retryable = true
+	}
+	return installerrors.CloudProvider(err, retryable, format, args...)
+}
@@ -0,0 +1,660 @@
+package azure
+
+import (
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	gocidr "github.com/apparentlymart/go-cidr/cidr"
+	log "github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/ignition"
+	"github.com/openshift/hypershift-toolkit/pkg/pki"
+	"github.com/openshift/hypershift-toolkit/pkg/render"
+)
+
+const (
+	externalOauthPort   = 8443
+	workerScaleSetCount = 3
+
+	defaultControlPlaneOperatorImage = "registry.svc.ci.openshift.org/hypershift-toolkit/hypershift-4.4:control-plane-operator"
+)
+
+// workerInfo is the machine configuration of the management cluster workers
+// that is reused for the workers of the new cluster
+type workerInfo struct {
+	VMSize             string
+	ImageID            string
+	VNetID             string
+	SubnetID           string
+	DiskSizeGB         int64
+	StorageAccountType string
+}
+
+func InstallCluster(name, releaseImage, dhParamsFile string, waitForReady, skipPrivilegedSCC bool) error {
+
+	// First, ensure that we can access the host cluster
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
+	}
+	// Extract config information from management cluster
+	sshKey, err := installer.GetSSHPublicKey(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to fetch an SSH public key from existing cluster")
+	}
+	log.Debugf("The SSH public key is: %s", string(sshKey))
+
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+	credentials, err := getAzureCredentials(client)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain Azure credentials from host cluster")
+	}
+	log.Debugf("Azure client ID: %s, subscription: %s, resource group: %s", credentials.ClientID, credentials.SubscriptionID, credentials.ResourceGroup)
+
+	if releaseImage == "" {
+		releaseImage, err = installer.GetReleaseImage(dynamicClient)
+		if err != nil {
+			return installerrors.Precondition(err, "failed to obtain release image from host cluster")
+		}
+	}
+
+	pullSecret, err := installer.GetPullSecret(client)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a pull secret from cluster")
+	}
+	log.Debugf("The pull secret is: %v", pullSecret)
+
+	ignitionVersion, err := installer.GetIgnitionVersion(client)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain the ignition version of the management cluster workers")
+	}
+	log.Debugf("The ignition version of the workers is: %s", ignitionVersion)
+
+	infraName, err := getInfrastructureName(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain infrastructure info for cluster")
+	}
+	log.Debugf("The management cluster infra name is: %s", infraName)
+	log.Debugf("The management cluster Azure region is: %s", credentials.Region)
+
+	serviceCIDR, podCIDR, err := installer.GetNetworkInfo(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain network info for cluster")
+	}
+
+	dnsZoneID, parentDomain, err := installer.GetDNSZoneInfo(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain public zone information")
+	}
+	log.Debugf("Using public DNS Zone: %s and parent suffix: %s", dnsZoneID, parentDomain)
+
+	machineNames, err := installer.GetMachineNames(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to fetch machine names for cluster")
+	}
+
+	worker, err := getWorkerInfo(dynamicClient, infraName, credentials.SubscriptionID)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain worker machine configuration")
+	}
+	log.Infof("Using VNet: %s, Subnet: %s, VM size: %s", worker.VNetID, worker.SubnetID, worker.VMSize)
+
+	machineIP, err := getMachineIP(dynamicClient, machineNames, fmt.Sprintf("%s-worker-", infraName))
+	if err != nil {
+		return installerrors.Precondition(err, "cannot get machine info")
+	}
+	log.Infof("Using management machine with IP: %s", machineIP)
+
+	routerNodePortHTTP, routerNodePortHTTPS, err := installer.RouterNodePorts(client, 0, 0)
+	if err != nil {
+		return installerrors.Precondition(err, "invalid router node ports")
+	}
+	log.Infof("Using router NodePorts: %d, %d", routerNodePortHTTP, routerNodePortHTTPS)
+
+	// Start creating resources on management cluster
+	_, err = client.CoreV1().Namespaces().Get(name, metav1.GetOptions{})
+	if err == nil {
+		return installerrors.Precondition(nil, "target namespace %s already exists on management cluster", name)
+	}
+	if !errors.IsNotFound(err) {
+		return installerrors.Precondition(err, "unexpected error getting namespaces from management cluster")
+	}
+	log.Infof("Creating namespace %s", name)
+	ns := &corev1.Namespace{}
+	ns.Name = name
+	_, err = client.CoreV1().Namespaces().Create(ns)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create namespace %s", name)
+	}
+
+	// Ensure that the VPN pods can run privileged
+	if err = installer.EnsureControlPlaneSCC(dynamicClient, name, skipPrivilegedSCC); err != nil {
+		return installerrors.Apply(err, "failed to ensure privileged SCC for the new namespace")
+	}
+
+	// Create pull secret
+	log.Infof("Creating pull secret")
+	if err := installer.CreatePullSecret(client, name, pullSecret); err != nil {
+		return installerrors.Apply(err, "failed to create pull secret")
+	}
+
+	// Create Kube APIServer service
+	log.Infof("Creating Kube API service")
+	apiNodePort, err := installer.CreateKubeAPIServerService(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create kube apiserver service")
+	}
+	log.Infof("Created Kube API service with NodePort %d", apiNodePort)
+
+	log.Infof("Creating VPN service")
+	vpnNodePort, err := installer.CreateVPNServerService(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create vpn server service")
+	}
+	log.Infof("Created VPN service with NodePort %d", vpnNodePort)
+
+	log.Infof("Creating Openshift API service")
+	openshiftClusterIP, err := installer.CreateOpenshiftService(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create openshift server service")
+	}
+	log.Infof("Created Openshift API service with cluster IP: %s", openshiftClusterIP)
+
+	oauthNodePort, err := installer.CreateOauthService(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create Oauth server service")
+	}
+	log.Infof("Created Oauth server service with NodePort: %d", oauthNodePort)
+
+	azure := NewAzureHelper(credentials, infraName)
+
+	apiLBName := generateResourceName(infraName, name, "api")
+	apiPublicIPID, apiPublicIP, err := azure.EnsurePublicIP(apiLBName)
+	if err != nil {
+		return cloudProviderError(err, "cannot allocate API load balancer public IP")
+	}
+	log.Infof("Allocated public IP with ID: %s, and IP: %s", apiPublicIPID, apiPublicIP)
+
+	_, err = azure.EnsureLoadBalancer(apiLBName, apiPublicIPID, worker.VNetID, []LBRule{
+		{Name: "api", Protocol: "Tcp", FrontendPort: 6443, BackendPort: apiNodePort, ProbePort: apiNodePort},
+		{Name: "oauth", Protocol: "Tcp", FrontendPort: externalOauthPort, BackendPort: oauthNodePort, ProbePort: oauthNodePort},
+	}, machineIP)
+	if err != nil {
+		return cloudProviderError(err, "cannot create API load balancer")
+	}
+	log.Infof("Created API load balancer %s with backend %s", apiLBName, machineIP)
+
+	apiDNSName := fmt.Sprintf("api.%s.%s", name, parentDomain)
+	if err = azure.EnsureARecord(dnsZoneID, apiDNSName, apiPublicIP); err != nil {
+		return cloudProviderError(err, "cannot create API DNS record")
+	}
+	log.Infof("Created DNS record for API name: %s", apiDNSName)
+
+	routerLBName := generateResourceName(infraName, name, "apps")
+	routerPublicIPID, routerPublicIP, err := azure.EnsurePublicIP(routerLBName)
+	if err != nil {
+		return cloudProviderError(err, "cannot allocate router load balancer public IP")
+	}
+	routerBackendPoolID, err := azure.EnsureLoadBalancer(routerLBName, routerPublicIPID, worker.VNetID, []LBRule{
+		{Name: "http", Protocol: "Tcp", FrontendPort: 80, BackendPort: routerNodePortHTTP, ProbePort: routerNodePortHTTP},
+		{Name: "https", Protocol: "Tcp", FrontendPort: 443, BackendPort: routerNodePortHTTPS, ProbePort: routerNodePortHTTPS},
+	})
+	if err != nil {
+		return cloudProviderError(err, "cannot create router load balancer")
+	}
+	log.Infof("Created router load balancer %s with IP: %s", routerLBName, routerPublicIP)
+
+	routerDNSName := fmt.Sprintf("*.apps.%s.%s", name, parentDomain)
+	if err = azure.EnsureARecord(dnsZoneID, routerDNSName, routerPublicIP); err != nil {
+		return cloudProviderError(err, "cannot create router DNS record")
+	}
+	log.Infof("Created DNS record for router name: %s", routerDNSName)
+
+	vpnLBName := generateResourceName(infraName, name, "vpn")
+	vpnPublicIPID, vpnPublicIP, err := azure.EnsurePublicIP(vpnLBName)
+	if err != nil {
+		return cloudProviderError(err, "cannot allocate VPN load balancer public IP")
+	}
+	_, err = azure.EnsureLoadBalancer(vpnLBName, vpnPublicIPID, worker.VNetID, []LBRule{
+		{Name: "vpn", Protocol: "Udp", FrontendPort: 1194, BackendPort: vpnNodePort, ProbePort: apiNodePort},
+	}, machineIP)
+	if err != nil {
+		return cloudProviderError(err, "cannot create VPN load balancer")
+	}
+	log.Infof("Created VPN load balancer %s with IP: %s", vpnLBName, vpnPublicIP)
+
+	vpnDNSName := fmt.Sprintf("vpn.%s.%s", name, parentDomain)
+	if err = azure.EnsureARecord(dnsZoneID, vpnDNSName, vpnPublicIP); err != nil {
+		return cloudProviderError(err, "cannot create VPN DNS record")
+	}
+	log.Infof("Created DNS record for VPN: %s", vpnDNSName)
+
+	tcpRule, udpRule, priority := generateNodePortRules(infraName, name)
+	if err = azure.EnsureNodePortRule(tcpRule, "Tcp", priority, []int{apiNodePort, oauthNodePort, routerNodePortHTTP, routerNodePortHTTPS}); err != nil {
+		return cloudProviderError(err, "cannot setup network security group for worker nodes")
+	}
+	if err = azure.EnsureNodePortRule(udpRule, "Udp", priority+1, []int{vpnNodePort}); err != nil {
+		return cloudProviderError(err, "cannot setup network security group for worker nodes")
+	}
+	log.Infof("Ensured that node ports on workers are accessible")
+
+	_, serviceCIDRNet, err := net.ParseCIDR(serviceCIDR)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot parse service CIDR %s", serviceCIDR)
+	}
+
+	_, podCIDRNet, err := net.ParseCIDR(podCIDR)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot parse pod CIDR %s", podCIDR)
+	}
+
+	serviceCIDRPrefixLen, _ := serviceCIDRNet.Mask.Size()
+	clusterServiceCIDR, exceedsMax := gocidr.NextSubnet(serviceCIDRNet, serviceCIDRPrefixLen)
+	if exceedsMax {
+		return installerrors.Precondition(nil, "cluster service CIDR exceeds max address space")
+	}
+
+	podCIDRPrefixLen, _ := podCIDRNet.Mask.Size()
+	clusterPodCIDR, exceedsMax := gocidr.NextSubnet(podCIDRNet, podCIDRPrefixLen)
+	if exceedsMax {
+		return installerrors.Precondition(nil, "cluster pod CIDR exceeds max address space")
+	}
+
+	params := api.NewClusterParams()
+	params.Namespace = name
+	params.PodSecurity.SkipPrivilegedSCC = skipPrivilegedSCC
+	params.ExternalAPIDNSName = apiDNSName
+	params.ExternalAPIPort = 6443
+	params.ExternalAPIIPAddress = apiPublicIP
+	params.ExternalOpenVPNDNSName = vpnDNSName
+	params.ExternalOpenVPNPort = 1194
+	params.ExternalOauthPort = externalOauthPort
+	params.APINodePort = uint(apiNodePort)
+	params.ServiceCIDR = clusterServiceCIDR.String()
+	params.PodCIDR = clusterPodCIDR.String()
+	params.ReleaseImage = releaseImage
+	params.IngressSubdomain = fmt.Sprintf("apps.%s.%s", name, parentDomain)
+	params.OpenShiftAPIClusterIP = openshiftClusterIP
+	params.OpenVPNNodePort = fmt.Sprintf("%d", vpnNodePort)
+	params.BaseDomain = fmt.Sprintf("%s.%s", name, parentDomain)
+	// The Azure cloud provider requires a cloud config file, so the control plane
+	// runs without a cloud provider
+	params.CloudProvider = ""
+	params.InternalAPIPort = 6443
+	params.EtcdClientName = "etcd-client"
+	params.NetworkType = api.NetworkTypeOpenShiftSDN
+	params.ImageRegistryHTTPSecret = installer.GenerateImageRegistrySecret()
+	params.RouterNodePortHTTP = fmt.Sprintf("%d", routerNodePortHTTP)
+	params.RouterNodePortHTTPS = fmt.Sprintf("%d", routerNodePortHTTPS)
+	params.RouterServiceType = "NodePort"
+	params.IgnitionVersion = ignitionVersion
+	params.Replicas = "1"
+	params.ControlPlaneOperatorControllers = []string{
+		"controller-manager-ca",
+		"auto-approver",
+		"kubeadmin-password",
+		"cluster-operator",
+		"cluster-version",
+		"kubelet-serving-ca",
+		"openshift-apiserver",
+		"openshift-controller-manager",
+		"cert-rotation",
+		"cluster-status",
+	}
+	cpOperatorImage := os.Getenv("CONTROL_PLANE_OPERATOR_IMAGE_OVERRIDE")
+	if cpOperatorImage == "" {
+		params.ControlPlaneOperatorImage = defaultControlPlaneOperatorImage
+	} else {
+		params.ControlPlaneOperatorImage = cpOperatorImage
+	}
+
+	workingDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return installerrors.Render(err, "cannot create temporary working directory")
+	}
+	log.Infof("The working directory is %s", workingDir)
+	pkiDir := filepath.Join(workingDir, "pki")
+	if err = os.Mkdir(pkiDir, 0755); err != nil {
+		return installerrors.Render(err, "cannot create temporary PKI directory")
+	}
+	log.Info("Generating PKI")
+	if len(dhParamsFile) > 0 {
+		if err = installer.CopyFile(dhParamsFile, filepath.Join(pkiDir, "openvpn-dh.pem")); err != nil {
+			return installerrors.Render(err, "cannot copy dh parameters file %s", dhParamsFile)
+		}
+	}
+	if err := pki.GeneratePKI(params, pkiDir); err != nil {
+		return installerrors.Render(err, "failed to generate PKI assets")
+	}
+	manifestsDir := filepath.Join(workingDir, "manifests")
+	if err = os.Mkdir(manifestsDir, 0755); err != nil {
+		return installerrors.Render(err, "cannot create temporary manifests directory")
+	}
+	pullSecretFile := filepath.Join(workingDir, "pull-secret")
+	if err = ioutil.WriteFile(pullSecretFile, []byte(pullSecret), 0644); err != nil {
+		return installerrors.Render(err, "failed to create temporary pull secret file")
+	}
+	log.Info("Generating ignition for workers")
+	if err = ignition.GenerateIgnition(params, sshKey, pullSecretFile, pkiDir, workingDir); err != nil {
+		return installerrors.Render(err, "cannot generate ignition file for workers")
+	}
+	// Ensure that the storage account with the ignition file in it exists
+	storageAccountName := generateStorageAccountName(infraName, name)
+	log.Infof("Ensuring ignition storage account exists")
+	ignitionURL, err := azure.EnsureIgnitionStorage(storageAccountName, filepath.Join(workingDir, "bootstrap.ign"))
+	if err != nil {
+		return cloudProviderError(err, "failed to ensure ignition storage account exists")
+	}
+
+	log.Info("Rendering Manifests")
+	if err := render.RenderPKISecrets(pkiDir, manifestsDir, true, true, false, true, render.CertRotationEnabled(params), false, params.EtcdEncryption.Provider != "", params.Monitoring.Enabled); err != nil {
+		return installerrors.Render(err, "failed to render PKI secrets")
+	}
+	if err := render.RenderNamedCertsSecret(params, pkiDir, manifestsDir); err != nil {
+		return installerrors.Render(err, "failed to render named certificates secret")
+	}
+	caBytes, err := ioutil.ReadFile(filepath.Join(pkiDir, "combined-ca.crt"))
+	if err != nil {
+		return installerrors.Render(err, "failed to render PKI secrets")
+	}
+	params.OpenshiftAPIServerCABundle = base64.StdEncoding.EncodeToString(caBytes)
+	if err = render.RenderClusterManifests(params, pullSecretFile, manifestsDir, true, true, false, true, true); err != nil {
+		return installerrors.Render(err, "failed to render manifests for cluster")
+	}
+
+	// Create a nodeport service for the router
+	if err = installer.GenerateRouterService(routerNodePortHTTP, routerNodePortHTTPS, filepath.Join(manifestsDir, "router-service.json")); err != nil {
+		return installerrors.Render(err, "failed to generate router service")
+	}
+	kubeadminPassword, err := installer.GenerateKubeadminPassword()
+	if err != nil {
+		return installerrors.Render(err, "failed to generate kubeadmin password")
+	}
+	if err = installer.GenerateKubeadminPasswordTargetSecret(kubeadminPassword, filepath.Join(manifestsDir, "kubeadmin-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create kubeadmin secret manifest for target cluster")
+	}
+	if err = installer.GenerateKubeadminPasswordSecret(kubeadminPassword, filepath.Join(manifestsDir, "kubeadmin-host-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create kubeadmin secret manifest for management cluster")
+	}
+	if err = installer.GenerateKubeconfigSecret(filepath.Join(pkiDir, "admin.kubeconfig"), filepath.Join(manifestsDir, "kubeconfig-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create kubeconfig secret manifest for management cluster")
+	}
+	if err = installer.GenerateTargetPullSecret([]byte(pullSecret), filepath.Join(manifestsDir, "user-pull-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create pull secret manifest for target cluster")
+	}
+
+	// Create the system branding manifest (cannot be applied because it's too large)
+	if err = installer.CreateBrandingSecret(client, name, filepath.Join(manifestsDir, "v4-0-config-system-branding.yaml")); err != nil {
+		return installerrors.Apply(err, "failed to create oauth branding secret")
+	}
+
+	excludedDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return installerrors.Render(err, "failed to create a temporary directory for excluded manifests")
+	}
+	log.Infof("Excluded manifests directory: %s", excludedDir)
+	if err = installer.ApplyManifests(cfg, name, manifestsDir, installer.ExcludeManifests, excludedDir); err != nil {
+		return installerrors.Apply(err, "failed to apply manifests")
+	}
+	log.Infof("Cluster resources applied")
+
+	// Create a scale set for the new cluster's worker nodes
+	scaleSetName := generateScaleSetName(infraName, name, "worker")
+	log.Infof("Creating worker scale set %s", scaleSetName)
+	userData, err := ignition.UserData(ignitionURL, nil, params.IgnitionVersion)
+	if err != nil {
+		return installerrors.Render(err, "cannot generate user data for workers")
+	}
+	err = azure.EnsureScaleSet(scaleSetName, &ScaleSetSpec{
+		Capacity:           workerScaleSetCount,
+		VMSize:             worker.VMSize,
+		ImageID:            worker.ImageID,
+		SubnetID:           worker.SubnetID,
+		BackendPoolID:      routerBackendPoolID,
+		DiskSizeGB:         worker.DiskSizeGB,
+		StorageAccountType: worker.StorageAccountType,
+		SSHKey:             string(sshKey),
+		CustomData:         base64.StdEncoding.EncodeToString(userData),
+	})
+	if err != nil {
+		return cloudProviderError(err, "cannot create worker scale set")
+	}
+
+	if waitForReady {
+		log.Infof("Waiting up to 10 minutes for API endpoint to be available.")
+		if err = installer.WaitForAPIEndpoint(pkiDir, apiDNSName, 6443, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to access API endpoint")
+		}
+		log.Infof("API is available at %s", fmt.Sprintf("https://%s:6443", apiDNSName))
+
+		log.Infof("Waiting up to 5 minutes for bootstrap pod to complete.")
+		if err = installer.WaitForBootstrapPod(client, name, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to wait for bootstrap pod to complete")
+		}
+		log.Infof("Bootstrap pod has completed.")
+
+		targetClusterCfg, err := installer.GetTargetClusterConfig(pkiDir)
+		if err != nil {
+			return installerrors.Precondition(err, "cannot create target cluster client config")
+		}
+		targetClient, err := kubeclient.NewForConfig(targetClusterCfg)
+		if err != nil {
+			return installerrors.Precondition(err, "cannot create target cluster client")
+		}
+
+		log.Infof("Waiting up to 10 minutes for nodes to be ready.")
+		if err = installer.WaitForNodesReady(targetClient, workerScaleSetCount, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to wait for nodes ready")
+		}
+		log.Infof("Nodes (%d) are ready", workerScaleSetCount)
+
+		log.Infof("Waiting up to 15 minutes for cluster operators to be ready.")
+		if err = installer.WaitForClusterOperators(targetClusterCfg, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to wait for cluster operators")
+		}
+	}
+
+	log.Infof("Cluster API URL: %s", fmt.Sprintf("https://%s:6443", apiDNSName))
+	log.Infof("Kubeconfig is available in secret %q in the %s namespace", "admin-kubeconfig", name)
+	log.Infof("Console URL:  %s", fmt.Sprintf("https://console-openshift-console.%s", params.IngressSubdomain))
+	log.Infof("kubeadmin password is available in secret %q in the %s namespace", "kubeadmin-password", name)
+	return nil
+}
+
+func getAzureCredentials(client kubeclient.Interface) (*Credentials, error) {
+	secret, err := client.CoreV1().Secrets("kube-system").Get("azure-credentials", metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	credentials := &Credentials{}
+	for key, value := range map[string]*string{
+		"azure_client_id":       &credentials.ClientID,
+		"azure_client_secret":   &credentials.ClientSecret,
+		"azure_tenant_id":       &credentials.TenantID,
+		"azure_subscription_id": &credentials.SubscriptionID,
+		"azure_resourcegroup":   &credentials.ResourceGroup,
+		"azure_region":          &credentials.Region,
+	} {
+		data, ok := secret.Data[key]
+		if !ok {
+			return nil, fmt.Errorf("did not find %s in the Azure credentials secret", key)
+		}
+		*value = string(data)
+	}
+	return credentials, nil
+}
+
+func getInfrastructureName(client dynamic.Interface) (string, error) {
+	infraGroupVersion, err := schema.ParseGroupVersion("config.openshift.io/v1")
+	if err != nil {
+		return "", err
+	}
+	infraGroupVersionResource := infraGroupVersion.WithResource("infrastructures")
+	obj, err := client.Resource(infraGroupVersionResource).Get("cluster", metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	infraName, exists, err := unstructured.NestedString(obj.Object, "status", "infrastructureName")
+	if !exists || err != nil {
+		return "", fmt.Errorf("could not find the infrastructure name in the infrastructure resource: %v", err)
+	}
+	return infraName, nil
+}
+
+// getWorkerInfo reads the machine configuration of the workers of the management
+// cluster from the provider spec of one of its worker machinesets
+func getWorkerInfo(client dynamic.Interface, infraName, subscriptionID string) (*workerInfo, error) {
+	machineGV, err := schema.ParseGroupVersion("machine.openshift.io/v1beta1")
+	if err != nil {
+		return nil, err
+	}
+	machineSetGVR := machineGV.WithResource("machinesets")
+	list, err := client.Resource(machineSetGVR).Namespace("openshift-machine-api").List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var providerSpec map[string]interface{}
+	for _, ms := range list.Items {
+		if !strings.HasPrefix(ms.GetName(), fmt.Sprintf("%s-worker-", infraName)) {
+			continue
+		}
+		providerSpec, _, err = unstructured.NestedMap(ms.Object, "spec", "template", "spec", "providerSpec", "value")
+		if err != nil {
+			return nil, fmt.Errorf("cannot read provider spec of machineset %s: %v", ms.GetName(), err)
+		}
+		break
+	}
+	if providerSpec == nil {
+		return nil, fmt.Errorf("did not find a worker machineset")
+	}
+	info := &workerInfo{}
+	fields := map[*string][]string{
+		&info.VMSize:             {"vmSize"},
+		&info.ImageID:            {"image", "resourceID"},
+		&info.StorageAccountType: {"osDisk", "managedDisk", "storageAccountType"},
+	}
+	for value, path := range fields {
+		*value, _, err = unstructured.NestedString(providerSpec, path...)
+		if err != nil || *value == "" {
+			return nil, fmt.Errorf("did not find %s in the worker provider spec: %v", strings.Join(path, "."), err)
+		}
+	}
+	info.DiskSizeGB, _, err = unstructured.NestedInt64(providerSpec, "osDisk", "diskSizeGB")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the worker disk size: %v", err)
+	}
+	networkResourceGroup, _, _ := unstructured.NestedString(providerSpec, "networkResourceGroup")
+	vnet, _, _ := unstructured.NestedString(providerSpec, "vnet")
+	subnet, _, _ := unstructured.NestedString(providerSpec, "subnet")
+	if networkResourceGroup == "" || vnet == "" || subnet == "" {
+		return nil, fmt.Errorf("did not find the network of the workers in the worker provider spec")
+	}
+	info.VNetID = fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/virtualNetworks/%s", subscriptionID, networkResourceGroup, vnet)
+	info.SubnetID = fmt.Sprintf("%s/subnets/%s", info.VNetID, subnet)
+	// The image of the machinesets created by the installer is relative to the subscription
+	if strings.HasPrefix(info.ImageID, "/resourceGroups/") {
+		info.ImageID = fmt.Sprintf("/subscriptions/%s%s", subscriptionID, info.ImageID)
+	}
+	return info, nil
+}
+
+func getMachineIP(client dynamic.Interface, machineNames []string, prefix string) (string, error) {
+	name := ""
+	for _, machineName := range machineNames {
+		if strings.HasPrefix(machineName, prefix) {
+			name = machineName
+			break
+		}
+	}
+	if name == "" {
+		return "", fmt.Errorf("did not find machine with prefix %s", prefix)
+	}
+	machineGroupVersion, err := schema.ParseGroupVersion("machine.openshift.io/v1beta1")
+	if err != nil {
+		return "", err
+	}
+	machineGroupVersionResource := machineGroupVersion.WithResource("machines")
+	machine, err := client.Resource(machineGroupVersionResource).Namespace("openshift-machine-api").Get(name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	addresses, exists, err := unstructured.NestedSlice(machine.Object, "status", "addresses")
+	if !exists || err != nil {
+		return "", fmt.Errorf("did not find addresses on machine object: %v", err)
+	}
+	for _, addr := range addresses {
+		addrType, _, err := unstructured.NestedString(addr.(map[string]interface{}), "type")
+		if err != nil {
+			return "", fmt.Errorf("cannot get address type: %v", err)
+		}
+		if addrType != "InternalIP" {
+			continue
+		}
+		machineIP, _, err := unstructured.NestedString(addr.(map[string]interface{}), "address")
+		if err != nil {
+			return "", fmt.Errorf("cannot get machine address: %v", err)
+		}
+		return machineIP, nil
+	}
+	return "", fmt.Errorf("could not find machine internal IP")
+}
+
+func generateResourceName(infraName, clusterName, suffix string) string {
+	return installer.GetName(fmt.Sprintf("%s-%s", infraName, clusterName), suffix, 80)
+}
+
+// generateScaleSetName returns a name that can also be used as the computer name
+// prefix of the scale set virtual machines
+func generateScaleSetName(infraName, clusterName, suffix string) string {
+	return installer.GetName(fmt.Sprintf("%s-%s", infraName, clusterName), suffix, 50)
+}
+
+var nonAlphanumeric = regexp.MustCompile("[^a-z0-9]")
+
+// generateStorageAccountName returns a globally unique storage account name, which
+// can only contain up to 24 lowercase letters and digits
+func generateStorageAccountName(infraName, clusterName string) string {
+	base := nonAlphanumeric.ReplaceAllString(strings.ToLower(infraName+clusterName), "")
+	if len(base) > 13 {
+		base = base[:13]
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(fmt.Sprintf("%s-%s", infraName, clusterName)))
+	return fmt.Sprintf("%s%08xign", base, hash.Sum32())
+}
+
+// generateNodePortRules returns the names of the TCP and UDP security rules that allow
+// access to the node ports of a cluster, and the priority of the first one. Priorities
+// must be unique within a network security group, so they are derived from the names.
+func generateNodePortRules(infraName, clusterName string) (string, string, int) {
+	hash := fnv.New32a()
+	hash.Write([]byte(fmt.Sprintf("%s-%s", infraName, clusterName)))
+	priority := 500 + 2*int(hash.Sum32()%1500)
+	return generateResourceName(infraName, clusterName, "tcp-node-ports"), generateResourceName(infraName, clusterName, "udp-node-ports"), priority
+}
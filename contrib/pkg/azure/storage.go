@@ -0,0 +1,99 @@
+package azure
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const blobAPIVersion = "2019-02-02"
+
+// storageError is an error returned by the blob storage API
+type storageError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *storageError) Error() string {
+	return fmt.Sprintf("blob storage returned %d: %s", e.StatusCode, e.Message)
+}
+
+// uploadBlob uploads the contents of fileName as a block blob, signing the request
+// with the shared key of the storage account. It returns the URL of the blob.
+func uploadBlob(client *http.Client, accountName, accountKey, container, blobName, fileName string) (string, error) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read file %s", fileName)
+	}
+	key, err := base64.StdEncoding.DecodeString(accountKey)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid storage account key")
+	}
+	blobURL := fmt.Sprintf("https://%s.blob.core.windows.net/%s/%s", accountName, container, blobName)
+	req, err := http.NewRequest(http.MethodPut, blobURL, bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", blobAPIVersion)
+	signature := hmac.New(sha256.New, key)
+	signature.Write([]byte(stringToSign(req, accountName, len(content))))
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", accountName, base64.StdEncoding.EncodeToString(signature.Sum(nil))))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return "", &storageError{StatusCode: resp.StatusCode, Message: string(body)}
+	}
+	return blobURL, nil
+}
+
+// stringToSign returns the string signed by the shared key authorization of a blob request
+// without query parameters
+func stringToSign(req *http.Request, accountName string, contentLength int) string {
+	length := ""
+	if contentLength > 0 {
+		length = fmt.Sprintf("%d", contentLength)
+	}
+	msHeaders := []string{}
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower)
+		}
+	}
+	sort.Strings(msHeaders)
+	canonicalHeaders := ""
+	for _, name := range msHeaders {
+		canonicalHeaders += fmt.Sprintf("%s:%s\n", name, req.Header.Get(name))
+	}
+	return strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		length,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, sent as x-ms-date
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		canonicalHeaders + fmt.Sprintf("/%s%s", accountName, req.URL.Path),
+	}, "\n")
+}
@@ -0,0 +1,73 @@
+package azure
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+)
+
+func TestStringToSign(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPut, "https://account.blob.core.windows.net/ignition/worker.ign", bytes.NewReader([]byte("{}")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-ms-version", "2019-02-02")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	req.Header.Set("x-ms-date", "Mon, 02 Jan 2006 15:04:05 GMT")
+
+	expected := "PUT\n" +
+		"\n" + // Content-Encoding
+		"\n" + // Content-Language
+		"2\n" + // Content-Length
+		"\n" + // Content-MD5
+		"application/json\n" +
+		"\n" + // Date
+		"\n" + // If-Modified-Since
+		"\n" + // If-Match
+		"\n" + // If-None-Match
+		"\n" + // If-Unmodified-Since
+		"\n" + // Range
+		"x-ms-blob-type:BlockBlob\n" +
+		"x-ms-date:Mon, 02 Jan 2006 15:04:05 GMT\n" +
+		"x-ms-version:2019-02-02\n" +
+		"/account/ignition/worker.ign"
+	if actual := stringToSign(req, "account", 2); actual != expected {
+		t.Errorf("unexpected string to sign:\n%q\nexpected:\n%q", actual, expected)
+	}
+}
+
+func TestStringToSignEmptyBody(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://account.blob.core.windows.net/ignition/worker.ign", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("x-ms-version", "2019-02-02")
+	expected := "GET\n\n\n\n\n\n\n\n\n\n\n\nx-ms-version:2019-02-02\n/account/ignition/worker.ign"
+	if actual := stringToSign(req, "account", 0); actual != expected {
+		t.Errorf("unexpected string to sign:\n%q\nexpected:\n%q", actual, expected)
+	}
+}
+
+func TestStorageErrorRetryable(t *testing.T) {
+	tests := []struct {
+		status    int
+		retryable bool
+	}{
+		{status: http.StatusTooManyRequests, retryable: true},
+		{status: http.StatusInternalServerError, retryable: true},
+		{status: http.StatusServiceUnavailable, retryable: true},
+		{status: http.StatusForbidden, retryable: false},
+		{status: http.StatusConflict, retryable: false},
+	}
+	for _, test := range tests {
+		err := errors.Wrap(&storageError{StatusCode: test.status, Message: "failed"}, "failed to upload ignition file")
+		if actual := installerrors.IsRetryable(cloudProviderError(err, "cannot create ignition storage")); actual != test.retryable {
+			t.Errorf("status %d: expected retryable %t, got %t", test.status, test.retryable, actual)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package azure
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+func UninstallCluster(name string) error {
+	// First, ensure that we can access the host cluster
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
+	}
+
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+	credentials, err := getAzureCredentials(client)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain Azure credentials from host cluster")
+	}
+
+	infraName, err := getInfrastructureName(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain infrastructure info for cluster")
+	}
+
+	dnsZoneID, parentDomain, err := installer.GetDNSZoneInfo(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain public zone information")
+	}
+
+	azure := NewAzureHelper(credentials, infraName)
+
+	log.Infof("Removing worker scale set")
+	if err = azure.RemoveScaleSet(generateScaleSetName(infraName, name, "worker")); err != nil {
+		return cloudProviderError(err, "cannot delete worker scale set")
+	}
+
+	for _, lb := range []struct {
+		description string
+		suffix      string
+		dnsName     string
+	}{
+		{description: "API", suffix: "api", dnsName: fmt.Sprintf("api.%s.%s", name, parentDomain)},
+		{description: "router", suffix: "apps", dnsName: fmt.Sprintf("*.apps.%s.%s", name, parentDomain)},
+		{description: "VPN", suffix: "vpn", dnsName: fmt.Sprintf("vpn.%s.%s", name, parentDomain)},
+	} {
+		lbName := generateResourceName(infraName, name, lb.suffix)
+
+		log.Infof("Removing %s DNS record", lb.description)
+		if err = azure.RemoveARecord(dnsZoneID, lb.dnsName); err != nil {
+			return cloudProviderError(err, "cannot delete %s DNS record", lb.description)
+		}
+
+		log.Infof("Removing %s load balancer", lb.description)
+		if err = azure.RemoveLoadBalancer(lbName); err != nil {
+			return cloudProviderError(err, "cannot delete %s load balancer", lb.description)
+		}
+
+		log.Infof("Removing %s public IP", lb.description)
+		if err = azure.RemovePublicIP(lbName); err != nil {
+			return cloudProviderError(err, "cannot delete %s public IP", lb.description)
+		}
+	}
+
+	log.Infof("Removing node port security rules")
+	tcpRule, udpRule, _ := generateNodePortRules(infraName, name)
+	for _, rule := range []string{tcpRule, udpRule} {
+		if err = azure.RemoveNodePortRule(rule); err != nil {
+			return cloudProviderError(err, "cannot delete security rule %s", rule)
+		}
+	}
+
+	log.Infof("Removing bootstrap ignition storage account")
+	if err = azure.RemoveIgnitionStorage(generateStorageAccountName(infraName, name)); err != nil {
+		return cloudProviderError(err, "cannot delete ignition storage account")
+	}
+
+	log.Info("Removing cluster namespace")
+	if err = client.CoreV1().Namespaces().Delete(name, &metav1.DeleteOptions{}); err != nil {
+		if !errors.IsNotFound(err) {
+			return installerrors.Apply(err, "failed to delete namespace %s", name)
+		}
+	}
+
+	return nil
+}
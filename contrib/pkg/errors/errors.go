@@ -0,0 +1,199 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Exit codes returned by the installer commands for each class of error
+const (
+	ExitCodeUnknown       = 1
+	ExitCodePrecondition  = 2
+	ExitCodeCloudProvider = 3
+	ExitCodeRender        = 4
+	ExitCodeApply         = 5
+	ExitCodeTimeout       = 6
+)
+
+// wrappedError holds a message and the underlying cause of an error
+type wrappedError struct {
+	msg string
+	err error
+}
+
+func (e *wrappedError) Error() string {
+	if e.err == nil {
+		return e.msg
+	}
+	return fmt.Sprintf("%s: %v", e.msg, e.err)
+}
+
+// Cause returns the underlying error (compatible with github.com/pkg/errors)
+func (e *wrappedError) Cause() error {
+	return e.err
+}
+
+// Unwrap returns the underlying error
+func (e *wrappedError) Unwrap() error {
+	return e.err
+}
+
+// PreconditionError is returned when the environment does not allow the
+// operation to proceed, ie. the management cluster is missing information
+// or the target cluster already exists. It is not retryable.
+type PreconditionError struct {
+	wrappedError
+}
+
+// CloudProviderError is returned when a call to the cloud provider fails.
+type CloudProviderError struct {
+	wrappedError
+
+	// Retryable is true if the cloud provider reported a transient failure
+	Retryable bool
+}
+
+// RenderError is returned when generating PKI, ignition or manifests fails.
+// It is not retryable.
+type RenderError struct {
+	wrappedError
+}
+
+// ApplyError is returned when creating or updating resources on the
+// management cluster fails.
+type ApplyError struct {
+	wrappedError
+}
+
+// TimeoutError is returned when waiting for a resource to become ready
+// does not succeed in the allotted time.
+type TimeoutError struct {
+	wrappedError
+}
+
+// Precondition returns a new PreconditionError
+func Precondition(err error, format string, args ...interface{}) error {
+	return &PreconditionError{wrappedError{msg: fmt.Sprintf(format, args...), err: err}}
+}
+
+// CloudProvider returns a new CloudProviderError
+func CloudProvider(err error, retryable bool, format string, args ...interface{}) error {
+	return &CloudProviderError{wrappedError: wrappedError{msg: fmt.Sprintf(format, args...), err: err}, Retryable: retryable}
+}
+
+// Render returns a new RenderError
+func Render(err error, format string, args ...interface{}) error {
+	return &RenderError{wrappedError{msg: fmt.Sprintf(format, args...), err: err}}
+}
+
+// Apply returns a new ApplyError
+func Apply(err error, format string, args ...interface{}) error {
+	return &ApplyError{wrappedError{msg: fmt.Sprintf(format, args...), err: err}}
+}
+
+// Timeout returns a new TimeoutError
+func Timeout(err error, format string, args ...interface{}) error {
+	return &TimeoutError{wrappedError{msg: fmt.Sprintf(format, args...), err: err}}
+}
+
+// IsRetryable returns true if the first typed error found in the chain of
+// causes of err indicates a transient failure.
+func IsRetryable(err error) bool {
+	switch e := classify(err).(type) {
+	case *CloudProviderError:
+		return e.Retryable
+	case *ApplyError:
+		return isRetryableAPIError(e.err)
+	case *TimeoutError:
+		return true
+	}
+	return false
+}
+
+// isRetryableAPIError returns true if the Kubernetes API server reported a
+// transient failure: a timeout, throttling, a conflict or a server error. An
+// aggregate of errors, as returned when applying several manifests, is retryable
+// if all of its errors are.
+func isRetryableAPIError(err error) bool {
+	err = cause(err)
+	if aggregate, ok := err.(utilerrors.Aggregate); ok {
+		for _, e := range aggregate.Errors() {
+			if !isRetryableAPIError(e) {
+				return false
+			}
+		}
+		return len(aggregate.Errors()) > 0
+	}
+	if apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) || apierrors.IsTooManyRequests(err) ||
+		apierrors.IsConflict(err) || apierrors.IsInternalError(err) || apierrors.IsServiceUnavailable(err) {
+		return true
+	}
+	if status, ok := err.(apierrors.APIStatus); ok {
+		return status.Status().Code >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// ExitCode returns the process exit code that corresponds to the given error
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	switch classify(err).(type) {
+	case *PreconditionError:
+		return ExitCodePrecondition
+	case *CloudProviderError:
+		return ExitCodeCloudProvider
+	case *RenderError:
+		return ExitCodeRender
+	case *ApplyError:
+		return ExitCodeApply
+	case *TimeoutError:
+		return ExitCodeTimeout
+	}
+	return ExitCodeUnknown
+}
+
+// cause walks the chain of causes of err and returns the last one
+func cause(err error) error {
+	for err != nil {
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			if e.Unwrap() == nil {
+				return err
+			}
+			err = e.Unwrap()
+		case interface{ Cause() error }:
+			if e.Cause() == nil {
+				return err
+			}
+			err = e.Cause()
+		default:
+			return err
+		}
+	}
+	return err
+}
+
+// classify walks the chain of causes of err and returns the first error that
+// is one of the types defined in this package, or nil if none is found.
+func classify(err error) error {
+	for err != nil {
+		switch err.(type) {
+		case *PreconditionError, *CloudProviderError, *RenderError, *ApplyError, *TimeoutError:
+			return err
+		}
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		case interface{ Cause() error }:
+			err = e.Cause()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+var configMaps = schema.GroupResource{Resource: "configmaps"}
+
+func TestClassify(t *testing.T) {
+	precondition := Precondition(nil, "cluster exists")
+	cloud := CloudProvider(fmt.Errorf("throttled"), true, "cannot create load balancer")
+	apply := Apply(cloud, "cannot apply")
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{name: "nil", err: nil, expected: nil},
+		{name: "untyped", err: fmt.Errorf("failed"), expected: nil},
+		{name: "typed", err: precondition, expected: precondition},
+		{name: "wrapped with pkg/errors", err: pkgerrors.Wrap(cloud, "install failed"), expected: cloud},
+		{name: "outermost typed error wins", err: apply, expected: apply},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := classify(test.err); actual != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "nil", err: nil, expected: 0},
+		{name: "untyped", err: fmt.Errorf("failed"), expected: ExitCodeUnknown},
+		{name: "precondition", err: Precondition(nil, "cluster exists"), expected: ExitCodePrecondition},
+		{name: "cloud provider", err: CloudProvider(nil, false, "cannot create bucket"), expected: ExitCodeCloudProvider},
+		{name: "render", err: Render(nil, "cannot render"), expected: ExitCodeRender},
+		{name: "apply", err: Apply(nil, "cannot apply"), expected: ExitCodeApply},
+		{name: "timeout", err: Timeout(nil, "timed out"), expected: ExitCodeTimeout},
+		{name: "wrapped", err: pkgerrors.Wrap(Render(nil, "cannot render"), "install failed"), expected: ExitCodeRender},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := ExitCode(test.err); actual != test.expected {
+				t.Errorf("expected exit code %d, got %d", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "untyped", err: fmt.Errorf("failed"), expected: false},
+		{name: "precondition", err: Precondition(nil, "cluster exists"), expected: false},
+		{name: "retryable cloud provider", err: CloudProvider(nil, true, "throttled"), expected: true},
+		{name: "fatal cloud provider", err: CloudProvider(nil, false, "access denied"), expected: false},
+		{name: "render", err: Render(nil, "cannot render"), expected: false},
+		{name: "timeout", err: Timeout(nil, "timed out"), expected: true},
+		{name: "apply server timeout", err: Apply(apierrors.NewServerTimeout(configMaps, "create", 1), "cannot apply"), expected: true},
+		{name: "apply too many requests", err: Apply(apierrors.NewTooManyRequests("slow down", 1), "cannot apply"), expected: true},
+		{name: "apply conflict", err: Apply(apierrors.NewConflict(configMaps, "config", fmt.Errorf("modified")), "cannot apply"), expected: true},
+		{name: "apply internal error", err: Apply(apierrors.NewInternalError(fmt.Errorf("etcd down")), "cannot apply"), expected: true},
+		{name: "apply service unavailable", err: Apply(apierrors.NewServiceUnavailable("unavailable"), "cannot apply"), expected: true},
+		{name: "apply wrapped server error", err: Apply(pkgerrors.Wrap(apierrors.NewInternalError(fmt.Errorf("etcd down")), "create failed"), "cannot apply"), expected: true},
+		{name: "apply forbidden", err: Apply(apierrors.NewForbidden(configMaps, "config", fmt.Errorf("denied")), "cannot apply"), expected: false},
+		{name: "apply invalid", err: Apply(apierrors.NewBadRequest("invalid"), "cannot apply"), expected: false},
+		{name: "apply untyped", err: Apply(fmt.Errorf("failed"), "cannot apply"), expected: false},
+		{name: "apply manifests conflict", err: Apply(pkgerrors.Wrap(utilerrors.NewAggregate([]error{apierrors.NewConflict(configMaps, "config", fmt.Errorf("modified"))}), "failed to apply manifests"), "cannot apply"), expected: true},
+		{name: "apply manifests throttled", err: Apply(pkgerrors.Wrap(utilerrors.NewAggregate([]error{apierrors.NewTooManyRequests("slow down", 1), apierrors.NewInternalError(fmt.Errorf("etcd down"))}), "failed to apply manifests"), "cannot apply"), expected: true},
+		{name: "apply manifests server error", err: Apply(pkgerrors.Wrap(apierrors.NewServiceUnavailable("unavailable"), "failed to apply manifests"), "cannot apply"), expected: true},
+		{name: "apply manifests partly invalid", err: Apply(pkgerrors.Wrap(utilerrors.NewAggregate([]error{apierrors.NewConflict(configMaps, "config", fmt.Errorf("modified")), apierrors.NewBadRequest("invalid")}), "failed to apply manifests"), "cannot apply"), expected: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := IsRetryable(test.err); actual != test.expected {
+				t.Errorf("expected retryable %t, got %t", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package gcp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	computeEndpoint = "https://compute.googleapis.com/compute/v1"
+	dnsEndpoint     = "https://dns.googleapis.com/dns/v1"
+	storageEndpoint = "https://storage.googleapis.com/storage/v1"
+	uploadEndpoint  = "https://storage.googleapis.com/upload/storage/v1"
+
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+	operationTimeout = 10 * time.Minute
+)
+
+// pollInterval is how often the status of a long running operation is checked
+var pollInterval = 3 * time.Second
+
+// ServiceAccount is the service account key the management cluster uses to access GCP
+type ServiceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// apiError is an error returned by a Google Cloud API
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("GCP API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// apiClient is a minimal client of the Google Cloud REST APIs
+type apiClient struct {
+	serviceAccount *ServiceAccount
+	privateKey     *rsa.PrivateKey
+	httpClient     *http.Client
+
+	tokenLock   sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func newAPIClient(serviceAccount *ServiceAccount) (*apiClient, error) {
+	block, _ := pem.Decode([]byte(serviceAccount.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("cannot decode the private key of service account %s", serviceAccount.ClientEmail)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse the private key of service account %s", serviceAccount.ClientEmail)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("the private key of service account %s is not an RSA key", serviceAccount.ClientEmail)
+	}
+	return &apiClient{
+		serviceAccount: serviceAccount,
+		privateKey:     rsaKey,
+		httpClient:     &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// authorize returns an access token for the cloud platform scope, exchanging a
+// JWT signed with the service account key when the current one is about to expire
+func (c *apiClient) authorize() (string, error) {
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+	if c.token != "" && time.Now().Add(time.Minute).Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+	assertion, err := c.signedJWT()
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+	resp, err := c.httpClient.PostForm(c.serviceAccount.TokenURI, form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", &apiError{StatusCode: resp.StatusCode, Message: string(body)}
+	}
+	result := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+	if err = json.Unmarshal(body, &result); err != nil {
+		return "", errors.Wrap(err, "cannot decode token response")
+	}
+	c.token = result.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+func (c *apiClient) signedJWT() (string, error) {
+	now := time.Now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   c.serviceAccount.ClientEmail,
+		"scope": cloudPlatformScope,
+		"aud":   c.serviceAccount.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, c.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", errors.Wrap(err, "cannot sign token request")
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// do sends a JSON request to the given URL and decodes the response into out
+func (c *apiClient) do(method, url string, in, out interface{}) error {
+	var body io.Reader
+	contentType := ""
+	if in != nil {
+		inBytes, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(inBytes)
+		contentType = "application/json"
+	}
+	return c.doRaw(method, url, contentType, body, out)
+}
+
+// doRaw sends a request with the given body to the given URL and decodes the response into out
+func (c *apiClient) doRaw(method, url, contentType string, body io.Reader, out interface{}) error {
+	token, err := c.authorize()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		result := struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}{}
+		json.Unmarshal(respBytes, &result)
+		return &apiError{StatusCode: resp.StatusCode, Message: result.Error.Message}
+	}
+	if out != nil && len(respBytes) > 0 {
+		return json.Unmarshal(respBytes, out)
+	}
+	return nil
+}
+
+// operation is a long running compute operation
+type operation struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	SelfLink string `json:"selfLink"`
+	Error    *struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"error"`
+}
+
+// doOperation sends a compute request that starts an operation and waits for the operation to complete
+func (c *apiClient) doOperation(method, url string, in interface{}) error {
+	op := &operation{}
+	if err := c.do(method, url, in, op); err != nil {
+		return errors.Wrapf(err, "failed to start operation %s %s", method, url)
+	}
+	err := wait.PollImmediate(pollInterval, operationTimeout, func() (bool, error) {
+		if op.Status == "DONE" {
+			if op.Error != nil && len(op.Error.Errors) > 0 {
+				return false, errors.Errorf("operation %s failed: %s: %s", op.Name, op.Error.Errors[0].Code, op.Error.Errors[0].Message)
+			}
+			return true, nil
+		}
+		selfLink := op.SelfLink
+		op = &operation{SelfLink: selfLink}
+		if err := c.do(http.MethodGet, selfLink, nil, op); err != nil {
+			return false, errors.Wrapf(err, "failed to get operation %s", selfLink)
+		}
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return errors.Wrapf(err, "timed out waiting for operation %s %s", method, url)
+	}
+	return err
+}
+
+func isNotFound(err error) bool {
+	if apiErr, ok := errors.Cause(err).(*apiError); ok {
+		return apiErr.StatusCode == http.StatusNotFound
+	}
+	return false
+}
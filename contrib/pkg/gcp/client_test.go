@@ -0,0 +1,228 @@
+package gcp
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+)
+
+// fakeGCP is a fake of the token and compute operation endpoints. Each GET of the
+// operation returns the next status in states.
+type fakeGCP struct {
+	sync.Mutex
+	key         *rsa.PrivateKey
+	states      []string
+	opError     string
+	tokens      int
+	claims      map[string]interface{}
+	errorStatus int
+}
+
+func (f *fakeGCP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	if r.URL.Path == "/token" {
+		f.tokens++
+		if err := f.verifyAssertion(r.PostFormValue("assertion")); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"error": "%v"}`, err)
+			return
+		}
+		fmt.Fprint(w, `{"access_token": "token", "expires_in": 3600}`)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if f.errorStatus != 0 {
+		w.WriteHeader(f.errorStatus)
+		fmt.Fprint(w, `{"error": {"code": 400, "message": "some message"}}`)
+		return
+	}
+	status := f.states[0]
+	if len(f.states) > 1 {
+		f.states = f.states[1:]
+	}
+	op := map[string]interface{}{
+		"name":     "operation-1",
+		"status":   status,
+		"selfLink": fmt.Sprintf("http://%s/operations/operation-1", r.Host),
+	}
+	if status == "DONE" && f.opError != "" {
+		op["error"] = map[string]interface{}{
+			"errors": []map[string]string{{"code": f.opError, "message": "some message"}},
+		}
+	}
+	json.NewEncoder(w).Encode(op)
+}
+
+// verifyAssertion verifies the signature of the JWT sent to the token endpoint
+func (f *fakeGCP) verifyAssertion(assertion string) error {
+	parts := strings.Split(assertion, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("expected 3 parts in JWT, got %d", len(parts))
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&f.key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		return err
+	}
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(claims, &f.claims)
+}
+
+func newTestClient(t *testing.T, fake *fakeGCP) (*apiClient, *httptest.Server) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate key: %v", err)
+	}
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("cannot marshal key: %v", err)
+	}
+	fake.key = key
+	server := httptest.NewServer(fake)
+	client, err := newAPIClient(&ServiceAccount{
+		ProjectID:   "project",
+		ClientEmail: "installer@project.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes})),
+		TokenURI:    server.URL + "/token",
+	})
+	if err != nil {
+		server.Close()
+		t.Fatalf("cannot create client: %v", err)
+	}
+	return client, server
+}
+
+func TestAuthorize(t *testing.T) {
+	fake := &fakeGCP{states: []string{"DONE"}}
+	client, server := newTestClient(t, fake)
+	defer server.Close()
+	for i := 0; i < 2; i++ {
+		if err := client.do(http.MethodGet, server.URL+"/operations/operation-1", nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if fake.tokens != 1 {
+		t.Errorf("expected the token to be requested once, got %d", fake.tokens)
+	}
+	if fake.claims["iss"] != "installer@project.iam.gserviceaccount.com" || fake.claims["scope"] != cloudPlatformScope || fake.claims["aud"] != server.URL+"/token" {
+		t.Errorf("unexpected JWT claims: %v", fake.claims)
+	}
+}
+
+func TestNewAPIClientInvalidKey(t *testing.T) {
+	if _, err := newAPIClient(&ServiceAccount{PrivateKey: "not a key"}); err == nil {
+		t.Errorf("expected an error for an invalid private key")
+	}
+}
+
+func TestDoOperation(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+	tests := []struct {
+		name        string
+		states      []string
+		opError     string
+		expectError bool
+	}{
+		{name: "done immediately", states: []string{"DONE"}},
+		{name: "done after polling", states: []string{"PENDING", "RUNNING", "DONE"}},
+		{name: "failed", states: []string{"RUNNING", "DONE"}, opError: "QUOTA_EXCEEDED", expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := &fakeGCP{states: test.states, opError: test.opError}
+			client, server := newTestClient(t, fake)
+			defer server.Close()
+			err := client.doOperation(http.MethodPost, server.URL+"/addresses", map[string]string{"name": "api"})
+			if test.expectError != (err != nil) {
+				t.Fatalf("expected error %t, got %v", test.expectError, err)
+			}
+			if len(fake.states) != 1 {
+				t.Errorf("expected all states to be polled, %d remaining", len(fake.states))
+			}
+		})
+	}
+}
+
+func TestErrorDecoding(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		retryable bool
+		notFound  bool
+	}{
+		{name: "throttled", status: http.StatusTooManyRequests, retryable: true},
+		{name: "server error", status: http.StatusServiceUnavailable, retryable: true},
+		{name: "bad request", status: http.StatusBadRequest},
+		{name: "not found", status: http.StatusNotFound, notFound: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, server := newTestClient(t, &fakeGCP{errorStatus: test.status})
+			defer server.Close()
+			err := client.doOperation(http.MethodPost, server.URL+"/addresses", nil)
+			apiErr, ok := errors.Cause(err).(*apiError)
+			if !ok {
+				t.Fatalf("expected an apiError, got %#v", err)
+			}
+			if apiErr.StatusCode != test.status || apiErr.Message != "some message" {
+				t.Errorf("unexpected error decoded: %#v", apiErr)
+			}
+			if actual := installerrors.IsRetryable(cloudProviderError(err, "cannot create API address")); actual != test.retryable {
+				t.Errorf("expected retryable %t, got %t", test.retryable, actual)
+			}
+			if actual := isNotFound(err); actual != test.notFound {
+				t.Errorf("expected not found %t, got %t", test.notFound, actual)
+			}
+		})
+	}
+}
+
+func TestCloudProviderErrorRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "throttled", err: &apiError{StatusCode: http.StatusTooManyRequests}, expected: true},
+		{name: "wrapped server error", err: errors.Wrap(&apiError{StatusCode: http.StatusBadGateway}, "failed to create bucket"), expected: true},
+		{name: "wrapped network error", err: errors.Wrap(&net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}, "failed to upload ignition file"), expected: true},
+		{name: "forbidden", err: errors.Wrap(&apiError{StatusCode: http.StatusForbidden}, "failed to create bucket"), expected: false},
+		{name: "untyped", err: fmt.Errorf("failed"), expected: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := cloudProviderError(test.err, "cannot create ignition bucket")
+			if actual := installerrors.IsRetryable(err); actual != test.expected {
+				t.Errorf("expected retryable %t, got %t", test.expected, actual)
+			}
+		})
+	}
+}
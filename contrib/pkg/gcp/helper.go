@@ -0,0 +1,304 @@
+package gcp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+)
+
+type GCPHelper struct {
+	client    *apiClient
+	project   string
+	region    string
+	infraName string
+}
+
+// NewGCPHelper creates an instance of the GCP helper for the project and region
+// of the management cluster
+func NewGCPHelper(serviceAccount *ServiceAccount, project, region, infraName string) (*GCPHelper, error) {
+	client, err := newAPIClient(serviceAccount)
+	if err != nil {
+		return nil, err
+	}
+	return &GCPHelper{
+		client:    client,
+		project:   project,
+		region:    region,
+		infraName: infraName,
+	}, nil
+}
+
+// EnsureAddress ensures that a regional external address with the given name is reserved
+// and returns the address
+func (h *GCPHelper) EnsureAddress(name string) (string, error) {
+	address := struct {
+		Address string `json:"address"`
+	}{}
+	err := h.client.do(http.MethodGet, h.regionURL("addresses", name), nil, &address)
+	if isNotFound(err) {
+		err = h.client.doOperation(http.MethodPost, h.regionURL("addresses"), map[string]interface{}{
+			"name":        name,
+			"description": h.description(),
+		})
+		if err != nil {
+			return "", err
+		}
+		err = h.client.do(http.MethodGet, h.regionURL("addresses", name), nil, &address)
+	}
+	if err != nil {
+		return "", err
+	}
+	return address.Address, nil
+}
+
+// RemoveAddress releases a reserved address
+func (h *GCPHelper) RemoveAddress(name string) error {
+	return h.remove(h.regionURL("addresses", name))
+}
+
+// EnsureTargetPool ensures that a target pool with the given name exists and that it
+// contains the given instances. It returns the URL of the target pool.
+func (h *GCPHelper) EnsureTargetPool(name string, instances ...string) (string, error) {
+	poolURL := h.regionURL("targetPools", name)
+	pool := struct {
+		Instances []string `json:"instances"`
+	}{}
+	err := h.client.do(http.MethodGet, poolURL, nil, &pool)
+	if isNotFound(err) {
+		err = h.client.doOperation(http.MethodPost, h.regionURL("targetPools"), map[string]interface{}{
+			"name":        name,
+			"description": h.description(),
+			"instances":   instances,
+		})
+		return poolURL, err
+	}
+	if err != nil {
+		return "", err
+	}
+	missing := []interface{}{}
+	for _, instance := range instances {
+		found := false
+		for _, existing := range pool.Instances {
+			if strings.HasSuffix(existing, strings.TrimPrefix(instance, computeEndpoint)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, map[string]interface{}{"instance": instance})
+		}
+	}
+	if len(missing) > 0 {
+		err = h.client.doOperation(http.MethodPost, poolURL+"/addInstance", map[string]interface{}{"instances": missing})
+	}
+	return poolURL, err
+}
+
+// RemoveTargetPool removes a target pool
+func (h *GCPHelper) RemoveTargetPool(name string) error {
+	return h.remove(h.regionURL("targetPools", name))
+}
+
+// EnsureForwardingRule ensures that a forwarding rule with the given name forwards traffic
+// for a port of an address to a target pool
+func (h *GCPHelper) EnsureForwardingRule(name, address, protocol string, port int, targetPoolURL string) error {
+	err := h.client.do(http.MethodGet, h.regionURL("forwardingRules", name), nil, nil)
+	if !isNotFound(err) {
+		return err
+	}
+	return h.client.doOperation(http.MethodPost, h.regionURL("forwardingRules"), map[string]interface{}{
+		"name":                name,
+		"description":         h.description(),
+		"IPAddress":           address,
+		"IPProtocol":          protocol,
+		"portRange":           fmt.Sprintf("%d", port),
+		"target":              targetPoolURL,
+		"loadBalancingScheme": "EXTERNAL",
+	})
+}
+
+// RemoveForwardingRule removes a forwarding rule
+func (h *GCPHelper) RemoveForwardingRule(name string) error {
+	return h.remove(h.regionURL("forwardingRules", name))
+}
+
+// EnsureWorkersAllowNodePortAccess ensures that a firewall rule allows traffic from the
+// load balancers to the node ports of the management cluster workers and to the router
+// of the new cluster workers, which are tagged like the management cluster workers
+func (h *GCPHelper) EnsureWorkersAllowNodePortAccess(network string, workerTags []string) error {
+	name := fmt.Sprintf("%s-hypershift-node-ports", h.infraName)
+	firewallURL := fmt.Sprintf("%s/projects/%s/global/firewalls/%s", computeEndpoint, h.project, name)
+	err := h.client.do(http.MethodGet, firewallURL, nil, nil)
+	if !isNotFound(err) {
+		return err
+	}
+	return h.client.doOperation(http.MethodPost, fmt.Sprintf("%s/projects/%s/global/firewalls", computeEndpoint, h.project), map[string]interface{}{
+		"name":         name,
+		"description":  h.description(),
+		"network":      fmt.Sprintf("projects/%s/global/networks/%s", h.project, network),
+		"direction":    "INGRESS",
+		"sourceRanges": []string{"0.0.0.0/0"},
+		"targetTags":   workerTags,
+		"allowed": []interface{}{
+			map[string]interface{}{"IPProtocol": "tcp", "ports": []string{"80", "443", "30000-32767"}},
+			map[string]interface{}{"IPProtocol": "udp", "ports": []string{"30000-32767"}},
+		},
+	})
+}
+
+// EnsureARecord ensures that an A record for dnsName in the given managed zone points to address
+func (h *GCPHelper) EnsureARecord(zone, dnsName, address string) error {
+	existing, err := h.getARecord(zone, dnsName)
+	if err != nil {
+		return err
+	}
+	record := aRecord(dnsName, address)
+	change := map[string]interface{}{"additions": []interface{}{record}}
+	if existing != nil {
+		if len(existing.Rrdatas) == 1 && existing.Rrdatas[0] == address {
+			return nil
+		}
+		change["deletions"] = []interface{}{existing}
+	}
+	return h.client.do(http.MethodPost, fmt.Sprintf("%s/projects/%s/managedZones/%s/changes", dnsEndpoint, h.project, zone), change, nil)
+}
+
+// RemoveARecord removes the A record for dnsName in the given managed zone
+func (h *GCPHelper) RemoveARecord(zone, dnsName string) error {
+	existing, err := h.getARecord(zone, dnsName)
+	if err != nil || existing == nil {
+		return err
+	}
+	change := map[string]interface{}{"deletions": []interface{}{existing}}
+	return h.client.do(http.MethodPost, fmt.Sprintf("%s/projects/%s/managedZones/%s/changes", dnsEndpoint, h.project, zone), change, nil)
+}
+
+type resourceRecordSet struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	TTL     int      `json:"ttl"`
+	Rrdatas []string `json:"rrdatas"`
+}
+
+func aRecord(dnsName, address string) *resourceRecordSet {
+	return &resourceRecordSet{
+		Name:    strings.TrimSuffix(dnsName, ".") + ".",
+		Type:    "A",
+		TTL:     300,
+		Rrdatas: []string{address},
+	}
+}
+
+func (h *GCPHelper) getARecord(zone, dnsName string) (*resourceRecordSet, error) {
+	query := url.Values{}
+	query.Set("name", strings.TrimSuffix(dnsName, ".")+".")
+	query.Set("type", "A")
+	result := struct {
+		Rrsets []*resourceRecordSet `json:"rrsets"`
+	}{}
+	err := h.client.do(http.MethodGet, fmt.Sprintf("%s/projects/%s/managedZones/%s/rrsets?%s", dnsEndpoint, h.project, zone, query.Encode()), nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Rrsets) == 0 {
+		return nil, nil
+	}
+	return result.Rrsets[0], nil
+}
+
+// EnsureIgnitionBucket ensures that a bucket with the given name exists and that it contains
+// a publicly readable object with the contents of the ignition filename passed.
+func (h *GCPHelper) EnsureIgnitionBucket(name, fileName string) error {
+	err := h.client.do(http.MethodGet, fmt.Sprintf("%s/b/%s", storageEndpoint, name), nil, nil)
+	if isNotFound(err) {
+		err = h.client.do(http.MethodPost, fmt.Sprintf("%s/b?project=%s", storageEndpoint, h.project), map[string]interface{}{
+			"name":     name,
+			"location": h.region,
+			"labels":   map[string]string{fmt.Sprintf("kubernetes-io-cluster-%s", h.infraName): "owned"},
+		}, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create bucket %s", name)
+		}
+	} else if err != nil {
+		return err
+	}
+	ign, err := os.Open(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "cannot open ignition file %s", fileName)
+	}
+	defer ign.Close()
+	err = h.client.doRaw(http.MethodPost, fmt.Sprintf("%s/b/%s/o?uploadType=media&name=worker.ign&predefinedAcl=publicRead", uploadEndpoint, name), "application/json", ign, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to upload ignition file")
+	}
+	return nil
+}
+
+// RemoveIgnitionBucket removes the ignition bucket and its objects
+func (h *GCPHelper) RemoveIgnitionBucket(name string) error {
+	bucketURL := fmt.Sprintf("%s/b/%s", storageEndpoint, name)
+	objects := struct {
+		Items []struct {
+			Name string `json:"name"`
+		} `json:"items"`
+	}{}
+	err := h.client.do(http.MethodGet, bucketURL+"/o", nil, &objects)
+	if isNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects.Items {
+		if err = h.client.do(http.MethodDelete, fmt.Sprintf("%s/o/%s", bucketURL, url.PathEscape(obj.Name)), nil, nil); err != nil && !isNotFound(err) {
+			return err
+		}
+	}
+	err = h.client.do(http.MethodDelete, bucketURL, nil, nil)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// InstanceURL returns the URL of an instance in the project of the management cluster
+func (h *GCPHelper) InstanceURL(zone, name string) string {
+	return fmt.Sprintf("%s/projects/%s/zones/%s/instances/%s", computeEndpoint, h.project, zone, name)
+}
+
+func (h *GCPHelper) regionURL(parts ...string) string {
+	return fmt.Sprintf("%s/projects/%s/regions/%s/%s", computeEndpoint, h.project, h.region, strings.Join(parts, "/"))
+}
+
+// remove deletes a compute resource if it exists
+func (h *GCPHelper) remove(resourceURL string) error {
+	err := h.client.doOperation(http.MethodDelete, resourceURL, nil)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (h *GCPHelper) description() string {
+	return fmt.Sprintf("Created by hypershift for the %s cluster", h.infraName)
+}
+
+// cloudProviderError wraps an error returned by a GCP API, flagging it as
+// retryable if it is a throttling, server or network failure.
+func cloudProviderError(err error, format string, args ...interface{}) error {
+	retryable := false
+	switch e := errors.Cause(err).(type) {
+	case *apiError:
+		retryable = e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+	case net.Error:
+		retryable = true
+	}
+	return installerrors.CloudProvider(err, retryable, format, args...)
+}
@@ -0,0 +1,605 @@
+package gcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gocidr "github.com/apparentlymart/go-cidr/cidr"
+	log "github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/ignition"
+	"github.com/openshift/hypershift-toolkit/pkg/pki"
+	"github.com/openshift/hypershift-toolkit/pkg/render"
+)
+
+const (
+	workerMachineSetCount = 3
+
+	defaultControlPlaneOperatorImage = "registry.svc.ci.openshift.org/hypershift-toolkit/hypershift-4.4:control-plane-operator"
+)
+
+// InstallCluster installs a new cluster on a management cluster running on GCP.
+// GCP network load balancers do not translate ports, so the API, OAuth and VPN
+// endpoints of the new cluster are exposed on the node ports of their services.
+func InstallCluster(name, releaseImage, dhParamsFile string, waitForReady, skipPrivilegedSCC bool) error {
+
+	// First, ensure that we can access the host cluster
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
+	}
+	// Extract config information from management cluster
+	sshKey, err := installer.GetSSHPublicKey(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to fetch an SSH public key from existing cluster")
+	}
+	log.Debugf("The SSH public key is: %s", string(sshKey))
+
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+	serviceAccount, err := getGCPServiceAccount(client)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain GCP credentials from host cluster")
+	}
+	log.Debugf("GCP service account: %s", serviceAccount.ClientEmail)
+
+	if releaseImage == "" {
+		releaseImage, err = installer.GetReleaseImage(dynamicClient)
+		if err != nil {
+			return installerrors.Precondition(err, "failed to obtain release image from host cluster")
+		}
+	}
+
+	pullSecret, err := installer.GetPullSecret(client)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a pull secret from cluster")
+	}
+	log.Debugf("The pull secret is: %v", pullSecret)
+
+	ignitionVersion, err := installer.GetIgnitionVersion(client)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain the ignition version of the management cluster workers")
+	}
+	log.Debugf("The ignition version of the workers is: %s", ignitionVersion)
+
+	infraName, project, region, err := getInfrastructureInfo(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain infrastructure info for cluster")
+	}
+	log.Debugf("The management cluster infra name is: %s", infraName)
+	log.Debugf("The management cluster GCP project is: %s, region: %s", project, region)
+
+	serviceCIDR, podCIDR, err := installer.GetNetworkInfo(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain network info for cluster")
+	}
+
+	dnsZone, parentDomain, err := installer.GetDNSZoneInfo(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain public zone information")
+	}
+	log.Debugf("Using public DNS Zone: %s and parent suffix: %s", dnsZone, parentDomain)
+
+	machineNames, err := installer.GetMachineNames(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to fetch machine names for cluster")
+	}
+
+	machineName, machineZone, err := getMachineInfo(dynamicClient, machineNames, fmt.Sprintf("%s-worker-", infraName))
+	if err != nil {
+		return installerrors.Precondition(err, "cannot get machine info")
+	}
+	log.Infof("Using management machine %s in zone %s", machineName, machineZone)
+
+	workerMachineSet, err := getWorkerMachineSet(dynamicClient, infraName)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot get worker machineset")
+	}
+	network, workerTags, err := getWorkerNetworkInfo(workerMachineSet)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot get worker network info")
+	}
+
+	// Start creating resources on management cluster
+	_, err = client.CoreV1().Namespaces().Get(name, metav1.GetOptions{})
+	if err == nil {
+		return installerrors.Precondition(nil, "target namespace %s already exists on management cluster", name)
+	}
+	if !errors.IsNotFound(err) {
+		return installerrors.Precondition(err, "unexpected error getting namespaces from management cluster")
+	}
+	log.Infof("Creating namespace %s", name)
+	ns := &corev1.Namespace{}
+	ns.Name = name
+	_, err = client.CoreV1().Namespaces().Create(ns)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create namespace %s", name)
+	}
+
+	// Ensure that the VPN pods can run privileged
+	if err = installer.EnsureControlPlaneSCC(dynamicClient, name, skipPrivilegedSCC); err != nil {
+		return installerrors.Apply(err, "failed to ensure privileged SCC for the new namespace")
+	}
+
+	// Create pull secret
+	log.Infof("Creating pull secret")
+	if err := installer.CreatePullSecret(client, name, pullSecret); err != nil {
+		return installerrors.Apply(err, "failed to create pull secret")
+	}
+
+	// Create Kube APIServer service
+	log.Infof("Creating Kube API service")
+	apiNodePort, err := installer.CreateKubeAPIServerService(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create kube apiserver service")
+	}
+	log.Infof("Created Kube API service with NodePort %d", apiNodePort)
+
+	log.Infof("Creating VPN service")
+	vpnNodePort, err := installer.CreateVPNServerService(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create vpn server service")
+	}
+	log.Infof("Created VPN service with NodePort %d", vpnNodePort)
+
+	log.Infof("Creating Openshift API service")
+	openshiftClusterIP, err := installer.CreateOpenshiftService(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create openshift server service")
+	}
+	log.Infof("Created Openshift API service with cluster IP: %s", openshiftClusterIP)
+
+	oauthNodePort, err := installer.CreateOauthService(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create Oauth server service")
+	}
+	log.Infof("Created Oauth server service with NodePort: %d", oauthNodePort)
+
+	gcp, err := NewGCPHelper(serviceAccount, project, region, infraName)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot create a GCP client")
+	}
+
+	apiLBName := generateResourceName(infraName, name, "api")
+	apiAddress, err := gcp.EnsureAddress(apiLBName)
+	if err != nil {
+		return cloudProviderError(err, "cannot reserve API load balancer address")
+	}
+	log.Infof("Reserved API address: %s", apiAddress)
+
+	apiPoolURL, err := gcp.EnsureTargetPool(apiLBName, gcp.InstanceURL(machineZone, machineName))
+	if err != nil {
+		return cloudProviderError(err, "cannot create API target pool")
+	}
+	log.Infof("Created API target pool with instance %s", machineName)
+
+	if err = gcp.EnsureForwardingRule(apiLBName, apiAddress, "TCP", apiNodePort, apiPoolURL); err != nil {
+		return cloudProviderError(err, "cannot create API forwarding rule")
+	}
+	log.Infof("Created API forwarding rule")
+
+	oauthRuleName := generateResourceName(infraName, name, "oauth")
+	if err = gcp.EnsureForwardingRule(oauthRuleName, apiAddress, "TCP", oauthNodePort, apiPoolURL); err != nil {
+		return cloudProviderError(err, "cannot create OAuth forwarding rule")
+	}
+	log.Infof("Created OAuth forwarding rule")
+
+	apiDNSName := fmt.Sprintf("api.%s.%s", name, parentDomain)
+	if err = gcp.EnsureARecord(dnsZone, apiDNSName, apiAddress); err != nil {
+		return cloudProviderError(err, "cannot create API DNS record")
+	}
+	log.Infof("Created DNS record for API name: %s", apiDNSName)
+
+	routerLBName := generateResourceName(infraName, name, "apps")
+	routerAddress, err := gcp.EnsureAddress(routerLBName)
+	if err != nil {
+		return cloudProviderError(err, "cannot reserve router load balancer address")
+	}
+	// Workers of the new cluster are added to the router target pool by the machine API
+	routerPoolURL, err := gcp.EnsureTargetPool(routerLBName)
+	if err != nil {
+		return cloudProviderError(err, "cannot create router target pool")
+	}
+	routerHTTPRuleName := generateResourceName(infraName, name, "http")
+	if err = gcp.EnsureForwardingRule(routerHTTPRuleName, routerAddress, "TCP", 80, routerPoolURL); err != nil {
+		return cloudProviderError(err, "cannot create router HTTP forwarding rule")
+	}
+	routerHTTPSRuleName := generateResourceName(infraName, name, "https")
+	if err = gcp.EnsureForwardingRule(routerHTTPSRuleName, routerAddress, "TCP", 443, routerPoolURL); err != nil {
+		return cloudProviderError(err, "cannot create router HTTPS forwarding rule")
+	}
+	log.Infof("Created router load balancer with address: %s", routerAddress)
+
+	routerDNSName := fmt.Sprintf("*.apps.%s.%s", name, parentDomain)
+	if err = gcp.EnsureARecord(dnsZone, routerDNSName, routerAddress); err != nil {
+		return cloudProviderError(err, "cannot create router DNS record")
+	}
+	log.Infof("Created DNS record for router name: %s", routerDNSName)
+
+	vpnLBName := generateResourceName(infraName, name, "vpn")
+	vpnAddress, err := gcp.EnsureAddress(vpnLBName)
+	if err != nil {
+		return cloudProviderError(err, "cannot reserve VPN load balancer address")
+	}
+	if err = gcp.EnsureForwardingRule(vpnLBName, vpnAddress, "UDP", vpnNodePort, apiPoolURL); err != nil {
+		return cloudProviderError(err, "cannot create VPN forwarding rule")
+	}
+	log.Infof("Created VPN load balancer with address: %s", vpnAddress)
+
+	vpnDNSName := fmt.Sprintf("vpn.%s.%s", name, parentDomain)
+	if err = gcp.EnsureARecord(dnsZone, vpnDNSName, vpnAddress); err != nil {
+		return cloudProviderError(err, "cannot create VPN DNS record")
+	}
+	log.Infof("Created DNS record for VPN: %s", vpnDNSName)
+
+	if err = gcp.EnsureWorkersAllowNodePortAccess(network, workerTags); err != nil {
+		return cloudProviderError(err, "cannot setup firewall for worker nodes")
+	}
+	log.Infof("Ensured that node ports on workers are accessible")
+
+	_, serviceCIDRNet, err := net.ParseCIDR(serviceCIDR)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot parse service CIDR %s", serviceCIDR)
+	}
+
+	_, podCIDRNet, err := net.ParseCIDR(podCIDR)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot parse pod CIDR %s", podCIDR)
+	}
+
+	serviceCIDRPrefixLen, _ := serviceCIDRNet.Mask.Size()
+	clusterServiceCIDR, exceedsMax := gocidr.NextSubnet(serviceCIDRNet, serviceCIDRPrefixLen)
+	if exceedsMax {
+		return installerrors.Precondition(nil, "cluster service CIDR exceeds max address space")
+	}
+
+	podCIDRPrefixLen, _ := podCIDRNet.Mask.Size()
+	clusterPodCIDR, exceedsMax := gocidr.NextSubnet(podCIDRNet, podCIDRPrefixLen)
+	if exceedsMax {
+		return installerrors.Precondition(nil, "cluster pod CIDR exceeds max address space")
+	}
+
+	params := api.NewClusterParams()
+	params.Namespace = name
+	params.PodSecurity.SkipPrivilegedSCC = skipPrivilegedSCC
+	params.ExternalAPIDNSName = apiDNSName
+	params.ExternalAPIPort = uint(apiNodePort)
+	params.ExternalAPIIPAddress = apiAddress
+	params.ExternalOpenVPNDNSName = vpnDNSName
+	params.ExternalOpenVPNPort = uint(vpnNodePort)
+	params.ExternalOauthPort = uint(oauthNodePort)
+	params.APINodePort = uint(apiNodePort)
+	params.ServiceCIDR = clusterServiceCIDR.String()
+	params.PodCIDR = clusterPodCIDR.String()
+	params.ReleaseImage = releaseImage
+	params.IngressSubdomain = fmt.Sprintf("apps.%s.%s", name, parentDomain)
+	params.OpenShiftAPIClusterIP = openshiftClusterIP
+	params.OpenVPNNodePort = fmt.Sprintf("%d", vpnNodePort)
+	params.BaseDomain = fmt.Sprintf("%s.%s", name, parentDomain)
+	params.InternalAPIPort = 6443
+	params.EtcdClientName = "etcd-client"
+	params.NetworkType = api.NetworkTypeOpenShiftSDN
+	params.ImageRegistryHTTPSecret = installer.GenerateImageRegistrySecret()
+	params.IgnitionVersion = ignitionVersion
+	params.Replicas = "1"
+	params.ControlPlaneOperatorControllers = []string{
+		"controller-manager-ca",
+		"auto-approver",
+		"kubeadmin-password",
+		"cluster-operator",
+		"cluster-version",
+		"kubelet-serving-ca",
+		"openshift-apiserver",
+		"openshift-controller-manager",
+		"cert-rotation",
+		"cluster-status",
+	}
+	cpOperatorImage := os.Getenv("CONTROL_PLANE_OPERATOR_IMAGE_OVERRIDE")
+	if cpOperatorImage == "" {
+		params.ControlPlaneOperatorImage = defaultControlPlaneOperatorImage
+	} else {
+		params.ControlPlaneOperatorImage = cpOperatorImage
+	}
+
+	workingDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return installerrors.Render(err, "cannot create temporary working directory")
+	}
+	log.Infof("The working directory is %s", workingDir)
+	pkiDir := filepath.Join(workingDir, "pki")
+	if err = os.Mkdir(pkiDir, 0755); err != nil {
+		return installerrors.Render(err, "cannot create temporary PKI directory")
+	}
+	log.Info("Generating PKI")
+	if len(dhParamsFile) > 0 {
+		if err = installer.CopyFile(dhParamsFile, filepath.Join(pkiDir, "openvpn-dh.pem")); err != nil {
+			return installerrors.Render(err, "cannot copy dh parameters file %s", dhParamsFile)
+		}
+	}
+	if err := pki.GeneratePKI(params, pkiDir); err != nil {
+		return installerrors.Render(err, "failed to generate PKI assets")
+	}
+	manifestsDir := filepath.Join(workingDir, "manifests")
+	if err = os.Mkdir(manifestsDir, 0755); err != nil {
+		return installerrors.Render(err, "cannot create temporary manifests directory")
+	}
+	pullSecretFile := filepath.Join(workingDir, "pull-secret")
+	if err = ioutil.WriteFile(pullSecretFile, []byte(pullSecret), 0644); err != nil {
+		return installerrors.Render(err, "failed to create temporary pull secret file")
+	}
+	log.Info("Generating ignition for workers")
+	if err = ignition.GenerateIgnition(params, sshKey, pullSecretFile, pkiDir, workingDir); err != nil {
+		return installerrors.Render(err, "cannot generate ignition file for workers")
+	}
+	// Ensure that GCS bucket with ignition file in it exists
+	bucketName := generateBucketName(infraName, name, "ign")
+	log.Infof("Ensuring ignition bucket exists")
+	if err = gcp.EnsureIgnitionBucket(bucketName, filepath.Join(workingDir, "bootstrap.ign")); err != nil {
+		return cloudProviderError(err, "failed to ensure ignition bucket exists")
+	}
+
+	log.Info("Rendering Manifests")
+	if err := render.RenderPKISecrets(pkiDir, manifestsDir, true, true, false, true, render.CertRotationEnabled(params), false, params.EtcdEncryption.Provider != "", params.Monitoring.Enabled); err != nil {
+		return installerrors.Render(err, "failed to render PKI secrets")
+	}
+	if err := render.RenderNamedCertsSecret(params, pkiDir, manifestsDir); err != nil {
+		return installerrors.Render(err, "failed to render named certificates secret")
+	}
+	caBytes, err := ioutil.ReadFile(filepath.Join(pkiDir, "combined-ca.crt"))
+	if err != nil {
+		return installerrors.Render(err, "failed to render PKI secrets")
+	}
+	params.OpenshiftAPIServerCABundle = base64.StdEncoding.EncodeToString(caBytes)
+	if err = render.RenderClusterManifests(params, pullSecretFile, manifestsDir, true, true, false, true, true); err != nil {
+		return installerrors.Render(err, "failed to render manifests for cluster")
+	}
+
+	// Create a machineset for the new cluster's worker nodes
+	if err = generateWorkerMachineset(workerMachineSet, infraName, name, routerLBName, filepath.Join(manifestsDir, "machineset.json")); err != nil {
+		return installerrors.Render(err, "failed to generate worker machineset")
+	}
+	ignitionURL := fmt.Sprintf("https://storage.googleapis.com/%s/worker.ign", bucketName)
+	if err = installer.GenerateUserDataSecret(name, ignitionURL, params.IgnitionVersion, filepath.Join(manifestsDir, "machine-user-data.json")); err != nil {
+		return installerrors.Render(err, "failed to generate user data secret")
+	}
+	kubeadminPassword, err := installer.GenerateKubeadminPassword()
+	if err != nil {
+		return installerrors.Render(err, "failed to generate kubeadmin password")
+	}
+	if err = installer.GenerateKubeadminPasswordTargetSecret(kubeadminPassword, filepath.Join(manifestsDir, "kubeadmin-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create kubeadmin secret manifest for target cluster")
+	}
+	if err = installer.GenerateKubeadminPasswordSecret(kubeadminPassword, filepath.Join(manifestsDir, "kubeadmin-host-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create kubeadmin secret manifest for management cluster")
+	}
+	if err = installer.GenerateKubeconfigSecret(filepath.Join(pkiDir, "admin.kubeconfig"), filepath.Join(manifestsDir, "kubeconfig-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create kubeconfig secret manifest for management cluster")
+	}
+	if err = installer.GenerateTargetPullSecret([]byte(pullSecret), filepath.Join(manifestsDir, "user-pull-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create pull secret manifest for target cluster")
+	}
+
+	// Create the system branding manifest (cannot be applied because it's too large)
+	if err = installer.CreateBrandingSecret(client, name, filepath.Join(manifestsDir, "v4-0-config-system-branding.yaml")); err != nil {
+		return installerrors.Apply(err, "failed to create oauth branding secret")
+	}
+
+	excludedDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return installerrors.Render(err, "failed to create a temporary directory for excluded manifests")
+	}
+	log.Infof("Excluded manifests directory: %s", excludedDir)
+	if err = installer.ApplyManifests(cfg, name, manifestsDir, installer.ExcludeManifests, excludedDir); err != nil {
+		return installerrors.Apply(err, "failed to apply manifests")
+	}
+	log.Infof("Cluster resources applied")
+
+	apiURL := fmt.Sprintf("https://%s:%d", apiDNSName, apiNodePort)
+	if waitForReady {
+		log.Infof("Waiting up to 10 minutes for API endpoint to be available.")
+		if err = installer.WaitForAPIEndpoint(pkiDir, apiDNSName, apiNodePort, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to access API endpoint")
+		}
+		log.Infof("API is available at %s", apiURL)
+
+		log.Infof("Waiting up to 5 minutes for bootstrap pod to complete.")
+		if err = installer.WaitForBootstrapPod(client, name, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to wait for bootstrap pod to complete")
+		}
+		log.Infof("Bootstrap pod has completed.")
+
+		targetClusterCfg, err := installer.GetTargetClusterConfig(pkiDir)
+		if err != nil {
+			return installerrors.Precondition(err, "cannot create target cluster client config")
+		}
+		targetClient, err := kubeclient.NewForConfig(targetClusterCfg)
+		if err != nil {
+			return installerrors.Precondition(err, "cannot create target cluster client")
+		}
+
+		log.Infof("Waiting up to 10 minutes for nodes to be ready.")
+		if err = installer.WaitForNodesReady(targetClient, workerMachineSetCount, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to wait for nodes ready")
+		}
+		log.Infof("Nodes (%d) are ready", workerMachineSetCount)
+
+		log.Infof("Waiting up to 15 minutes for cluster operators to be ready.")
+		if err = installer.WaitForClusterOperators(targetClusterCfg, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to wait for cluster operators")
+		}
+	}
+
+	log.Infof("Cluster API URL: %s", apiURL)
+	log.Infof("Kubeconfig is available in secret %q in the %s namespace", "admin-kubeconfig", name)
+	log.Infof("Console URL:  %s", fmt.Sprintf("https://console-openshift-console.%s", params.IngressSubdomain))
+	log.Infof("kubeadmin password is available in secret %q in the %s namespace", "kubeadmin-password", name)
+	return nil
+}
+
+func getGCPServiceAccount(client kubeclient.Interface) (*ServiceAccount, error) {
+	secret, err := client.CoreV1().Secrets("kube-system").Get("gcp-credentials", metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	data, ok := secret.Data["service_account.json"]
+	if !ok {
+		return nil, fmt.Errorf("did not find a GCP service account key")
+	}
+	serviceAccount := &ServiceAccount{}
+	if err = json.Unmarshal(data, serviceAccount); err != nil {
+		return nil, fmt.Errorf("cannot parse GCP service account key: %v", err)
+	}
+	if serviceAccount.TokenURI == "" {
+		serviceAccount.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	return serviceAccount, nil
+}
+
+func getInfrastructureInfo(client dynamic.Interface) (string, string, string, error) {
+	infraGroupVersion, err := schema.ParseGroupVersion("config.openshift.io/v1")
+	if err != nil {
+		return "", "", "", err
+	}
+	infraGroupVersionResource := infraGroupVersion.WithResource("infrastructures")
+	obj, err := client.Resource(infraGroupVersionResource).Get("cluster", metav1.GetOptions{})
+	if err != nil {
+		return "", "", "", err
+	}
+	infraName, exists, err := unstructured.NestedString(obj.Object, "status", "infrastructureName")
+	if !exists || err != nil {
+		return "", "", "", fmt.Errorf("could not find the infrastructure name in the infrastructure resource: %v", err)
+	}
+	project, exists, err := unstructured.NestedString(obj.Object, "status", "platformStatus", "gcp", "projectID")
+	if !exists || err != nil {
+		return "", "", "", fmt.Errorf("could not find the GCP project in the infrastructure resource: %v", err)
+	}
+	region, exists, err := unstructured.NestedString(obj.Object, "status", "platformStatus", "gcp", "region")
+	if !exists || err != nil {
+		return "", "", "", fmt.Errorf("could not find the GCP region in the infrastructure resource: %v", err)
+	}
+	return infraName, project, region, nil
+}
+
+// getMachineInfo returns the instance name and zone of a machine with the given prefix
+func getMachineInfo(client dynamic.Interface, machineNames []string, prefix string) (string, string, error) {
+	name := ""
+	for _, machineName := range machineNames {
+		if strings.HasPrefix(machineName, prefix) {
+			name = machineName
+			break
+		}
+	}
+	if name == "" {
+		return "", "", fmt.Errorf("did not find machine with prefix %s", prefix)
+	}
+	machineGroupVersion, err := schema.ParseGroupVersion("machine.openshift.io/v1beta1")
+	if err != nil {
+		return "", "", err
+	}
+	machineGroupVersionResource := machineGroupVersion.WithResource("machines")
+	machine, err := client.Resource(machineGroupVersionResource).Namespace("openshift-machine-api").Get(name, metav1.GetOptions{})
+	if err != nil {
+		return "", "", err
+	}
+	zone, exists, err := unstructured.NestedString(machine.Object, "spec", "providerSpec", "value", "zone")
+	if !exists || err != nil {
+		return "", "", fmt.Errorf("did not find zone on machine object: %v", err)
+	}
+	return name, zone, nil
+}
+
+func getWorkerMachineSet(client dynamic.Interface, infraName string) (*unstructured.Unstructured, error) {
+	machineGV, err := schema.ParseGroupVersion("machine.openshift.io/v1beta1")
+	if err != nil {
+		return nil, err
+	}
+	machineSetGVR := machineGV.WithResource("machinesets")
+	list, err := client.Resource(machineSetGVR).Namespace("openshift-machine-api").List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		if strings.HasPrefix(list.Items[i].GetName(), fmt.Sprintf("%s-worker-", infraName)) {
+			return &list.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("did not find a worker machineset")
+}
+
+// getWorkerNetworkInfo returns the network and network tags of the workers of a machineset
+func getWorkerNetworkInfo(machineSet *unstructured.Unstructured) (string, []string, error) {
+	interfaces, exists, err := unstructured.NestedSlice(machineSet.Object, "spec", "template", "spec", "providerSpec", "value", "networkInterfaces")
+	if !exists || err != nil || len(interfaces) == 0 {
+		return "", nil, fmt.Errorf("did not find network interfaces in worker provider spec: %v", err)
+	}
+	network, _, err := unstructured.NestedString(interfaces[0].(map[string]interface{}), "network")
+	if err != nil || network == "" {
+		return "", nil, fmt.Errorf("did not find network in worker provider spec: %v", err)
+	}
+	tags, exists, err := unstructured.NestedStringSlice(machineSet.Object, "spec", "template", "spec", "providerSpec", "value", "tags")
+	if !exists || err != nil || len(tags) == 0 {
+		return "", nil, fmt.Errorf("did not find network tags in worker provider spec: %v", err)
+	}
+	return network, tags, nil
+}
+
+func generateWorkerMachineset(machineSet *unstructured.Unstructured, infraName, namespace, targetPool, fileName string) error {
+	workerName := generateMachineSetName(infraName, namespace, "worker")
+	object := machineSet.DeepCopy().Object
+
+	unstructured.RemoveNestedField(object, "status")
+	unstructured.RemoveNestedField(object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(object, "metadata", "generation")
+	unstructured.RemoveNestedField(object, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(object, "metadata", "selfLink")
+	unstructured.RemoveNestedField(object, "metadata", "uid")
+	unstructured.RemoveNestedField(object, "spec", "template", "spec", "metadata")
+	unstructured.SetNestedField(object, int64(workerMachineSetCount), "spec", "replicas")
+	unstructured.SetNestedField(object, workerName, "metadata", "name")
+	unstructured.SetNestedField(object, workerName, "spec", "selector", "matchLabels", "machine.openshift.io/cluster-api-machineset")
+	unstructured.SetNestedField(object, workerName, "spec", "template", "metadata", "labels", "machine.openshift.io/cluster-api-machineset")
+	unstructured.SetNestedField(object, fmt.Sprintf("%s-user-data", namespace), "spec", "template", "spec", "providerSpec", "value", "userDataSecret", "name")
+	unstructured.SetNestedStringSlice(object, []string{targetPool}, "spec", "template", "spec", "providerSpec", "value", "targetPools")
+
+	machineSetBytes, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, machineSetBytes, 0644)
+}
+
+func generateResourceName(infraName, clusterName, suffix string) string {
+	return installer.GetName(fmt.Sprintf("%s-%s", infraName, clusterName), suffix, 63)
+}
+
+func generateBucketName(infraName, clusterName, suffix string) string {
+	return installer.GetName(fmt.Sprintf("%s-%s", infraName, clusterName), suffix, 63)
+}
+
+func generateMachineSetName(infraName, clusterName, suffix string) string {
+	return installer.GetName(fmt.Sprintf("%s-%s", infraName, clusterName), suffix, 43)
+}
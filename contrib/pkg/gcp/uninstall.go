@@ -0,0 +1,121 @@
+package gcp
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+func UninstallCluster(name string) error {
+	// First, ensure that we can access the host cluster
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
+	}
+
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+	serviceAccount, err := getGCPServiceAccount(client)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain GCP credentials from host cluster")
+	}
+
+	infraName, project, region, err := getInfrastructureInfo(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain infrastructure info for cluster")
+	}
+
+	dnsZone, parentDomain, err := installer.GetDNSZoneInfo(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain public zone information")
+	}
+
+	gcp, err := NewGCPHelper(serviceAccount, project, region, infraName)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot create a GCP client")
+	}
+
+	log.Infof("Removing worker machineset")
+	if err = removeWorkerMachineset(dynamicClient, infraName, name); err != nil {
+		return installerrors.Apply(err, "failed to remove worker machineset")
+	}
+
+	for _, record := range []struct {
+		description string
+		dnsName     string
+	}{
+		{description: "API", dnsName: fmt.Sprintf("api.%s.%s", name, parentDomain)},
+		{description: "router", dnsName: fmt.Sprintf("*.apps.%s.%s", name, parentDomain)},
+		{description: "VPN", dnsName: fmt.Sprintf("vpn.%s.%s", name, parentDomain)},
+	} {
+		log.Infof("Removing %s DNS record", record.description)
+		if err = gcp.RemoveARecord(dnsZone, record.dnsName); err != nil {
+			return cloudProviderError(err, "cannot delete %s DNS record", record.description)
+		}
+	}
+
+	for _, suffix := range []string{"api", "oauth", "http", "https", "vpn"} {
+		log.Infof("Removing %s forwarding rule", suffix)
+		if err = gcp.RemoveForwardingRule(generateResourceName(infraName, name, suffix)); err != nil {
+			return cloudProviderError(err, "cannot delete %s forwarding rule", suffix)
+		}
+	}
+
+	for _, suffix := range []string{"api", "apps"} {
+		log.Infof("Removing %s target pool", suffix)
+		if err = gcp.RemoveTargetPool(generateResourceName(infraName, name, suffix)); err != nil {
+			return cloudProviderError(err, "cannot delete %s target pool", suffix)
+		}
+	}
+
+	for _, suffix := range []string{"api", "apps", "vpn"} {
+		log.Infof("Releasing %s address", suffix)
+		if err = gcp.RemoveAddress(generateResourceName(infraName, name, suffix)); err != nil {
+			return cloudProviderError(err, "cannot release %s address", suffix)
+		}
+	}
+
+	log.Infof("Removing bootstrap ignition bucket")
+	if err = gcp.RemoveIgnitionBucket(generateBucketName(infraName, name, "ign")); err != nil {
+		return cloudProviderError(err, "cannot delete ignition bucket")
+	}
+
+	log.Info("Removing cluster namespace")
+	if err = client.CoreV1().Namespaces().Delete(name, &metav1.DeleteOptions{}); err != nil {
+		if !errors.IsNotFound(err) {
+			return installerrors.Apply(err, "failed to delete namespace %s", name)
+		}
+	}
+
+	return nil
+}
+
+func removeWorkerMachineset(client dynamic.Interface, infraName, namespace string) error {
+	name := generateMachineSetName(infraName, namespace, "worker")
+	machineGV, err := schema.ParseGroupVersion("machine.openshift.io/v1beta1")
+	if err != nil {
+		return err
+	}
+	machineSetGVR := machineGV.WithResource("machinesets")
+	err = client.Resource(machineSetGVR).Namespace("openshift-machine-api").Delete(name, &metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
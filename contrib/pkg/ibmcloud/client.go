@@ -0,0 +1,217 @@
+package ibmcloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	iamEndpoint = "https://iam.cloud.ibm.com"
+	dnsEndpoint = "https://api.dns-svcs.cloud.ibm.com"
+
+	// vpcAPIVersion is the date of the version of the VPC API that requests are made with
+	vpcAPIVersion = "2021-06-29"
+
+	provisioningTimeout = 15 * time.Minute
+)
+
+// pollInterval is the interval between checks of the provisioning status of a resource
+var pollInterval = 10 * time.Second
+
+// apiError is an error returned by an IBM Cloud API
+type apiError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("IBM Cloud API returned %d (%s): %s", e.StatusCode, e.Code, e.Message)
+}
+
+// ibmClient is a minimal client of the IBM Cloud VPC and DNS Services REST APIs
+type ibmClient struct {
+	apiKey      string
+	httpClient  *http.Client
+	iamEndpoint string
+	vpcEndpoint string
+	dnsEndpoint string
+
+	tokenLock   sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func newIBMClient(apiKey, region string) *ibmClient {
+	return &ibmClient{
+		apiKey:      apiKey,
+		httpClient:  &http.Client{Timeout: 60 * time.Second},
+		iamEndpoint: iamEndpoint,
+		vpcEndpoint: fmt.Sprintf("https://%s.iaas.cloud.ibm.com", region),
+		dnsEndpoint: dnsEndpoint,
+	}
+}
+
+// vpcURL returns the URL of a path of the VPC API of the region
+func (c *ibmClient) vpcURL(path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s/v1%s%sversion=%s&generation=2", c.vpcEndpoint, path, separator, vpcAPIVersion)
+}
+
+// dnsURL returns the URL of a path of the DNS Services API
+func (c *ibmClient) dnsURL(path string) string {
+	return fmt.Sprintf("%s/v1%s", c.dnsEndpoint, path)
+}
+
+// authorize returns an IAM access token, exchanging the API key for a new one
+// when the current one is about to expire
+func (c *ibmClient) authorize() (string, error) {
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+	if c.token != "" && time.Now().Add(time.Minute).Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+	form := url.Values{}
+	form.Set("grant_type", "urn:ibm:params:oauth:grant-type:apikey")
+	form.Set("apikey", c.apiKey)
+	resp, err := c.httpClient.PostForm(c.iamEndpoint+"/identity/token", form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", &apiError{StatusCode: resp.StatusCode, Code: "AuthenticationFailed", Message: string(body)}
+	}
+	result := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+	if err = json.Unmarshal(body, &result); err != nil {
+		return "", errors.Wrap(err, "cannot decode token response")
+	}
+	c.token = result.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+// do sends a request to the given URL and decodes the response into out
+func (c *ibmClient) do(method, requestURL string, in, out interface{}) error {
+	token, err := c.authorize()
+	if err != nil {
+		return err
+	}
+	body := bytes.NewReader(nil)
+	if in != nil {
+		inBytes, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(inBytes)
+	}
+	req, err := http.NewRequest(method, requestURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return decodeError(resp.StatusCode, respBytes)
+	}
+	if out != nil && len(respBytes) > 0 {
+		return json.Unmarshal(respBytes, out)
+	}
+	return nil
+}
+
+// decodeError returns the error of a failed response. The VPC API returns a list of
+// errors, the DNS Services API a single code and message.
+func decodeError(statusCode int, body []byte) error {
+	result := struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Errors  []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{}
+	json.Unmarshal(body, &result)
+	if len(result.Errors) > 0 {
+		return &apiError{StatusCode: statusCode, Code: result.Errors[0].Code, Message: result.Errors[0].Message}
+	}
+	return &apiError{StatusCode: statusCode, Code: result.Code, Message: result.Message}
+}
+
+// list fetches all pages of a collection of the VPC API. The items of each page are
+// passed to add, which decodes them. The link to the next page does not include the
+// version of the API, so only its start token is used.
+func (c *ibmClient) list(path, collection string, add func(json.RawMessage) error) error {
+	start := ""
+	for {
+		requestURL := c.vpcURL(path)
+		if len(start) > 0 {
+			requestURL += "&start=" + url.QueryEscape(start)
+		}
+		page := map[string]json.RawMessage{}
+		if err := c.do(http.MethodGet, requestURL, nil, &page); err != nil {
+			return err
+		}
+		if items, ok := page[collection]; ok {
+			if err := add(items); err != nil {
+				return err
+			}
+		}
+		next := struct {
+			Href string `json:"href"`
+		}{}
+		if len(page["next"]) > 0 {
+			if err := json.Unmarshal(page["next"], &next); err != nil {
+				return err
+			}
+		}
+		if len(next.Href) == 0 {
+			return nil
+		}
+		nextURL, err := url.Parse(next.Href)
+		if err != nil {
+			return errors.Wrapf(err, "invalid link to the next page of %s", collection)
+		}
+		start = nextURL.Query().Get("start")
+		if len(start) == 0 {
+			return errors.Errorf("link to the next page of %s has no start token", collection)
+		}
+	}
+}
+
+func isNotFound(err error) bool {
+	if e, ok := errors.Cause(err).(*apiError); ok {
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
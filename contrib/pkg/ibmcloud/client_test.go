@@ -0,0 +1,192 @@
+package ibmcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+)
+
+// fakeVPC is a fake of the IAM and VPC load balancer endpoints. Load balancers are
+// listed in pages of one, and each GET of a load balancer returns the next provisioning
+// status in statuses.
+type fakeVPC struct {
+	sync.Mutex
+	lbNames     []string
+	statuses    []string
+	tokens      int
+	creates     int
+	errorStatus int
+}
+
+func (f *fakeVPC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	if r.URL.Path == "/identity/token" {
+		f.tokens++
+		fmt.Fprint(w, `{"access_token": "token", "expires_in": 3600}`)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if f.errorStatus != 0 {
+		w.WriteHeader(f.errorStatus)
+		fmt.Fprint(w, `{"errors": [{"code": "some_code", "message": "some message"}]}`)
+		return
+	}
+	if r.URL.Query().Get("version") != vpcAPIVersion {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/load_balancers":
+		index := 0
+		fmt.Sscanf(r.URL.Query().Get("start"), "%d", &index)
+		page := map[string]interface{}{"load_balancers": []loadBalancer{}}
+		if index < len(f.lbNames) {
+			page["load_balancers"] = []loadBalancer{{ID: fmt.Sprintf("id-%d", index), Name: f.lbNames[index]}}
+		}
+		if index+1 < len(f.lbNames) {
+			page["next"] = map[string]string{"href": fmt.Sprintf("https://example.com/v1/load_balancers?start=%d&limit=1", index+1)}
+		}
+		json.NewEncoder(w).Encode(page)
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/load_balancers":
+		f.creates++
+		json.NewEncoder(w).Encode(loadBalancer{ID: "new-id", ProvisioningStatus: "create_pending"})
+	case r.Method == http.MethodGet:
+		status := f.statuses[0]
+		if len(f.statuses) > 1 {
+			f.statuses = f.statuses[1:]
+		}
+		json.NewEncoder(w).Encode(loadBalancer{ID: "new-id", Hostname: "lb.example.com", ProvisioningStatus: status})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestHelper(fake *fakeVPC) (*IBMCloudHelper, *httptest.Server) {
+	server := httptest.NewServer(fake)
+	helper := NewIBMCloudHelper("key", "region")
+	helper.client.iamEndpoint = server.URL
+	helper.client.vpcEndpoint = server.URL
+	return helper, server
+}
+
+func TestGetLoadBalancerPagination(t *testing.T) {
+	fake := &fakeVPC{lbNames: []string{"a", "b", "c"}}
+	helper, server := newTestHelper(fake)
+	defer server.Close()
+	for i, name := range fake.lbNames {
+		lb, err := helper.getLoadBalancer(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if lb == nil || lb.ID != fmt.Sprintf("id-%d", i) {
+			t.Errorf("expected load balancer %s to be found, got %#v", name, lb)
+		}
+	}
+	lb, err := helper.getLoadBalancer("d")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lb != nil {
+		t.Errorf("expected no load balancer, got %#v", lb)
+	}
+	if fake.tokens != 1 {
+		t.Errorf("expected the token to be requested once, got %d", fake.tokens)
+	}
+}
+
+func TestEnsureLoadBalancer(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+	tests := []struct {
+		name          string
+		existing      []string
+		statuses      []string
+		expectCreates int
+		expectError   bool
+	}{
+		{name: "created", statuses: []string{"create_pending", "create_pending", "active"}, expectCreates: 1},
+		{name: "exists", existing: []string{"api"}, statuses: []string{"active"}},
+		{name: "failed", statuses: []string{"create_pending", "failed"}, expectCreates: 1, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := &fakeVPC{lbNames: test.existing, statuses: test.statuses}
+			helper, server := newTestHelper(fake)
+			defer server.Close()
+			lb, err := helper.EnsureLoadBalancer("api", "subnet", []LBListener{{Name: "api", Protocol: "tcp", Port: 6443, MemberPort: 30000}}, "10.0.0.1")
+			if fake.creates != test.expectCreates {
+				t.Errorf("expected %d create requests, got %d", test.expectCreates, fake.creates)
+			}
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if lb.Hostname != "lb.example.com" {
+				t.Errorf("expected the active load balancer to be returned, got %#v", lb)
+			}
+			if len(fake.statuses) != 1 {
+				t.Errorf("expected all statuses to be polled, %d remaining", len(fake.statuses))
+			}
+		})
+	}
+}
+
+func TestErrorDecoding(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		retryable bool
+		notFound  bool
+	}{
+		{name: "throttled", status: http.StatusTooManyRequests, retryable: true},
+		{name: "server error", status: http.StatusServiceUnavailable, retryable: true},
+		{name: "bad request", status: http.StatusBadRequest},
+		{name: "not found", status: http.StatusNotFound, notFound: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			helper, server := newTestHelper(&fakeVPC{errorStatus: test.status})
+			defer server.Close()
+			_, err := helper.GetSubnet("subnet")
+			apiErr, ok := err.(*apiError)
+			if !ok {
+				t.Fatalf("expected an apiError, got %#v", err)
+			}
+			if apiErr.StatusCode != test.status || apiErr.Code != "some_code" || apiErr.Message != "some message" {
+				t.Errorf("unexpected error decoded: %#v", apiErr)
+			}
+			wrapped := errors.Wrap(err, "failed to get subnet")
+			if actual := installerrors.IsRetryable(cloudProviderError(wrapped, "cannot get subnet")); actual != test.retryable {
+				t.Errorf("expected retryable %t, got %t", test.retryable, actual)
+			}
+			if actual := isNotFound(wrapped); actual != test.notFound {
+				t.Errorf("expected not found %t, got %t", test.notFound, actual)
+			}
+		})
+	}
+}
+
+func TestDecodeDNSServicesError(t *testing.T) {
+	err := decodeError(http.StatusConflict, []byte(`{"code": "zone_conflict", "message": "conflict"}`))
+	apiErr, ok := err.(*apiError)
+	if !ok || apiErr.Code != "zone_conflict" || apiErr.Message != "conflict" {
+		t.Errorf("unexpected error decoded: %#v", err)
+	}
+}
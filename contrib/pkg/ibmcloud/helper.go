@@ -0,0 +1,478 @@
+package ibmcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+)
+
+const (
+	// loadBalancerProfile is the profile of network load balancers, which support UDP
+	// listeners and preserve the addresses of clients
+	loadBalancerProfile = "network-fixed"
+
+	nodePortMin = 30000
+	nodePortMax = 32767
+)
+
+// LBListener describes a listener of a load balancer and the pool that it forwards to
+type LBListener struct {
+	Name            string
+	Protocol        string
+	Port            int
+	MemberPort      int
+	HealthCheckPort int
+}
+
+// InstanceSpec describes a virtual server instance of a worker pool
+type InstanceSpec struct {
+	Profile         string
+	ImageID         string
+	SecurityGroupID string
+	SSHKeyID        string
+	UserData        string
+}
+
+// reference is a reference to another resource in a request or response of the VPC API
+type reference struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+type loadBalancer struct {
+	ID                 string      `json:"id"`
+	Name               string      `json:"name"`
+	Hostname           string      `json:"hostname"`
+	ProvisioningStatus string      `json:"provisioning_status"`
+	Pools              []reference `json:"pools"`
+	PublicIPs          []struct {
+		Address string `json:"address"`
+	} `json:"public_ips"`
+}
+
+// poolID returns the ID of the pool of the load balancer with the given name
+func (lb *loadBalancer) poolID(name string) (string, error) {
+	for _, pool := range lb.Pools {
+		if pool.Name == name {
+			return pool.ID, nil
+		}
+	}
+	return "", errors.Errorf("load balancer %s has no pool %s", lb.Name, name)
+}
+
+// Subnet is a subnet of the VPC of the management cluster
+type Subnet struct {
+	ID   string    `json:"id"`
+	Name string    `json:"name"`
+	Zone reference `json:"zone"`
+	VPC  reference `json:"vpc"`
+}
+
+type instance struct {
+	ID                      string `json:"id"`
+	Name                    string `json:"name"`
+	PrimaryNetworkInterface struct {
+		PrimaryIPv4Address string `json:"primary_ipv4_address"`
+	} `json:"primary_network_interface"`
+}
+
+type resourceRecord struct {
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	TTL   int    `json:"ttl,omitempty"`
+	RData struct {
+		CName string `json:"cname,omitempty"`
+	} `json:"rdata"`
+}
+
+type IBMCloudHelper struct {
+	client *ibmClient
+}
+
+// NewIBMCloudHelper creates an instance of the IBM Cloud helper for the VPC API of a region
+func NewIBMCloudHelper(apiKey, region string) *IBMCloudHelper {
+	return &IBMCloudHelper{
+		client: newIBMClient(apiKey, region),
+	}
+}
+
+// GetSubnet returns the subnet with the given ID
+func (h *IBMCloudHelper) GetSubnet(id string) (*Subnet, error) {
+	subnet := &Subnet{}
+	if err := h.client.do(http.MethodGet, h.client.vpcURL("/subnets/"+id), nil, subnet); err != nil {
+		return nil, err
+	}
+	return subnet, nil
+}
+
+// EnsureLoadBalancer ensures that a public network load balancer with the given name and
+// listeners exists in a subnet, with the given addresses as members of all of its pools.
+// It returns the load balancer once it is active.
+func (h *IBMCloudHelper) EnsureLoadBalancer(name, subnetID string, listeners []LBListener, members ...string) (*loadBalancer, error) {
+	lb, err := h.getLoadBalancer(name)
+	if err != nil {
+		return nil, err
+	}
+	if lb != nil {
+		return h.waitForLoadBalancer(lb.ID)
+	}
+	type healthMonitor struct {
+		Delay      int    `json:"delay"`
+		MaxRetries int    `json:"max_retries"`
+		Timeout    int    `json:"timeout"`
+		Type       string `json:"type"`
+		Port       int    `json:"port,omitempty"`
+	}
+	type pool struct {
+		Name          string                   `json:"name"`
+		Algorithm     string                   `json:"algorithm"`
+		Protocol      string                   `json:"protocol"`
+		HealthMonitor healthMonitor            `json:"health_monitor"`
+		Members       []map[string]interface{} `json:"members,omitempty"`
+	}
+	type listener struct {
+		Port        int       `json:"port"`
+		Protocol    string    `json:"protocol"`
+		DefaultPool reference `json:"default_pool"`
+	}
+	request := struct {
+		Name      string      `json:"name"`
+		IsPublic  bool        `json:"is_public"`
+		Profile   reference   `json:"profile"`
+		Subnets   []reference `json:"subnets"`
+		Pools     []pool      `json:"pools"`
+		Listeners []listener  `json:"listeners"`
+	}{
+		Name:     name,
+		IsPublic: true,
+		Profile:  reference{Name: loadBalancerProfile},
+		Subnets:  []reference{{ID: subnetID}},
+	}
+	for _, l := range listeners {
+		p := pool{
+			Name:      l.Name,
+			Algorithm: "round_robin",
+			Protocol:  l.Protocol,
+			// Health checks are always TCP, UDP pools are checked on another port
+			HealthMonitor: healthMonitor{Delay: 5, MaxRetries: 2, Timeout: 2, Type: "tcp", Port: l.HealthCheckPort},
+			Members:       poolMembers(l.MemberPort, members),
+		}
+		request.Pools = append(request.Pools, p)
+		request.Listeners = append(request.Listeners, listener{Port: l.Port, Protocol: l.Protocol, DefaultPool: reference{Name: l.Name}})
+	}
+	lb = &loadBalancer{}
+	if err = h.client.do(http.MethodPost, h.client.vpcURL("/load_balancers"), request, lb); err != nil {
+		return nil, err
+	}
+	return h.waitForLoadBalancer(lb.ID)
+}
+
+func poolMembers(port int, addresses []string) []map[string]interface{} {
+	var members []map[string]interface{}
+	for _, address := range addresses {
+		members = append(members, map[string]interface{}{
+			"port":   port,
+			"target": map[string]string{"address": address},
+		})
+	}
+	return members
+}
+
+// SetPoolMembers replaces the members of a pool of a load balancer with the given addresses
+func (h *IBMCloudHelper) SetPoolMembers(lb *loadBalancer, poolName string, port int, addresses []string) error {
+	poolID, err := lb.poolID(poolName)
+	if err != nil {
+		return err
+	}
+	request := map[string]interface{}{"members": poolMembers(port, addresses)}
+	if err = h.client.do(http.MethodPut, h.client.vpcURL(fmt.Sprintf("/load_balancers/%s/pools/%s/members", lb.ID, poolID)), request, nil); err != nil {
+		return err
+	}
+	// The load balancer does not accept other updates until the members are provisioned
+	_, err = h.waitForLoadBalancer(lb.ID)
+	return err
+}
+
+// getLoadBalancer returns the load balancer with the given name, or nil if it does not exist
+func (h *IBMCloudHelper) getLoadBalancer(name string) (*loadBalancer, error) {
+	var result *loadBalancer
+	err := h.client.list("/load_balancers", "load_balancers", func(items json.RawMessage) error {
+		var lbs []loadBalancer
+		if err := json.Unmarshal(items, &lbs); err != nil {
+			return err
+		}
+		for i := range lbs {
+			if lbs[i].Name == name {
+				result = &lbs[i]
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// waitForLoadBalancer waits for the load balancer with the given ID to be active
+func (h *IBMCloudHelper) waitForLoadBalancer(id string) (*loadBalancer, error) {
+	var lb *loadBalancer
+	err := wait.PollImmediate(pollInterval, provisioningTimeout, func() (bool, error) {
+		lb = &loadBalancer{}
+		if err := h.client.do(http.MethodGet, h.client.vpcURL("/load_balancers/"+id), nil, lb); err != nil {
+			return false, err
+		}
+		switch lb.ProvisioningStatus {
+		case "active":
+			return true, nil
+		case "failed":
+			return false, errors.Errorf("provisioning of load balancer %s failed", lb.Name)
+		}
+		return false, nil
+	})
+	return lb, err
+}
+
+// RemoveLoadBalancer removes the load balancer with the given name if it exists, and
+// waits for it to be gone
+func (h *IBMCloudHelper) RemoveLoadBalancer(name string) error {
+	lb, err := h.getLoadBalancer(name)
+	if err != nil || lb == nil {
+		return err
+	}
+	// A load balancer cannot be deleted while it is being updated
+	if _, err = h.waitForLoadBalancer(lb.ID); err != nil {
+		return err
+	}
+	return h.remove(h.client.vpcURL("/load_balancers/" + lb.ID))
+}
+
+// EnsureNodePortRules ensures that a security group allows TCP and UDP traffic to the
+// node port range from any address. Network load balancers preserve the addresses of
+// clients, so traffic is not limited to the subnet of the load balancers.
+func (h *IBMCloudHelper) EnsureNodePortRules(securityGroupID string) error {
+	type rule struct {
+		Direction string            `json:"direction"`
+		Protocol  string            `json:"protocol"`
+		PortMin   int               `json:"port_min"`
+		PortMax   int               `json:"port_max"`
+		Remote    map[string]string `json:"remote"`
+	}
+	result := struct {
+		Rules []rule `json:"rules"`
+	}{}
+	rulesURL := h.client.vpcURL(fmt.Sprintf("/security_groups/%s/rules", securityGroupID))
+	if err := h.client.do(http.MethodGet, rulesURL, nil, &result); err != nil {
+		return err
+	}
+	for _, protocol := range []string{"tcp", "udp"} {
+		expected := rule{
+			Direction: "inbound",
+			Protocol:  protocol,
+			PortMin:   nodePortMin,
+			PortMax:   nodePortMax,
+			Remote:    map[string]string{"cidr_block": "0.0.0.0/0"},
+		}
+		exists := false
+		for _, r := range result.Rules {
+			if r.Direction == expected.Direction && r.Protocol == protocol && r.PortMin <= nodePortMin && r.PortMax >= nodePortMax && r.Remote["cidr_block"] == "0.0.0.0/0" {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+		if err := h.client.do(http.MethodPost, rulesURL, expected, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsureInstance ensures that a virtual server instance with the given name exists and
+// returns its private IP address
+func (h *IBMCloudHelper) EnsureInstance(name string, subnet *Subnet, spec *InstanceSpec) (string, error) {
+	instances, err := h.listInstances(name)
+	if err != nil {
+		return "", err
+	}
+	for _, i := range instances {
+		if i.Name == name {
+			return i.PrimaryNetworkInterface.PrimaryIPv4Address, nil
+		}
+	}
+	networkInterface := map[string]interface{}{
+		"name":   "eth0",
+		"subnet": reference{ID: subnet.ID},
+	}
+	if len(spec.SecurityGroupID) > 0 {
+		networkInterface["security_groups"] = []reference{{ID: spec.SecurityGroupID}}
+	}
+	request := map[string]interface{}{
+		"name":                      name,
+		"profile":                   reference{Name: spec.Profile},
+		"image":                     reference{ID: spec.ImageID},
+		"zone":                      reference{Name: subnet.Zone.Name},
+		"vpc":                       reference{ID: subnet.VPC.ID},
+		"primary_network_interface": networkInterface,
+		"user_data":                 spec.UserData,
+	}
+	if len(spec.SSHKeyID) > 0 {
+		request["keys"] = []reference{{ID: spec.SSHKeyID}}
+	}
+	result := &instance{}
+	if err = h.client.do(http.MethodPost, h.client.vpcURL("/instances"), request, result); err != nil {
+		return "", err
+	}
+	return result.PrimaryNetworkInterface.PrimaryIPv4Address, nil
+}
+
+// RemoveInstances removes the virtual server instances whose names start with the given
+// prefix and waits for them to be gone
+func (h *IBMCloudHelper) RemoveInstances(prefix string) error {
+	instances, err := h.listInstances("")
+	if err != nil {
+		return err
+	}
+	for _, i := range instances {
+		if !strings.HasPrefix(i.Name, prefix) {
+			continue
+		}
+		if err = h.remove(h.client.vpcURL("/instances/" + i.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listInstances returns the instances with the given name, or all instances if name is empty
+func (h *IBMCloudHelper) listInstances(name string) ([]instance, error) {
+	path := "/instances"
+	if len(name) > 0 {
+		path += "?name=" + url.QueryEscape(name)
+	}
+	var result []instance
+	err := h.client.list(path, "instances", func(items json.RawMessage) error {
+		var instances []instance
+		if err := json.Unmarshal(items, &instances); err != nil {
+			return err
+		}
+		result = append(result, instances...)
+		return nil
+	})
+	return result, err
+}
+
+// remove deletes the VPC resource with the given URL if it exists and waits for it to be gone
+func (h *IBMCloudHelper) remove(resourceURL string) error {
+	err := h.client.do(http.MethodDelete, resourceURL, nil, nil)
+	if isNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return wait.PollImmediate(pollInterval, provisioningTimeout, func() (bool, error) {
+		err := h.client.do(http.MethodGet, resourceURL, nil, nil)
+		if isNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+// GetZoneName returns the domain name of a zone of a DNS Services instance
+func (h *IBMCloudHelper) GetZoneName(instanceID, zoneID string) (string, error) {
+	zone := struct {
+		Name string `json:"name"`
+	}{}
+	if err := h.client.do(http.MethodGet, h.client.dnsURL(fmt.Sprintf("/instances/%s/dnszones/%s", instanceID, zoneID)), nil, &zone); err != nil {
+		return "", err
+	}
+	return zone.Name, nil
+}
+
+// EnsureCNameRecord ensures that a CNAME record of dnsName to target exists in a zone of
+// a DNS Services instance
+func (h *IBMCloudHelper) EnsureCNameRecord(instanceID, zoneID, dnsName, target string) error {
+	records, err := h.listRecords(instanceID, zoneID)
+	if err != nil {
+		return err
+	}
+	recordsURL := h.client.dnsURL(fmt.Sprintf("/instances/%s/dnszones/%s/resource_records", instanceID, zoneID))
+	for _, record := range records {
+		if record.Type != "CNAME" || record.Name != dnsName {
+			continue
+		}
+		if record.RData.CName == target {
+			return nil
+		}
+		// The target of the record is updated, ie. when a load balancer was recreated
+		if err = h.client.do(http.MethodDelete, recordsURL+"/"+record.ID, nil, nil); err != nil && !isNotFound(err) {
+			return err
+		}
+	}
+	record := resourceRecord{Name: dnsName, Type: "CNAME", TTL: 60}
+	record.RData.CName = target
+	return h.client.do(http.MethodPost, recordsURL, record, nil)
+}
+
+// RemoveRecord removes the records of dnsName from a zone of a DNS Services instance
+func (h *IBMCloudHelper) RemoveRecord(instanceID, zoneID, dnsName string) error {
+	records, err := h.listRecords(instanceID, zoneID)
+	if err != nil {
+		return err
+	}
+	recordsURL := h.client.dnsURL(fmt.Sprintf("/instances/%s/dnszones/%s/resource_records", instanceID, zoneID))
+	for _, record := range records {
+		if record.Name != dnsName {
+			continue
+		}
+		if err = h.client.do(http.MethodDelete, recordsURL+"/"+record.ID, nil, nil); err != nil && !isNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// listRecords returns all resource records of a zone of a DNS Services instance, which
+// are paginated by offset
+func (h *IBMCloudHelper) listRecords(instanceID, zoneID string) ([]resourceRecord, error) {
+	const limit = 1000
+	var records []resourceRecord
+	for offset := 0; ; offset += limit {
+		page := struct {
+			ResourceRecords []resourceRecord `json:"resource_records"`
+			TotalCount      int              `json:"total_count"`
+		}{}
+		pageURL := h.client.dnsURL(fmt.Sprintf("/instances/%s/dnszones/%s/resource_records?offset=%d&limit=%d", instanceID, zoneID, offset, limit))
+		if err := h.client.do(http.MethodGet, pageURL, nil, &page); err != nil {
+			return nil, err
+		}
+		records = append(records, page.ResourceRecords...)
+		if len(page.ResourceRecords) == 0 || len(records) >= page.TotalCount {
+			return records, nil
+		}
+	}
+}
+
+// cloudProviderError wraps an error returned by an IBM Cloud API, flagging it as
+// retryable if it is a throttling, server or network failure.
+func cloudProviderError(err error, format string, args ...interface{}) error {
+	retryable := false
+	switch e := errors.Cause(err).(type) {
+	case *apiError:
+		retryable = e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+	case net.Error:
+		retryable = true
+	}
+	return installerrors.CloudProvider(err, retryable, format, args...)
+}
@@ -0,0 +1,562 @@
+package ibmcloud
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	gocidr "github.com/apparentlymart/go-cidr/cidr"
+	log "github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/ignition"
+	"github.com/openshift/hypershift-toolkit/pkg/pki"
+	"github.com/openshift/hypershift-toolkit/pkg/render"
+)
+
+const (
+	externalOauthPort    = 8443
+	externalIgnitionPort = 22623
+
+	maxInstanceNameLength = 63
+
+	// apiKeyEnvVar overrides the API key in the credentials secret of the management cluster
+	apiKeyEnvVar = "IBMCLOUD_API_KEY"
+
+	defaultWorkerProfile = "bx2-4x16"
+
+	defaultControlPlaneOperatorImage = "registry.svc.ci.openshift.org/hypershift-toolkit/hypershift-4.4:control-plane-operator"
+)
+
+// Config is the IBM Cloud configuration of a hosted cluster, which cannot be read from
+// the management cluster
+type Config struct {
+	// SubnetID is the VPC subnet of the load balancers and workers of the cluster
+	SubnetID string
+
+	// SecurityGroupID is a security group of the management cluster workers that allows
+	// access to their node ports. It is also the security group of the cluster workers.
+	SecurityGroupID string
+
+	// DNSInstanceID and DNSZoneID identify the DNS Services zone of the records of the cluster
+	DNSInstanceID string
+	DNSZoneID     string
+
+	// WorkerImageID is the RHCOS image of the workers
+	WorkerImageID string
+
+	// WorkerProfile is the instance profile of the workers
+	WorkerProfile string
+
+	// WorkerCount is the number of workers
+	WorkerCount int
+
+	// SSHKeyID is a VPC SSH key added to the workers
+	SSHKeyID string
+
+	// RouterHTTPNodePort and RouterHTTPSNodePort are the node ports of the router on the
+	// workers, which are allocated when both are 0
+	RouterHTTPNodePort  int
+	RouterHTTPSNodePort int
+
+	// SkipPrivilegedSCC leaves the privileged SCC of the management cluster unchanged
+	SkipPrivilegedSCC bool
+}
+
+// InstallCluster installs a new cluster on a management cluster running on IBM Cloud.
+// The workers of the new cluster are virtual server instances that fetch their ignition
+// config from the ignition server of the control plane.
+func InstallCluster(name, releaseImage, dhParamsFile string, config *Config, waitForReady bool) error {
+	if len(config.SubnetID) == 0 || len(config.DNSInstanceID) == 0 || len(config.DNSZoneID) == 0 || len(config.WorkerImageID) == 0 {
+		return installerrors.Precondition(nil, "a subnet, a DNS Services instance and zone, and a worker image are required")
+	}
+
+	// First, ensure that we can access the host cluster
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
+	}
+	// Extract config information from management cluster
+	sshKey, err := installer.GetSSHPublicKey(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to fetch an SSH public key from existing cluster")
+	}
+	log.Debugf("The SSH public key is: %s", string(sshKey))
+
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+	apiKey, err := getAPIKey(client)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain IBM Cloud credentials")
+	}
+
+	if releaseImage == "" {
+		releaseImage, err = installer.GetReleaseImage(dynamicClient)
+		if err != nil {
+			return installerrors.Precondition(err, "failed to obtain release image from host cluster")
+		}
+	}
+
+	pullSecret, err := installer.GetPullSecret(client)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a pull secret from cluster")
+	}
+	log.Debugf("The pull secret is: %v", pullSecret)
+
+	ignitionVersion, err := installer.GetIgnitionVersion(client)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain the ignition version of the management cluster workers")
+	}
+	log.Debugf("The ignition version of the workers is: %s", ignitionVersion)
+
+	infraName, err := getInfrastructureName(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain infrastructure info for cluster")
+	}
+	log.Debugf("The management cluster infra name is: %s", infraName)
+
+	serviceCIDR, podCIDR, err := installer.GetNetworkInfo(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain network info for cluster")
+	}
+
+	region, machineIPs, err := getWorkerNodes(client)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot get worker node info")
+	}
+	log.Infof("Using region %s and management workers with IPs: %v", region, machineIPs)
+
+	ibm := NewIBMCloudHelper(apiKey, region)
+	subnet, err := ibm.GetSubnet(config.SubnetID)
+	if err != nil {
+		return cloudProviderError(err, "cannot get subnet %s", config.SubnetID)
+	}
+	log.Infof("Using VPC: %s, Zone: %s, Subnet: %s", subnet.VPC.ID, subnet.Zone.Name, subnet.ID)
+
+	parentDomain, err := ibm.GetZoneName(config.DNSInstanceID, config.DNSZoneID)
+	if err != nil {
+		return cloudProviderError(err, "cannot get DNS zone %s", config.DNSZoneID)
+	}
+	log.Debugf("Using DNS zone: %s and parent suffix: %s", config.DNSZoneID, parentDomain)
+
+	routerNodePortHTTP, routerNodePortHTTPS, err := installer.RouterNodePorts(client, config.RouterHTTPNodePort, config.RouterHTTPSNodePort)
+	if err != nil {
+		return installerrors.Precondition(err, "invalid router node ports")
+	}
+	log.Infof("Using router NodePorts: %d, %d", routerNodePortHTTP, routerNodePortHTTPS)
+
+	// Start creating resources on management cluster
+	_, err = client.CoreV1().Namespaces().Get(name, metav1.GetOptions{})
+	if err == nil {
+		return installerrors.Precondition(nil, "target namespace %s already exists on management cluster", name)
+	}
+	if !errors.IsNotFound(err) {
+		return installerrors.Precondition(err, "unexpected error getting namespaces from management cluster")
+	}
+	log.Infof("Creating namespace %s", name)
+	ns := &corev1.Namespace{}
+	ns.Name = name
+	_, err = client.CoreV1().Namespaces().Create(ns)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create namespace %s", name)
+	}
+
+	// Ensure that the VPN pods can run privileged
+	if err = installer.EnsureControlPlaneSCC(dynamicClient, name, config.SkipPrivilegedSCC); err != nil {
+		return installerrors.Apply(err, "failed to ensure privileged SCC for the new namespace")
+	}
+
+	// Create pull secret
+	log.Infof("Creating pull secret")
+	if err := installer.CreatePullSecret(client, name, pullSecret); err != nil {
+		return installerrors.Apply(err, "failed to create pull secret")
+	}
+
+	// Create Kube APIServer service
+	log.Infof("Creating Kube API service")
+	apiNodePort, err := installer.CreateKubeAPIServerService(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create kube apiserver service")
+	}
+	log.Infof("Created Kube API service with NodePort %d", apiNodePort)
+
+	log.Infof("Creating VPN service")
+	vpnNodePort, err := installer.CreateVPNServerService(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create vpn server service")
+	}
+	log.Infof("Created VPN service with NodePort %d", vpnNodePort)
+
+	log.Infof("Creating Openshift API service")
+	openshiftClusterIP, err := installer.CreateOpenshiftService(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create openshift server service")
+	}
+	log.Infof("Created Openshift API service with cluster IP: %s", openshiftClusterIP)
+
+	oauthNodePort, err := installer.CreateOauthService(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create Oauth server service")
+	}
+	log.Infof("Created Oauth server service with NodePort: %d", oauthNodePort)
+
+	ignitionNodePort, err := installer.CreateIgnitionServerService(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create ignition server service")
+	}
+	log.Infof("Created ignition server service with NodePort: %d", ignitionNodePort)
+
+	workerProfile := config.WorkerProfile
+	if len(workerProfile) == 0 {
+		workerProfile = defaultWorkerProfile
+	}
+	provider := &ibmProvider{
+		helper:          ibm,
+		infraName:       infraName,
+		clusterName:     name,
+		dnsInstanceID:   config.DNSInstanceID,
+		dnsZoneID:       config.DNSZoneID,
+		parentDomain:    parentDomain,
+		subnet:          subnet,
+		securityGroupID: config.SecurityGroupID,
+		machineIPs:      machineIPs,
+		worker: InstanceSpec{
+			Profile:         workerProfile,
+			ImageID:         config.WorkerImageID,
+			SecurityGroupID: config.SecurityGroupID,
+			SSHKeyID:        config.SSHKeyID,
+		},
+	}
+	apiEndpoint, err := provider.EnsureAPIEndpoint(installer.APIEndpointPorts{
+		API:      apiNodePort,
+		OAuth:    oauthNodePort,
+		Ignition: ignitionNodePort,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = provider.EnsureIngressEndpoint(routerNodePortHTTP, routerNodePortHTTPS); err != nil {
+		return err
+	}
+	vpnEndpoint, err := provider.EnsureVPNEndpoint(vpnNodePort, apiNodePort)
+	if err != nil {
+		return err
+	}
+	apiDNSName := apiEndpoint.DNSName
+
+	_, serviceCIDRNet, err := net.ParseCIDR(serviceCIDR)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot parse service CIDR %s", serviceCIDR)
+	}
+
+	_, podCIDRNet, err := net.ParseCIDR(podCIDR)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot parse pod CIDR %s", podCIDR)
+	}
+
+	serviceCIDRPrefixLen, _ := serviceCIDRNet.Mask.Size()
+	clusterServiceCIDR, exceedsMax := gocidr.NextSubnet(serviceCIDRNet, serviceCIDRPrefixLen)
+	if exceedsMax {
+		return installerrors.Precondition(nil, "cluster service CIDR exceeds max address space")
+	}
+
+	podCIDRPrefixLen, _ := podCIDRNet.Mask.Size()
+	clusterPodCIDR, exceedsMax := gocidr.NextSubnet(podCIDRNet, podCIDRPrefixLen)
+	if exceedsMax {
+		return installerrors.Precondition(nil, "cluster pod CIDR exceeds max address space")
+	}
+
+	params := api.NewClusterParams()
+	params.Namespace = name
+	params.PodSecurity.SkipPrivilegedSCC = config.SkipPrivilegedSCC
+	params.ExternalAPIDNSName = apiDNSName
+	params.ExternalAPIPort = 6443
+	params.ExternalAPIIPAddress = apiEndpoint.Address
+	params.ExternalOpenVPNDNSName = vpnEndpoint.DNSName
+	params.ExternalOpenVPNPort = 1194
+	params.ExternalOauthPort = externalOauthPort
+	params.APINodePort = uint(apiNodePort)
+	params.ServiceCIDR = clusterServiceCIDR.String()
+	params.PodCIDR = clusterPodCIDR.String()
+	params.ReleaseImage = releaseImage
+	params.IngressSubdomain = fmt.Sprintf("apps.%s.%s", name, parentDomain)
+	params.OpenShiftAPIClusterIP = openshiftClusterIP
+	params.OpenVPNNodePort = fmt.Sprintf("%d", vpnNodePort)
+	params.BaseDomain = fmt.Sprintf("%s.%s", name, parentDomain)
+	// There is no cloud provider for workers that are not managed by the Machine API
+	params.CloudProvider = ""
+	params.InternalAPIPort = 6443
+	params.EtcdClientName = "etcd-client"
+	params.NetworkType = api.NetworkTypeOpenShiftSDN
+	params.ImageRegistryHTTPSecret = installer.GenerateImageRegistrySecret()
+	params.RouterNodePortHTTP = fmt.Sprintf("%d", routerNodePortHTTP)
+	params.RouterNodePortHTTPS = fmt.Sprintf("%d", routerNodePortHTTPS)
+	params.RouterServiceType = "NodePort"
+	params.NodePools = []api.NodePool{{Name: "worker", InstanceType: workerProfile, Replicas: config.WorkerCount}}
+	params.IgnitionVersion = ignitionVersion
+	params.ExternalIgnitionPort = externalIgnitionPort
+	if params.IgnitionServerToken, err = installer.GenerateIgnitionServerToken(); err != nil {
+		return installerrors.Render(err, "failed to generate ignition server token")
+	}
+	params.Replicas = "1"
+	params.ControlPlaneOperatorControllers = []string{
+		"controller-manager-ca",
+		"auto-approver",
+		"kubeadmin-password",
+		"cluster-operator",
+		"cluster-version",
+		"kubelet-serving-ca",
+		"openshift-apiserver",
+		"openshift-controller-manager",
+		"cert-rotation",
+		"cluster-status",
+	}
+	cpOperatorImage := os.Getenv("CONTROL_PLANE_OPERATOR_IMAGE_OVERRIDE")
+	if cpOperatorImage == "" {
+		params.ControlPlaneOperatorImage = defaultControlPlaneOperatorImage
+	} else {
+		params.ControlPlaneOperatorImage = cpOperatorImage
+	}
+
+	workingDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return installerrors.Render(err, "cannot create temporary working directory")
+	}
+	log.Infof("The working directory is %s", workingDir)
+	pkiDir := filepath.Join(workingDir, "pki")
+	if err = os.Mkdir(pkiDir, 0755); err != nil {
+		return installerrors.Render(err, "cannot create temporary PKI directory")
+	}
+	log.Info("Generating PKI")
+	if len(dhParamsFile) > 0 {
+		if err = installer.CopyFile(dhParamsFile, filepath.Join(pkiDir, "openvpn-dh.pem")); err != nil {
+			return installerrors.Render(err, "cannot copy dh parameters file %s", dhParamsFile)
+		}
+	}
+	if err := pki.GeneratePKI(params, pkiDir); err != nil {
+		return installerrors.Render(err, "failed to generate PKI assets")
+	}
+	manifestsDir := filepath.Join(workingDir, "manifests")
+	if err = os.Mkdir(manifestsDir, 0755); err != nil {
+		return installerrors.Render(err, "cannot create temporary manifests directory")
+	}
+	pullSecretFile := filepath.Join(workingDir, "pull-secret")
+	if err = ioutil.WriteFile(pullSecretFile, []byte(pullSecret), 0644); err != nil {
+		return installerrors.Render(err, "failed to create temporary pull secret file")
+	}
+	log.Info("Generating ignition for workers")
+	if err = ignition.GenerateIgnition(params, sshKey, pullSecretFile, pkiDir, workingDir); err != nil {
+		return installerrors.Render(err, "cannot generate ignition file for workers")
+	}
+	// Workers fetch the ignition file from the ignition server, which trusts the root CA
+	// of the cluster
+	if err = installer.GenerateIgnitionServerConfigSecret(filepath.Join(workingDir, "bootstrap.ign"), filepath.Join(manifestsDir, "ignition-server-config-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to generate ignition server config secret")
+	}
+	ignitionCA, err := ioutil.ReadFile(filepath.Join(pkiDir, "root-ca.crt"))
+	if err != nil {
+		return installerrors.Render(err, "cannot read root CA")
+	}
+	userData, err := ignition.UserData(ignition.ServerURL(apiDNSName, externalIgnitionPort, params.IgnitionServerToken), ignitionCA, params.IgnitionVersion)
+	if err != nil {
+		return installerrors.Render(err, "cannot generate user data for workers")
+	}
+	provider.worker.UserData = string(userData)
+
+	log.Info("Rendering Manifests")
+	if err := render.RenderPKISecrets(pkiDir, manifestsDir, true, true, false, true, render.CertRotationEnabled(params), true, params.EtcdEncryption.Provider != "", params.Monitoring.Enabled); err != nil {
+		return installerrors.Render(err, "failed to render PKI secrets")
+	}
+	if err := render.RenderNamedCertsSecret(params, pkiDir, manifestsDir); err != nil {
+		return installerrors.Render(err, "failed to render named certificates secret")
+	}
+	caBytes, err := ioutil.ReadFile(filepath.Join(pkiDir, "combined-ca.crt"))
+	if err != nil {
+		return installerrors.Render(err, "failed to render PKI secrets")
+	}
+	params.OpenshiftAPIServerCABundle = base64.StdEncoding.EncodeToString(caBytes)
+	if err = render.RenderClusterManifests(params, pullSecretFile, manifestsDir, true, true, false, true, true); err != nil {
+		return installerrors.Render(err, "failed to render manifests for cluster")
+	}
+
+	// Create a nodeport service for the router
+	if err = installer.GenerateRouterService(routerNodePortHTTP, routerNodePortHTTPS, filepath.Join(manifestsDir, "router-service.json")); err != nil {
+		return installerrors.Render(err, "failed to generate router service")
+	}
+	kubeadminPassword, err := installer.GenerateKubeadminPassword()
+	if err != nil {
+		return installerrors.Render(err, "failed to generate kubeadmin password")
+	}
+	if err = installer.GenerateKubeadminPasswordTargetSecret(kubeadminPassword, filepath.Join(manifestsDir, "kubeadmin-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create kubeadmin secret manifest for target cluster")
+	}
+	if err = installer.GenerateKubeadminPasswordSecret(kubeadminPassword, filepath.Join(manifestsDir, "kubeadmin-host-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create kubeadmin secret manifest for management cluster")
+	}
+	if err = installer.GenerateKubeconfigSecret(filepath.Join(pkiDir, "admin.kubeconfig"), filepath.Join(manifestsDir, "kubeconfig-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create kubeconfig secret manifest for management cluster")
+	}
+	if err = installer.GenerateTargetPullSecret([]byte(pullSecret), filepath.Join(manifestsDir, "user-pull-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create pull secret manifest for target cluster")
+	}
+
+	// Create the system branding manifest (cannot be applied because it's too large)
+	if err = installer.CreateBrandingSecret(client, name, filepath.Join(manifestsDir, "v4-0-config-system-branding.yaml")); err != nil {
+		return installerrors.Apply(err, "failed to create oauth branding secret")
+	}
+
+	excludedDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return installerrors.Render(err, "failed to create a temporary directory for excluded manifests")
+	}
+	log.Infof("Excluded manifests directory: %s", excludedDir)
+	if err = installer.ApplyManifests(cfg, name, manifestsDir, installer.ExcludeManifests, excludedDir); err != nil {
+		return installerrors.Apply(err, "failed to apply manifests")
+	}
+	log.Infof("Cluster resources applied")
+
+	// Create the worker instances of the new cluster, which retry fetching their ignition
+	// config until the ignition server is available
+	for _, pool := range params.NodePools {
+		if err = provider.EnsureWorkerPool(pool, ""); err != nil {
+			return err
+		}
+	}
+
+	if waitForReady {
+		log.Infof("Waiting up to 10 minutes for API endpoint to be available.")
+		if err = installer.WaitForAPIEndpoint(pkiDir, apiDNSName, 6443, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to access API endpoint")
+		}
+		log.Infof("API is available at %s", fmt.Sprintf("https://%s:6443", apiDNSName))
+
+		log.Infof("Waiting up to 5 minutes for bootstrap pod to complete.")
+		if err = installer.WaitForBootstrapPod(client, name, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to wait for bootstrap pod to complete")
+		}
+		log.Infof("Bootstrap pod has completed.")
+
+		targetClusterCfg, err := installer.GetTargetClusterConfig(pkiDir)
+		if err != nil {
+			return installerrors.Precondition(err, "cannot create target cluster client config")
+		}
+		targetClient, err := kubeclient.NewForConfig(targetClusterCfg)
+		if err != nil {
+			return installerrors.Precondition(err, "cannot create target cluster client")
+		}
+
+		log.Infof("Waiting up to 10 minutes for nodes to be ready.")
+		if err = installer.WaitForNodesReady(targetClient, config.WorkerCount, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to wait for nodes ready")
+		}
+		log.Infof("Nodes (%d) are ready", config.WorkerCount)
+
+		log.Infof("Waiting up to 15 minutes for cluster operators to be ready.")
+		if err = installer.WaitForClusterOperators(targetClusterCfg, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to wait for cluster operators")
+		}
+	}
+
+	log.Infof("Cluster API URL: %s", fmt.Sprintf("https://%s:6443", apiDNSName))
+	log.Infof("Kubeconfig is available in secret %q in the %s namespace", "admin-kubeconfig", name)
+	log.Infof("Console URL:  %s", fmt.Sprintf("https://console-openshift-console.%s", params.IngressSubdomain))
+	log.Infof("kubeadmin password is available in secret %q in the %s namespace", "kubeadmin-password", name)
+	return nil
+}
+
+// getAPIKey returns the IBM Cloud API key from the environment, or from the credentials
+// secret of the management cluster
+func getAPIKey(client kubeclient.Interface) (string, error) {
+	if apiKey := os.Getenv(apiKeyEnvVar); len(apiKey) > 0 {
+		return apiKey, nil
+	}
+	secret, err := client.CoreV1().Secrets("kube-system").Get("ibmcloud-credentials", metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("%s is not set and the credentials secret cannot be read: %v", apiKeyEnvVar, err)
+	}
+	apiKey, ok := secret.Data["ibmcloud_api_key"]
+	if !ok || len(apiKey) == 0 {
+		return "", fmt.Errorf("did not find ibmcloud_api_key in the IBM Cloud credentials secret")
+	}
+	return string(apiKey), nil
+}
+
+func getInfrastructureName(client dynamic.Interface) (string, error) {
+	infraGroupVersion, err := schema.ParseGroupVersion("config.openshift.io/v1")
+	if err != nil {
+		return "", err
+	}
+	infraGroupVersionResource := infraGroupVersion.WithResource("infrastructures")
+	obj, err := client.Resource(infraGroupVersionResource).Get("cluster", metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	infraName, exists, err := unstructured.NestedString(obj.Object, "status", "infrastructureName")
+	if !exists || err != nil {
+		return "", fmt.Errorf("could not find the infrastructure name in the infrastructure resource: %v", err)
+	}
+	return infraName, nil
+}
+
+// getWorkerNodes returns the region and the internal IPs of the worker nodes of the
+// management cluster. Workers of managed clusters are not machines of the Machine API,
+// so they are read from the nodes.
+func getWorkerNodes(client kubeclient.Interface) (string, []string, error) {
+	nodes, err := client.CoreV1().Nodes().List(metav1.ListOptions{LabelSelector: "node-role.kubernetes.io/worker"})
+	if err != nil {
+		return "", nil, err
+	}
+	region := ""
+	var ips []string
+	for _, node := range nodes.Items {
+		if len(region) == 0 {
+			region = node.Labels["topology.kubernetes.io/region"]
+		}
+		if len(region) == 0 {
+			region = node.Labels["failure-domain.beta.kubernetes.io/region"]
+		}
+		for _, address := range node.Status.Addresses {
+			if address.Type == corev1.NodeInternalIP {
+				ips = append(ips, address.Address)
+				break
+			}
+		}
+	}
+	if len(ips) == 0 {
+		return "", nil, fmt.Errorf("did not find worker nodes with an internal IP")
+	}
+	if len(region) == 0 {
+		return "", nil, fmt.Errorf("did not find the region of the worker nodes")
+	}
+	return region, ips, nil
+}
+
+func generateResourceName(infraName, clusterName, suffix string) string {
+	return installer.GetName(fmt.Sprintf("%s-%s", infraName, clusterName), suffix, maxInstanceNameLength)
+}
+
+// generateInstancePrefix returns the prefix of the names of the worker instances of a
+// cluster, which leaves room for the name of the node pool and the index of the instance
+func generateInstancePrefix(infraName, clusterName string) string {
+	return installer.GetName(fmt.Sprintf("%s-%s", infraName, clusterName), "worker", 40)
+}
@@ -0,0 +1,207 @@
+package ibmcloud
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+// ibmProvider is the cloud provider of hosted clusters on an IBM Cloud management cluster.
+// The endpoints of a cluster are VPC network load balancers that target the management
+// cluster workers by address, with CNAME records in a zone of a DNS Services instance.
+// Management clusters on IBM Cloud have no Machine API, so workers are virtual server
+// instances created in the subnet of the load balancers.
+type ibmProvider struct {
+	helper      *IBMCloudHelper
+	infraName   string
+	clusterName string
+
+	// dnsInstanceID and dnsZoneID identify the DNS Services zone of the records of the
+	// cluster, parentDomain is the domain of the zone
+	dnsInstanceID string
+	dnsZoneID     string
+	parentDomain  string
+
+	// subnet is the subnet of the load balancers and workers
+	subnet *Subnet
+
+	// securityGroupID is the security group of the workers, the default security group of
+	// the VPC if empty
+	securityGroupID string
+
+	// machineIPs are the addresses of the management cluster workers
+	machineIPs []string
+
+	// worker is the configuration of the worker instances. Its user data is set once the
+	// ignition URL of the cluster is known.
+	worker InstanceSpec
+
+	// routerLB and routerNodePorts are the router load balancer and the node ports of its
+	// pools, whose members are the workers of all node pools
+	routerLB        *loadBalancer
+	routerNodePorts map[string]int
+	workerIPs       []string
+}
+
+var _ installer.CloudProvider = &ibmProvider{}
+
+func (p *ibmProvider) lbName(suffix string) string {
+	return generateResourceName(p.infraName, p.clusterName, suffix)
+}
+
+func (p *ibmProvider) dnsName(prefix string) string {
+	return fmt.Sprintf("%s.%s.%s", prefix, p.clusterName, p.parentDomain)
+}
+
+// ensureRecord ensures a CNAME record of the cluster for the hostname of a load balancer
+func (p *ibmProvider) ensureRecord(prefix string, lb *loadBalancer, description string) (string, error) {
+	dnsName := p.dnsName(prefix)
+	if err := p.helper.EnsureCNameRecord(p.dnsInstanceID, p.dnsZoneID, dnsName, lb.Hostname); err != nil {
+		return "", cloudProviderError(err, "cannot create %s DNS record", description)
+	}
+	log.Infof("Created DNS record for %s name: %s", description, dnsName)
+	return dnsName, nil
+}
+
+// EnsureAPIEndpoint ensures the API load balancer, which targets the management cluster
+// workers, and that the workers accept traffic to node ports
+func (p *ibmProvider) EnsureAPIEndpoint(ports installer.APIEndpointPorts) (*installer.Endpoint, error) {
+	if len(p.securityGroupID) > 0 {
+		if err := p.helper.EnsureNodePortRules(p.securityGroupID); err != nil {
+			return nil, cloudProviderError(err, "cannot setup security group for worker nodes")
+		}
+		log.Infof("Ensured that node ports on workers are accessible")
+	}
+	listeners := []LBListener{
+		{Name: "api", Protocol: "tcp", Port: 6443, MemberPort: ports.API},
+		{Name: "oauth", Protocol: "tcp", Port: externalOauthPort, MemberPort: ports.OAuth},
+	}
+	if ports.Ignition != 0 {
+		listeners = append(listeners, LBListener{Name: "ignition", Protocol: "tcp", Port: externalIgnitionPort, MemberPort: ports.Ignition})
+	}
+	lb, err := p.helper.EnsureLoadBalancer(p.lbName("api"), p.subnet.ID, listeners, p.machineIPs...)
+	if err != nil {
+		return nil, cloudProviderError(err, "cannot create API load balancer")
+	}
+	log.Infof("Created API load balancer with ID: %s, hostname: %s and targets %s", lb.ID, lb.Hostname, strings.Join(p.machineIPs, ", "))
+	dnsName, err := p.ensureRecord("api", lb, "API")
+	if err != nil {
+		return nil, err
+	}
+	address := ""
+	if len(lb.PublicIPs) > 0 {
+		address = lb.PublicIPs[0].Address
+	}
+	return &installer.Endpoint{DNSName: dnsName, Address: address}, nil
+}
+
+// EnsureIngressEndpoint ensures the router load balancer. The routers of the cluster run
+// on its workers, which are added to the pools of the load balancer with each node pool.
+func (p *ibmProvider) EnsureIngressEndpoint(httpNodePort, httpsNodePort int) (*installer.Endpoint, error) {
+	lb, err := p.helper.EnsureLoadBalancer(p.lbName("apps"), p.subnet.ID, []LBListener{
+		{Name: "http", Protocol: "tcp", Port: 80, MemberPort: httpNodePort},
+		{Name: "https", Protocol: "tcp", Port: 443, MemberPort: httpsNodePort},
+	})
+	if err != nil {
+		return nil, cloudProviderError(err, "cannot create router load balancer")
+	}
+	log.Infof("Created router load balancer with ID: %s and hostname: %s", lb.ID, lb.Hostname)
+	p.routerLB = lb
+	p.routerNodePorts = map[string]int{"http": httpNodePort, "https": httpsNodePort}
+	dnsName, err := p.ensureRecord("*.apps", lb, "router")
+	if err != nil {
+		return nil, err
+	}
+	return &installer.Endpoint{DNSName: dnsName}, nil
+}
+
+// EnsureVPNEndpoint ensures the VPN load balancer, with a UDP pool that targets the
+// management cluster workers
+func (p *ibmProvider) EnsureVPNEndpoint(vpnNodePort, healthCheckNodePort int) (*installer.Endpoint, error) {
+	lb, err := p.helper.EnsureLoadBalancer(p.lbName("vpn"), p.subnet.ID, []LBListener{
+		{Name: "vpn", Protocol: "udp", Port: 1194, MemberPort: vpnNodePort, HealthCheckPort: healthCheckNodePort},
+	}, p.machineIPs...)
+	if err != nil {
+		return nil, cloudProviderError(err, "cannot create VPN load balancer")
+	}
+	log.Infof("Created VPN load balancer with ID: %s and hostname: %s", lb.ID, lb.Hostname)
+	dnsName, err := p.ensureRecord("vpn", lb, "VPN")
+	if err != nil {
+		return nil, err
+	}
+	return &installer.Endpoint{DNSName: dnsName}, nil
+}
+
+// EnsureIgnitionStorage is not supported on IBM Cloud, where workers fetch their ignition
+// config from the ignition server of the cluster
+func (p *ibmProvider) EnsureIgnitionStorage(fileName string) (string, error) {
+	return "", installerrors.Precondition(nil, "ignition storage is not supported on IBM Cloud, workers use the ignition server")
+}
+
+// EnsureWorkerPool creates the instances of a node pool in the subnet of the cluster and
+// adds them to the router load balancer. There is no Machine API on the management
+// cluster, so fileName is not written.
+func (p *ibmProvider) EnsureWorkerPool(pool api.NodePool, fileName string) error {
+	spec := p.worker
+	if len(pool.InstanceType) > 0 {
+		spec.Profile = pool.InstanceType
+	}
+	prefix := generateInstancePrefix(p.infraName, p.clusterName)
+	for i := 0; i < pool.Replicas; i++ {
+		name := fmt.Sprintf("%s-%s-%d", prefix, pool.Name, i)
+		if len(name) > maxInstanceNameLength {
+			return installerrors.Precondition(nil, "the name of node pool %s is too long", pool.Name)
+		}
+		ip, err := p.helper.EnsureInstance(name, p.subnet, &spec)
+		if err != nil {
+			return cloudProviderError(err, "cannot create worker instance %s", name)
+		}
+		log.Infof("Created worker instance %s with IP: %s", name, ip)
+		p.workerIPs = append(p.workerIPs, ip)
+	}
+	if p.routerLB == nil {
+		return nil
+	}
+	for _, poolName := range []string{"http", "https"} {
+		if err := p.helper.SetPoolMembers(p.routerLB, poolName, p.routerNodePorts[poolName], p.workerIPs); err != nil {
+			return cloudProviderError(err, "cannot add workers to router %s pool", poolName)
+		}
+	}
+	log.Infof("Added %d workers to the router load balancer", len(p.workerIPs))
+	return nil
+}
+
+// Teardown removes the DNS records, worker instances and load balancers of the cluster
+func (p *ibmProvider) Teardown() error {
+	for _, record := range []struct {
+		description string
+		prefix      string
+	}{
+		{description: "API", prefix: "api"},
+		{description: "router", prefix: "*.apps"},
+		{description: "VPN", prefix: "vpn"},
+	} {
+		log.Infof("Removing %s DNS record", record.description)
+		if err := p.helper.RemoveRecord(p.dnsInstanceID, p.dnsZoneID, p.dnsName(record.prefix)); err != nil {
+			return cloudProviderError(err, "cannot delete %s DNS record", record.description)
+		}
+	}
+
+	log.Infof("Removing worker instances")
+	if err := p.helper.RemoveInstances(generateInstancePrefix(p.infraName, p.clusterName) + "-"); err != nil {
+		return cloudProviderError(err, "cannot delete worker instances")
+	}
+
+	for _, suffix := range []string{"api", "apps", "vpn"} {
+		log.Infof("Removing %s load balancer", suffix)
+		if err := p.helper.RemoveLoadBalancer(p.lbName(suffix)); err != nil {
+			return cloudProviderError(err, "cannot delete %s load balancer", suffix)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package ibmcloud
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+// UninstallCluster removes a cluster installed with InstallCluster. The DNS Services
+// instance and zone of the cluster's records are those given to InstallCluster.
+func UninstallCluster(name, dnsInstanceID, dnsZoneID string) error {
+	if len(dnsInstanceID) == 0 || len(dnsZoneID) == 0 {
+		return installerrors.Precondition(nil, "a DNS Services instance and zone are required")
+	}
+
+	// First, ensure that we can access the host cluster
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
+	}
+
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+	apiKey, err := getAPIKey(client)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain IBM Cloud credentials")
+	}
+
+	infraName, err := getInfrastructureName(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain infrastructure info for cluster")
+	}
+
+	region, _, err := getWorkerNodes(client)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot get worker node info")
+	}
+
+	ibm := NewIBMCloudHelper(apiKey, region)
+	parentDomain, err := ibm.GetZoneName(dnsInstanceID, dnsZoneID)
+	if err != nil {
+		return cloudProviderError(err, "cannot get DNS zone %s", dnsZoneID)
+	}
+
+	provider := &ibmProvider{
+		helper:        ibm,
+		infraName:     infraName,
+		clusterName:   name,
+		dnsInstanceID: dnsInstanceID,
+		dnsZoneID:     dnsZoneID,
+		parentDomain:  parentDomain,
+	}
+	if err = provider.Teardown(); err != nil {
+		return err
+	}
+
+	log.Info("Removing cluster namespace")
+	if err = client.CoreV1().Namespaces().Delete(name, &metav1.DeleteOptions{}); err != nil {
+		if !errors.IsNotFound(err) {
+			return installerrors.Apply(err, "failed to delete namespace %s", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package installer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+// multiAZWorkerTaint is the taint of management cluster workers that the control plane
+// components tolerate
+const multiAZWorkerTaint = "multi-az-worker"
+
+// replicatedComponents are the control plane components that run a replica per replica of
+// the control plane. The other components that run on the management cluster run a single
+// replica.
+var replicatedComponents = []string{
+	"kube-apiserver",
+	"kube-controller-manager",
+	"kube-scheduler",
+	"openshift-apiserver",
+	"openshift-controller-manager",
+	"cluster-policy-controller",
+	"oauth-openshift",
+	"ignition-server",
+}
+
+// singleComponents are the control plane components that run a single replica
+var singleComponents = []string{
+	"cluster-version-operator",
+	"control-plane-operator",
+	"openvpn-server",
+}
+
+// PodRequest is the CPU and memory requested by a replica of a control plane component
+type PodRequest struct {
+	Cluster   string
+	Component string
+	CPU       resource.Quantity
+	Memory    resource.Quantity
+}
+
+// NodeCapacity is the CPU and memory of a management cluster node that is not requested by
+// the pods that run on it
+type NodeCapacity struct {
+	Name   string
+	CPU    resource.Quantity
+	Memory resource.Quantity
+}
+
+// CapacityReport is the outcome of a capacity check of the management cluster
+type CapacityReport struct {
+	// CPU and Memory are the total requests of the control planes
+	CPU    resource.Quantity
+	Memory resource.Quantity
+	// FreeCPU and FreeMemory are the total free capacity of the nodes
+	FreeCPU    resource.Quantity
+	FreeMemory resource.Quantity
+	// Unplaced are the replicas that do not fit on any node next to the other replicas
+	Unplaced []PodRequest
+	// Nodes are the nodes with the most free memory after the control planes are placed
+	Nodes []NodeCapacity
+}
+
+// Sufficient returns true if the total free capacity of the nodes covers the requests of
+// the control planes
+func (r *CapacityReport) Sufficient() bool {
+	return r.CPU.Cmp(r.FreeCPU) <= 0 && r.Memory.Cmp(r.FreeMemory) <= 0
+}
+
+// ControlPlaneFootprint returns the replicas of the components of the control plane of a
+// cluster that run on the management cluster, with their requests. The requests are those
+// of the sizing profile of the cluster, as returned by api.ComponentRequests.
+func ControlPlaneFootprint(name string, params *api.ClusterParams) ([]PodRequest, error) {
+	requests, err := api.ComponentRequests(params)
+	if err != nil {
+		return nil, err
+	}
+	replicas := 1
+	if len(params.Replicas) > 0 {
+		if replicas, err = strconv.Atoi(params.Replicas); err != nil || replicas < 1 {
+			return nil, fmt.Errorf("invalid control plane replicas %q", params.Replicas)
+		}
+	}
+	counts := map[string]int{}
+	for _, component := range replicatedComponents {
+		counts[component] = replicas
+	}
+	for _, component := range singleComponents {
+		counts[component] = 1
+	}
+	// The API server of a cluster with direct routing reaches its workers without a VPN
+	if params.DirectRouting {
+		delete(counts, "openvpn-server")
+	}
+	// An external etcd does not run on the management cluster
+	if len(params.EtcdEndpoints) == 0 {
+		counts["etcd"] = replicas
+	}
+	components := make([]string, 0, len(counts))
+	for component := range counts {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	var pods []PodRequest
+	for _, component := range components {
+		count := counts[component]
+		request := requests[component]
+		cpu, err := resource.ParseQuantity(request.CPU)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU request %q of %s: %v", request.CPU, component, err)
+		}
+		memory, err := resource.ParseQuantity(request.Memory)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory request %q of %s: %v", request.Memory, component, err)
+		}
+		for i := 0; i < count; i++ {
+			pods = append(pods, PodRequest{Cluster: name, Component: component, CPU: cpu, Memory: memory})
+		}
+	}
+	return pods, nil
+}
+
+// FreeNodeCapacity returns the capacity of the schedulable nodes of the management cluster
+// that is not requested by the pods that run on them. Nodes that are not ready, or that
+// have taints that the control plane components do not tolerate, are left out.
+func FreeNodeCapacity(client kubeclient.Interface) ([]NodeCapacity, error) {
+	nodes, err := client.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list nodes: %v", err)
+	}
+	pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list pods: %v", err)
+	}
+	requested := map[string]corev1.ResourceList{}
+	for _, pod := range pods.Items {
+		if len(pod.Spec.NodeName) == 0 {
+			continue
+		}
+		total, ok := requested[pod.Spec.NodeName]
+		if !ok {
+			total = corev1.ResourceList{}
+			requested[pod.Spec.NodeName] = total
+		}
+		for _, container := range pod.Spec.Containers {
+			for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+				if quantity, ok := container.Resources.Requests[name]; ok {
+					sum := total[name]
+					sum.Add(quantity)
+					total[name] = sum
+				}
+			}
+		}
+	}
+	var result []NodeCapacity
+	for _, node := range nodes.Items {
+		if !schedulableNode(&node) {
+			continue
+		}
+		free := NodeCapacity{
+			Name:   node.Name,
+			CPU:    node.Status.Allocatable.Cpu().DeepCopy(),
+			Memory: node.Status.Allocatable.Memory().DeepCopy(),
+		}
+		free.CPU.Sub(requested[node.Name][corev1.ResourceCPU])
+		free.Memory.Sub(requested[node.Name][corev1.ResourceMemory])
+		result = append(result, free)
+	}
+	return result, nil
+}
+
+// schedulableNode returns true if the control plane components can be scheduled on a node
+func schedulableNode(node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if taint.Key != multiAZWorkerTaint || taint.Value != "true" || taint.Effect != corev1.TaintEffectNoSchedule {
+			return false
+		}
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// CheckCapacity places the replicas of control planes on the free capacity of nodes, the
+// largest first on the node with the most free memory, with the replicas of a component of
+// a cluster on different nodes as their anti-affinity requires. The report has the replicas
+// that could not be placed, and the nodes with the most free capacity that remains.
+func CheckCapacity(nodes []NodeCapacity, pods []PodRequest) *CapacityReport {
+	report := &CapacityReport{}
+	free := make([]NodeCapacity, len(nodes))
+	for i, node := range nodes {
+		free[i] = NodeCapacity{Name: node.Name, CPU: node.CPU.DeepCopy(), Memory: node.Memory.DeepCopy()}
+		report.FreeCPU.Add(node.CPU)
+		report.FreeMemory.Add(node.Memory)
+	}
+	sorted := append([]PodRequest{}, pods...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Memory.Cmp(sorted[j].Memory) > 0
+	})
+	placed := map[string]bool{}
+	for _, pod := range sorted {
+		report.CPU.Add(pod.CPU)
+		report.Memory.Add(pod.Memory)
+		sort.SliceStable(free, func(i, j int) bool {
+			return free[i].Memory.Cmp(free[j].Memory) > 0
+		})
+		fits := false
+		for i := range free {
+			key := pod.Cluster + "/" + pod.Component + "/" + free[i].Name
+			if placed[key] || free[i].CPU.Cmp(pod.CPU) < 0 || free[i].Memory.Cmp(pod.Memory) < 0 {
+				continue
+			}
+			free[i].CPU.Sub(pod.CPU)
+			free[i].Memory.Sub(pod.Memory)
+			placed[key] = true
+			fits = true
+			break
+		}
+		if !fits {
+			report.Unplaced = append(report.Unplaced, pod)
+		}
+	}
+	sort.SliceStable(free, func(i, j int) bool {
+		return free[i].Memory.Cmp(free[j].Memory) > 0
+	})
+	if len(free) > 3 {
+		free = free[:3]
+	}
+	report.Nodes = free
+	return report
+}
@@ -0,0 +1,120 @@
+package installer
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestControlPlaneFootprint(t *testing.T) {
+	pods, err := ControlPlaneFootprint("dev", &api.ClusterParams{Replicas: "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 9 replicated components including etcd, and 3 single ones
+	if len(pods) != 3*9+3 {
+		t.Errorf("expected %d replicas, got %d", 3*9+3, len(pods))
+	}
+	pods, err = ControlPlaneFootprint("dev", &api.ClusterParams{Replicas: "1", EtcdEndpoints: []string{"https://etcd:2379"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pod := range pods {
+		if pod.Component == "etcd" {
+			t.Errorf("expected no etcd replicas with an external etcd")
+		}
+	}
+	pods, err = ControlPlaneFootprint("dev", &api.ClusterParams{Replicas: "1", DirectRouting: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pod := range pods {
+		if pod.Component == "openvpn-server" {
+			t.Errorf("expected no VPN server with direct routing")
+		}
+	}
+	if _, err = ControlPlaneFootprint("dev", &api.ClusterParams{Replicas: "0"}); err == nil {
+		t.Errorf("expected an error for invalid replicas")
+	}
+}
+
+func TestCheckCapacity(t *testing.T) {
+	node := func(name, cpu, memory string) NodeCapacity {
+		return NodeCapacity{Name: name, CPU: resource.MustParse(cpu), Memory: resource.MustParse(memory)}
+	}
+	pod := func(component, cpu, memory string) PodRequest {
+		return PodRequest{Cluster: "dev", Component: component, CPU: resource.MustParse(cpu), Memory: resource.MustParse(memory)}
+	}
+	tests := []struct {
+		name               string
+		nodes              []NodeCapacity
+		pods               []PodRequest
+		expectedSufficient bool
+		expectedUnplaced   int
+	}{
+		{
+			name:               "fits",
+			nodes:              []NodeCapacity{node("a", "2", "4Gi"), node("b", "2", "4Gi")},
+			pods:               []PodRequest{pod("kube-apiserver", "1", "3Gi"), pod("etcd", "1", "3Gi")},
+			expectedSufficient: true,
+		},
+		{
+			name:  "not enough capacity",
+			nodes: []NodeCapacity{node("a", "2", "4Gi")},
+			pods:  []PodRequest{pod("kube-apiserver", "1", "3Gi"), pod("etcd", "1", "3Gi")},
+			// Neither the total nor the nodes fit both replicas
+			expectedUnplaced: 1,
+		},
+		{
+			name:               "replicas on different nodes",
+			nodes:              []NodeCapacity{node("a", "4", "16Gi"), node("b", "100m", "128Mi")},
+			pods:               []PodRequest{pod("kube-apiserver", "1", "1Gi"), pod("kube-apiserver", "1", "1Gi")},
+			expectedSufficient: true,
+			expectedUnplaced:   1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := CheckCapacity(test.nodes, test.pods)
+			if report.Sufficient() != test.expectedSufficient {
+				t.Errorf("expected sufficient %t, got requests %s/%s and free %s/%s", test.expectedSufficient,
+					report.CPU.String(), report.Memory.String(), report.FreeCPU.String(), report.FreeMemory.String())
+			}
+			if len(report.Unplaced) != test.expectedUnplaced {
+				t.Errorf("expected %d unplaced replicas, got %v", test.expectedUnplaced, report.Unplaced)
+			}
+		})
+	}
+}
+
+func TestSchedulableNode(t *testing.T) {
+	ready := corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}}
+	tests := []struct {
+		name     string
+		node     corev1.Node
+		expected bool
+	}{
+		{name: "ready", node: corev1.Node{Status: ready}, expected: true},
+		{name: "not ready", node: corev1.Node{}},
+		{name: "unschedulable", node: corev1.Node{Spec: corev1.NodeSpec{Unschedulable: true}, Status: ready}},
+		{
+			name:     "tolerated taint",
+			node:     corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: multiAZWorkerTaint, Value: "true", Effect: corev1.TaintEffectNoSchedule}}}, Status: ready},
+			expected: true,
+		},
+		{
+			name: "master",
+			node: corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "node-role.kubernetes.io/master", Effect: corev1.TaintEffectNoSchedule}}}, Status: ready},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := schedulableNode(&test.node); actual != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+
+	gocidr "github.com/apparentlymart/go-cidr/cidr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	kubeclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// CIDRAllocationsConfigMapName is the config map of the management cluster that records
+	// the service and pod CIDRs allocated to its hosted clusters, by cluster name
+	CIDRAllocationsConfigMapName = "hypershift-cidr-allocations"
+	// CIDRAllocationsNamespace is the namespace of the CIDR allocations config map
+	CIDRAllocationsNamespace = "kube-system"
+)
+
+// CIDRAllocation is the service and pod CIDR of a hosted cluster
+type CIDRAllocation struct {
+	ServiceCIDR string `json:"serviceCIDR"`
+	PodCIDR     string `json:"podCIDR"`
+}
+
+// cidrAllocationsLock serializes the allocations of clusters that are installed
+// concurrently by this process, which would otherwise keep conflicting on the config map
+var cidrAllocationsLock sync.Mutex
+
+// AllocateClusterCIDRs returns the service and pod CIDRs of a hosted cluster. A cluster keeps
+// the CIDRs that are recorded for it, or that its stored parameters have if it was installed
+// before CIDRs were recorded. Otherwise it gets the first subnets after the service and pod
+// CIDRs of the management cluster, of the same sizes, that overlap neither the CIDRs of the
+// management cluster nor those of the other hosted clusters. The allocation is recorded in
+// the CIDR allocations config map unless dryRun is set.
+func AllocateClusterCIDRs(client kubeclient.Interface, name, serviceCIDR, podCIDR string, dryRun bool) (*CIDRAllocation, error) {
+	cidrAllocationsLock.Lock()
+	defer cidrAllocationsLock.Unlock()
+	var allocation *CIDRAllocation
+	// The config map is created by the first allocation, which another process may do too
+	conflict := func(err error) bool {
+		return errors.IsConflict(err) || errors.IsAlreadyExists(err)
+	}
+	err := retry.OnError(retry.DefaultRetry, conflict, func() error {
+		cm, err := client.CoreV1().ConfigMaps(CIDRAllocationsNamespace).Get(CIDRAllocationsConfigMapName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{}
+			cm.Name = CIDRAllocationsConfigMapName
+			cm.Namespace = CIDRAllocationsNamespace
+		} else if err != nil {
+			return err
+		}
+		allocations, err := parseCIDRAllocations(cm)
+		if err != nil {
+			return err
+		}
+		if recorded, ok := allocations[name]; ok {
+			allocation = &recorded
+			return nil
+		}
+		stored, err := storedClusterCIDRs(client)
+		if err != nil {
+			return err
+		}
+		if existing, ok := stored[name]; ok && len(existing.ServiceCIDR) > 0 && len(existing.PodCIDR) > 0 {
+			allocation = &existing
+		} else {
+			var used []CIDRAllocation
+			for _, a := range allocations {
+				used = append(used, a)
+			}
+			for _, a := range stored {
+				used = append(used, a)
+			}
+			if allocation, err = allocateCIDRs(serviceCIDR, podCIDR, used); err != nil {
+				return err
+			}
+		}
+		if dryRun {
+			return nil
+		}
+		value, err := json.Marshal(allocation)
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[name] = string(value)
+		if len(cm.ResourceVersion) == 0 {
+			_, err = client.CoreV1().ConfigMaps(CIDRAllocationsNamespace).Create(cm)
+		} else {
+			_, err = client.CoreV1().ConfigMaps(CIDRAllocationsNamespace).Update(cm)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allocation, nil
+}
+
+// ReleaseClusterCIDRs removes the CIDRs of a hosted cluster from the CIDR allocations config
+// map, so that they can be allocated to another cluster
+func ReleaseClusterCIDRs(client kubeclient.Interface, name string) error {
+	cidrAllocationsLock.Lock()
+	defer cidrAllocationsLock.Unlock()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := client.CoreV1().ConfigMaps(CIDRAllocationsNamespace).Get(CIDRAllocationsConfigMapName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := cm.Data[name]; !ok {
+			return nil
+		}
+		delete(cm.Data, name)
+		_, err = client.CoreV1().ConfigMaps(CIDRAllocationsNamespace).Update(cm)
+		return err
+	})
+}
+
+// parseCIDRAllocations returns the allocations of the CIDR allocations config map by
+// cluster name
+func parseCIDRAllocations(cm *corev1.ConfigMap) (map[string]CIDRAllocation, error) {
+	allocations := map[string]CIDRAllocation{}
+	for name, value := range cm.Data {
+		allocation := CIDRAllocation{}
+		if err := json.Unmarshal([]byte(value), &allocation); err != nil {
+			return nil, fmt.Errorf("invalid CIDR allocation of cluster %s in config map %s/%s: %v", name, CIDRAllocationsNamespace, CIDRAllocationsConfigMapName, err)
+		}
+		allocations[name] = allocation
+	}
+	return allocations, nil
+}
+
+// storedClusterCIDRs returns the CIDRs of the hosted clusters whose parameters are stored on
+// the management cluster, by cluster name
+func storedClusterCIDRs(client kubeclient.Interface) (map[string]CIDRAllocation, error) {
+	secrets, err := client.CoreV1().Secrets(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", ClusterParamsSecretName).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	stored := map[string]CIDRAllocation{}
+	for _, secret := range secrets.Items {
+		params, err := GetClusterParams(client, secret.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the parameters of the cluster in namespace %s: %v", secret.Namespace, err)
+		}
+		stored[secret.Namespace] = CIDRAllocation{ServiceCIDR: params.ServiceCIDR, PodCIDR: params.PodCIDR}
+	}
+	return stored, nil
+}
+
+// allocateCIDRs returns the first subnets after serviceCIDR and podCIDR, of the same sizes,
+// that overlap neither them, nor each other, nor the CIDRs of the used allocations
+func allocateCIDRs(serviceCIDR, podCIDR string, used []CIDRAllocation) (*CIDRAllocation, error) {
+	_, serviceNet, err := net.ParseCIDR(serviceCIDR)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse service CIDR %s: %v", serviceCIDR, err)
+	}
+	_, podNet, err := net.ParseCIDR(podCIDR)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse pod CIDR %s: %v", podCIDR, err)
+	}
+	taken := []*net.IPNet{serviceNet, podNet}
+	for _, allocation := range used {
+		for _, cidr := range []string{allocation.ServiceCIDR, allocation.PodCIDR} {
+			// The CIDRs of clusters that failed to install before they were set are empty
+			if _, n, err := net.ParseCIDR(cidr); err == nil {
+				taken = append(taken, n)
+			}
+		}
+	}
+	clusterServiceNet, err := freeSubnet(serviceNet, taken)
+	if err != nil {
+		return nil, fmt.Errorf("cannot allocate a service CIDR: %v", err)
+	}
+	taken = append(taken, clusterServiceNet)
+	clusterPodNet, err := freeSubnet(podNet, taken)
+	if err != nil {
+		return nil, fmt.Errorf("cannot allocate a pod CIDR: %v", err)
+	}
+	return &CIDRAllocation{ServiceCIDR: clusterServiceNet.String(), PodCIDR: clusterPodNet.String()}, nil
+}
+
+// freeSubnet returns the first subnet after base, of the same size, that overlaps none of
+// the taken subnets
+func freeSubnet(base *net.IPNet, taken []*net.IPNet) (*net.IPNet, error) {
+	prefixLen, _ := base.Mask.Size()
+	subnet := base
+	for {
+		next, exceedsMax := gocidr.NextSubnet(subnet, prefixLen)
+		if exceedsMax {
+			return nil, fmt.Errorf("no /%d subnet after %s is free", prefixLen, base)
+		}
+		subnet = next
+		if !overlapsAny(subnet, taken) {
+			return subnet, nil
+		}
+	}
+}
+
+func overlapsAny(subnet *net.IPNet, taken []*net.IPNet) bool {
+	for _, n := range taken {
+		if n.Contains(subnet.IP) || subnet.Contains(n.IP) {
+			return true
+		}
+	}
+	return false
+}
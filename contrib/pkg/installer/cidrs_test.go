@@ -0,0 +1,75 @@
+package installer
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestAllocateCIDRs(t *testing.T) {
+	tests := []struct {
+		name     string
+		used     []CIDRAllocation
+		expected CIDRAllocation
+	}{
+		{
+			name:     "first cluster",
+			expected: CIDRAllocation{ServiceCIDR: "172.31.0.0/16", PodCIDR: "10.132.0.0/14"},
+		},
+		{
+			name:     "second cluster",
+			used:     []CIDRAllocation{{ServiceCIDR: "172.31.0.0/16", PodCIDR: "10.132.0.0/14"}},
+			expected: CIDRAllocation{ServiceCIDR: "172.32.0.0/16", PodCIDR: "10.136.0.0/14"},
+		},
+		{
+			name:     "released CIDRs",
+			used:     []CIDRAllocation{{ServiceCIDR: "172.32.0.0/16", PodCIDR: "10.136.0.0/14"}},
+			expected: CIDRAllocation{ServiceCIDR: "172.31.0.0/16", PodCIDR: "10.132.0.0/14"},
+		},
+		{
+			name:     "smaller CIDR of another cluster",
+			used:     []CIDRAllocation{{ServiceCIDR: "172.31.128.0/20", PodCIDR: "10.133.0.0/16"}, {}},
+			expected: CIDRAllocation{ServiceCIDR: "172.32.0.0/16", PodCIDR: "10.136.0.0/14"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			allocation, err := allocateCIDRs("172.30.0.0/16", "10.128.0.0/14", test.used)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *allocation != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, *allocation)
+			}
+		})
+	}
+}
+
+func TestAllocateCIDRsOverlappingKinds(t *testing.T) {
+	// The service CIDR of the cluster must not be the next pod CIDR
+	allocation, err := allocateCIDRs("10.0.0.0/16", "10.1.0.0/16", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allocation.ServiceCIDR != "10.2.0.0/16" || allocation.PodCIDR != "10.3.0.0/16" {
+		t.Errorf("unexpected allocation: %+v", allocation)
+	}
+	if _, err = allocateCIDRs("255.255.0.0/16", "10.128.0.0/14", nil); err == nil {
+		t.Errorf("expected an error when no subnet is left")
+	}
+}
+
+func TestParseCIDRAllocations(t *testing.T) {
+	cm := &corev1.ConfigMap{Data: map[string]string{"dev": `{"serviceCIDR":"172.31.0.0/16","podCIDR":"10.132.0.0/14"}`}}
+	allocations, err := parseCIDRAllocations(cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allocations["dev"].PodCIDR != "10.132.0.0/14" {
+		t.Errorf("unexpected allocations: %+v", allocations)
+	}
+	cm.Data["test"] = "10.0.0.0/16"
+	if _, err = parseCIDRAllocations(cm); err == nil {
+		t.Errorf("expected an error for an invalid allocation")
+	}
+}
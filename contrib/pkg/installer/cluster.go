@@ -0,0 +1,195 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/openshift/hypershift-toolkit/pkg/ignition"
+)
+
+// LoadConfig loads a REST Config as per the rules specified in GetConfig
+func LoadConfig() (*rest.Config, error) {
+	if len(os.Getenv("KUBECONFIG")) > 0 {
+		return clientcmd.BuildConfigFromFlags("", os.Getenv("KUBECONFIG"))
+	}
+	if c, err := rest.InClusterConfig(); err == nil {
+		return c, nil
+	}
+	if usr, err := user.Current(); err == nil {
+		if c, err := clientcmd.BuildConfigFromFlags(
+			"", filepath.Join(usr.HomeDir, ".kube", "config")); err == nil {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("could not locate a kubeconfig")
+}
+
+// GetSSHPublicKey returns the SSH public key configured for the masters of the management cluster
+func GetSSHPublicKey(client dynamic.Interface) ([]byte, error) {
+	machineConfigGroupVersion, err := schema.ParseGroupVersion("machineconfiguration.openshift.io/v1")
+	if err != nil {
+		return nil, err
+	}
+	machineConfigGroupVersionResource := machineConfigGroupVersion.WithResource("machineconfigs")
+	obj, err := client.Resource(machineConfigGroupVersionResource).Get("99-master-ssh", metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	obj.GetName()
+	users, exists, err := unstructured.NestedSlice(obj.Object, "spec", "config", "passwd", "users")
+	if !exists || err != nil {
+		return nil, fmt.Errorf("could not find users slice in ssh machine config: %v", err)
+	}
+	keys, exists, err := unstructured.NestedStringSlice(users[0].(map[string]interface{}), "sshAuthorizedKeys")
+	if !exists || err != nil {
+		return nil, fmt.Errorf("could not find authorized keys for machine config: %v", err)
+	}
+	return []byte(keys[0]), nil
+}
+
+// GetPullSecret returns the global pull secret of the management cluster
+func GetPullSecret(client kubeclient.Interface) (string, error) {
+	secret, err := client.CoreV1().Secrets("openshift-config").Get("pull-secret", metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	pullSecret, ok := secret.Data[".dockerconfigjson"]
+	if !ok {
+		return "", fmt.Errorf("did not find pull secret data in secret")
+	}
+	return string(pullSecret), nil
+}
+
+// GetIgnitionVersion returns the Ignition spec version of the user data of the workers of the
+// management cluster, which is the version the boot images of its machinesets expect
+func GetIgnitionVersion(client kubeclient.Interface) (string, error) {
+	secret, err := client.CoreV1().Secrets("openshift-machine-api").Get("worker-user-data", metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	userData, ok := secret.Data["userData"]
+	if !ok {
+		return "", fmt.Errorf("did not find user data in worker user data secret")
+	}
+	return ignition.VersionOf(userData)
+}
+
+// GetReleaseImage returns the release image the management cluster is running
+func GetReleaseImage(client dynamic.Interface) (string, error) {
+	configGroupVersion, err := schema.ParseGroupVersion("config.openshift.io/v1")
+	if err != nil {
+		return "", err
+	}
+	clusterVersionGVR := configGroupVersion.WithResource("clusterversions")
+	obj, err := client.Resource(clusterVersionGVR).Get("version", metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	releaseImage, exists, err := unstructured.NestedString(obj.Object, "status", "desired", "image")
+	if !exists || err != nil {
+		return "", fmt.Errorf("cannot find release image in cluster version resource")
+	}
+	return releaseImage, nil
+}
+
+// GetNetworkInfo returns the service and pod CIDRs of the management cluster
+func GetNetworkInfo(client dynamic.Interface) (string, string, error) {
+	configGroupVersion, err := schema.ParseGroupVersion("config.openshift.io/v1")
+	if err != nil {
+		return "", "", err
+	}
+	networkGroupVersionResource := configGroupVersion.WithResource("networks")
+	obj, err := client.Resource(networkGroupVersionResource).Get("cluster", metav1.GetOptions{})
+	if err != nil {
+		return "", "", err
+	}
+	serviceNetworks, exists, err := unstructured.NestedSlice(obj.Object, "status", "serviceNetwork")
+	if !exists || err != nil || len(serviceNetworks) == 0 {
+		return "", "", fmt.Errorf("could not find service networks in the network status: %v", err)
+	}
+	serviceCIDR := serviceNetworks[0].(string)
+
+	podNetworks, exists, err := unstructured.NestedSlice(obj.Object, "status", "clusterNetwork")
+	if !exists || err != nil || len(podNetworks) == 0 {
+		return "", "", fmt.Errorf("could not find cluster networks in the network status: %v", err)
+	}
+	podCIDR, exists, err := unstructured.NestedString(podNetworks[0].(map[string]interface{}), "cidr")
+	if !exists || err != nil {
+		return "", "", fmt.Errorf("cannot find cluster network cidr: %v", err)
+	}
+	return serviceCIDR, podCIDR, nil
+}
+
+// GetDNSZoneInfo returns the ID of the public DNS zone of the management cluster
+// and the parent domain of its base domain
+func GetDNSZoneInfo(client dynamic.Interface) (string, string, error) {
+	configGroupVersion, err := schema.ParseGroupVersion("config.openshift.io/v1")
+	if err != nil {
+		return "", "", err
+	}
+	dnsGroupVersionResource := configGroupVersion.WithResource("dnses")
+	obj, err := client.Resource(dnsGroupVersionResource).Get("cluster", metav1.GetOptions{})
+	if err != nil {
+		return "", "", err
+	}
+	publicZoneID, exists, err := unstructured.NestedString(obj.Object, "spec", "publicZone", "id")
+	if !exists || err != nil {
+		return "", "", fmt.Errorf("could not find the dns public zone id in the dns resource: %v", err)
+	}
+	domain, exists, err := unstructured.NestedString(obj.Object, "spec", "baseDomain")
+	if !exists || err != nil {
+		return "", "", fmt.Errorf("could not find the dns base domain in the dns resource: %v", err)
+	}
+	parts := strings.Split(domain, ".")
+	baseDomain := strings.Join(parts[1:], ".")
+
+	return publicZoneID, baseDomain, nil
+}
+
+// GetIngressDomain returns the domain of the routes of the management cluster, which its
+// wildcard record resolves to its routers
+func GetIngressDomain(client dynamic.Interface) (string, error) {
+	configGroupVersion, err := schema.ParseGroupVersion("config.openshift.io/v1")
+	if err != nil {
+		return "", err
+	}
+	ingressGroupVersionResource := configGroupVersion.WithResource("ingresses")
+	obj, err := client.Resource(ingressGroupVersionResource).Get("cluster", metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	domain, exists, err := unstructured.NestedString(obj.Object, "spec", "domain")
+	if !exists || err != nil || len(domain) == 0 {
+		return "", fmt.Errorf("could not find the domain in the ingress config: %v", err)
+	}
+	return domain, nil
+}
+
+// GetMachineNames returns the names of the machines of the management cluster
+func GetMachineNames(client dynamic.Interface) ([]string, error) {
+	machineGroupVersion, err := schema.ParseGroupVersion("machine.openshift.io/v1beta1")
+	if err != nil {
+		return nil, err
+	}
+	machineGroupVersionResource := machineGroupVersion.WithResource("machines")
+	list, err := client.Resource(machineGroupVersionResource).Namespace("openshift-machine-api").List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, m := range list.Items {
+		names = append(names, m.GetName())
+	}
+	return names, nil
+}
@@ -0,0 +1,382 @@
+package installer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ghodss/yaml"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	configclient "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+const (
+	bootstrapPodName = "manifests-bootstrapper"
+
+	// gatherLogLines is the number of lines of the logs of the containers that are not ready
+	// that diagnostics include
+	gatherLogLines = int64(500)
+)
+
+// GatherDiagnostics writes the diagnostics of a cluster that failed to become ready to a
+// gzipped tarball in dir, and returns its path. The tarball has the logs of the bootstrap
+// pod, the statuses of the control plane pods with the logs of their containers that are
+// not ready, the events of the control plane namespace and, when targetCfg is not nil, the
+// cluster operators of the cluster with the conditions of those that are not available.
+// Diagnostics that cannot be collected are listed in errors.txt of the tarball.
+func GatherDiagnostics(client kubeclient.Interface, targetCfg *rest.Config, namespace, dir string) (string, error) {
+	name := fmt.Sprintf("gather-%s-%s", namespace, time.Now().UTC().Format("20060102-150405"))
+	fileName := filepath.Join(dir, name+".tar.gz")
+	f, err := os.Create(fileName)
+	if err != nil {
+		return "", fmt.Errorf("cannot create diagnostics file: %v", err)
+	}
+	defer f.Close()
+	archive := newGatherArchive(f, name)
+	archive.gatherControlPlane(client, namespace, false)
+	if targetCfg != nil {
+		archive.gatherClusterOperators(targetCfg)
+	}
+	if err = archive.close(); err != nil {
+		return "", fmt.Errorf("cannot write diagnostics file: %v", err)
+	}
+	return fileName, nil
+}
+
+// DumpCluster writes the diagnostics of the cluster of a control plane namespace for a
+// support case to out as a gzipped tarball. The tarball has the objects of the namespace,
+// with the data of secrets redacted, the status and logs of all of its pods, its events,
+// the stored parameters of the cluster with their secrets redacted, and the status and
+// cluster operators of the cluster. Diagnostics that cannot be collected are listed in
+// errors.txt of the tarball.
+func DumpCluster(client kubeclient.Interface, dynamicClient dynamic.Interface, namespace string, out io.Writer) error {
+	archive := newGatherArchive(out, fmt.Sprintf("dump-%s-%s", namespace, time.Now().UTC().Format("20060102-150405")))
+	archive.gatherControlPlane(client, namespace, true)
+	archive.gatherObjects(dynamicClient, namespace)
+
+	params, err := GetClusterParams(client, namespace)
+	if err != nil {
+		archive.failed("cluster parameters", err)
+	} else {
+		archive.addYAML("params.yaml", RedactClusterParams(params))
+	}
+
+	rows, err := GetClusterStatus(client, namespace)
+	if err != nil {
+		archive.failed("cluster status", err)
+	} else {
+		status := &bytes.Buffer{}
+		PrintClusterStatus(status, rows)
+		archive.add("status.txt", status.Bytes())
+	}
+	targetCfg, err := GetTargetClusterConfigFromSecret(client, namespace)
+	if err != nil {
+		archive.failed("cluster operators", err)
+	} else {
+		archive.gatherClusterOperators(targetCfg)
+	}
+	return archive.close()
+}
+
+// dumpResources are the resources of the control plane namespace that a dump has the
+// objects of. Pods and events are dumped separately.
+var dumpResources = []schema.GroupVersionResource{
+	{Version: "v1", Resource: "configmaps"},
+	{Version: "v1", Resource: "secrets"},
+	{Version: "v1", Resource: "services"},
+	{Version: "v1", Resource: "serviceaccounts"},
+	{Version: "v1", Resource: "persistentvolumeclaims"},
+	{Group: "apps", Version: "v1", Resource: "deployments"},
+	{Group: "apps", Version: "v1", Resource: "statefulsets"},
+	{Group: "batch", Version: "v1", Resource: "jobs"},
+	{Group: "batch", Version: "v1beta1", Resource: "cronjobs"},
+	{Group: "policy", Version: "v1beta1", Resource: "poddisruptionbudgets"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"},
+	{Group: "route.openshift.io", Version: "v1", Resource: "routes"},
+}
+
+// redactedValue replaces the values of secrets in dumps
+const redactedValue = "REDACTED"
+
+func (a *gatherArchive) gatherObjects(client dynamic.Interface, namespace string) {
+	for _, gvr := range dumpResources {
+		list, err := client.Resource(gvr).Namespace(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			a.failed(gvr.Resource, err)
+			continue
+		}
+		if len(list.Items) == 0 {
+			continue
+		}
+		for i := range list.Items {
+			redactObject(&list.Items[i])
+		}
+		a.addYAML(path.Join("namespace", gvr.Resource+".yaml"), list)
+	}
+}
+
+// redactObject removes the managed fields of an object and the values of the data of a
+// secret, keeping its keys
+func redactObject(obj *unstructured.Unstructured) {
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	if obj.GetKind() != "Secret" {
+		return
+	}
+	for _, field := range []string{"data", "stringData"} {
+		data, found, _ := unstructured.NestedMap(obj.Object, field)
+		if !found {
+			continue
+		}
+		for key := range data {
+			data[key] = redactedValue
+		}
+		unstructured.SetNestedMap(obj.Object, data, field)
+	}
+	annotations := obj.GetAnnotations()
+	if _, ok := annotations[corev1.LastAppliedConfigAnnotation]; ok {
+		annotations[corev1.LastAppliedConfigAnnotation] = redactedValue
+		obj.SetAnnotations(annotations)
+	}
+}
+
+// RedactClusterParams returns the parameters of a cluster without the secrets that they have
+func RedactClusterParams(params *api.ClusterParams) *api.ClusterParams {
+	redacted := *params
+	if len(redacted.ImageRegistryHTTPSecret) > 0 {
+		redacted.ImageRegistryHTTPSecret = redactedValue
+	}
+	if len(redacted.IgnitionServerToken) > 0 {
+		redacted.IgnitionServerToken = redactedValue
+	}
+	return &redacted
+}
+
+// gatherArchive is a gzipped tarball of diagnostics, whose files are in a directory
+type gatherArchive struct {
+	gz   *gzip.Writer
+	tw   *tar.Writer
+	dir  string
+	err  error
+	errs []string
+}
+
+func newGatherArchive(w io.Writer, dir string) *gatherArchive {
+	gz := gzip.NewWriter(w)
+	return &gatherArchive{gz: gz, tw: tar.NewWriter(gz), dir: dir}
+}
+
+// add adds a file to the archive. The first error writing the archive is returned by close.
+func (a *gatherArchive) add(name string, data []byte) {
+	if a.err != nil {
+		return
+	}
+	header := &tar.Header{
+		Name:    path.Join(a.dir, name),
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if a.err = a.tw.WriteHeader(header); a.err == nil {
+		_, a.err = a.tw.Write(data)
+	}
+}
+
+// failed records diagnostics that cannot be collected
+func (a *gatherArchive) failed(what string, err error) {
+	a.errs = append(a.errs, fmt.Sprintf("cannot gather %s: %v", what, err))
+}
+
+func (a *gatherArchive) close() error {
+	if len(a.errs) > 0 {
+		a.add("errors.txt", []byte(strings.Join(a.errs, "\n")+"\n"))
+	}
+	if a.err != nil {
+		return a.err
+	}
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
+
+func (a *gatherArchive) addYAML(name string, obj interface{}) {
+	b, err := yaml.Marshal(obj)
+	if err != nil {
+		a.failed(name, err)
+		return
+	}
+	a.add(name, b)
+}
+
+// gatherControlPlane adds the pods and events of the control plane namespace, and the logs
+// of the bootstrap pod and of the containers that are not ready, or of all containers if
+// allLogs is true
+func (a *gatherArchive) gatherControlPlane(client kubeclient.Interface, namespace string, allLogs bool) {
+	pods, err := client.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		a.failed("control plane pods", err)
+	} else {
+		a.add("pods.txt", podsSummary(pods.Items))
+		a.addYAML("pods.yaml", pods.Items)
+		for _, pod := range pods.Items {
+			for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+				if !allLogs && pod.Name != bootstrapPodName && containerReady(pod, container.Name) {
+					continue
+				}
+				a.gatherLogs(client, pod, container.Name)
+			}
+		}
+	}
+
+	events, err := client.CoreV1().Events(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		a.failed("events", err)
+	} else {
+		a.add("events.txt", eventsSummary(events.Items))
+	}
+}
+
+// gatherLogs adds the logs of a container, and of its previous instance if it restarted,
+// except for the bootstrap pod whose logs are added in full
+func (a *gatherArchive) gatherLogs(client kubeclient.Interface, pod corev1.Pod, container string) {
+	options := &corev1.PodLogOptions{Container: container}
+	if pod.Name != bootstrapPodName {
+		tail := gatherLogLines
+		options.TailLines = &tail
+	}
+	logs, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, options).DoRaw()
+	if err != nil {
+		a.failed(fmt.Sprintf("logs of %s/%s", pod.Name, container), err)
+		return
+	}
+	a.add(path.Join("logs", pod.Name, container+".log"), logs)
+	if restarts(pod, container) == 0 {
+		return
+	}
+	options.Previous = true
+	if logs, err = client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, options).DoRaw(); err == nil {
+		a.add(path.Join("logs", pod.Name, container+".previous.log"), logs)
+	}
+}
+
+func (a *gatherArchive) gatherClusterOperators(targetCfg *rest.Config) {
+	client, err := configclient.NewForConfig(targetCfg)
+	if err != nil {
+		a.failed("cluster operators", err)
+		return
+	}
+	operators, err := client.ClusterOperators().List(metav1.ListOptions{})
+	if err != nil {
+		a.failed("cluster operators", err)
+		return
+	}
+	var rows []StatusRow
+	for _, co := range operators.Items {
+		rows = append(rows, clusterOperatorStatus(co))
+	}
+	out := &bytes.Buffer{}
+	PrintClusterStatus(out, rows)
+	a.add("clusteroperators.txt", out.Bytes())
+	a.addYAML("clusteroperators.yaml", operators.Items)
+}
+
+func containerStatus(pod corev1.Pod, container string) (corev1.ContainerStatus, bool) {
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		if status.Name == container {
+			return status, true
+		}
+	}
+	return corev1.ContainerStatus{}, false
+}
+
+func containerReady(pod corev1.Pod, container string) bool {
+	status, ok := containerStatus(pod, container)
+	if !ok {
+		return false
+	}
+	return status.Ready || (status.State.Terminated != nil && status.State.Terminated.ExitCode == 0)
+}
+
+func restarts(pod corev1.Pod, container string) int32 {
+	status, _ := containerStatus(pod, container)
+	return status.RestartCount
+}
+
+// podsSummary returns a table of the readiness of pods, with the reasons that their
+// containers are not ready
+func podsSummary(pods []corev1.Pod) []byte {
+	out := &bytes.Buffer{}
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tREADY\tPHASE\tRESTARTS\tREASON")
+	for _, pod := range pods {
+		ready, restartCount := 0, int32(0)
+		var reasons []string
+		for _, status := range pod.Status.ContainerStatuses {
+			restartCount += status.RestartCount
+			if status.Ready {
+				ready++
+				continue
+			}
+			switch {
+			case status.State.Waiting != nil:
+				reasons = append(reasons, fmt.Sprintf("%s: %s", status.Name, status.State.Waiting.Reason))
+			case status.State.Terminated != nil:
+				reasons = append(reasons, fmt.Sprintf("%s: %s (exit code %d)", status.Name, status.State.Terminated.Reason, status.State.Terminated.ExitCode))
+			}
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status != corev1.ConditionTrue {
+				reasons = append(reasons, fmt.Sprintf("%s: %s", condition.Reason, condition.Message))
+			}
+		}
+		fmt.Fprintf(w, "%s\t%d/%d\t%s\t%d\t%s\n", pod.Name, ready, len(pod.Spec.Containers), pod.Status.Phase, restartCount, strings.Join(reasons, "; "))
+	}
+	w.Flush()
+	return out.Bytes()
+}
+
+// eventsSummary returns a table of events, oldest first
+func eventsSummary(events []corev1.Event) []byte {
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(events[i]).Before(eventTime(events[j]))
+	})
+	out := &bytes.Buffer{}
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "LAST SEEN\tTYPE\tREASON\tOBJECT\tCOUNT\tMESSAGE")
+	for _, event := range events {
+		object := strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", eventTime(event).UTC().Format(time.RFC3339), event.Type, event.Reason, object, event.Count, strings.TrimSpace(event.Message))
+	}
+	w.Flush()
+	return out.Bytes()
+}
+
+func eventTime(event corev1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
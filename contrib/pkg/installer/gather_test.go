@@ -0,0 +1,142 @@
+package installer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestGatherArchive(t *testing.T) {
+	out := &bytes.Buffer{}
+	archive := newGatherArchive(out, "gather-test")
+	archive.add("pods.txt", []byte("pods"))
+	archive.failed("events", errors.New("forbidden"))
+	if err := archive.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	gz, err := gzip.NewReader(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[header.Name] = string(b)
+	}
+	if files["gather-test/pods.txt"] != "pods" {
+		t.Errorf("unexpected pods file: %q", files["gather-test/pods.txt"])
+	}
+	if files["gather-test/errors.txt"] != "cannot gather events: forbidden\n" {
+		t.Errorf("unexpected errors file: %q", files["gather-test/errors.txt"])
+	}
+}
+
+func TestPodsSummary(t *testing.T) {
+	pod := corev1.Pod{}
+	pod.Name = "kube-apiserver-1"
+	pod.Spec.Containers = []corev1.Container{{Name: "kube-apiserver"}, {Name: "kube-controller-manager"}}
+	pod.Status.Phase = corev1.PodRunning
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: "kube-apiserver", Ready: true},
+		{Name: "kube-controller-manager", RestartCount: 4, State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+	}
+	summary := string(podsSummary([]corev1.Pod{pod}))
+	lines := strings.Split(strings.TrimSpace(summary), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected summary:\n%s", summary)
+	}
+	fields := strings.Fields(lines[1])
+	if fields[0] != "kube-apiserver-1" || fields[1] != "1/2" || fields[2] != "Running" || fields[3] != "4" {
+		t.Errorf("unexpected pod row: %s", lines[1])
+	}
+	if !strings.Contains(lines[1], "kube-controller-manager: CrashLoopBackOff") {
+		t.Errorf("expected the reason the container is not ready: %s", lines[1])
+	}
+}
+
+func TestEventsSummary(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	event := func(reason string, at time.Time) corev1.Event {
+		e := corev1.Event{Reason: reason, Type: corev1.EventTypeWarning, Count: 1}
+		e.InvolvedObject.Kind = "Pod"
+		e.InvolvedObject.Name = "etcd-0"
+		e.LastTimestamp = metav1.NewTime(at)
+		return e
+	}
+	summary := string(eventsSummary([]corev1.Event{event("BackOff", now.Add(time.Minute)), event("FailedScheduling", now)}))
+	lines := strings.Split(strings.TrimSpace(summary), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "FailedScheduling") || !strings.Contains(lines[2], "BackOff") {
+		t.Errorf("expected events oldest first:\n%s", summary)
+	}
+	if !strings.Contains(lines[1], "pod/etcd-0") {
+		t.Errorf("expected the object of the event: %s", lines[1])
+	}
+}
+
+func TestRedactObject(t *testing.T) {
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":          "pull-secret",
+			"managedFields": []interface{}{map[string]interface{}{"manager": "hypershift"}},
+			"annotations":   map[string]interface{}{corev1.LastAppliedConfigAnnotation: `{"data":{}}`},
+		},
+		"data": map[string]interface{}{".dockerconfigjson": "e30="},
+	}}
+	redactObject(secret)
+	if value, _, _ := unstructured.NestedString(secret.Object, "data", ".dockerconfigjson"); value != redactedValue {
+		t.Errorf("expected the data of the secret to be redacted, got %q", value)
+	}
+	if secret.GetAnnotations()[corev1.LastAppliedConfigAnnotation] != redactedValue {
+		t.Errorf("expected the last applied configuration to be redacted")
+	}
+	if _, found, _ := unstructured.NestedSlice(secret.Object, "metadata", "managedFields"); found {
+		t.Errorf("expected the managed fields to be removed")
+	}
+
+	configMap := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"data":       map[string]interface{}{"config.yaml": "kind: Config"},
+	}}
+	redactObject(configMap)
+	if value, _, _ := unstructured.NestedString(configMap.Object, "data", "config.yaml"); value != "kind: Config" {
+		t.Errorf("expected the data of the config map to be kept, got %q", value)
+	}
+}
+
+func TestRedactClusterParams(t *testing.T) {
+	params := &api.ClusterParams{Namespace: "cluster", IgnitionServerToken: "token", ImageRegistryHTTPSecret: "secret"}
+	redacted := RedactClusterParams(params)
+	if redacted.IgnitionServerToken != redactedValue || redacted.ImageRegistryHTTPSecret != redactedValue || redacted.Namespace != "cluster" {
+		t.Errorf("unexpected redacted parameters: %+v", redacted)
+	}
+	if params.IgnitionServerToken != "token" {
+		t.Errorf("expected the parameters to be unchanged")
+	}
+}
@@ -0,0 +1,224 @@
+package installer
+
+import (
+	"fmt"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeclient "k8s.io/client-go/kubernetes"
+)
+
+const (
+	// HibernatedReplicasAnnotation records the replicas of a workload or machineset of a
+	// hibernated cluster, which are restored when the cluster resumes
+	HibernatedReplicasAnnotation = "hypershift.openshift.io/hibernated-replicas"
+
+	// hibernatedAnnotation marks the cronjobs that were suspended by the hibernation of a
+	// cluster, so that cronjobs suspended otherwise stay suspended when it resumes
+	hibernatedAnnotation = "hypershift.openshift.io/hibernated"
+
+	// controlPlaneOperatorDeployment is the deployment that reconciles the control plane,
+	// which is scaled down first and up last
+	controlPlaneOperatorDeployment = "control-plane-operator"
+)
+
+// HibernateControlPlane scales the deployments and statefulsets of the control plane of a
+// cluster to zero and suspends its cronjobs. The statefulsets keep their persistent volumes,
+// so etcd resumes with its data. Workloads that are hibernated already are left unchanged,
+// so that an interrupted hibernation can be repeated.
+func HibernateControlPlane(client kubeclient.Interface, namespace string) error {
+	deployments, err := client.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("cannot list deployments: %v", err)
+	}
+	// The control plane operator is scaled down first, so that it does not reconcile the
+	// components that are scaled down after it
+	items := deployments.Items
+	for i := range items {
+		if items[i].Name == controlPlaneOperatorDeployment {
+			items[0], items[i] = items[i], items[0]
+			break
+		}
+	}
+	for i := range items {
+		d := &items[i]
+		if !hibernateReplicas(&d.ObjectMeta, &d.Spec.Replicas) {
+			continue
+		}
+		if _, err = client.AppsV1().Deployments(namespace).Update(d); err != nil {
+			return fmt.Errorf("cannot scale down deployment %s: %v", d.Name, err)
+		}
+		log.Infof("Scaled down deployment %s", d.Name)
+	}
+	statefulSets, err := client.AppsV1().StatefulSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("cannot list statefulsets: %v", err)
+	}
+	for i := range statefulSets.Items {
+		s := &statefulSets.Items[i]
+		if !hibernateReplicas(&s.ObjectMeta, &s.Spec.Replicas) {
+			continue
+		}
+		if _, err = client.AppsV1().StatefulSets(namespace).Update(s); err != nil {
+			return fmt.Errorf("cannot scale down statefulset %s: %v", s.Name, err)
+		}
+		log.Infof("Scaled down statefulset %s", s.Name)
+	}
+	cronJobs, err := client.BatchV1beta1().CronJobs(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("cannot list cronjobs: %v", err)
+	}
+	for i := range cronJobs.Items {
+		c := &cronJobs.Items[i]
+		if c.Spec.Suspend != nil && *c.Spec.Suspend {
+			continue
+		}
+		suspend := true
+		c.Spec.Suspend = &suspend
+		setAnnotation(&c.ObjectMeta, hibernatedAnnotation, "true")
+		if _, err = client.BatchV1beta1().CronJobs(namespace).Update(c); err != nil {
+			return fmt.Errorf("cannot suspend cronjob %s: %v", c.Name, err)
+		}
+		log.Infof("Suspended cronjob %s", c.Name)
+	}
+	return nil
+}
+
+// ResumeControlPlane restores the replicas of the deployments and statefulsets of the
+// control plane of a hibernated cluster and resumes the cronjobs that were suspended by its
+// hibernation. It returns false if none of them were hibernated.
+func ResumeControlPlane(client kubeclient.Interface, namespace string) (bool, error) {
+	resumed := false
+	statefulSets, err := client.AppsV1().StatefulSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return false, fmt.Errorf("cannot list statefulsets: %v", err)
+	}
+	for i := range statefulSets.Items {
+		s := &statefulSets.Items[i]
+		ok, err := resumeReplicas(&s.ObjectMeta, &s.Spec.Replicas)
+		if err != nil {
+			return false, fmt.Errorf("cannot resume statefulset %s: %v", s.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		if _, err = client.AppsV1().StatefulSets(namespace).Update(s); err != nil {
+			return false, fmt.Errorf("cannot scale up statefulset %s: %v", s.Name, err)
+		}
+		log.Infof("Scaled up statefulset %s to %d replicas", s.Name, *s.Spec.Replicas)
+		resumed = true
+	}
+	deployments, err := client.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return false, fmt.Errorf("cannot list deployments: %v", err)
+	}
+	// The control plane operator is scaled up last, once the components it reconciles are
+	// scaled up
+	items := deployments.Items
+	for i := range items {
+		if items[i].Name == controlPlaneOperatorDeployment {
+			last := len(items) - 1
+			items[last], items[i] = items[i], items[last]
+			break
+		}
+	}
+	for i := range items {
+		d := &items[i]
+		ok, err := resumeReplicas(&d.ObjectMeta, &d.Spec.Replicas)
+		if err != nil {
+			return false, fmt.Errorf("cannot resume deployment %s: %v", d.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		if _, err = client.AppsV1().Deployments(namespace).Update(d); err != nil {
+			return false, fmt.Errorf("cannot scale up deployment %s: %v", d.Name, err)
+		}
+		log.Infof("Scaled up deployment %s to %d replicas", d.Name, *d.Spec.Replicas)
+		resumed = true
+	}
+	cronJobs, err := client.BatchV1beta1().CronJobs(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return false, fmt.Errorf("cannot list cronjobs: %v", err)
+	}
+	for i := range cronJobs.Items {
+		c := &cronJobs.Items[i]
+		if _, ok := c.Annotations[hibernatedAnnotation]; !ok {
+			continue
+		}
+		suspend := false
+		c.Spec.Suspend = &suspend
+		delete(c.Annotations, hibernatedAnnotation)
+		if _, err = client.BatchV1beta1().CronJobs(namespace).Update(c); err != nil {
+			return false, fmt.Errorf("cannot resume cronjob %s: %v", c.Name, err)
+		}
+		log.Infof("Resumed cronjob %s", c.Name)
+	}
+	return resumed, nil
+}
+
+// ControlPlaneHibernated returns whether a deployment or statefulset of the control plane of
+// a cluster is hibernated
+func ControlPlaneHibernated(client kubeclient.Interface, namespace string) (bool, error) {
+	deployments, err := client.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return false, err
+	}
+	for _, d := range deployments.Items {
+		if _, ok := d.Annotations[HibernatedReplicasAnnotation]; ok {
+			return true, nil
+		}
+	}
+	statefulSets, err := client.AppsV1().StatefulSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return false, err
+	}
+	for _, s := range statefulSets.Items {
+		if _, ok := s.Annotations[HibernatedReplicasAnnotation]; ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hibernateReplicas records the replicas of an object in its hibernated replicas
+// annotation and sets them to zero. It returns false if the object is hibernated already.
+func hibernateReplicas(meta *metav1.ObjectMeta, replicas **int32) bool {
+	if _, ok := meta.Annotations[HibernatedReplicasAnnotation]; ok {
+		return false
+	}
+	current := int32(1)
+	if *replicas != nil {
+		current = **replicas
+	}
+	setAnnotation(meta, HibernatedReplicasAnnotation, strconv.Itoa(int(current)))
+	zero := int32(0)
+	*replicas = &zero
+	return true
+}
+
+// resumeReplicas restores the replicas of an object from its hibernated replicas
+// annotation and removes the annotation. It returns false if the object is not hibernated.
+func resumeReplicas(meta *metav1.ObjectMeta, replicas **int32) (bool, error) {
+	value, ok := meta.Annotations[HibernatedReplicasAnnotation]
+	if !ok {
+		return false, nil
+	}
+	count, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || count < 0 {
+		return false, fmt.Errorf("invalid %s annotation %q", HibernatedReplicasAnnotation, value)
+	}
+	restored := int32(count)
+	*replicas = &restored
+	delete(meta.Annotations, HibernatedReplicasAnnotation)
+	return true, nil
+}
+
+func setAnnotation(meta *metav1.ObjectMeta, name, value string) {
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[name] = value
+}
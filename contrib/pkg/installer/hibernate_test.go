@@ -0,0 +1,48 @@
+package installer
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHibernateReplicas(t *testing.T) {
+	three := int32(3)
+	meta := &metav1.ObjectMeta{}
+	replicas := &three
+	if !hibernateReplicas(meta, &replicas) {
+		t.Fatalf("expected the object to be hibernated")
+	}
+	if *replicas != 0 || meta.Annotations[HibernatedReplicasAnnotation] != "3" {
+		t.Errorf("expected 0 replicas with 3 recorded, got %d and %v", *replicas, meta.Annotations)
+	}
+	if hibernateReplicas(meta, &replicas) {
+		t.Errorf("expected a hibernated object to be left unchanged")
+	}
+	if meta.Annotations[HibernatedReplicasAnnotation] != "3" {
+		t.Errorf("expected the recorded replicas to be kept, got %v", meta.Annotations)
+	}
+
+	resumed, err := resumeReplicas(meta, &replicas)
+	if err != nil || !resumed {
+		t.Fatalf("expected the object to be resumed: %v", err)
+	}
+	if *replicas != 3 {
+		t.Errorf("expected 3 replicas, got %d", *replicas)
+	}
+	if _, ok := meta.Annotations[HibernatedReplicasAnnotation]; ok {
+		t.Errorf("expected the annotation to be removed")
+	}
+	if resumed, _ = resumeReplicas(meta, &replicas); resumed {
+		t.Errorf("expected an object that is not hibernated to be left unchanged")
+	}
+
+	var unset *int32
+	if !hibernateReplicas(meta, &unset) || meta.Annotations[HibernatedReplicasAnnotation] != "1" {
+		t.Errorf("expected unset replicas to be recorded as 1, got %v", meta.Annotations)
+	}
+	meta.Annotations[HibernatedReplicasAnnotation] = "many"
+	if _, err = resumeReplicas(meta, &unset); err == nil {
+		t.Errorf("expected an error for an invalid annotation")
+	}
+}
@@ -0,0 +1,420 @@
+package installer
+
+import (
+	crand "crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/bcrypt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubeclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/openshift/hypershift-toolkit/pkg/ignition"
+)
+
+const (
+	// KubeadminPasswordSecretName is the name of the secret in the control plane namespace
+	// that holds the kubeadmin password of the cluster
+	KubeadminPasswordSecretName = "kubeadmin-password"
+
+	// AdminKubeconfigSecretName is the name of the secret in the control plane namespace
+	// that holds the admin kubeconfig of the cluster
+	AdminKubeconfigSecretName = "admin-kubeconfig"
+
+	kubeadminPasswordKey = "password"
+)
+
+// GenerateRouterService writes a user manifest for a router service that uses the given node ports
+func GenerateRouterService(httpNodePort, httpsNodePort int, fileName string) error {
+	svc := &corev1.Service{}
+	svc.APIVersion = "v1"
+	svc.Kind = "Service"
+	svc.Name = "router-default"
+	svc.Namespace = "openshift-ingress"
+	svc.Labels = map[string]string{
+		"app":    "router",
+		"router": "router-default",
+	}
+	svc.Spec.Ports = []corev1.ServicePort{
+		{
+			Name:       "http",
+			NodePort:   int32(httpNodePort),
+			Port:       80,
+			Protocol:   corev1.ProtocolTCP,
+			TargetPort: intstr.FromString("http"),
+		},
+		{
+			Name:       "https",
+			NodePort:   int32(httpsNodePort),
+			Port:       443,
+			Protocol:   corev1.ProtocolTCP,
+			TargetPort: intstr.FromString("https"),
+		},
+	}
+	svc.Spec.Selector = map[string]string{
+		"ingresscontroller.operator.openshift.io/deployment-ingresscontroller": "default",
+	}
+	svc.Spec.SessionAffinity = corev1.ServiceAffinityNone
+	svc.Spec.Type = corev1.ServiceTypeNodePort
+
+	svcBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), svc)
+	if err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{}
+	configMap.APIVersion = "v1"
+	configMap.Kind = "ConfigMap"
+	configMap.Name = "user-manifest-router-service"
+	configMap.Data = map[string]string{"data": string(svcBytes)}
+	configMapBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), configMap)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, configMapBytes, 0644)
+}
+
+func GenerateTargetPullSecret(data []byte, fileName string) error {
+	secret := &corev1.Secret{}
+	secret.Name = "pull-secret"
+	secret.Namespace = "openshift-config"
+	secret.Data = map[string][]byte{".dockerconfigjson": data}
+	secret.Type = corev1.SecretTypeDockerConfigJson
+	secretBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), secret)
+	if err != nil {
+		return err
+	}
+	configMap := &corev1.ConfigMap{}
+	configMap.APIVersion = "v1"
+	configMap.Kind = "ConfigMap"
+	configMap.Name = "user-manifest-pullsecret"
+	configMap.Data = map[string]string{"data": string(secretBytes)}
+	configMapBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), configMap)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, configMapBytes, 0644)
+}
+
+// GenerateAWSCredentialsTargetSecret generates a user manifest with the kube-system/aws-creds
+// secret of the target cluster, which AWS components of the cluster read their credentials from
+func GenerateAWSCredentialsTargetSecret(accessKeyID, secretAccessKey, fileName string) error {
+	secret := &corev1.Secret{}
+	secret.Name = "aws-creds"
+	secret.Namespace = "kube-system"
+	secret.Data = map[string][]byte{
+		"aws_access_key_id":     []byte(accessKeyID),
+		"aws_secret_access_key": []byte(secretAccessKey),
+	}
+	secretBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), secret)
+	if err != nil {
+		return err
+	}
+	configMap := &corev1.ConfigMap{}
+	configMap.APIVersion = "v1"
+	configMap.Kind = "ConfigMap"
+	configMap.Name = "user-manifest-aws-creds"
+	configMap.Data = map[string]string{"data": string(secretBytes)}
+	configMapBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), configMap)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, configMapBytes, 0644)
+}
+
+// GenerateImageRegistryS3TargetSecret generates a user manifest with the credentials of the
+// S3 storage of the target cluster's image registry
+func GenerateImageRegistryS3TargetSecret(accessKeyID, secretAccessKey, fileName string) error {
+	secret := &corev1.Secret{}
+	secret.Name = "image-registry-private-configuration-user"
+	secret.Namespace = "openshift-image-registry"
+	secret.Data = map[string][]byte{
+		"REGISTRY_STORAGE_S3_ACCESSKEY": []byte(accessKeyID),
+		"REGISTRY_STORAGE_S3_SECRETKEY": []byte(secretAccessKey),
+	}
+	secretBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), secret)
+	if err != nil {
+		return err
+	}
+	configMap := &corev1.ConfigMap{}
+	configMap.APIVersion = "v1"
+	configMap.Kind = "ConfigMap"
+	configMap.Name = "user-manifest-image-registry-s3-credentials"
+	configMap.Data = map[string]string{"data": string(secretBytes)}
+	configMapBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), configMap)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, configMapBytes, 0644)
+}
+
+// GenerateKubernetesEndpointsTarget generates a user manifest with the endpoints of the
+// kubernetes service of the target cluster, for clusters whose API server does not
+// reconcile them because its advertised address is not reachable on its secure port. The
+// endpoints are the node port of the API service on the management cluster nodes.
+func GenerateKubernetesEndpointsTarget(addresses []string, port int, fileName string) error {
+	endpoints := &corev1.Endpoints{}
+	endpoints.Name = "kubernetes"
+	endpoints.Namespace = "default"
+	subset := corev1.EndpointSubset{
+		Ports: []corev1.EndpointPort{{Name: "https", Port: int32(port), Protocol: corev1.ProtocolTCP}},
+	}
+	for _, address := range addresses {
+		subset.Addresses = append(subset.Addresses, corev1.EndpointAddress{IP: address})
+	}
+	endpoints.Subsets = []corev1.EndpointSubset{subset}
+	endpointsBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), endpoints)
+	if err != nil {
+		return err
+	}
+	configMap := &corev1.ConfigMap{}
+	configMap.APIVersion = "v1"
+	configMap.Kind = "ConfigMap"
+	configMap.Name = "user-manifest-kubernetes-endpoints"
+	configMap.Data = map[string]string{"data": string(endpointsBytes)}
+	configMapBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), configMap)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, configMapBytes, 0644)
+}
+
+func GenerateKubeadminPasswordTargetSecret(password string, fileName string) error {
+	secret := &corev1.Secret{}
+	secret.APIVersion = "v1"
+	secret.Kind = "Secret"
+	secret.Name = "kubeadmin"
+	secret.Namespace = "kube-system"
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	secret.Data = map[string][]byte{"kubeadmin": passwordHash}
+
+	secretBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), secret)
+	if err != nil {
+		return err
+	}
+	configMap := &corev1.ConfigMap{}
+	configMap.APIVersion = "v1"
+	configMap.Kind = "ConfigMap"
+	configMap.Name = "user-manifest-kubeadmin-password"
+	configMap.Data = map[string]string{"data": string(secretBytes)}
+	configMapBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), configMap)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, configMapBytes, 0644)
+}
+
+func GenerateKubeadminPasswordSecret(password string, fileName string) error {
+	secret := &corev1.Secret{}
+	secret.APIVersion = "v1"
+	secret.Kind = "Secret"
+	secret.Name = KubeadminPasswordSecretName
+	secret.Data = map[string][]byte{kubeadminPasswordKey: []byte(password)}
+	secretBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), secret)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, secretBytes, 0644)
+}
+
+// GetKubeadminPassword returns the kubeadmin password stored in the control plane
+// namespace of a cluster
+func GetKubeadminPassword(client kubeclient.Interface, namespace string) (string, error) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(KubeadminPasswordSecretName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	password, ok := secret.Data[kubeadminPasswordKey]
+	if !ok {
+		return "", fmt.Errorf("did not find a password in secret %s", KubeadminPasswordSecretName)
+	}
+	return string(password), nil
+}
+
+// ConsoleURL returns the URL of the web console of a cluster with the given ingress subdomain
+func ConsoleURL(ingressSubdomain string) string {
+	return fmt.Sprintf("https://console-openshift-console.%s", ingressSubdomain)
+}
+
+func GenerateKubeconfigSecret(kubeconfigFile, manifestFilename string) error {
+	secret := &corev1.Secret{}
+	secret.APIVersion = "v1"
+	secret.Kind = "Secret"
+	secret.Name = AdminKubeconfigSecretName
+	kubeconfigBytes, err := ioutil.ReadFile(kubeconfigFile)
+	if err != nil {
+		return err
+	}
+	secret.Data = map[string][]byte{"kubeconfig": kubeconfigBytes}
+	secretBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), secret)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(manifestFilename, secretBytes, 0644)
+}
+
+// GenerateIgnitionServerConfigSecret writes the secret with the worker ignition config that
+// is served by the ignition server of a cluster
+func GenerateIgnitionServerConfigSecret(ignitionFile, manifestFilename string) error {
+	secret := &corev1.Secret{}
+	secret.APIVersion = "v1"
+	secret.Kind = "Secret"
+	secret.Name = "ignition-server-config"
+	ignitionBytes, err := ioutil.ReadFile(ignitionFile)
+	if err != nil {
+		return err
+	}
+	secret.Data = map[string][]byte{"worker.ign": ignitionBytes}
+	secretBytes, err := runtime.Encode(coreCodecs.LegacyCodec(corev1.SchemeGroupVersion), secret)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(manifestFilename, secretBytes, 0644)
+}
+
+// GenerateUserDataSecret writes the user data secret of the worker machineset of a cluster,
+// with an ignition config of the given version that appends the config stored in the given URL
+func GenerateUserDataSecret(namespace, ignitionURL, ignitionVersion, fileName string) error {
+	return generateUserDataSecret(fmt.Sprintf("%s-user-data", namespace), nil, ignitionURL, nil, ignitionVersion, fileName)
+}
+
+// GenerateNodePoolUserDataSecret writes the user data secret of the machineset of a node pool
+// of a cluster, labeled with the cluster name so that it can be removed with the cluster.
+// A non-empty CA bundle is trusted when fetching the config from the ignition URL.
+func GenerateNodePoolUserDataSecret(namespace, pool, ignitionURL string, caBundle []byte, ignitionVersion, fileName string) error {
+	labels := map[string]string{"hypershift.openshift.io/cluster": namespace}
+	return generateUserDataSecret(NodePoolUserDataSecretName(namespace, pool), labels, ignitionURL, caBundle, ignitionVersion, fileName)
+}
+
+// NodePoolUserDataSecretName returns the name of the user data secret of a node pool
+func NodePoolUserDataSecretName(namespace, pool string) string {
+	return fmt.Sprintf("%s-%s-user-data", namespace, pool)
+}
+
+func generateUserDataSecret(name string, labels map[string]string, ignitionURL string, caBundle []byte, ignitionVersion, fileName string) error {
+	secret := &corev1.Secret{}
+	secret.Kind = "Secret"
+	secret.APIVersion = "v1"
+	secret.Name = name
+	secret.Namespace = "openshift-machine-api"
+	secret.Labels = labels
+
+	disableTemplatingValue := []byte(base64.StdEncoding.EncodeToString([]byte("true")))
+	userDataValue, err := ignition.UserData(ignitionURL, caBundle, ignitionVersion)
+	if err != nil {
+		return err
+	}
+
+	secret.Data = map[string][]byte{
+		"disableTemplating": disableTemplatingValue,
+		"userData":          userDataValue,
+	}
+
+	secretBytes, err := json.Marshal(secret)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, secretBytes, 0644)
+}
+
+// GenerateImageRegistrySecret returns a random HTTP secret for the image registry
+func GenerateImageRegistrySecret() string {
+	num := make([]byte, 64)
+	rand.Read(num)
+	return hex.EncodeToString(num)
+}
+
+// GenerateIgnitionServerToken returns a random token for requests to the ignition server
+func GenerateIgnitionServerToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := crand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// GenerateKubeadminPassword returns a random password for the kubeadmin user
+func GenerateKubeadminPassword() (string, error) {
+	const (
+		lowerLetters = "abcdefghijkmnopqrstuvwxyz"
+		upperLetters = "ABCDEFGHIJKLMNPQRSTUVWXYZ"
+		digits       = "23456789"
+		all          = lowerLetters + upperLetters + digits
+		length       = 23
+	)
+	var password string
+	for i := 0; i < length; i++ {
+		n, err := crand.Int(crand.Reader, big.NewInt(int64(len(all))))
+		if err != nil {
+			return "", err
+		}
+		newchar := string(all[n.Int64()])
+		if password == "" {
+			password = newchar
+		}
+		if i < length-1 {
+			n, err = crand.Int(crand.Reader, big.NewInt(int64(len(password)+1)))
+			if err != nil {
+				return "", err
+			}
+			j := n.Int64()
+			password = password[0:j] + newchar + password[j:]
+		}
+	}
+	pw := []rune(password)
+	for _, replace := range []int{5, 11, 17} {
+		pw[replace] = '-'
+	}
+	return string(pw), nil
+}
+
+// GetTargetClusterConfig returns a client config for the admin kubeconfig of the new cluster
+func GetTargetClusterConfig(pkiDir string) (*rest.Config, error) {
+	return clientcmd.BuildConfigFromFlags("", filepath.Join(pkiDir, "admin.kubeconfig"))
+}
+
+// GetTargetClusterConfigFromSecret returns a client config for the admin kubeconfig stored
+// in the control plane namespace of a cluster
+func GetTargetClusterConfigFromSecret(client kubeclient.Interface, namespace string) (*rest.Config, error) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(AdminKubeconfigSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	kubeconfig, ok := secret.Data["kubeconfig"]
+	if !ok {
+		return nil, fmt.Errorf("did not find a kubeconfig in secret %s", AdminKubeconfigSecretName)
+	}
+	return clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+}
+
+func CopyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
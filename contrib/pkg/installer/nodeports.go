@@ -0,0 +1,130 @@
+package installer
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubeclient "k8s.io/client-go/kubernetes"
+)
+
+const (
+	// NodePortMin and NodePortMax are the default node port range of a cluster
+	NodePortMin = 30000
+	NodePortMax = 32767
+)
+
+// UsedNodePorts returns the node ports that are in use on the management cluster: the node
+// ports of its services, and the router node ports of the hosted clusters whose parameters
+// are stored on it. The routers of hosted clusters listen on their workers, which share the
+// load balancers and security groups of the management cluster workers on some providers.
+func UsedNodePorts(client kubeclient.Interface) (sets.Int, error) {
+	used := sets.NewInt()
+	services, err := client.CoreV1().Services(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, svc := range services.Items {
+		for _, port := range svc.Spec.Ports {
+			if port.NodePort != 0 {
+				used.Insert(int(port.NodePort))
+			}
+		}
+	}
+	secrets, err := client.CoreV1().Secrets(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", ClusterParamsSecretName).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range secrets.Items {
+		params, err := GetClusterParams(client, secret.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the parameters of the cluster in namespace %s: %v", secret.Namespace, err)
+		}
+		for _, port := range []string{params.RouterNodePortHTTP, params.RouterNodePortHTTPS} {
+			if value, err := strconv.Atoi(port); err == nil && value != 0 {
+				used.Insert(value)
+			}
+		}
+	}
+	return used, nil
+}
+
+// reservedNodePorts are the router node ports that were returned by RouterNodePorts in this
+// process. They are not in use on the management cluster until the parameters of their
+// cluster are stored, so clusters that are installed concurrently would otherwise get the
+// same node ports.
+var reservedNodePorts = struct {
+	sync.Mutex
+	ports sets.Int
+}{ports: sets.NewInt()}
+
+// RouterNodePorts returns the HTTP and HTTPS node ports of the router of a new cluster. If
+// both are 0, the first free node ports of the management cluster are allocated; otherwise
+// both must be free node ports. The node ports are reserved for the rest of the process.
+func RouterNodePorts(client kubeclient.Interface, httpNodePort, httpsNodePort int) (int, int, error) {
+	reservedNodePorts.Lock()
+	defer reservedNodePorts.Unlock()
+	used, err := UsedNodePorts(client)
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot determine the node ports in use: %v", err)
+	}
+	httpNodePort, httpsNodePort, err = routerNodePorts(used.Union(reservedNodePorts.ports), httpNodePort, httpsNodePort)
+	if err != nil {
+		return 0, 0, err
+	}
+	reservedNodePorts.ports.Insert(httpNodePort, httpsNodePort)
+	return httpNodePort, httpsNodePort, nil
+}
+
+// routerNodePorts allocates the first free router node ports if both are 0, or else
+// verifies that they are free
+func routerNodePorts(used sets.Int, httpNodePort, httpsNodePort int) (int, int, error) {
+	if httpNodePort == 0 && httpsNodePort == 0 {
+		ports, err := freeNodePorts(used, 2)
+		if err != nil {
+			return 0, 0, err
+		}
+		return ports[0], ports[1], nil
+	}
+	if err := validateNodePorts(used, httpNodePort, httpsNodePort); err != nil {
+		return 0, 0, err
+	}
+	return httpNodePort, httpsNodePort, nil
+}
+
+// freeNodePorts returns the first count node ports of the node port range that are not used
+func freeNodePorts(used sets.Int, count int) ([]int, error) {
+	var ports []int
+	for port := NodePortMin; port <= NodePortMax && len(ports) < count; port++ {
+		if !used.Has(port) {
+			ports = append(ports, port)
+		}
+	}
+	if len(ports) < count {
+		return nil, fmt.Errorf("only %d of %d node ports are free", len(ports), count)
+	}
+	return ports, nil
+}
+
+// validateNodePorts verifies that ports are distinct node ports of the node port range that
+// are not used
+func validateNodePorts(used sets.Int, ports ...int) error {
+	seen := sets.NewInt()
+	for _, port := range ports {
+		switch {
+		case port < NodePortMin || port > NodePortMax:
+			return fmt.Errorf("node port %d is not between %d and %d", port, NodePortMin, NodePortMax)
+		case seen.Has(port):
+			return fmt.Errorf("node port %d is specified more than once", port)
+		case used.Has(port):
+			return fmt.Errorf("node port %d is already in use on the management cluster", port)
+		}
+		seen.Insert(port)
+	}
+	return nil
+}
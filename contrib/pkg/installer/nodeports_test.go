@@ -0,0 +1,70 @@
+package installer
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestFreeNodePorts(t *testing.T) {
+	ports, err := freeNodePorts(sets.NewInt(NodePortMin, NodePortMin+2), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{NodePortMin + 1, NodePortMin + 3}; !reflect.DeepEqual(ports, expected) {
+		t.Errorf("expected %v, got %v", expected, ports)
+	}
+
+	used := sets.NewInt()
+	for port := NodePortMin; port < NodePortMax; port++ {
+		used.Insert(port)
+	}
+	if _, err := freeNodePorts(used, 2); err == nil {
+		t.Errorf("expected an error when the node port range is exhausted")
+	}
+}
+
+func TestValidateNodePorts(t *testing.T) {
+	used := sets.NewInt(31080)
+	tests := []struct {
+		name        string
+		ports       []int
+		expectError bool
+	}{
+		{name: "free", ports: []int{31081, 31443}},
+		{name: "used", ports: []int{31080, 31443}, expectError: true},
+		{name: "duplicate", ports: []int{31443, 31443}, expectError: true},
+		{name: "below range", ports: []int{80, 31443}, expectError: true},
+		{name: "above range", ports: []int{31081, 32768}, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := validateNodePorts(used, test.ports...); test.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", test.expectError, err)
+			}
+		})
+	}
+}
+
+func TestRouterNodePorts(t *testing.T) {
+	used := sets.NewInt(NodePortMin)
+	http, https, err := routerNodePorts(used, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if http != NodePortMin+1 || https != NodePortMin+2 {
+		t.Errorf("expected %d and %d, got %d and %d", NodePortMin+1, NodePortMin+2, http, https)
+	}
+	// The node ports of a concurrent install are allocated after the reserved ones
+	used.Insert(http, https)
+	if http, https, err = routerNodePorts(used, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if http != NodePortMin+3 || https != NodePortMin+4 {
+		t.Errorf("expected %d and %d, got %d and %d", NodePortMin+3, NodePortMin+4, http, https)
+	}
+	if _, _, err = routerNodePorts(used, NodePortMin+1, 31443); err == nil {
+		t.Errorf("expected an error for a reserved node port")
+	}
+}
@@ -0,0 +1,114 @@
+package installer
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+const (
+	// ClusterParamsSecretName is the name of the secret in the control plane namespace
+	// that holds the parameters the cluster was installed with
+	ClusterParamsSecretName = "cluster-params"
+
+	clusterParamsKey = "cluster.yaml"
+)
+
+// CreateClusterParamsSecret stores the parameters of a cluster in its namespace, so that
+// its manifests can be rendered again when it is upgraded
+func CreateClusterParamsSecret(client kubeclient.Interface, namespace string, params *api.ClusterParams) error {
+	paramsBytes, err := yaml.Marshal(params)
+	if err != nil {
+		return err
+	}
+	secret := &corev1.Secret{}
+	secret.Name = ClusterParamsSecretName
+	secret.Data = map[string][]byte{clusterParamsKey: paramsBytes}
+	_, err = client.CoreV1().Secrets(namespace).Create(secret)
+	return err
+}
+
+// UpdateClusterParamsSecret replaces the parameters stored for a cluster
+func UpdateClusterParamsSecret(client kubeclient.Interface, namespace string, params *api.ClusterParams) error {
+	paramsBytes, err := yaml.Marshal(params)
+	if err != nil {
+		return err
+	}
+	secret, err := client.CoreV1().Secrets(namespace).Get(ClusterParamsSecretName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	secret.Data = map[string][]byte{clusterParamsKey: paramsBytes}
+	_, err = client.CoreV1().Secrets(namespace).Update(secret)
+	return err
+}
+
+// GetClusterParams returns the parameters stored for a cluster
+func GetClusterParams(client kubeclient.Interface, namespace string) (*api.ClusterParams, error) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(ClusterParamsSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	paramsBytes, ok := secret.Data[clusterParamsKey]
+	if !ok {
+		return nil, fmt.Errorf("did not find cluster parameters in secret %s", ClusterParamsSecretName)
+	}
+	params := api.NewClusterParams()
+	if err := yaml.Unmarshal(paramsBytes, params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// ExportClusterParams returns the parameters of a cluster without the values that are
+// specific to it: its name and the names, addresses, node ports, CIDRs and secrets that the
+// installer allocates or discovers for each cluster. The result is a template for clusters
+// that are installed like it.
+func ExportClusterParams(params *api.ClusterParams) *api.ClusterParams {
+	exported := *params
+	exported.Namespace = ""
+	exported.ExternalAPIDNSName = ""
+	exported.ExternalAPIIPAddress = ""
+	exported.ExternalOpenVPNDNSName = ""
+	exported.ExternalOauthDNSName = ""
+	exported.ExternalIgnitionDNSName = ""
+	exported.ExternalKonnectivityDNSName = ""
+	exported.APINodePort = 0
+	exported.OpenVPNNodePort = ""
+	exported.KonnectivityNodePort = ""
+	exported.RouterNodePortHTTP = ""
+	exported.RouterNodePortHTTPS = ""
+	exported.ServiceCIDR = ""
+	exported.PodCIDR = ""
+	exported.BaseDomain = ""
+	exported.IngressSubdomain = ""
+	exported.OpenShiftAPIClusterIP = ""
+	exported.OpenshiftAPIServerCABundle = ""
+	exported.ImageRegistryHTTPSecret = ""
+	exported.ImageRegistryS3Bucket = ""
+	exported.ImageRegistryS3Region = ""
+	exported.IgnitionServerToken = ""
+	exported.RestartDate = ""
+	exported.DefaultFeatureGates = nil
+	return &exported
+}
+
+// ReadNodePools reads a YAML list of node pools from a file
+func ReadNodePools(fileName string) ([]api.NodePool, error) {
+	poolsBytes, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	pools := []api.NodePool{}
+	if err = yaml.Unmarshal(poolsBytes, &pools); err != nil {
+		return nil, fmt.Errorf("cannot parse node pools in %s: %v", fileName, err)
+	}
+	return pools, nil
+}
@@ -0,0 +1,50 @@
+package installer
+
+import (
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+// CloudProvider creates and removes the cloud resources of a hosted cluster on the
+// platform of the management cluster. The install flow creates the services of the
+// control plane on the management cluster, and a cloud provider exposes them outside
+// of it and runs the workers of the hosted cluster.
+type CloudProvider interface {
+	// EnsureAPIEndpoint ensures that the kube-apiserver, the OAuth server and, when its
+	// node port is not 0, the ignition server are reachable at an endpoint of the cluster
+	EnsureAPIEndpoint(ports APIEndpointPorts) (*Endpoint, error)
+
+	// EnsureIngressEndpoint ensures that the router of the cluster is reachable at a
+	// wildcard endpoint of the cluster's apps domain
+	EnsureIngressEndpoint(httpNodePort, httpsNodePort int) (*Endpoint, error)
+
+	// EnsureVPNEndpoint ensures that the VPN server is reachable at an endpoint of the
+	// cluster. healthCheckNodePort is a TCP node port that tells whether a node is up.
+	EnsureVPNEndpoint(vpnNodePort, healthCheckNodePort int) (*Endpoint, error)
+
+	// EnsureIgnitionStorage stores the worker ignition file fileName and returns the URL
+	// that workers fetch it from
+	EnsureIgnitionStorage(fileName string) (string, error)
+
+	// EnsureWorkerPool writes the manifest of the machines of a node pool to fileName, to be
+	// applied with the manifests of the cluster
+	EnsureWorkerPool(pool api.NodePool, fileName string) error
+
+	// Teardown removes the worker pools and the cloud resources of the cluster
+	Teardown() error
+}
+
+// APIEndpointPorts are the node ports that the API endpoint forwards to
+type APIEndpointPorts struct {
+	API      int
+	OAuth    int
+	Ignition int
+}
+
+// Endpoint is an endpoint of a hosted cluster created by a cloud provider
+type Endpoint struct {
+	// DNSName is the name of the DNS record of the endpoint
+	DNSName string
+
+	// Address is the IP address of the endpoint, if it has a stable address
+	Address string
+}
@@ -0,0 +1,420 @@
+package installer
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/openshift/hypershift-toolkit/pkg/applier"
+	"github.com/openshift/hypershift-toolkit/pkg/render"
+)
+
+var (
+	// ExcludeManifests are the rendered manifests that are not applied, because the
+	// installer creates them directly on the management cluster
+	ExcludeManifests = []string{
+		"kube-apiserver-service.yaml",
+		"openshift-apiserver-service.yaml",
+		"openvpn-server-service.yaml",
+		"v4-0-config-system-branding.yaml",
+		"oauth-server-service.yaml",
+		"ignition-server-service.yaml",
+	}
+	// UpgradeExcludeManifests are the rendered manifests that are not applied when a
+	// cluster is upgraded: the ones the installer creates directly, the completed
+	// bootstrap pod and the secret with randomly generated OAuth session keys
+	UpgradeExcludeManifests = append([]string{
+		"user-manifests-bootstrapper-pod.yaml",
+		"oauth-server-sessionsecret-secret.yaml",
+	}, ExcludeManifests...)
+	coreScheme = runtime.NewScheme()
+	coreCodecs = serializer.NewCodecFactory(coreScheme)
+)
+
+func init() {
+	if err := corev1.AddToScheme(coreScheme); err != nil {
+		panic(err)
+	}
+}
+
+// EnsurePrivilegedSCC allows the service accounts of a namespace that run privileged
+// containers to use the privileged SCC. The default service account of the namespace, which
+// earlier installs allowed to use it, is removed from its users.
+func EnsurePrivilegedSCC(client dynamic.Interface, namespace string, serviceAccounts ...string) error {
+	securityGV, err := schema.ParseGroupVersion("security.openshift.io/v1")
+	if err != nil {
+		return err
+	}
+	sccGVR := securityGV.WithResource("securitycontextconstraints")
+	obj, err := client.Resource(sccGVR).Get("privileged", metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	users, exists, err := unstructured.NestedStringSlice(obj.Object, "users")
+	if err != nil {
+		return err
+	}
+	userSet := sets.NewString()
+	if exists {
+		userSet.Insert(users...)
+	}
+	expected := sets.NewString(userSet.List()...)
+	expected.Delete(serviceAccountUser(namespace, "default"))
+	for _, name := range serviceAccounts {
+		expected.Insert(serviceAccountUser(namespace, name))
+	}
+	if expected.Equal(userSet) {
+		// No need to update anything, the service accounts already have the privileged SCC
+		return nil
+	}
+
+	if err = unstructured.SetNestedStringSlice(obj.Object, expected.List(), "users"); err != nil {
+		return err
+	}
+
+	_, err = client.Resource(sccGVR).Update(obj, metav1.UpdateOptions{})
+	return err
+}
+
+// EnsureControlPlaneSCC allows the privileged VPN pods of a control plane to run, unless
+// skip is set, in which case the privileged SCC is left unchanged
+func EnsureControlPlaneSCC(client dynamic.Interface, namespace string, skip bool) error {
+	serviceAccounts := render.PrivilegedServiceAccounts(true)
+	if skip {
+		log.Infof("Skipping the privileged SCC, the %v service accounts of namespace %s must be allowed to use it", serviceAccounts, namespace)
+		return nil
+	}
+	return EnsurePrivilegedSCC(client, namespace, serviceAccounts...)
+}
+
+// serviceAccountUser returns the user name of a service account
+func serviceAccountUser(namespace, name string) string {
+	return fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name)
+}
+
+func CreatePullSecret(client kubeclient.Interface, namespace, data string) error {
+	secret := &corev1.Secret{}
+	secret.Name = "pull-secret"
+	secret.Data = map[string][]byte{".dockerconfigjson": []byte(data)}
+	secret.Type = corev1.SecretTypeDockerConfigJson
+	_, err := client.CoreV1().Secrets(namespace).Create(secret)
+	if err != nil {
+		return err
+	}
+	retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		sa, err := client.CoreV1().ServiceAccounts(namespace).Get("default", metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: "pull-secret"})
+		_, err = client.CoreV1().ServiceAccounts(namespace).Update(sa)
+		return err
+	})
+	return nil
+}
+
+func CreateKubeAPIServerService(client kubeclient.Interface, namespace string) (int, error) {
+	svc := &corev1.Service{}
+	svc.Name = "kube-apiserver"
+	svc.Spec.Selector = map[string]string{"app": "kube-apiserver"}
+	svc.Spec.Type = corev1.ServiceTypeNodePort
+	svc.Spec.Ports = []corev1.ServicePort{
+		{
+			Port:       6443,
+			Protocol:   corev1.ProtocolTCP,
+			TargetPort: intstr.FromInt(6443),
+		},
+	}
+	svc, err := client.CoreV1().Services(namespace).Create(svc)
+	if err != nil {
+		return 0, err
+	}
+	return int(svc.Spec.Ports[0].NodePort), nil
+}
+
+func CreateVPNServerService(client kubeclient.Interface, namespace string) (int, error) {
+	svc := &corev1.Service{}
+	svc.Name = "openvpn-server"
+	svc.Spec.Selector = map[string]string{"app": "openvpn-server"}
+	svc.Spec.Type = corev1.ServiceTypeNodePort
+	svc.Spec.Ports = []corev1.ServicePort{
+		{
+			Port:       1194,
+			Protocol:   corev1.ProtocolUDP,
+			TargetPort: intstr.FromInt(1194),
+		},
+	}
+	svc, err := client.CoreV1().Services(namespace).Create(svc)
+	if err != nil {
+		return 0, err
+	}
+	return int(svc.Spec.Ports[0].NodePort), nil
+}
+
+func CreateOpenshiftService(client kubeclient.Interface, namespace string) (string, error) {
+	svc := &corev1.Service{}
+	svc.Name = "openshift-apiserver"
+	svc.Spec.Selector = map[string]string{"app": "openshift-apiserver"}
+	svc.Spec.Type = corev1.ServiceTypeClusterIP
+	svc.Spec.Ports = []corev1.ServicePort{
+		{
+			Name:       "https",
+			Port:       443,
+			Protocol:   corev1.ProtocolTCP,
+			TargetPort: intstr.FromInt(8443),
+		},
+	}
+	svc, err := client.CoreV1().Services(namespace).Create(svc)
+	if err != nil {
+		return "", err
+	}
+	return svc.Spec.ClusterIP, nil
+}
+
+func CreateOauthService(client kubeclient.Interface, namespace string) (int, error) {
+	svc := &corev1.Service{}
+	svc.Name = "oauth-openshift"
+	svc.Spec.Selector = map[string]string{"app": "oauth-openshift"}
+	svc.Spec.Type = corev1.ServiceTypeNodePort
+	svc.Spec.Ports = []corev1.ServicePort{
+		{
+			Name:       "https",
+			Port:       443,
+			Protocol:   corev1.ProtocolTCP,
+			TargetPort: intstr.FromInt(6443),
+		},
+	}
+	svc, err := client.CoreV1().Services(namespace).Create(svc)
+	if err != nil {
+		return 0, err
+	}
+	return int(svc.Spec.Ports[0].NodePort), nil
+}
+
+// CreateIgnitionServerService creates a node port service for the ignition server of a
+// cluster and returns its node port
+func CreateIgnitionServerService(client kubeclient.Interface, namespace string) (int, error) {
+	svc := &corev1.Service{}
+	svc.Name = "ignition-server"
+	svc.Spec.Selector = map[string]string{"app": "ignition-server"}
+	svc.Spec.Type = corev1.ServiceTypeNodePort
+	svc.Spec.Ports = []corev1.ServicePort{
+		{
+			Name:       "https",
+			Port:       443,
+			Protocol:   corev1.ProtocolTCP,
+			TargetPort: intstr.FromInt(8443),
+		},
+	}
+	svc, err := client.CoreV1().Services(namespace).Create(svc)
+	if err != nil {
+		return 0, err
+	}
+	return int(svc.Spec.Ports[0].NodePort), nil
+}
+
+// LoadBalancerServiceTimeout is how long EnsureLoadBalancerService waits for the load
+// balancer of a service to be provisioned
+const LoadBalancerServiceTimeout = 10 * time.Minute
+
+// EnsureLoadBalancerService changes the type of a service of a cluster to LoadBalancer with
+// the given annotations, so that the cloud provider of the management cluster provisions a
+// load balancer for it, and returns the hostname, or the IP, of the load balancer once it
+// is provisioned. The node ports of the service are kept.
+func EnsureLoadBalancerService(client kubeclient.Interface, namespace, name string, annotations map[string]string) (string, error) {
+	services := client.CoreV1().Services(namespace)
+	svc, err := services.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		if svc.Annotations == nil {
+			svc.Annotations = map[string]string{}
+		}
+		for key, value := range annotations {
+			svc.Annotations[key] = value
+		}
+		svc.Spec.Type = corev1.ServiceTypeLoadBalancer
+		if _, err = services.Update(svc); err != nil {
+			return "", err
+		}
+	}
+	address := ""
+	err = wait.PollImmediate(5*time.Second, LoadBalancerServiceTimeout, func() (bool, error) {
+		svc, err := services.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if len(ingress.Hostname) > 0 {
+				address = ingress.Hostname
+				return true, nil
+			}
+			if len(ingress.IP) > 0 {
+				address = ingress.IP
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "load balancer of service %s was not provisioned", name)
+	}
+	return address, nil
+}
+
+// EnsurePassthroughRoute ensures that a route of the management cluster with the given host
+// passes TLS connections through to the target port of a service, so that the service is
+// reachable through the routers of the management cluster by the name that clients send
+// with SNI
+func EnsurePassthroughRoute(client dynamic.Interface, namespace, name, host, serviceName string, targetPort int) error {
+	routeGV, err := schema.ParseGroupVersion("route.openshift.io/v1")
+	if err != nil {
+		return err
+	}
+	routes := client.Resource(routeGV.WithResource("routes")).Namespace(namespace)
+	route := &unstructured.Unstructured{}
+	route.SetAPIVersion(routeGV.String())
+	route.SetKind("Route")
+	route.SetName(name)
+	spec := map[string]interface{}{
+		"host": host,
+		"to": map[string]interface{}{
+			"kind": "Service",
+			"name": serviceName,
+		},
+		"port": map[string]interface{}{
+			"targetPort": int64(targetPort),
+		},
+		"tls": map[string]interface{}{
+			"termination":                   "passthrough",
+			"insecureEdgeTerminationPolicy": "None",
+		},
+	}
+	if err = unstructured.SetNestedMap(route.Object, spec, "spec"); err != nil {
+		return err
+	}
+	existing, err := routes.Get(name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = routes.Create(route, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	route.SetResourceVersion(existing.GetResourceVersion())
+	_, err = routes.Update(route, metav1.UpdateOptions{})
+	return err
+}
+
+// RouteHost returns the host of a route of a cluster in the ingress domain of the
+// management cluster, the host that the management cluster's routers would default to
+func RouteHost(name, namespace, ingressDomain string) string {
+	return fmt.Sprintf("%s-%s.%s", name, namespace, ingressDomain)
+}
+
+// CreateBrandingSecret creates the branding secret in fileName directly, because it is too
+// large to be applied. An existing branding secret is updated.
+func CreateBrandingSecret(client kubeclient.Interface, namespace, fileName string) error {
+	objBytes, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	requiredObj, err := runtime.Decode(coreCodecs.UniversalDecoder(corev1.SchemeGroupVersion), objBytes)
+	if err != nil {
+		return err
+	}
+	secret, ok := requiredObj.(*corev1.Secret)
+	if !ok {
+		return fmt.Errorf("object in %s is not a secret", fileName)
+	}
+	existing, err := client.CoreV1().Secrets(namespace).Get(secret.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.CoreV1().Secrets(namespace).Create(secret)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	existing.Data = secret.Data
+	_, err = client.CoreV1().Secrets(namespace).Update(existing)
+	return err
+}
+
+// ApplyOutput receives the objects that applying manifests changes, like the output of
+// kubectl apply
+var ApplyOutput io.Writer = os.Stdout
+
+// ApplyManifests applies the manifests in directory, after moving the ones in exclude to excludedDir
+func ApplyManifests(cfg *rest.Config, namespace, directory string, exclude []string, excludedDir string) error {
+	if err := excludeManifests(directory, exclude, excludedDir); err != nil {
+		return err
+	}
+	backoff := wait.Backoff{
+		Steps:    3,
+		Duration: 10 * time.Second,
+		Factor:   1.0,
+		Jitter:   0.1,
+	}
+	attempt := 0
+	err := retry.OnError(backoff, func(err error) bool { return true }, func() error {
+		attempt++
+		log.Infof("Applying Manifests. Attempt %d/3", attempt)
+		a := applier.NewApplier(cfg, namespace)
+		a.Out = ApplyOutput
+		return a.ApplyFile(directory)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to apply manifests")
+	}
+	return nil
+}
+
+// DiffManifests writes to out the changes that applying the manifests in directory would
+// make, validated by a server-side dry run, after moving the ones in exclude to excludedDir
+func DiffManifests(cfg *rest.Config, namespace, directory string, exclude []string, excludedDir string, out io.Writer) error {
+	if err := excludeManifests(directory, exclude, excludedDir); err != nil {
+		return err
+	}
+	log.Info("Applying Manifests with a server-side dry run")
+	a := applier.NewApplier(cfg, namespace)
+	a.DryRun = true
+	a.Out = ApplyOutput
+	a.Diff = out
+	if err := a.ApplyFile(directory); err != nil {
+		return errors.Wrap(err, "failed to dry run manifests")
+	}
+	return nil
+}
+
+func excludeManifests(directory string, exclude []string, excludedDir string) error {
+	for _, f := range exclude {
+		name := filepath.Join(directory, f)
+		targetName := filepath.Join(excludedDir, f)
+		if err := os.Rename(name, targetName); err != nil {
+			return fmt.Errorf("cannot move %s: %v", name, err)
+		}
+	}
+	return nil
+}
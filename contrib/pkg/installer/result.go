@@ -0,0 +1,159 @@
+package installer
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+)
+
+// Result is the machine readable result of an installer command, written with
+// --output json so that automation does not have to parse log lines. The methods of a nil
+// result do nothing, so that the commands can record results unconditionally.
+type Result struct {
+	// Command is the installer command, such as install or uninstall
+	Command string `json:"command"`
+	// Cluster is the name of the hosted cluster
+	Cluster string `json:"cluster"`
+	// DryRun is true when nothing was changed
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Succeeded is true when the command succeeded. Otherwise Error is the error of the
+	// command, ExitCode its exit code and Retryable whether running it again may succeed.
+	Succeeded bool   `json:"succeeded"`
+	Error     string `json:"error,omitempty"`
+	ExitCode  int    `json:"exitCode"`
+	Retryable bool   `json:"retryable,omitempty"`
+
+	// APIURL and ConsoleURL are the URLs of an installed cluster
+	APIURL     string `json:"apiURL,omitempty"`
+	ConsoleURL string `json:"consoleURL,omitempty"`
+	// KubeconfigSecret and KubeadminPasswordSecret are the secrets of the control plane
+	// namespace with the admin kubeconfig and kubeadmin password of an installed cluster
+	KubeconfigSecret        *SecretReference `json:"kubeconfigSecret,omitempty"`
+	KubeadminPasswordSecret *SecretReference `json:"kubeadminPasswordSecret,omitempty"`
+
+	// CloudResources are the IDs of the cloud resources of an installed cluster, by role
+	CloudResources map[string]string `json:"cloudResources,omitempty"`
+	// RemovedResources are the resources that an uninstall removed, or would remove
+	RemovedResources []Resource `json:"removedResources,omitempty"`
+
+	// Diff is the diff of the manifests of a dry run
+	Diff string `json:"diff,omitempty"`
+	// Diagnostics is the tarball of the diagnostics of a cluster that failed to become ready
+	Diagnostics string `json:"diagnostics,omitempty"`
+
+	// StartTime, DurationSeconds and Phases are the timings of the command
+	StartTime       time.Time `json:"startTime"`
+	DurationSeconds float64   `json:"durationSeconds"`
+	Phases          []Phase   `json:"phases,omitempty"`
+
+	// phaseStart is the start of the last phase while it is not ended
+	phaseStart time.Time
+}
+
+// SecretReference is a secret of the management cluster
+type SecretReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// Resource is a cloud or management cluster resource of a cluster
+type Resource struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// Phase is the duration of a phase of a command
+type Phase struct {
+	Name            string  `json:"name"`
+	DurationSeconds float64 `json:"durationSeconds"`
+}
+
+// NewResult returns the result of a command that starts now
+func NewResult(command, cluster string) *Result {
+	return &Result{Command: command, Cluster: cluster, StartTime: time.Now()}
+}
+
+// StartPhase ends the current phase of the command and starts a new one
+func (r *Result) StartPhase(name string) {
+	if r == nil {
+		return
+	}
+	r.endPhase()
+	r.Phases = append(r.Phases, Phase{Name: name})
+	r.phaseStart = time.Now()
+}
+
+func (r *Result) endPhase() {
+	if !r.phaseStart.IsZero() {
+		r.Phases[len(r.Phases)-1].DurationSeconds = time.Since(r.phaseStart).Seconds()
+		r.phaseStart = time.Time{}
+	}
+}
+
+// SetCloudResources records the IDs of the cloud resources of the cluster, by role
+func (r *Result) SetCloudResources(resources map[string]string) {
+	if r == nil {
+		return
+	}
+	r.CloudResources = map[string]string{}
+	for role, id := range resources {
+		r.CloudResources[role] = id
+	}
+}
+
+// Installed records the URLs of an installed cluster and the secrets of its control plane
+// namespace with its admin credentials
+func (r *Result) Installed(apiURL, consoleURL, namespace string) {
+	if r == nil {
+		return
+	}
+	r.APIURL = apiURL
+	r.ConsoleURL = consoleURL
+	r.KubeconfigSecret = &SecretReference{Namespace: namespace, Name: AdminKubeconfigSecretName}
+	r.KubeadminPasswordSecret = &SecretReference{Namespace: namespace, Name: KubeadminPasswordSecretName}
+}
+
+// SetDiagnostics records the tarball of the diagnostics of the cluster
+func (r *Result) SetDiagnostics(fileName string) {
+	if r == nil {
+		return
+	}
+	r.Diagnostics = fileName
+}
+
+// AddRemovedResource records a resource that was removed, or would be removed
+func (r *Result) AddRemovedResource(kind, id, name string) {
+	if r == nil {
+		return
+	}
+	r.RemovedResources = append(r.RemovedResources, Resource{Kind: kind, ID: id, Name: name})
+}
+
+// Finish records the outcome of the command
+func (r *Result) Finish(err error) {
+	if r == nil {
+		return
+	}
+	r.endPhase()
+	r.DurationSeconds = time.Since(r.StartTime).Seconds()
+	r.Succeeded = err == nil
+	if err != nil {
+		r.Error = err.Error()
+		r.ExitCode = installerrors.ExitCode(err)
+		r.Retryable = installerrors.IsRetryable(err)
+	}
+}
+
+// Write writes the result as an indented JSON document
+func (r *Result) Write(out io.Writer) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(append(b, '\n'))
+	return err
+}
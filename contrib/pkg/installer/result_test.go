@@ -0,0 +1,49 @@
+package installer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+)
+
+func TestResultNil(t *testing.T) {
+	var result *Result
+	result.StartPhase("apply")
+	result.SetCloudResources(map[string]string{"vpc-id": "vpc-1"})
+	result.Installed("https://api.example.com:6443", "https://console.example.com", "cluster")
+	result.AddRemovedResource("Namespace", "cluster", "")
+	result.Finish(nil)
+}
+
+func TestResultWrite(t *testing.T) {
+	result := NewResult("install", "cluster")
+	result.StartPhase("render")
+	result.StartPhase("apply")
+	result.SetCloudResources(map[string]string{"vpc-id": "vpc-1"})
+	result.Installed("https://api.example.com:6443", "https://console.example.com", "cluster")
+	result.Finish(installerrors.Timeout(fmt.Errorf("nodes are not ready"), "failed to wait for nodes ready"))
+
+	out := &bytes.Buffer{}
+	if err := result.Write(out); err != nil {
+		t.Fatal(err)
+	}
+	written := &Result{}
+	if err := json.Unmarshal(out.Bytes(), written); err != nil {
+		t.Fatalf("cannot parse result: %v", err)
+	}
+	if written.Succeeded || written.ExitCode != installerrors.ExitCodeTimeout || !written.Retryable {
+		t.Errorf("unexpected outcome: succeeded %t, exit code %d, retryable %t", written.Succeeded, written.ExitCode, written.Retryable)
+	}
+	if len(written.Phases) != 2 || written.Phases[0].Name != "render" || written.Phases[1].Name != "apply" {
+		t.Errorf("unexpected phases: %v", written.Phases)
+	}
+	if written.KubeconfigSecret == nil || written.KubeconfigSecret.Name != AdminKubeconfigSecretName || written.KubeconfigSecret.Namespace != "cluster" {
+		t.Errorf("unexpected kubeconfig secret: %v", written.KubeconfigSecret)
+	}
+	if written.CloudResources["vpc-id"] != "vpc-1" {
+		t.Errorf("unexpected cloud resources: %v", written.CloudResources)
+	}
+}
@@ -0,0 +1,143 @@
+package installer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// installStateFile is the file in the state directory of an install that records its progress
+const installStateFile = "state.json"
+
+// InstallState records the completed steps of an install and the values they produced in
+// the state directory of the install, so that a failed install can be resumed from its last
+// completed step. The state directory also keeps the PKI and manifests of the cluster.
+type InstallState struct {
+	dir    string
+	dryRun bool
+
+	// Steps are the completed steps of the install
+	Steps []string `json:"steps"`
+
+	// Values are the resource IDs and generated values recorded by completed steps
+	Values map[string]string `json:"values"`
+}
+
+// LoadInstallState reads the state of an install from dir, returning an empty state when
+// the install has not started yet
+func LoadInstallState(dir string) (*InstallState, error) {
+	state := &InstallState{dir: dir, Values: map[string]string{}}
+	b, err := ioutil.ReadFile(filepath.Join(dir, installStateFile))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read install state")
+	}
+	if err := json.Unmarshal(b, state); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse install state %s", filepath.Join(dir, installStateFile))
+	}
+	if state.Values == nil {
+		state.Values = map[string]string{}
+	}
+	return state, nil
+}
+
+// Dir is the state directory of the install
+func (s *InstallState) Dir() string {
+	return s.dir
+}
+
+// SetDryRun keeps the steps and values of the install in memory from now on, so that a
+// dry run of the install does not change the state that a later install resumes from
+func (s *InstallState) SetDryRun() {
+	s.dryRun = true
+}
+
+// Started returns true if any step of the install was completed
+func (s *InstallState) Started() bool {
+	return len(s.Steps) > 0
+}
+
+// Done returns true if step was completed
+func (s *InstallState) Done(step string) bool {
+	for _, completed := range s.Steps {
+		if completed == step {
+			return true
+		}
+	}
+	return false
+}
+
+// Step runs fn unless step was completed, and records step as completed when fn succeeds
+func (s *InstallState) Step(step string, fn func() error) error {
+	if s.Done(step) {
+		log.Debugf("Skipping completed install step %s", step)
+		return nil
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	s.Steps = append(s.Steps, step)
+	return s.save()
+}
+
+// StringValue returns the value recorded for key, or records and returns the value created by fn
+func (s *InstallState) StringValue(key string, fn func() (string, error)) (string, error) {
+	if value, ok := s.Values[key]; ok {
+		return value, nil
+	}
+	value, err := fn()
+	if err != nil {
+		return "", err
+	}
+	s.Values[key] = value
+	return value, s.save()
+}
+
+// IntValue returns the number recorded for key, or records and returns the number created by fn
+func (s *InstallState) IntValue(key string, fn func() (int, error)) (int, error) {
+	value, err := s.StringValue(key, func() (string, error) {
+		n, err := fn()
+		return strconv.Itoa(n), err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+// Record records values that are not needed to resume the install
+func (s *InstallState) Record(values map[string]string) error {
+	for key, value := range values {
+		s.Values[key] = value
+	}
+	return s.save()
+}
+
+// Complete removes the state file of a finished install. The PKI and manifests are kept.
+func (s *InstallState) Complete() error {
+	if err := os.Remove(filepath.Join(s.dir, installStateFile)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "cannot remove install state")
+	}
+	return nil
+}
+
+func (s *InstallState) save() error {
+	if s.dryRun {
+		return nil
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(s.dir, installStateFile), b, 0600); err != nil {
+		return errors.Wrap(err, "cannot write install state")
+	}
+	return nil
+}
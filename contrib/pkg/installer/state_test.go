@@ -0,0 +1,93 @@
+package installer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestInstallStateResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	state, err := LoadInstallState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Started() {
+		t.Fatalf("expected a new install not to be started")
+	}
+	runs := 0
+	step := func() error {
+		runs++
+		return nil
+	}
+	if err := state.Step("namespace", step); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.Step("pull-secret", func() error { return fmt.Errorf("failed") }); err == nil {
+		t.Fatalf("expected the error of a failed step")
+	}
+	port, err := state.IntValue("api-node-port", func() (int, error) { return 30443, nil })
+	if err != nil || port != 30443 {
+		t.Fatalf("unexpected value %d: %v", port, err)
+	}
+
+	resumed, err := LoadInstallState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resumed.Started() || !resumed.Done("namespace") || resumed.Done("pull-secret") {
+		t.Errorf("unexpected completed steps %v", resumed.Steps)
+	}
+	if err := resumed.Step("namespace", step); err != nil || runs != 1 {
+		t.Errorf("expected a completed step not to run again, ran %d times: %v", runs, err)
+	}
+	port, err = resumed.IntValue("api-node-port", func() (int, error) { return 0, fmt.Errorf("service exists") })
+	if err != nil || port != 30443 {
+		t.Errorf("expected the recorded value, got %d: %v", port, err)
+	}
+
+	if err := resumed.Complete(); err != nil {
+		t.Fatal(err)
+	}
+	if state, err := LoadInstallState(dir); err != nil || state.Started() {
+		t.Errorf("expected a completed install to leave no state: %v", err)
+	}
+}
+
+func TestInstallStateDryRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	state, err := LoadInstallState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := state.Step("namespace", func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	state.SetDryRun()
+	if err := state.Step("pull-secret", func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	port, err := state.IntValue("api-node-port", func() (int, error) { return 30443, nil })
+	if err != nil || port != 30443 || !state.Done("pull-secret") {
+		t.Fatalf("expected a dry run to keep its values in memory, got %d: %v", port, err)
+	}
+
+	resumed, err := LoadInstallState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resumed.Done("namespace") || resumed.Done("pull-secret") || len(resumed.Values) != 0 {
+		t.Errorf("expected a dry run not to change the state, got %v %v", resumed.Steps, resumed.Values)
+	}
+}
@@ -0,0 +1,205 @@
+package installer
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	configapi "github.com/openshift/api/config/v1"
+	configclient "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
+)
+
+// certExpiryWarning is how long before their expiry certificates are reported as expiring
+const certExpiryWarning = 30 * 24 * time.Hour
+
+// StatusRow is the status of a component of a hosted cluster
+type StatusRow struct {
+	Component string
+	Status    string
+	Message   string
+}
+
+// GetClusterStatus returns the status of the control plane in namespace and of the
+// cluster it hosts, which is accessed with the admin kubeconfig of the namespace
+func GetClusterStatus(client kubeclient.Interface, namespace string) ([]StatusRow, error) {
+	if _, err := client.CoreV1().Namespaces().Get(namespace, metav1.GetOptions{}); err != nil {
+		return nil, err
+	}
+	var rows []StatusRow
+	deployments, err := client.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list control plane deployments: %v", err)
+	}
+	for _, d := range deployments.Items {
+		replicas := int32(1)
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		status := "Available"
+		if d.Status.AvailableReplicas < replicas {
+			status = "Unavailable"
+		}
+		rows = append(rows, StatusRow{
+			Component: "deployment/" + d.Name,
+			Status:    status,
+			Message:   fmt.Sprintf("%d/%d replicas available", d.Status.AvailableReplicas, replicas),
+		})
+	}
+	rows = append(rows, targetClusterStatus(client, namespace)...)
+
+	secrets, err := client.CoreV1().Secrets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list control plane secrets: %v", err)
+	}
+	now := time.Now()
+	for i := range secrets.Items {
+		if row, ok := certificateStatus(&secrets.Items[i], now); ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// targetClusterStatus returns the availability of the API of the hosted cluster and,
+// when it is available, the status of its nodes and cluster operators
+func targetClusterStatus(client kubeclient.Interface, namespace string) []StatusRow {
+	cfg, err := GetTargetClusterConfigFromSecret(client, namespace)
+	if err != nil {
+		return []StatusRow{{Component: "api", Status: "Unknown", Message: fmt.Sprintf("cannot read admin kubeconfig: %v", err)}}
+	}
+	targetClient, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return []StatusRow{{Component: "api", Status: "Unknown", Message: err.Error()}}
+	}
+	if err := targetClient.Discovery().RESTClient().Get().AbsPath("/healthz").Do().Error(); err != nil {
+		return []StatusRow{{Component: "api", Status: "Unavailable", Message: err.Error()}}
+	}
+	rows := []StatusRow{{Component: "api", Status: "Available", Message: cfg.Host}}
+
+	nodes, err := targetClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		rows = append(rows, StatusRow{Component: "nodes", Status: "Unknown", Message: err.Error()})
+	} else {
+		rows = append(rows, nodesStatus(nodes.Items))
+	}
+
+	operatorClient, err := configclient.NewForConfig(cfg)
+	if err != nil {
+		return append(rows, StatusRow{Component: "clusteroperators", Status: "Unknown", Message: err.Error()})
+	}
+	operators, err := operatorClient.ClusterOperators().List(metav1.ListOptions{})
+	if err != nil {
+		return append(rows, StatusRow{Component: "clusteroperators", Status: "Unknown", Message: err.Error()})
+	}
+	for _, co := range operators.Items {
+		rows = append(rows, clusterOperatorStatus(co))
+	}
+	return rows
+}
+
+func nodesStatus(nodes []corev1.Node) StatusRow {
+	var notReady []string
+	for _, node := range nodes {
+		ready := false
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == corev1.NodeReady && cond.Status == corev1.ConditionTrue {
+				ready = true
+			}
+		}
+		if !ready {
+			notReady = append(notReady, node.Name)
+		}
+	}
+	row := StatusRow{
+		Component: "nodes",
+		Status:    "Ready",
+		Message:   fmt.Sprintf("%d/%d ready", len(nodes)-len(notReady), len(nodes)),
+	}
+	if len(nodes) == 0 || len(notReady) > 0 {
+		row.Status = "NotReady"
+	}
+	if len(notReady) > 0 {
+		sort.Strings(notReady)
+		row.Message += ", not ready: " + strings.Join(notReady, ", ")
+	}
+	return row
+}
+
+func clusterOperatorStatus(co configapi.ClusterOperator) StatusRow {
+	row := StatusRow{Component: "clusteroperator/" + co.Name, Status: "Unavailable"}
+	var messages []string
+	for _, cond := range co.Status.Conditions {
+		switch {
+		case cond.Type == configapi.OperatorAvailable && cond.Status == configapi.ConditionTrue:
+			row.Status = "Available"
+		case cond.Type == configapi.OperatorAvailable && len(cond.Message) > 0:
+			messages = append(messages, cond.Message)
+		case cond.Type == configapi.OperatorDegraded && cond.Status == configapi.ConditionTrue:
+			messages = append(messages, "Degraded: "+cond.Message)
+		case cond.Type == configapi.OperatorProgressing && cond.Status == configapi.ConditionTrue:
+			messages = append(messages, "Progressing: "+cond.Message)
+		}
+	}
+	row.Message = strings.Join(messages, "; ")
+	return row
+}
+
+// certificateStatus returns the expiry of the certificate of secret that expires first,
+// and false if the secret has no certificates
+func certificateStatus(secret *corev1.Secret, now time.Time) (StatusRow, bool) {
+	var first *x509.Certificate
+	for key, value := range secret.Data {
+		if !strings.HasSuffix(key, ".crt") {
+			continue
+		}
+		for rest := value; ; {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				continue
+			}
+			if first == nil || cert.NotAfter.Before(first.NotAfter) {
+				first = cert
+			}
+		}
+	}
+	if first == nil {
+		return StatusRow{}, false
+	}
+	row := StatusRow{Component: "certificate/" + secret.Name, Status: "Valid"}
+	remaining := first.NotAfter.Sub(now)
+	switch {
+	case remaining <= 0:
+		row.Status = "Expired"
+	case remaining < certExpiryWarning:
+		row.Status = "Expiring"
+	}
+	row.Message = fmt.Sprintf("%s expires %s (%dd)", first.Subject.CommonName, first.NotAfter.UTC().Format(time.RFC3339), int(remaining.Hours()/24))
+	return row, true
+}
+
+// PrintClusterStatus writes the status of a cluster as a table
+func PrintClusterStatus(out io.Writer, rows []StatusRow) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "COMPONENT\tSTATUS\tMESSAGE")
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", row.Component, row.Status, row.Message)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,78 @@
+package installer
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func testCertificate(t *testing.T, cn string, notAfter time.Time) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestCertificateStatus(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	secret := &corev1.Secret{}
+	secret.Name = "kube-apiserver"
+	if _, ok := certificateStatus(secret, now); ok {
+		t.Errorf("expected no status for a secret without certificates")
+	}
+
+	secret.Data = map[string][]byte{
+		"server.crt": testCertificate(t, "server", now.Add(300*24*time.Hour)),
+		"server.key": []byte("not a certificate"),
+		"ca.crt":     append(testCertificate(t, "root-ca", now.Add(3000*24*time.Hour)), testCertificate(t, "kubelet", now.Add(10*24*time.Hour))...),
+	}
+	row, ok := certificateStatus(secret, now)
+	if !ok || row.Status != "Expiring" || row.Component != "certificate/kube-apiserver" {
+		t.Fatalf("unexpected status %#v", row)
+	}
+	if expected := "kubelet expires 2020-01-11T00:00:00Z (10d)"; row.Message != expected {
+		t.Errorf("expected message %q, got %q", expected, row.Message)
+	}
+
+	secret.Data = map[string][]byte{"server.crt": testCertificate(t, "expired", now.Add(-time.Hour))}
+	if row, _ := certificateStatus(secret, now); row.Status != "Expired" {
+		t.Errorf("expected an expired certificate, got %#v", row)
+	}
+}
+
+func TestNodesStatus(t *testing.T) {
+	node := func(name string, ready corev1.ConditionStatus) corev1.Node {
+		n := corev1.Node{}
+		n.Name = name
+		n.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}
+		return n
+	}
+	if row := nodesStatus(nil); row.Status != "NotReady" {
+		t.Errorf("expected a cluster without nodes not to be ready, got %#v", row)
+	}
+	row := nodesStatus([]corev1.Node{node("b", corev1.ConditionFalse), node("a", corev1.ConditionTrue)})
+	if row.Status != "NotReady" || row.Message != "1/2 ready, not ready: b" {
+		t.Errorf("unexpected status %#v", row)
+	}
+	if row := nodesStatus([]corev1.Node{node("a", corev1.ConditionTrue)}); row.Status != "Ready" {
+		t.Errorf("unexpected status %#v", row)
+	}
+}
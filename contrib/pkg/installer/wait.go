@@ -0,0 +1,262 @@
+package installer
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubeclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	configapi "github.com/openshift/api/config/v1"
+	configclient "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
+)
+
+// The phases of the waits for a cluster, which the timeouts of a WaitConfig are set for
+const (
+	WaitPhaseAPIEndpoint      = "api"
+	WaitPhaseBootstrapPod     = "bootstrap"
+	WaitPhaseNodesReady       = "nodes"
+	WaitPhaseClusterOperators = "operators"
+	WaitPhaseRollout          = "rollout"
+)
+
+// defaultWaitTimeouts are the timeouts of the wait phases unless they are configured
+var defaultWaitTimeouts = map[string]time.Duration{
+	WaitPhaseAPIEndpoint:      10 * time.Minute,
+	WaitPhaseBootstrapPod:     5 * time.Minute,
+	WaitPhaseNodesReady:       10 * time.Minute,
+	WaitPhaseClusterOperators: 15 * time.Minute,
+	WaitPhaseRollout:          10 * time.Minute,
+}
+
+const defaultWaitInterval = 10 * time.Second
+
+// WaitConfig configures how long the installer waits for a cluster to become ready. The
+// zero value waits with the default timeouts.
+type WaitConfig struct {
+	// Timeout is the timeout of every phase that has no timeout in PhaseTimeouts
+	Timeout time.Duration
+	// PhaseTimeouts are the timeouts of the phases, by WaitPhase* name
+	PhaseTimeouts map[string]time.Duration
+	// Interval is the time between checks of the readiness of the cluster
+	Interval time.Duration
+}
+
+// ParsePhaseTimeouts parses the timeouts of phases, such as those of a --phase-timeout
+// flag, by WaitPhase* name
+func ParsePhaseTimeouts(values map[string]string) (map[string]time.Duration, error) {
+	timeouts := map[string]time.Duration{}
+	for phase, value := range values {
+		if _, ok := defaultWaitTimeouts[phase]; !ok {
+			return nil, fmt.Errorf("unknown wait phase %q, the phases are %s", phase, strings.Join(waitPhases(), ", "))
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout of wait phase %s: %v", phase, err)
+		}
+		if timeout <= 0 {
+			return nil, fmt.Errorf("the timeout of wait phase %s must be positive, got %s", phase, value)
+		}
+		timeouts[phase] = timeout
+	}
+	return timeouts, nil
+}
+
+func waitPhases() []string {
+	var phases []string
+	for phase := range defaultWaitTimeouts {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+	return phases
+}
+
+// Validate verifies that the timeouts and interval are not negative
+func (c WaitConfig) Validate() error {
+	if c.Timeout < 0 {
+		return fmt.Errorf("the wait timeout cannot be negative, got %s", c.Timeout)
+	}
+	if c.Interval < 0 {
+		return fmt.Errorf("the wait interval cannot be negative, got %s", c.Interval)
+	}
+	return nil
+}
+
+// PhaseTimeout returns the timeout of a phase
+func (c WaitConfig) PhaseTimeout(phase string) time.Duration {
+	if timeout, ok := c.PhaseTimeouts[phase]; ok {
+		return timeout
+	}
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return defaultWaitTimeouts[phase]
+}
+
+func (c WaitConfig) interval() time.Duration {
+	if c.Interval > 0 {
+		return c.Interval
+	}
+	return defaultWaitInterval
+}
+
+// poll checks condition every interval of config until it is met, returns an error or the
+// timeout of phase passes. Condition returns what is not ready yet, which the error of a
+// timeout reports.
+func (c WaitConfig) poll(phase, what string, condition func() (bool, string, error)) error {
+	timeout := c.PhaseTimeout(phase)
+	notReady := ""
+	err := wait.PollImmediate(c.interval(), timeout, func() (bool, error) {
+		done, message, err := condition()
+		notReady = message
+		return done, err
+	})
+	if err == wait.ErrWaitTimeout {
+		if len(notReady) > 0 {
+			return fmt.Errorf("timed out after %s waiting for %s: %s", timeout, what, notReady)
+		}
+		return fmt.Errorf("timed out after %s waiting for %s", timeout, what)
+	}
+	return err
+}
+
+func WaitForAPIEndpoint(pkiDir, apiDNSName string, apiPort int, config WaitConfig) error {
+	caCertBytes, err := ioutil.ReadFile(filepath.Join(pkiDir, "root-ca.crt"))
+	if err != nil {
+		return fmt.Errorf("cannot read CA file: %v", err)
+	}
+	caCertPool := x509.NewCertPool()
+	caCertPool.AppendCertsFromPEM(caCertBytes)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs: caCertPool,
+			},
+		},
+		Timeout: 3 * time.Second,
+	}
+
+	url := fmt.Sprintf("https://%s:%d/healthz", apiDNSName, apiPort)
+
+	return config.poll(WaitPhaseAPIEndpoint, "the API endpoint", func() (bool, string, error) {
+		resp, err := client.Get(url)
+		if err != nil {
+			return false, err.Error(), nil
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK, fmt.Sprintf("%s returned %s", url, resp.Status), nil
+	})
+}
+
+func WaitForNodesReady(client kubeclient.Interface, expectedCount int, config WaitConfig) error {
+	return config.poll(WaitPhaseNodesReady, "nodes to be ready", func() (bool, string, error) {
+		nodes, err := client.CoreV1().Nodes().List(metav1.ListOptions{})
+		if err != nil {
+			return false, fmt.Sprintf("cannot list nodes: %v", err), nil
+		}
+		return nodesReady(nodes.Items, expectedCount)
+	})
+}
+
+// nodesReady returns true if expectedCount nodes exist and all of them are ready, or else
+// the nodes that are missing or not ready
+func nodesReady(nodes []corev1.Node, expectedCount int) (bool, string, error) {
+	status := nodesStatus(nodes)
+	if len(nodes) < expectedCount {
+		return false, fmt.Sprintf("%d of %d nodes registered, %s", len(nodes), expectedCount, status.Message), nil
+	}
+	return status.Status == "Ready", status.Message, nil
+}
+
+func WaitForBootstrapPod(client kubeclient.Interface, namespace string, config WaitConfig) error {
+	return config.poll(WaitPhaseBootstrapPod, "the bootstrap pod to complete", func() (bool, string, error) {
+		pod, err := client.CoreV1().Pods(namespace).Get(bootstrapPodName, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Sprintf("cannot get pod %s: %v", bootstrapPodName, err), nil
+		}
+		return pod.Status.Phase == corev1.PodSucceeded, fmt.Sprintf("pod %s is %s", bootstrapPodName, pod.Status.Phase), nil
+	})
+}
+
+func WaitForClusterOperators(cfg *rest.Config, config WaitConfig) error {
+	client, err := configclient.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	return config.poll(WaitPhaseClusterOperators, "cluster operators to be available", func() (bool, string, error) {
+		operators, err := client.ClusterOperators().List(metav1.ListOptions{})
+		if err != nil {
+			return false, fmt.Sprintf("cannot list cluster operators: %v", err), nil
+		}
+		return clusterOperatorsAvailable(operators.Items)
+	})
+}
+
+// clusterOperatorsAvailable returns true if all cluster operators are available, or else
+// the operators that are not available with their conditions
+func clusterOperatorsAvailable(operators []configapi.ClusterOperator) (bool, string, error) {
+	var unavailable []string
+	for _, co := range operators {
+		row := clusterOperatorStatus(co)
+		if row.Status == "Available" {
+			continue
+		}
+		if len(row.Message) > 0 {
+			unavailable = append(unavailable, fmt.Sprintf("%s (%s)", co.Name, row.Message))
+		} else {
+			unavailable = append(unavailable, co.Name)
+		}
+	}
+	if len(unavailable) > 0 {
+		sort.Strings(unavailable)
+		return false, "not available: " + strings.Join(unavailable, ", "), nil
+	}
+	return true, "", nil
+}
+
+// WaitForDeploymentsRolledOut waits until every deployment in namespace runs the latest
+// revision of its pod template with all of its replicas available
+func WaitForDeploymentsRolledOut(client kubeclient.Interface, namespace string, config WaitConfig) error {
+	return config.poll(WaitPhaseRollout, "the control plane to roll out", func() (bool, string, error) {
+		deployments, err := client.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			return false, fmt.Sprintf("cannot list deployments: %v", err), nil
+		}
+		return deploymentsRolledOut(deployments.Items)
+	})
+}
+
+// deploymentsRolledOut returns true if all deployments are rolled out, or else the
+// deployments that are not with their available replicas
+func deploymentsRolledOut(deployments []appsv1.Deployment) (bool, string, error) {
+	var pending []string
+	for _, d := range deployments {
+		replicas := int32(1)
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		if d.Status.ObservedGeneration < d.Generation ||
+			d.Status.UpdatedReplicas != replicas ||
+			d.Status.Replicas != replicas ||
+			d.Status.AvailableReplicas != replicas {
+			pending = append(pending, fmt.Sprintf("%s (%d/%d updated, %d/%d available)", d.Name, d.Status.UpdatedReplicas, replicas, d.Status.AvailableReplicas, replicas))
+		}
+	}
+	if len(pending) > 0 {
+		sort.Strings(pending)
+		return false, "not rolled out: " + strings.Join(pending, ", "), nil
+	}
+	return true, "", nil
+}
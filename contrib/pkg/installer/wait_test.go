@@ -0,0 +1,110 @@
+package installer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	configapi "github.com/openshift/api/config/v1"
+)
+
+func TestWaitConfigPhaseTimeout(t *testing.T) {
+	config := WaitConfig{}
+	if timeout := config.PhaseTimeout(WaitPhaseClusterOperators); timeout != 15*time.Minute {
+		t.Errorf("expected the default timeout of 15m, got %s", timeout)
+	}
+	config.Timeout = 30 * time.Minute
+	config.PhaseTimeouts = map[string]time.Duration{WaitPhaseNodesReady: time.Hour}
+	if timeout := config.PhaseTimeout(WaitPhaseClusterOperators); timeout != 30*time.Minute {
+		t.Errorf("expected the wait timeout of 30m, got %s", timeout)
+	}
+	if timeout := config.PhaseTimeout(WaitPhaseNodesReady); timeout != time.Hour {
+		t.Errorf("expected the phase timeout of 1h, got %s", timeout)
+	}
+}
+
+func TestParsePhaseTimeouts(t *testing.T) {
+	timeouts, err := ParsePhaseTimeouts(map[string]string{"operators": "45m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeouts[WaitPhaseClusterOperators] != 45*time.Minute {
+		t.Errorf("unexpected timeouts: %v", timeouts)
+	}
+	for _, values := range []map[string]string{{"etcd": "10m"}, {"nodes": "ten minutes"}, {"nodes": "0s"}} {
+		if _, err := ParsePhaseTimeouts(values); err == nil {
+			t.Errorf("expected an error for %v", values)
+		}
+	}
+}
+
+func TestWaitConfigPollTimeout(t *testing.T) {
+	config := WaitConfig{Timeout: 20 * time.Millisecond, Interval: 5 * time.Millisecond}
+	err := config.poll(WaitPhaseNodesReady, "nodes to be ready", func() (bool, string, error) {
+		return false, "not ready: worker-1", nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "not ready: worker-1") {
+		t.Errorf("expected a timeout that reports the nodes that are not ready, got %v", err)
+	}
+}
+
+func TestNodesReady(t *testing.T) {
+	node := func(name string, status corev1.ConditionStatus) corev1.Node {
+		n := corev1.Node{}
+		n.Name = name
+		n.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}}
+		return n
+	}
+	nodes := []corev1.Node{node("worker-1", corev1.ConditionTrue), node("worker-2", corev1.ConditionFalse)}
+	if ready, message, _ := nodesReady(nodes, 3); ready || !strings.Contains(message, "2 of 3 nodes registered") || !strings.Contains(message, "worker-2") {
+		t.Errorf("unexpected readiness %t: %s", ready, message)
+	}
+	if ready, message, _ := nodesReady(nodes, 2); ready || !strings.Contains(message, "not ready: worker-2") {
+		t.Errorf("unexpected readiness %t: %s", ready, message)
+	}
+	nodes[1] = node("worker-2", corev1.ConditionTrue)
+	if ready, message, _ := nodesReady(nodes, 2); !ready {
+		t.Errorf("expected the nodes to be ready: %s", message)
+	}
+}
+
+func TestClusterOperatorsAvailable(t *testing.T) {
+	operator := func(name string, status configapi.ConditionStatus, message string) configapi.ClusterOperator {
+		co := configapi.ClusterOperator{}
+		co.Name = name
+		co.Status.Conditions = []configapi.ClusterOperatorStatusCondition{{Type: configapi.OperatorAvailable, Status: status, Message: message}}
+		return co
+	}
+	operators := []configapi.ClusterOperator{
+		operator("console", configapi.ConditionFalse, "route not admitted"),
+		operator("authentication", configapi.ConditionTrue, ""),
+		{},
+	}
+	operators[2].Name = "ingress"
+	available, message, _ := clusterOperatorsAvailable(operators)
+	if available || message != "not available: console (route not admitted), ingress" {
+		t.Errorf("unexpected availability %t: %s", available, message)
+	}
+	if available, message, _ := clusterOperatorsAvailable(operators[1:2]); !available {
+		t.Errorf("expected the operators to be available: %s", message)
+	}
+}
+
+func TestDeploymentsRolledOut(t *testing.T) {
+	replicas := int32(2)
+	d := appsv1.Deployment{}
+	d.Name = "kube-apiserver"
+	d.Spec.Replicas = &replicas
+	d.Status = appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 2}
+	rolledOut, message, _ := deploymentsRolledOut([]appsv1.Deployment{d})
+	if rolledOut || message != "not rolled out: kube-apiserver (1/2 updated, 2/2 available)" {
+		t.Errorf("unexpected rollout %t: %s", rolledOut, message)
+	}
+	d.Status.UpdatedReplicas = 2
+	if rolledOut, message, _ := deploymentsRolledOut([]appsv1.Deployment{d}); !rolledOut {
+		t.Errorf("expected the deployment to be rolled out: %s", message)
+	}
+}
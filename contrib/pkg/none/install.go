@@ -0,0 +1,434 @@
+package none
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	gocidr "github.com/apparentlymart/go-cidr/cidr"
+	log "github.com/sirupsen/logrus"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/ignition"
+	"github.com/openshift/hypershift-toolkit/pkg/pki"
+	"github.com/openshift/hypershift-toolkit/pkg/render"
+)
+
+const (
+	nodePortMin = 30000
+	nodePortMax = 32767
+
+	defaultControlPlaneOperatorImage = "registry.svc.ci.openshift.org/hypershift-toolkit/hypershift-4.4:control-plane-operator"
+)
+
+// excludeManifests are the rendered manifests that are not applied. Unlike on cloud
+// providers, the node port services are applied with the static node ports they are
+// rendered with.
+var excludeManifests = []string{
+	"openshift-apiserver-service.yaml",
+	"v4-0-config-system-branding.yaml",
+}
+
+// Config is the configuration of a cluster on a management cluster without a cloud provider
+type Config struct {
+	// BaseDomain is the parent domain of the cluster's domain
+	BaseDomain string
+
+	// Addresses are the addresses of the management cluster nodes that the API and VPN
+	// records resolve to. Defaults to the internal IPs of the management cluster workers.
+	Addresses []string
+
+	// NodePortBase is the first of the four consecutive node ports of the API, OAuth,
+	// ignition and VPN services of the cluster, which must be unique on the management
+	// cluster
+	NodePortBase int
+
+	// WorkerCount is the number of workers that the administrator boots
+	WorkerCount int
+
+	// OutputDir is the directory that the PKI, manifests and worker ignition config are
+	// written to
+	OutputDir string
+
+	// SkipPrivilegedSCC leaves the privileged SCC of the management cluster unchanged
+	SkipPrivilegedSCC bool
+}
+
+// nodePorts returns the node ports of the API, OAuth, ignition and VPN services
+func (c *Config) nodePorts() (int, int, int, int, error) {
+	if c.NodePortBase < nodePortMin || c.NodePortBase+3 > nodePortMax {
+		return 0, 0, 0, 0, fmt.Errorf("the node port base must be between %d and %d", nodePortMin, nodePortMax-3)
+	}
+	return c.NodePortBase, c.NodePortBase + 1, c.NodePortBase + 2, c.NodePortBase + 3, nil
+}
+
+// InstallCluster installs a new cluster on a management cluster without calling any cloud
+// API. The services of the control plane are exposed on static node ports of the
+// management cluster nodes, workers boot with the ignition config written to the output
+// directory, and the DNS records that the administrator must create are written to out.
+func InstallCluster(name, releaseImage, dhParamsFile string, config *Config, waitForReady bool, out io.Writer) error {
+	if len(config.BaseDomain) == 0 {
+		return installerrors.Precondition(nil, "a base domain is required")
+	}
+	apiNodePort, oauthNodePort, ignitionNodePort, vpnNodePort, err := config.nodePorts()
+	if err != nil {
+		return installerrors.Precondition(err, "invalid node ports")
+	}
+
+	// First, ensure that we can access the host cluster
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot obtain dynamic client")
+	}
+	// Extract config information from management cluster
+	sshKey, err := installer.GetSSHPublicKey(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to fetch an SSH public key from existing cluster")
+	}
+	log.Debugf("The SSH public key is: %s", string(sshKey))
+
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+
+	if releaseImage == "" {
+		releaseImage, err = installer.GetReleaseImage(dynamicClient)
+		if err != nil {
+			return installerrors.Precondition(err, "failed to obtain release image from host cluster")
+		}
+	}
+
+	pullSecret, err := installer.GetPullSecret(client)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a pull secret from cluster")
+	}
+	log.Debugf("The pull secret is: %v", pullSecret)
+
+	ignitionVersion, err := installer.GetIgnitionVersion(client)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain the ignition version of the management cluster workers")
+	}
+	log.Debugf("The ignition version of the workers is: %s", ignitionVersion)
+
+	serviceCIDR, podCIDR, err := installer.GetNetworkInfo(dynamicClient)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain network info for cluster")
+	}
+
+	addresses := config.Addresses
+	if len(addresses) == 0 {
+		if addresses, err = getWorkerNodeIPs(client); err != nil {
+			return installerrors.Precondition(err, "cannot get worker node addresses")
+		}
+	}
+	log.Infof("Using management node addresses: %v", addresses)
+
+	// Start creating resources on management cluster
+	_, err = client.CoreV1().Namespaces().Get(name, metav1.GetOptions{})
+	if err == nil {
+		return installerrors.Precondition(nil, "target namespace %s already exists on management cluster", name)
+	}
+	if !errors.IsNotFound(err) {
+		return installerrors.Precondition(err, "unexpected error getting namespaces from management cluster")
+	}
+	log.Infof("Creating namespace %s", name)
+	ns := &corev1.Namespace{}
+	ns.Name = name
+	_, err = client.CoreV1().Namespaces().Create(ns)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create namespace %s", name)
+	}
+
+	// Ensure that the VPN pods can run privileged
+	if err = installer.EnsureControlPlaneSCC(dynamicClient, name, config.SkipPrivilegedSCC); err != nil {
+		return installerrors.Apply(err, "failed to ensure privileged SCC for the new namespace")
+	}
+
+	// Create pull secret
+	log.Infof("Creating pull secret")
+	if err := installer.CreatePullSecret(client, name, pullSecret); err != nil {
+		return installerrors.Apply(err, "failed to create pull secret")
+	}
+
+	log.Infof("Creating Openshift API service")
+	openshiftClusterIP, err := installer.CreateOpenshiftService(client, name)
+	if err != nil {
+		return installerrors.Apply(err, "failed to create openshift server service")
+	}
+	log.Infof("Created Openshift API service with cluster IP: %s", openshiftClusterIP)
+
+	provider := &noneProvider{
+		clusterName: name,
+		baseDomain:  config.BaseDomain,
+		addresses:   addresses,
+	}
+	apiEndpoint, err := provider.EnsureAPIEndpoint(installer.APIEndpointPorts{
+		API:      apiNodePort,
+		OAuth:    oauthNodePort,
+		Ignition: ignitionNodePort,
+	})
+	if err != nil {
+		return err
+	}
+	routerEndpoint, err := provider.EnsureIngressEndpoint(0, 0)
+	if err != nil {
+		return err
+	}
+	vpnEndpoint, err := provider.EnsureVPNEndpoint(vpnNodePort, apiNodePort)
+	if err != nil {
+		return err
+	}
+	apiDNSName := apiEndpoint.DNSName
+
+	_, serviceCIDRNet, err := net.ParseCIDR(serviceCIDR)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot parse service CIDR %s", serviceCIDR)
+	}
+
+	_, podCIDRNet, err := net.ParseCIDR(podCIDR)
+	if err != nil {
+		return installerrors.Precondition(err, "cannot parse pod CIDR %s", podCIDR)
+	}
+
+	serviceCIDRPrefixLen, _ := serviceCIDRNet.Mask.Size()
+	clusterServiceCIDR, exceedsMax := gocidr.NextSubnet(serviceCIDRNet, serviceCIDRPrefixLen)
+	if exceedsMax {
+		return installerrors.Precondition(nil, "cluster service CIDR exceeds max address space")
+	}
+
+	podCIDRPrefixLen, _ := podCIDRNet.Mask.Size()
+	clusterPodCIDR, exceedsMax := gocidr.NextSubnet(podCIDRNet, podCIDRPrefixLen)
+	if exceedsMax {
+		return installerrors.Precondition(nil, "cluster pod CIDR exceeds max address space")
+	}
+
+	// Without a load balancer to translate ports, the endpoints of the cluster are
+	// reachable on the node ports of their services
+	params := api.NewClusterParams()
+	params.Namespace = name
+	params.PodSecurity.SkipPrivilegedSCC = config.SkipPrivilegedSCC
+	params.ExternalAPIDNSName = apiDNSName
+	params.ExternalAPIPort = uint(apiNodePort)
+	params.ExternalOpenVPNDNSName = vpnEndpoint.DNSName
+	params.ExternalOpenVPNPort = uint(vpnNodePort)
+	params.ExternalOauthPort = uint(oauthNodePort)
+	params.APINodePort = uint(apiNodePort)
+	params.ServiceCIDR = clusterServiceCIDR.String()
+	params.PodCIDR = clusterPodCIDR.String()
+	params.ReleaseImage = releaseImage
+	params.IngressSubdomain = fmt.Sprintf("apps.%s.%s", name, config.BaseDomain)
+	params.OpenShiftAPIClusterIP = openshiftClusterIP
+	params.OpenVPNNodePort = fmt.Sprintf("%d", vpnNodePort)
+	params.BaseDomain = fmt.Sprintf("%s.%s", name, config.BaseDomain)
+	params.CloudProvider = ""
+	params.InternalAPIPort = 6443
+	params.EtcdClientName = "etcd-client"
+	params.NetworkType = api.NetworkTypeOpenShiftSDN
+	params.ImageRegistryHTTPSecret = installer.GenerateImageRegistrySecret()
+	params.NodePools = []api.NodePool{{Name: "worker", Replicas: config.WorkerCount}}
+	params.IgnitionVersion = ignitionVersion
+	params.ExternalIgnitionPort = uint(ignitionNodePort)
+	if params.IgnitionServerToken, err = installer.GenerateIgnitionServerToken(); err != nil {
+		return installerrors.Render(err, "failed to generate ignition server token")
+	}
+	params.Replicas = "1"
+	params.ControlPlaneOperatorControllers = []string{
+		"controller-manager-ca",
+		"auto-approver",
+		"kubeadmin-password",
+		"cluster-operator",
+		"cluster-version",
+		"kubelet-serving-ca",
+		"openshift-apiserver",
+		"openshift-controller-manager",
+		"cert-rotation",
+		"cluster-status",
+	}
+	cpOperatorImage := os.Getenv("CONTROL_PLANE_OPERATOR_IMAGE_OVERRIDE")
+	if cpOperatorImage == "" {
+		params.ControlPlaneOperatorImage = defaultControlPlaneOperatorImage
+	} else {
+		params.ControlPlaneOperatorImage = cpOperatorImage
+	}
+
+	workingDir := config.OutputDir
+	if err = os.MkdirAll(workingDir, 0755); err != nil {
+		return installerrors.Render(err, "cannot create output directory")
+	}
+	log.Infof("The output directory is %s", workingDir)
+	pkiDir := filepath.Join(workingDir, "pki")
+	if err = os.Mkdir(pkiDir, 0700); err != nil {
+		return installerrors.Render(err, "cannot create PKI directory")
+	}
+	log.Info("Generating PKI")
+	if len(dhParamsFile) > 0 {
+		if err = installer.CopyFile(dhParamsFile, filepath.Join(pkiDir, "openvpn-dh.pem")); err != nil {
+			return installerrors.Render(err, "cannot copy dh parameters file %s", dhParamsFile)
+		}
+	}
+	if err := pki.GeneratePKI(params, pkiDir); err != nil {
+		return installerrors.Render(err, "failed to generate PKI assets")
+	}
+	manifestsDir := filepath.Join(workingDir, "manifests")
+	if err = os.Mkdir(manifestsDir, 0755); err != nil {
+		return installerrors.Render(err, "cannot create manifests directory")
+	}
+	pullSecretFile := filepath.Join(workingDir, "pull-secret")
+	if err = ioutil.WriteFile(pullSecretFile, []byte(pullSecret), 0600); err != nil {
+		return installerrors.Render(err, "failed to create pull secret file")
+	}
+	log.Info("Generating ignition for workers")
+	if err = ignition.GenerateIgnition(params, sshKey, pullSecretFile, pkiDir, workingDir); err != nil {
+		return installerrors.Render(err, "cannot generate ignition file for workers")
+	}
+	// Workers fetch the ignition file from the ignition server, which trusts the root CA
+	// of the cluster
+	if err = installer.GenerateIgnitionServerConfigSecret(filepath.Join(workingDir, "bootstrap.ign"), filepath.Join(manifestsDir, "ignition-server-config-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to generate ignition server config secret")
+	}
+	ignitionCA, err := ioutil.ReadFile(filepath.Join(pkiDir, "root-ca.crt"))
+	if err != nil {
+		return installerrors.Render(err, "cannot read root CA")
+	}
+	if provider.userData, err = ignition.UserData(ignition.ServerURL(apiDNSName, params.ExternalIgnitionPort, params.IgnitionServerToken), ignitionCA, params.IgnitionVersion); err != nil {
+		return installerrors.Render(err, "cannot generate user data for workers")
+	}
+	for _, pool := range params.NodePools {
+		if err = provider.EnsureWorkerPool(pool, filepath.Join(workingDir, fmt.Sprintf("%s.ign", pool.Name))); err != nil {
+			return err
+		}
+	}
+
+	log.Info("Rendering Manifests")
+	if err := render.RenderPKISecrets(pkiDir, manifestsDir, true, true, false, true, render.CertRotationEnabled(params), true, params.EtcdEncryption.Provider != "", params.Monitoring.Enabled); err != nil {
+		return installerrors.Render(err, "failed to render PKI secrets")
+	}
+	if err := render.RenderNamedCertsSecret(params, pkiDir, manifestsDir); err != nil {
+		return installerrors.Render(err, "failed to render named certificates secret")
+	}
+	caBytes, err := ioutil.ReadFile(filepath.Join(pkiDir, "combined-ca.crt"))
+	if err != nil {
+		return installerrors.Render(err, "failed to render PKI secrets")
+	}
+	params.OpenshiftAPIServerCABundle = base64.StdEncoding.EncodeToString(caBytes)
+	if err = render.RenderClusterManifests(params, pullSecretFile, manifestsDir, true, true, false, true, true); err != nil {
+		return installerrors.Render(err, "failed to render manifests for cluster")
+	}
+	kubeadminPassword, err := installer.GenerateKubeadminPassword()
+	if err != nil {
+		return installerrors.Render(err, "failed to generate kubeadmin password")
+	}
+	if err = installer.GenerateKubeadminPasswordTargetSecret(kubeadminPassword, filepath.Join(manifestsDir, "kubeadmin-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create kubeadmin secret manifest for target cluster")
+	}
+	if err = installer.GenerateKubeadminPasswordSecret(kubeadminPassword, filepath.Join(manifestsDir, "kubeadmin-host-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create kubeadmin secret manifest for management cluster")
+	}
+	if err = installer.GenerateKubeconfigSecret(filepath.Join(pkiDir, "admin.kubeconfig"), filepath.Join(manifestsDir, "kubeconfig-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create kubeconfig secret manifest for management cluster")
+	}
+	if err = installer.GenerateTargetPullSecret([]byte(pullSecret), filepath.Join(manifestsDir, "user-pull-secret.json")); err != nil {
+		return installerrors.Render(err, "failed to create pull secret manifest for target cluster")
+	}
+
+	// Create the system branding manifest (cannot be applied because it's too large)
+	if err = installer.CreateBrandingSecret(client, name, filepath.Join(manifestsDir, "v4-0-config-system-branding.yaml")); err != nil {
+		return installerrors.Apply(err, "failed to create oauth branding secret")
+	}
+
+	excludedDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return installerrors.Render(err, "failed to create a temporary directory for excluded manifests")
+	}
+	log.Infof("Excluded manifests directory: %s", excludedDir)
+	if err = installer.ApplyManifests(cfg, name, manifestsDir, excludeManifests, excludedDir); err != nil {
+		return installerrors.Apply(err, "failed to apply manifests")
+	}
+	log.Infof("Cluster resources applied")
+
+	fmt.Fprintf(out, "Create the following DNS records, the %s record resolving to the workers of the cluster:\n", routerEndpoint.DNSName)
+	if err = provider.printRecords(out); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Boot %d RHCOS workers with the ignition config in %s\n", config.WorkerCount, filepath.Join(workingDir, "worker.ign"))
+
+	apiURL := fmt.Sprintf("https://%s:%d", apiDNSName, apiNodePort)
+	if waitForReady {
+		log.Infof("Waiting up to 10 minutes for API endpoint to be available.")
+		if err = installer.WaitForAPIEndpoint(pkiDir, apiDNSName, apiNodePort, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to access API endpoint")
+		}
+		log.Infof("API is available at %s", apiURL)
+
+		log.Infof("Waiting up to 5 minutes for bootstrap pod to complete.")
+		if err = installer.WaitForBootstrapPod(client, name, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to wait for bootstrap pod to complete")
+		}
+		log.Infof("Bootstrap pod has completed.")
+
+		targetClusterCfg, err := installer.GetTargetClusterConfig(pkiDir)
+		if err != nil {
+			return installerrors.Precondition(err, "cannot create target cluster client config")
+		}
+		targetClient, err := kubeclient.NewForConfig(targetClusterCfg)
+		if err != nil {
+			return installerrors.Precondition(err, "cannot create target cluster client")
+		}
+
+		log.Infof("Waiting up to 10 minutes for nodes to be ready.")
+		if err = installer.WaitForNodesReady(targetClient, config.WorkerCount, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to wait for nodes ready")
+		}
+		log.Infof("Nodes (%d) are ready", config.WorkerCount)
+
+		log.Infof("Waiting up to 15 minutes for cluster operators to be ready.")
+		if err = installer.WaitForClusterOperators(targetClusterCfg, installer.WaitConfig{}); err != nil {
+			return installerrors.Timeout(err, "failed to wait for cluster operators")
+		}
+	}
+
+	log.Infof("Cluster API URL: %s", apiURL)
+	log.Infof("Kubeconfig is available in secret %q in the %s namespace", "admin-kubeconfig", name)
+	log.Infof("Console URL:  %s", fmt.Sprintf("https://console-openshift-console.%s", params.IngressSubdomain))
+	log.Infof("kubeadmin password is available in secret %q in the %s namespace", "kubeadmin-password", name)
+	return nil
+}
+
+// getWorkerNodeIPs returns the internal IPs of the worker nodes of the management cluster
+func getWorkerNodeIPs(client kubeclient.Interface) ([]string, error) {
+	nodes, err := client.CoreV1().Nodes().List(metav1.ListOptions{LabelSelector: "node-role.kubernetes.io/worker"})
+	if err != nil {
+		return nil, err
+	}
+	var ips []string
+	for _, node := range nodes.Items {
+		for _, address := range node.Status.Addresses {
+			if address.Type == corev1.NodeInternalIP {
+				ips = append(ips, address.Address)
+				break
+			}
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("did not find worker nodes with an internal IP")
+	}
+	return ips, nil
+}
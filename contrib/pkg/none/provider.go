@@ -0,0 +1,110 @@
+package none
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/tabwriter"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+// dnsRecord is a DNS record that the administrator creates for an endpoint of a cluster
+type dnsRecord struct {
+	name        string
+	addresses   []string
+	ports       []int
+	description string
+}
+
+// noneProvider is the provider of hosted clusters on a management cluster without a cloud
+// provider. It creates no resources: the endpoints of a cluster are the static node ports
+// of its services on the management cluster, and its workers are booted by the
+// administrator, who creates the DNS records of the cluster.
+type noneProvider struct {
+	clusterName string
+	baseDomain  string
+
+	// addresses are the addresses of the management cluster nodes that the records of the
+	// API and VPN endpoints resolve to
+	addresses []string
+
+	// userData is the ignition config that workers boot with
+	userData []byte
+
+	records []dnsRecord
+}
+
+var _ installer.CloudProvider = &noneProvider{}
+
+func (p *noneProvider) dnsName(prefix string) string {
+	return fmt.Sprintf("%s.%s.%s", prefix, p.clusterName, p.baseDomain)
+}
+
+func (p *noneProvider) addRecord(prefix string, addresses []string, description string, ports ...int) *installer.Endpoint {
+	record := dnsRecord{name: p.dnsName(prefix), addresses: addresses, ports: ports, description: description}
+	p.records = append(p.records, record)
+	return &installer.Endpoint{DNSName: record.name}
+}
+
+// EnsureAPIEndpoint records the API record, which resolves to the management cluster nodes
+func (p *noneProvider) EnsureAPIEndpoint(ports installer.APIEndpointPorts) (*installer.Endpoint, error) {
+	nodePorts := []int{ports.API, ports.OAuth}
+	if ports.Ignition != 0 {
+		nodePorts = append(nodePorts, ports.Ignition)
+	}
+	return p.addRecord("api", p.addresses, "API, OAuth and ignition server", nodePorts...), nil
+}
+
+// EnsureIngressEndpoint records the wildcard apps record. The routers of a cluster without
+// a cloud provider use the host network of its workers, so the record resolves to the
+// workers and the node ports are not used.
+func (p *noneProvider) EnsureIngressEndpoint(httpNodePort, httpsNodePort int) (*installer.Endpoint, error) {
+	return p.addRecord("*.apps", nil, "router", 80, 443), nil
+}
+
+// EnsureVPNEndpoint records the VPN record, which resolves to the management cluster nodes
+func (p *noneProvider) EnsureVPNEndpoint(vpnNodePort, healthCheckNodePort int) (*installer.Endpoint, error) {
+	return p.addRecord("vpn", p.addresses, "VPN (UDP)", vpnNodePort), nil
+}
+
+// EnsureIgnitionStorage is not supported without a cloud provider, workers fetch their
+// ignition config from the ignition server of the cluster
+func (p *noneProvider) EnsureIgnitionStorage(fileName string) (string, error) {
+	return "", installerrors.Precondition(nil, "ignition storage is not supported without a cloud provider, workers use the ignition server")
+}
+
+// EnsureWorkerPool writes the ignition config that the workers of a node pool boot with
+// to fileName
+func (p *noneProvider) EnsureWorkerPool(pool api.NodePool, fileName string) error {
+	if err := ioutil.WriteFile(fileName, p.userData, 0644); err != nil {
+		return installerrors.Render(err, "failed to write the ignition config of node pool %s", pool.Name)
+	}
+	return nil
+}
+
+// Teardown removes nothing, the records of the cluster are removed by the administrator
+func (p *noneProvider) Teardown() error {
+	return nil
+}
+
+// printRecords writes a table of the DNS records of the cluster to out
+func (p *noneProvider) printRecords(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tVALUE\tPORTS\tENDPOINT")
+	for _, record := range p.records {
+		value := strings.Join(record.addresses, ",")
+		if len(value) == 0 {
+			value = "<worker-addresses>"
+		}
+		ports := make([]string, 0, len(record.ports))
+		for _, port := range record.ports {
+			ports = append(ports, fmt.Sprintf("%d", port))
+		}
+		fmt.Fprintf(w, "%s\tA\t%s\t%s\t%s\n", record.name, value, strings.Join(ports, ","), record.description)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,75 @@
+package none
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+func TestPrintRecords(t *testing.T) {
+	provider := &noneProvider{clusterName: "test", baseDomain: "example.com", addresses: []string{"10.0.0.1", "10.0.0.2"}}
+	if _, err := provider.EnsureAPIEndpoint(installer.APIEndpointPorts{API: 30600, OAuth: 30601, Ignition: 30602}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := provider.EnsureIngressEndpoint(0, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	endpoint, err := provider.EnsureVPNEndpoint(30603, 30600)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if endpoint.DNSName != "vpn.test.example.com" {
+		t.Errorf("unexpected VPN DNS name: %s", endpoint.DNSName)
+	}
+	out := &bytes.Buffer{}
+	if err := provider.printRecords(out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	expected := [][]string{
+		{"NAME", "TYPE", "VALUE", "PORTS", "ENDPOINT"},
+		{"api.test.example.com", "A", "10.0.0.1,10.0.0.2", "30600,30601,30602"},
+		{"*.apps.test.example.com", "A", "<worker-addresses>", "80,443"},
+		{"vpn.test.example.com", "A", "10.0.0.1,10.0.0.2", "30603"},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got:\n%s", len(expected), out.String())
+	}
+	for i, fields := range expected {
+		actual := strings.Fields(lines[i])
+		for j, field := range fields {
+			if j >= len(actual) || actual[j] != field {
+				t.Errorf("line %d: expected field %d to be %q, got %q", i, j, field, lines[i])
+			}
+		}
+	}
+}
+
+func TestNodePorts(t *testing.T) {
+	tests := []struct {
+		base        int
+		expectError bool
+	}{
+		{base: 30600},
+		{base: 29999, expectError: true},
+		{base: 32765, expectError: true},
+	}
+	for _, test := range tests {
+		config := &Config{NodePortBase: test.base}
+		api, _, _, vpn, err := config.nodePorts()
+		if test.expectError {
+			if err == nil {
+				t.Errorf("base %d: expected an error", test.base)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("base %d: unexpected error: %v", test.base, err)
+		}
+		if api != test.base || vpn != test.base+3 {
+			t.Errorf("base %d: unexpected ports %d, %d", test.base, api, vpn)
+		}
+	}
+}
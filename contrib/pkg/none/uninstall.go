@@ -0,0 +1,46 @@
+package none
+
+import (
+	"fmt"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeclient "k8s.io/client-go/kubernetes"
+
+	installerrors "github.com/openshift/hypershift-toolkit/contrib/pkg/errors"
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+// UninstallCluster removes a cluster installed with InstallCluster. The DNS records and
+// workers of the cluster are not known to the management cluster, and the records that
+// the administrator must remove are written to out.
+func UninstallCluster(name, baseDomain string, out io.Writer) error {
+	if len(baseDomain) == 0 {
+		return installerrors.Precondition(nil, "a base domain is required")
+	}
+
+	// First, ensure that we can access the host cluster
+	cfg, err := installer.LoadConfig()
+	if err != nil {
+		return installerrors.Precondition(err, "cannot access existing cluster; make sure a connection to host cluster is available")
+	}
+
+	client, err := kubeclient.NewForConfig(cfg)
+	if err != nil {
+		return installerrors.Precondition(err, "failed to obtain a kubernetes client from existing configuration")
+	}
+
+	log.Info("Removing cluster namespace")
+	if err = client.CoreV1().Namespaces().Delete(name, &metav1.DeleteOptions{}); err != nil {
+		if !errors.IsNotFound(err) {
+			return installerrors.Apply(err, "failed to delete namespace %s", name)
+		}
+	}
+
+	provider := &noneProvider{clusterName: name, baseDomain: baseDomain}
+	fmt.Fprintf(out, "Remove the DNS records %s, %s and %s, and the workers of the cluster\n", provider.dnsName("api"), provider.dnsName("*.apps"), provider.dnsName("vpn"))
+	return nil
+}
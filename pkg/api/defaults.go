@@ -14,5 +14,7 @@ func NewClusterParams() *ClusterParams {
 		"RotateKubeletServerCertificate=true",
 	}
 	p.ImageRegistryHTTPSecret = uuid.New().String()
+	p.KonnectivityServerImage = "us.gcr.io/k8s-artifacts-prod/kas-network-proxy/proxy-server:v0.0.12"
+	p.KonnectivityAgentImage = "us.gcr.io/k8s-artifacts-prod/kas-network-proxy/proxy-agent:v0.0.12"
 	return p
 }
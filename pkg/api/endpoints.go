@@ -0,0 +1,14 @@
+package api
+
+import (
+	"fmt"
+)
+
+// ExternalOauthHost returns the host and port that clients reach the OAuth server at: the
+// OAuth port on the external API name, or the HTTPS port of its own name if it has one
+func (p *ClusterParams) ExternalOauthHost() string {
+	if len(p.ExternalOauthDNSName) > 0 {
+		return p.ExternalOauthDNSName
+	}
+	return fmt.Sprintf("%s:%d", p.ExternalAPIDNSName, p.ExternalOauthPort)
+}
@@ -0,0 +1,16 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestExternalOauthHost(t *testing.T) {
+	params := &ClusterParams{ExternalAPIDNSName: "api.example.com", ExternalOauthPort: 8443}
+	if actual := params.ExternalOauthHost(); actual != "api.example.com:8443" {
+		t.Errorf("unexpected OAuth host %s", actual)
+	}
+	params.ExternalOauthDNSName = "oauth.example.com"
+	if actual := params.ExternalOauthHost(); actual != "oauth.example.com" {
+		t.Errorf("unexpected OAuth host %s", actual)
+	}
+}
@@ -0,0 +1,22 @@
+package api
+
+// APIServerFrontendParams are the parameters of the API server frontend of a management
+// cluster, which forwards the API, OAuth and ignition connections of the clusters whose
+// API names are api.NAMESPACE.DOMAIN to their control plane namespaces by SNI, so that the
+// clusters share a single load balancer
+type APIServerFrontendParams struct {
+	// Namespace is the namespace of the frontend
+	Namespace string `json:"namespace"`
+
+	// Image is the control plane operator image, which runs the frontend
+	Image string `json:"image"`
+
+	// Replicas is the number of replicas of the frontend
+	Replicas int `json:"replicas"`
+
+	// Domain is the parent domain of the API names of the clusters
+	Domain string `json:"domain"`
+
+	// ServiceAnnotations are the annotations of the load balancer service of the frontend
+	ServiceAnnotations map[string]string `json:"serviceAnnotations,omitempty"`
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"fmt"
+)
+
+// Network types of a cluster, which select the network plugin that the cluster network
+// operator deploys on its workers
+const (
+	NetworkTypeOpenShiftSDN  = "OpenShiftSDN"
+	NetworkTypeOVNKubernetes = "OVNKubernetes"
+	// NetworkTypeNone leaves the network of the cluster to a third-party network plugin,
+	// which is installed with the manifests of userManifestsDir
+	NetworkTypeNone = "None"
+)
+
+// NetworkTypes are the supported network types of a cluster
+var NetworkTypes = []string{NetworkTypeOpenShiftSDN, NetworkTypeOVNKubernetes, NetworkTypeNone}
+
+// ValidateNetworkType returns an error if networkType is not a supported network type. An
+// empty network type is valid, the installers default it to OpenShiftSDN.
+func ValidateNetworkType(networkType string) error {
+	if len(networkType) == 0 {
+		return nil
+	}
+	for _, t := range NetworkTypes {
+		if networkType == t {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported network type %q, must be one of %v", networkType, NetworkTypes)
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"strings"
+)
+
+// ProxyEnabled returns whether the cluster reaches external hosts through an egress proxy
+func (p *ClusterParams) ProxyEnabled() bool {
+	return len(p.HTTPProxy) > 0 || len(p.HTTPSProxy) > 0
+}
+
+// EffectiveNoProxy returns the comma separated hosts that are reached without the proxy:
+// local and cluster internal addresses, followed by NoProxy
+func (p *ClusterParams) EffectiveNoProxy() string {
+	hosts := []string{"127.0.0.1", "localhost", ".svc", ".cluster.local"}
+	for _, cidr := range []string{p.ServiceCIDR, p.PodCIDR} {
+		if len(cidr) > 0 {
+			hosts = append(hosts, cidr)
+		}
+	}
+	for _, host := range strings.Split(p.NoProxy, ",") {
+		if host = strings.TrimSpace(host); len(host) > 0 {
+			hosts = append(hosts, host)
+		}
+	}
+	return strings.Join(hosts, ",")
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestEffectiveNoProxy(t *testing.T) {
+	params := &ClusterParams{
+		ServiceCIDR: "172.30.0.0/16",
+		PodCIDR:     "10.128.0.0/14",
+		NoProxy:     "example.com, .corp.example.com,",
+	}
+	expected := "127.0.0.1,localhost,.svc,.cluster.local,172.30.0.0/16,10.128.0.0/14,example.com,.corp.example.com"
+	if actual := params.EffectiveNoProxy(); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+	if params.ProxyEnabled() {
+		t.Errorf("expected the proxy to be disabled without proxy URLs")
+	}
+	params.HTTPSProxy = "http://proxy.example.com:3128"
+	if !params.ProxyEnabled() {
+		t.Errorf("expected the proxy to be enabled")
+	}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"fmt"
+)
+
+// Sizing profiles of the control plane components of a cluster
+const (
+	SizingProfileSmall  = "small"
+	SizingProfileMedium = "medium"
+	SizingProfileLarge  = "large"
+)
+
+// SizingProfiles are the supported sizing profiles of a control plane
+var SizingProfiles = []string{SizingProfileSmall, SizingProfileMedium, SizingProfileLarge}
+
+// componentSize is the CPU and memory request and the memory limit of a component. CPU is
+// not limited, since throttled API servers and etcd members fail their probes long before
+// they would affect other tenants. The memory limit keeps a runaway control plane from
+// taking the memory of the other control planes of its nodes.
+type componentSize struct {
+	cpu         string
+	memory      string
+	memoryLimit string
+}
+
+// sizingProfiles are the sizes of the components of a control plane by profile: small for
+// development clusters with a few nodes, medium for clusters with up to about 50 nodes and
+// large for clusters beyond that
+var sizingProfiles = map[string]map[string]componentSize{
+	SizingProfileSmall: {
+		"kube-apiserver":               {"200m", "1Gi", "4Gi"},
+		"kube-controller-manager":      {"50m", "256Mi", "1Gi"},
+		"kube-scheduler":               {"20m", "64Mi", "256Mi"},
+		"openshift-apiserver":          {"50m", "256Mi", "1Gi"},
+		"openshift-controller-manager": {"20m", "128Mi", "512Mi"},
+		"cluster-policy-controller":    {"10m", "64Mi", "256Mi"},
+		"cluster-version-operator":     {"10m", "64Mi", "256Mi"},
+		"control-plane-operator":       {"10m", "64Mi", "256Mi"},
+		"oauth-openshift":              {"10m", "64Mi", "256Mi"},
+		"openvpn-server":               {"10m", "16Mi", "128Mi"},
+		"openvpn-client":               {"10m", "16Mi", "128Mi"},
+		"etcd":                         {"100m", "512Mi", "2Gi"},
+		"ignition-server":              {"10m", "32Mi", "128Mi"},
+	},
+	SizingProfileMedium: {
+		"kube-apiserver":               {"500m", "2Gi", "8Gi"},
+		"kube-controller-manager":      {"100m", "512Mi", "2Gi"},
+		"kube-scheduler":               {"50m", "128Mi", "512Mi"},
+		"openshift-apiserver":          {"100m", "512Mi", "2Gi"},
+		"openshift-controller-manager": {"50m", "256Mi", "1Gi"},
+		"cluster-policy-controller":    {"20m", "128Mi", "512Mi"},
+		"cluster-version-operator":     {"20m", "128Mi", "512Mi"},
+		"control-plane-operator":       {"20m", "128Mi", "512Mi"},
+		"oauth-openshift":              {"20m", "128Mi", "512Mi"},
+		"openvpn-server":               {"20m", "32Mi", "256Mi"},
+		"openvpn-client":               {"20m", "32Mi", "256Mi"},
+		"etcd":                         {"200m", "1Gi", "4Gi"},
+		"ignition-server":              {"20m", "64Mi", "256Mi"},
+	},
+	SizingProfileLarge: {
+		"kube-apiserver":               {"1", "4Gi", "16Gi"},
+		"kube-controller-manager":      {"200m", "1Gi", "4Gi"},
+		"kube-scheduler":               {"100m", "256Mi", "1Gi"},
+		"openshift-apiserver":          {"200m", "1Gi", "4Gi"},
+		"openshift-controller-manager": {"100m", "512Mi", "2Gi"},
+		"cluster-policy-controller":    {"50m", "256Mi", "1Gi"},
+		"cluster-version-operator":     {"50m", "256Mi", "1Gi"},
+		"control-plane-operator":       {"50m", "256Mi", "1Gi"},
+		"oauth-openshift":              {"50m", "256Mi", "1Gi"},
+		"openvpn-server":               {"50m", "64Mi", "512Mi"},
+		"openvpn-client":               {"50m", "64Mi", "512Mi"},
+		"etcd":                         {"500m", "2Gi", "8Gi"},
+		"ignition-server":              {"50m", "128Mi", "512Mi"},
+	},
+}
+
+// componentResources returns the resource requirements of the components of a cluster by
+// the name of their deployment
+func (p *ClusterParams) componentResources() map[string]*[]ResourceRequirements {
+	return map[string]*[]ResourceRequirements{
+		"kube-apiserver":               &p.KubeAPIServerResources,
+		"kube-controller-manager":      &p.KubeControllerManagerResources,
+		"kube-scheduler":               &p.KubeSchedulerResources,
+		"openshift-apiserver":          &p.OpenshiftAPIServerResources,
+		"openshift-controller-manager": &p.OpenshiftControllerManagerResources,
+		"cluster-policy-controller":    &p.ClusterPolicyControllerResources,
+		"cluster-version-operator":     &p.ClusterVersionOperatorResources,
+		"control-plane-operator":       &p.ControlPlaneOperatorResources,
+		"oauth-openshift":              &p.OAuthServerResources,
+		"openvpn-server":               &p.OpenVPNServerResources,
+		"openvpn-client":               &p.OpenVPNClientResources,
+		"etcd":                         &p.EtcdResources,
+		"ignition-server":              &p.IgnitionServerResources,
+	}
+}
+
+// ValidateSizingProfile verifies that a sizing profile that is set is supported
+func ValidateSizingProfile(profile string) error {
+	if len(profile) == 0 {
+		return nil
+	}
+	if _, ok := sizingProfiles[profile]; !ok {
+		return fmt.Errorf("unsupported sizing profile %q, must be one of %v", profile, SizingProfiles)
+	}
+	return nil
+}
+
+// WithSizingProfile returns a copy of the parameters of a cluster in which the components
+// without resource requirements have those of its sizing profile. Requirements that are
+// set explicitly take precedence over the profile. The parameters are returned unchanged
+// if no profile is set.
+func WithSizingProfile(params *ClusterParams) (*ClusterParams, error) {
+	if len(params.SizingProfile) == 0 {
+		return params, nil
+	}
+	if err := ValidateSizingProfile(params.SizingProfile); err != nil {
+		return nil, err
+	}
+	sized := *params
+	profile := sizingProfiles[params.SizingProfile]
+	for name, resources := range sized.componentResources() {
+		if len(*resources) > 0 {
+			continue
+		}
+		size := profile[name]
+		*resources = []ResourceRequirements{{
+			ResourceRequest: []ResourceRequest{{CPU: size.cpu, Memory: size.memory}},
+			ResourceLimit:   []ResourceLimit{{Memory: size.memoryLimit}},
+		}}
+	}
+	return &sized, nil
+}
+
+// ComponentRequests returns the CPU and memory requests of a replica of each component of a
+// cluster by the name of its deployment: those set explicitly or by the sizing profile of
+// the cluster, or else those of the small profile, which are about the least that the
+// components run with
+func ComponentRequests(params *ClusterParams) (map[string]ResourceRequest, error) {
+	sized, err := WithSizingProfile(params)
+	if err != nil {
+		return nil, err
+	}
+	small := sizingProfiles[SizingProfileSmall]
+	requests := map[string]ResourceRequest{}
+	for name, resources := range sized.componentResources() {
+		request := ResourceRequest{CPU: small[name].cpu, Memory: small[name].memory}
+		for _, requirements := range *resources {
+			for _, r := range requirements.ResourceRequest {
+				if len(r.CPU) > 0 {
+					request.CPU = r.CPU
+				}
+				if len(r.Memory) > 0 {
+					request.Memory = r.Memory
+				}
+			}
+		}
+		requests[name] = request
+	}
+	return requests, nil
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestWithSizingProfile(t *testing.T) {
+	explicit := []ResourceRequirements{{ResourceRequest: []ResourceRequest{{CPU: "2", Memory: "8Gi"}}}}
+	params := &ClusterParams{
+		SizingProfile:          SizingProfileMedium,
+		KubeAPIServerResources: explicit,
+	}
+	sized, err := WithSizingProfile(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(params.EtcdResources) > 0 {
+		t.Errorf("expected the parameters to be unchanged")
+	}
+	if sized.KubeAPIServerResources[0].ResourceRequest[0].CPU != "2" {
+		t.Errorf("expected explicit resources to take precedence, got %v", sized.KubeAPIServerResources)
+	}
+	for name, resources := range sized.componentResources() {
+		if len(*resources) != 1 || len((*resources)[0].ResourceRequest) != 1 {
+			t.Errorf("expected resources for %s, got %v", name, *resources)
+		}
+	}
+	if actual := sized.EtcdResources[0].ResourceLimit[0].Memory; actual != "4Gi" {
+		t.Errorf("expected an etcd memory limit of 4Gi, got %s", actual)
+	}
+
+	unsized, err := WithSizingProfile(&ClusterParams{})
+	if err != nil || len(unsized.EtcdResources) > 0 {
+		t.Errorf("expected no resources without a profile, got %v (%v)", unsized.EtcdResources, err)
+	}
+	if _, err = WithSizingProfile(&ClusterParams{SizingProfile: "huge"}); err == nil {
+		t.Errorf("expected an error for an unsupported profile")
+	}
+}
+
+func TestComponentRequests(t *testing.T) {
+	params := &ClusterParams{
+		KubeAPIServerResources: []ResourceRequirements{{ResourceRequest: []ResourceRequest{{Memory: "3Gi"}}}},
+	}
+	requests, err := ComponentRequests(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := (ResourceRequest{CPU: "200m", Memory: "3Gi"}); requests["kube-apiserver"] != expected {
+		t.Errorf("expected %v, got %v", expected, requests["kube-apiserver"])
+	}
+	if expected := (ResourceRequest{CPU: "100m", Memory: "512Mi"}); requests["etcd"] != expected {
+		t.Errorf("expected the small profile without requests, got %v", requests["etcd"])
+	}
+
+	params.SizingProfile = SizingProfileLarge
+	if requests, err = ComponentRequests(params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := (ResourceRequest{CPU: "500m", Memory: "2Gi"}); requests["etcd"] != expected {
+		t.Errorf("expected the requests of the profile, got %v", requests["etcd"])
+	}
+}
@@ -8,28 +8,47 @@ type ClusterParams struct {
 	ExternalOpenVPNDNSName              string                 `json:"externalVPNDNSName"`
 	ExternalOpenVPNPort                 uint                   `json:"externalVPNPort"`
 	ExternalOauthPort                   uint                   `json:"externalOauthPort"`
+	ExternalOauthDNSName                string                 `json:"externalOauthDNSName,omitempty"`
+	ExternalIgnitionDNSName             string                 `json:"externalIgnitionDNSName,omitempty"`
+	ExternalKonnectivityDNSName         string                 `json:"externalKonnectivityDNSName"`
+	ExternalKonnectivityPort            uint                   `json:"externalKonnectivityPort"`
 	IdentityProviders                   string                 `json:"identityProviders"`
+	OAuthIdentityProviders              []IdentityProvider     `json:"oauthIdentityProviders,omitempty"`
 	ServiceCIDR                         string                 `json:"serviceCIDR"`
 	NamedCerts                          []NamedCert            `json:"namedCerts,omitempty"`
 	PodCIDR                             string                 `json:"podCIDR"`
 	ReleaseImage                        string                 `json:"releaseImage"`
+	AssetVersion                        string                 `json:"assetVersion,omitempty"`
+	AssetsDir                           string                 `json:"assetsDir,omitempty"`
+	UserManifestsDir                    string                 `json:"userManifestsDir,omitempty"`
+	MachineConfigsDir                   string                 `json:"machineConfigsDir,omitempty"`
+	Arch                                string                 `json:"arch,omitempty"`
 	APINodePort                         uint                   `json:"apiNodePort"`
 	IngressSubdomain                    string                 `json:"ingressSubdomain"`
 	OpenShiftAPIClusterIP               string                 `json:"openshiftAPIClusterIP"`
 	ImageRegistryHTTPSecret             string                 `json:"imageRegistryHTTPSecret"`
+	ImageRegistryS3Bucket               string                 `json:"imageRegistryS3Bucket,omitempty"`
+	ImageRegistryS3Region               string                 `json:"imageRegistryS3Region,omitempty"`
 	RouterNodePortHTTP                  string                 `json:"routerNodePortHTTP"`
 	RouterNodePortHTTPS                 string                 `json:"routerNodePortHTTPS"`
 	OpenVPNNodePort                     string                 `json:"openVPNNodePort"`
+	KonnectivityNodePort                string                 `json:"konnectivityNodePort"`
+	KonnectivityServerImage             string                 `json:"konnectivityServerImage"`
+	KonnectivityAgentImage              string                 `json:"konnectivityAgentImage"`
 	BaseDomain                          string                 `json:"baseDomain"`
 	NetworkType                         string                 `json:"networkType"`
 	Replicas                            string                 `json:"replicas"`
 	EtcdClientName                      string                 `json:"etcdClientName"`
+	EtcdEndpoints                       []string               `json:"etcdEndpoints,omitempty"`
+	EtcdCAFile                          string                 `json:"etcdCAFile,omitempty"`
 	OriginReleasePrefix                 string                 `json:"originReleasePrefix"`
 	OpenshiftAPIServerCABundle          string                 `json:"openshiftAPIServerCABundle"`
 	CloudProvider                       string                 `json:"cloudProvider"`
 	CVOSetupImage                       string                 `json:"cvoSetupImage"`
 	InternalAPIPort                     uint                   `json:"internalAPIPort"`
 	RouterServiceType                   string                 `json:"routerServiceType"`
+	APIServiceType                      string                 `json:"apiServiceType,omitempty"`
+	APIEndpointReconcilerType           string                 `json:"apiEndpointReconcilerType,omitempty"`
 	KubeAPIServerResources              []ResourceRequirements `json:"kubeAPIServerResources"`
 	OpenshiftControllerManagerResources []ResourceRequirements `json:"openshiftControllerManagerResources"`
 	ClusterVersionOperatorResources     []ResourceRequirements `json:"clusterVersionOperatorResources"`
@@ -42,21 +61,410 @@ type ClusterParams struct {
 	AutoApproverResources               []ResourceRequirements `json:"autoApproverResources"`
 	OpenVPNClientResources              []ResourceRequirements `json:"openVPNClientResources"`
 	OpenVPNServerResources              []ResourceRequirements `json:"openVPNServerResources"`
+	EtcdResources                       []ResourceRequirements `json:"etcdResources,omitempty"`
+	IgnitionServerResources             []ResourceRequirements `json:"ignitionServerResources,omitempty"`
+	SizingProfile                       string                 `json:"sizingProfile,omitempty"`
 	APIServerAuditEnabled               bool                   `json:"apiServerAuditEnabled"`
+	AuditPolicy                         string                 `json:"auditPolicy,omitempty"`
+	AuditWebhook                        AuditWebhook           `json:"auditWebhook,omitempty"`
+	AuditForwarder                      AuditForwarder         `json:"auditForwarder,omitempty"`
 	RestartDate                         string                 `json:"restartDate"`
 	ControlPlaneOperatorImage           string                 `json:"controlPlaneOperatorImage"`
 	ControlPlaneOperatorControllers     []string               `json:"controlPlaneOperatorControllers"`
 	ExtraFeatureGates                   []string               `json:"extraFeatureGates"`
 	ControlPlaneOperatorSecurity        string                 `json:"controlPlaneOperatorSecurity"`
+	KubeletServingCASyncInterval        string                 `json:"kubeletServingCASyncInterval,omitempty"`
 	ApiserverLivenessPath               string                 `json:"apiserverLivenessPath"`
 	DefaultFeatureGates                 []string
-	PlatformType                        string `json:"platformType"`
-	EndpointPublishingStrategyScope     string `json:"endpointPublishingStrategyScope"`
+	PlatformType                        string                       `json:"platformType"`
+	EndpointPublishingStrategyScope     string                       `json:"endpointPublishingStrategyScope"`
+	PKI                                 PKIParams                    `json:"pki,omitempty"`
+	Autoscaling                         AutoscalingParams            `json:"autoscaling,omitempty"`
+	NodePools                           []NodePool                   `json:"nodePools,omitempty"`
+	ImageContentSources                 []ImageContentSource         `json:"imageContentSources,omitempty"`
+	HTTPProxy                           string                       `json:"httpProxy,omitempty"`
+	HTTPSProxy                          string                       `json:"httpsProxy,omitempty"`
+	NoProxy                             string                       `json:"noProxy,omitempty"`
+	AdditionalTrustBundle               string                       `json:"additionalTrustBundle,omitempty"`
+	FIPS                                bool                         `json:"fips,omitempty"`
+	IgnitionVersion                     string                       `json:"ignitionVersion,omitempty"`
+	ExternalIgnitionPort                uint                         `json:"externalIgnitionPort,omitempty"`
+	IgnitionServerToken                 string                       `json:"ignitionServerToken,omitempty"`
+	EtcdEncryption                      EtcdEncryptionParams         `json:"etcdEncryption,omitempty"`
+	Etcd                                EtcdParams                   `json:"etcd,omitempty"`
+	Monitoring                          MonitoringParams             `json:"monitoring,omitempty"`
+	NetworkPolicy                       NetworkPolicyParams          `json:"networkPolicy,omitempty"`
+	PodSecurity                         PodSecurityParams            `json:"podSecurity,omitempty"`
+	VerticalPodAutoscaling              VerticalPodAutoscalingParams `json:"verticalPodAutoscaling,omitempty"`
+	ClusterVersion                      ClusterVersionParams         `json:"clusterVersion,omitempty"`
+	NodeUpdates                         NodeUpdatesParams            `json:"nodeUpdates,omitempty"`
+	DirectRouting                       bool                         `json:"directRouting,omitempty"`
 }
 
+// ImageContentSource is a repository whose images are pulled from mirrors, for clusters
+// that cannot reach the registry of the source repository
+type ImageContentSource struct {
+	// Source is the repository of the images (ie. quay.io/openshift-release-dev/ocp-release)
+	Source string `json:"source"`
+
+	// Mirrors are the repositories that contain the images of the source, in order of
+	// preference. The control plane images are pulled from the first mirror.
+	Mirrors []string `json:"mirrors"`
+}
+
+// NodePool is a named group of worker nodes that share an instance type, a zone and the
+// labels and taints of their nodes. Each pool is installed as its own machineset.
+type NodePool struct {
+	// Name identifies the pool within the cluster
+	Name string `json:"name"`
+
+	// InstanceType is the instance type of the nodes. Defaults to the instance type of
+	// the management cluster workers.
+	InstanceType string `json:"instanceType,omitempty"`
+
+	// Replicas is the number of nodes of the pool
+	Replicas int `json:"replicas"`
+
+	// Zone is the availability zone of the nodes. Defaults to the first zone of the
+	// control plane.
+	Zone string `json:"zone,omitempty"`
+
+	// Labels are added to the nodes of the pool
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Taints are added to the nodes of the pool
+	Taints []NodePoolTaint `json:"taints,omitempty"`
+
+	// SpotMarketOptions runs the nodes of the pool on spot instances when set
+	SpotMarketOptions *SpotMarketOptions `json:"spotMarketOptions,omitempty"`
+}
+
+// SpotMarketOptions configures the spot instances of a node pool. Spot instances can be
+// reclaimed by the cloud provider at any time, so they suit dev and test clusters.
+type SpotMarketOptions struct {
+	// MaxPrice is the maximum hourly price in USD of an instance (ie. 0.05).
+	// Defaults to the on-demand price.
+	MaxPrice string `json:"maxPrice,omitempty"`
+
+	// OnDemandFallbackReplicas is the number of on-demand nodes of a fallback pool
+	// named <pool>-on-demand, with the same settings as the spot pool. The fallback
+	// pool keeps workloads running while spot instances are not available.
+	OnDemandFallbackReplicas int `json:"onDemandFallbackReplicas,omitempty"`
+}
+
+// NodePoolTaint is a taint of the nodes of a pool
+type NodePoolTaint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"`
+}
+
+// AutoscalingParams configures the cluster autoscaler for the workers of a cluster.
+// The autoscaler is only rendered when at least one pool is given.
+type AutoscalingParams struct {
+	// Pools are the worker machinesets scaled by the autoscaler
+	Pools []AutoscalingPool `json:"pools,omitempty"`
+}
+
+// AutoscalingPool sets the replica bounds of a worker machineset
+type AutoscalingPool struct {
+	// MachineSet is the name of the machineset in the openshift-machine-api namespace
+	MachineSet string `json:"machineSet"`
+
+	// MinReplicas is the minimum number of machines of the pool
+	MinReplicas int `json:"minReplicas"`
+
+	// MaxReplicas is the maximum number of machines of the pool
+	MaxReplicas int `json:"maxReplicas"`
+}
+
+// PKIParams customizes the keys and certificates generated for a cluster.
+// Empty fields select the defaults.
+type PKIParams struct {
+	// CAValidity is the validity of CA certificates as a duration (ie. 87600h).
+	// Defaults to ten years.
+	CAValidity string `json:"caValidity,omitempty"`
+
+	// CertValidity is the validity of all other certificates as a duration (ie. 720h).
+	// Defaults to one year.
+	CertValidity string `json:"certValidity,omitempty"`
+
+	// KeySize is the size in bits of generated RSA keys. Defaults to 2048.
+	KeySize int `json:"keySize,omitempty"`
+
+	// SignatureAlgorithm is the algorithm used to sign certificates, one of
+	// SHA256-RSA, SHA384-RSA or SHA512-RSA for RSA keys and ECDSA-SHA256,
+	// ECDSA-SHA384 or ECDSA-SHA512 for ECDSA keys. Defaults to SHA256-RSA for
+	// RSA keys and ECDSA-SHA256 for ECDSA keys.
+	SignatureAlgorithm string `json:"signatureAlgorithm,omitempty"`
+
+	// KeyType is the type of generated keys, one of RSA, ECDSA-P256 or ECDSA-P384.
+	// Defaults to RSA.
+	KeyType string `json:"keyType,omitempty"`
+
+	// CertKeyTypes overrides KeyType for individual CAs, certificates and kubeconfigs,
+	// keyed by their name (ie. root-ca or kube-apiserver-server).
+	CertKeyTypes map[string]string `json:"certKeyTypes,omitempty"`
+
+	// CADirectory is a directory with existing CA key pairs in <name>.crt and
+	// <name>.key files (ie. root-ca.crt and root-ca.key). The root-ca, cluster-signer,
+	// openvpn-ca and konnectivity-ca CAs found there are used instead of generating self-signed CAs,
+	// so that they can be chained to an existing CA.
+	CADirectory string `json:"caDirectory,omitempty"`
+}
+
+// AuditWebhook sends the audit events of the kube-apiserver to a webhook
+type AuditWebhook struct {
+	// URL is the https URL of the webhook. Events are only sent when it is set.
+	URL string `json:"url,omitempty"`
+
+	// CAFile is a file with the CA bundle of the webhook. Defaults to the system roots.
+	CAFile string `json:"caFile,omitempty"`
+
+	// Mode is either batch, which buffers events and sends them asynchronously, or
+	// blocking, which fails requests whose events cannot be sent. Defaults to batch.
+	Mode string `json:"mode,omitempty"`
+}
+
+// AuditForwarder runs a sidecar next to the kube-apiserver that forwards the audit log,
+// which it can read at /var/log/kube-apiserver/audit.log
+type AuditForwarder struct {
+	// Image is the image of the sidecar. The sidecar only runs when it is set.
+	Image string `json:"image,omitempty"`
+
+	// Args are the arguments of the sidecar
+	Args []string `json:"args,omitempty"`
+}
+
+// MonitoringParams configures the scraping of the control plane by the monitoring stack
+// of the management cluster
+type MonitoringParams struct {
+	// Enabled renders ServiceMonitors and metrics proxies for the kube-apiserver, the
+	// kube-controller-manager, the control-plane-operator and etcd in simple mode
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Labels are added to all metrics of the cluster, in addition to a hosted_cluster
+	// label with the control plane namespace
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// NodeUpdatesParams configures the updates of the operating system of the workers to the OS
+// image of the release, which hosted clusters have no machine config operator for
+type NodeUpdatesParams struct {
+	// Enabled renders a node updater daemonset in the target cluster and the settings of the
+	// node-updater controller of the control plane operator, which drains and updates the
+	// workers whose OS image is not the one of the release
+	Enabled bool `json:"enabled,omitempty"`
+
+	// MaxUnavailable is the number of workers that are updated at a time. Defaults to 1.
+	MaxUnavailable int `json:"maxUnavailable,omitempty"`
+}
+
+// EtcdParams configures the etcd cluster rendered with the control plane
+type EtcdParams struct {
+	// Mode selects how etcd is deployed, either operator, an EtcdCluster of the etcd
+	// operator, or simple, a StatefulSet that needs no cluster-scoped CRDs or roles.
+	// Defaults to operator.
+	Mode string `json:"mode,omitempty"`
+
+	// StorageSize is the size of the volume of each member in simple mode. Defaults to 4Gi.
+	StorageSize string `json:"storageSize,omitempty"`
+
+	// StorageClass is the storage class of the member volumes in simple mode. Defaults
+	// to the default storage class of the management cluster.
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// DefragSchedule is the cron schedule of the defragmentation of the members in simple
+	// mode. Defaults to daily at 02:00.
+	DefragSchedule string `json:"defragSchedule,omitempty"`
+}
+
+// NetworkPolicyParams configures the network policies that isolate the control plane
+// namespace from the other namespaces of the management cluster
+type NetworkPolicyParams struct {
+	// Disabled leaves out the network policies, for management clusters whose network
+	// plugin does not enforce them or that isolate control planes otherwise
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// PodSecurityParams configures the security of the control plane pods. Every component runs
+// with a service account of its own, and only the VPN containers run privileged.
+type PodSecurityParams struct {
+	// SkipPrivilegedSCC leaves the privileged SCC of the management cluster unchanged. The
+	// service accounts of the pods with privileged containers must then be allowed to use it
+	// otherwise.
+	SkipPrivilegedSCC bool `json:"skipPrivilegedSCC,omitempty"`
+
+	// SeccompProfile is the seccomp profile of the control plane pods (ie. runtime/default).
+	// The SCC that admits the pods must allow it. Defaults to none.
+	SeccompProfile string `json:"seccompProfile,omitempty"`
+}
+
+// ClusterVersionParams configures the ClusterVersion of the cluster, which the cluster-version
+// controller of the control plane operator keeps in sync so that tenants cannot change it
+type ClusterVersionParams struct {
+	// Channel is the update channel of the cluster (ie. stable-4.5). Defaults to none, which
+	// disables the update recommendations of the cluster.
+	Channel string `json:"channel,omitempty"`
+
+	// Upstream is the URL of the update service that recommends updates for the channel.
+	// Defaults to the update service of the cluster version operator.
+	Upstream string `json:"upstream,omitempty"`
+
+	// Overrides are the components of the release that the cluster version operator of the
+	// cluster does not manage, because they are hosted outside of it
+	Overrides []ClusterVersionOverride `json:"overrides,omitempty"`
+}
+
+// ClusterVersionOverride identifies a manifest of the release that is left unmanaged
+type ClusterVersionOverride struct {
+	Kind      string `json:"kind"`
+	Group     string `json:"group,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// VerticalPodAutoscalingParams configures the VerticalPodAutoscalers of the control plane
+// components, which adjust the resource requests of their pods to their usage so that the
+// control planes of idle clusters shrink. The management cluster must run the vertical pod
+// autoscaler.
+type VerticalPodAutoscalingParams struct {
+	// Enabled adds a VerticalPodAutoscaler for every control plane deployment and for the
+	// etcd statefulset in simple mode
+	Enabled bool `json:"enabled,omitempty"`
+
+	// UpdateMode is how recommendations are applied, one of Off, Initial, Recreate or Auto.
+	// Defaults to Auto.
+	UpdateMode string `json:"updateMode,omitempty"`
+}
+
+// EtcdEncryptionParams configures the encryption at rest of the resources that the API
+// servers store in etcd. Resources are stored unencrypted when no provider is set.
+type EtcdEncryptionParams struct {
+	// Provider encrypts resources, one of aescbc, with a key generated with the PKI of the
+	// cluster, or kms, with a KMS plugin that runs next to each API server
+	Provider string `json:"provider,omitempty"`
+
+	// KMSPluginImage is the image of the KMS plugin. The plugin must listen on the
+	// unix socket /var/run/kmsplugin/socket.sock. Required with the kms provider.
+	KMSPluginImage string `json:"kmsPluginImage,omitempty"`
+
+	// KMSPluginArgs are the arguments of the KMS plugin (ie. the key to encrypt with)
+	KMSPluginArgs []string `json:"kmsPluginArgs,omitempty"`
+}
+
+// IdentityProvider is an identity provider of the OAuth server. Exactly one of the
+// provider types must be set. Files referenced by a provider are read when the manifests
+// are rendered and stored in the oauth-openshift-identity-providers secret.
+type IdentityProvider struct {
+	// Name identifies the provider and prefixes the identities of its users
+	Name string `json:"name"`
+
+	// MappingMethod is how identities are mapped to users, one of claim, lookup, add or
+	// generate. Defaults to claim.
+	MappingMethod string `json:"mappingMethod,omitempty"`
+
+	HTPasswd *HTPasswdIdentityProvider `json:"htpasswd,omitempty"`
+	LDAP     *LDAPIdentityProvider     `json:"ldap,omitempty"`
+	OpenID   *OpenIDIdentityProvider   `json:"openID,omitempty"`
+	GitHub   *GitHubIdentityProvider   `json:"github,omitempty"`
+}
+
+// HTPasswdIdentityProvider authenticates users with the passwords of an htpasswd file
+type HTPasswdIdentityProvider struct {
+	// File is the htpasswd file
+	File string `json:"file"`
+}
+
+// LDAPIdentityProvider authenticates users with their password against an LDAP server
+type LDAPIdentityProvider struct {
+	// URL is an RFC 2255 URL of the server and of the search for users
+	// (ie. ldaps://ldap.example.com/ou=users,dc=example,dc=com?uid)
+	URL string `json:"url"`
+
+	// BindDN is the DN to bind with during the search for users
+	BindDN string `json:"bindDN,omitempty"`
+
+	// BindPasswordFile is a file with the password of BindDN
+	BindPasswordFile string `json:"bindPasswordFile,omitempty"`
+
+	// CAFile is a file with the CA bundle of the server. Defaults to the system roots.
+	CAFile string `json:"caFile,omitempty"`
+
+	// Insecure disables TLS for ldap:// URLs
+	Insecure bool `json:"insecure,omitempty"`
+
+	// ID, PreferredUsername, Name and Email are the attributes of the identity of
+	// a user. ID defaults to dn and PreferredUsername to uid.
+	ID                []string `json:"id,omitempty"`
+	PreferredUsername []string `json:"preferredUsername,omitempty"`
+	Name              []string `json:"name,omitempty"`
+	Email             []string `json:"email,omitempty"`
+}
+
+// OpenIDIdentityProvider authenticates users with an OpenID Connect provider
+type OpenIDIdentityProvider struct {
+	// ClientID is the ID of the OAuth client registered with the provider
+	ClientID string `json:"clientID"`
+
+	// ClientSecretFile is a file with the secret of the OAuth client
+	ClientSecretFile string `json:"clientSecretFile"`
+
+	// AuthorizeURL, TokenURL and UserInfoURL are the endpoints of the provider, as found
+	// in its discovery document. UserInfoURL is optional.
+	AuthorizeURL string `json:"authorizeURL"`
+	TokenURL     string `json:"tokenURL"`
+	UserInfoURL  string `json:"userInfoURL,omitempty"`
+
+	// CAFile is a file with the CA bundle of the provider. Defaults to the system roots.
+	CAFile string `json:"caFile,omitempty"`
+
+	// ExtraScopes are requested in addition to the openid scope
+	ExtraScopes []string `json:"extraScopes,omitempty"`
+
+	// PreferredUsername, Name and Email are the claims of the identity of a user. The ID
+	// is the sub claim. PreferredUsername defaults to preferred_username.
+	PreferredUsername []string `json:"preferredUsername,omitempty"`
+	Name              []string `json:"name,omitempty"`
+	Email             []string `json:"email,omitempty"`
+}
+
+// GitHubIdentityProvider authenticates users with GitHub or GitHub Enterprise
+type GitHubIdentityProvider struct {
+	// ClientID is the ID of the OAuth application registered with GitHub
+	ClientID string `json:"clientID"`
+
+	// ClientSecretFile is a file with the secret of the OAuth application
+	ClientSecretFile string `json:"clientSecretFile"`
+
+	// Organizations restricts users to the members of these organizations
+	Organizations []string `json:"organizations,omitempty"`
+
+	// Teams restricts users to the members of these teams (ie. myorg/myteam)
+	Teams []string `json:"teams,omitempty"`
+
+	// Hostname is the host of a GitHub Enterprise instance
+	Hostname string `json:"hostname,omitempty"`
+
+	// CAFile is a file with the CA bundle of a GitHub Enterprise instance
+	CAFile string `json:"caFile,omitempty"`
+}
+
+// NamedCert is a serving certificate of the kube-apiserver for a domain, in addition to the
+// certificate for its external and internal names
 type NamedCert struct {
+	// NamedCertPrefix is the name of the certificate and key files in the PKI directory and
+	// in the kube-apiserver-named-certs secret (ie. api-custom for api-custom.crt)
 	NamedCertPrefix string `json:"namedCertPrefix"`
+
+	// NamedCertDomain is the name, or wildcard name (ie. *.example.com), that clients reach
+	// the API with and that the certificate is served for
 	NamedCertDomain string `json:"namedCertDomain"`
+
+	// NamedCertFile and NamedKeyFile are the PEM encoded certificate, with its chain, and key
+	// for the domain. A certificate signed by the root CA is generated if they are not set.
+	NamedCertFile string `json:"namedCertFile,omitempty"`
+	NamedKeyFile  string `json:"namedKeyFile,omitempty"`
 }
 
 type ResourceRequirements struct {
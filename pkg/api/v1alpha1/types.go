@@ -0,0 +1,89 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+var (
+	// GroupVersion is the API group and version of hosted cluster resources
+	GroupVersion = schema.GroupVersion{Group: "hypershift.openshift.io", Version: "v1alpha1"}
+
+	// HostedClusterResource is the resource for HostedCluster objects
+	HostedClusterResource = GroupVersion.WithResource("hostedclusters")
+)
+
+// HostedClusterPhase is the lifecycle phase of a hosted cluster
+type HostedClusterPhase string
+
+const (
+	// HostedClusterPending means the control plane has not been rendered yet
+	HostedClusterPending HostedClusterPhase = "Pending"
+
+	// HostedClusterAvailable means the control plane manifests for the latest
+	// spec have been applied to the management cluster
+	HostedClusterAvailable HostedClusterPhase = "Available"
+
+	// HostedClusterFailed means the latest spec could not be applied
+	HostedClusterFailed HostedClusterPhase = "Failed"
+
+	// HostedClusterDeleting means the control plane is being removed
+	HostedClusterDeleting HostedClusterPhase = "Deleting"
+)
+
+// HostedCluster is a cluster scoped resource that describes a hosted control plane.
+// The control plane components run in a namespace of the management cluster with the
+// same name as the HostedCluster.
+type HostedCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HostedClusterSpec   `json:"spec"`
+	Status HostedClusterStatus `json:"status,omitempty"`
+}
+
+// HostedClusterSpec is the desired state of a hosted control plane
+type HostedClusterSpec struct {
+	// Params are the parameters used to generate PKI and render manifests for the
+	// control plane, with the same format as the cluster.yaml file used by the
+	// render command.
+	Params api.ClusterParams `json:"params"`
+
+	// PullSecret references the secret that contains the pull secret of the cluster
+	// in its .dockerconfigjson key.
+	PullSecret corev1.SecretReference `json:"pullSecret"`
+
+	// CASecret optionally references a secret with existing CA key pairs in
+	// <name>.crt and <name>.key keys (ie. root-ca.crt and root-ca.key). The CAs
+	// found there are used instead of generating self-signed CAs when the PKI of
+	// the cluster is first generated.
+	CASecret *corev1.SecretReference `json:"caSecret,omitempty"`
+
+	// IncludeEtcd includes the etcd operator and cluster in the control plane
+	IncludeEtcd bool `json:"includeEtcd,omitempty"`
+
+	// IncludeVPN includes a VPN server, sidecar and client
+	IncludeVPN bool `json:"includeVPN,omitempty"`
+
+	// IncludeKonnectivity includes a konnectivity server in the kube-apiserver pods and
+	// konnectivity agents on the workers, instead of the VPN server, sidecar and client
+	IncludeKonnectivity bool `json:"includeKonnectivity,omitempty"`
+
+	// IncludeRegistry includes a default registry config for the user cluster
+	IncludeRegistry bool `json:"includeRegistry,omitempty"`
+}
+
+// HostedClusterStatus is the observed state of a hosted control plane
+type HostedClusterStatus struct {
+	// ObservedGeneration is the generation of the spec that was last applied
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Phase is the lifecycle phase of the cluster
+	Phase HostedClusterPhase `json:"phase,omitempty"`
+
+	// Message is a human readable description of the phase
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,275 @@
+package applier
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+)
+
+const (
+	// DefaultFieldManager is the manager of the fields that the applier sets by default
+	DefaultFieldManager = "hypershift"
+
+	// AppliedClusterLabel is set on the objects that the applier applies to the cluster that
+	// they belong to, which pruning selects the objects of
+	AppliedClusterLabel = "hypershift.openshift.io/applied-cluster"
+
+	// crdEstablishedTimeout is how long the applier waits for the CRDs of the manifests to be
+	// served before applying the other objects
+	crdEstablishedTimeout = time.Minute
+)
+
+// Applier applies manifests the way `kubectl apply --server-side` does. The API server
+// merges the fields of the manifests into the objects and tracks them as owned by the
+// field manager of the applier, so fields set by other controllers are preserved, and
+// objects do not carry a last applied configuration annotation, which large objects
+// such as CRDs exceed the size of annotations with.
+//
+// Objects are applied in dependency order: namespaces, CRDs, then configuration, then
+// workloads. Objects of CRDs of the manifests are applied once the CRDs are established.
+type Applier struct {
+	restConfig       *rest.Config
+	defaultNamespace string
+
+	// FieldManager is the manager of the fields that the applier sets
+	FieldManager string
+
+	// ForceConflicts takes over the fields that other managers set to different values.
+	// Otherwise applying an object whose fields conflict fails.
+	ForceConflicts bool
+
+	// Cluster is the value of the AppliedClusterLabel of the applied objects. Objects are not
+	// labeled when it is empty.
+	Cluster string
+
+	// Prune deletes the objects labeled for the cluster that are not in the manifests, such
+	// as the manifests that a new release no longer renders. Only objects of the kinds in
+	// the manifests and of the kinds that control planes are made of are pruned.
+	Prune bool
+
+	// DryRun applies and prunes objects with server-side dry runs, which validate the
+	// changes without persisting them. Objects in namespaces and of CRDs that the manifests
+	// create are not validated, since those do not exist yet.
+	DryRun bool
+
+	// Out receives a line for each object that is applied or pruned
+	Out io.Writer
+
+	// Diff receives a unified diff of the changes to each object when it is set
+	Diff io.Writer
+}
+
+// NewApplier returns an applier with the default field manager, which takes over
+// conflicting fields like the client side apply that the applier used before, and labels
+// objects for the cluster of the namespace.
+func NewApplier(cfg *rest.Config, namespace string) *Applier {
+	return &Applier{
+		restConfig:       cfg,
+		defaultNamespace: namespace,
+		FieldManager:     DefaultFieldManager,
+		ForceConflicts:   true,
+		Cluster:          namespace,
+		Out:              os.Stdout,
+	}
+}
+
+// ApplyFile applies the manifests in a file or directory
+func (a *Applier) ApplyFile(fileName string) error {
+	objs, err := readManifests(fileName)
+	if err != nil {
+		return err
+	}
+	sortManifests(objs)
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(a.restConfig)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := dynamic.NewForConfig(a.restConfig)
+	if err != nil {
+		return err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(&uncachedDiscoveryClient{DiscoveryClient: discoveryClient})
+	var errs []error
+	var crds []*unstructured.Unstructured
+	crdKinds := map[schema.GroupKind]bool{}
+	applied := map[string]bool{}
+	for _, obj := range objs {
+		// Objects that follow the CRDs may be of their kinds
+		if len(crds) > 0 && kindPriority(obj.GetKind()) > kindPriority("CustomResourceDefinition") {
+			if !a.DryRun {
+				if err := a.waitForCRDs(dynamicClient, mapper, crds); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			crds = nil
+			mapper.Reset()
+		}
+		mapping, err := mapper.RESTMapping(obj.GroupVersionKind().GroupKind(), obj.GroupVersionKind().Version)
+		if err != nil {
+			if a.DryRun && crdKinds[obj.GroupVersionKind().GroupKind()] {
+				if err := a.created(obj); err != nil {
+					errs = append(errs, err)
+				}
+				continue
+			}
+			errs = append(errs, fmt.Errorf("cannot apply %s: %v", objectName(obj), err))
+			continue
+		}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace && len(obj.GetNamespace()) == 0 {
+			obj.SetNamespace(a.defaultNamespace)
+		}
+		if err := a.apply(dynamicClient, mapping, obj); err != nil {
+			errs = append(errs, fmt.Errorf("cannot apply %s: %v", objectName(obj), err))
+			continue
+		}
+		applied[objectKey(mapping, obj.GetNamespace(), obj.GetName())] = true
+		if obj.GetKind() == "CustomResourceDefinition" {
+			crds = append(crds, obj)
+			if kind, found := crdKind(obj); found {
+				crdKinds[kind] = true
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+	if a.Prune && len(a.Cluster) > 0 {
+		return a.prune(dynamicClient, mapper, objs, applied)
+	}
+	return nil
+}
+
+func (a *Applier) apply(client dynamic.Interface, mapping *meta.RESTMapping, obj *unstructured.Unstructured) error {
+	obj = a.labeled(obj)
+	var resource dynamic.ResourceInterface = client.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		resource = client.Resource(mapping.Resource).Namespace(obj.GetNamespace())
+	}
+	var live *unstructured.Unstructured
+	if a.Diff != nil {
+		var err error
+		if live, err = resource.Get(obj.GetName(), metav1.GetOptions{}); apierrors.IsNotFound(err) {
+			live = nil
+		} else if err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	force := a.ForceConflicts
+	options := metav1.PatchOptions{FieldManager: a.FieldManager, Force: &force}
+	if a.DryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+	result, err := resource.Patch(obj.GetName(), types.ApplyPatchType, data, options)
+	// Applying an object only fails with not found when its namespace does not exist
+	if a.DryRun && apierrors.IsNotFound(err) && mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return a.created(obj)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.Out, "%s serverside-applied%s\n", objectName(obj), a.dryRunSuffix())
+	if a.Diff != nil {
+		return writeDiff(a.Diff, objectName(obj), live, result)
+	}
+	return nil
+}
+
+// created reports an object that a dry run cannot validate as it would be created
+func (a *Applier) created(obj *unstructured.Unstructured) error {
+	obj = a.labeled(obj)
+	fmt.Fprintf(a.Out, "%s created (dry run)\n", objectName(obj))
+	if a.Diff != nil {
+		return writeDiff(a.Diff, objectName(obj), nil, obj)
+	}
+	return nil
+}
+
+// labeled returns a copy of an object with the AppliedClusterLabel
+func (a *Applier) labeled(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+	if len(a.Cluster) > 0 {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[AppliedClusterLabel] = a.Cluster
+		obj.SetLabels(labels)
+	}
+	return obj
+}
+
+func (a *Applier) dryRunSuffix() string {
+	if a.DryRun {
+		return " (server dry run)"
+	}
+	return ""
+}
+
+// waitForCRDs waits until CRDs are established, so that their objects can be applied
+func (a *Applier) waitForCRDs(client dynamic.Interface, mapper meta.RESTMapper, crds []*unstructured.Unstructured) error {
+	for _, crd := range crds {
+		mapping, err := mapper.RESTMapping(crd.GroupVersionKind().GroupKind(), crd.GroupVersionKind().Version)
+		if err != nil {
+			return err
+		}
+		err = wait.PollImmediate(time.Second, crdEstablishedTimeout, func() (bool, error) {
+			current, err := client.Resource(mapping.Resource).Get(crd.GetName(), metav1.GetOptions{})
+			if err != nil {
+				return false, nil
+			}
+			return isEstablished(current), nil
+		})
+		if err != nil {
+			return fmt.Errorf("CRD %s was not established: %v", crd.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// crdKind returns the kind of the objects of a CRD
+func crdKind(crd *unstructured.Unstructured) (schema.GroupKind, bool) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	kind, found, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	return schema.GroupKind{Group: group, Kind: kind}, found
+}
+
+func isEstablished(crd *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == "Established" && condition["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
+
+// uncachedDiscoveryClient implements the cached discovery interface of the deferred REST
+// mapper without a cache, so that the resources of new CRDs are discovered once the
+// mapper is reset
+type uncachedDiscoveryClient struct {
+	*discovery.DiscoveryClient
+}
+
+func (d *uncachedDiscoveryClient) Fresh() bool { return true }
+
+func (d *uncachedDiscoveryClient) Invalidate() {}
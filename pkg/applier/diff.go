@@ -0,0 +1,239 @@
+package applier
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// diffContext is the number of unchanged lines around the changes of a diff
+	diffContext = 3
+
+	// maxDiffSize bounds the lines compared by a diff, beyond which the changed lines of
+	// an object are shown as replaced in full
+	maxDiffSize = 4000000
+)
+
+// writeDiff writes a unified diff between the live object and the object as applied, nil
+// for objects that do not exist. Nothing is written when they do not differ. The data of
+// secrets is masked, only showing which keys changed.
+func writeDiff(w io.Writer, name string, live, applied *unstructured.Unstructured) error {
+	live, applied = diffable(live), diffable(applied)
+	maskSecretData(live, applied)
+	before, err := diffLines(live)
+	if err != nil {
+		return err
+	}
+	after, err := diffLines(applied)
+	if err != nil {
+		return err
+	}
+	diff := unifiedDiff(before, after)
+	if len(diff) == 0 {
+		return nil
+	}
+	_, err = fmt.Fprintf(w, "--- live/%s\n+++ applied/%s\n%s", name, name, diff)
+	return err
+}
+
+// diffable returns a copy of an object without the fields that change on every write
+func diffable(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	if obj == nil {
+		return nil
+	}
+	obj = obj.DeepCopy()
+	for _, field := range []string{"managedFields", "resourceVersion", "generation", "creationTimestamp", "uid", "selfLink"} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	return obj
+}
+
+// maskSecretData replaces the values of secrets, like kubectl diff does
+func maskSecretData(live, applied *unstructured.Unstructured) {
+	for _, field := range []string{"data", "stringData"} {
+		var before, after map[string]interface{}
+		if live != nil && live.GetKind() == "Secret" {
+			before, _, _ = unstructured.NestedMap(live.Object, field)
+		}
+		if applied != nil && applied.GetKind() == "Secret" {
+			after, _, _ = unstructured.NestedMap(applied.Object, field)
+		}
+		for key, value := range before {
+			if other, ok := after[key]; ok && other == value {
+				before[key], after[key] = "***", "***"
+				continue
+			}
+			before[key] = "*** (before)"
+			if _, ok := after[key]; ok {
+				after[key] = "*** (after)"
+			}
+		}
+		for key := range after {
+			if _, ok := before[key]; !ok {
+				after[key] = "*** (after)"
+			}
+		}
+		if before != nil {
+			unstructured.SetNestedMap(live.Object, before, field)
+		}
+		if after != nil {
+			unstructured.SetNestedMap(applied.Object, after, field)
+		}
+	}
+}
+
+func diffLines(obj *unstructured.Unstructured) ([]string, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	b, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	for i := range lines {
+		lines[i] += "\n"
+	}
+	return lines, nil
+}
+
+// diffOp is a line of a diff: ' ' for a line of both sides, '-' for a removed line and
+// '+' for an added line
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff returns the hunks of a unified diff between lines, or an empty string when
+// they are the same
+func unifiedDiff(a, b []string) string {
+	ops := diffOps(a, b)
+	var out strings.Builder
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// A hunk spans the changes that are less than twice the context apart
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		end += diffContext
+		if end > len(ops) {
+			end = len(ops)
+		}
+		aStart, bStart := 1, 1
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				aStart++
+			}
+			if op.kind != '-' {
+				bStart++
+			}
+		}
+		aLen, bLen := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+		// Empty sides of a hunk start at the line before it, like diff -u
+		if aLen == 0 {
+			aStart--
+		}
+		if bLen == 0 {
+			bStart--
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// diffOps returns the operations that turn a into b, from the longest common subsequence
+// of the lines between their common prefix and suffix
+func diffOps(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, middleDiffOps(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+func middleDiffOps(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > maxDiffSize {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
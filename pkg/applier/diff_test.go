@@ -0,0 +1,95 @@
+package applier
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(s string) []string {
+		var result []string
+		for _, line := range strings.SplitAfter(s, "\n") {
+			if len(line) > 0 {
+				result = append(result, line)
+			}
+		}
+		return result
+	}
+	tests := []struct {
+		name     string
+		a, b     string
+		expected string
+	}{
+		{
+			name: "same",
+			a:    "a\nb\n",
+			b:    "a\nb\n",
+		},
+		{
+			name:     "added",
+			b:        "a\nb\n",
+			expected: "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:     "changed in the middle",
+			a:        "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			b:        "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			expected: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name:     "separate hunks",
+			a:        "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b:        "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			expected: "@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := unifiedDiff(lines(test.a), lines(test.b)); diff != test.expected {
+				t.Errorf("unexpected diff:\n%s\nexpected:\n%s", diff, test.expected)
+			}
+		})
+	}
+}
+
+func TestWriteDiffMasksSecrets(t *testing.T) {
+	secret := func(data map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]interface{}{
+				"name":            "pki",
+				"resourceVersion": "1",
+			},
+			"data": data,
+		}}
+	}
+	live := secret(map[string]interface{}{"ca.crt": "Y2E=", "tls.key": "a2V5"})
+	applied := secret(map[string]interface{}{"ca.crt": "Y2E=", "tls.key": "bmV3"})
+	applied.SetResourceVersion("2")
+	out := &bytes.Buffer{}
+	if err := writeDiff(out, "secret/pki", live, applied); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `--- live/secret/pki
++++ applied/secret/pki
+@@ -1,7 +1,7 @@
+ apiVersion: v1
+ data:
+   ca.crt: '***'
+-  tls.key: '*** (before)'
++  tls.key: '*** (after)'
+ kind: Secret
+ metadata:
+   name: pki
+`
+	if out.String() != expected {
+		t.Errorf("unexpected diff:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+	if strings.Contains(out.String(), "a2V5") || strings.Contains(out.String(), "bmV3") {
+		t.Errorf("secret data was not masked")
+	}
+}
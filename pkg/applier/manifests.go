@@ -0,0 +1,145 @@
+package applier
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// kindOrder is the order in which objects are applied, so that the objects that others
+// depend on exist first: namespaces and CRDs before the objects in them, and the
+// configuration and permissions of workloads before the workloads. Kinds that are not
+// listed are applied after the configuration and before the workloads.
+var kindOrder = []string{
+	"Namespace",
+	"CustomResourceDefinition",
+	"ServiceAccount",
+	"Secret",
+	"ConfigMap",
+	"ClusterRole",
+	"Role",
+	"ClusterRoleBinding",
+	"RoleBinding",
+	"PersistentVolumeClaim",
+	"Service",
+	"", // other kinds
+	"Deployment",
+	"StatefulSet",
+	"DaemonSet",
+	"Job",
+	"CronJob",
+	"Pod",
+	"PodDisruptionBudget",
+	"HorizontalPodAutoscaler",
+	"VerticalPodAutoscaler",
+}
+
+func kindPriority(kind string) int {
+	other := 0
+	for i, k := range kindOrder {
+		if k == kind {
+			return i
+		}
+		if len(k) == 0 {
+			other = i
+		}
+	}
+	return other
+}
+
+// sortManifests sorts objects in the order they are applied, keeping the order of the
+// files for objects of the same priority
+func sortManifests(objs []*unstructured.Unstructured) {
+	sort.SliceStable(objs, func(i, j int) bool {
+		return kindPriority(objs[i].GetKind()) < kindPriority(objs[j].GetKind())
+	})
+}
+
+// readManifests reads the objects in a manifest file, or in the .yaml, .yml and .json
+// files of a directory like `kubectl apply -f` does. Subdirectories are not read. Files may
+// contain several YAML documents and lists of objects.
+func readManifests(path string) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if file != path {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Files passed explicitly are read whatever their extension
+		switch filepath.Ext(file) {
+		case ".yaml", ".yml", ".json":
+		default:
+			if file != path {
+				return nil
+			}
+		}
+		contents, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		fileObjs, err := decodeManifests(contents)
+		if err != nil {
+			return fmt.Errorf("cannot read manifests of %s: %v", file, err)
+		}
+		objs = append(objs, fileObjs...)
+		return nil
+	})
+	return objs, err
+}
+
+func decodeManifests(contents []byte) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(contents), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				return objs, nil
+			}
+			return nil, err
+		}
+		// Skip empty documents
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if len(obj.GetKind()) == 0 || len(obj.GetAPIVersion()) == 0 {
+			return nil, fmt.Errorf("object %q has no kind or apiVersion", obj.GetName())
+		}
+		if !obj.IsList() {
+			objs = append(objs, obj)
+			continue
+		}
+		list, err := obj.ToList()
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			objs = append(objs, &list.Items[i])
+		}
+	}
+}
+
+// objectName returns a description of an object for messages, in the format of kubectl
+func objectName(obj *unstructured.Unstructured) string {
+	name := strings.ToLower(obj.GetKind()) + "/" + obj.GetName()
+	if group := obj.GroupVersionKind().Group; len(group) > 0 {
+		name = strings.ToLower(obj.GetKind()) + "." + group + "/" + obj.GetName()
+	}
+	if len(obj.GetNamespace()) > 0 {
+		name = obj.GetNamespace() + "/" + name
+	}
+	return name
+}
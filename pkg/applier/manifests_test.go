@@ -0,0 +1,88 @@
+package applier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadManifests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: kube-apiserver
+---
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: kube-apiserver
+`,
+		"list.json": `{"apiVersion": "v1", "kind": "List", "items": [
+  {"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "config"}},
+  {"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "cluster"}}
+]}`,
+		"crd.yml": `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: tests.example.com
+`,
+		"README.md":       "not a manifest",
+		"sub/secret.yaml": "apiVersion: v1\nkind: Secret\nmetadata:\n  name: ignored\n",
+	}
+	for name, contents := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	objs, err := readManifests(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sortManifests(objs)
+	var names []string
+	for _, obj := range objs {
+		names = append(names, objectName(obj))
+	}
+	expected := []string{
+		"namespace/cluster",
+		"customresourcedefinition.apiextensions.k8s.io/tests.example.com",
+		"configmap/config",
+		"service/kube-apiserver",
+		"deployment.apps/kube-apiserver",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("unexpected manifests: %v, expected %v", names, expected)
+	}
+
+	// Files passed explicitly are read whatever their extension
+	objs, err = readManifests(filepath.Join(dir, "sub", "secret.yaml"))
+	if err != nil || len(objs) != 1 {
+		t.Errorf("unexpected manifests of a file: %v, %v", objs, err)
+	}
+}
+
+func TestDecodeManifestsWithoutKind(t *testing.T) {
+	if _, err := decodeManifests([]byte("metadata:\n  name: test\n")); err == nil {
+		t.Errorf("expected an error for an object without kind")
+	}
+}
+
+func TestKindPriority(t *testing.T) {
+	if kindPriority("Route") <= kindPriority("Service") || kindPriority("Route") >= kindPriority("Deployment") {
+		t.Errorf("kinds that are not listed must be applied between the configuration and the workloads")
+	}
+}
@@ -0,0 +1,117 @@
+package applier
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/dynamic"
+)
+
+// defaultPruneKinds are the kinds that are pruned in addition to the kinds of the
+// manifests, so that the last object of a kind that a release no longer renders is
+// pruned too
+var defaultPruneKinds = []schema.GroupKind{
+	{Kind: "ConfigMap"},
+	{Kind: "Secret"},
+	{Kind: "Service"},
+	{Kind: "ServiceAccount"},
+	{Kind: "PersistentVolumeClaim"},
+	{Group: "apps", Kind: "Deployment"},
+	{Group: "apps", Kind: "StatefulSet"},
+	{Group: "apps", Kind: "DaemonSet"},
+	{Group: "batch", Kind: "Job"},
+	{Group: "batch", Kind: "CronJob"},
+	{Group: "policy", Kind: "PodDisruptionBudget"},
+	{Group: "rbac.authorization.k8s.io", Kind: "Role"},
+	{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"},
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"},
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"},
+	{Group: "route.openshift.io", Kind: "Route"},
+}
+
+// neverPrunedKinds are not pruned even when they are labeled for the cluster, because
+// deleting them deletes the objects in them
+var neverPrunedKinds = map[schema.GroupKind]bool{
+	{Kind: "Namespace"}: true,
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}: true,
+}
+
+// prune deletes the objects labeled for the cluster that were not applied
+func (a *Applier) prune(client dynamic.Interface, mapper meta.RESTMapper, objs []*unstructured.Unstructured, applied map[string]bool) error {
+	var errs []error
+	for _, mapping := range pruneMappings(mapper, objs) {
+		list, err := client.Resource(mapping.Resource).List(metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s", AppliedClusterLabel, a.Cluster),
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot list %s to prune: %v", mapping.Resource.Resource, err))
+			continue
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if applied[objectKey(mapping, obj.GetNamespace(), obj.GetName())] || obj.GetDeletionTimestamp() != nil {
+				continue
+			}
+			var resource dynamic.ResourceInterface = client.Resource(mapping.Resource)
+			if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+				resource = client.Resource(mapping.Resource).Namespace(obj.GetNamespace())
+			}
+			propagation := metav1.DeletePropagationBackground
+			options := &metav1.DeleteOptions{PropagationPolicy: &propagation}
+			if a.DryRun {
+				options.DryRun = []string{metav1.DryRunAll}
+			}
+			err := resource.Delete(obj.GetName(), options)
+			if err != nil && !errors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("cannot prune %s: %v", objectName(obj), err))
+				continue
+			}
+			fmt.Fprintf(a.Out, "%s pruned%s\n", objectName(obj), a.dryRunSuffix())
+			if a.Diff != nil {
+				if err := writeDiff(a.Diff, objectName(obj), obj, nil); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// pruneMappings returns the mappings of the kinds to prune. Kinds that the cluster does not
+// serve, such as routes outside of OpenShift, are skipped.
+func pruneMappings(mapper meta.RESTMapper, objs []*unstructured.Unstructured) []*meta.RESTMapping {
+	kinds := append([]schema.GroupKind{}, defaultPruneKinds...)
+	for _, obj := range objs {
+		kinds = append(kinds, obj.GroupVersionKind().GroupKind())
+	}
+	var mappings []*meta.RESTMapping
+	seen := map[schema.GroupResource]bool{}
+	for _, kind := range kinds {
+		if neverPrunedKinds[kind] {
+			continue
+		}
+		mapping, err := mapper.RESTMapping(kind)
+		if err != nil {
+			continue
+		}
+		if seen[mapping.Resource.GroupResource()] {
+			continue
+		}
+		seen[mapping.Resource.GroupResource()] = true
+		mappings = append(mappings, mapping)
+	}
+	return mappings
+}
+
+// objectKey identifies an object of a mapping across the versions of its resource
+func objectKey(mapping *meta.RESTMapping, namespace, name string) string {
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		namespace = ""
+	}
+	return mapping.Resource.GroupResource().String() + "/" + namespace + "/" + name
+}
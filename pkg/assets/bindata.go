@@ -1,5 +1,12 @@
 // Code generated by go-bindata.
 // sources:
+// assets/apiserver-frontend/apiserver-frontend-deployment.yaml
+// assets/apiserver-frontend/apiserver-frontend-namespace.yaml
+// assets/apiserver-frontend/apiserver-frontend-pdb.yaml
+// assets/apiserver-frontend/apiserver-frontend-service.yaml
+// assets/cluster-autoscaler/cluster-autoscaler-deployment.yaml
+// assets/cluster-autoscaler/cluster-autoscaler-rbac.yaml
+// assets/cluster-autoscaler/machine-autoscaler-template.yaml
 // assets/cluster-bootstrap/00000_namespaces-needed-for-monitoring.yaml
 // assets/cluster-bootstrap/cluster-config-v1-configmap.yaml
 // assets/cluster-bootstrap/cluster-dns-02-config.yaml
@@ -8,30 +15,59 @@
 // assets/cluster-bootstrap/cluster-ingresscontrollers-02-config.yaml
 // assets/cluster-bootstrap/cluster-network-01-crd.yaml
 // assets/cluster-bootstrap/cluster-network-02-config.yaml
+// assets/cluster-bootstrap/cluster-network-03-config.yaml
 // assets/cluster-bootstrap/cluster-proxy-01-config.yaml
+// assets/cluster-bootstrap/cluster-proxy-02-user-ca-bundle.yaml
 // assets/cluster-bootstrap/cluster-version-namespace.yaml
 // assets/cluster-bootstrap/node-bootstrapper-clusterrolebinding.yaml
+// assets/cluster-version-operator/cluster-version-configmap.yaml
 // assets/cluster-version-operator/cluster-version-operator-deployment.yaml
+// assets/common/fips-serving-info.yaml
+// assets/common/pki-ca-secret.yaml
+// assets/common/pod-annotations.yaml
+// assets/common/pod-disruption-budget-template.yaml
+// assets/common/proxy-env.yaml
+// assets/common/security-context.yaml
 // assets/common/service-network-admin-kubeconfig-secret.yaml
+// assets/common/vertical-pod-autoscaler-template.yaml
 // assets/control-plane-operator/cp-operator-configmap.yaml
 // assets/control-plane-operator/cp-operator-deployment.yaml
 // assets/etcd/etcd-cluster-crd.yaml
 // assets/etcd/etcd-cluster.yaml
+// assets/etcd/etcd-defrag-cronjob-template.yaml
 // assets/etcd/etcd-operator-cluster-role-binding.yaml
 // assets/etcd/etcd-operator-cluster-role.yaml
 // assets/etcd/etcd-operator.yaml
 // assets/etcd/etcd-secret-template.yaml
+// assets/etcd/etcd-services.yaml
+// assets/etcd/etcd-statefulset-template.yaml
 // assets/ignition/files/etc/crio/crio.conf
 // assets/ignition/files/etc/kubernetes/kubelet.conf.template
+// assets/ignition/files/etc/kubernetes/proxy.env.template
 // assets/ignition/files/etc/sysctl.d/forward.conf
 // assets/ignition/files/etc/sysctl.d/inotify.conf
+// assets/ignition/files/etc/systemd/system/crio.service.d/10-proxy.conf
 // assets/ignition/files/etc/tmpfiles.d/cleanup-cni.conf
 // assets/ignition/units/kubelet.service
+// assets/ignition-server/ignition-server-deployment.yaml
+// assets/ignition-server/ignition-server-secret.yaml
+// assets/ignition-server/ignition-server-service.yaml
+// assets/ignition-server/ignition-server-token-secret.yaml
+// assets/image-content-sources/image-content-source-policy.yaml
+// assets/konnectivity/konnectivity-agent-daemonset.yaml
+// assets/konnectivity/konnectivity-agent-secret.yaml
+// assets/konnectivity/konnectivity-server-secret.yaml
+// assets/konnectivity/konnectivity-server-service.yaml
 // assets/kube-apiserver/client.conf
 // assets/kube-apiserver/config.yaml
+// assets/kube-apiserver/etcd-encryption-secret.yaml
+// assets/kube-apiserver/kube-apiserver-audit-policy-configmap.yaml
+// assets/kube-apiserver/kube-apiserver-audit-webhook-secret.yaml
 // assets/kube-apiserver/kube-apiserver-config-configmap.yaml
 // assets/kube-apiserver/kube-apiserver-configmap.yaml
 // assets/kube-apiserver/kube-apiserver-deployment.yaml
+// assets/kube-apiserver/kube-apiserver-egress-selector-configmap.yaml
+// assets/kube-apiserver/kube-apiserver-named-certs-secret.yaml
 // assets/kube-apiserver/kube-apiserver-oauth-metadata-configmap.yaml
 // assets/kube-apiserver/kube-apiserver-secret.yaml
 // assets/kube-apiserver/kube-apiserver-service.yaml
@@ -47,12 +83,30 @@
 // assets/kube-scheduler/kube-scheduler-config-configmap.yaml
 // assets/kube-scheduler/kube-scheduler-deployment.yaml
 // assets/kube-scheduler/kube-scheduler-secret.yaml
+// assets/monitoring/metrics-client-secret.yaml
+// assets/monitoring/metrics-proxy-rbac.yaml
+// assets/monitoring/metrics-reader-rbac.yaml
+// assets/monitoring/metrics-service-template.yaml
+// assets/monitoring/service-monitor-template.yaml
+// assets/network-policy/default-deny-network-policy.yaml
+// assets/network-policy/etcd-network-policy.yaml
+// assets/network-policy/ignition-server-network-policy.yaml
+// assets/network-policy/kube-apiserver-network-policy.yaml
+// assets/network-policy/metrics-network-policy.yaml
+// assets/network-policy/oauth-openshift-network-policy.yaml
+// assets/network-policy/openshift-apiserver-network-policy.yaml
+// assets/network-policy/openvpn-server-network-policy.yaml
+// assets/node-updater/node-updater-configmap.yaml
+// assets/node-updater/node-updater-daemonset.yaml
+// assets/node-updater/node-updater-namespace.yaml
+// assets/node-updater/node-updater-rbac.yaml
 // assets/oauth-openshift/oauth-browser-client.yaml
 // assets/oauth-openshift/oauth-challenging-client.yaml
 // assets/oauth-openshift/oauth-server-config-configmap.yaml
 // assets/oauth-openshift/oauth-server-config.yaml
 // assets/oauth-openshift/oauth-server-configmap.yaml
 // assets/oauth-openshift/oauth-server-deployment.yaml
+// assets/oauth-openshift/oauth-server-identity-providers-secret.yaml
 // assets/oauth-openshift/oauth-server-secret.yaml
 // assets/oauth-openshift/oauth-server-service.yaml
 // assets/oauth-openshift/oauth-server-sessionsecret-secret.yaml
@@ -77,6 +131,8 @@
 // assets/openshift-controller-manager/openshift-controller-manager-service-ca.yaml
 // assets/openvpn/Dockerfile
 // assets/openvpn/client.conf
+// assets/openvpn/fips.conf
+// assets/openvpn/openvpn-ca-secret.yaml
 // assets/openvpn/openvpn-ccd-configmap.yaml
 // assets/openvpn/openvpn-client-configmap.yaml
 // assets/openvpn/openvpn-client-deployment.yaml
@@ -134,6 +190,334 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
+var _apiserverFrontendApiserverFrontendDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: apiserver-frontend
+  namespace: {{ .Namespace }}
+---
+kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: apiserver-frontend
+  namespace: {{ .Namespace }}
+spec:
+  replicas: {{ .Replicas }}
+  selector:
+    matchLabels:
+      app: apiserver-frontend
+  template:
+    metadata:
+      labels:
+        app: apiserver-frontend
+    spec:
+      tolerations:
+      - key: "multi-az-worker"
+        operator: "Equal"
+        value: "true"
+        effect: NoSchedule
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+          - weight: 100
+            podAffinityTerm:
+              labelSelector:
+                matchExpressions:
+                - key: app
+                  operator: In
+                  values: ["apiserver-frontend"]
+              topologyKey: "failure-domain.beta.kubernetes.io/zone"
+      serviceAccountName: apiserver-frontend
+      automountServiceAccountToken: false
+      containers:
+      - name: apiserver-frontend
+        image: {{ .Image }}
+{{ include "common/security-context.yaml" 8 }}
+        imagePullPolicy: IfNotPresent
+        command:
+        - "/usr/bin/control-plane-operator"
+        - "apiserver-frontend"
+        - "--domain={{ .Domain }}"
+        - "--api-listen=:6443"
+        - "--oauth-listen=:8443"
+        - "--ignition-listen=:22623"
+        ports:
+        - name: api
+          containerPort: 6443
+        - name: oauth
+          containerPort: 8443
+        - name: ignition
+          containerPort: 22623
+        readinessProbe:
+          tcpSocket:
+            port: 6443
+          periodSeconds: 10
+        livenessProbe:
+          tcpSocket:
+            port: 6443
+          initialDelaySeconds: 10
+          periodSeconds: 30
+        resources:
+          requests:
+            cpu: 50m
+            memory: 64Mi
+`)
+
+func apiserverFrontendApiserverFrontendDeploymentYamlBytes() ([]byte, error) {
+	return _apiserverFrontendApiserverFrontendDeploymentYaml, nil
+}
+
+func apiserverFrontendApiserverFrontendDeploymentYaml() (*asset, error) {
+	bytes, err := apiserverFrontendApiserverFrontendDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "apiserver-frontend/apiserver-frontend-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _apiserverFrontendApiserverFrontendNamespaceYaml = []byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ .Namespace }}
+`)
+
+func apiserverFrontendApiserverFrontendNamespaceYamlBytes() ([]byte, error) {
+	return _apiserverFrontendApiserverFrontendNamespaceYaml, nil
+}
+
+func apiserverFrontendApiserverFrontendNamespaceYaml() (*asset, error) {
+	bytes, err := apiserverFrontendApiserverFrontendNamespaceYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "apiserver-frontend/apiserver-frontend-namespace.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _apiserverFrontendApiserverFrontendPdbYaml = []byte(`apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: apiserver-frontend
+  namespace: {{ .Namespace }}
+spec:
+  maxUnavailable: 1
+  selector:
+    matchLabels:
+      app: apiserver-frontend
+`)
+
+func apiserverFrontendApiserverFrontendPdbYamlBytes() ([]byte, error) {
+	return _apiserverFrontendApiserverFrontendPdbYaml, nil
+}
+
+func apiserverFrontendApiserverFrontendPdbYaml() (*asset, error) {
+	bytes, err := apiserverFrontendApiserverFrontendPdbYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "apiserver-frontend/apiserver-frontend-pdb.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _apiserverFrontendApiserverFrontendServiceYaml = []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: apiserver-frontend
+  namespace: {{ .Namespace }}{{ if .ServiceAnnotations }}
+  annotations:{{ range $key, $value := .ServiceAnnotations }}
+    {{ $key }}: "{{ $value }}"{{ end }}{{ end }}
+spec:
+  type: LoadBalancer
+  selector:
+    app: apiserver-frontend
+  ports:
+  - name: api
+    port: 6443
+    protocol: TCP
+    targetPort: 6443
+  - name: oauth
+    port: 8443
+    protocol: TCP
+    targetPort: 8443
+  - name: ignition
+    port: 22623
+    protocol: TCP
+    targetPort: 22623
+`)
+
+func apiserverFrontendApiserverFrontendServiceYamlBytes() ([]byte, error) {
+	return _apiserverFrontendApiserverFrontendServiceYaml, nil
+}
+
+func apiserverFrontendApiserverFrontendServiceYaml() (*asset, error) {
+	bytes, err := apiserverFrontendApiserverFrontendServiceYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "apiserver-frontend/apiserver-frontend-service.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _clusterAutoscalerClusterAutoscalerDeploymentYaml = []byte(`kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: cluster-autoscaler
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: cluster-autoscaler
+  template:
+    metadata:
+      labels:
+        app: cluster-autoscaler
+{{ include "common/pod-annotations.yaml" 6 }}
+    spec:
+      tolerations:
+        - key: "multi-az-worker"
+          operator: "Equal"
+          value: "true"
+          effect: NoSchedule
+      serviceAccountName: cluster-autoscaler
+      containers:
+      - name: cluster-autoscaler
+        image: {{ imageFor "cluster-autoscaler" }}
+{{ include "common/security-context.yaml" 8 }}
+        command:
+        - /usr/bin/cluster-autoscaler
+        args:
+        - "--cloud-provider=clusterapi"
+        - "--kubeconfig=/etc/kubernetes/kubeconfig/kubeconfig"
+        - "--clusterapi-cloud-config-authoritative"
+        - "--node-group-auto-discovery=clusterapi:namespace=openshift-machine-api,hypershift.openshift.io/cluster={{ .Namespace }}"
+        - "--leader-elect=false"
+        - "--v=2"
+        env:
+        - name: CAPI_GROUP
+          value: machine.openshift.io
+        volumeMounts:
+        - mountPath: /etc/kubernetes/kubeconfig
+          name: kubeconfig
+      volumes:
+      - secret:
+          secretName: service-network-admin-kubeconfig
+        name: kubeconfig
+`)
+
+func clusterAutoscalerClusterAutoscalerDeploymentYamlBytes() ([]byte, error) {
+	return _clusterAutoscalerClusterAutoscalerDeploymentYaml, nil
+}
+
+func clusterAutoscalerClusterAutoscalerDeploymentYaml() (*asset, error) {
+	bytes, err := clusterAutoscalerClusterAutoscalerDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "cluster-autoscaler/cluster-autoscaler-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _clusterAutoscalerClusterAutoscalerRbacYaml = []byte(`---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: cluster-autoscaler
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: hypershift-{{ .Namespace }}-cluster-autoscaler
+  namespace: openshift-machine-api
+  labels:
+    hypershift.openshift.io/cluster: {{ .Namespace }}
+rules:
+- apiGroups:
+  - machine.openshift.io
+  resources:
+  - machinesets
+  - machinesets/scale
+  - machines
+  verbs:
+  - get
+  - list
+  - watch
+  - update
+  - patch
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: hypershift-{{ .Namespace }}-cluster-autoscaler
+  namespace: openshift-machine-api
+  labels:
+    hypershift.openshift.io/cluster: {{ .Namespace }}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: hypershift-{{ .Namespace }}-cluster-autoscaler
+subjects:
+- kind: ServiceAccount
+  name: cluster-autoscaler
+  namespace: {{ .Namespace }}
+`)
+
+func clusterAutoscalerClusterAutoscalerRbacYamlBytes() ([]byte, error) {
+	return _clusterAutoscalerClusterAutoscalerRbacYaml, nil
+}
+
+func clusterAutoscalerClusterAutoscalerRbacYaml() (*asset, error) {
+	bytes, err := clusterAutoscalerClusterAutoscalerRbacYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "cluster-autoscaler/cluster-autoscaler-rbac.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _clusterAutoscalerMachineAutoscalerTemplateYaml = []byte(`apiVersion: autoscaling.openshift.io/v1beta1
+kind: MachineAutoscaler
+metadata:
+  name: {{ .Name }}
+  namespace: openshift-machine-api
+  labels:
+    hypershift.openshift.io/cluster: {{ .Cluster }}
+spec:
+  minReplicas: {{ .MinReplicas }}
+  maxReplicas: {{ .MaxReplicas }}
+  scaleTargetRef:
+    apiVersion: machine.openshift.io/v1beta1
+    kind: MachineSet
+    name: {{ .MachineSet }}
+`)
+
+func clusterAutoscalerMachineAutoscalerTemplateYamlBytes() ([]byte, error) {
+	return _clusterAutoscalerMachineAutoscalerTemplateYaml, nil
+}
+
+func clusterAutoscalerMachineAutoscalerTemplateYaml() (*asset, error) {
+	bytes, err := clusterAutoscalerMachineAutoscalerTemplateYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "cluster-autoscaler/machine-autoscaler-template.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _clusterBootstrap00000_namespacesNeededForMonitoringYaml = []byte(`---
 apiVersion: v1
 kind: Namespace
@@ -199,6 +583,7 @@ data:
       replicas: 1
     networking:
       machineCIDR: 10.0.0.0/16
+      networkType: {{ .NetworkType }}
     # read by image-registry-operator and ingress-operator
     platform:
       none: {}`)
@@ -406,14 +791,51 @@ func clusterBootstrapClusterNetwork02ConfigYaml() (*asset, error) {
 	return a, nil
 }
 
+var _clusterBootstrapClusterNetwork03ConfigYaml = []byte(`apiVersion: operator.openshift.io/v1
+kind: Network
+metadata:
+  name: cluster
+spec:
+  clusterNetwork:
+  - cidr: {{ .PodCIDR }}
+    hostPrefix: 23
+  serviceNetwork:
+  - {{ .ServiceCIDR }}
+  defaultNetwork:
+    type: {{ .NetworkType }}{{ if eq .NetworkType "OVNKubernetes" }}
+    ovnKubernetesConfig:
+      genevePort: 6081{{ else }}
+    openshiftSDNConfig:
+      mode: NetworkPolicy
+      vxlanPort: 4789{{ end }}
+`)
+
+func clusterBootstrapClusterNetwork03ConfigYamlBytes() ([]byte, error) {
+	return _clusterBootstrapClusterNetwork03ConfigYaml, nil
+}
+
+func clusterBootstrapClusterNetwork03ConfigYaml() (*asset, error) {
+	bytes, err := clusterBootstrapClusterNetwork03ConfigYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "cluster-bootstrap/cluster-network-03-config.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _clusterBootstrapClusterProxy01ConfigYaml = []byte(`apiVersion: config.openshift.io/v1
 kind: Proxy
 metadata:
   creationTimestamp: null
   name: cluster
-spec:
+spec:{{ if .HTTPProxy }}
+  httpProxy: "{{ .HTTPProxy }}"{{ end }}{{ if .HTTPSProxy }}
+  httpsProxy: "{{ .HTTPSProxy }}"{{ end }}{{ if .NoProxy }}
+  noProxy: "{{ .NoProxy }}"{{ end }}
   trustedCA:
-    name: ""
+    name: "{{ if .AdditionalTrustBundle }}user-ca-bundle{{ end }}"
 status: {}
 `)
 
@@ -432,6 +854,31 @@ func clusterBootstrapClusterProxy01ConfigYaml() (*asset, error) {
 	return a, nil
 }
 
+var _clusterBootstrapClusterProxy02UserCaBundleYaml = []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: user-ca-bundle
+  namespace: openshift-config
+data:
+  ca-bundle.crt: |
+{{ indent 4 .AdditionalTrustBundle }}
+`)
+
+func clusterBootstrapClusterProxy02UserCaBundleYamlBytes() ([]byte, error) {
+	return _clusterBootstrapClusterProxy02UserCaBundleYaml, nil
+}
+
+func clusterBootstrapClusterProxy02UserCaBundleYaml() (*asset, error) {
+	bytes, err := clusterBootstrapClusterProxy02UserCaBundleYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "cluster-bootstrap/cluster-proxy-02-user-ca-bundle.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _clusterBootstrapClusterVersionNamespaceYaml = []byte(`apiVersion: v1
 kind: Namespace
 metadata:
@@ -481,7 +928,43 @@ func clusterBootstrapNodeBootstrapperClusterrolebindingYaml() (*asset, error) {
 	return a, nil
 }
 
-var _clusterVersionOperatorClusterVersionOperatorDeploymentYaml = []byte(`apiVersion: apps/v1
+var _clusterVersionOperatorClusterVersionConfigmapYaml = []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cluster-version
+data:
+  release-image: "{{ .ReleaseImage }}"
+  channel: "{{ .ClusterVersion.Channel }}"
+  upstream: "{{ .ClusterVersion.Upstream }}"
+{{ if .ClusterVersion.Overrides }}  overrides: |
+{{ range .ClusterVersion.Overrides }}    - kind: {{ .Kind }}
+      group: "{{ .Group }}"
+      namespace: "{{ .Namespace }}"
+      name: {{ .Name }}
+{{ end }}{{ end }}
+`)
+
+func clusterVersionOperatorClusterVersionConfigmapYamlBytes() ([]byte, error) {
+	return _clusterVersionOperatorClusterVersionConfigmapYaml, nil
+}
+
+func clusterVersionOperatorClusterVersionConfigmapYaml() (*asset, error) {
+	bytes, err := clusterVersionOperatorClusterVersionConfigmapYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "cluster-version-operator/cluster-version-configmap.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _clusterVersionOperatorClusterVersionOperatorDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: cluster-version-operator
+---
+apiVersion: apps/v1
 kind: Deployment
 metadata:
   name: cluster-version-operator
@@ -495,20 +978,19 @@ spec:
       name: cluster-version-operator
       labels:
         k8s-app: cluster-version-operator
-{{ if .RestartDate }}
-      annotations:
-        openshift.io/restartedAt: "{{ .RestartDate }}"
-{{ end }}
+{{ include "common/pod-annotations.yaml" 6 }}
     spec:
       tolerations:
         - key: "multi-az-worker"
           operator: "Equal"
           value: "true"
           effect: NoSchedule
+      serviceAccountName: cluster-version-operator
       automountServiceAccountToken: false
       containers:
         - name: cluster-version-operator
           image: {{ .ReleaseImage }}
+{{ include "common/security-context.yaml" 10 }}
           imagePullPolicy: Always
           command:
             - "cluster-version-operator"
@@ -568,9 +1050,149 @@ func clusterVersionOperatorClusterVersionOperatorDeploymentYaml() (*asset, error
 	return a, nil
 }
 
-var _commonServiceNetworkAdminKubeconfigSecretYaml = []byte(`apiVersion: v1
-kind: Secret
-metadata:
+var _commonFipsServingInfoYaml = []byte(`cipherSuites:
+- TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256
+- TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+- TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384
+- TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384
+minTLSVersion: VersionTLS12
+`)
+
+func commonFipsServingInfoYamlBytes() ([]byte, error) {
+	return _commonFipsServingInfoYaml, nil
+}
+
+func commonFipsServingInfoYaml() (*asset, error) {
+	bytes, err := commonFipsServingInfoYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "common/fips-serving-info.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _commonPkiCaSecretYaml = []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: pki-ca
+data:
+  root-ca.crt: {{ pki "root-ca.crt" }}
+  root-ca.key: {{ pki "root-ca.key" }}
+`)
+
+func commonPkiCaSecretYamlBytes() ([]byte, error) {
+	return _commonPkiCaSecretYaml, nil
+}
+
+func commonPkiCaSecretYaml() (*asset, error) {
+	bytes, err := commonPkiCaSecretYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "common/pki-ca-secret.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _commonPodAnnotationsYaml = []byte(`{{ if or .RestartDate .PodSecurity.SeccompProfile }}annotations:{{ if .RestartDate }}
+  openshift.io/restartedAt: "{{ .RestartDate }}"{{ end }}{{ if .PodSecurity.SeccompProfile }}
+  seccomp.security.alpha.kubernetes.io/pod: "{{ .PodSecurity.SeccompProfile }}"{{ end }}{{ end }}
+`)
+
+func commonPodAnnotationsYamlBytes() ([]byte, error) {
+	return _commonPodAnnotationsYaml, nil
+}
+
+func commonPodAnnotationsYaml() (*asset, error) {
+	bytes, err := commonPodAnnotationsYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "common/pod-annotations.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _commonPodDisruptionBudgetTemplateYaml = []byte(`apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: {{ .Name }}
+spec:
+  maxUnavailable: 1
+  selector:
+    matchLabels:
+      app: {{ .Name }}
+`)
+
+func commonPodDisruptionBudgetTemplateYamlBytes() ([]byte, error) {
+	return _commonPodDisruptionBudgetTemplateYaml, nil
+}
+
+func commonPodDisruptionBudgetTemplateYaml() (*asset, error) {
+	bytes, err := commonPodDisruptionBudgetTemplateYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "common/pod-disruption-budget-template.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _commonProxyEnvYaml = []byte(`env:{{ if .HTTPProxy }}
+- name: HTTP_PROXY
+  value: "{{ .HTTPProxy }}"{{ end }}{{ if .HTTPSProxy }}
+- name: HTTPS_PROXY
+  value: "{{ .HTTPSProxy }}"{{ end }}
+- name: NO_PROXY
+  value: "{{ .EffectiveNoProxy }}"
+`)
+
+func commonProxyEnvYamlBytes() ([]byte, error) {
+	return _commonProxyEnvYaml, nil
+}
+
+func commonProxyEnvYaml() (*asset, error) {
+	bytes, err := commonProxyEnvYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "common/proxy-env.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _commonSecurityContextYaml = []byte(`securityContext:
+  allowPrivilegeEscalation: false
+  runAsNonRoot: true
+  capabilities:
+    drop:
+    - ALL
+`)
+
+func commonSecurityContextYamlBytes() ([]byte, error) {
+	return _commonSecurityContextYaml, nil
+}
+
+func commonSecurityContextYaml() (*asset, error) {
+	bytes, err := commonSecurityContextYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "common/security-context.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _commonServiceNetworkAdminKubeconfigSecretYaml = []byte(`apiVersion: v1
+kind: Secret
+metadata:
   name: service-network-admin-kubeconfig
 data:
   kubeconfig: {{ pki "internal-admin.kubeconfig" }}
@@ -591,6 +1213,39 @@ func commonServiceNetworkAdminKubeconfigSecretYaml() (*asset, error) {
 	return a, nil
 }
 
+var _commonVerticalPodAutoscalerTemplateYaml = []byte(`apiVersion: autoscaling.k8s.io/v1
+kind: VerticalPodAutoscaler
+metadata:
+  name: {{ .Name }}
+spec:
+  targetRef:
+    apiVersion: apps/v1
+    kind: {{ .Kind }}
+    name: {{ .Name }}
+  updatePolicy:
+    updateMode: {{ .UpdateMode }}
+  resourcePolicy:
+    containerPolicies:
+    - containerName: "*"
+      controlledResources: ["cpu", "memory"]
+      controlledValues: RequestsOnly
+`)
+
+func commonVerticalPodAutoscalerTemplateYamlBytes() ([]byte, error) {
+	return _commonVerticalPodAutoscalerTemplateYaml, nil
+}
+
+func commonVerticalPodAutoscalerTemplateYaml() (*asset, error) {
+	bytes, err := commonVerticalPodAutoscalerTemplateYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "common/vertical-pod-autoscaler-template.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _controlPlaneOperatorCpOperatorConfigmapYaml = []byte(`apiVersion: v1
 kind: ConfigMap
 metadata:
@@ -638,6 +1293,30 @@ rules:
   - create
   - list
   - watch
+- apiGroups:
+  - ""
+  resources:
+  - secrets
+  verbs:
+  - get
+  - list
+  - watch
+  - update
+- apiGroups:
+  - ""
+  resources:
+  - events
+  verbs:
+  - create
+  - patch
+- apiGroups:
+  - ""
+  resources:
+  - services
+  verbs:
+  - get
+  - list
+  - watch
 - apiGroups: ["extensions", "apps"]
   resources:
   - deployments
@@ -647,6 +1326,14 @@ rules:
   - update
   - list
   - watch
+- apiGroups:
+  - route.openshift.io
+  resources:
+  - routes
+  verbs:
+  - get
+  - list
+  - watch
 ---
 apiVersion: rbac.authorization.k8s.io/v1
 kind: RoleBinding
@@ -665,7 +1352,7 @@ kind: Deployment
 metadata:
   name: control-plane-operator
 spec:
-  replicas: 1
+  replicas: {{ .Replicas }}
   selector:
     matchLabels:
       app: control-plane-operator
@@ -673,10 +1360,7 @@ spec:
     metadata:
       labels:
         app: control-plane-operator
-{{ if .RestartDate }}
-      annotations:
-        openshift.io/restartedAt: "{{ .RestartDate }}"
-{{ end }}
+{{ include "common/pod-annotations.yaml" 6 }}
     spec:
       tolerations:
         - key: "multi-az-worker"
@@ -705,8 +1389,27 @@ spec:
         - "--initial-ca-file=/etc/kubernetes/config/initial-ca.crt"
         - "--target-kubeconfig=/etc/kubernetes/kubeconfig/kubeconfig"
         - "--namespace"
-        - "$(POD_NAMESPACE)"{{range $controller := .ControlPlaneOperatorControllers }}
+        - "$(POD_NAMESPACE)"{{ if .PKI.CertValidity }}
+        - "--cert-validity={{ .PKI.CertValidity }}"{{ end }}{{ if .KubeletServingCASyncInterval }}
+        - "--kubelet-serving-ca-sync-interval={{ .KubeletServingCASyncInterval }}"{{ end }}{{ if .Monitoring.Enabled }}
+        - "--metrics-addr=127.0.0.1:8080"{{ end }}{{range $controller := .ControlPlaneOperatorControllers }}
         - "--controllers={{$controller}}"{{end}}
+        - "--controllers-configmap=control-plane-operator-controllers"
+        ports:
+        - name: health
+          containerPort: 8081
+        readinessProbe:
+          httpGet:
+            path: /readyz
+            port: 8081
+          periodSeconds: 10
+        livenessProbe:
+          httpGet:
+            path: /healthz
+            port: 8081
+          initialDelaySeconds: 10
+          periodSeconds: 30
+          failureThreshold: 3
 {{ if .ControlPlaneOperatorResources }}
         resources:{{ range .ControlPlaneOperatorResources }}{{ range .ResourceRequest }}
           requests: {{ if .CPU }}
@@ -721,6 +1424,24 @@ spec:
           name: kubeconfig
         - mountPath: /etc/kubernetes/config
           name: config
+{{ if .Monitoring.Enabled }}
+      - name: metrics-proxy
+        image: {{ imageFor "kube-rbac-proxy" }}
+{{ include "common/security-context.yaml" 8 }}
+        args:
+        - "--secure-listen-address=0.0.0.0:9443"
+        - "--upstream=http://127.0.0.1:8080/"
+        - "--tls-cert-file=/etc/metrics-proxy/serving/tls.crt"
+        - "--tls-private-key-file=/etc/metrics-proxy/serving/tls.key"
+        - "--allow-paths=/metrics"
+        - "--logtostderr=true"
+        ports:
+        - name: metrics
+          containerPort: 9443
+        volumeMounts:
+        - mountPath: /etc/metrics-proxy/serving/
+          name: metrics-serving
+{{ end }}
       restartPolicy: Always
       serviceAccountName: control-plane-operator
       volumes:
@@ -730,6 +1451,11 @@ spec:
       - name: config
         configMap:
           name: control-plane-operator
+{{ if .Monitoring.Enabled }}
+      - secret:
+          secretName: control-plane-operator-metrics-tls
+        name: metrics-serving
+{{ end }}
 `)
 
 func controlPlaneOperatorCpOperatorDeploymentYamlBytes() ([]byte, error) {
@@ -790,8 +1516,29 @@ kind: EtcdCluster
 metadata:
   name: etcd
 spec:
-  size: 1
+  size: {{ .Replicas }}
   version: "3.2.13"
+  pod:
+    affinity:
+      podAntiAffinity:
+        requiredDuringSchedulingIgnoredDuringExecution:
+          - labelSelector:
+              matchLabels:
+                etcd_cluster: etcd
+            topologyKey: "kubernetes.io/hostname"
+          - labelSelector:
+              matchLabels:
+                etcd_cluster: etcd
+            topologyKey: "failure-domain.beta.kubernetes.io/zone"
+{{ if .EtcdResources }}
+    resources:{{ range .EtcdResources }}{{ range .ResourceRequest }}
+      requests: {{ if .CPU }}
+        cpu: {{ .CPU }}{{ end }}{{ if .Memory }}
+        memory: {{ .Memory }}{{ end }}{{ end }}{{ range .ResourceLimit }}
+      limits: {{ if .CPU }}
+        cpu: {{ .CPU }}{{ end }}{{ if .Memory }}
+        memory: {{ .Memory }}{{ end }}{{ end }}{{ end }}
+{{ end }}
   TLS:
     static:
       member:
@@ -815,6 +1562,66 @@ func etcdEtcdClusterYaml() (*asset, error) {
 	return a, nil
 }
 
+var _etcdEtcdDefragCronjobTemplateYaml = []byte(`apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: etcd-defrag
+spec:
+  schedule: "{{ .Schedule }}"
+  concurrencyPolicy: Forbid
+  successfulJobsHistoryLimit: 1
+  failedJobsHistoryLimit: 3
+  jobTemplate:
+    spec:
+      backoffLimit: 2
+      template:
+        metadata:
+          labels:
+            app: etcd-defrag
+        spec:
+          restartPolicy: OnFailure
+          automountServiceAccountToken: false
+          containers:
+          - name: defrag
+            image: {{ imageFor "etcd" }}
+            command:
+            - /bin/sh
+            - -c
+            - |
+              for i in $(seq 0 $(({{ .Replicas }} - 1))); do
+                etcdctl --endpoints=https://etcd-${i}.etcd.{{ .Namespace }}.svc:2379 \
+                  --cacert=/etc/etcd/client/etcd-client-ca.crt \
+                  --cert=/etc/etcd/client/etcd-client.crt \
+                  --key=/etc/etcd/client/etcd-client.key \
+                  defrag || exit 1
+              done
+            env:
+            - name: ETCDCTL_API
+              value: "3"
+            volumeMounts:
+            - mountPath: /etc/etcd/client
+              name: client-tls
+          volumes:
+          - secret:
+              secretName: etcd-client-tls
+            name: client-tls
+`)
+
+func etcdEtcdDefragCronjobTemplateYamlBytes() ([]byte, error) {
+	return _etcdEtcdDefragCronjobTemplateYaml, nil
+}
+
+func etcdEtcdDefragCronjobTemplateYaml() (*asset, error) {
+	bytes, err := etcdEtcdDefragCronjobTemplateYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "etcd/etcd-defrag-cronjob-template.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _etcdEtcdOperatorClusterRoleBindingYaml = []byte(`apiVersion: rbac.authorization.k8s.io/v1beta1
 kind: ClusterRoleBinding
 metadata:
@@ -922,15 +1729,13 @@ spec:
     metadata:
       labels:
         name: etcd-operator
-{{ if .RestartDate }}
-      annotations:
-        openshift.io/restartedAt: "{{ .RestartDate }}"
-{{ end }}
+{{ include "common/pod-annotations.yaml" 6 }}
     spec:
       serviceAccountName: etcd-operator
       containers:
       - name: etcd-operator
         image: quay.io/coreos/etcd-operator:v0.9.4
+{{ include "common/security-context.yaml" 8 }}
         command:
         - etcd-operator
         env:
@@ -984,84 +1789,315 @@ func etcdEtcdSecretTemplateYaml() (*asset, error) {
 	return a, nil
 }
 
-var _ignitionFilesEtcCrioCrioConf = []byte(`[crio]
-
-# The default log directory where all logs will go unless directly specified by
-# the kubelet. The log directory specified must be an absolute directory.
-log_dir = "/var/log/crio/pods"
-
-# Location for CRI-O to lay down the version file
-version_file = "/var/lib/crio/version"
-
-# The crio.api table contains settings for the kubelet/gRPC interface.
-[crio.api]
-
-# Path to AF_LOCAL socket on which CRI-O will listen.
-listen = "/var/run/crio/crio.sock"
-
-# Host IP considered as the primary IP to use by CRI-O for things such as host network IP.
-host_ip = ""
-
-# IP address on which the stream server will listen.
-stream_address = ""
-
-# The port on which the stream server will listen.
-stream_port = "10010"
-
-# Enable encrypted TLS transport of the stream server.
-stream_enable_tls = false
-
-# Path to the x509 certificate file used to serve the encrypted stream. This
-# file can change, and CRI-O will automatically pick up the changes within 5
-# minutes.
-stream_tls_cert = ""
-
-# Path to the key file used to serve the encrypted stream. This file can
-# change and CRI-O will automatically pick up the changes within 5 minutes.
-stream_tls_key = ""
-
-# Path to the x509 CA(s) file used to verify and authenticate client
-# communication with the encrypted stream. This file can change and CRI-O will
-# automatically pick up the changes within 5 minutes.
-stream_tls_ca = ""
-
-# Maximum grpc send message size in bytes. If not set or <=0, then CRI-O will default to 16 * 1024 * 1024.
-grpc_max_send_msg_size = 16777216
-
-# Maximum grpc receive message size. If not set or <= 0, then CRI-O will default to 16 * 1024 * 1024.
-grpc_max_recv_msg_size = 16777216
-
-# The crio.runtime table contains settings pertaining to the OCI runtime used
-# and options for how to set up and manage the OCI runtime.
-[crio.runtime]
-
-# default_runtime is the _name_ of the OCI runtime to be used as the default.
-# The name is matched against the runtimes map below.
-default_runtime = "runc"
-
-# If true, the runtime will not use pivot_root, but instead use MS_MOVE.
-no_pivot = false
-
-# Path to the conmon binary, used for monitoring the OCI runtime.
-# Will be searched for using $PATH if empty.
-conmon = "/usr/libexec/crio/conmon"
+var _etcdEtcdServicesYaml = []byte(`---
+apiVersion: v1
+kind: Service
+metadata:
+  name: etcd
+  labels:
+    app: etcd
+spec:
+  clusterIP: None
+  publishNotReadyAddresses: true
+  selector:
+    app: etcd
+  ports:
+  - name: client
+    port: 2379
+    targetPort: 2379
+  - name: peer
+    port: 2380
+    targetPort: 2380
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: etcd-client
+  labels:
+    app: etcd
+spec:
+  selector:
+    app: etcd
+  ports:
+  - name: client
+    port: 2379
+    targetPort: 2379
+`)
 
-# Cgroup setting for conmon
-conmon_cgroup = "pod"
+func etcdEtcdServicesYamlBytes() ([]byte, error) {
+	return _etcdEtcdServicesYaml, nil
+}
 
-# Environment variable list for the conmon process, used for passing necessary
-# environment variables to conmon or the runtime.
-conmon_env = [
-    "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
-]
+func etcdEtcdServicesYaml() (*asset, error) {
+	bytes, err := etcdEtcdServicesYamlBytes()
+	if err != nil {
+		return nil, err
+	}
 
-# If true, SELinux will be used for pod separation on the host.
-selinux = true
+	info := bindataFileInfo{name: "etcd/etcd-services.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
 
-# Path to the seccomp.json profile which is used as the default seccomp profile
-# for the runtime. If not specified, then the internal default seccomp profile
-# will be used.
-seccomp_profile = ""
+var _etcdEtcdStatefulsetTemplateYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: etcd
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: etcd
+spec:
+  serviceName: etcd
+  replicas: {{ .Replicas }}
+  podManagementPolicy: Parallel
+  selector:
+    matchLabels:
+      app: etcd
+  template:
+    metadata:
+      labels:
+        app: etcd
+{{ if .SeccompProfile }}
+      annotations:
+        seccomp.security.alpha.kubernetes.io/pod: "{{ .SeccompProfile }}"
+{{ end }}
+    spec:
+      tolerations:
+        - key: "multi-az-worker"
+          operator: "Equal"
+          value: "true"
+          effect: NoSchedule
+      affinity:
+        podAntiAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+            - labelSelector:
+                matchExpressions:
+                  - key: app
+                    operator: In
+                    values: ["etcd"]
+              topologyKey: "kubernetes.io/hostname"
+            - labelSelector:
+                matchExpressions:
+                  - key: app
+                    operator: In
+                    values: ["etcd"]
+              topologyKey: "failure-domain.beta.kubernetes.io/zone"
+      serviceAccountName: etcd
+{{ if not .Monitoring.Enabled }}
+      automountServiceAccountToken: false
+{{ end }}
+      containers:
+      - name: etcd
+        image: {{ imageFor "etcd" }}
+{{ include "common/security-context.yaml" 8 }}
+        command:
+        - /bin/sh
+        - -c
+        - |
+          INITIAL_CLUSTER=""
+          for i in $(seq 0 $(({{ .Replicas }} - 1))); do
+            INITIAL_CLUSTER="${INITIAL_CLUSTER}${INITIAL_CLUSTER:+,}etcd-${i}=https://etcd-${i}.etcd.{{ .Namespace }}.svc:2380"
+          done
+          exec etcd \
+            --name=${HOSTNAME} \
+            --data-dir=/var/lib/etcd/data \
+            --listen-client-urls=https://0.0.0.0:2379 \
+            --advertise-client-urls=https://${HOSTNAME}.etcd.{{ .Namespace }}.svc:2379 \
+            --listen-peer-urls=https://0.0.0.0:2380 \
+            --initial-advertise-peer-urls=https://${HOSTNAME}.etcd.{{ .Namespace }}.svc:2380 \
+            --initial-cluster=${INITIAL_CLUSTER} \
+            --initial-cluster-state=new \
+            --initial-cluster-token=etcd \
+            --cert-file=/etc/etcd/server/server.crt \
+            --key-file=/etc/etcd/server/server.key \
+            --trusted-ca-file=/etc/etcd/server/server-ca.crt \
+            --client-cert-auth=true \
+            --peer-cert-file=/etc/etcd/peer/peer.crt \
+            --peer-key-file=/etc/etcd/peer/peer.key \
+            --peer-trusted-ca-file=/etc/etcd/peer/peer-ca.crt \
+            --peer-client-cert-auth=true
+        env:
+        - name: ETCDCTL_API
+          value: "3"
+        ports:
+        - name: client
+          containerPort: 2379
+        - name: peer
+          containerPort: 2380
+        readinessProbe:
+          exec:
+            command:
+            - /bin/sh
+            - -c
+            - etcdctl --endpoints=https://localhost:2379 --cacert=/etc/etcd/client/etcd-client-ca.crt --cert=/etc/etcd/client/etcd-client.crt --key=/etc/etcd/client/etcd-client.key endpoint health
+          initialDelaySeconds: 10
+          periodSeconds: 10
+          timeoutSeconds: 10
+{{ if .Resources }}
+        resources:{{ range .Resources }}{{ range .ResourceRequest }}
+          requests: {{ if .CPU }}
+            cpu: {{ .CPU }}{{ end }}{{ if .Memory }}
+            memory: {{ .Memory }}{{ end }}{{ end }}{{ range .ResourceLimit }}
+          limits: {{ if .CPU }}
+            cpu: {{ .CPU }}{{ end }}{{ if .Memory }}
+            memory: {{ .Memory }}{{ end }}{{ end }}{{ end }}
+{{ end }}
+        volumeMounts:
+        - mountPath: /var/lib/etcd
+          name: data
+        - mountPath: /etc/etcd/server
+          name: server-tls
+        - mountPath: /etc/etcd/peer
+          name: peer-tls
+        - mountPath: /etc/etcd/client
+          name: client-tls
+{{ if .Monitoring.Enabled }}
+      - name: metrics-proxy
+        image: {{ imageFor "kube-rbac-proxy" }}
+{{ include "common/security-context.yaml" 8 }}
+        args:
+        - "--secure-listen-address=0.0.0.0:9443"
+        - "--upstream=https://localhost:2379/"
+        - "--upstream-ca-file=/etc/etcd/client/etcd-client-ca.crt"
+        - "--upstream-client-cert-file=/etc/etcd/client/etcd-client.crt"
+        - "--upstream-client-key-file=/etc/etcd/client/etcd-client.key"
+        - "--tls-cert-file=/etc/metrics-proxy/serving/tls.crt"
+        - "--tls-private-key-file=/etc/metrics-proxy/serving/tls.key"
+        - "--allow-paths=/metrics"
+        - "--logtostderr=true"
+        ports:
+        - name: metrics
+          containerPort: 9443
+        volumeMounts:
+        - mountPath: /etc/metrics-proxy/serving/
+          name: metrics-serving
+        - mountPath: /etc/etcd/client
+          name: client-tls
+{{ end }}
+      volumes:
+      - secret:
+          secretName: etcd-server-tls
+        name: server-tls
+      - secret:
+          secretName: etcd-peer-tls
+        name: peer-tls
+      - secret:
+          secretName: etcd-client-tls
+        name: client-tls
+{{ if .Monitoring.Enabled }}
+      - secret:
+          secretName: etcd-metrics-tls
+        name: metrics-serving
+{{ end }}
+  volumeClaimTemplates:
+  - metadata:
+      name: data
+    spec:
+      accessModes:
+      - ReadWriteOnce
+{{ if .StorageClass }}
+      storageClassName: {{ .StorageClass }}
+{{ end }}
+      resources:
+        requests:
+          storage: {{ .StorageSize }}
+`)
+
+func etcdEtcdStatefulsetTemplateYamlBytes() ([]byte, error) {
+	return _etcdEtcdStatefulsetTemplateYaml, nil
+}
+
+func etcdEtcdStatefulsetTemplateYaml() (*asset, error) {
+	bytes, err := etcdEtcdStatefulsetTemplateYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "etcd/etcd-statefulset-template.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _ignitionFilesEtcCrioCrioConf = []byte(`[crio]
+
+# The default log directory where all logs will go unless directly specified by
+# the kubelet. The log directory specified must be an absolute directory.
+log_dir = "/var/log/crio/pods"
+
+# Location for CRI-O to lay down the version file
+version_file = "/var/lib/crio/version"
+
+# The crio.api table contains settings for the kubelet/gRPC interface.
+[crio.api]
+
+# Path to AF_LOCAL socket on which CRI-O will listen.
+listen = "/var/run/crio/crio.sock"
+
+# Host IP considered as the primary IP to use by CRI-O for things such as host network IP.
+host_ip = ""
+
+# IP address on which the stream server will listen.
+stream_address = ""
+
+# The port on which the stream server will listen.
+stream_port = "10010"
+
+# Enable encrypted TLS transport of the stream server.
+stream_enable_tls = false
+
+# Path to the x509 certificate file used to serve the encrypted stream. This
+# file can change, and CRI-O will automatically pick up the changes within 5
+# minutes.
+stream_tls_cert = ""
+
+# Path to the key file used to serve the encrypted stream. This file can
+# change and CRI-O will automatically pick up the changes within 5 minutes.
+stream_tls_key = ""
+
+# Path to the x509 CA(s) file used to verify and authenticate client
+# communication with the encrypted stream. This file can change and CRI-O will
+# automatically pick up the changes within 5 minutes.
+stream_tls_ca = ""
+
+# Maximum grpc send message size in bytes. If not set or <=0, then CRI-O will default to 16 * 1024 * 1024.
+grpc_max_send_msg_size = 16777216
+
+# Maximum grpc receive message size. If not set or <= 0, then CRI-O will default to 16 * 1024 * 1024.
+grpc_max_recv_msg_size = 16777216
+
+# The crio.runtime table contains settings pertaining to the OCI runtime used
+# and options for how to set up and manage the OCI runtime.
+[crio.runtime]
+
+# default_runtime is the _name_ of the OCI runtime to be used as the default.
+# The name is matched against the runtimes map below.
+default_runtime = "runc"
+
+# If true, the runtime will not use pivot_root, but instead use MS_MOVE.
+no_pivot = false
+
+# Path to the conmon binary, used for monitoring the OCI runtime.
+# Will be searched for using $PATH if empty.
+conmon = "/usr/libexec/crio/conmon"
+
+# Cgroup setting for conmon
+conmon_cgroup = "pod"
+
+# Environment variable list for the conmon process, used for passing necessary
+# environment variables to conmon or the runtime.
+conmon_env = [
+    "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+]
+
+# If true, SELinux will be used for pod separation on the host.
+selinux = true
+
+# Path to the seccomp.json profile which is used as the default seccomp profile
+# for the runtime. If not specified, then the internal default seccomp profile
+# will be used.
+seccomp_profile = ""
 
 # Used to change the name of the default AppArmor profile of CRI-O. The default
 # profile name is "crio-default-" followed by the version string of CRI-O.
@@ -1291,6 +2327,27 @@ func ignitionFilesEtcKubernetesKubeletConfTemplate() (*asset, error) {
 	return a, nil
 }
 
+var _ignitionFilesEtcKubernetesProxyEnvTemplate = []byte(`{{ if .ProxyEnabled }}{{ if .HTTPProxy }}HTTP_PROXY={{ .HTTPProxy }}
+{{ end }}{{ if .HTTPSProxy }}HTTPS_PROXY={{ .HTTPSProxy }}
+{{ end }}NO_PROXY={{ .EffectiveNoProxy }}
+{{ end }}
+`)
+
+func ignitionFilesEtcKubernetesProxyEnvTemplateBytes() ([]byte, error) {
+	return _ignitionFilesEtcKubernetesProxyEnvTemplate, nil
+}
+
+func ignitionFilesEtcKubernetesProxyEnvTemplate() (*asset, error) {
+	bytes, err := ignitionFilesEtcKubernetesProxyEnvTemplateBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "ignition/files/etc/kubernetes/proxy.env.template", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _ignitionFilesEtcSysctlDForwardConf = []byte(`net.ipv4.ip_forward = 1
 `)
 
@@ -1329,6 +2386,25 @@ func ignitionFilesEtcSysctlDInotifyConf() (*asset, error) {
 	return a, nil
 }
 
+var _ignitionFilesEtcSystemdSystemCrioServiceD10ProxyConf = []byte(`[Service]
+EnvironmentFile=-/etc/kubernetes/proxy.env
+`)
+
+func ignitionFilesEtcSystemdSystemCrioServiceD10ProxyConfBytes() ([]byte, error) {
+	return _ignitionFilesEtcSystemdSystemCrioServiceD10ProxyConf, nil
+}
+
+func ignitionFilesEtcSystemdSystemCrioServiceD10ProxyConf() (*asset, error) {
+	bytes, err := ignitionFilesEtcSystemdSystemCrioServiceD10ProxyConfBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "ignition/files/etc/systemd/system/crio.service.d/10-proxy.conf", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _ignitionFilesEtcTmpfilesDCleanupCniConf = []byte(`r /etc/kubernetes/cni/net.d/80-openshift-network.conf
 r /etc/kubernetes/cni/net.d/10-ovn-kubernetes.conf
 r /etc/kubernetes/cni/net.d/00-multus.conf
@@ -1357,6 +2433,7 @@ After=crio.service
 Requires=crio.service
 
 [Service]
+EnvironmentFile=-/etc/kubernetes/proxy.env
 ExecStartPre=/bin/mkdir -p /etc/kubernetes/manifests
 ExecStart=/usr/bin/hyperkube kubelet \
   --config=/etc/kubernetes/kubelet.conf \
@@ -1388,43 +2465,416 @@ func ignitionUnitsKubeletService() (*asset, error) {
 	return a, nil
 }
 
-var _kubeApiserverClientConf = []byte(`client
-verb 3
-nobind
-dev tun
-remote-cert-tls server
-remote openvpn-server 1194 udp
-ca secret/ca.crt
-cert secret/tls.crt
-key secret/tls.key
+var _ignitionServerIgnitionServerDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: ignition-server
+---
+kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: ignition-server
+spec:
+  replicas: {{ .Replicas }}
+  selector:
+    matchLabels:
+      app: ignition-server
+  template:
+    metadata:
+      labels:
+        app: ignition-server
+{{ include "common/pod-annotations.yaml" 6 }}
+    spec:
+      tolerations:
+      - key: "multi-az-worker"
+        operator: "Equal"
+        value: "true"
+        effect: NoSchedule
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+          - weight: 100
+            podAffinityTerm:
+              labelSelector:
+                matchExpressions:
+                - key: app
+                  operator: In
+                  values: ["ignition-server"]
+              topologyKey: "failure-domain.beta.kubernetes.io/zone"
+      serviceAccountName: ignition-server
+      automountServiceAccountToken: false
+      containers:
+      - name: ignition-server
+        image: {{ .ControlPlaneOperatorImage }}
+{{ include "common/security-context.yaml" 8 }}
+        imagePullPolicy: IfNotPresent
+        command:
+        - "/usr/bin/control-plane-operator"
+        - "ignition-server"
+        - "--config-file=/etc/ignition-server/config/worker.ign"
+        - "--token-file=/etc/ignition-server/token/token"
+        - "--tls-cert-file=/etc/ignition-server/tls/tls.crt"
+        - "--tls-key-file=/etc/ignition-server/tls/tls.key"
+        - "--listen=:8443"
+        ports:
+        - name: https
+          containerPort: 8443
+        readinessProbe:
+          httpGet:
+            path: /healthz
+            port: 8443
+            scheme: HTTPS
+          periodSeconds: 10
+        livenessProbe:
+          httpGet:
+            path: /healthz
+            port: 8443
+            scheme: HTTPS
+          initialDelaySeconds: 10
+          periodSeconds: 30
+{{ if .IgnitionServerResources }}
+        resources:{{ range .IgnitionServerResources }}{{ range .ResourceRequest }}
+          requests: {{ if .CPU }}
+            cpu: {{ .CPU }}{{ end }}{{ if .Memory }}
+            memory: {{ .Memory }}{{ end }}{{ end }}{{ range .ResourceLimit }}
+          limits: {{ if .CPU }}
+            cpu: {{ .CPU }}{{ end }}{{ if .Memory }}
+            memory: {{ .Memory }}{{ end }}{{ end }}{{ end }}
+{{ end }}
+        volumeMounts:
+        - mountPath: /etc/ignition-server/config
+          name: config
+        - mountPath: /etc/ignition-server/token
+          name: token
+        - mountPath: /etc/ignition-server/tls
+          name: tls
+      volumes:
+      - name: config
+        secret:
+          secretName: ignition-server-config
+      - name: token
+        secret:
+          secretName: ignition-server-token
+      - name: tls
+        secret:
+          secretName: ignition-server
 `)
 
-func kubeApiserverClientConfBytes() ([]byte, error) {
-	return _kubeApiserverClientConf, nil
+func ignitionServerIgnitionServerDeploymentYamlBytes() ([]byte, error) {
+	return _ignitionServerIgnitionServerDeploymentYaml, nil
 }
 
-func kubeApiserverClientConf() (*asset, error) {
-	bytes, err := kubeApiserverClientConfBytes()
+func ignitionServerIgnitionServerDeploymentYaml() (*asset, error) {
+	bytes, err := ignitionServerIgnitionServerDeploymentYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "kube-apiserver/client.conf", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "ignition-server/ignition-server-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _kubeApiserverConfigYaml = []byte(`---
-apiVersion: kubecontrolplane.config.openshift.io/v1
-kind: KubeAPIServerConfig
-admission:
-  pluginConfig:
-    network.openshift.io/ExternalIPRanger:
-      configuration:
-        allowIngressIP: false
-        apiVersion: network.openshift.io/v1
-        externalIPNetworkCIDRs:
-        kind: ExternalIPRangerAdmissionConfig
+var _ignitionServerIgnitionServerSecretYaml = []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: ignition-server
+data:
+  tls.crt: {{ pki "ignition-server.crt" }}
+  tls.key: {{ pki "ignition-server.key" }}
+`)
+
+func ignitionServerIgnitionServerSecretYamlBytes() ([]byte, error) {
+	return _ignitionServerIgnitionServerSecretYaml, nil
+}
+
+func ignitionServerIgnitionServerSecretYaml() (*asset, error) {
+	bytes, err := ignitionServerIgnitionServerSecretYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "ignition-server/ignition-server-secret.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _ignitionServerIgnitionServerServiceYaml = []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: ignition-server
+spec:
+  selector:
+    app: ignition-server
+  ports:
+  - name: https
+    port: 443
+    targetPort: 8443
+    nodePort: {{ .ExternalIgnitionPort }}
+  type: {{ if .APIServiceType }}{{ .APIServiceType }}{{ else }}NodePort{{ end }}
+`)
+
+func ignitionServerIgnitionServerServiceYamlBytes() ([]byte, error) {
+	return _ignitionServerIgnitionServerServiceYaml, nil
+}
+
+func ignitionServerIgnitionServerServiceYaml() (*asset, error) {
+	bytes, err := ignitionServerIgnitionServerServiceYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "ignition-server/ignition-server-service.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _ignitionServerIgnitionServerTokenSecretYaml = []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: ignition-server-token
+data:
+  token: {{ base64String .IgnitionServerToken }}
+`)
+
+func ignitionServerIgnitionServerTokenSecretYamlBytes() ([]byte, error) {
+	return _ignitionServerIgnitionServerTokenSecretYaml, nil
+}
+
+func ignitionServerIgnitionServerTokenSecretYaml() (*asset, error) {
+	bytes, err := ignitionServerIgnitionServerTokenSecretYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "ignition-server/ignition-server-token-secret.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _imageContentSourcesImageContentSourcePolicyYaml = []byte(`apiVersion: operator.openshift.io/v1alpha1
+kind: ImageContentSourcePolicy
+metadata:
+  name: hypershift-mirrors
+spec:
+  repositoryDigestMirrors:
+{{- range .ImageContentSources }}
+  - source: {{ .Source }}
+    mirrors:
+{{- range .Mirrors }}
+    - {{ . }}
+{{- end }}
+{{- end }}
+`)
+
+func imageContentSourcesImageContentSourcePolicyYamlBytes() ([]byte, error) {
+	return _imageContentSourcesImageContentSourcePolicyYaml, nil
+}
+
+func imageContentSourcesImageContentSourcePolicyYaml() (*asset, error) {
+	bytes, err := imageContentSourcesImageContentSourcePolicyYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "image-content-sources/image-content-source-policy.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _konnectivityKonnectivityAgentDaemonsetYaml = []byte(`kind: DaemonSet
+apiVersion: apps/v1
+metadata:
+  name: konnectivity-agent
+  namespace: kube-system
+spec:
+  selector:
+    matchLabels:
+      app: konnectivity-agent
+  template:
+    metadata:
+      labels:
+        app: konnectivity-agent
+    spec:
+      automountServiceAccountToken: false
+      hostNetwork: true
+      priorityClassName: system-node-critical
+      tolerations:
+      - operator: Exists
+      containers:
+      - name: konnectivity-agent
+        image: {{ .KonnectivityAgentImage }}
+        command:
+        - /proxy-agent
+        args:
+        - --logtostderr=true
+        - --ca-cert=/etc/konnectivity/ca.crt
+        - --agent-cert=/etc/konnectivity/tls.crt
+        - --agent-key=/etc/konnectivity/tls.key
+        - --proxy-server-host={{ .ExternalKonnectivityDNSName }}
+        - --proxy-server-port={{ .ExternalKonnectivityPort }}
+        - --health-server-port=8134
+        livenessProbe:
+          httpGet:
+            scheme: HTTP
+            port: 8134
+            path: /healthz
+          initialDelaySeconds: 15
+          timeoutSeconds: 15
+        volumeMounts:
+        - mountPath: /etc/konnectivity
+          name: secret
+      volumes:
+      - secret:
+          secretName: konnectivity-agent
+        name: secret
+`)
+
+func konnectivityKonnectivityAgentDaemonsetYamlBytes() ([]byte, error) {
+	return _konnectivityKonnectivityAgentDaemonsetYaml, nil
+}
+
+func konnectivityKonnectivityAgentDaemonsetYaml() (*asset, error) {
+	bytes, err := konnectivityKonnectivityAgentDaemonsetYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "konnectivity/konnectivity-agent-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _konnectivityKonnectivityAgentSecretYaml = []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: user-manifest-konnectivity-agent-secret
+data:
+  data: |
+    apiVersion: v1
+    kind: Secret
+    metadata:
+      name: konnectivity-agent
+      namespace: kube-system
+    data:
+      tls.crt: {{ pki "konnectivity-agent.crt" }}
+      tls.key: {{ pki "konnectivity-agent.key" }}
+      ca.crt: {{ pki "konnectivity-ca.crt" }}
+`)
+
+func konnectivityKonnectivityAgentSecretYamlBytes() ([]byte, error) {
+	return _konnectivityKonnectivityAgentSecretYaml, nil
+}
+
+func konnectivityKonnectivityAgentSecretYaml() (*asset, error) {
+	bytes, err := konnectivityKonnectivityAgentSecretYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "konnectivity/konnectivity-agent-secret.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _konnectivityKonnectivityServerSecretYaml = []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: konnectivity-server
+data:
+  tls.crt: {{ pki "konnectivity-server.crt" }}
+  tls.key: {{ pki "konnectivity-server.key" }}
+  ca.crt: {{ pki "konnectivity-ca.crt" }}
+`)
+
+func konnectivityKonnectivityServerSecretYamlBytes() ([]byte, error) {
+	return _konnectivityKonnectivityServerSecretYaml, nil
+}
+
+func konnectivityKonnectivityServerSecretYaml() (*asset, error) {
+	bytes, err := konnectivityKonnectivityServerSecretYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "konnectivity/konnectivity-server-secret.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _konnectivityKonnectivityServerServiceYaml = []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: konnectivity-server
+spec:
+  ports:
+  - name: agent
+    port: 8132
+    protocol: TCP
+    targetPort: 8132
+{{ if .KonnectivityNodePort }}
+    nodePort: {{ .KonnectivityNodePort }}
+{{ end }}
+  selector:
+    app: kube-apiserver
+  type: NodePort
+`)
+
+func konnectivityKonnectivityServerServiceYamlBytes() ([]byte, error) {
+	return _konnectivityKonnectivityServerServiceYaml, nil
+}
+
+func konnectivityKonnectivityServerServiceYaml() (*asset, error) {
+	bytes, err := konnectivityKonnectivityServerServiceYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "konnectivity/konnectivity-server-service.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _kubeApiserverClientConf = []byte(`client
+verb 3
+nobind
+dev tun
+remote-cert-tls server
+remote openvpn-server 1194 udp
+ca secret/ca.crt
+cert secret/tls.crt
+key secret/tls.key
+{{ if .FIPS }}
+{{ include "openvpn/fips.conf" 0 }}
+{{ end }}
+`)
+
+func kubeApiserverClientConfBytes() ([]byte, error) {
+	return _kubeApiserverClientConf, nil
+}
+
+func kubeApiserverClientConf() (*asset, error) {
+	bytes, err := kubeApiserverClientConfBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "kube-apiserver/client.conf", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _kubeApiserverConfigYaml = []byte(`---
+apiVersion: kubecontrolplane.config.openshift.io/v1
+kind: KubeAPIServerConfig
+admission:
+  pluginConfig:
+    network.openshift.io/ExternalIPRanger:
+      configuration:
+        allowIngressIP: false
+        apiVersion: network.openshift.io/v1
+        externalIPNetworkCIDRs:
+        kind: ExternalIPRangerAdmissionConfig
       location: ''
     network.openshift.io/RestrictedEndpointsAdmission:
       configuration:
@@ -1443,6 +2893,7 @@ apiServerArguments:
   feature-gates:
   {{ range $featureGate := .DefaultFeatureGates }}- {{ $featureGate }}
   {{ end }}{{ range $featureGate := .ExtraFeatureGates }}- {{ $featureGate }}
+  {{ end }}{{ if includeKonnectivity }}- APIServerNetworkProxy=true
   {{ end }}
   http2-max-streams-per-connection:
   - '2000'
@@ -1456,8 +2907,20 @@ apiServerArguments:
   - application/vnd.kubernetes.protobuf
   advertise-address:
   - "{{ .ExternalAPIIPAddress }}"
+{{ if .APIEndpointReconcilerType }}
+  endpoint-reconciler-type:
+  - "{{ .APIEndpointReconcilerType }}"
+{{ end }}
   cloud-provider:
   - "{{ .CloudProvider }}"
+{{ if .EtcdEncryption.Provider }}
+  encryption-provider-config:
+  - /etc/kubernetes/encryption/config.yaml
+{{ end }}
+{{ if includeKonnectivity }}
+  egress-selector-config-file:
+  - /etc/kubernetes/egress-selector/config.yaml
+{{ end }}
 auditConfig:
   auditFilePath: "/var/log/kube-apiserver/audit.log"
   enabled: true
@@ -1468,6 +2931,13 @@ auditConfig:
   policyFile: /etc/kubernetes/audit/policy.yaml
   webHookKubeConfig: /etc/kubernetes/audit/webhook-kubeconfig
   webHookMode: batch
+{{ else }}
+{{ if .AuditWebhook.URL }}
+  webHookKubeConfig: /etc/kubernetes/audit-webhook/kubeconfig
+  webHookMode: {{ with .AuditWebhook.Mode }}{{ . }}{{ else }}batch{{ end }}
+{{ end }}
+{{ if .AuditPolicy }}
+  policyFile: /etc/kubernetes/audit-policy/policy.yaml
 {{ else }}
   policyConfiguration:
     apiVersion: audit.k8s.io/v1
@@ -1499,6 +2969,7 @@ auditConfig:
       omitStages:
       - RequestReceived
 {{ end }}
+{{ end }}
 authConfig:
   oauthMetadataFile: "/etc/kubernetes/oauth/oauthMetadata.json"
   requestHeader:
@@ -1539,11 +3010,14 @@ servingInfo:
   keyFile: "/etc/kubernetes/secret/server.key"
   maxRequestsInFlight: 1200
   requestTimeoutSeconds: 3600
+{{ if .FIPS }}
+{{ include "common/fips-serving-info.yaml" 2 }}
+{{ end }}
 {{ if .NamedCerts }}
   namedCertificates:
   {{ range .NamedCerts }}
-  - certFile: {{ .NamedCertPrefix }}.crt
-    keyFile: {{ .NamedCertPrefix }}.key
+  - certFile: /etc/kubernetes/named-certs/{{ .NamedCertPrefix }}.crt
+    keyFile: /etc/kubernetes/named-certs/{{ .NamedCertPrefix }}.key
     names:
     - {{ .NamedCertDomain }}
   {{ end }}
@@ -1553,8 +3027,9 @@ storageConfig:
   certFile: "/etc/kubernetes/secret/etcd-client.crt"
   keyFile: "/etc/kubernetes/secret/etcd-client.key"
   urls:
-  - https://{{ .EtcdClientName }}:2379
-userAgentMatchingConfig:
+{{ if .EtcdEndpoints }}{{ range .EtcdEndpoints }}  - {{ . }}
+{{ end }}{{ else }}  - https://{{ .EtcdClientName }}:2379
+{{ end }}userAgentMatchingConfig:
   defaultRejectionMessage: ''
   deniedClients:
   requiredClients:
@@ -1575,6 +3050,94 @@ func kubeApiserverConfigYaml() (*asset, error) {
 	return a, nil
 }
 
+var _kubeApiserverEtcdEncryptionSecretYaml = []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: etcd-encryption-config
+data:
+  config.yaml: {{ pki "etcd-encryption.yaml" }}
+`)
+
+func kubeApiserverEtcdEncryptionSecretYamlBytes() ([]byte, error) {
+	return _kubeApiserverEtcdEncryptionSecretYaml, nil
+}
+
+func kubeApiserverEtcdEncryptionSecretYaml() (*asset, error) {
+	bytes, err := kubeApiserverEtcdEncryptionSecretYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "kube-apiserver/etcd-encryption-secret.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _kubeApiserverKubeApiserverAuditPolicyConfigmapYaml = []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kube-apiserver-audit-policy
+data:
+  policy.yaml: |-
+{{ trimTrailingSpace .AuditPolicy | indent 4 }}
+`)
+
+func kubeApiserverKubeApiserverAuditPolicyConfigmapYamlBytes() ([]byte, error) {
+	return _kubeApiserverKubeApiserverAuditPolicyConfigmapYaml, nil
+}
+
+func kubeApiserverKubeApiserverAuditPolicyConfigmapYaml() (*asset, error) {
+	bytes, err := kubeApiserverKubeApiserverAuditPolicyConfigmapYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "kube-apiserver/kube-apiserver-audit-policy-configmap.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _kubeApiserverKubeApiserverAuditWebhookSecretYaml = []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: kube-apiserver-audit-webhook
+stringData:
+  kubeconfig: |-
+    apiVersion: v1
+    kind: Config
+    clusters:
+    - name: audit-webhook
+      cluster:
+        server: {{ .URL }}
+{{ if .CA }}
+        certificate-authority-data: {{ .CA }}
+{{ end }}
+    contexts:
+    - name: audit-webhook
+      context:
+        cluster: audit-webhook
+        user: kube-apiserver
+    current-context: audit-webhook
+    users:
+    - name: kube-apiserver
+      user: {}
+`)
+
+func kubeApiserverKubeApiserverAuditWebhookSecretYamlBytes() ([]byte, error) {
+	return _kubeApiserverKubeApiserverAuditWebhookSecretYaml, nil
+}
+
+func kubeApiserverKubeApiserverAuditWebhookSecretYaml() (*asset, error) {
+	bytes, err := kubeApiserverKubeApiserverAuditWebhookSecretYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "kube-apiserver/kube-apiserver-audit-webhook-secret.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _kubeApiserverKubeApiserverConfigConfigmapYaml = []byte(`kind: ConfigMap
 apiVersion: v1
 metadata:
@@ -1613,7 +3176,7 @@ data:
   serving-ca.crt: |-
 {{ include_pki "combined-ca.crt" 4 }}
   etcd-ca.crt: |-
-{{ include_pki "root-ca.crt" 4 }}
+{{ include_pki "etcd-ca.crt" 4 }}
 `)
 
 func kubeApiserverKubeApiserverConfigmapYamlBytes() ([]byte, error) {
@@ -1631,7 +3194,12 @@ func kubeApiserverKubeApiserverConfigmapYaml() (*asset, error) {
 	return a, nil
 }
 
-var _kubeApiserverKubeApiserverDeploymentYaml = []byte(`kind: Deployment
+var _kubeApiserverKubeApiserverDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: kube-apiserver
+---
+kind: Deployment
 apiVersion: apps/v1
 metadata:
   name: kube-apiserver
@@ -1651,10 +3219,7 @@ spec:
     metadata:
       labels:
         app: kube-apiserver
-{{ if .RestartDate }}
-      annotations:
-        openshift.io/restartedAt: "{{ .RestartDate }}"
-{{ end }}
+{{ include "common/pod-annotations.yaml" 6 }}
     spec:
       tolerations:
         - key: "multi-az-worker"
@@ -1676,16 +3241,23 @@ spec:
                     operator: In
                     values: ["kube-apiserver"]
               topologyKey: "failure-domain.beta.kubernetes.io/zone"
+      serviceAccountName: kube-apiserver
+{{ if not .Monitoring.Enabled }}
       automountServiceAccountToken: false
+{{ end }}
       containers:
       - name: kube-apiserver
         image: {{ imageFor "hyperkube" }}
+{{ include "common/security-context.yaml" 8 }}
         command:
         - hyperkube
         - kube-apiserver
         args:
         - "--openshift-config=/etc/kubernetes/apiserver-config/config.yaml"
         workingDir: /var/log/kube-apiserver
+{{ if .ProxyEnabled }}
+{{ include "common/proxy-env.yaml" 8 }}
+{{ end }}
         livenessProbe:
           httpGet:
             scheme: HTTPS
@@ -1724,10 +3296,113 @@ spec:
           name: oauth
         - mountPath: /var/log/kube-apiserver/
           name: logs
+{{ if .NamedCerts }}
+        - mountPath: /etc/kubernetes/named-certs/
+          name: named-certs
+{{ end }}
+{{ if .EtcdEncryption.Provider }}
+        - mountPath: /etc/kubernetes/encryption/
+          name: encryption-config
+{{ end }}{{ if eq .EtcdEncryption.Provider "kms" }}
+        - mountPath: /var/run/kmsplugin/
+          name: kms-socket
+{{ end }}
 {{ if .APIServerAuditEnabled }}
         - name: apiserver-cm
           mountPath: /etc/kubernetes/audit/
 {{ end }}
+{{ if .AuditPolicy }}
+        - mountPath: /etc/kubernetes/audit-policy/
+          name: audit-policy
+{{ end }}
+{{ if .AuditWebhook.URL }}
+        - mountPath: /etc/kubernetes/audit-webhook/
+          name: audit-webhook
+{{ end }}
+{{ if includeKonnectivity }}
+        - mountPath: /etc/kubernetes/konnectivity/
+          name: konnectivity-uds
+        - mountPath: /etc/kubernetes/egress-selector/
+          name: egress-selector
+      - name: konnectivity-server
+        image: {{ .KonnectivityServerImage }}
+{{ include "common/security-context.yaml" 8 }}
+        command:
+        - /proxy-server
+        args:
+        - --logtostderr=true
+        - --mode=grpc
+        - --uds-name=/etc/kubernetes/konnectivity/konnectivity-server.socket
+        - --delete-existing-uds-file=true
+        - --server-port=0
+        - --agent-port=8132
+        - --health-port=8092
+        - --admin-port=8093
+        - --cluster-cert=/etc/konnectivity/secret/tls.crt
+        - --cluster-key=/etc/konnectivity/secret/tls.key
+        - --cluster-ca-cert=/etc/konnectivity/secret/ca.crt
+        - --server-count={{ .Replicas }}
+        livenessProbe:
+          httpGet:
+            scheme: HTTP
+            port: 8092
+            path: /healthz
+          initialDelaySeconds: 10
+          timeoutSeconds: 10
+        volumeMounts:
+        - mountPath: /etc/kubernetes/konnectivity/
+          name: konnectivity-uds
+        - mountPath: /etc/konnectivity/secret/
+          name: konnectivity-secret
+{{ end }}
+{{ if eq .EtcdEncryption.Provider "kms" }}
+      - name: kms-plugin
+        image: {{ .EtcdEncryption.KMSPluginImage }}
+{{ include "common/security-context.yaml" 8 }}
+{{ if .EtcdEncryption.KMSPluginArgs }}
+        args:
+{{ range .EtcdEncryption.KMSPluginArgs }}        - "{{ . }}"
+{{ end }}{{ end }}
+        volumeMounts:
+        - mountPath: /var/run/kmsplugin/
+          name: kms-socket
+{{ end }}
+{{ if .Monitoring.Enabled }}
+      - name: metrics-proxy
+        image: {{ imageFor "kube-rbac-proxy" }}
+{{ include "common/security-context.yaml" 8 }}
+        args:
+        - "--secure-listen-address=0.0.0.0:9443"
+        - "--upstream=https://localhost:{{ .InternalAPIPort }}/"
+        - "--upstream-ca-file=/etc/metrics-proxy/client/ca.crt"
+        - "--upstream-client-cert-file=/etc/metrics-proxy/client/tls.crt"
+        - "--upstream-client-key-file=/etc/metrics-proxy/client/tls.key"
+        - "--tls-cert-file=/etc/metrics-proxy/serving/tls.crt"
+        - "--tls-private-key-file=/etc/metrics-proxy/serving/tls.key"
+        - "--allow-paths=/metrics"
+        - "--logtostderr=true"
+        ports:
+        - name: metrics
+          containerPort: 9443
+        volumeMounts:
+        - mountPath: /etc/metrics-proxy/serving/
+          name: metrics-serving
+        - mountPath: /etc/metrics-proxy/client/
+          name: metrics-client
+{{ end }}
+{{ if .AuditForwarder.Image }}
+      - name: audit-forwarder
+        image: {{ .AuditForwarder.Image }}
+{{ include "common/security-context.yaml" 8 }}
+{{ if .AuditForwarder.Args }}
+        args:
+{{ range .AuditForwarder.Args }}        - "{{ . }}"
+{{ end }}{{ end }}
+        volumeMounts:
+        - mountPath: /var/log/kube-apiserver/
+          name: logs
+          readOnly: true
+{{ end }}
 {{ if includeVPN }}
       - name: openvpn-client
         image: quay.io/sjenning/poc:openvpn
@@ -1760,11 +3435,52 @@ spec:
       - configMap:
           name: kube-apiserver-oauth-metadata
         name: oauth
+{{ if .Monitoring.Enabled }}
+      - secret:
+          secretName: kube-apiserver-metrics-tls
+        name: metrics-serving
+      - secret:
+          secretName: metrics-client
+        name: metrics-client
+{{ end }}
+{{ if .NamedCerts }}
+      - secret:
+          secretName: kube-apiserver-named-certs
+        name: named-certs
+{{ end }}
+{{ if .EtcdEncryption.Provider }}
+      - secret:
+          secretName: etcd-encryption-config
+        name: encryption-config
+{{ end }}{{ if eq .EtcdEncryption.Provider "kms" }}
+      - emptyDir: {}
+        name: kms-socket
+{{ end }}
 {{ if .APIServerAuditEnabled }}
       - name: apiserver-cm
         configMap:
           name: apiserver-audit-cm
 {{ end }}
+{{ if .AuditPolicy }}
+      - configMap:
+          name: kube-apiserver-audit-policy
+        name: audit-policy
+{{ end }}
+{{ if .AuditWebhook.URL }}
+      - secret:
+          secretName: kube-apiserver-audit-webhook
+        name: audit-webhook
+{{ end }}
+{{ if includeKonnectivity }}
+      - emptyDir: {}
+        name: konnectivity-uds
+      - configMap:
+          name: kube-apiserver-egress-selector
+        name: egress-selector
+      - secret:
+          secretName: konnectivity-server
+        name: konnectivity-secret
+{{ end }}
 {{ if includeVPN }}
       - configMap:
           name: kube-apiserver-vpnclient-config
@@ -1790,6 +3506,63 @@ func kubeApiserverKubeApiserverDeploymentYaml() (*asset, error) {
 	return a, nil
 }
 
+var _kubeApiserverKubeApiserverEgressSelectorConfigmapYaml = []byte(`kind: ConfigMap
+apiVersion: v1
+metadata:
+  name: kube-apiserver-egress-selector
+data:
+  config.yaml: |-
+    apiVersion: apiserver.k8s.io/v1alpha1
+    kind: EgressSelectorConfiguration
+    egressSelections:
+    - name: cluster
+      connection:
+        proxyProtocol: GRPC
+        transport:
+          uds:
+            udsName: /etc/kubernetes/konnectivity/konnectivity-server.socket
+`)
+
+func kubeApiserverKubeApiserverEgressSelectorConfigmapYamlBytes() ([]byte, error) {
+	return _kubeApiserverKubeApiserverEgressSelectorConfigmapYaml, nil
+}
+
+func kubeApiserverKubeApiserverEgressSelectorConfigmapYaml() (*asset, error) {
+	bytes, err := kubeApiserverKubeApiserverEgressSelectorConfigmapYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "kube-apiserver/kube-apiserver-egress-selector-configmap.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _kubeApiserverKubeApiserverNamedCertsSecretYaml = []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: kube-apiserver-named-certs
+data:
+{{ range .NamedCerts }}  {{ .NamedCertPrefix }}.crt: {{ pki (printf "%s.crt" .NamedCertPrefix) }}
+  {{ .NamedCertPrefix }}.key: {{ pki (printf "%s.key" .NamedCertPrefix) }}
+{{ end }}
+`)
+
+func kubeApiserverKubeApiserverNamedCertsSecretYamlBytes() ([]byte, error) {
+	return _kubeApiserverKubeApiserverNamedCertsSecretYaml, nil
+}
+
+func kubeApiserverKubeApiserverNamedCertsSecretYaml() (*asset, error) {
+	bytes, err := kubeApiserverKubeApiserverNamedCertsSecretYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "kube-apiserver/kube-apiserver-named-certs-secret.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _kubeApiserverKubeApiserverOauthMetadataConfigmapYaml = []byte(`kind: ConfigMap
 apiVersion: v1
 metadata:
@@ -1856,7 +3629,7 @@ spec:
     nodePort: {{ .APINodePort }}
   selector:
     app: kube-apiserver
-  type: NodePort
+  type: {{ if .APIServiceType }}{{ .APIServiceType }}{{ else }}NodePort{{ end }}
 `)
 
 func kubeApiserverKubeApiserverServiceYamlBytes() ([]byte, error) {
@@ -1925,9 +3698,9 @@ func kubeApiserverKubeApiserverVpnclientSecretYaml() (*asset, error) {
 
 var _kubeApiserverOauthmetadataJson = []byte(`{
 {{ if ne .ExternalOauthPort 0 }}
-"issuer": "https://{{ .ExternalAPIDNSName }}:{{ .ExternalOauthPort }}",
-"authorization_endpoint": "https://{{ .ExternalAPIDNSName }}:{{ .ExternalOauthPort }}/oauth/authorize",
-"token_endpoint": "https://{{ .ExternalAPIDNSName }}:{{ .ExternalOauthPort }}/oauth/token",
+"issuer": "https://{{ .ExternalOauthHost }}",
+"authorization_endpoint": "https://{{ .ExternalOauthHost }}/oauth/authorize",
+"token_endpoint": "https://{{ .ExternalOauthHost }}/oauth/token",
 {{ else }}
 "issuer": "https://oauth-openshift.{{ .IngressSubdomain }}",
 "authorization_endpoint": "https://oauth-openshift.{{ .IngressSubdomain }}/oauth/authorize",
@@ -2024,6 +3797,10 @@ extendedArguments:
   - "/etc/kubernetes/secret/service-account.key"
   service-cluster-ip-range:
   - {{ .ServiceCIDR }}
+  tls-cert-file:
+  - "/etc/kubernetes/secret/server.crt"
+  tls-private-key-file:
+  - "/etc/kubernetes/secret/server.key"
   use-service-account-credentials:
   - 'true'
   experimental-cluster-signing-duration:
@@ -2097,7 +3874,12 @@ func kubeControllerManagerKubeControllerManagerConfigmapYaml() (*asset, error) {
 	return a, nil
 }
 
-var _kubeControllerManagerKubeControllerManagerDeploymentYaml = []byte(`kind: Deployment
+var _kubeControllerManagerKubeControllerManagerDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: kube-controller-manager
+---
+kind: Deployment
 apiVersion: apps/v1
 metadata:
   name: kube-controller-manager
@@ -2115,10 +3897,7 @@ spec:
     metadata:
       labels:
         app: kube-controller-manager
-{{ if .RestartDate }}
-      annotations:
-        openshift.io/restartedAt: "{{ .RestartDate }}"
-{{ end }}
+{{ include "common/pod-annotations.yaml" 6 }}
     spec:
       tolerations:
         - key: "multi-az-worker"
@@ -2140,16 +3919,23 @@ spec:
                     operator: In
                     values: ["kube-controller-manager"]
               topologyKey: "failure-domain.beta.kubernetes.io/zone"
+      serviceAccountName: kube-controller-manager
+{{ if not .Monitoring.Enabled }}
       automountServiceAccountToken: false
+{{ end }}
       containers:
       - name: kube-controller-manager
         image: {{ imageFor "hyperkube" }}
+{{ include "common/security-context.yaml" 8 }}
         command:
         - hyperkube
         - kube-controller-manager
         args:
         - "--openshift-config=/etc/kubernetes/cmconfig/config.yaml"
         - "--kubeconfig=/etc/kubernetes/secret/kubeconfig"
+{{ if .ProxyEnabled }}
+{{ include "common/proxy-env.yaml" 8 }}
+{{ end }}
 {{ if .KubeControllerManagerResources }}
         resources:{{ range .KubeControllerManagerResources }}{{ range .ResourceRequest }}
           requests: {{ if .CPU }}
@@ -2171,6 +3957,29 @@ spec:
         - mountPath: /var/log/kube-controller-manager
           name: logs
         workingDir: /var/log/kube-controller-manager
+{{ if .Monitoring.Enabled }}
+      - name: metrics-proxy
+        image: {{ imageFor "kube-rbac-proxy" }}
+{{ include "common/security-context.yaml" 8 }}
+        args:
+        - "--secure-listen-address=0.0.0.0:9443"
+        - "--upstream=https://localhost:10257/"
+        - "--upstream-ca-file=/etc/metrics-proxy/client/ca.crt"
+        - "--upstream-client-cert-file=/etc/metrics-proxy/client/tls.crt"
+        - "--upstream-client-key-file=/etc/metrics-proxy/client/tls.key"
+        - "--tls-cert-file=/etc/metrics-proxy/serving/tls.crt"
+        - "--tls-private-key-file=/etc/metrics-proxy/serving/tls.key"
+        - "--allow-paths=/metrics"
+        - "--logtostderr=true"
+        ports:
+        - name: metrics
+          containerPort: 9443
+        volumeMounts:
+        - mountPath: /etc/metrics-proxy/serving/
+          name: metrics-serving
+        - mountPath: /etc/metrics-proxy/client/
+          name: metrics-client
+{{ end }}
       volumes:
       - secret:
           secretName: kube-controller-manager
@@ -2185,6 +3994,14 @@ spec:
         name: logs
       - emptyDir: {}
         name: certdir
+{{ if .Monitoring.Enabled }}
+      - secret:
+          secretName: kube-controller-manager-metrics-tls
+        name: metrics-serving
+      - secret:
+          secretName: metrics-client
+        name: metrics-client
+{{ end }}
 `)
 
 func kubeControllerManagerKubeControllerManagerDeploymentYamlBytes() ([]byte, error) {
@@ -2211,6 +4028,8 @@ data:
   service-account.key: {{ pki "service-account.key" }}
   cluster-signer.crt: {{ pki "cluster-signer.crt" }}
   cluster-signer.key: {{ pki "cluster-signer.key" }}
+  server.crt: {{ pki "kube-controller-manager-server.crt" }}
+  server.key: {{ pki "kube-controller-manager-server.key" }}
 `)
 
 func kubeControllerManagerKubeControllerManagerSecretYamlBytes() ([]byte, error) {
@@ -2254,149 +4073,836 @@ func kubeSchedulerConfigYaml() (*asset, error) {
 var _kubeSchedulerKubeSchedulerConfigConfigmapYaml = []byte(`kind: ConfigMap
 apiVersion: v1
 metadata:
-  name: kube-scheduler-config
+  name: kube-scheduler-config
+data:
+  config.yaml: |-
+{{ include "kube-scheduler/config.yaml" 4 }}
+`)
+
+func kubeSchedulerKubeSchedulerConfigConfigmapYamlBytes() ([]byte, error) {
+	return _kubeSchedulerKubeSchedulerConfigConfigmapYaml, nil
+}
+
+func kubeSchedulerKubeSchedulerConfigConfigmapYaml() (*asset, error) {
+	bytes, err := kubeSchedulerKubeSchedulerConfigConfigmapYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "kube-scheduler/kube-scheduler-config-configmap.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _kubeSchedulerKubeSchedulerDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: kube-scheduler
+---
+kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: kube-scheduler
+spec:
+  replicas: {{ .Replicas }}
+  strategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxSurge: 3
+      maxUnavailable: 1
+  selector:
+    matchLabels:
+      app: kube-scheduler
+  template:
+    metadata:
+      labels:
+        app: kube-scheduler
+{{ include "common/pod-annotations.yaml" 6 }}
+    spec:
+      tolerations:
+        - key: "multi-az-worker"
+          operator: "Equal"
+          value: "true"
+          effect: NoSchedule
+      affinity:
+        podAntiAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+            - labelSelector:
+                matchExpressions:
+                  - key: app
+                    operator: In
+                    values: ["kube-scheduler"]
+              topologyKey: "kubernetes.io/hostname"
+            - labelSelector:
+                matchExpressions:
+                  - key: app
+                    operator: In
+                    values: ["kube-scheduler"]
+              topologyKey: "failure-domain.beta.kubernetes.io/zone"
+      serviceAccountName: kube-scheduler
+      automountServiceAccountToken: false
+      containers:
+      - name: kube-scheduler
+        image: {{ imageFor "hyperkube" }}
+{{ include "common/security-context.yaml" 8 }}
+        command:
+        - hyperkube
+        - kube-scheduler
+        args:
+        - "--config=/etc/kubernetes/config/config.yaml"
+        - "--cert-dir=/var/run/kubernetes"
+        - "--port=0"
+        - "--authentication-kubeconfig=/etc/kubernetes/secret/kubeconfig"
+        - "--authorization-kubeconfig=/etc/kubernetes/secret/kubeconfig"
+        - "-v=2"
+        {{ range $featureGate := .DefaultFeatureGates }}- "--feature-gates={{ $featureGate }}"
+        {{ end }}{{ range $featureGate := .ExtraFeatureGates }}- "--feature-gates={{ $featureGate }}"
+        {{ end }}
+{{ if .KubeSchedulerResources }}
+        resources:{{ range .KubeSchedulerResources }}{{ range .ResourceRequest }}
+          requests: {{ if .CPU }}
+            cpu: {{ .CPU }}{{ end }}{{ if .Memory }}
+            memory: {{ .Memory }}{{ end }}{{ end }}{{ range .ResourceLimit }}
+          limits: {{ if .CPU }}
+            cpu: {{ .CPU }}{{ end }}{{ if .Memory }}
+            memory: {{ .Memory }}{{ end }}{{ end }}{{ end }}
+{{ end }}
+        volumeMounts:
+        - mountPath: /etc/kubernetes/secret
+          name: secret
+        - mountPath: /etc/kubernetes/config
+          name: config
+        - mountPath: /var/run/kubernetes/
+          name: cert
+        workDir: /var/run/kubernetes
+      volumes:
+      - secret:
+          secretName: service-network-admin-kubeconfig
+        name: secret
+      - configMap:
+          name: kube-scheduler-config
+        name: config
+      - emptyDir: {}
+        name: cert
+`)
+
+func kubeSchedulerKubeSchedulerDeploymentYamlBytes() ([]byte, error) {
+	return _kubeSchedulerKubeSchedulerDeploymentYaml, nil
+}
+
+func kubeSchedulerKubeSchedulerDeploymentYaml() (*asset, error) {
+	bytes, err := kubeSchedulerKubeSchedulerDeploymentYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "kube-scheduler/kube-scheduler-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _kubeSchedulerKubeSchedulerSecretYaml = []byte(`kind: Secret
+apiVersion: v1
+metadata:
+  name: kube-scheduler
+data:
+  kubeconfig: {{ pki "internal-admin.kubeconfig" }}
+`)
+
+func kubeSchedulerKubeSchedulerSecretYamlBytes() ([]byte, error) {
+	return _kubeSchedulerKubeSchedulerSecretYaml, nil
+}
+
+func kubeSchedulerKubeSchedulerSecretYaml() (*asset, error) {
+	bytes, err := kubeSchedulerKubeSchedulerSecretYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "kube-scheduler/kube-scheduler-secret.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _monitoringMetricsClientSecretYaml = []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: metrics-client
+data:
+  tls.crt: {{ pki "metrics-client.crt" }}
+  tls.key: {{ pki "metrics-client.key" }}
+  ca.crt: {{ pki "root-ca.crt" }}
+`)
+
+func monitoringMetricsClientSecretYamlBytes() ([]byte, error) {
+	return _monitoringMetricsClientSecretYaml, nil
+}
+
+func monitoringMetricsClientSecretYaml() (*asset, error) {
+	bytes, err := monitoringMetricsClientSecretYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "monitoring/metrics-client-secret.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _monitoringMetricsProxyRbacYaml = []byte(`---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: hypershift-metrics-proxy-{{ .Namespace }}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: system:auth-delegator
+subjects:
+- kind: ServiceAccount
+  name: kube-apiserver
+  namespace: {{ .Namespace }}
+- kind: ServiceAccount
+  name: kube-controller-manager
+  namespace: {{ .Namespace }}
+- kind: ServiceAccount
+  name: etcd
+  namespace: {{ .Namespace }}
+- kind: ServiceAccount
+  name: control-plane-operator
+  namespace: {{ .Namespace }}
+`)
+
+func monitoringMetricsProxyRbacYamlBytes() ([]byte, error) {
+	return _monitoringMetricsProxyRbacYaml, nil
+}
+
+func monitoringMetricsProxyRbacYaml() (*asset, error) {
+	bytes, err := monitoringMetricsProxyRbacYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "monitoring/metrics-proxy-rbac.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _monitoringMetricsReaderRbacYaml = []byte(`---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: hypershift:metrics-reader
+rules:
+- nonResourceURLs:
+  - /metrics
+  verbs:
+  - get
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: hypershift:metrics-reader
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: hypershift:metrics-reader
+subjects:
+- apiGroup: rbac.authorization.k8s.io
+  kind: User
+  name: hypershift:metrics
+`)
+
+func monitoringMetricsReaderRbacYamlBytes() ([]byte, error) {
+	return _monitoringMetricsReaderRbacYaml, nil
+}
+
+func monitoringMetricsReaderRbacYaml() (*asset, error) {
+	bytes, err := monitoringMetricsReaderRbacYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "monitoring/metrics-reader-rbac.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _monitoringMetricsServiceTemplateYaml = []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .Name }}-metrics
+  labels:
+    hypershift.openshift.io/metrics: {{ .Name }}
+  annotations:
+    service.beta.openshift.io/serving-cert-secret-name: {{ .Name }}-metrics-tls
+spec:
+  selector:
+    app: {{ .Name }}
+  ports:
+  - name: metrics
+    port: 9443
+    targetPort: 9443
+`)
+
+func monitoringMetricsServiceTemplateYamlBytes() ([]byte, error) {
+	return _monitoringMetricsServiceTemplateYaml, nil
+}
+
+func monitoringMetricsServiceTemplateYaml() (*asset, error) {
+	bytes, err := monitoringMetricsServiceTemplateYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "monitoring/metrics-service-template.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _monitoringServiceMonitorTemplateYaml = []byte(`apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: {{ .Name }}
+  labels:
+    hypershift.openshift.io/cluster: {{ .Namespace }}
+spec:
+  selector:
+    matchLabels:
+      hypershift.openshift.io/metrics: {{ .Name }}
+  namespaceSelector:
+    matchNames:
+    - {{ .Namespace }}
+  endpoints:
+  - port: metrics
+    scheme: https
+    path: /metrics
+    interval: 30s
+    bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
+    tlsConfig:
+      caFile: /etc/prometheus/configmaps/serving-certs-ca-bundle/service-ca.crt
+      serverName: {{ .Name }}-metrics.{{ .Namespace }}.svc
+    relabelings:
+    - targetLabel: hosted_cluster
+      replacement: "{{ .Namespace }}"
+{{ range $key, $value := .Labels }}    - targetLabel: {{ $key }}
+      replacement: "{{ $value }}"
+{{ end }}
+`)
+
+func monitoringServiceMonitorTemplateYamlBytes() ([]byte, error) {
+	return _monitoringServiceMonitorTemplateYaml, nil
+}
+
+func monitoringServiceMonitorTemplateYaml() (*asset, error) {
+	bytes, err := monitoringServiceMonitorTemplateYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "monitoring/service-monitor-template.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _networkPolicyDefaultDenyNetworkPolicyYaml = []byte(`apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: default-deny
+spec:
+  podSelector: {}
+  policyTypes:
+  - Ingress
+`)
+
+func networkPolicyDefaultDenyNetworkPolicyYamlBytes() ([]byte, error) {
+	return _networkPolicyDefaultDenyNetworkPolicyYaml, nil
+}
+
+func networkPolicyDefaultDenyNetworkPolicyYaml() (*asset, error) {
+	bytes, err := networkPolicyDefaultDenyNetworkPolicyYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "network-policy/default-deny-network-policy.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _networkPolicyEtcdNetworkPolicyYaml = []byte(`apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: etcd
+spec:
+  podSelector:
+    matchLabels:
+      app: etcd
+  policyTypes:
+  - Ingress
+  ingress:
+  - from:
+    - podSelector:
+        matchExpressions:
+        - key: app
+          operator: In
+          values:
+          - kube-apiserver
+          - openshift-apiserver
+          - etcd-defrag
+    - podSelector:
+        matchLabels:
+          name: etcd-operator
+    ports:
+    - port: 2379
+      protocol: TCP
+  - from:
+    - podSelector:
+        matchLabels:
+          app: etcd
+    ports:
+    - port: 2379
+      protocol: TCP
+    - port: 2380
+      protocol: TCP
+`)
+
+func networkPolicyEtcdNetworkPolicyYamlBytes() ([]byte, error) {
+	return _networkPolicyEtcdNetworkPolicyYaml, nil
+}
+
+func networkPolicyEtcdNetworkPolicyYaml() (*asset, error) {
+	bytes, err := networkPolicyEtcdNetworkPolicyYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "network-policy/etcd-network-policy.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _networkPolicyIgnitionServerNetworkPolicyYaml = []byte(`apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: ignition-server
+spec:
+  podSelector:
+    matchLabels:
+      app: ignition-server
+  policyTypes:
+  - Ingress
+  ingress:
+  - ports:
+    - port: 8443
+      protocol: TCP
+`)
+
+func networkPolicyIgnitionServerNetworkPolicyYamlBytes() ([]byte, error) {
+	return _networkPolicyIgnitionServerNetworkPolicyYaml, nil
+}
+
+func networkPolicyIgnitionServerNetworkPolicyYaml() (*asset, error) {
+	bytes, err := networkPolicyIgnitionServerNetworkPolicyYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "network-policy/ignition-server-network-policy.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _networkPolicyKubeApiserverNetworkPolicyYaml = []byte(`apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: kube-apiserver
+spec:
+  podSelector:
+    matchLabels:
+      app: kube-apiserver
+  policyTypes:
+  - Ingress
+  ingress:
+  - ports:
+    - port: {{ .InternalAPIPort }}
+      protocol: TCP
+{{ if includeKonnectivity }}
+    - port: 8132
+      protocol: TCP
+{{ end }}
+`)
+
+func networkPolicyKubeApiserverNetworkPolicyYamlBytes() ([]byte, error) {
+	return _networkPolicyKubeApiserverNetworkPolicyYaml, nil
+}
+
+func networkPolicyKubeApiserverNetworkPolicyYaml() (*asset, error) {
+	bytes, err := networkPolicyKubeApiserverNetworkPolicyYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "network-policy/kube-apiserver-network-policy.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _networkPolicyMetricsNetworkPolicyYaml = []byte(`apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: metrics
+spec:
+  podSelector:
+    matchExpressions:
+    - key: app
+      operator: In
+      values:
+      - kube-apiserver
+      - kube-controller-manager
+      - control-plane-operator
+      - etcd
+  policyTypes:
+  - Ingress
+  ingress:
+  - from:
+    - namespaceSelector: {}
+    ports:
+    - port: 9443
+      protocol: TCP
+`)
+
+func networkPolicyMetricsNetworkPolicyYamlBytes() ([]byte, error) {
+	return _networkPolicyMetricsNetworkPolicyYaml, nil
+}
+
+func networkPolicyMetricsNetworkPolicyYaml() (*asset, error) {
+	bytes, err := networkPolicyMetricsNetworkPolicyYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "network-policy/metrics-network-policy.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _networkPolicyOauthOpenshiftNetworkPolicyYaml = []byte(`apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: oauth-openshift
+spec:
+  podSelector:
+    matchLabels:
+      app: oauth-openshift
+  policyTypes:
+  - Ingress
+  ingress:
+  - ports:
+    - port: 6443
+      protocol: TCP
+`)
+
+func networkPolicyOauthOpenshiftNetworkPolicyYamlBytes() ([]byte, error) {
+	return _networkPolicyOauthOpenshiftNetworkPolicyYaml, nil
+}
+
+func networkPolicyOauthOpenshiftNetworkPolicyYaml() (*asset, error) {
+	bytes, err := networkPolicyOauthOpenshiftNetworkPolicyYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "network-policy/oauth-openshift-network-policy.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _networkPolicyOpenshiftApiserverNetworkPolicyYaml = []byte(`apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: openshift-apiserver
+spec:
+  podSelector:
+    matchLabels:
+      app: openshift-apiserver
+  policyTypes:
+  - Ingress
+  ingress:
+  - from:
+    - podSelector:
+        matchLabels:
+          app: kube-apiserver
+    ports:
+    - port: 8443
+      protocol: TCP
+`)
+
+func networkPolicyOpenshiftApiserverNetworkPolicyYamlBytes() ([]byte, error) {
+	return _networkPolicyOpenshiftApiserverNetworkPolicyYaml, nil
+}
+
+func networkPolicyOpenshiftApiserverNetworkPolicyYaml() (*asset, error) {
+	bytes, err := networkPolicyOpenshiftApiserverNetworkPolicyYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "network-policy/openshift-apiserver-network-policy.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _networkPolicyOpenvpnServerNetworkPolicyYaml = []byte(`apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: openvpn-server
+spec:
+  podSelector:
+    matchLabels:
+      app: openvpn-server
+  policyTypes:
+  - Ingress
+  ingress:
+  - ports:
+    - port: 1194
+      protocol: UDP
+`)
+
+func networkPolicyOpenvpnServerNetworkPolicyYamlBytes() ([]byte, error) {
+	return _networkPolicyOpenvpnServerNetworkPolicyYaml, nil
+}
+
+func networkPolicyOpenvpnServerNetworkPolicyYaml() (*asset, error) {
+	bytes, err := networkPolicyOpenvpnServerNetworkPolicyYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "network-policy/openvpn-server-network-policy.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _nodeUpdaterNodeUpdaterConfigmapYaml = []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: node-updater
 data:
-  config.yaml: |-
-{{ include "kube-scheduler/config.yaml" 4 }}
+  os-image-url: "{{ imageFor "machine-os-content" }}"
+  max-unavailable: "{{ .NodeUpdates.MaxUnavailable }}"
 `)
 
-func kubeSchedulerKubeSchedulerConfigConfigmapYamlBytes() ([]byte, error) {
-	return _kubeSchedulerKubeSchedulerConfigConfigmapYaml, nil
+func nodeUpdaterNodeUpdaterConfigmapYamlBytes() ([]byte, error) {
+	return _nodeUpdaterNodeUpdaterConfigmapYaml, nil
 }
 
-func kubeSchedulerKubeSchedulerConfigConfigmapYaml() (*asset, error) {
-	bytes, err := kubeSchedulerKubeSchedulerConfigConfigmapYamlBytes()
+func nodeUpdaterNodeUpdaterConfigmapYaml() (*asset, error) {
+	bytes, err := nodeUpdaterNodeUpdaterConfigmapYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "kube-scheduler/kube-scheduler-config-configmap.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "node-updater/node-updater-configmap.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _kubeSchedulerKubeSchedulerDeploymentYaml = []byte(`kind: Deployment
+var _nodeUpdaterNodeUpdaterDaemonsetYaml = []byte(`kind: DaemonSet
 apiVersion: apps/v1
 metadata:
-  name: kube-scheduler
+  name: node-updater
+  namespace: openshift-node-updater
 spec:
-  replicas: {{ .Replicas }}
-  strategy:
-    type: RollingUpdate
-    rollingUpdate:
-      maxSurge: 3
-      maxUnavailable: 1
   selector:
     matchLabels:
-      app: kube-scheduler
+      app: node-updater
+  updateStrategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxUnavailable: 10%
   template:
     metadata:
       labels:
-        app: kube-scheduler
-{{ if .RestartDate }}
-      annotations:
-        openshift.io/restartedAt: "{{ .RestartDate }}"
-{{ end }}
+        app: node-updater
     spec:
+      serviceAccountName: node-updater
+      hostNetwork: true
+      priorityClassName: system-node-critical
       tolerations:
-        - key: "multi-az-worker"
-          operator: "Equal"
-          value: "true"
-          effect: NoSchedule
-      affinity:
-        podAntiAffinity:
-          requiredDuringSchedulingIgnoredDuringExecution:
-            - labelSelector:
-                matchExpressions:
-                  - key: app
-                    operator: In
-                    values: ["kube-scheduler"]
-              topologyKey: "kubernetes.io/hostname"
-            - labelSelector:
-                matchExpressions:
-                  - key: app
-                    operator: In
-                    values: ["kube-scheduler"]
-              topologyKey: "failure-domain.beta.kubernetes.io/zone"
-      automountServiceAccountToken: false
+      - operator: Exists
       containers:
-      - name: kube-scheduler
-        image: {{ imageFor "hyperkube" }}
+      - name: node-updater
+        image: {{ imageFor "cli" }}
+        securityContext:
+          privileged: true
+        env:
+        - name: NODE_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: spec.nodeName
         command:
-        - hyperkube
-        - kube-scheduler
-        args:
-        - "--config=/etc/kubernetes/config/config.yaml"
-        - "--cert-dir=/var/run/kubernetes"
-        - "--port=0"
-        - "--authentication-kubeconfig=/etc/kubernetes/secret/kubeconfig"
-        - "--authorization-kubeconfig=/etc/kubernetes/secret/kubeconfig"
-        - "-v=2"
-        {{ range $featureGate := .DefaultFeatureGates }}- "--feature-gates={{ $featureGate }}"
-        {{ end }}{{ range $featureGate := .ExtraFeatureGates }}- "--feature-gates={{ $featureGate }}"
-        {{ end }}
-{{ if .KubeSchedulerResources }}
-        resources:{{ range .KubeSchedulerResources }}{{ range .ResourceRequest }}
-          requests: {{ if .CPU }}
-            cpu: {{ .CPU }}{{ end }}{{ if .Memory }}
-            memory: {{ .Memory }}{{ end }}{{ end }}{{ range .ResourceLimit }}
-          limits: {{ if .CPU }}
-            cpu: {{ .CPU }}{{ end }}{{ if .Memory }}
-            memory: {{ .Memory }}{{ end }}{{ end }}{{ end }}
-{{ end }}
+        - /bin/bash
+        - -c
+        - |
+          # The node updater of the control plane operator drains the node and sets its
+          # desired OS image. The node is rebased to the ostree commit of the image and
+          # rebooted, and reports the OS image it booted once it is back.
+          annotations=hypershift.openshift.io
+          host() {
+            chroot /rootfs "$@"
+          }
+          booted() {
+            host rpm-ostree status --booted | grep -o 'pivot://[^ ]*' | head -n 1 | sed 's|^pivot://||'
+          }
+          annotate() {
+            until oc annotate node "${NODE_NAME}" --overwrite "$@"; do
+              sleep 10
+            done
+          }
+          rebase() {
+            local image=$1 commit container mnt status=0
+            host podman pull -q --authfile /var/lib/kubelet/config.json "${image}" || return 1
+            commit=$(host podman image inspect "${image}" | grep -o '"com.coreos.ostree-commit": *"[0-9a-f]*"' | head -n 1 | grep -o '[0-9a-f]\{64\}')
+            if [ -z "${commit}" ]; then
+              echo "${image} has no ostree commit"
+              return 1
+            fi
+            container=$(host podman create --net=none "${image}") || return 1
+            if mnt=$(host podman mount "${container}"); then
+              host rpm-ostree rebase --experimental "${mnt}/srv/repo:${commit}" \
+                --custom-origin-url "pivot://${image}" \
+                --custom-origin-description "Managed by the hypershift node updater" || status=1
+              host podman umount "${container}"
+            else
+              status=1
+            fi
+            host podman rm "${container}"
+            return ${status}
+          }
+
+          current=$(booted)
+          echo "Booted OS image: ${current:-boot image}"
+          annotate "${annotations}/current-os-image=${current}" "${annotations}/os-update-state=Done"
+          while true; do
+            desired=$(oc get node "${NODE_NAME}" -o jsonpath='{.metadata.annotations.hypershift\.openshift\.io/desired-os-image}')
+            if [ -n "${desired}" ] && [ "${desired}" != "${current}" ]; then
+              echo "Updating to OS image ${desired}"
+              annotate "${annotations}/os-update-state=Working"
+              if rebase "${desired}"; then
+                echo "Rebooting"
+                host systemctl reboot
+                sleep infinity
+              fi
+              echo "Update to OS image ${desired} failed, retrying in 5 minutes"
+              annotate "${annotations}/os-update-state=Degraded"
+              sleep 300
+            fi
+            sleep 30
+          done
+        resources:
+          requests:
+            cpu: 10m
+            memory: 50Mi
         volumeMounts:
-        - mountPath: /etc/kubernetes/secret
-          name: secret
-        - mountPath: /etc/kubernetes/config
-          name: config
-        - mountPath: /var/run/kubernetes/
-          name: cert
-        workDir: /var/run/kubernetes
+        - mountPath: /rootfs
+          name: rootfs
       volumes:
-      - secret:
-          secretName: service-network-admin-kubeconfig
-        name: secret
-      - configMap:
-          name: kube-scheduler-config
-        name: config
-      - emptyDir: {}
-        name: cert
+      - hostPath:
+          path: /
+        name: rootfs
 `)
 
-func kubeSchedulerKubeSchedulerDeploymentYamlBytes() ([]byte, error) {
-	return _kubeSchedulerKubeSchedulerDeploymentYaml, nil
+func nodeUpdaterNodeUpdaterDaemonsetYamlBytes() ([]byte, error) {
+	return _nodeUpdaterNodeUpdaterDaemonsetYaml, nil
 }
 
-func kubeSchedulerKubeSchedulerDeploymentYaml() (*asset, error) {
-	bytes, err := kubeSchedulerKubeSchedulerDeploymentYamlBytes()
+func nodeUpdaterNodeUpdaterDaemonsetYaml() (*asset, error) {
+	bytes, err := nodeUpdaterNodeUpdaterDaemonsetYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "kube-scheduler/kube-scheduler-deployment.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "node-updater/node-updater-daemonset.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _kubeSchedulerKubeSchedulerSecretYaml = []byte(`kind: Secret
-apiVersion: v1
+var _nodeUpdaterNodeUpdaterNamespaceYaml = []byte(`apiVersion: v1
+kind: Namespace
 metadata:
-  name: kube-scheduler
-data:
-  kubeconfig: {{ pki "internal-admin.kubeconfig" }}
+  name: openshift-node-updater
+  annotations:
+    openshift.io/node-selector: ""
 `)
 
-func kubeSchedulerKubeSchedulerSecretYamlBytes() ([]byte, error) {
-	return _kubeSchedulerKubeSchedulerSecretYaml, nil
+func nodeUpdaterNodeUpdaterNamespaceYamlBytes() ([]byte, error) {
+	return _nodeUpdaterNodeUpdaterNamespaceYaml, nil
 }
 
-func kubeSchedulerKubeSchedulerSecretYaml() (*asset, error) {
-	bytes, err := kubeSchedulerKubeSchedulerSecretYamlBytes()
+func nodeUpdaterNodeUpdaterNamespaceYaml() (*asset, error) {
+	bytes, err := nodeUpdaterNodeUpdaterNamespaceYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "kube-scheduler/kube-scheduler-secret.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "node-updater/node-updater-namespace.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _nodeUpdaterNodeUpdaterRbacYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: node-updater
+  namespace: openshift-node-updater
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: hypershift-node-updater
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - nodes
+  verbs:
+  - get
+  - patch
+- apiGroups:
+  - security.openshift.io
+  resources:
+  - securitycontextconstraints
+  resourceNames:
+  - privileged
+  verbs:
+  - use
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: hypershift-node-updater
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: hypershift-node-updater
+subjects:
+- kind: ServiceAccount
+  name: node-updater
+  namespace: openshift-node-updater
+`)
+
+func nodeUpdaterNodeUpdaterRbacYamlBytes() ([]byte, error) {
+	return _nodeUpdaterNodeUpdaterRbacYaml, nil
+}
+
+func nodeUpdaterNodeUpdaterRbacYaml() (*asset, error) {
+	bytes, err := nodeUpdaterNodeUpdaterRbacYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "node-updater/node-updater-rbac.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -2413,7 +4919,7 @@ data:
     metadata:
       name: openshift-browser-client
     redirectURIs:
-    - https://{{ .ExternalAPIDNSName }}:{{ .ExternalOauthPort }}/oauth/token/display
+    - https://{{ .ExternalOauthHost }}/oauth/token/display
     secret: "{{ randomString 32  }}"
 `)
 
@@ -2444,7 +4950,7 @@ data:
     metadata:
       name: openshift-challenging-client
     redirectURIs:
-    - https://{{ .ExternalAPIDNSName }}:{{ .ExternalOauthPort }}/oauth/token/implicit
+    - https://{{ .ExternalOauthHost }}/oauth/token/implicit
     respondWithChallenges: true
 `)
 
@@ -2514,14 +5020,14 @@ oauthConfig:
   grantConfig:
     method: auto
     serviceAccountMethod: prompt
-{{ if .IdentityProviders }}  identityProviders:
-{{ trimTrailingSpace .IdentityProviders | indent 2 }}{{- else }}  identityProviders: []{{- end }}
+{{ with identityProviders }}  identityProviders:
+{{ indent 2 . }}{{- else }}  identityProviders: []{{- end }}
   loginURL: https://{{ .ExternalAPIDNSName }}:{{ .ExternalAPIPort }}
 {{ if .NamedCerts }}  masterCA: ""
 {{- else }}  masterCA: "/etc/oauth-openshift-config/ca.crt"
 {{- end }}
-  masterPublicURL: https://{{ .ExternalAPIDNSName }}:{{ .ExternalOauthPort }}
-  masterURL: https://{{ .ExternalAPIDNSName }}:{{ .ExternalOauthPort }}
+  masterPublicURL: https://{{ .ExternalOauthHost }}
+  masterURL: https://{{ .ExternalOauthHost }}
   sessionConfig:
     sessionMaxAgeSeconds: 300
     sessionName: ssn
@@ -2537,6 +5043,9 @@ servingInfo:
   bindAddress: 0.0.0.0:6443
   bindNetwork: tcp4
   certFile: /etc/oauth-openshift-secrets/server.crt
+{{ if .FIPS }}
+{{ include "common/fips-serving-info.yaml" 2 }}
+{{ else }}
   cipherSuites:
     - TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305
     - TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305
@@ -2554,9 +5063,10 @@ servingInfo:
     - TLS_RSA_WITH_AES_256_GCM_SHA384
     - TLS_RSA_WITH_AES_128_CBC_SHA
     - TLS_RSA_WITH_AES_256_CBC_SHA
+  minTLSVersion: VersionTLS12
+{{ end }}
   keyFile: /etc/oauth-openshift-secrets/server.key
   maxRequestsInFlight: 1000
-  minTLSVersion: VersionTLS12
   requestTimeoutSeconds: 300
 storageConfig:
   ca: ''
@@ -2604,7 +5114,12 @@ func oauthOpenshiftOauthServerConfigmapYaml() (*asset, error) {
 	return a, nil
 }
 
-var _oauthOpenshiftOauthServerDeploymentYaml = []byte(`kind: Deployment
+var _oauthOpenshiftOauthServerDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: oauth-openshift
+---
+kind: Deployment
 apiVersion: apps/v1
 metadata:
   name: oauth-openshift
@@ -2622,10 +5137,7 @@ spec:
     metadata:
       labels:
         app: oauth-openshift
-{{ if .RestartDate }}
-      annotations:
-        openshift.io/restartedAt: "{{ .RestartDate }}"
-{{ end }}
+{{ include "common/pod-annotations.yaml" 6 }}
     spec:
       tolerations:
       - key: "multi-az-worker"
@@ -2647,10 +5159,12 @@ spec:
                   operator: In
                   values: ["oauth-openshift"]
               topologyKey: "failure-domain.beta.kubernetes.io/zone"
+      serviceAccountName: oauth-openshift
       automountServiceAccountToken: false
       containers:
         - name: openshift-oauthserver
           image: {{ imageFor "oauth-server" }}
+{{ include "common/security-context.yaml" 10 }}
           livenessProbe:
             failureThreshold: 3
             httpGet:
@@ -2687,6 +5201,11 @@ spec:
             - mountPath: /var/config/system/secrets/v4-0-config-system-ocp-branding-template
               name: v4-0-config-system-ocp-branding-template
               readOnly: true
+{{ if .OAuthIdentityProviders }}
+            - mountPath: /etc/oauth-openshift-identity-providers/
+              name: identity-providers
+              readOnly: true
+{{ end }}
           workingDir: /var/run/kubernetes
       volumes:
       - emptyDir: {}
@@ -2716,6 +5235,12 @@ spec:
             - key: errors.html
               path: errors.html
           secretName: v4-0-config-system-ocp-branding-template
+{{ if .OAuthIdentityProviders }}
+      - name: identity-providers
+        secret:
+          defaultMode: 420
+          secretName: oauth-openshift-identity-providers
+{{ end }}
 `)
 
 func oauthOpenshiftOauthServerDeploymentYamlBytes() ([]byte, error) {
@@ -2733,6 +5258,30 @@ func oauthOpenshiftOauthServerDeploymentYaml() (*asset, error) {
 	return a, nil
 }
 
+var _oauthOpenshiftOauthServerIdentityProvidersSecretYaml = []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .Name }}
+data:
+{{ range $key, $value := .Data }}  {{ $key }}: {{ $value }}
+{{ end }}
+`)
+
+func oauthOpenshiftOauthServerIdentityProvidersSecretYamlBytes() ([]byte, error) {
+	return _oauthOpenshiftOauthServerIdentityProvidersSecretYaml, nil
+}
+
+func oauthOpenshiftOauthServerIdentityProvidersSecretYaml() (*asset, error) {
+	bytes, err := oauthOpenshiftOauthServerIdentityProvidersSecretYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "oauth-openshift/oauth-server-identity-providers-secret.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _oauthOpenshiftOauthServerSecretYaml = []byte(`apiVersion: v1
 kind: Secret
 metadata:
@@ -2770,7 +5319,7 @@ spec:
     port: 443
     targetPort: 6443
     nodePort: {{ .ExternalOauthPort }}
-  type: NodePort
+  type: {{ if .APIServiceType }}{{ .APIServiceType }}{{ else }}NodePort{{ end }}
 `)
 
 func oauthOpenshiftOauthServerServiceYamlBytes() ([]byte, error) {
@@ -2880,6 +5429,10 @@ aggregatorConfig:
 apiServerArguments:
   minimal-shutdown-duration:
   - 3s
+{{ if .EtcdEncryption.Provider }}
+  encryption-provider-config:
+  - /etc/kubernetes/encryption/config.yaml
+{{ end }}
 auditConfig:
   auditFilePath: "/var/run/kubernetes/audit.log"
   enabled: true
@@ -2920,6 +5473,9 @@ servingInfo:
   certFile: /etc/kubernetes/secret/server.crt
   keyFile: /etc/kubernetes/secret/server.key
   clientCA: /etc/kubernetes/config/serving-ca.crt
+{{ if .FIPS }}
+{{ include "common/fips-serving-info.yaml" 2 }}
+{{ end }}
 imagePolicyConfig:
   internalRegistryHostname: image-registry.openshift-image-registry.svc:5000
 projectConfig:
@@ -2928,8 +5484,9 @@ routingConfig:
   subdomain: {{ .IngressSubdomain }}
 storageConfig:
   urls:
-  - https://{{ .EtcdClientName }}:2379
-  certFile: /etc/kubernetes/secret/etcd-client.crt
+{{ if .EtcdEndpoints }}{{ range .EtcdEndpoints }}  - {{ . }}
+{{ end }}{{ else }}  - https://{{ .EtcdClientName }}:2379
+{{ end }}  certFile: /etc/kubernetes/secret/etcd-client.crt
   keyFile: /etc/kubernetes/secret/etcd-client.key
   ca: /etc/kubernetes/config/etcd-ca.crt
 `)
@@ -2981,7 +5538,7 @@ data:
   aggregator-client-ca.crt: |-
 {{ include_pki "root-ca.crt" 4 }}
   etcd-ca.crt: |-
-{{ include_pki "root-ca.crt" 4 }}
+{{ include_pki "etcd-ca.crt" 4 }}
   serving-ca.crt: |- 
 {{ include_pki "root-ca.crt" 4 }}
 `)
@@ -3001,7 +5558,12 @@ func openshiftApiserverOpenshiftApiserverConfigmapYaml() (*asset, error) {
 	return a, nil
 }
 
-var _openshiftApiserverOpenshiftApiserverDeploymentYaml = []byte(`kind: Deployment
+var _openshiftApiserverOpenshiftApiserverDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: openshift-apiserver
+---
+kind: Deployment
 apiVersion: apps/v1
 metadata:
   name: openshift-apiserver
@@ -3019,10 +5581,7 @@ spec:
     metadata:
       labels:
         app: openshift-apiserver
-{{ if .RestartDate }}
-      annotations:
-        openshift.io/restartedAt: "{{ .RestartDate }}"
-{{ end }}
+{{ include "common/pod-annotations.yaml" 6 }}
     spec:
       tolerations:
         - key: "multi-az-worker"
@@ -3044,10 +5603,12 @@ spec:
                     operator: In
                     values: ["openshift-apiserver"]
               topologyKey: "failure-domain.beta.kubernetes.io/zone"
+      serviceAccountName: openshift-apiserver
       automountServiceAccountToken: false
       containers:
       - name: openshift-apiserver
         image: {{ imageFor "openshift-apiserver" }}
+{{ include "common/security-context.yaml" 8 }}
         args:
         - "start"
         - "--config=/etc/kubernetes/apiserver-config/config.yaml"
@@ -3059,6 +5620,9 @@ spec:
         - "--requestheader-group-headers=X-Remote-Group"
         - "--requestheader-extra-headers-prefix=X-Remote-Extra-"
         - "--client-ca-file=/etc/kubernetes/config/serving-ca.crt"
+{{ if .ProxyEnabled }}
+{{ include "common/proxy-env.yaml" 8 }}
+{{ end }}
 {{ if .OpenshiftAPIServerResources }}
         resources:{{ range .OpenshiftAPIServerResources }}{{ range .ResourceRequest }}
           requests: {{ if .CPU }}
@@ -3077,7 +5641,26 @@ spec:
           name: config
         - mountPath: /var/run/kubernetes
           name: logs
+{{ if .EtcdEncryption.Provider }}
+        - mountPath: /etc/kubernetes/encryption/
+          name: encryption-config
+{{ end }}{{ if eq .EtcdEncryption.Provider "kms" }}
+        - mountPath: /var/run/kmsplugin/
+          name: kms-socket
+{{ end }}
         workingDir: /var/run/kubernetes
+{{ if eq .EtcdEncryption.Provider "kms" }}
+      - name: kms-plugin
+        image: {{ .EtcdEncryption.KMSPluginImage }}
+{{ include "common/security-context.yaml" 8 }}
+{{ if .EtcdEncryption.KMSPluginArgs }}
+        args:
+{{ range .EtcdEncryption.KMSPluginArgs }}        - "{{ . }}"
+{{ end }}{{ end }}
+        volumeMounts:
+        - mountPath: /var/run/kmsplugin/
+          name: kms-socket
+{{ end }}
       volumes:
       - secret:
           secretName: openshift-apiserver
@@ -3090,6 +5673,14 @@ spec:
         name: apiserver-config
       - emptyDir: {}
         name: logs
+{{ if .EtcdEncryption.Provider }}
+      - secret:
+          secretName: etcd-encryption-config
+        name: encryption-config
+{{ end }}{{ if eq .EtcdEncryption.Provider "kms" }}
+      - emptyDir: {}
+        name: kms-socket
+{{ end }}
 `)
 
 func openshiftApiserverOpenshiftApiserverDeploymentYamlBytes() ([]byte, error) {
@@ -3272,7 +5863,12 @@ func openshiftControllerManager00OpenshiftControllerManagerNamespaceYaml() (*ass
 	return a, nil
 }
 
-var _openshiftControllerManagerClusterPolicyControllerDeploymentYaml = []byte(`kind: Deployment
+var _openshiftControllerManagerClusterPolicyControllerDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: cluster-policy-controller
+---
+kind: Deployment
 apiVersion: apps/v1
 metadata:
   name: cluster-policy-controller
@@ -3290,10 +5886,7 @@ spec:
     metadata:
       labels:
         app: cluster-policy-controller
-{{ if .RestartDate }}
-      annotations:
-        openshift.io/restartedAt: "{{ .RestartDate }}"
-{{ end }}
+{{ include "common/pod-annotations.yaml" 6 }}
     spec:
       tolerations:
         - key: "multi-az-worker"
@@ -3315,10 +5908,12 @@ spec:
                     operator: In
                     values: ["cluster-policy-controller"]
               topologyKey: "failure-domain.beta.kubernetes.io/zone"
+      serviceAccountName: cluster-policy-controller
       automountServiceAccountToken: false
       containers:
       - name: cluster-policy-controller
         image: {{ imageFor "cluster-policy-controller" }}
+{{ include "common/security-context.yaml" 8 }}
         command:
         - "cluster-policy-controller"
         args:
@@ -3453,7 +6048,12 @@ func openshiftControllerManagerOpenshiftControllerManagerConfigmapYaml() (*asset
 	return a, nil
 }
 
-var _openshiftControllerManagerOpenshiftControllerManagerDeploymentYaml = []byte(`kind: Deployment
+var _openshiftControllerManagerOpenshiftControllerManagerDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: openshift-controller-manager
+---
+kind: Deployment
 apiVersion: apps/v1
 metadata:
   name: openshift-controller-manager
@@ -3471,10 +6071,7 @@ spec:
     metadata:
       labels:
         app: openshift-controller-manager
-{{ if .RestartDate }}
-      annotations:
-        openshift.io/restartedAt: "{{ .RestartDate }}"
-{{ end }}
+{{ include "common/pod-annotations.yaml" 6 }}
     spec:
       tolerations:
         - key: "multi-az-worker"
@@ -3496,10 +6093,12 @@ spec:
                     operator: In
                     values: ["openshift-controller-manager"]
               topologyKey: "failure-domain.beta.kubernetes.io/zone"
+      serviceAccountName: openshift-controller-manager
       automountServiceAccountToken: false
       containers:
       - name: openshift-controller-manager
         image: {{ imageFor "openshift-controller-manager" }}
+{{ include "common/security-context.yaml" 8 }}
         command:
         - "openshift-controller-manager"
         args:
@@ -3629,6 +6228,9 @@ remote {{ .ExternalOpenVPNDNSName }} {{ .ExternalOpenVPNPort }} udp
 ca ca.crt
 cert tls.crt
 key tls.key
+{{ if .FIPS }}
+{{ include "openvpn/fips.conf" 0 }}
+{{ end }}
 `)
 
 func openvpnClientConfBytes() ([]byte, error) {
@@ -3646,6 +6248,51 @@ func openvpnClientConf() (*asset, error) {
 	return a, nil
 }
 
+var _openvpnFipsConf = []byte(`cipher AES-256-GCM
+auth SHA256
+tls-version-min 1.2
+tls-cipher TLS-ECDHE-ECDSA-WITH-AES-256-GCM-SHA384:TLS-ECDHE-RSA-WITH-AES-256-GCM-SHA384:TLS-DHE-RSA-WITH-AES-256-GCM-SHA384
+`)
+
+func openvpnFipsConfBytes() ([]byte, error) {
+	return _openvpnFipsConf, nil
+}
+
+func openvpnFipsConf() (*asset, error) {
+	bytes, err := openvpnFipsConfBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "openvpn/fips.conf", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _openvpnOpenvpnCaSecretYaml = []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: openvpn-ca
+data:
+  openvpn-ca.crt: {{ pki "openvpn-ca.crt" }}
+  openvpn-ca.key: {{ pki "openvpn-ca.key" }}
+`)
+
+func openvpnOpenvpnCaSecretYamlBytes() ([]byte, error) {
+	return _openvpnOpenvpnCaSecretYaml, nil
+}
+
+func openvpnOpenvpnCaSecretYaml() (*asset, error) {
+	bytes, err := openvpnOpenvpnCaSecretYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "openvpn/openvpn-ca-secret.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _openvpnOpenvpnCcdConfigmapYaml = []byte(`apiVersion: v1
 kind: ConfigMap
 metadata:
@@ -3827,7 +6474,12 @@ func openvpnOpenvpnServerConfigmapYaml() (*asset, error) {
 	return a, nil
 }
 
-var _openvpnOpenvpnServerDeploymentYaml = []byte(`kind: Deployment
+var _openvpnOpenvpnServerDeploymentYaml = []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: openvpn-server
+---
+kind: Deployment
 apiVersion: apps/v1
 metadata:
   name: openvpn-server
@@ -3840,11 +6492,9 @@ spec:
     metadata:
       labels:
         app: openvpn-server
-{{ if .RestartDate }}
-      annotations:
-        openshift.io/restartedAt: "{{ .RestartDate }}"
-{{ end }}
+{{ include "common/pod-annotations.yaml" 6 }}
     spec:
+      serviceAccountName: openvpn-server
       automountServiceAccountToken: false
       containers:
       - name: openvpn-server
@@ -3909,6 +6559,7 @@ data:
   tls.key: {{ pki "openvpn-server.key" }}
   ca.crt: {{ pki "openvpn-ca.crt" }}
   dh.pem: {{ pki "openvpn-dh.pem" }}
+  crl.pem: {{ pki "openvpn-crl.pem" }}
 `)
 
 func openvpnOpenvpnServerSecretYamlBytes() ([]byte, error) {
@@ -3962,6 +6613,7 @@ ca ca.crt
 cert tls.crt
 key tls.key
 dh dh.pem
+crl-verify crl.pem
 #TODO figure out how to generate this without needing the openvpn command if possible
 #tls-auth /etc/openvpn/pki/ta.key
 #key-direction 0
@@ -3992,6 +6644,9 @@ duplicate-cn
 client-to-client
 push "route {{ address .PodCIDR }} {{ mask .PodCIDR }}"
 push "route {{ address .ServiceCIDR }} {{ mask .ServiceCIDR }}"
+{{ if .FIPS }}
+{{ include "openvpn/fips.conf" 0 }}
+{{ end }}
 `)
 
 func openvpnServerConfBytes() ([]byte, error) {
@@ -4054,8 +6709,11 @@ spec:
       maxInQueue: 0
       maxRunning: 0
       maxWaitInQueue: 0s
-  storage:
-    emptyDir: {}
+  storage:{{ if .ImageRegistryS3Bucket }}
+    s3:
+      bucket: {{ .ImageRegistryS3Bucket }}
+      region: {{ .ImageRegistryS3Region }}{{ else }}
+    emptyDir: {}{{ end }}
 `)
 
 func registryClusterImageregistryConfigYamlBytes() ([]byte, error) {
@@ -4119,6 +6777,10 @@ apiVersion: v1
 kind: Pod
 metadata:
   name: manifests-bootstrapper
+{{ if .PodSecurity.SeccompProfile }}
+  annotations:
+    seccomp.security.alpha.kubernetes.io/pod: "{{ .PodSecurity.SeccompProfile }}"
+{{ end }}
 spec:
   tolerations:
     - key: "multi-az-worker"
@@ -4127,6 +6789,7 @@ spec:
       effect: NoSchedule
   initContainers:
     - image: {{ .ReleaseImage }}
+{{ include "common/security-context.yaml" 6 }}
       imagePullPolicy: IfNotPresent
       name: cluster-version-operator
       workingDir: /tmp
@@ -4145,6 +6808,7 @@ spec:
         - mountPath: /work
           name: work
     - image: {{ imageFor "cluster-config-operator" }}
+{{ include "common/security-context.yaml" 6 }}
       imagePullPolicy: IfNotPresent
       name: config-operator
       workingDir: /tmp
@@ -4162,6 +6826,7 @@ spec:
           name: work
   containers:
     - image: {{ imageFor "cli" }}
+{{ include "common/security-context.yaml" 6 }}
       imagePullPolicy: IfNotPresent
       name: bootstrapper
       workingDir: /work
@@ -4263,6 +6928,13 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
+	"apiserver-frontend/apiserver-frontend-deployment.yaml":                           apiserverFrontendApiserverFrontendDeploymentYaml,
+	"apiserver-frontend/apiserver-frontend-namespace.yaml":                            apiserverFrontendApiserverFrontendNamespaceYaml,
+	"apiserver-frontend/apiserver-frontend-pdb.yaml":                                  apiserverFrontendApiserverFrontendPdbYaml,
+	"apiserver-frontend/apiserver-frontend-service.yaml":                              apiserverFrontendApiserverFrontendServiceYaml,
+	"cluster-autoscaler/cluster-autoscaler-deployment.yaml":                           clusterAutoscalerClusterAutoscalerDeploymentYaml,
+	"cluster-autoscaler/cluster-autoscaler-rbac.yaml":                                 clusterAutoscalerClusterAutoscalerRbacYaml,
+	"cluster-autoscaler/machine-autoscaler-template.yaml":                             clusterAutoscalerMachineAutoscalerTemplateYaml,
 	"cluster-bootstrap/00000_namespaces-needed-for-monitoring.yaml":                   clusterBootstrap00000_namespacesNeededForMonitoringYaml,
 	"cluster-bootstrap/cluster-config-v1-configmap.yaml":                              clusterBootstrapClusterConfigV1ConfigmapYaml,
 	"cluster-bootstrap/cluster-dns-02-config.yaml":                                    clusterBootstrapClusterDns02ConfigYaml,
@@ -4271,30 +6943,59 @@ var _bindata = map[string]func() (*asset, error){
 	"cluster-bootstrap/cluster-ingresscontrollers-02-config.yaml":                     clusterBootstrapClusterIngresscontrollers02ConfigYaml,
 	"cluster-bootstrap/cluster-network-01-crd.yaml":                                   clusterBootstrapClusterNetwork01CrdYaml,
 	"cluster-bootstrap/cluster-network-02-config.yaml":                                clusterBootstrapClusterNetwork02ConfigYaml,
+	"cluster-bootstrap/cluster-network-03-config.yaml":                                clusterBootstrapClusterNetwork03ConfigYaml,
 	"cluster-bootstrap/cluster-proxy-01-config.yaml":                                  clusterBootstrapClusterProxy01ConfigYaml,
+	"cluster-bootstrap/cluster-proxy-02-user-ca-bundle.yaml":                          clusterBootstrapClusterProxy02UserCaBundleYaml,
 	"cluster-bootstrap/cluster-version-namespace.yaml":                                clusterBootstrapClusterVersionNamespaceYaml,
 	"cluster-bootstrap/node-bootstrapper-clusterrolebinding.yaml":                     clusterBootstrapNodeBootstrapperClusterrolebindingYaml,
+	"cluster-version-operator/cluster-version-configmap.yaml":                         clusterVersionOperatorClusterVersionConfigmapYaml,
 	"cluster-version-operator/cluster-version-operator-deployment.yaml":               clusterVersionOperatorClusterVersionOperatorDeploymentYaml,
+	"common/fips-serving-info.yaml":                                                   commonFipsServingInfoYaml,
+	"common/pki-ca-secret.yaml":                                                       commonPkiCaSecretYaml,
+	"common/pod-annotations.yaml":                                                     commonPodAnnotationsYaml,
+	"common/pod-disruption-budget-template.yaml":                                      commonPodDisruptionBudgetTemplateYaml,
+	"common/proxy-env.yaml":                                                           commonProxyEnvYaml,
+	"common/security-context.yaml":                                                    commonSecurityContextYaml,
 	"common/service-network-admin-kubeconfig-secret.yaml":                             commonServiceNetworkAdminKubeconfigSecretYaml,
+	"common/vertical-pod-autoscaler-template.yaml":                                    commonVerticalPodAutoscalerTemplateYaml,
 	"control-plane-operator/cp-operator-configmap.yaml":                               controlPlaneOperatorCpOperatorConfigmapYaml,
 	"control-plane-operator/cp-operator-deployment.yaml":                              controlPlaneOperatorCpOperatorDeploymentYaml,
 	"etcd/etcd-cluster-crd.yaml":                                                      etcdEtcdClusterCrdYaml,
 	"etcd/etcd-cluster.yaml":                                                          etcdEtcdClusterYaml,
+	"etcd/etcd-defrag-cronjob-template.yaml":                                          etcdEtcdDefragCronjobTemplateYaml,
 	"etcd/etcd-operator-cluster-role-binding.yaml":                                    etcdEtcdOperatorClusterRoleBindingYaml,
 	"etcd/etcd-operator-cluster-role.yaml":                                            etcdEtcdOperatorClusterRoleYaml,
 	"etcd/etcd-operator.yaml":                                                         etcdEtcdOperatorYaml,
 	"etcd/etcd-secret-template.yaml":                                                  etcdEtcdSecretTemplateYaml,
+	"etcd/etcd-services.yaml":                                                         etcdEtcdServicesYaml,
+	"etcd/etcd-statefulset-template.yaml":                                             etcdEtcdStatefulsetTemplateYaml,
 	"ignition/files/etc/crio/crio.conf":                                               ignitionFilesEtcCrioCrioConf,
 	"ignition/files/etc/kubernetes/kubelet.conf.template":                             ignitionFilesEtcKubernetesKubeletConfTemplate,
+	"ignition/files/etc/kubernetes/proxy.env.template":                                ignitionFilesEtcKubernetesProxyEnvTemplate,
 	"ignition/files/etc/sysctl.d/forward.conf":                                        ignitionFilesEtcSysctlDForwardConf,
 	"ignition/files/etc/sysctl.d/inotify.conf":                                        ignitionFilesEtcSysctlDInotifyConf,
+	"ignition/files/etc/systemd/system/crio.service.d/10-proxy.conf":                  ignitionFilesEtcSystemdSystemCrioServiceD10ProxyConf,
 	"ignition/files/etc/tmpfiles.d/cleanup-cni.conf":                                  ignitionFilesEtcTmpfilesDCleanupCniConf,
 	"ignition/units/kubelet.service":                                                  ignitionUnitsKubeletService,
+	"ignition-server/ignition-server-deployment.yaml":                                 ignitionServerIgnitionServerDeploymentYaml,
+	"ignition-server/ignition-server-secret.yaml":                                     ignitionServerIgnitionServerSecretYaml,
+	"ignition-server/ignition-server-service.yaml":                                    ignitionServerIgnitionServerServiceYaml,
+	"ignition-server/ignition-server-token-secret.yaml":                               ignitionServerIgnitionServerTokenSecretYaml,
+	"image-content-sources/image-content-source-policy.yaml":                          imageContentSourcesImageContentSourcePolicyYaml,
+	"konnectivity/konnectivity-agent-daemonset.yaml":                                  konnectivityKonnectivityAgentDaemonsetYaml,
+	"konnectivity/konnectivity-agent-secret.yaml":                                     konnectivityKonnectivityAgentSecretYaml,
+	"konnectivity/konnectivity-server-secret.yaml":                                    konnectivityKonnectivityServerSecretYaml,
+	"konnectivity/konnectivity-server-service.yaml":                                   konnectivityKonnectivityServerServiceYaml,
 	"kube-apiserver/client.conf":                                                      kubeApiserverClientConf,
 	"kube-apiserver/config.yaml":                                                      kubeApiserverConfigYaml,
+	"kube-apiserver/etcd-encryption-secret.yaml":                                      kubeApiserverEtcdEncryptionSecretYaml,
+	"kube-apiserver/kube-apiserver-audit-policy-configmap.yaml":                       kubeApiserverKubeApiserverAuditPolicyConfigmapYaml,
+	"kube-apiserver/kube-apiserver-audit-webhook-secret.yaml":                         kubeApiserverKubeApiserverAuditWebhookSecretYaml,
 	"kube-apiserver/kube-apiserver-config-configmap.yaml":                             kubeApiserverKubeApiserverConfigConfigmapYaml,
 	"kube-apiserver/kube-apiserver-configmap.yaml":                                    kubeApiserverKubeApiserverConfigmapYaml,
 	"kube-apiserver/kube-apiserver-deployment.yaml":                                   kubeApiserverKubeApiserverDeploymentYaml,
+	"kube-apiserver/kube-apiserver-egress-selector-configmap.yaml":                    kubeApiserverKubeApiserverEgressSelectorConfigmapYaml,
+	"kube-apiserver/kube-apiserver-named-certs-secret.yaml":                           kubeApiserverKubeApiserverNamedCertsSecretYaml,
 	"kube-apiserver/kube-apiserver-oauth-metadata-configmap.yaml":                     kubeApiserverKubeApiserverOauthMetadataConfigmapYaml,
 	"kube-apiserver/kube-apiserver-secret.yaml":                                       kubeApiserverKubeApiserverSecretYaml,
 	"kube-apiserver/kube-apiserver-service.yaml":                                      kubeApiserverKubeApiserverServiceYaml,
@@ -4310,12 +7011,30 @@ var _bindata = map[string]func() (*asset, error){
 	"kube-scheduler/kube-scheduler-config-configmap.yaml":                             kubeSchedulerKubeSchedulerConfigConfigmapYaml,
 	"kube-scheduler/kube-scheduler-deployment.yaml":                                   kubeSchedulerKubeSchedulerDeploymentYaml,
 	"kube-scheduler/kube-scheduler-secret.yaml":                                       kubeSchedulerKubeSchedulerSecretYaml,
+	"monitoring/metrics-client-secret.yaml":                                           monitoringMetricsClientSecretYaml,
+	"monitoring/metrics-proxy-rbac.yaml":                                              monitoringMetricsProxyRbacYaml,
+	"monitoring/metrics-reader-rbac.yaml":                                             monitoringMetricsReaderRbacYaml,
+	"monitoring/metrics-service-template.yaml":                                        monitoringMetricsServiceTemplateYaml,
+	"monitoring/service-monitor-template.yaml":                                        monitoringServiceMonitorTemplateYaml,
+	"network-policy/default-deny-network-policy.yaml":                                 networkPolicyDefaultDenyNetworkPolicyYaml,
+	"network-policy/etcd-network-policy.yaml":                                         networkPolicyEtcdNetworkPolicyYaml,
+	"network-policy/ignition-server-network-policy.yaml":                              networkPolicyIgnitionServerNetworkPolicyYaml,
+	"network-policy/kube-apiserver-network-policy.yaml":                               networkPolicyKubeApiserverNetworkPolicyYaml,
+	"network-policy/metrics-network-policy.yaml":                                      networkPolicyMetricsNetworkPolicyYaml,
+	"network-policy/oauth-openshift-network-policy.yaml":                              networkPolicyOauthOpenshiftNetworkPolicyYaml,
+	"network-policy/openshift-apiserver-network-policy.yaml":                          networkPolicyOpenshiftApiserverNetworkPolicyYaml,
+	"network-policy/openvpn-server-network-policy.yaml":                               networkPolicyOpenvpnServerNetworkPolicyYaml,
+	"node-updater/node-updater-configmap.yaml":                                        nodeUpdaterNodeUpdaterConfigmapYaml,
+	"node-updater/node-updater-daemonset.yaml":                                        nodeUpdaterNodeUpdaterDaemonsetYaml,
+	"node-updater/node-updater-namespace.yaml":                                        nodeUpdaterNodeUpdaterNamespaceYaml,
+	"node-updater/node-updater-rbac.yaml":                                             nodeUpdaterNodeUpdaterRbacYaml,
 	"oauth-openshift/oauth-browser-client.yaml":                                       oauthOpenshiftOauthBrowserClientYaml,
 	"oauth-openshift/oauth-challenging-client.yaml":                                   oauthOpenshiftOauthChallengingClientYaml,
 	"oauth-openshift/oauth-server-config-configmap.yaml":                              oauthOpenshiftOauthServerConfigConfigmapYaml,
 	"oauth-openshift/oauth-server-config.yaml":                                        oauthOpenshiftOauthServerConfigYaml,
 	"oauth-openshift/oauth-server-configmap.yaml":                                     oauthOpenshiftOauthServerConfigmapYaml,
 	"oauth-openshift/oauth-server-deployment.yaml":                                    oauthOpenshiftOauthServerDeploymentYaml,
+	"oauth-openshift/oauth-server-identity-providers-secret.yaml":                     oauthOpenshiftOauthServerIdentityProvidersSecretYaml,
 	"oauth-openshift/oauth-server-secret.yaml":                                        oauthOpenshiftOauthServerSecretYaml,
 	"oauth-openshift/oauth-server-service.yaml":                                       oauthOpenshiftOauthServerServiceYaml,
 	"oauth-openshift/oauth-server-sessionsecret-secret.yaml":                          oauthOpenshiftOauthServerSessionsecretSecretYaml,
@@ -4340,6 +7059,8 @@ var _bindata = map[string]func() (*asset, error){
 	"openshift-controller-manager/openshift-controller-manager-service-ca.yaml":       openshiftControllerManagerOpenshiftControllerManagerServiceCaYaml,
 	"openvpn/Dockerfile":                                                              openvpnDockerfile,
 	"openvpn/client.conf":                                                             openvpnClientConf,
+	"openvpn/fips.conf":                                                               openvpnFipsConf,
+	"openvpn/openvpn-ca-secret.yaml":                                                  openvpnOpenvpnCaSecretYaml,
 	"openvpn/openvpn-ccd-configmap.yaml":                                              openvpnOpenvpnCcdConfigmapYaml,
 	"openvpn/openvpn-client-configmap.yaml":                                           openvpnOpenvpnClientConfigmapYaml,
 	"openvpn/openvpn-client-deployment.yaml":                                          openvpnOpenvpnClientDeploymentYaml,
@@ -4396,6 +7117,17 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
+	"apiserver-frontend": {nil, map[string]*bintree{
+		"apiserver-frontend-deployment.yaml": {apiserverFrontendApiserverFrontendDeploymentYaml, map[string]*bintree{}},
+		"apiserver-frontend-namespace.yaml":  {apiserverFrontendApiserverFrontendNamespaceYaml, map[string]*bintree{}},
+		"apiserver-frontend-pdb.yaml":        {apiserverFrontendApiserverFrontendPdbYaml, map[string]*bintree{}},
+		"apiserver-frontend-service.yaml":    {apiserverFrontendApiserverFrontendServiceYaml, map[string]*bintree{}},
+	}},
+	"cluster-autoscaler": {nil, map[string]*bintree{
+		"cluster-autoscaler-deployment.yaml": {clusterAutoscalerClusterAutoscalerDeploymentYaml, map[string]*bintree{}},
+		"cluster-autoscaler-rbac.yaml":       {clusterAutoscalerClusterAutoscalerRbacYaml, map[string]*bintree{}},
+		"machine-autoscaler-template.yaml":   {clusterAutoscalerMachineAutoscalerTemplateYaml, map[string]*bintree{}},
+	}},
 	"cluster-bootstrap": {nil, map[string]*bintree{
 		"00000_namespaces-needed-for-monitoring.yaml": {clusterBootstrap00000_namespacesNeededForMonitoringYaml, map[string]*bintree{}},
 		"cluster-config-v1-configmap.yaml":            {clusterBootstrapClusterConfigV1ConfigmapYaml, map[string]*bintree{}},
@@ -4405,15 +7137,25 @@ var _bintree = &bintree{nil, map[string]*bintree{
 		"cluster-ingresscontrollers-02-config.yaml":   {clusterBootstrapClusterIngresscontrollers02ConfigYaml, map[string]*bintree{}},
 		"cluster-network-01-crd.yaml":                 {clusterBootstrapClusterNetwork01CrdYaml, map[string]*bintree{}},
 		"cluster-network-02-config.yaml":              {clusterBootstrapClusterNetwork02ConfigYaml, map[string]*bintree{}},
+		"cluster-network-03-config.yaml":              {clusterBootstrapClusterNetwork03ConfigYaml, map[string]*bintree{}},
 		"cluster-proxy-01-config.yaml":                {clusterBootstrapClusterProxy01ConfigYaml, map[string]*bintree{}},
+		"cluster-proxy-02-user-ca-bundle.yaml":        {clusterBootstrapClusterProxy02UserCaBundleYaml, map[string]*bintree{}},
 		"cluster-version-namespace.yaml":              {clusterBootstrapClusterVersionNamespaceYaml, map[string]*bintree{}},
 		"node-bootstrapper-clusterrolebinding.yaml":   {clusterBootstrapNodeBootstrapperClusterrolebindingYaml, map[string]*bintree{}},
 	}},
 	"cluster-version-operator": {nil, map[string]*bintree{
+		"cluster-version-configmap.yaml":           {clusterVersionOperatorClusterVersionConfigmapYaml, map[string]*bintree{}},
 		"cluster-version-operator-deployment.yaml": {clusterVersionOperatorClusterVersionOperatorDeploymentYaml, map[string]*bintree{}},
 	}},
 	"common": {nil, map[string]*bintree{
+		"fips-serving-info.yaml":                       {commonFipsServingInfoYaml, map[string]*bintree{}},
+		"pki-ca-secret.yaml":                           {commonPkiCaSecretYaml, map[string]*bintree{}},
+		"pod-annotations.yaml":                         {commonPodAnnotationsYaml, map[string]*bintree{}},
+		"pod-disruption-budget-template.yaml":          {commonPodDisruptionBudgetTemplateYaml, map[string]*bintree{}},
+		"proxy-env.yaml":                               {commonProxyEnvYaml, map[string]*bintree{}},
+		"security-context.yaml":                        {commonSecurityContextYaml, map[string]*bintree{}},
 		"service-network-admin-kubeconfig-secret.yaml": {commonServiceNetworkAdminKubeconfigSecretYaml, map[string]*bintree{}},
+		"vertical-pod-autoscaler-template.yaml":        {commonVerticalPodAutoscalerTemplateYaml, map[string]*bintree{}},
 	}},
 	"control-plane-operator": {nil, map[string]*bintree{
 		"cp-operator-configmap.yaml":  {controlPlaneOperatorCpOperatorConfigmapYaml, map[string]*bintree{}},
@@ -4422,10 +7164,13 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"etcd": {nil, map[string]*bintree{
 		"etcd-cluster-crd.yaml":                   {etcdEtcdClusterCrdYaml, map[string]*bintree{}},
 		"etcd-cluster.yaml":                       {etcdEtcdClusterYaml, map[string]*bintree{}},
+		"etcd-defrag-cronjob-template.yaml":       {etcdEtcdDefragCronjobTemplateYaml, map[string]*bintree{}},
 		"etcd-operator-cluster-role-binding.yaml": {etcdEtcdOperatorClusterRoleBindingYaml, map[string]*bintree{}},
 		"etcd-operator-cluster-role.yaml":         {etcdEtcdOperatorClusterRoleYaml, map[string]*bintree{}},
 		"etcd-operator.yaml":                      {etcdEtcdOperatorYaml, map[string]*bintree{}},
 		"etcd-secret-template.yaml":               {etcdEtcdSecretTemplateYaml, map[string]*bintree{}},
+		"etcd-services.yaml":                      {etcdEtcdServicesYaml, map[string]*bintree{}},
+		"etcd-statefulset-template.yaml":          {etcdEtcdStatefulsetTemplateYaml, map[string]*bintree{}},
 	}},
 	"ignition": {nil, map[string]*bintree{
 		"files": {nil, map[string]*bintree{
@@ -4435,11 +7180,19 @@ var _bintree = &bintree{nil, map[string]*bintree{
 				}},
 				"kubernetes": {nil, map[string]*bintree{
 					"kubelet.conf.template": {ignitionFilesEtcKubernetesKubeletConfTemplate, map[string]*bintree{}},
+					"proxy.env.template":    {ignitionFilesEtcKubernetesProxyEnvTemplate, map[string]*bintree{}},
 				}},
 				"sysctl.d": {nil, map[string]*bintree{
 					"forward.conf": {ignitionFilesEtcSysctlDForwardConf, map[string]*bintree{}},
 					"inotify.conf": {ignitionFilesEtcSysctlDInotifyConf, map[string]*bintree{}},
 				}},
+				"systemd": {nil, map[string]*bintree{
+					"system": {nil, map[string]*bintree{
+						"crio.service.d": {nil, map[string]*bintree{
+							"10-proxy.conf": {ignitionFilesEtcSystemdSystemCrioServiceD10ProxyConf, map[string]*bintree{}},
+						}},
+					}},
+				}},
 				"tmpfiles.d": {nil, map[string]*bintree{
 					"cleanup-cni.conf": {ignitionFilesEtcTmpfilesDCleanupCniConf, map[string]*bintree{}},
 				}},
@@ -4449,18 +7202,38 @@ var _bintree = &bintree{nil, map[string]*bintree{
 			"kubelet.service": {ignitionUnitsKubeletService, map[string]*bintree{}},
 		}},
 	}},
+	"ignition-server": {nil, map[string]*bintree{
+		"ignition-server-deployment.yaml":   {ignitionServerIgnitionServerDeploymentYaml, map[string]*bintree{}},
+		"ignition-server-secret.yaml":       {ignitionServerIgnitionServerSecretYaml, map[string]*bintree{}},
+		"ignition-server-service.yaml":      {ignitionServerIgnitionServerServiceYaml, map[string]*bintree{}},
+		"ignition-server-token-secret.yaml": {ignitionServerIgnitionServerTokenSecretYaml, map[string]*bintree{}},
+	}},
+	"image-content-sources": {nil, map[string]*bintree{
+		"image-content-source-policy.yaml": {imageContentSourcesImageContentSourcePolicyYaml, map[string]*bintree{}},
+	}},
+	"konnectivity": {nil, map[string]*bintree{
+		"konnectivity-agent-daemonset.yaml": {konnectivityKonnectivityAgentDaemonsetYaml, map[string]*bintree{}},
+		"konnectivity-agent-secret.yaml":    {konnectivityKonnectivityAgentSecretYaml, map[string]*bintree{}},
+		"konnectivity-server-secret.yaml":   {konnectivityKonnectivityServerSecretYaml, map[string]*bintree{}},
+		"konnectivity-server-service.yaml":  {konnectivityKonnectivityServerServiceYaml, map[string]*bintree{}},
+	}},
 	"kube-apiserver": {nil, map[string]*bintree{
-		"client.conf":                                  {kubeApiserverClientConf, map[string]*bintree{}},
-		"config.yaml":                                  {kubeApiserverConfigYaml, map[string]*bintree{}},
-		"kube-apiserver-config-configmap.yaml":         {kubeApiserverKubeApiserverConfigConfigmapYaml, map[string]*bintree{}},
-		"kube-apiserver-configmap.yaml":                {kubeApiserverKubeApiserverConfigmapYaml, map[string]*bintree{}},
-		"kube-apiserver-deployment.yaml":               {kubeApiserverKubeApiserverDeploymentYaml, map[string]*bintree{}},
-		"kube-apiserver-oauth-metadata-configmap.yaml": {kubeApiserverKubeApiserverOauthMetadataConfigmapYaml, map[string]*bintree{}},
-		"kube-apiserver-secret.yaml":                   {kubeApiserverKubeApiserverSecretYaml, map[string]*bintree{}},
-		"kube-apiserver-service.yaml":                  {kubeApiserverKubeApiserverServiceYaml, map[string]*bintree{}},
-		"kube-apiserver-vpnclient-config.yaml":         {kubeApiserverKubeApiserverVpnclientConfigYaml, map[string]*bintree{}},
-		"kube-apiserver-vpnclient-secret.yaml":         {kubeApiserverKubeApiserverVpnclientSecretYaml, map[string]*bintree{}},
-		"oauthMetadata.json":                           {kubeApiserverOauthmetadataJson, map[string]*bintree{}},
+		"client.conf":                 {kubeApiserverClientConf, map[string]*bintree{}},
+		"config.yaml":                 {kubeApiserverConfigYaml, map[string]*bintree{}},
+		"etcd-encryption-secret.yaml": {kubeApiserverEtcdEncryptionSecretYaml, map[string]*bintree{}},
+		"kube-apiserver-audit-policy-configmap.yaml":    {kubeApiserverKubeApiserverAuditPolicyConfigmapYaml, map[string]*bintree{}},
+		"kube-apiserver-audit-webhook-secret.yaml":      {kubeApiserverKubeApiserverAuditWebhookSecretYaml, map[string]*bintree{}},
+		"kube-apiserver-config-configmap.yaml":          {kubeApiserverKubeApiserverConfigConfigmapYaml, map[string]*bintree{}},
+		"kube-apiserver-configmap.yaml":                 {kubeApiserverKubeApiserverConfigmapYaml, map[string]*bintree{}},
+		"kube-apiserver-deployment.yaml":                {kubeApiserverKubeApiserverDeploymentYaml, map[string]*bintree{}},
+		"kube-apiserver-egress-selector-configmap.yaml": {kubeApiserverKubeApiserverEgressSelectorConfigmapYaml, map[string]*bintree{}},
+		"kube-apiserver-named-certs-secret.yaml":        {kubeApiserverKubeApiserverNamedCertsSecretYaml, map[string]*bintree{}},
+		"kube-apiserver-oauth-metadata-configmap.yaml":  {kubeApiserverKubeApiserverOauthMetadataConfigmapYaml, map[string]*bintree{}},
+		"kube-apiserver-secret.yaml":                    {kubeApiserverKubeApiserverSecretYaml, map[string]*bintree{}},
+		"kube-apiserver-service.yaml":                   {kubeApiserverKubeApiserverServiceYaml, map[string]*bintree{}},
+		"kube-apiserver-vpnclient-config.yaml":          {kubeApiserverKubeApiserverVpnclientConfigYaml, map[string]*bintree{}},
+		"kube-apiserver-vpnclient-secret.yaml":          {kubeApiserverKubeApiserverVpnclientSecretYaml, map[string]*bintree{}},
+		"oauthMetadata.json":                            {kubeApiserverOauthmetadataJson, map[string]*bintree{}},
 	}},
 	"kube-controller-manager": {nil, map[string]*bintree{
 		"config.yaml": {kubeControllerManagerConfigYaml, map[string]*bintree{}},
@@ -4475,18 +7248,42 @@ var _bintree = &bintree{nil, map[string]*bintree{
 		"kube-scheduler-deployment.yaml":       {kubeSchedulerKubeSchedulerDeploymentYaml, map[string]*bintree{}},
 		"kube-scheduler-secret.yaml":           {kubeSchedulerKubeSchedulerSecretYaml, map[string]*bintree{}},
 	}},
+	"monitoring": {nil, map[string]*bintree{
+		"metrics-client-secret.yaml":    {monitoringMetricsClientSecretYaml, map[string]*bintree{}},
+		"metrics-proxy-rbac.yaml":       {monitoringMetricsProxyRbacYaml, map[string]*bintree{}},
+		"metrics-reader-rbac.yaml":      {monitoringMetricsReaderRbacYaml, map[string]*bintree{}},
+		"metrics-service-template.yaml": {monitoringMetricsServiceTemplateYaml, map[string]*bintree{}},
+		"service-monitor-template.yaml": {monitoringServiceMonitorTemplateYaml, map[string]*bintree{}},
+	}},
+	"network-policy": {nil, map[string]*bintree{
+		"default-deny-network-policy.yaml":        {networkPolicyDefaultDenyNetworkPolicyYaml, map[string]*bintree{}},
+		"etcd-network-policy.yaml":                {networkPolicyEtcdNetworkPolicyYaml, map[string]*bintree{}},
+		"ignition-server-network-policy.yaml":     {networkPolicyIgnitionServerNetworkPolicyYaml, map[string]*bintree{}},
+		"kube-apiserver-network-policy.yaml":      {networkPolicyKubeApiserverNetworkPolicyYaml, map[string]*bintree{}},
+		"metrics-network-policy.yaml":             {networkPolicyMetricsNetworkPolicyYaml, map[string]*bintree{}},
+		"oauth-openshift-network-policy.yaml":     {networkPolicyOauthOpenshiftNetworkPolicyYaml, map[string]*bintree{}},
+		"openshift-apiserver-network-policy.yaml": {networkPolicyOpenshiftApiserverNetworkPolicyYaml, map[string]*bintree{}},
+		"openvpn-server-network-policy.yaml":      {networkPolicyOpenvpnServerNetworkPolicyYaml, map[string]*bintree{}},
+	}},
+	"node-updater": {nil, map[string]*bintree{
+		"node-updater-configmap.yaml": {nodeUpdaterNodeUpdaterConfigmapYaml, map[string]*bintree{}},
+		"node-updater-daemonset.yaml": {nodeUpdaterNodeUpdaterDaemonsetYaml, map[string]*bintree{}},
+		"node-updater-namespace.yaml": {nodeUpdaterNodeUpdaterNamespaceYaml, map[string]*bintree{}},
+		"node-updater-rbac.yaml":      {nodeUpdaterNodeUpdaterRbacYaml, map[string]*bintree{}},
+	}},
 	"oauth-openshift": {nil, map[string]*bintree{
-		"oauth-browser-client.yaml":              {oauthOpenshiftOauthBrowserClientYaml, map[string]*bintree{}},
-		"oauth-challenging-client.yaml":          {oauthOpenshiftOauthChallengingClientYaml, map[string]*bintree{}},
-		"oauth-server-config-configmap.yaml":     {oauthOpenshiftOauthServerConfigConfigmapYaml, map[string]*bintree{}},
-		"oauth-server-config.yaml":               {oauthOpenshiftOauthServerConfigYaml, map[string]*bintree{}},
-		"oauth-server-configmap.yaml":            {oauthOpenshiftOauthServerConfigmapYaml, map[string]*bintree{}},
-		"oauth-server-deployment.yaml":           {oauthOpenshiftOauthServerDeploymentYaml, map[string]*bintree{}},
-		"oauth-server-secret.yaml":               {oauthOpenshiftOauthServerSecretYaml, map[string]*bintree{}},
-		"oauth-server-service.yaml":              {oauthOpenshiftOauthServerServiceYaml, map[string]*bintree{}},
-		"oauth-server-sessionsecret-secret.yaml": {oauthOpenshiftOauthServerSessionsecretSecretYaml, map[string]*bintree{}},
-		"v4-0-config-system-branding.yaml":       {oauthOpenshiftV40ConfigSystemBrandingYaml, map[string]*bintree{}},
-		"v4-0-config-system-session.json":        {oauthOpenshiftV40ConfigSystemSessionJson, map[string]*bintree{}},
+		"oauth-browser-client.yaml":                   {oauthOpenshiftOauthBrowserClientYaml, map[string]*bintree{}},
+		"oauth-challenging-client.yaml":               {oauthOpenshiftOauthChallengingClientYaml, map[string]*bintree{}},
+		"oauth-server-config-configmap.yaml":          {oauthOpenshiftOauthServerConfigConfigmapYaml, map[string]*bintree{}},
+		"oauth-server-config.yaml":                    {oauthOpenshiftOauthServerConfigYaml, map[string]*bintree{}},
+		"oauth-server-configmap.yaml":                 {oauthOpenshiftOauthServerConfigmapYaml, map[string]*bintree{}},
+		"oauth-server-deployment.yaml":                {oauthOpenshiftOauthServerDeploymentYaml, map[string]*bintree{}},
+		"oauth-server-identity-providers-secret.yaml": {oauthOpenshiftOauthServerIdentityProvidersSecretYaml, map[string]*bintree{}},
+		"oauth-server-secret.yaml":                    {oauthOpenshiftOauthServerSecretYaml, map[string]*bintree{}},
+		"oauth-server-service.yaml":                   {oauthOpenshiftOauthServerServiceYaml, map[string]*bintree{}},
+		"oauth-server-sessionsecret-secret.yaml":      {oauthOpenshiftOauthServerSessionsecretSecretYaml, map[string]*bintree{}},
+		"v4-0-config-system-branding.yaml":            {oauthOpenshiftV40ConfigSystemBrandingYaml, map[string]*bintree{}},
+		"v4-0-config-system-session.json":             {oauthOpenshiftV40ConfigSystemSessionJson, map[string]*bintree{}},
 	}},
 	"openshift-apiserver": {nil, map[string]*bintree{
 		"config.yaml": {openshiftApiserverConfigYaml, map[string]*bintree{}},
@@ -4512,6 +7309,8 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"openvpn": {nil, map[string]*bintree{
 		"Dockerfile":                     {openvpnDockerfile, map[string]*bintree{}},
 		"client.conf":                    {openvpnClientConf, map[string]*bintree{}},
+		"fips.conf":                      {openvpnFipsConf, map[string]*bintree{}},
+		"openvpn-ca-secret.yaml":         {openvpnOpenvpnCaSecretYaml, map[string]*bintree{}},
 		"openvpn-ccd-configmap.yaml":     {openvpnOpenvpnCcdConfigmapYaml, map[string]*bintree{}},
 		"openvpn-client-configmap.yaml":  {openvpnOpenvpnClientConfigmapYaml, map[string]*bintree{}},
 		"openvpn-client-deployment.yaml": {openvpnOpenvpnClientDeploymentYaml, map[string]*bintree{}},
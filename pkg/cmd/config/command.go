@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/openshift/hypershift-toolkit/pkg/cmd/util"
+	"github.com/openshift/hypershift-toolkit/pkg/config"
+)
+
+func NewConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Commands for the config file of a cluster",
+	}
+	cmd.AddCommand(newValidateCommand())
+	return cmd
+}
+
+func newValidateCommand() *cobra.Command {
+	var configFile string
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validates the config file of a cluster before PKI, manifests or ignition are generated from it",
+		Run: func(cmd *cobra.Command, args []string) {
+			params, err := config.ReadFrom(configFile)
+			if err != nil {
+				log.WithError(err).Fatal("Cannot read config file")
+			}
+			errs := config.Validate(params)
+			if len(errs) == 0 {
+				fmt.Printf("%s is valid\n", configFile)
+				return
+			}
+			for _, err := range errs {
+				fmt.Fprintln(os.Stderr, err.Error())
+			}
+			log.Fatalf("%s has %d errors", configFile, len(errs))
+		},
+	}
+	cmd.Flags().StringVar(&configFile, "config", defaultConfigFile(), "Specify the config file for this cluster")
+	return cmd
+}
+
+func defaultConfigFile() string {
+	return filepath.Join(util.WorkingDir(), "cluster.yaml")
+}
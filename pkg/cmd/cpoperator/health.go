@@ -0,0 +1,142 @@
+package cpoperator
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// stuckReconcileTimeout is how long a controller may reconcile a single item before it
+	// is considered wedged, which fails the liveness check of the operator
+	stuckReconcileTimeout = 10 * time.Minute
+
+	// longestRunningProcessorMetric is the workqueue metric of controller-runtime controllers
+	// with how long the oldest reconcile in progress has been running, labeled by controller
+	longestRunningProcessorMetric = "workqueue_longest_running_processor_seconds"
+)
+
+// healthChecks serves the liveness and readiness of the operator. /healthz fails when a
+// controller has been stuck in a reconcile for longer than the timeout, and
+// /healthz/<controller> reports a single controller. /readyz fails while the controller
+// managers are not started. Both list the result of each check in their body.
+type healthChecks struct {
+	gatherer prometheus.Gatherer
+	timeout  time.Duration
+	started  int32
+}
+
+func newHealthChecks() *healthChecks {
+	return &healthChecks{gatherer: metrics.Registry, timeout: stuckReconcileTimeout}
+}
+
+func (h *healthChecks) setStarted(started bool) {
+	var value int32
+	if started {
+		value = 1
+	}
+	atomic.StoreInt32(&h.started, value)
+}
+
+func (h *healthChecks) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", h.serveLiveness)
+	mux.HandleFunc("/healthz/", h.serveLiveness)
+	mux.HandleFunc("/readyz", h.serveReadiness)
+	return mux
+}
+
+func (h *healthChecks) serveLiveness(w http.ResponseWriter, req *http.Request) {
+	results, err := controllerHealth(h.gatherer, h.timeout)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("cannot gather controller metrics: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if name := strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, "/healthz"), "/"); len(name) > 0 {
+		result, ok := results[name]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		results = map[string]error{name: result}
+	}
+	writeResults(w, "healthz", results)
+}
+
+func (h *healthChecks) serveReadiness(w http.ResponseWriter, req *http.Request) {
+	var result error
+	if atomic.LoadInt32(&h.started) == 0 {
+		result = fmt.Errorf("controller managers are not started")
+	}
+	writeResults(w, "readyz", map[string]error{"managers": result})
+}
+
+// writeResults writes a line for each check, sorted by name, and fails the request when
+// any check failed
+func writeResults(w http.ResponseWriter, endpoint string, results map[string]error) {
+	names := make([]string, 0, len(results))
+	failed := false
+	for name, err := range results {
+		names = append(names, name)
+		failed = failed || err != nil
+	}
+	sort.Strings(names)
+	var body strings.Builder
+	for _, name := range names {
+		if err := results[name]; err != nil {
+			fmt.Fprintf(&body, "[-]%s failed: %v\n", name, err)
+		} else {
+			fmt.Fprintf(&body, "[+]%s ok\n", name)
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if failed {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(&body, "%s check failed\n", endpoint)
+	} else {
+		fmt.Fprintf(&body, "%s check passed\n", endpoint)
+	}
+	w.Write([]byte(body.String()))
+}
+
+// controllerHealth returns the health of each controller-runtime controller with a
+// workqueue in the registry. A controller whose longest running reconcile exceeds the
+// timeout is reported as stuck.
+func controllerHealth(gatherer prometheus.Gatherer, timeout time.Duration) (map[string]error, error) {
+	families, err := gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+	results := map[string]error{}
+	for _, family := range families {
+		if family.GetName() != longestRunningProcessorMetric {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			name := ""
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "name" {
+					name = label.GetValue()
+				}
+			}
+			if len(name) == 0 {
+				continue
+			}
+			running := time.Duration(metric.GetGauge().GetValue() * float64(time.Second))
+			if running > timeout {
+				results[name] = fmt.Errorf("a reconcile has been running for %s", running.Round(time.Second))
+				continue
+			}
+			results[name] = nil
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,94 @@
+package cpoperator
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func testRegistry(running map[string]float64) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	for name, seconds := range running {
+		gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        longestRunningProcessorMetric,
+			Help:        "How many seconds has the longest running processor for workqueue been running.",
+			ConstLabels: prometheus.Labels{"name": name},
+		})
+		gauge.Set(seconds)
+		registry.MustRegister(gauge)
+	}
+	return registry
+}
+
+func TestControllerHealth(t *testing.T) {
+	registry := testRegistry(map[string]float64{"cluster-version": 2, "auto-approver": 3600})
+	results, err := controllerHealth(registry, 10*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected the health of 2 controllers, got %v", results)
+	}
+	if err := results["cluster-version"]; err != nil {
+		t.Errorf("expected cluster-version to be healthy, got %v", err)
+	}
+	if err := results["auto-approver"]; err == nil {
+		t.Errorf("expected auto-approver to be stuck")
+	}
+}
+
+func get(t *testing.T, url string) (int, string) {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestHealthChecks(t *testing.T) {
+	health := &healthChecks{
+		gatherer: testRegistry(map[string]float64{"cluster-version": 2, "auto-approver": 3600}),
+		timeout:  10 * time.Minute,
+	}
+	server := httptest.NewServer(health.handler())
+	defer server.Close()
+
+	if status, body := get(t, server.URL+"/readyz"); status != http.StatusInternalServerError || !strings.Contains(body, "[-]managers failed") {
+		t.Errorf("expected the operator not to be ready before the managers started, got %d: %s", status, body)
+	}
+	health.setStarted(true)
+	if status, body := get(t, server.URL+"/readyz"); status != http.StatusOK || !strings.Contains(body, "readyz check passed") {
+		t.Errorf("expected the operator to be ready, got %d: %s", status, body)
+	}
+
+	tests := []struct {
+		path     string
+		status   int
+		contains string
+	}{
+		{path: "/healthz", status: http.StatusInternalServerError, contains: "[+]cluster-version ok\n"},
+		{path: "/healthz", status: http.StatusInternalServerError, contains: "[-]auto-approver failed"},
+		{path: "/healthz/cluster-version", status: http.StatusOK, contains: "healthz check passed"},
+		{path: "/healthz/auto-approver", status: http.StatusInternalServerError, contains: "healthz check failed"},
+		{path: "/healthz/unknown", status: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		status, body := get(t, server.URL+test.path)
+		if status != test.status {
+			t.Errorf("%s: expected status %d, got %d: %s", test.path, test.status, status, body)
+		}
+		if !strings.Contains(body, test.contains) {
+			t.Errorf("%s: expected %q in body, got %s", test.path, test.contains, body)
+		}
+	}
+}
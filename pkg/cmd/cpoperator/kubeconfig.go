@@ -0,0 +1,46 @@
+package cpoperator
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// kubeconfigCheckInterval is how often the target kubeconfig is checked for changes
+const kubeconfigCheckInterval = 30 * time.Second
+
+// watchKubeconfig notifies the returned channel when the contents of a kubeconfig file
+// change, which happens when the secret that it is mounted from is rotated. Kubeconfigs
+// that cannot be loaded are ignored until they are fixed, so that a partial update does
+// not stop the controllers.
+func watchKubeconfig(path string, interval time.Duration, log logr.Logger, stopCh <-chan struct{}) <-chan struct{} {
+	changed := make(chan struct{}, 1)
+	last, _ := ioutil.ReadFile(path)
+	lastSum := sha256.Sum256(last)
+	go wait.Until(func() {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Error(err, "cannot read the kubeconfig", "path", path)
+			return
+		}
+		sum := sha256.Sum256(contents)
+		if sum == lastSum {
+			return
+		}
+		if _, err := clientcmd.Load(contents); err != nil {
+			log.Error(err, "ignoring invalid kubeconfig", "path", path)
+			return
+		}
+		lastSum = sum
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}, interval, stopCh)
+	return changed
+}
@@ -0,0 +1,65 @@
+package cpoperator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: cluster
+  cluster:
+    server: https://kube-apiserver:6443
+contexts:
+- name: admin
+  context:
+    cluster: cluster
+    user: admin
+current-context: admin
+users:
+- name: admin
+  user:
+    token: token
+`
+
+func TestWatchKubeconfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "kubeconfig")
+	write := func(contents string) {
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(testKubeconfig)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	changed := watchKubeconfig(path, 10*time.Millisecond, ctrl.Log, stopCh)
+
+	expectChange := func(expected bool, description string) {
+		select {
+		case <-changed:
+			if !expected {
+				t.Errorf("did not expect a change when %s", description)
+			}
+		case <-time.After(200 * time.Millisecond):
+			if expected {
+				t.Errorf("expected a change when %s", description)
+			}
+		}
+	}
+	expectChange(false, "the kubeconfig is unchanged")
+	write("clusters: [")
+	expectChange(false, "the kubeconfig is invalid")
+	write(testKubeconfig + "preferences: {}\n")
+	expectChange(true, "the kubeconfig is rotated")
+}
@@ -0,0 +1,76 @@
+package cpoperator
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const targetClusterProbeInterval = 30 * time.Second
+
+var (
+	controllersRunning = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hypershift_control_plane_operator_controller_running",
+		Help: "Whether a controller of the control plane operator was set up and is running",
+	}, []string{"controller"})
+
+	targetClusterReachable = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "hypershift_control_plane_operator_target_cluster_reachable",
+		Help: "Whether the last health check of the target cluster's API server succeeded",
+	})
+)
+
+func init() {
+	// Reconcile errors of the controllers are counted by
+	// hypershift_control_plane_operator_reconcile_errors_total in the same registry
+	metrics.Registry.MustRegister(controllersRunning, targetClusterReachable)
+}
+
+// serveMetrics serves the metrics of the operator's registry, which both controller
+// managers share, until stopCh is closed
+func serveMetrics(addr string, stopCh <-chan struct{}) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.HTTPErrorOnError,
+	}))
+	return serveHTTP(addr, mux, stopCh)
+}
+
+// serveHTTP serves a handler on an address until stopCh is closed
+func serveHTTP(addr string, handler http.Handler, stopCh <-chan struct{}) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: handler}
+	go func() {
+		<-stopCh
+		server.Shutdown(context.Background())
+	}()
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// probeTargetCluster periodically checks the health endpoint of the target cluster's
+// API server and records the result
+func (c *ControlPlaneOperatorConfig) probeTargetCluster(stopCh <-chan struct{}) error {
+	wait.Until(func() {
+		result := c.TargetKubeClient().Discovery().RESTClient().Get().AbsPath("/healthz").Do()
+		if err := result.Error(); err != nil {
+			c.Logger().Error(err, "target cluster is not reachable")
+			targetClusterReachable.Set(0)
+			return
+		}
+		targetClusterReachable.Set(1)
+	}, targetClusterProbeInterval, stopCh)
+	return nil
+}
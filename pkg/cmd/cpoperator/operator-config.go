@@ -3,15 +3,22 @@ package cpoperator
 import (
 	"fmt"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/informers"
 	kubeclient "k8s.io/client-go/kubernetes"
 	kubescheme "k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
@@ -21,35 +28,62 @@ import (
 	common "github.com/openshift/hypershift-toolkit/pkg/controllers"
 )
 
+// shutdownTimeout is how long the operator waits for its controller managers to stop after
+// it is asked to terminate, which is within the default termination grace period of pods
+const shutdownTimeout = 20 * time.Second
+
 type ControllerSetupFunc func(*ControlPlaneOperatorConfig) error
 
-func NewControlPlaneOperatorConfig(targetKubeconfig, namespace string, initialCA []byte, versions map[string]string, controllers []string, controllerFuncs map[string]ControllerSetupFunc) *ControlPlaneOperatorConfig {
+func NewControlPlaneOperatorConfig(targetKubeconfig, managementKubeconfig, namespace string, initialCA []byte, versions map[string]string, certValidity, kubeletServingCASyncInterval time.Duration, metricsAddr, healthAddr string, leaderElect bool, controllers []string, controllersConfigMap string, controllerFuncs map[string]ControllerSetupFunc) *ControlPlaneOperatorConfig {
 	return &ControlPlaneOperatorConfig{
 		targetKubeconfig: targetKubeconfig,
+		metricsAddr:      metricsAddr,
+		healthAddr:       healthAddr,
+		leaderElect:      leaderElect,
 		namespace:        namespace,
 		initialCA:        initialCA,
 		controllers:      controllers,
 		controllerFuncs:  controllerFuncs,
 		versions:         versions,
+		certValidity:     certValidity,
+
+		managementKubeconfig:         managementKubeconfig,
+		controllersConfigMap:         controllersConfigMap,
+		kubeletServingCASyncInterval: kubeletServingCASyncInterval,
 	}
 }
 
 type ControlPlaneOperatorConfig struct {
-	manager          ctrl.Manager
-	config           *rest.Config
-	targetConfig     *rest.Config
-	targetKubeClient kubeclient.Interface
-	kubeClient       kubeclient.Interface
-	logger           logr.Logger
-	scheme           *runtime.Scheme
+	manager           ctrl.Manager
+	managementManager ctrl.Manager
+	config            *rest.Config
+	targetConfig      *rest.Config
+	targetKubeClient  kubeclient.Interface
+	kubeClient        kubeclient.Interface
+	logger            logr.Logger
+	scheme            *runtime.Scheme
+	eventRecorder     record.EventRecorder
 
 	versions            map[string]string
+	certValidity        time.Duration
+	metricsAddr         string
+	healthAddr          string
+	leaderElect         bool
 	targetKubeconfig    string
 	namespace           string
 	initialCA           []byte
 	controllers         []string
 	controllerFuncs     map[string]ControllerSetupFunc
 	namespacedInformers map[string]informers.SharedInformerFactory
+	kubeInformers       informers.SharedInformerFactory
+
+	// managementKubeconfig is the kubeconfig of the management cluster, which defaults to the
+	// --kubeconfig flag or the in-cluster config
+	managementKubeconfig string
+	// controllersConfigMap is the configmap in the namespace that selects the controllers
+	// to run at runtime, if any
+	controllersConfigMap         string
+	kubeletServingCASyncInterval time.Duration
 }
 
 func (c *ControlPlaneOperatorConfig) Scheme() *runtime.Scheme {
@@ -65,10 +99,11 @@ func (c *ControlPlaneOperatorConfig) Manager() ctrl.Manager {
 		var err error
 		c.manager, err = ctrl.NewManager(c.TargetConfig(), ctrl.Options{
 			Scheme:                  c.Scheme(),
-			LeaderElection:          true,
+			LeaderElection:          c.leaderElect,
 			LeaderElectionNamespace: c.TargetNamespace(),
 			LeaderElectionID:        "control-plane-operator",
 			Namespace:               c.TargetNamespace(),
+			MetricsBindAddress:      "0",
 		})
 		if err != nil {
 			c.Fatal(err, "failed to create controller manager")
@@ -77,6 +112,26 @@ func (c *ControlPlaneOperatorConfig) Manager() ctrl.Manager {
 	return c.manager
 }
 
+// ManagementManager returns a controller manager for controllers that manage resources
+// across the management cluster. Leader election happens in the operator's namespace,
+// which defaults to the namespace of the operator pod.
+func (c *ControlPlaneOperatorConfig) ManagementManager() ctrl.Manager {
+	if c.managementManager == nil {
+		var err error
+		c.managementManager, err = ctrl.NewManager(c.Config(), ctrl.Options{
+			Scheme:                  c.Scheme(),
+			LeaderElection:          c.leaderElect,
+			LeaderElectionNamespace: c.Namespace(),
+			LeaderElectionID:        "hosted-cluster-operator",
+			MetricsBindAddress:      "0",
+		})
+		if err != nil {
+			c.Fatal(err, "failed to create management controller manager")
+		}
+	}
+	return c.managementManager
+}
+
 func (c *ControlPlaneOperatorConfig) Namespace() string {
 	return c.namespace
 }
@@ -87,7 +142,17 @@ func (c *ControlPlaneOperatorConfig) TargetNamespace() string {
 
 func (c *ControlPlaneOperatorConfig) Config() *rest.Config {
 	if c.config == nil {
-		c.config = ctrl.GetConfigOrDie()
+		if len(c.managementKubeconfig) == 0 {
+			c.config = ctrl.GetConfigOrDie()
+			return c.config
+		}
+		var err error
+		c.config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: c.managementKubeconfig},
+			&clientcmd.ConfigOverrides{}).ClientConfig()
+		if err != nil {
+			c.Fatal(err, "cannot get the management cluster's rest config")
+		}
 	}
 	return c.config
 }
@@ -156,6 +221,19 @@ func (c *ControlPlaneOperatorConfig) TargetKubeInformersForNamespace(namespace s
 	return informer
 }
 
+// KubeInformers returns an informer factory for the namespace of the control plane
+// on the management cluster
+func (c *ControlPlaneOperatorConfig) KubeInformers() informers.SharedInformerFactory {
+	if c.kubeInformers == nil {
+		c.kubeInformers = informers.NewSharedInformerFactoryWithOptions(c.KubeClient(), common.DefaultResync, informers.WithNamespace(c.Namespace()))
+		c.Manager().Add(manager.RunnableFunc(func(stopCh <-chan struct{}) error {
+			c.kubeInformers.Start(stopCh)
+			return nil
+		}))
+	}
+	return c.kubeInformers
+}
+
 func (c *ControlPlaneOperatorConfig) KubeClient() kubeclient.Interface {
 	if c.kubeClient == nil {
 		var err error
@@ -167,10 +245,32 @@ func (c *ControlPlaneOperatorConfig) KubeClient() kubeclient.Interface {
 	return c.kubeClient
 }
 
+// EventRecorder returns a recorder of the events of the controllers on the management
+// cluster. Events are created in the namespace of the object that they are about.
+func (c *ControlPlaneOperatorConfig) EventRecorder() record.EventRecorder {
+	if c.eventRecorder == nil {
+		broadcaster := record.NewBroadcaster()
+		broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: c.KubeClient().CoreV1().Events("")})
+		c.eventRecorder = broadcaster.NewRecorder(c.Scheme(), corev1.EventSource{Component: "control-plane-operator"})
+	}
+	return c.eventRecorder
+}
+
 func (c *ControlPlaneOperatorConfig) Versions() map[string]string {
 	return c.versions
 }
 
+// CertValidity is the validity of certificates issued by the control plane PKI
+func (c *ControlPlaneOperatorConfig) CertValidity() time.Duration {
+	return c.certValidity
+}
+
+// KubeletServingCASyncInterval is how often the kubelet serving CA bundle of the target
+// cluster is compared with the CAs of the control plane
+func (c *ControlPlaneOperatorConfig) KubeletServingCASyncInterval() time.Duration {
+	return c.kubeletServingCASyncInterval
+}
+
 func (c *ControlPlaneOperatorConfig) InitialCA() string {
 	return string(c.initialCA)
 }
@@ -181,7 +281,99 @@ func (c *ControlPlaneOperatorConfig) Fatal(err error, msg string) {
 }
 
 func (c *ControlPlaneOperatorConfig) Start() error {
-	for _, controllerName := range c.controllers {
+	stopCh := ctrl.SetupSignalHandler()
+	errCh := make(chan error, 2)
+	// The managers share a metrics registry, which is served once for both of them
+	if c.metricsAddr != "0" {
+		go func() {
+			if err := serveMetrics(c.metricsAddr, stopCh); err != nil {
+				errCh <- fmt.Errorf("cannot serve metrics: %v", err)
+			}
+		}()
+	}
+	health := newHealthChecks()
+	if c.healthAddr != "0" {
+		go func() {
+			if err := serveHTTP(c.healthAddr, health.handler(), stopCh); err != nil {
+				errCh <- fmt.Errorf("cannot serve health checks: %v", err)
+			}
+		}()
+	}
+	controllers := c.controllers
+	var selection *controllerSelection
+	var changed <-chan struct{}
+	if len(c.controllersConfigMap) > 0 {
+		known := sets.NewString()
+		for name := range c.controllerFuncs {
+			known.Insert(name)
+		}
+		selection = newControllerSelection(c.KubeClient(), c.Namespace(), c.controllersConfigMap, c.controllers, known)
+		if err := selection.start(stopCh); err != nil {
+			return err
+		}
+		changed = selection.changed
+	}
+	// Controllers are restarted with new target clients when the target kubeconfig is rotated
+	var kubeconfigChanged <-chan struct{}
+	if len(c.targetKubeconfig) > 0 {
+		kubeconfigChanged = watchKubeconfig(c.targetKubeconfig, kubeconfigCheckInterval, c.Logger(), stopCh)
+	}
+	for {
+		if selection != nil {
+			var unknown []string
+			controllers, unknown = selection.controllers()
+			if len(unknown) > 0 {
+				c.Logger().Info("Ignoring unknown controllers of the controllers configmap", "configmap", c.controllersConfigMap, "controllers", unknown)
+			}
+		}
+		c.Logger().Info("Starting controllers", "controllers", controllers)
+		runStopCh := make(chan struct{})
+		runErrCh := make(chan error, 1)
+		go func(controllers []string) {
+			runErrCh <- c.runControllers(controllers, health, runStopCh)
+		}(controllers)
+		restart := ""
+		var err error
+	wait:
+		for {
+			select {
+			case err = <-runErrCh:
+				return err
+			case err = <-errCh:
+				break wait
+			case <-stopCh:
+				c.Logger().Info("Shutting down")
+				break wait
+			case <-changed:
+				if selected, _ := selection.controllers(); !equality.Semantic.DeepEqual(selected, controllers) {
+					restart = "the controllers configmap changed"
+					break wait
+				}
+			case <-kubeconfigChanged:
+				restart = "the target kubeconfig changed"
+				break wait
+			}
+		}
+		close(runStopCh)
+		if runErr := <-runErrCh; err == nil {
+			err = runErr
+		}
+		if len(restart) == 0 || err != nil {
+			return err
+		}
+		c.Logger().Info("Restarting controllers", "reason", restart)
+		if err := c.reset(); err != nil {
+			return err
+		}
+	}
+}
+
+// runControllers sets up and runs controllers until stopCh is closed, and waits for them to
+// stop
+func (c *ControlPlaneOperatorConfig) runControllers(controllers []string, health *healthChecks, stopCh <-chan struct{}) error {
+	health.setStarted(false)
+	controllersRunning.Reset()
+	for _, controllerName := range controllers {
 		setupFunc, ok := c.controllerFuncs[controllerName]
 		if !ok {
 			return fmt.Errorf("unknown controller specified: %s", controllerName)
@@ -189,7 +381,68 @@ func (c *ControlPlaneOperatorConfig) Start() error {
 		if err := setupFunc(c); err != nil {
 			return fmt.Errorf("cannot setup controller %s: %v", controllerName, err)
 		}
+		controllersRunning.WithLabelValues(controllerName).Set(1)
 	}
-	stopCh := make(chan struct{})
-	return c.Manager().Start(stopCh)
+	if c.manager != nil {
+		c.manager.Add(manager.RunnableFunc(c.probeTargetCluster))
+	}
+	// Only start the managers that controllers were added to, so that controllers of the
+	// management cluster can run without a target cluster
+	managers := []ctrl.Manager{}
+	for _, m := range []ctrl.Manager{c.manager, c.managementManager} {
+		if m != nil {
+			managers = append(managers, m)
+		}
+	}
+	if len(managers) == 0 {
+		// The controllers configmap may disable every controller until it changes again
+		if len(c.controllersConfigMap) == 0 {
+			return fmt.Errorf("no controllers were set up")
+		}
+		health.setStarted(true)
+		<-stopCh
+		return nil
+	}
+	managerStopCh := make(chan struct{})
+	errCh := make(chan error, len(managers))
+	var running sync.WaitGroup
+	for _, m := range managers {
+		running.Add(1)
+		go func(m ctrl.Manager) {
+			defer running.Done()
+			errCh <- m.Start(managerStopCh)
+		}(m)
+	}
+	health.setStarted(true)
+	var err error
+	select {
+	case err = <-errCh:
+	case <-stopCh:
+	}
+	close(managerStopCh)
+	// Wait for the managers to stop their controllers and informers. Another replica, or the
+	// restarted controllers, take over once the leases of the managers expire.
+	stopped := make(chan struct{})
+	go func() {
+		running.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		c.Logger().Info("Controller managers did not stop in time", "timeout", shutdownTimeout)
+	}
+	return err
+}
+
+// reset drops the managers, informers and target clients of stopped controllers, so that
+// the controllers that replace them are set up with new ones
+func (c *ControlPlaneOperatorConfig) reset() error {
+	c.manager = nil
+	c.managementManager = nil
+	c.targetConfig = nil
+	c.targetKubeClient = nil
+	c.namespacedInformers = nil
+	c.kubeInformers = nil
+	return unregisterWorkqueueMetrics()
 }
@@ -0,0 +1,154 @@
+package cpoperator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/informers"
+	kubeclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	common "github.com/openshift/hypershift-toolkit/pkg/controllers"
+)
+
+// ControllersConfigMapKey is the key of the controllers configmap of the operator with the
+// controllers to run
+const ControllersConfigMapKey = "controllers"
+
+// controllerSelection watches the controllers configmap of the operator, which selects the
+// controllers to run at runtime instead of the --controllers flag
+type controllerSelection struct {
+	name     string
+	defaults []string
+	known    sets.String
+	informer cache.SharedIndexInformer
+	changed  chan struct{}
+}
+
+func newControllerSelection(client kubeclient.Interface, namespace, name string, defaults []string, known sets.String) *controllerSelection {
+	factory := informers.NewSharedInformerFactoryWithOptions(client, common.DefaultResync, informers.WithNamespace(namespace), informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+	}))
+	s := &controllerSelection{
+		name:     name,
+		defaults: defaults,
+		known:    known,
+		informer: factory.Core().V1().ConfigMaps().Informer(),
+		changed:  make(chan struct{}, 1),
+	}
+	notify := func() {
+		select {
+		case s.changed <- struct{}{}:
+		default:
+		}
+	}
+	s.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { notify() },
+		UpdateFunc: func(interface{}, interface{}) { notify() },
+		DeleteFunc: func(interface{}) { notify() },
+	})
+	return s
+}
+
+// start watches the configmap until stopCh is closed, and returns once it was read
+func (s *controllerSelection) start(stopCh <-chan struct{}) error {
+	go s.informer.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, s.informer.HasSynced) {
+		return fmt.Errorf("cannot read the controllers configmap %s", s.name)
+	}
+	return nil
+}
+
+// controllers returns the known controllers that the configmap selects, and the unknown
+// controllers that it names. Without the configmap or its key, the controllers of the
+// --controllers flag are selected.
+func (s *controllerSelection) controllers() (selected []string, unknown []string) {
+	setting, found := "", false
+	for _, obj := range s.informer.GetStore().List() {
+		if cm, ok := obj.(*corev1.ConfigMap); ok && cm.Name == s.name {
+			setting, found = cm.Data[ControllersConfigMapKey]
+		}
+	}
+	if !found {
+		return s.defaults, nil
+	}
+	for _, controller := range selectControllers(setting, s.defaults) {
+		if s.known.Has(controller) {
+			selected = append(selected, controller)
+		} else {
+			unknown = append(unknown, controller)
+		}
+	}
+	return selected, unknown
+}
+
+// selectControllers returns the controllers that a setting selects. Like the --controllers
+// flag of the kube-controller-manager, the setting is a comma separated list where "*"
+// stands for the default controllers, "name" enables a controller and "-name" disables it.
+func selectControllers(setting string, defaults []string) []string {
+	enabled, disabled := sets.NewString(), sets.NewString()
+	all := false
+	var named []string
+	for _, item := range strings.Split(setting, ",") {
+		item = strings.TrimSpace(item)
+		switch {
+		case len(item) == 0:
+		case item == "*":
+			all = true
+		case strings.HasPrefix(item, "-"):
+			disabled.Insert(strings.TrimPrefix(item, "-"))
+		default:
+			if !enabled.Has(item) {
+				named = append(named, item)
+			}
+			enabled.Insert(item)
+		}
+	}
+	selected := []string{}
+	candidates := append(append([]string{}, defaults...), named...)
+	for _, controller := range candidates {
+		if disabled.Has(controller) || (!all && !enabled.Has(controller)) {
+			continue
+		}
+		// Controllers are selected once, in the order of the defaults and then of the setting
+		disabled.Insert(controller)
+		selected = append(selected, controller)
+	}
+	return selected
+}
+
+// unregisterWorkqueueMetrics removes the workqueue metrics of stopped controllers from the
+// registry. Controller-runtime cannot register the metrics of the controllers that replace
+// them otherwise, and would keep exporting the last values of the stopped workqueues.
+func unregisterWorkqueueMetrics() error {
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		return err
+	}
+	for _, family := range families {
+		if !strings.HasPrefix(family.GetName(), "workqueue_") {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() != "name" {
+					continue
+				}
+				// Collectors are unregistered by the name and constant labels of their metrics
+				metrics.Registry.Unregister(prometheus.NewGauge(prometheus.GaugeOpts{
+					Name:        family.GetName(),
+					Help:        family.GetHelp(),
+					ConstLabels: prometheus.Labels{"name": label.GetValue()},
+				}))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package cpoperator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func TestSelectControllers(t *testing.T) {
+	defaults := []string{"controller-manager-ca", "cluster-operator", "cluster-version"}
+	tests := []struct {
+		setting  string
+		expected []string
+	}{
+		{setting: "*", expected: defaults},
+		{setting: "", expected: []string{}},
+		{setting: "*,-cluster-operator", expected: []string{"controller-manager-ca", "cluster-version"}},
+		{setting: " * , node-gc ", expected: []string{"controller-manager-ca", "cluster-operator", "cluster-version", "node-gc"}},
+		{setting: "node-gc,cluster-version,node-gc", expected: []string{"cluster-version", "node-gc"}},
+		{setting: "node-gc,-node-gc,*", expected: defaults},
+	}
+	for _, test := range tests {
+		if actual := selectControllers(test.setting, defaults); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%q: expected %v, got %v", test.setting, test.expected, actual)
+		}
+	}
+}
+
+func TestUnregisterWorkqueueMetrics(t *testing.T) {
+	adds := func() prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "workqueue_adds_total",
+			Help:        "Total number of adds handled by workqueue",
+			ConstLabels: prometheus.Labels{"name": "test-controller"},
+		})
+	}
+	if err := metrics.Registry.Register(adds()); err != nil {
+		t.Fatal(err)
+	}
+	if err := unregisterWorkqueueMetrics(); err != nil {
+		t.Fatal(err)
+	}
+	if err := metrics.Registry.Register(adds()); err != nil {
+		t.Errorf("expected the metrics of a restarted workqueue to be registered again: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package frontend
+
+import (
+	"net"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/openshift/hypershift-toolkit/pkg/frontend"
+)
+
+func NewAPIServerFrontendCommand() *cobra.Command {
+	var domain, apiListenAddress, oauthListenAddress, ignitionListenAddress string
+	cmd := &cobra.Command{
+		Use:   "apiserver-frontend",
+		Short: "Forwards the API, OAuth and ignition connections of the clusters of a management cluster to their control plane namespaces by SNI",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(domain) == 0 {
+				log.Fatal("The domain of the clusters is required")
+			}
+			proxy := frontend.NewProxy(domain)
+			listeners := map[string]frontend.Route{
+				apiListenAddress:      {Service: "kube-apiserver", Port: 6443},
+				oauthListenAddress:    {Service: "oauth-openshift", Port: 443},
+				ignitionListenAddress: {Service: "ignition-server", Port: 443},
+			}
+			errs := make(chan error, len(listeners))
+			for address, route := range listeners {
+				if len(address) == 0 {
+					continue
+				}
+				listener, err := net.Listen("tcp", address)
+				if err != nil {
+					log.WithError(err).Fatalf("Cannot listen on %s", address)
+				}
+				log.Infof("Forwarding %s to service %s port %d of api.NAMESPACE.%s", address, route.Service, route.Port, proxy.Domain)
+				go func(listener net.Listener, route frontend.Route) {
+					errs <- proxy.Serve(listener, route)
+				}(listener, route)
+			}
+			log.WithError(<-errs).Fatal("API server frontend failed")
+		},
+	}
+	cmd.Flags().StringVar(&domain, "domain", "", "Specify the parent domain of the API names (api.NAMESPACE.DOMAIN) of the clusters")
+	cmd.Flags().StringVar(&apiListenAddress, "api-listen", ":6443", "Specify the address to listen on for the kube-apiservers")
+	cmd.Flags().StringVar(&oauthListenAddress, "oauth-listen", ":8443", "Specify the address to listen on for the OAuth servers, or empty to not forward them")
+	cmd.Flags().StringVar(&ignitionListenAddress, "ignition-listen", ":22623", "Specify the address to listen on for the ignition servers, or empty to not forward them")
+	return cmd
+}
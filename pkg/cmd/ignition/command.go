@@ -13,7 +13,7 @@ import (
 )
 
 func NewIgnitionCommand() *cobra.Command {
-	var pkiDir, outputDir, configFile, pullSecretFile, sshPublicKeyFile string
+	var pkiDir, outputDir, configFile, pullSecretFile, sshPublicKeyFile, machineConfigsDir string
 	cmd := &cobra.Command{
 		Use:   "ignition",
 		Short: "Generates an ignition file to be used by RHCOS workers on boot",
@@ -24,6 +24,9 @@ func NewIgnitionCommand() *cobra.Command {
 			if err != nil {
 				log.WithError(err).Fatal("Cannot read config file")
 			}
+			if len(machineConfigsDir) > 0 {
+				params.MachineConfigsDir = machineConfigsDir
+			}
 
 			sshPublicKey, err := ioutil.ReadFile(sshPublicKeyFile)
 			if err != nil {
@@ -40,6 +43,7 @@ func NewIgnitionCommand() *cobra.Command {
 	cmd.Flags().StringVar(&sshPublicKeyFile, "ssh-public-key", defaultSSHPublicKeyFile(), "Specify the config file for this cluster")
 	cmd.Flags().StringVar(&pkiDir, "pki-dir", defaultPKIDir(), "Specify the directory containing PKI files")
 	cmd.Flags().StringVar(&pullSecretFile, "pull-secret", defaultPullSecretFile(), "Specify the config file for this cluster")
+	cmd.Flags().StringVar(&machineConfigsDir, "machine-configs-dir", "", "Directory of MachineConfigs and ignition configs whose files, units and kernel arguments are merged into the worker ignition")
 	return cmd
 }
 
@@ -0,0 +1,45 @@
+package ignition
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/openshift/hypershift-toolkit/pkg/ignition"
+)
+
+func NewIgnitionServerCommand() *cobra.Command {
+	var configFile, tokenFile, certFile, keyFile, listenAddress string
+	cmd := &cobra.Command{
+		Use:   "ignition-server",
+		Short: "Serves the worker ignition config of a cluster over TLS from its control plane namespace",
+		Run: func(cmd *cobra.Command, args []string) {
+			token, err := ioutil.ReadFile(tokenFile)
+			if err != nil {
+				log.WithError(err).Fatal("Cannot read token file")
+			}
+			if len(strings.TrimSpace(string(token))) == 0 {
+				log.Fatal("The token file is empty")
+			}
+			server := &http.Server{
+				Addr:      listenAddress,
+				Handler:   ignition.ServerHandler(configFile, strings.TrimSpace(string(token))),
+				TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+			}
+			log.Infof("Serving ignition config %s on %s", configFile, listenAddress)
+			if err := server.ListenAndServeTLS(certFile, keyFile); err != nil {
+				log.WithError(err).Fatal("Ignition server failed")
+			}
+		},
+	}
+	cmd.Flags().StringVar(&configFile, "config-file", "/etc/ignition-server/config/worker.ign", "Specify the ignition config file to serve")
+	cmd.Flags().StringVar(&tokenFile, "token-file", "/etc/ignition-server/token/token", "Specify the file with the token that requests must include")
+	cmd.Flags().StringVar(&certFile, "tls-cert-file", "/etc/ignition-server/tls/tls.crt", "Specify the serving certificate file")
+	cmd.Flags().StringVar(&keyFile, "tls-key-file", "/etc/ignition-server/tls/tls.key", "Specify the serving key file")
+	cmd.Flags().StringVar(&listenAddress, "listen", ":8443", "Specify the address to listen on")
+	return cmd
+}
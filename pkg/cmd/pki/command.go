@@ -12,7 +12,7 @@ import (
 )
 
 func NewPKICommand() *cobra.Command {
-	var outputDir, configFile string
+	var outputDir, configFile, caDir string
 	cmd := &cobra.Command{
 		Use:   "pki",
 		Short: "Generates PKI artifacts given an output directory",
@@ -23,6 +23,9 @@ func NewPKICommand() *cobra.Command {
 			if err != nil {
 				log.WithError(err).Fatal("Cannot read config file")
 			}
+			if caDir != "" {
+				params.PKI.CADirectory = caDir
+			}
 
 			if err := pki.GeneratePKI(params, outputDir); err != nil {
 				log.WithError(err).Fatal("Failed to generate PKI")
@@ -31,6 +34,8 @@ func NewPKICommand() *cobra.Command {
 	}
 	cmd.Flags().StringVar(&outputDir, "output-dir", defaultOutputDir(), "Specify the directory where PKI artifacts should be output")
 	cmd.Flags().StringVar(&configFile, "config", defaultConfigFile(), "Specify the config file for this cluster")
+	cmd.Flags().StringVar(&caDir, "ca-dir", "", "Specify a directory with existing CA key pairs to use instead of generating CAs (overrides pki.caDirectory)")
+	cmd.AddCommand(NewRevokeCommand())
 	return cmd
 }
 
@@ -0,0 +1,35 @@
+package pki
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/openshift/hypershift-toolkit/pkg/config"
+	"github.com/openshift/hypershift-toolkit/pkg/pki"
+)
+
+func NewRevokeCommand() *cobra.Command {
+	var pkiDir, configFile string
+	cmd := &cobra.Command{
+		Use:   "revoke CERT...",
+		Short: "Revokes OpenVPN client certificates and generates new ones",
+		Long: fmt.Sprintf("Adds OpenVPN client certificates (%s) to the CRL of the OpenVPN CA and replaces them "+
+			"with new certificates. Render and apply the secrets of the cluster afterwards.", strings.Join(pki.RevocableCerts, ", ")),
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			params, err := config.ReadFrom(configFile)
+			if err != nil {
+				log.WithError(err).Fatal("Cannot read config file")
+			}
+			if err := pki.RevokeVPNCerts(params, pkiDir, args); err != nil {
+				log.WithError(err).Fatal("Failed to revoke certificates")
+			}
+		},
+	}
+	cmd.Flags().StringVar(&pkiDir, "pki-dir", defaultOutputDir(), "Specify the directory of the PKI artifacts of the cluster")
+	cmd.Flags().StringVar(&configFile, "config", defaultConfigFile(), "Specify the config file for this cluster")
+	return cmd
+}
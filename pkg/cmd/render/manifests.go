@@ -2,27 +2,40 @@ package render
 
 import (
 	"encoding/base64"
+	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/openshift/hypershift-toolkit/pkg/cmd/util"
 	"github.com/openshift/hypershift-toolkit/pkg/config"
+	"github.com/openshift/hypershift-toolkit/pkg/release"
 	"github.com/openshift/hypershift-toolkit/pkg/render"
 )
 
 type RenderManifestsOptions struct {
-	OutputDir      string
-	ConfigFile     string
-	PullSecretFile string
-	PKIDir         string
+	OutputDir        string
+	ConfigFile       string
+	PullSecretFile   string
+	PKIDir           string
+	OutputFormat     string
+	ChartVersion     string
+	AssetVersion     string
+	AssetsDir        string
+	UserManifestsDir string
+	NoCache          bool
+	CacheTTL         time.Duration
 
-	IncludeSecrets  bool
-	IncludeEtcd     bool
-	IncludeVPN      bool
-	IncludeRegistry bool
+	IncludeSecrets      bool
+	IncludeEtcd         bool
+	IncludeVPN          bool
+	IncludeKonnectivity bool
+	IncludeRegistry     bool
 }
 
 func NewRenderManifestsCommand() *cobra.Command {
@@ -36,35 +49,89 @@ func NewRenderManifestsCommand() *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&opt.OutputDir, "output-dir", defaultManifestsDir(), "Specify the directory where manifest files should be output")
+	cmd.Flags().StringVar(&opt.OutputFormat, "output-format", render.OutputFormatDir, fmt.Sprintf("Format of the rendered manifests, one of: %s", strings.Join(render.OutputFormats, ", ")))
+	cmd.Flags().StringVar(&opt.ChartVersion, "chart-version", "0.1.0", "Version of the Helm chart rendered with the helm output format")
+	cmd.Flags().StringVar(&opt.AssetVersion, "asset-version", "", fmt.Sprintf("OpenShift minor version of the manifests to render, instead of the version of the release image (one of: %s)", strings.Join(render.AssetVersions(), ", ")))
+	cmd.Flags().StringVar(&opt.AssetsDir, "assets-dir", "", "Directory of manifest templates that replace or add to the built-in templates")
+	cmd.Flags().StringVar(&opt.UserManifestsDir, "user-manifests-dir", "", "Directory of manifests that are applied to the cluster as they are, such as those of a third-party network plugin")
+	cmd.Flags().BoolVar(&opt.NoCache, "no-cache", false, "If true, the info of the release image is pulled rather than read from the release info cache")
+	cmd.Flags().DurationVar(&opt.CacheTTL, "release-cache-ttl", release.DefaultCacheTTL, "How long the cached info of a release image referenced by tag is used")
 	cmd.Flags().StringVar(&opt.ConfigFile, "config", defaultConfigFile(), "Specify the config file for this cluster")
 	cmd.Flags().StringVar(&opt.PullSecretFile, "pull-secret", defaultPullSecretFile(), "Specify the config file for this cluster")
 	cmd.Flags().StringVar(&opt.PKIDir, "pki-dir", defaultPKIDir(), "Specify the directory where the input PKI files have been placed")
 	cmd.Flags().BoolVar(&opt.IncludeSecrets, "include-secrets", false, "If true, PKI secrets will be included in rendered manifests")
 	cmd.Flags().BoolVar(&opt.IncludeEtcd, "include-etcd", false, "If true, Etcd manifests will be included in rendered manifests")
 	cmd.Flags().BoolVar(&opt.IncludeVPN, "include-vpn", false, "If true, includes a VPN server, sidecar and client")
+	cmd.Flags().BoolVar(&opt.IncludeKonnectivity, "include-konnectivity", false, "If true, includes a konnectivity server and agents instead of a VPN")
 	cmd.Flags().BoolVar(&opt.IncludeRegistry, "include-registry", false, "If true, includes a default registry config to deploy into the user cluster")
 	return cmd
 }
 
 func (o *RenderManifestsOptions) Run() error {
-	util.EnsureDir(o.OutputDir)
+	if o.IncludeVPN && o.IncludeKonnectivity {
+		return fmt.Errorf("--include-vpn and --include-konnectivity cannot be used together")
+	}
+	if o.NoCache {
+		release.Cache = nil
+	} else if release.Cache != nil {
+		release.Cache.TTL = o.CacheTTL
+	}
+	switch o.OutputFormat {
+	case render.OutputFormatDir, render.OutputFormatKustomize, render.OutputFormatHelm:
+		util.EnsureDir(o.OutputDir)
+	case render.OutputFormatStream:
+		// The manifests are rendered to a temporary directory and written to stdout
+		dir, err := ioutil.TempDir("", "manifests")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		o.OutputDir = dir
+	default:
+		return fmt.Errorf("unsupported output format %q, must be one of: %s", o.OutputFormat, strings.Join(render.OutputFormats, ", "))
+	}
 	params, err := config.ReadFrom(o.ConfigFile)
 	if err != nil {
 		log.WithError(err).Fatalf("Error occurred reading configuration")
 	}
+	if len(o.AssetVersion) > 0 {
+		params.AssetVersion = o.AssetVersion
+	}
+	if len(o.AssetsDir) > 0 {
+		params.AssetsDir = o.AssetsDir
+	}
+	if len(o.UserManifestsDir) > 0 {
+		params.UserManifestsDir = o.UserManifestsDir
+	}
+	if errs := config.Validate(params); len(errs) > 0 {
+		return errs.ToAggregate()
+	}
 	externalOauth := params.ExternalOauthPort != 0
 	if o.IncludeSecrets {
-		render.RenderPKISecrets(o.PKIDir, o.OutputDir, o.IncludeEtcd, o.IncludeVPN, externalOauth)
+		if err := render.RenderPKISecrets(o.PKIDir, o.OutputDir, o.IncludeEtcd, o.IncludeVPN, o.IncludeKonnectivity, externalOauth, render.CertRotationEnabled(params), params.ExternalIgnitionPort != 0, params.EtcdEncryption.Provider != "", params.Monitoring.Enabled); err != nil {
+			return err
+		}
+		if err := render.RenderNamedCertsSecret(params, o.PKIDir, o.OutputDir); err != nil {
+			return err
+		}
 		caBytes, err := ioutil.ReadFile(filepath.Join(o.PKIDir, "combined-ca.crt"))
 		if err != nil {
 			log.WithError(err).Fatalf("Error reading combined ca cert")
 		}
 		params.OpenshiftAPIServerCABundle = base64.StdEncoding.EncodeToString(caBytes)
 	}
-	err = render.RenderClusterManifests(params, o.PullSecretFile, o.OutputDir, o.IncludeEtcd, o.IncludeVPN, externalOauth, o.IncludeRegistry)
+	err = render.RenderClusterManifests(params, o.PullSecretFile, o.OutputDir, o.IncludeEtcd, o.IncludeVPN, o.IncludeKonnectivity, externalOauth, o.IncludeRegistry)
 	if err != nil {
 		return err
 	}
+	switch o.OutputFormat {
+	case render.OutputFormatStream:
+		return render.WriteManifestStream(o.OutputDir, os.Stdout)
+	case render.OutputFormatKustomize:
+		return render.WriteKustomization(o.OutputDir)
+	case render.OutputFormatHelm:
+		return render.WriteHelmChart(o.OutputDir, params, o.ChartVersion)
+	}
 	return nil
 }
 
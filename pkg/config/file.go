@@ -1,6 +1,8 @@
 package config
 
 import (
+	"encoding/json"
+	"io"
 	"io/ioutil"
 
 	"github.com/ghodss/yaml"
@@ -20,3 +22,59 @@ func ReadFrom(fileName string) (*api.ClusterParams, error) {
 	}
 	return result, nil
 }
+
+// WriteTo writes the parameters of a cluster as a cluster.yaml file. Parameters that are
+// not set are left out, so that the file only has the values that differ from the defaults.
+func WriteTo(w io.Writer, params *api.ClusterParams) error {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	values := map[string]interface{}{}
+	if err = json.Unmarshal(b, &values); err != nil {
+		return err
+	}
+	pruneEmpty(values)
+	out, err := yaml.Marshal(values)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// pruneEmpty removes the empty values of a decoded JSON object and its nested objects
+func pruneEmpty(values map[string]interface{}) {
+	for key, value := range values {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			pruneEmpty(v)
+			if len(v) == 0 {
+				delete(values, key)
+			}
+		case []interface{}:
+			for _, item := range v {
+				if m, ok := item.(map[string]interface{}); ok {
+					pruneEmpty(m)
+				}
+			}
+			if len(v) == 0 {
+				delete(values, key)
+			}
+		case string:
+			if len(v) == 0 {
+				delete(values, key)
+			}
+		case float64:
+			if v == 0 {
+				delete(values, key)
+			}
+		case bool:
+			if !v {
+				delete(values, key)
+			}
+		case nil:
+			delete(values, key)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestWriteTo(t *testing.T) {
+	params := api.NewClusterParams()
+	params.ReleaseImage = "quay.io/openshift-release-dev/ocp-release:4.4.0-x86_64"
+	params.Replicas = "3"
+	params.Etcd.Mode = "simple"
+	params.NodePools = []api.NodePool{{Name: "worker", Replicas: 2, Labels: map[string]string{}}}
+	out := &bytes.Buffer{}
+	if err := WriteTo(out, params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, unset := range []string{"namespace:", "kubeAPIServerResources:", "apiServerAuditEnabled:", "labels:", "monitoring:"} {
+		if strings.Contains(out.String(), unset) {
+			t.Errorf("expected %s to be left out:\n%s", unset, out.String())
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "cluster.yaml")
+	if err = ioutil.WriteFile(fileName, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadFrom(fileName)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if read.ReleaseImage != params.ReleaseImage || read.Replicas != "3" || read.Etcd.Mode != "simple" {
+		t.Errorf("unexpected parameters: %+v", read)
+	}
+	if len(read.NodePools) != 1 || read.NodePools[0].Replicas != 2 {
+		t.Errorf("unexpected node pools: %+v", read.NodePools)
+	}
+}
@@ -0,0 +1,278 @@
+package config
+
+import (
+	"crypto/x509"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/release"
+)
+
+var assetVersionRegexp = regexp.MustCompile(`^\d+\.\d+(\.\d+)?$`)
+
+// Validate checks the parameters of a cluster that the templates of its manifests depend on:
+// required fields, the syntax and overlap of its CIDRs, ports, DNS names and the quantities
+// of resource requirements. Field paths are the keys of the cluster.yaml file.
+func Validate(params *api.ClusterParams) field.ErrorList {
+	errs := field.ErrorList{}
+
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"namespace", params.Namespace},
+		{"externalAPIDNSName", params.ExternalAPIDNSName},
+		{"serviceCIDR", params.ServiceCIDR},
+		{"podCIDR", params.PodCIDR},
+		{"releaseImage", params.ReleaseImage},
+		{"baseDomain", params.BaseDomain},
+		{"ingressSubdomain", params.IngressSubdomain},
+	}
+	for _, r := range required {
+		if len(r.value) == 0 {
+			errs = append(errs, field.Required(field.NewPath(r.name), ""))
+		}
+	}
+	if len(params.Namespace) > 0 {
+		for _, msg := range validation.IsDNS1123Label(params.Namespace) {
+			errs = append(errs, field.Invalid(field.NewPath("namespace"), params.Namespace, msg))
+		}
+	}
+
+	serviceNet := validateCIDR(field.NewPath("serviceCIDR"), params.ServiceCIDR, &errs)
+	podNet := validateCIDR(field.NewPath("podCIDR"), params.PodCIDR, &errs)
+	if serviceNet != nil && podNet != nil && (serviceNet.Contains(podNet.IP) || podNet.Contains(serviceNet.IP)) {
+		errs = append(errs, field.Invalid(field.NewPath("podCIDR"), params.PodCIDR, "overlaps with serviceCIDR "+params.ServiceCIDR))
+	}
+
+	if params.ExternalAPIPort == 0 {
+		errs = append(errs, field.Required(field.NewPath("externalAPIPort"), ""))
+	}
+	if params.InternalAPIPort == 0 {
+		errs = append(errs, field.Required(field.NewPath("internalAPIPort"), ""))
+	}
+	ports := []struct {
+		name  string
+		value uint
+	}{
+		{"externalAPIPort", params.ExternalAPIPort},
+		{"internalAPIPort", params.InternalAPIPort},
+		{"externalVPNPort", params.ExternalOpenVPNPort},
+		{"externalOauthPort", params.ExternalOauthPort},
+		{"externalKonnectivityPort", params.ExternalKonnectivityPort},
+		{"externalIgnitionPort", params.ExternalIgnitionPort},
+		{"apiNodePort", params.APINodePort},
+	}
+	for _, p := range ports {
+		validatePort(field.NewPath(p.name), int64(p.value), &errs)
+	}
+	nodePorts := []struct {
+		name  string
+		value string
+	}{
+		{"routerNodePortHTTP", params.RouterNodePortHTTP},
+		{"routerNodePortHTTPS", params.RouterNodePortHTTPS},
+		{"openVPNNodePort", params.OpenVPNNodePort},
+		{"konnectivityNodePort", params.KonnectivityNodePort},
+	}
+	for _, p := range nodePorts {
+		if len(p.value) == 0 {
+			continue
+		}
+		port, err := strconv.ParseInt(p.value, 10, 32)
+		if err != nil {
+			errs = append(errs, field.Invalid(field.NewPath(p.name), p.value, "must be a port number"))
+			continue
+		}
+		validatePort(field.NewPath(p.name), port, &errs)
+	}
+
+	names := []struct {
+		name  string
+		value string
+	}{
+		{"externalAPIDNSName", params.ExternalAPIDNSName},
+		{"externalVPNDNSName", params.ExternalOpenVPNDNSName},
+		{"externalOauthDNSName", params.ExternalOauthDNSName},
+		{"externalIgnitionDNSName", params.ExternalIgnitionDNSName},
+		{"externalKonnectivityDNSName", params.ExternalKonnectivityDNSName},
+		{"baseDomain", params.BaseDomain},
+		{"ingressSubdomain", params.IngressSubdomain},
+	}
+	for _, n := range names {
+		// The endpoints of a cluster may be addresses rather than names
+		if len(n.value) == 0 || net.ParseIP(n.value) != nil {
+			continue
+		}
+		for _, msg := range validation.IsDNS1123Subdomain(n.value) {
+			errs = append(errs, field.Invalid(field.NewPath(n.name), n.value, msg))
+		}
+	}
+	if len(params.ExternalAPIIPAddress) > 0 && net.ParseIP(params.ExternalAPIIPAddress) == nil {
+		errs = append(errs, field.Invalid(field.NewPath("externalAPIAddress"), params.ExternalAPIIPAddress, "must be an IP address"))
+	}
+
+	if len(params.AssetVersion) > 0 && !assetVersionRegexp.MatchString(params.AssetVersion) {
+		errs = append(errs, field.Invalid(field.NewPath("assetVersion"), params.AssetVersion, "must be an OpenShift minor version (ie. 4.4)"))
+	}
+	if err := release.ValidateArch(params.Arch); err != nil {
+		errs = append(errs, field.NotSupported(field.NewPath("arch"), params.Arch, release.Archs))
+	}
+	if len(params.UserManifestsDir) > 0 {
+		if info, err := os.Stat(params.UserManifestsDir); err != nil || !info.IsDir() {
+			errs = append(errs, field.Invalid(field.NewPath("userManifestsDir"), params.UserManifestsDir, "must be a directory"))
+		}
+	}
+	if len(params.MachineConfigsDir) > 0 {
+		if info, err := os.Stat(params.MachineConfigsDir); err != nil || !info.IsDir() {
+			errs = append(errs, field.Invalid(field.NewPath("machineConfigsDir"), params.MachineConfigsDir, "must be a directory"))
+		}
+	}
+	if len(params.AssetsDir) > 0 {
+		if info, err := os.Stat(params.AssetsDir); err != nil || !info.IsDir() {
+			errs = append(errs, field.Invalid(field.NewPath("assetsDir"), params.AssetsDir, "must be a directory"))
+		}
+	}
+
+	if len(params.Replicas) > 0 {
+		if replicas, err := strconv.Atoi(params.Replicas); err != nil || replicas < 1 {
+			errs = append(errs, field.Invalid(field.NewPath("replicas"), params.Replicas, "must be a positive number"))
+		}
+	}
+
+	if len(params.KubeletServingCASyncInterval) > 0 {
+		if interval, err := time.ParseDuration(params.KubeletServingCASyncInterval); err != nil || interval <= 0 {
+			errs = append(errs, field.Invalid(field.NewPath("kubeletServingCASyncInterval"), params.KubeletServingCASyncInterval, "must be a positive duration (ie. 5m)"))
+		}
+	}
+
+	prefixes := map[string]bool{}
+	for i, namedCert := range params.NamedCerts {
+		path := field.NewPath("namedCerts").Index(i)
+		if len(namedCert.NamedCertPrefix) == 0 {
+			errs = append(errs, field.Required(path.Child("namedCertPrefix"), ""))
+		} else {
+			for _, msg := range validation.IsDNS1123Label(namedCert.NamedCertPrefix) {
+				errs = append(errs, field.Invalid(path.Child("namedCertPrefix"), namedCert.NamedCertPrefix, msg))
+			}
+			if prefixes[namedCert.NamedCertPrefix] {
+				errs = append(errs, field.Duplicate(path.Child("namedCertPrefix"), namedCert.NamedCertPrefix))
+			}
+			prefixes[namedCert.NamedCertPrefix] = true
+		}
+		if len(namedCert.NamedCertDomain) == 0 {
+			errs = append(errs, field.Required(path.Child("namedCertDomain"), ""))
+		} else {
+			for _, msg := range validation.IsDNS1123Subdomain(strings.TrimPrefix(namedCert.NamedCertDomain, "*.")) {
+				errs = append(errs, field.Invalid(path.Child("namedCertDomain"), namedCert.NamedCertDomain, msg))
+			}
+		}
+		if (len(namedCert.NamedCertFile) == 0) != (len(namedCert.NamedKeyFile) == 0) {
+			errs = append(errs, field.Invalid(path, namedCert.NamedCertPrefix, "namedCertFile and namedKeyFile must be set together"))
+		}
+	}
+
+	if upstream := params.ClusterVersion.Upstream; len(upstream) > 0 {
+		if u, err := url.Parse(upstream); err != nil || !u.IsAbs() || len(u.Host) == 0 {
+			errs = append(errs, field.Invalid(field.NewPath("clusterVersion", "upstream"), upstream, "must be an absolute URL"))
+		}
+	}
+	for i, override := range params.ClusterVersion.Overrides {
+		path := field.NewPath("clusterVersion", "overrides").Index(i)
+		if len(override.Kind) == 0 {
+			errs = append(errs, field.Required(path.Child("kind"), ""))
+		}
+		if len(override.Name) == 0 {
+			errs = append(errs, field.Required(path.Child("name"), ""))
+		}
+	}
+
+	if len(params.AdditionalTrustBundle) > 0 && !x509.NewCertPool().AppendCertsFromPEM([]byte(params.AdditionalTrustBundle)) {
+		errs = append(errs, field.Invalid(field.NewPath("additionalTrustBundle"), "", "must contain PEM encoded certificates"))
+	}
+
+	if err := api.ValidateNetworkType(params.NetworkType); err != nil {
+		errs = append(errs, field.NotSupported(field.NewPath("networkType"), params.NetworkType, api.NetworkTypes))
+	}
+
+	if err := api.ValidateSizingProfile(params.SizingProfile); err != nil {
+		errs = append(errs, field.NotSupported(field.NewPath("sizingProfile"), params.SizingProfile, api.SizingProfiles))
+	}
+
+	resources := []struct {
+		name  string
+		value []api.ResourceRequirements
+	}{
+		{"kubeAPIServerResources", params.KubeAPIServerResources},
+		{"openshiftControllerManagerResources", params.OpenshiftControllerManagerResources},
+		{"clusterVersionOperatorResources", params.ClusterVersionOperatorResources},
+		{"kubeControllerManagerResources", params.KubeControllerManagerResources},
+		{"openshiftAPIServerResources", params.OpenshiftAPIServerResources},
+		{"kubeSchedulerResources", params.KubeSchedulerResources},
+		{"controlPlaneOperatorResources", params.ControlPlaneOperatorResources},
+		{"oAuthServerResources", params.OAuthServerResources},
+		{"clusterPolicyControllerResources", params.ClusterPolicyControllerResources},
+		{"autoApproverResources", params.AutoApproverResources},
+		{"openVPNClientResources", params.OpenVPNClientResources},
+		{"openVPNServerResources", params.OpenVPNServerResources},
+		{"etcdResources", params.EtcdResources},
+		{"ignitionServerResources", params.IgnitionServerResources},
+	}
+	for _, r := range resources {
+		for i, requirements := range r.value {
+			path := field.NewPath(r.name).Index(i)
+			for j, limit := range requirements.ResourceLimit {
+				validateQuantity(path.Child("resourceLimit").Index(j).Child("cpu"), limit.CPU, &errs)
+				validateQuantity(path.Child("resourceLimit").Index(j).Child("memory"), limit.Memory, &errs)
+			}
+			for j, request := range requirements.ResourceRequest {
+				validateQuantity(path.Child("resourceRequest").Index(j).Child("cpu"), request.CPU, &errs)
+				validateQuantity(path.Child("resourceRequest").Index(j).Child("memory"), request.Memory, &errs)
+			}
+		}
+	}
+	return errs
+}
+
+// validateCIDR returns the network of a CIDR, or nil if it is empty or invalid
+func validateCIDR(path *field.Path, cidr string, errs *field.ErrorList) *net.IPNet {
+	if len(cidr) == 0 {
+		return nil
+	}
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		*errs = append(*errs, field.Invalid(path, cidr, "must be a CIDR (ie. 10.0.0.0/16)"))
+		return nil
+	}
+	return ipNet
+}
+
+// validatePort verifies that a port that is set is a valid port number
+func validatePort(path *field.Path, port int64, errs *field.ErrorList) {
+	if port == 0 {
+		return
+	}
+	for _, msg := range validation.IsValidPortNum(int(port)) {
+		*errs = append(*errs, field.Invalid(path, port, msg))
+	}
+}
+
+// validateQuantity verifies that a quantity that is set is a valid resource quantity
+func validateQuantity(path *field.Path, quantity string, errs *field.ErrorList) {
+	if len(quantity) == 0 {
+		return
+	}
+	if _, err := resource.ParseQuantity(quantity); err != nil {
+		*errs = append(*errs, field.Invalid(path, quantity, "must be a quantity (ie. 100m or 256Mi)"))
+	}
+}
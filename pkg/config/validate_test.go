@@ -0,0 +1,94 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestValidateExample(t *testing.T) {
+	params, err := ReadFrom("../../cluster.yaml.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := Validate(params); len(errs) > 0 {
+		t.Errorf("unexpected errors in the example config: %v", errs)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *api.ClusterParams {
+		params := api.NewClusterParams()
+		params.Namespace = "example"
+		params.ExternalAPIDNSName = "api.example.mydomain.com"
+		params.ExternalAPIPort = 6443
+		params.InternalAPIPort = 6443
+		params.ServiceCIDR = "172.31.0.0/16"
+		params.PodCIDR = "10.132.0.0/14"
+		params.ReleaseImage = "quay.io/openshift-release-dev/ocp-release:4.4.0-x86_64"
+		params.BaseDomain = "example.mydomain.com"
+		params.IngressSubdomain = "apps.example.mydomain.com"
+		return params
+	}
+	tests := []struct {
+		name   string
+		modify func(*api.ClusterParams)
+		field  string
+	}{
+		{name: "valid", modify: func(p *api.ClusterParams) {}},
+		{name: "API address", modify: func(p *api.ClusterParams) { p.ExternalAPIDNSName = "10.0.0.10" }},
+		{name: "missing namespace", modify: func(p *api.ClusterParams) { p.Namespace = "" }, field: "namespace"},
+		{name: "invalid CIDR", modify: func(p *api.ClusterParams) { p.ServiceCIDR = "172.31.0/16" }, field: "serviceCIDR"},
+		{name: "overlapping CIDRs", modify: func(p *api.ClusterParams) { p.PodCIDR = "172.31.128.0/17" }, field: "podCIDR"},
+		{name: "port out of range", modify: func(p *api.ClusterParams) { p.ExternalOauthPort = 70000 }, field: "externalOauthPort"},
+		{name: "invalid node port", modify: func(p *api.ClusterParams) { p.RouterNodePortHTTP = "http" }, field: "routerNodePortHTTP"},
+		{name: "invalid DNS name", modify: func(p *api.ClusterParams) { p.ExternalOpenVPNDNSName = "vpn_example.mydomain.com" }, field: "externalVPNDNSName"},
+		{name: "invalid replicas", modify: func(p *api.ClusterParams) { p.Replicas = "two" }, field: "replicas"},
+		{name: "invalid kubelet serving CA sync interval", modify: func(p *api.ClusterParams) { p.KubeletServingCASyncInterval = "-5m" }, field: "kubeletServingCASyncInterval"},
+		{name: "wildcard named certificate", modify: func(p *api.ClusterParams) {
+			p.NamedCerts = []api.NamedCert{{NamedCertPrefix: "api-custom", NamedCertDomain: "*.example.com"}}
+		}},
+		{name: "invalid named certificate domain", modify: func(p *api.ClusterParams) {
+			p.NamedCerts = []api.NamedCert{{NamedCertPrefix: "api-custom", NamedCertDomain: "api_example.com"}}
+		}, field: "namedCerts[0].namedCertDomain"},
+		{name: "duplicate named certificate prefix", modify: func(p *api.ClusterParams) {
+			p.NamedCerts = []api.NamedCert{{NamedCertPrefix: "api-custom", NamedCertDomain: "api.example.com"}, {NamedCertPrefix: "api-custom", NamedCertDomain: "api.example.org"}}
+		}, field: "namedCerts[1].namedCertPrefix"},
+		{name: "named certificate without key", modify: func(p *api.ClusterParams) {
+			p.NamedCerts = []api.NamedCert{{NamedCertPrefix: "api-custom", NamedCertDomain: "api.example.com", NamedCertFile: "api.crt"}}
+		}, field: "namedCerts[0]"},
+		{name: "relative update service URL", modify: func(p *api.ClusterParams) { p.ClusterVersion.Upstream = "/graph" }, field: "clusterVersion.upstream"},
+		{name: "cluster version override without name", modify: func(p *api.ClusterParams) {
+			p.ClusterVersion.Overrides = []api.ClusterVersionOverride{{Kind: "Deployment", Group: "apps", Namespace: "openshift-console"}}
+		}, field: "clusterVersion.overrides[0].name"},
+		{name: "OVN Kubernetes", modify: func(p *api.ClusterParams) { p.NetworkType = api.NetworkTypeOVNKubernetes }},
+		{name: "third-party network", modify: func(p *api.ClusterParams) { p.NetworkType = api.NetworkTypeNone }},
+		{name: "missing user manifests directory", modify: func(p *api.ClusterParams) { p.UserManifestsDir = "/nonexistent" }, field: "userManifestsDir"},
+		{name: "invalid additional trust bundle", modify: func(p *api.ClusterParams) { p.AdditionalTrustBundle = "-----BEGIN CERTIFICATE-----" }, field: "additionalTrustBundle"},
+		{name: "unsupported network type", modify: func(p *api.ClusterParams) { p.NetworkType = "Calico" }, field: "networkType"},
+		{name: "unsupported sizing profile", modify: func(p *api.ClusterParams) { p.SizingProfile = "huge" }, field: "sizingProfile"},
+		{
+			name: "invalid resource quantity",
+			modify: func(p *api.ClusterParams) {
+				p.KubeAPIServerResources = []api.ResourceRequirements{{ResourceRequest: []api.ResourceRequest{{CPU: "1 core"}}}}
+			},
+			field: "kubeAPIServerResources[0].resourceRequest[0].cpu",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := valid()
+			test.modify(params)
+			errs := Validate(params)
+			if len(test.field) == 0 {
+				if len(errs) > 0 {
+					t.Errorf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != test.field {
+				t.Errorf("expected a single error for %s, got %v", test.field, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package autoapprover
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	certsv1beta1 "k8s.io/api/certificates/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// BootstrapUser is the user of the bootstrap kubeconfig of the workers, which requests
+	// the first client certificate of a node
+	BootstrapUser = "system:bootstrapper"
+
+	nodeUserPrefix = "system:node:"
+	nodesGroup     = "system:nodes"
+)
+
+var (
+	clientUsages  = sets.NewString(string(certsv1beta1.UsageDigitalSignature), string(certsv1beta1.UsageKeyEncipherment), string(certsv1beta1.UsageClientAuth))
+	servingUsages = sets.NewString(string(certsv1beta1.UsageDigitalSignature), string(certsv1beta1.UsageKeyEncipherment), string(certsv1beta1.UsageServerAuth))
+)
+
+// decision is the outcome of the validation of a CSR
+type decision int
+
+const (
+	// ignore leaves a CSR that is not a node CSR to other approvers
+	ignore decision = iota
+	// approve approves a valid node CSR
+	approve
+	// deny denies an invalid node CSR
+	deny
+	// wait leaves a node CSR pending until the machine of the node is known
+	wait
+)
+
+// Nodes are the nodes that the approver expects CSRs from
+type Nodes interface {
+	// Synced returns whether the nodes and machines are known
+	Synced() bool
+	// Exists returns whether a node of the cluster has the name
+	Exists(name string) bool
+	// Expected returns whether a node with the name is expected, and the addresses that its
+	// serving certificate may have. Any node is expected if the nodes are not known.
+	Expected(name string) (bool, sets.String)
+}
+
+// validation is the outcome of the validation of a CSR with the reason for it
+type validation struct {
+	decision decision
+	// reason is a short reason of a denial, which labels the denied CSRs metric
+	reason string
+	// message explains the decision
+	message string
+}
+
+func denied(reason, format string, args ...interface{}) validation {
+	return validation{decision: deny, reason: reason, message: fmt.Sprintf(format, args...)}
+}
+
+// validateCSR validates a node client or serving CSR. A client CSR of a new node must be
+// requested by the bootstrap user for a node that is expected and does not exist yet, and
+// a renewal by the node itself. A serving CSR must be requested by the node and have only
+// addresses of its machine.
+func validateCSR(csr *certsv1beta1.CertificateSigningRequest, nodes Nodes) validation {
+	req, err := parseCSR(csr.Spec.Request)
+	if err != nil {
+		if strings.HasPrefix(csr.Spec.Username, nodeUserPrefix) || csr.Spec.Username == BootstrapUser {
+			return denied("InvalidRequest", "cannot parse the certificate request: %v", err)
+		}
+		return validation{decision: ignore, message: "not a node CSR"}
+	}
+	if !strings.HasPrefix(req.Subject.CommonName, nodeUserPrefix) {
+		if strings.HasPrefix(csr.Spec.Username, nodeUserPrefix) || csr.Spec.Username == BootstrapUser {
+			return denied("InvalidSubject", "the common name %q of a node certificate must start with %s", req.Subject.CommonName, nodeUserPrefix)
+		}
+		return validation{decision: ignore, message: "not a node CSR"}
+	}
+	nodeName := strings.TrimPrefix(req.Subject.CommonName, nodeUserPrefix)
+	if len(nodeName) == 0 {
+		return denied("InvalidSubject", "the common name of a node certificate has no node name")
+	}
+	if len(req.Subject.Organization) != 1 || req.Subject.Organization[0] != nodesGroup {
+		return denied("InvalidSubject", "the organization of a node certificate must be %s, got %v", nodesGroup, req.Subject.Organization)
+	}
+	if len(req.EmailAddresses) > 0 || len(req.URIs) > 0 {
+		return denied("InvalidSubjectAltNames", "a node certificate cannot have email or URI names")
+	}
+	usages := sets.NewString()
+	for _, usage := range csr.Spec.Usages {
+		usages.Insert(string(usage))
+	}
+	switch {
+	case usages.Has(string(certsv1beta1.UsageClientAuth)):
+		return validateClientCSR(csr, req, nodeName, usages, nodes)
+	case usages.Has(string(certsv1beta1.UsageServerAuth)):
+		return validateServingCSR(csr, req, nodeName, usages, nodes)
+	}
+	return denied("InvalidUsages", "a node certificate must be for client or server auth, got %v", usages.List())
+}
+
+func validateClientCSR(csr *certsv1beta1.CertificateSigningRequest, req *x509.CertificateRequest, nodeName string, usages sets.String, nodes Nodes) validation {
+	if !clientUsages.IsSuperset(usages) || !usages.Has(string(certsv1beta1.UsageDigitalSignature)) {
+		return denied("InvalidUsages", "unexpected usages %v of a node client certificate", usages.List())
+	}
+	if len(req.DNSNames) > 0 || len(req.IPAddresses) > 0 {
+		return denied("InvalidSubjectAltNames", "a node client certificate cannot have DNS names or IP addresses")
+	}
+	// A node renews its client certificate with the current one
+	if csr.Spec.Username == req.Subject.CommonName {
+		return validation{decision: approve, message: fmt.Sprintf("renewal of the client certificate of node %s", nodeName)}
+	}
+	if csr.Spec.Username != BootstrapUser {
+		return denied("UnexpectedRequester", "the client certificate of node %s was requested by %s", nodeName, csr.Spec.Username)
+	}
+	if nodes.Exists(nodeName) {
+		return denied("NodeExists", "node %s already exists and must renew its own client certificate", nodeName)
+	}
+	if expected, _ := nodes.Expected(nodeName); !expected {
+		return validation{decision: wait, reason: "UnknownNode", message: fmt.Sprintf("no worker machine of the cluster has node name %s", nodeName)}
+	}
+	return validation{decision: approve, message: fmt.Sprintf("client certificate of new node %s", nodeName)}
+}
+
+func validateServingCSR(csr *certsv1beta1.CertificateSigningRequest, req *x509.CertificateRequest, nodeName string, usages sets.String, nodes Nodes) validation {
+	if !servingUsages.IsSuperset(usages) || !usages.Has(string(certsv1beta1.UsageDigitalSignature)) {
+		return denied("InvalidUsages", "unexpected usages %v of a node serving certificate", usages.List())
+	}
+	if csr.Spec.Username != req.Subject.CommonName || !sets.NewString(csr.Spec.Groups...).Has(nodesGroup) {
+		return denied("UnexpectedRequester", "the serving certificate of node %s was requested by %s", nodeName, csr.Spec.Username)
+	}
+	if len(req.DNSNames) == 0 && len(req.IPAddresses) == 0 {
+		return denied("InvalidSubjectAltNames", "the serving certificate of node %s has no DNS names or IP addresses", nodeName)
+	}
+	expected, addresses := nodes.Expected(nodeName)
+	if !expected {
+		return validation{decision: wait, reason: "UnknownNode", message: fmt.Sprintf("no worker machine of the cluster has node name %s", nodeName)}
+	}
+	if addresses == nil {
+		return validation{decision: approve, message: fmt.Sprintf("serving certificate of node %s", nodeName)}
+	}
+	names := sets.NewString(req.DNSNames...)
+	for _, ip := range req.IPAddresses {
+		names.Insert(ip.String())
+	}
+	if unexpected := names.Difference(addresses); unexpected.Len() > 0 {
+		return denied("UnexpectedAddresses", "the serving certificate of node %s has addresses %s that its machine does not have", nodeName, strings.Join(unexpected.List(), ", "))
+	}
+	return validation{decision: approve, message: fmt.Sprintf("serving certificate of node %s", nodeName)}
+}
+
+// parseCSR parses the PEM encoded certificate request of a CSR
+func parseCSR(pemBytes []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("no PEM encoded certificate request")
+	}
+	req, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if err = req.CheckSignature(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
@@ -0,0 +1,228 @@
+package autoapprover
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"testing"
+
+	certsv1beta1 "k8s.io/api/certificates/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+)
+
+type testNodes struct {
+	existing sets.String
+	expected map[string]sets.String
+}
+
+func (n *testNodes) Synced() bool { return true }
+
+func (n *testNodes) Exists(name string) bool { return n.existing.Has(name) }
+
+func (n *testNodes) Expected(name string) (bool, sets.String) {
+	if n.expected == nil {
+		return true, nil
+	}
+	addresses, ok := n.expected[name]
+	return ok, addresses
+}
+
+func testCSR(t *testing.T, username string, groups []string, subject pkix.Name, dnsNames []string, ips []net.IP, usages ...certsv1beta1.KeyUsage) *certsv1beta1.CertificateSigningRequest {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:     subject,
+		DNSNames:    dnsNames,
+		IPAddresses: ips,
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &certsv1beta1.CertificateSigningRequest{
+		Spec: certsv1beta1.CertificateSigningRequestSpec{
+			Request:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}),
+			Username: username,
+			Groups:   groups,
+			Usages:   usages,
+		},
+	}
+}
+
+func TestValidateCSR(t *testing.T) {
+	const node = "ip-10-0-1-2.ec2.internal"
+	nodeSubject := pkix.Name{CommonName: "system:node:" + node, Organization: []string{"system:nodes"}}
+	nodeGroups := []string{"system:nodes", "system:authenticated"}
+	bootstrapGroups := []string{"system:bootstrappers", "system:authenticated"}
+	clientUsages := []certsv1beta1.KeyUsage{certsv1beta1.UsageDigitalSignature, certsv1beta1.UsageKeyEncipherment, certsv1beta1.UsageClientAuth}
+	servingUsages := []certsv1beta1.KeyUsage{certsv1beta1.UsageDigitalSignature, certsv1beta1.UsageKeyEncipherment, certsv1beta1.UsageServerAuth}
+	machines := &testNodes{
+		existing: sets.NewString("ip-10-0-1-3.ec2.internal"),
+		expected: map[string]sets.String{
+			node:                       sets.NewString(node, "10.0.1.2"),
+			"ip-10-0-1-3.ec2.internal": sets.NewString("ip-10-0-1-3.ec2.internal", "10.0.1.3"),
+		},
+	}
+	anyNode := &testNodes{existing: sets.NewString()}
+	tests := []struct {
+		name     string
+		csr      *certsv1beta1.CertificateSigningRequest
+		nodes    Nodes
+		expected decision
+		reason   string
+	}{
+		{
+			name:     "bootstrap client",
+			csr:      testCSR(t, BootstrapUser, bootstrapGroups, nodeSubject, nil, nil, clientUsages...),
+			nodes:    machines,
+			expected: approve,
+		},
+		{
+			name:     "bootstrap client without machines",
+			csr:      testCSR(t, BootstrapUser, bootstrapGroups, nodeSubject, nil, nil, clientUsages...),
+			nodes:    anyNode,
+			expected: approve,
+		},
+		{
+			name:     "client renewal",
+			csr:      testCSR(t, "system:node:"+node, nodeGroups, nodeSubject, nil, nil, clientUsages...),
+			nodes:    machines,
+			expected: approve,
+		},
+		{
+			name:     "bootstrap client of an existing node",
+			csr:      testCSR(t, BootstrapUser, bootstrapGroups, pkix.Name{CommonName: "system:node:ip-10-0-1-3.ec2.internal", Organization: []string{"system:nodes"}}, nil, nil, clientUsages...),
+			nodes:    machines,
+			expected: deny,
+			reason:   "NodeExists",
+		},
+		{
+			name:     "bootstrap client of an unknown node",
+			csr:      testCSR(t, BootstrapUser, bootstrapGroups, pkix.Name{CommonName: "system:node:ip-10-0-9-9.ec2.internal", Organization: []string{"system:nodes"}}, nil, nil, clientUsages...),
+			nodes:    machines,
+			expected: wait,
+			reason:   "UnknownNode",
+		},
+		{
+			name:     "client of another node",
+			csr:      testCSR(t, "system:node:ip-10-0-1-3.ec2.internal", nodeGroups, nodeSubject, nil, nil, clientUsages...),
+			nodes:    machines,
+			expected: deny,
+			reason:   "UnexpectedRequester",
+		},
+		{
+			name:     "client by another user",
+			csr:      testCSR(t, "developer", []string{"system:authenticated"}, nodeSubject, nil, nil, clientUsages...),
+			nodes:    machines,
+			expected: deny,
+			reason:   "UnexpectedRequester",
+		},
+		{
+			name:     "client with another organization",
+			csr:      testCSR(t, BootstrapUser, bootstrapGroups, pkix.Name{CommonName: "system:node:" + node, Organization: []string{"system:masters"}}, nil, nil, clientUsages...),
+			nodes:    machines,
+			expected: deny,
+			reason:   "InvalidSubject",
+		},
+		{
+			name:     "client with server auth",
+			csr:      testCSR(t, BootstrapUser, bootstrapGroups, nodeSubject, nil, nil, append(clientUsages, certsv1beta1.UsageServerAuth)...),
+			nodes:    machines,
+			expected: deny,
+			reason:   "InvalidUsages",
+		},
+		{
+			name:     "bootstrap user with another subject",
+			csr:      testCSR(t, BootstrapUser, bootstrapGroups, pkix.Name{CommonName: "admin", Organization: []string{"system:masters"}}, nil, nil, clientUsages...),
+			nodes:    machines,
+			expected: deny,
+			reason:   "InvalidSubject",
+		},
+		{
+			name:     "serving",
+			csr:      testCSR(t, "system:node:"+node, nodeGroups, nodeSubject, []string{node}, []net.IP{net.ParseIP("10.0.1.2")}, servingUsages...),
+			nodes:    machines,
+			expected: approve,
+		},
+		{
+			name:     "serving without machines",
+			csr:      testCSR(t, "system:node:"+node, nodeGroups, nodeSubject, []string{"worker.example.com"}, nil, servingUsages...),
+			nodes:    anyNode,
+			expected: approve,
+		},
+		{
+			name:     "serving with another address",
+			csr:      testCSR(t, "system:node:"+node, nodeGroups, nodeSubject, []string{node, "api.example.com"}, nil, servingUsages...),
+			nodes:    machines,
+			expected: deny,
+			reason:   "UnexpectedAddresses",
+		},
+		{
+			name:     "serving by the bootstrap user",
+			csr:      testCSR(t, BootstrapUser, bootstrapGroups, nodeSubject, []string{node}, nil, servingUsages...),
+			nodes:    machines,
+			expected: deny,
+			reason:   "UnexpectedRequester",
+		},
+		{
+			name:     "serving without addresses",
+			csr:      testCSR(t, "system:node:"+node, nodeGroups, nodeSubject, nil, nil, servingUsages...),
+			nodes:    machines,
+			expected: deny,
+			reason:   "InvalidSubjectAltNames",
+		},
+		{
+			name:     "other CSR",
+			csr:      testCSR(t, "developer", []string{"system:authenticated"}, pkix.Name{CommonName: "developer"}, nil, nil, clientUsages...),
+			nodes:    machines,
+			expected: ignore,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := validateCSR(test.csr, test.nodes)
+			if result.decision != test.expected || result.reason != test.reason {
+				t.Errorf("expected decision %d with reason %q, got %d with reason %q: %s", test.expected, test.reason, result.decision, result.reason, result.message)
+			}
+		})
+	}
+}
+
+func TestClusterNodesExpected(t *testing.T) {
+	machineSet := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	machineSet.SetName("mgmt-example-worker")
+	machine := func(name, machineSet, dnsName, ip string) *unstructured.Unstructured {
+		m := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		m.SetName(name)
+		m.SetLabels(map[string]string{machineSetLabel: machineSet})
+		unstructured.SetNestedSlice(m.Object, []interface{}{
+			map[string]interface{}{"type": "InternalDNS", "address": dnsName},
+			map[string]interface{}{"type": "InternalIP", "address": ip},
+		}, "status", "addresses")
+		return m
+	}
+	machineSets := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	machines := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	machines.Add(machine("mgmt-example-worker-1", "mgmt-example-worker", "ip-10-0-1-2.ec2.internal", "10.0.1.2"))
+	machines.Add(machine("mgmt-worker-1", "mgmt-worker-us-east-1a", "ip-10-0-2-2.ec2.internal", "10.0.2.2"))
+	nodes := &clusterNodes{machineSets: machineSets, machines: machines}
+
+	if expected, addresses := nodes.Expected("ip-10-0-9-9.ec2.internal"); !expected || addresses != nil {
+		t.Errorf("expected any node without machinesets of the cluster")
+	}
+	machineSets.Add(machineSet)
+	expected, addresses := nodes.Expected("ip-10-0-1-2.ec2.internal")
+	if !expected || !addresses.Equal(sets.NewString("ip-10-0-1-2.ec2.internal", "10.0.1.2")) {
+		t.Errorf("expected the node of a worker machine with its addresses, got %t, %v", expected, addresses)
+	}
+	if expected, _ := nodes.Expected("ip-10-0-2-2.ec2.internal"); expected {
+		t.Errorf("did not expect the node of a machine of another machineset")
+	}
+}
@@ -0,0 +1,99 @@
+package autoapprover
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// MachineSetClusterLabel is the label with the control plane namespace that installers
+	// set on the worker machinesets of a cluster on the management cluster
+	MachineSetClusterLabel = "hypershift.openshift.io/cluster"
+
+	// machineSetLabel is the label with the machineset of a machine
+	machineSetLabel = "machine.openshift.io/cluster-api-machineset"
+)
+
+// nodeNameAddressTypes are the types of the machine addresses that a node may be named after
+var nodeNameAddressTypes = sets.NewString("InternalDNS", "Hostname")
+
+// clusterNodes are the nodes of a cluster and the worker machines that are expected to
+// become its nodes
+type clusterNodes struct {
+	// nodes lists the nodes of the cluster
+	nodes       corelisters.NodeLister
+	nodesSynced cache.InformerSynced
+
+	// machineSets and machines are stores of the worker machinesets of the cluster and of
+	// the machines of the management cluster. When they are nil or there are no machinesets
+	// of the cluster, its workers are not machines of the management cluster and any node
+	// is expected.
+	machineSets cache.Store
+	machines    cache.Store
+
+	// machinesSynced returns whether the stores of the machines are synced
+	machinesSynced cache.InformerSynced
+}
+
+var _ Nodes = &clusterNodes{}
+
+func (n *clusterNodes) Synced() bool {
+	return n.nodesSynced() && (n.machinesSynced == nil || n.machinesSynced())
+}
+
+func (n *clusterNodes) Exists(name string) bool {
+	_, err := n.nodes.Get(name)
+	return err == nil || !errors.IsNotFound(err)
+}
+
+func (n *clusterNodes) Expected(name string) (bool, sets.String) {
+	if n.machineSets == nil || n.machines == nil {
+		return true, nil
+	}
+	machineSetNames := sets.NewString()
+	for _, obj := range n.machineSets.List() {
+		if machineSet, ok := obj.(*unstructured.Unstructured); ok {
+			machineSetNames.Insert(machineSet.GetName())
+		}
+	}
+	if machineSetNames.Len() == 0 {
+		return true, nil
+	}
+	for _, obj := range n.machines.List() {
+		machine, ok := obj.(*unstructured.Unstructured)
+		if !ok || machine.GetDeletionTimestamp() != nil || !machineSetNames.Has(machine.GetLabels()[machineSetLabel]) {
+			continue
+		}
+		nodeNames, addresses := machineAddresses(machine)
+		if nodeNames.Has(name) {
+			return true, addresses
+		}
+	}
+	return false, nil
+}
+
+// machineAddresses returns the names that the node of a machine may have and all of the
+// addresses of the machine
+func machineAddresses(machine *unstructured.Unstructured) (sets.String, sets.String) {
+	nodeNames, addresses := sets.NewString(), sets.NewString()
+	list, _, _ := unstructured.NestedSlice(machine.Object, "status", "addresses")
+	for _, item := range list {
+		addr, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		addrType, _, _ := unstructured.NestedString(addr, "type")
+		address, _, _ := unstructured.NestedString(addr, "address")
+		if len(address) == 0 {
+			continue
+		}
+		addresses.Insert(address)
+		if nodeNameAddressTypes.Has(addrType) {
+			nodeNames.Insert(address)
+		}
+	}
+	return nodeNames, addresses
+}
@@ -0,0 +1,22 @@
+package autoapprover
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	csrsApproved = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hypershift_control_plane_operator_csrs_approved_total",
+		Help: "Number of node CSRs of the target cluster that the auto-approver approved",
+	})
+
+	csrsDenied = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hypershift_control_plane_operator_csrs_denied_total",
+		Help: "Number of node CSRs of the target cluster that the auto-approver denied, by reason",
+	}, []string{"reason"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(csrsApproved, csrsDenied)
+}
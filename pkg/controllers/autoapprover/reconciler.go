@@ -1,53 +1,129 @@
 package autoapprover
 
 import (
+	"time"
+
 	"github.com/go-logr/logr"
 
 	certsv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeclient "k8s.io/client-go/kubernetes"
 	certslister "k8s.io/client-go/listers/certificates/v1beta1"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/openshift/hypershift-toolkit/pkg/controllers"
+)
+
+const (
+	// unknownNodeRetryInterval is how often a CSR of a node whose machine is not known
+	// is validated again
+	unknownNodeRetryInterval = 30 * time.Second
+
+	// unknownNodeTimeout is how long a CSR of a node whose machine is not known stays
+	// pending before it is denied, since the addresses of a new machine may be set after its
+	// node requests a certificate
+	unknownNodeTimeout = 15 * time.Minute
 )
 
+// AutoApprover approves the client and serving CSRs of the nodes of the target cluster that
+// are valid for its worker machines, and denies the invalid ones. Other CSRs are left to
+// other approvers.
 type AutoApprover struct {
 	Lister     certslister.CertificateSigningRequestLister
 	KubeClient kubeclient.Interface
+	Nodes      Nodes
 	Log        logr.Logger
+
+	// Recorder records approvals and denials of CSRs on the control plane namespace
+	Recorder  record.EventRecorder
+	Namespace string
 }
 
 func (a *AutoApprover) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	logger := a.Log.WithValues("csr", req.NamespacedName.String())
-	logger.Info("Start reconcile")
 	csr, err := a.Lister.Get(req.Name)
 	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
 		return ctrl.Result{}, err
 	}
-	if isApproved(csr) {
-		logger.Info("CSR is already approved")
+	if isApproved(csr) || isDenied(csr) {
 		return ctrl.Result{}, nil
 	}
 
-	logger.Info("Approving CSR")
-	err = a.approveCSR(csr)
+	if !a.Nodes.Synced() {
+		return ctrl.Result{RequeueAfter: unknownNodeRetryInterval}, nil
+	}
+	result := validateCSR(csr, a.Nodes)
+	switch result.decision {
+	case ignore:
+		logger.V(1).Info("Ignoring CSR", "reason", result.message)
+		return ctrl.Result{}, nil
+	case wait:
+		if time.Since(csr.CreationTimestamp.Time) < unknownNodeTimeout {
+			logger.Info("Leaving CSR pending", "reason", result.message)
+			return ctrl.Result{RequeueAfter: unknownNodeRetryInterval}, nil
+		}
+		result = denied(result.reason, "%s after %s", result.message, unknownNodeTimeout)
+	case approve:
+		logger.Info("Approving CSR", "reason", result.message)
+		if err = a.approveCSR(csr, result.message); err != nil {
+			a.Recorder.Eventf(controllers.NamespaceReference(a.Namespace), corev1.EventTypeWarning, "CSRApprovalFailed", "Cannot approve CSR %s: %v", csr.Name, err)
+			return ctrl.Result{}, err
+		}
+		a.Recorder.Eventf(controllers.NamespaceReference(a.Namespace), corev1.EventTypeNormal, "CSRApproved", "Approved CSR %s of %s: %s", csr.Name, csr.Spec.Username, result.message)
+		csrsApproved.Inc()
+		return ctrl.Result{}, nil
+	}
+	logger.Info("Denying CSR", "reason", result.message, "requester", csr.Spec.Username)
+	if err = a.denyCSR(csr, result.reason, result.message); err != nil {
+		a.Recorder.Eventf(controllers.NamespaceReference(a.Namespace), corev1.EventTypeWarning, "CSRApprovalFailed", "Cannot deny CSR %s: %v", csr.Name, err)
+		return ctrl.Result{}, err
+	}
+	a.Recorder.Eventf(controllers.NamespaceReference(a.Namespace), corev1.EventTypeWarning, "CSRDenied", "Denied CSR %s of %s (%s): %s", csr.Name, csr.Spec.Username, result.reason, result.message)
+	csrsDenied.WithLabelValues(result.reason).Inc()
+	return ctrl.Result{}, nil
+}
 
-	return ctrl.Result{}, err
+func (a *AutoApprover) approveCSR(csr *certsv1beta1.CertificateSigningRequest, message string) error {
+	return a.updateApproval(csr, certsv1beta1.CertificateSigningRequestCondition{
+		Type:    certsv1beta1.CertificateApproved,
+		Reason:  "NodeCSRApprove",
+		Message: "This CSR was approved by the control plane operator: " + message,
+	})
 }
 
-func (a *AutoApprover) approveCSR(csr *certsv1beta1.CertificateSigningRequest) error {
-	csr.Status.Conditions = append(csr.Status.Conditions, certsv1beta1.CertificateSigningRequestCondition{
-		Type:           certsv1beta1.CertificateApproved,
-		Reason:         "KubectlApprove",
-		Message:        "This CSR was automatically approved.",
-		LastUpdateTime: metav1.Now(),
+func (a *AutoApprover) denyCSR(csr *certsv1beta1.CertificateSigningRequest, reason, message string) error {
+	return a.updateApproval(csr, certsv1beta1.CertificateSigningRequestCondition{
+		Type:    certsv1beta1.CertificateDenied,
+		Reason:  reason,
+		Message: "This CSR was denied by the control plane operator: " + message,
 	})
+}
+
+func (a *AutoApprover) updateApproval(csr *certsv1beta1.CertificateSigningRequest, condition certsv1beta1.CertificateSigningRequestCondition) error {
+	csr = csr.DeepCopy()
+	condition.LastUpdateTime = metav1.Now()
+	csr.Status.Conditions = append(csr.Status.Conditions, condition)
 	_, err := a.KubeClient.CertificatesV1beta1().CertificateSigningRequests().UpdateApproval(csr)
 	return err
 }
 
 func isApproved(csr *certsv1beta1.CertificateSigningRequest) bool {
+	return hasCondition(csr, certsv1beta1.CertificateApproved)
+}
+
+func isDenied(csr *certsv1beta1.CertificateSigningRequest) bool {
+	return hasCondition(csr, certsv1beta1.CertificateDenied)
+}
+
+func hasCondition(csr *certsv1beta1.CertificateSigningRequest, conditionType certsv1beta1.RequestConditionType) bool {
 	for _, c := range csr.Status.Conditions {
-		if c.Type == certsv1beta1.CertificateApproved {
+		if c.Type == conditionType {
 			return true
 		}
 	}
@@ -1,14 +1,19 @@
 package autoapprover
 
 import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
-	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/openshift/hypershift-toolkit/pkg/cmd/cpoperator"
 	"github.com/openshift/hypershift-toolkit/pkg/controllers"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/awsinfra"
 )
 
 func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
@@ -18,12 +23,20 @@ func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
 		return nil
 	}))
 	csrs := informerFactory.Certificates().V1beta1().CertificateSigningRequests()
+	nodeInformer := informerFactory.Core().V1().Nodes()
+	nodes := &clusterNodes{nodes: nodeInformer.Lister(), nodesSynced: nodeInformer.Informer().HasSynced}
+	if err := setupMachineInformers(cfg, nodes); err != nil {
+		return err
+	}
 	reconciler := &AutoApprover{
 		Lister:     csrs.Lister(),
 		KubeClient: cfg.TargetKubeClient(),
+		Nodes:      nodes,
 		Log:        cfg.Logger().WithName("AutoApprover"),
+		Recorder:   cfg.EventRecorder(),
+		Namespace:  cfg.Namespace(),
 	}
-	c, err := controller.New("auto-approver", cfg.Manager(), controller.Options{Reconciler: reconciler})
+	c, err := controllers.NewController("auto-approver", cfg.Manager(), reconciler, cfg.Logger().WithName("auto-approver"))
 	if err != nil {
 		return err
 	}
@@ -32,3 +45,45 @@ func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
 	}
 	return nil
 }
+
+// setupMachineInformers watches the worker machinesets of the cluster and the machines of
+// the management cluster, so that node names are validated against the worker machines.
+// Clusters whose workers are not machines of the management cluster, or whose control plane
+// operator is not allowed to read them, only have the requesters and contents of their CSRs
+// validated.
+func setupMachineInformers(cfg *cpoperator.ControlPlaneOperatorConfig, nodes *clusterNodes) error {
+	client, err := dynamic.NewForConfig(cfg.Config())
+	if err != nil {
+		return err
+	}
+	selector := fmt.Sprintf("%s=%s", MachineSetClusterLabel, cfg.Namespace())
+	machineSets := client.Resource(awsinfra.MachineResource.GroupVersion().WithResource("machinesets")).Namespace(awsinfra.MachineNamespace)
+	machines := client.Resource(awsinfra.MachineResource).Namespace(awsinfra.MachineNamespace)
+	if _, err = machineSets.List(metav1.ListOptions{LabelSelector: selector, Limit: 1}); err != nil {
+		if errors.IsForbidden(err) || errors.IsNotFound(err) {
+			cfg.Logger().WithName("AutoApprover").Info("The worker machines of the cluster cannot be read, node names of CSRs are not validated against them", "error", err.Error())
+			return nil
+		}
+		return err
+	}
+	if _, err = machines.List(metav1.ListOptions{Limit: 1}); err != nil {
+		if errors.IsForbidden(err) {
+			cfg.Logger().WithName("AutoApprover").Info("The machines of the management cluster cannot be read, node names of CSRs are not validated against them", "error", err.Error())
+			return nil
+		}
+		return err
+	}
+	machineSetInformer := controllers.NewUnstructuredInformer(machineSets, selector, controllers.DefaultResync)
+	machineInformer := controllers.NewUnstructuredInformer(machines, "", controllers.DefaultResync)
+	nodes.machineSets = machineSetInformer.GetStore()
+	nodes.machines = machineInformer.GetStore()
+	nodes.machinesSynced = func() bool {
+		return machineSetInformer.HasSynced() && machineInformer.HasSynced()
+	}
+	return cfg.Manager().Add(manager.RunnableFunc(func(stopCh <-chan struct{}) error {
+		go machineSetInformer.Run(stopCh)
+		go machineInformer.Run(stopCh)
+		<-stopCh
+		return nil
+	}))
+}
@@ -0,0 +1,87 @@
+package awsinfra
+
+const (
+	// InfraConfigMapName is the name of the configmap on the management cluster
+	// that describes the AWS resources that were created for a cluster
+	InfraConfigMapName = "aws-infra"
+
+	// InfraConfigKey is the key in the infrastructure configmap that holds the
+	// JSON representation of the InfraConfig
+	InfraConfigKey = "infra.json"
+
+	// CredentialsSecretName is the name of the secret on the management cluster
+	// that contains the AWS credentials used by the verifier
+	CredentialsSecretName = "aws-infra-credentials"
+)
+
+// InfraConfig describes the AWS resources that support a hosted control plane
+type InfraConfig struct {
+	// Region is the AWS region where resources were created
+	Region string `json:"region"`
+
+	// VPC is the id of the VPC that contains the load balancer targets
+	VPC string `json:"vpc"`
+
+	// DNSZoneID is the id of the Route53 zone that contains the cluster's records
+	DNSZoneID string `json:"dnsZoneID"`
+
+	// LoadBalancers are the network load balancers created for the cluster
+	LoadBalancers []LoadBalancer `json:"loadBalancers"`
+
+	// DNSRecords are the CNAME records that point to the cluster's load balancers
+	DNSRecords []DNSRecord `json:"dnsRecords"`
+
+	// IgnitionBucket is the S3 bucket that holds the worker ignition file
+	IgnitionBucket string `json:"ignitionBucket"`
+
+	// IgnitionKey is the key of the worker ignition file in the bucket
+	IgnitionKey string `json:"ignitionKey"`
+
+	// IgnitionURLSecrets are the user data secrets in the machine namespace that hold a
+	// pre-signed URL of the ignition file. The aws-ignition-urls controller replaces the
+	// URLs before they expire.
+	IgnitionURLSecrets []string `json:"ignitionURLSecrets,omitempty"`
+
+	// WorkerMachinePrefixes are the name prefixes of the management cluster machines that
+	// are the targets of target groups with expected targets. When set, the aws-machine-targets
+	// controller keeps the targets in sync with those machines.
+	WorkerMachinePrefixes []string `json:"workerMachinePrefixes,omitempty"`
+
+	// Tags are added to the resources that are re-created, so that they can be found
+	// when the cluster is uninstalled
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Repair enables re-creating resources that have drifted from this configuration.
+	// When false, drift is only reported.
+	Repair bool `json:"repair"`
+}
+
+// LoadBalancer is a network load balancer and its listeners
+type LoadBalancer struct {
+	Name      string     `json:"name"`
+	Listeners []Listener `json:"listeners"`
+}
+
+// Listener is a load balancer listener that forwards to a target group
+type Listener struct {
+	Port        int64       `json:"port"`
+	Protocol    string      `json:"protocol"`
+	TargetGroup TargetGroup `json:"targetGroup"`
+}
+
+// TargetGroup is a load balancer target group and its expected targets. A target group
+// without expected targets has its targets registered by others and any are accepted.
+type TargetGroup struct {
+	Name            string   `json:"name"`
+	Port            int64    `json:"port"`
+	Protocol        string   `json:"protocol"`
+	TargetType      string   `json:"targetType"`
+	HealthCheckPort string   `json:"healthCheckPort,omitempty"`
+	Targets         []string `json:"targets"`
+}
+
+// DNSRecord is a CNAME record that points to a load balancer
+type DNSRecord struct {
+	Name         string `json:"name"`
+	LoadBalancer string `json:"loadBalancer"`
+}
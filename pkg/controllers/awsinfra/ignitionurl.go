@@ -0,0 +1,117 @@
+package awsinfra
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/hypershift-toolkit/pkg/ignition"
+)
+
+// IgnitionURLExpiry is the validity of the pre-signed URLs of the worker ignition file,
+// the longest validity of URLs signed with the credentials of an IAM user
+const IgnitionURLExpiry = 7 * 24 * time.Hour
+
+// ignitionURLRefreshThreshold is how long before expiry a pre-signed URL is replaced
+var ignitionURLRefreshThreshold = 2 * 24 * time.Hour
+
+// IgnitionURLRefresher replaces the pre-signed URLs of the worker ignition file in the
+// user data secrets of the infrastructure configuration before they expire, so that
+// machines created later can still fetch their ignition config
+type IgnitionURLRefresher struct {
+	// Verifier provides the infrastructure configuration and AWS clients
+	Verifier *InfraVerifier
+}
+
+// Run performs a single refresh, logging any error
+func (r *IgnitionURLRefresher) Run() {
+	if err := r.Refresh(); err != nil {
+		r.Verifier.Log.Error(err, "Ignition URL refresh failed")
+	}
+}
+
+// Refresh replaces the ignition URLs that expire within the refresh threshold
+func (r *IgnitionURLRefresher) Refresh() error {
+	v := r.Verifier
+	infra, err := v.infraConfig()
+	if err != nil {
+		return err
+	}
+	if infra == nil || len(infra.IgnitionBucket) == 0 || len(infra.IgnitionURLSecrets) == 0 {
+		return nil
+	}
+	var clients *awsClients
+	now := time.Now()
+	for _, name := range infra.IgnitionURLSecrets {
+		secret, err := v.Client.CoreV1().Secrets(MachineNamespace).Get(name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			v.Log.Info("User data secret not found, skipping", "secret", name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("cannot get user data secret %s: %v", name, err)
+		}
+		source, err := ignition.UserDataSource(secret.Data["userData"])
+		if err != nil {
+			return fmt.Errorf("cannot get ignition URL of user data secret %s: %v", name, err)
+		}
+		if expiry, err := presignedURLExpiry(source); err == nil && expiry.Sub(now) > ignitionURLRefreshThreshold {
+			continue
+		}
+		if clients == nil {
+			if clients, err = v.awsClients(infra.Region); err != nil {
+				return err
+			}
+		}
+		presigned, err := PresignIgnitionURL(clients.s3, infra.IgnitionBucket, infra.IgnitionKey)
+		if err != nil {
+			return fmt.Errorf("cannot pre-sign ignition URL: %v", err)
+		}
+		userData, err := ignition.SetUserDataSource(secret.Data["userData"], presigned)
+		if err != nil {
+			return fmt.Errorf("cannot set ignition URL of user data secret %s: %v", name, err)
+		}
+		secret = secret.DeepCopy()
+		secret.Data["userData"] = userData
+		if _, err := v.Client.CoreV1().Secrets(MachineNamespace).Update(secret); err != nil {
+			return fmt.Errorf("cannot update user data secret %s: %v", name, err)
+		}
+		v.Log.Info("Refreshed ignition URL", "secret", name, "expiry", now.Add(IgnitionURLExpiry))
+	}
+	return nil
+}
+
+// PresignIgnitionURL returns a URL of the ignition file in the bucket that is valid for
+// IgnitionURLExpiry
+func PresignIgnitionURL(client *s3.S3, bucket, key string) (string, error) {
+	req, _ := client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	return req.Presign(IgnitionURLExpiry)
+}
+
+// presignedURLExpiry returns when a pre-signed S3 URL expires
+func presignedURLExpiry(rawURL string) (time.Time, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return time.Time{}, err
+	}
+	query := u.Query()
+	signed, err := time.Parse("20060102T150405Z", query.Get("X-Amz-Date"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("URL is not pre-signed: %v", err)
+	}
+	seconds, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("URL is not pre-signed: %v", err)
+	}
+	return signed.Add(time.Duration(seconds) * time.Second), nil
+}
@@ -0,0 +1,58 @@
+package awsinfra
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestPresignedURLExpiry(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		expected    time.Time
+		expectError bool
+	}{
+		{
+			name:     "pre-signed",
+			url:      "https://bucket.s3.amazonaws.com/worker.ign?X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Date=20200501T120000Z&X-Amz-Expires=604800&X-Amz-Signature=abc",
+			expected: time.Date(2020, 5, 8, 12, 0, 0, 0, time.UTC),
+		},
+		{name: "public", url: "https://bucket.s3.amazonaws.com/worker.ign", expectError: true},
+		{name: "no expiry", url: "https://bucket.s3.amazonaws.com/worker.ign?X-Amz-Date=20200501T120000Z", expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expiry, err := presignedURLExpiry(test.url)
+			if test.expectError != (err != nil) {
+				t.Fatalf("expected error %t, got %v", test.expectError, err)
+			}
+			if !expiry.Equal(test.expected) {
+				t.Errorf("expected expiry %v, got %v", test.expected, expiry)
+			}
+		})
+	}
+}
+
+func TestPresignIgnitionURL(t *testing.T) {
+	s := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("key", "secret", ""),
+	}))
+	before := time.Now().Truncate(time.Second)
+	presigned, err := PresignIgnitionURL(s3.New(s), "bucket", "worker.ign")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expiry, err := presignedURLExpiry(presigned)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expiry.Before(before.Add(IgnitionURLExpiry)) || expiry.After(time.Now().Add(IgnitionURLExpiry)) {
+		t.Errorf("expected the URL to expire in %v, got %v", IgnitionURLExpiry, expiry)
+	}
+}
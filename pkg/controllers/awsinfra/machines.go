@@ -0,0 +1,199 @@
+package awsinfra
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/elbv2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+// MachineNamespace is the namespace of the machines of the management cluster
+const MachineNamespace = "openshift-machine-api"
+
+// MachineResource is the resource of the machines of the management cluster
+var MachineResource = schema.GroupVersionResource{Group: "machine.openshift.io", Version: "v1beta1", Resource: "machines"}
+
+// MachineTargetSyncer keeps the expected targets of the infrastructure configuration in
+// sync with the worker machines of the management cluster, and registers them with their
+// target groups. Target groups without expected targets are left alone.
+type MachineTargetSyncer struct {
+	// Verifier verifies the target groups whose targets changed
+	Verifier *InfraVerifier
+
+	// Machines is a store of the machines of the management cluster
+	Machines cache.Store
+
+	trigger chan struct{}
+}
+
+// NewMachineTargetSyncer returns a syncer for the given verifier and machine store
+func NewMachineTargetSyncer(verifier *InfraVerifier, machines cache.Store) *MachineTargetSyncer {
+	return &MachineTargetSyncer{
+		Verifier: verifier,
+		Machines: machines,
+		trigger:  make(chan struct{}, 1),
+	}
+}
+
+// Trigger requests a sync without blocking. Requests made while a sync is pending are merged.
+func (s *MachineTargetSyncer) Trigger() {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Triggered returns the channel that receives sync requests
+func (s *MachineTargetSyncer) Triggered() <-chan struct{} {
+	return s.trigger
+}
+
+// Run performs a single sync, logging any error
+func (s *MachineTargetSyncer) Run() {
+	if err := s.Sync(); err != nil {
+		s.Verifier.Log.Error(err, "AWS target group sync failed")
+	}
+}
+
+// Sync updates the expected targets of the infrastructure configuration from the worker
+// machines and, if they changed, stores the configuration and verifies the target groups.
+func (s *MachineTargetSyncer) Sync() error {
+	v := s.Verifier
+	cm, err := v.Client.CoreV1().ConfigMaps(v.Namespace).Get(InfraConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("cannot get AWS infrastructure config: %v", err)
+	}
+	infra := &InfraConfig{}
+	if err := json.Unmarshal([]byte(cm.Data[InfraConfigKey]), infra); err != nil {
+		return fmt.Errorf("cannot parse AWS infrastructure config: %v", err)
+	}
+	if len(infra.WorkerMachinePrefixes) == 0 {
+		return nil
+	}
+	instanceIDs, machineIPs := s.workerTargets(infra.WorkerMachinePrefixes)
+	// Never remove all targets because of an empty or stale machine cache
+	if len(instanceIDs) == 0 {
+		v.Log.Info("No provisioned worker machines found, keeping the current targets")
+		return nil
+	}
+	changed := updateTargets(infra, instanceIDs, machineIPs)
+	if len(changed) == 0 {
+		return nil
+	}
+	infraBytes, err := json.Marshal(infra)
+	if err != nil {
+		return err
+	}
+	cm.Data[InfraConfigKey] = string(infraBytes)
+	if _, err = v.Client.CoreV1().ConfigMaps(v.Namespace).Update(cm); err != nil {
+		return fmt.Errorf("cannot update AWS infrastructure config: %v", err)
+	}
+	v.Log.Info("Updated expected targets from worker machines", "targetGroups", changed)
+
+	clients, err := v.awsClients(infra.Region)
+	if err != nil {
+		return err
+	}
+	for _, tg := range changed {
+		if _, err := v.verifyTargetGroup(clients, infra, targetGroup(infra, tg)); err != nil {
+			return fmt.Errorf("cannot verify target group %s: %v", tg, err)
+		}
+	}
+	return nil
+}
+
+// workerTargets returns the sorted instance IDs and internal IPs of the provisioned
+// machines whose names start with one of the prefixes
+func (s *MachineTargetSyncer) workerTargets(prefixes []string) ([]string, []string) {
+	var instanceIDs, machineIPs []string
+	for _, obj := range s.Machines.List() {
+		machine, ok := obj.(*unstructured.Unstructured)
+		if !ok || machine.GetDeletionTimestamp() != nil || !hasPrefix(machine.GetName(), prefixes) {
+			continue
+		}
+		instanceID, machineIP := MachineInstance(machine)
+		if len(instanceID) == 0 || len(machineIP) == 0 {
+			continue
+		}
+		instanceIDs = append(instanceIDs, instanceID)
+		machineIPs = append(machineIPs, machineIP)
+	}
+	sort.Strings(instanceIDs)
+	sort.Strings(machineIPs)
+	return instanceIDs, machineIPs
+}
+
+// updateTargets sets the expected targets of the target groups that have any to the
+// instance IDs or IPs, depending on their target type. It returns the names of the
+// target groups whose targets changed.
+func updateTargets(infra *InfraConfig, instanceIDs, machineIPs []string) []string {
+	changed := []string{}
+	for i := range infra.LoadBalancers {
+		for j := range infra.LoadBalancers[i].Listeners {
+			tg := &infra.LoadBalancers[i].Listeners[j].TargetGroup
+			if len(tg.Targets) == 0 {
+				continue
+			}
+			targets := machineIPs
+			if tg.TargetType == elbv2.TargetTypeEnumInstance {
+				targets = instanceIDs
+			}
+			current := append([]string{}, tg.Targets...)
+			sort.Strings(current)
+			if reflect.DeepEqual(current, targets) {
+				continue
+			}
+			tg.Targets = append([]string{}, targets...)
+			changed = append(changed, tg.Name)
+		}
+	}
+	return changed
+}
+
+// targetGroup returns the target group with the given name
+func targetGroup(infra *InfraConfig, name string) TargetGroup {
+	for _, lb := range infra.LoadBalancers {
+		for _, listener := range lb.Listeners {
+			if listener.TargetGroup.Name == name {
+				return listener.TargetGroup
+			}
+		}
+	}
+	return TargetGroup{Name: name}
+}
+
+// MachineInstance returns the instance ID and internal IP of a machine, or empty strings
+// if the machine does not have them yet
+func MachineInstance(machine *unstructured.Unstructured) (string, string) {
+	instanceID, _, _ := unstructured.NestedString(machine.Object, "status", "providerStatus", "instanceId")
+	addresses, _, _ := unstructured.NestedSlice(machine.Object, "status", "addresses")
+	for _, addr := range addresses {
+		addrMap, ok := addr.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if addrType, _, _ := unstructured.NestedString(addrMap, "type"); addrType != "InternalIP" {
+			continue
+		}
+		machineIP, _, _ := unstructured.NestedString(addrMap, "address")
+		return instanceID, machineIP
+	}
+	return instanceID, ""
+}
+
+func hasPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package awsinfra
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/elbv2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+func testMachine(name, instanceID, ip string) *unstructured.Unstructured {
+	machine := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	machine.SetName(name)
+	machine.SetNamespace(MachineNamespace)
+	if len(instanceID) > 0 {
+		unstructured.SetNestedField(machine.Object, instanceID, "status", "providerStatus", "instanceId")
+	}
+	if len(ip) > 0 {
+		unstructured.SetNestedSlice(machine.Object, []interface{}{
+			map[string]interface{}{"type": "InternalDNS", "address": "ip.ec2.internal"},
+			map[string]interface{}{"type": "InternalIP", "address": ip},
+		}, "status", "addresses")
+	}
+	return machine
+}
+
+func TestMachineInstance(t *testing.T) {
+	if id, ip := MachineInstance(testMachine("worker", "i-1", "10.0.0.1")); id != "i-1" || ip != "10.0.0.1" {
+		t.Errorf("unexpected machine instance %s, %s", id, ip)
+	}
+	if id, ip := MachineInstance(testMachine("worker", "", "")); id != "" || ip != "" {
+		t.Errorf("expected no machine instance, got %s, %s", id, ip)
+	}
+}
+
+func TestWorkerTargets(t *testing.T) {
+	deleted := testMachine("infra-worker-us-east-1a-3", "i-3", "10.0.0.3")
+	now := metav1.Now()
+	deleted.SetDeletionTimestamp(&now)
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	for _, machine := range []*unstructured.Unstructured{
+		testMachine("infra-worker-us-east-1b-2", "i-2", "10.0.1.2"),
+		testMachine("infra-worker-us-east-1a-1", "i-1", "10.0.0.1"),
+		testMachine("infra-worker-us-east-1a-4", "", ""),
+		testMachine("infra-worker-us-east-1c-5", "i-5", "10.0.2.5"),
+		testMachine("infra-master-0", "i-0", "10.0.0.10"),
+		deleted,
+	} {
+		store.Add(machine)
+	}
+	syncer := NewMachineTargetSyncer(&InfraVerifier{}, store)
+	ids, ips := syncer.workerTargets([]string{"infra-worker-us-east-1a", "infra-worker-us-east-1b"})
+	if !reflect.DeepEqual(ids, []string{"i-1", "i-2"}) {
+		t.Errorf("unexpected instance IDs %v", ids)
+	}
+	if !reflect.DeepEqual(ips, []string{"10.0.0.1", "10.0.1.2"}) {
+		t.Errorf("unexpected IPs %v", ips)
+	}
+}
+
+func TestUpdateTargets(t *testing.T) {
+	infra := &InfraConfig{
+		LoadBalancers: []LoadBalancer{
+			{
+				Name: "api",
+				Listeners: []Listener{
+					{TargetGroup: TargetGroup{Name: "api", TargetType: elbv2.TargetTypeEnumIp, Targets: []string{"10.0.0.2", "10.0.0.1"}}},
+					{TargetGroup: TargetGroup{Name: "oauth", TargetType: elbv2.TargetTypeEnumIp, Targets: []string{"10.0.0.1"}}},
+				},
+			},
+			{
+				Name: "apps",
+				Listeners: []Listener{
+					{TargetGroup: TargetGroup{Name: "http", TargetType: elbv2.TargetTypeEnumIp}},
+				},
+			},
+			{
+				Name: "vpn",
+				Listeners: []Listener{
+					{TargetGroup: TargetGroup{Name: "vpn", TargetType: elbv2.TargetTypeEnumInstance, Targets: []string{"i-1"}}},
+				},
+			},
+		},
+	}
+	changed := updateTargets(infra, []string{"i-1", "i-2"}, []string{"10.0.0.1", "10.0.0.2"})
+	if !reflect.DeepEqual(changed, []string{"oauth", "vpn"}) {
+		t.Errorf("unexpected changed target groups %v", changed)
+	}
+	if targets := targetGroup(infra, "oauth").Targets; !reflect.DeepEqual(targets, []string{"10.0.0.1", "10.0.0.2"}) {
+		t.Errorf("unexpected oauth targets %v", targets)
+	}
+	if targets := targetGroup(infra, "vpn").Targets; !reflect.DeepEqual(targets, []string{"i-1", "i-2"}) {
+		t.Errorf("unexpected vpn targets %v", targets)
+	}
+	if targets := targetGroup(infra, "http").Targets; len(targets) != 0 {
+		t.Errorf("expected target groups without targets to be left alone, got %v", targets)
+	}
+}
+
+func TestMachineTargetChanged(t *testing.T) {
+	original := testMachine("worker", "i-1", "10.0.0.1")
+	deleted := original.DeepCopy()
+	now := metav1.Now()
+	deleted.SetDeletionTimestamp(&now)
+	relabeled := original.DeepCopy()
+	relabeled.SetLabels(map[string]string{"updated": "true"})
+	tests := []struct {
+		name     string
+		updated  *unstructured.Unstructured
+		expected bool
+	}{
+		{name: "unrelated change", updated: relabeled},
+		{name: "new address", updated: testMachine("worker", "i-1", "10.0.0.2"), expected: true},
+		{name: "new instance", updated: testMachine("worker", "i-2", "10.0.0.1"), expected: true},
+		{name: "deleted", updated: deleted, expected: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := machineTargetChanged(original, test.updated); actual != test.expected {
+				t.Errorf("expected changed %t, got %t", test.expected, actual)
+			}
+		})
+	}
+}
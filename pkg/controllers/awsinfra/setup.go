@@ -0,0 +1,99 @@
+package awsinfra
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/openshift/hypershift-toolkit/pkg/cmd/cpoperator"
+)
+
+func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
+	verifier := newVerifier(cfg, "AWSInfraVerifier")
+	return cfg.Manager().Add(manager.RunnableFunc(func(stopCh <-chan struct{}) error {
+		wait.Until(verifier.Run, syncInterval, stopCh)
+		return nil
+	}))
+}
+
+// SetupIgnitionURLs sets up a controller that periodically replaces the pre-signed
+// ignition URLs in the user data secrets of the cluster before they expire
+func SetupIgnitionURLs(cfg *cpoperator.ControlPlaneOperatorConfig) error {
+	refresher := &IgnitionURLRefresher{Verifier: newVerifier(cfg, "AWSIgnitionURLs")}
+	return cfg.Manager().Add(manager.RunnableFunc(func(stopCh <-chan struct{}) error {
+		wait.Until(refresher.Run, syncInterval, stopCh)
+		return nil
+	}))
+}
+
+// SetupMachineTargets sets up a controller that watches the machines of the management
+// cluster and keeps the load balancer targets of the cluster in sync with its workers
+func SetupMachineTargets(cfg *cpoperator.ControlPlaneOperatorConfig) error {
+	client, err := dynamic.NewForConfig(cfg.Config())
+	if err != nil {
+		return err
+	}
+	machines := client.Resource(MachineResource).Namespace(MachineNamespace)
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return machines.List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return machines.Watch(options)
+		},
+	}, &unstructured.Unstructured{}, syncInterval, cache.Indexers{})
+	syncer := NewMachineTargetSyncer(newVerifier(cfg, "AWSMachineTargets"), informer.GetStore())
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(interface{}) { syncer.Trigger() },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if machineTargetChanged(oldObj, newObj) {
+				syncer.Trigger()
+			}
+		},
+		DeleteFunc: func(interface{}) { syncer.Trigger() },
+	})
+	return cfg.Manager().Add(manager.RunnableFunc(func(stopCh <-chan struct{}) error {
+		go informer.Run(stopCh)
+		if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+			return nil
+		}
+		for {
+			select {
+			case <-stopCh:
+				return nil
+			case <-syncer.Triggered():
+				syncer.Run()
+			}
+		}
+	}))
+}
+
+func newVerifier(cfg *cpoperator.ControlPlaneOperatorConfig, name string) *InfraVerifier {
+	return &InfraVerifier{
+		Client:    cfg.KubeClient(),
+		Namespace: cfg.Namespace(),
+		Recorder:  cfg.EventRecorder(),
+		Log:       cfg.Logger().WithName(name),
+	}
+}
+
+// machineTargetChanged returns whether an update of a machine changed its instance,
+// its address or whether it is being deleted
+func machineTargetChanged(oldObj, newObj interface{}) bool {
+	oldMachine, ok := oldObj.(*unstructured.Unstructured)
+	if !ok {
+		return true
+	}
+	newMachine, ok := newObj.(*unstructured.Unstructured)
+	if !ok {
+		return true
+	}
+	oldID, oldIP := MachineInstance(oldMachine)
+	newID, newIP := MachineInstance(newMachine)
+	return oldID != newID || oldIP != newIP || (oldMachine.GetDeletionTimestamp() == nil) != (newMachine.GetDeletionTimestamp() == nil)
+}
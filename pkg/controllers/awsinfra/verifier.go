@@ -0,0 +1,445 @@
+package awsinfra
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubeclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+)
+
+// syncInterval is the amount of time to use between checks
+var syncInterval = 5 * time.Minute
+
+// InfraVerifier periodically verifies that the AWS resources described in the
+// aws-infra configmap still exist and are healthy. Drift is reported as a warning
+// event on the configmap and, if repair is enabled, fixed where possible.
+type InfraVerifier struct {
+	// Client is a client of the management cluster
+	Client kubeclient.Interface
+
+	// Namespace is the namespace where the control plane of the cluster
+	// lives on the management server
+	Namespace string
+
+	// Recorder records drift events on the infrastructure configmap. Repeated
+	// events are aggregated by the recorder.
+	Recorder record.EventRecorder
+
+	// Log is the logger for this controller
+	Log logr.Logger
+}
+
+// awsClients holds the AWS service clients used for a single verification pass
+type awsClients struct {
+	elb     *elbv2.ELBV2
+	route53 *route53.Route53
+	s3      *s3.S3
+}
+
+// Run performs a single verification pass, logging any error
+func (v *InfraVerifier) Run() {
+	if err := v.Verify(); err != nil {
+		v.Log.Error(err, "AWS infrastructure verification failed")
+	}
+}
+
+// Verify checks the load balancers, listeners, target groups, DNS records and
+// ignition object described by the infrastructure configmap.
+func (v *InfraVerifier) Verify() error {
+	infra, err := v.infraConfig()
+	if err != nil {
+		return err
+	}
+	if infra == nil {
+		v.Log.Info("AWS infrastructure configmap not found, skipping verification")
+		return nil
+	}
+	clients, err := v.awsClients(infra.Region)
+	if err != nil {
+		return err
+	}
+	lbDNSNames := map[string]string{}
+	for _, lb := range infra.LoadBalancers {
+		dnsName, err := v.verifyLoadBalancer(clients, infra, lb)
+		if err != nil {
+			return fmt.Errorf("cannot verify load balancer %s: %v", lb.Name, err)
+		}
+		lbDNSNames[lb.Name] = dnsName
+	}
+	for _, record := range infra.DNSRecords {
+		if err := v.verifyDNSRecord(clients, infra, record, lbDNSNames[record.LoadBalancer]); err != nil {
+			return fmt.Errorf("cannot verify DNS record %s: %v", record.Name, err)
+		}
+	}
+	if len(infra.IgnitionBucket) > 0 {
+		if err := v.verifyIgnitionObject(clients, infra); err != nil {
+			return fmt.Errorf("cannot verify ignition object: %v", err)
+		}
+	}
+	return nil
+}
+
+func (v *InfraVerifier) infraConfig() (*InfraConfig, error) {
+	cm, err := v.Client.CoreV1().ConfigMaps(v.Namespace).Get(InfraConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	infra := &InfraConfig{}
+	if err := json.Unmarshal([]byte(cm.Data[InfraConfigKey]), infra); err != nil {
+		return nil, fmt.Errorf("cannot parse AWS infrastructure config: %v", err)
+	}
+	return infra, nil
+}
+
+func (v *InfraVerifier) awsClients(region string) (*awsClients, error) {
+	secret, err := v.Client.CoreV1().Secrets(v.Namespace).Get(CredentialsSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get AWS credentials: %v", err)
+	}
+	key, ok := secret.Data["aws_access_key_id"]
+	if !ok {
+		return nil, fmt.Errorf("did not find an AWS access key")
+	}
+	secretKey, ok := secret.Data["aws_secret_access_key"]
+	if !ok {
+		return nil, fmt.Errorf("did not find an AWS secret access key")
+	}
+	s, err := session.NewSession(&aws.Config{
+		Region:      aws.String(region),
+		Credentials: credentials.NewStaticCredentials(string(key), string(secretKey), ""),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &awsClients{
+		elb:     elbv2.New(s),
+		route53: route53.New(s),
+		s3:      s3.New(s),
+	}, nil
+}
+
+// verifyLoadBalancer verifies that a load balancer is active and that its listeners
+// forward to healthy target groups. It returns the DNS name of the load balancer.
+func (v *InfraVerifier) verifyLoadBalancer(clients *awsClients, infra *InfraConfig, lb LoadBalancer) (string, error) {
+	output, err := clients.elb.DescribeLoadBalancers(&elbv2.DescribeLoadBalancersInput{
+		Names: []*string{aws.String(lb.Name)},
+	})
+	if isAWSErrorCode(err, elbv2.ErrCodeLoadBalancerNotFoundException) || (err == nil && len(output.LoadBalancers) == 0) {
+		v.reportDrift("LoadBalancerMissing", "Load balancer %s does not exist", lb.Name)
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	nlb := output.LoadBalancers[0]
+	if nlb.State != nil && aws.StringValue(nlb.State.Code) != elbv2.LoadBalancerStateEnumActive {
+		v.reportDrift("LoadBalancerNotActive", "Load balancer %s is in state %s", lb.Name, aws.StringValue(nlb.State.Code))
+	}
+	listeners, err := clients.elb.DescribeListeners(&elbv2.DescribeListenersInput{
+		LoadBalancerArn: nlb.LoadBalancerArn,
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, listener := range lb.Listeners {
+		tgARN, err := v.verifyTargetGroup(clients, infra, listener.TargetGroup)
+		if err != nil {
+			return "", fmt.Errorf("cannot verify target group %s: %v", listener.TargetGroup.Name, err)
+		}
+		if len(tgARN) == 0 {
+			continue
+		}
+		if err := v.verifyListener(clients, infra, lb.Name, aws.StringValue(nlb.LoadBalancerArn), listeners.Listeners, listener, tgARN); err != nil {
+			return "", fmt.Errorf("cannot verify listener on port %d: %v", listener.Port, err)
+		}
+	}
+	return aws.StringValue(nlb.DNSName), nil
+}
+
+// verifyTargetGroup verifies that a target group exists, that it contains exactly the
+// expected targets and that its targets are healthy. It returns the ARN of the target group or
+// an empty string if the target group does not exist and could not be re-created.
+func (v *InfraVerifier) verifyTargetGroup(clients *awsClients, infra *InfraConfig, tg TargetGroup) (string, error) {
+	output, err := clients.elb.DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{
+		Names: []*string{aws.String(tg.Name)},
+	})
+	var tgARN string
+	if isAWSErrorCode(err, elbv2.ErrCodeTargetGroupNotFoundException) || (err == nil && len(output.TargetGroups) == 0) {
+		v.reportDrift("TargetGroupMissing", "Target group %s does not exist", tg.Name)
+		if !infra.Repair {
+			return "", nil
+		}
+		if tgARN, err = createTargetGroup(clients, infra, tg); err != nil {
+			return "", err
+		}
+		v.Log.Info("Re-created target group", "name", tg.Name)
+	} else if err != nil {
+		return "", err
+	} else {
+		tgARN = aws.StringValue(output.TargetGroups[0].TargetGroupArn)
+	}
+
+	health, err := clients.elb.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{
+		TargetGroupArn: aws.String(tgARN),
+	})
+	if err != nil {
+		return "", err
+	}
+	registered := sets.NewString()
+	for _, hd := range health.TargetHealthDescriptions {
+		target := aws.StringValue(hd.Target.Id)
+		registered.Insert(target)
+		if hd.TargetHealth != nil && aws.StringValue(hd.TargetHealth.State) != elbv2.TargetHealthStateEnumHealthy {
+			v.reportDrift("TargetUnhealthy", "Target %s in target group %s is %s: %s", target, tg.Name, aws.StringValue(hd.TargetHealth.State), aws.StringValue(hd.TargetHealth.Description))
+		}
+	}
+	// Target groups without expected targets have their targets registered by others,
+	// such as the machine API for the router, so only listed targets are kept
+	expected := sets.NewString(tg.Targets...)
+	stale := []*elbv2.TargetDescription{}
+	for _, target := range registered.List() {
+		if expected.Len() > 0 && !expected.Has(target) {
+			v.reportDrift("TargetUnexpected", "Target %s is registered with target group %s but is not expected", target, tg.Name)
+			stale = append(stale, &elbv2.TargetDescription{Id: aws.String(target)})
+		}
+	}
+	if len(stale) > 0 && infra.Repair {
+		_, err = clients.elb.DeregisterTargets(&elbv2.DeregisterTargetsInput{
+			TargetGroupArn: aws.String(tgARN),
+			Targets:        stale,
+		})
+		if err != nil {
+			return "", err
+		}
+		v.Log.Info("Deregistered unexpected targets", "targetGroup", tg.Name, "count", len(stale))
+	}
+	missing := []*elbv2.TargetDescription{}
+	for _, target := range tg.Targets {
+		if !registered.Has(target) {
+			v.reportDrift("TargetMissing", "Target %s is not registered with target group %s", target, tg.Name)
+			missing = append(missing, &elbv2.TargetDescription{Id: aws.String(target)})
+		}
+	}
+	if len(missing) > 0 && infra.Repair {
+		_, err = clients.elb.RegisterTargets(&elbv2.RegisterTargetsInput{
+			TargetGroupArn: aws.String(tgARN),
+			Targets:        missing,
+		})
+		if err != nil {
+			return "", err
+		}
+		v.Log.Info("Registered missing targets", "targetGroup", tg.Name, "count", len(missing))
+	}
+	return tgARN, nil
+}
+
+// verifyListener verifies that a listener exists on the expected port and that it
+// forwards to the expected target group.
+func (v *InfraVerifier) verifyListener(clients *awsClients, infra *InfraConfig, lbName, lbARN string, existing []*elbv2.Listener, listener Listener, tgARN string) error {
+	for _, l := range existing {
+		if aws.Int64Value(l.Port) != listener.Port {
+			continue
+		}
+		if len(l.DefaultActions) > 0 && aws.StringValue(l.DefaultActions[0].TargetGroupArn) == tgARN {
+			return nil
+		}
+		v.reportDrift("ListenerMisconfigured", "Listener on port %d of load balancer %s does not forward to target group %s", listener.Port, lbName, listener.TargetGroup.Name)
+		if !infra.Repair {
+			return nil
+		}
+		_, err := clients.elb.ModifyListener(&elbv2.ModifyListenerInput{
+			ListenerArn: l.ListenerArn,
+			DefaultActions: []*elbv2.Action{
+				{
+					TargetGroupArn: aws.String(tgARN),
+					Type:           aws.String(elbv2.ActionTypeEnumForward),
+				},
+			},
+		})
+		return err
+	}
+	v.reportDrift("ListenerMissing", "Listener on port %d of load balancer %s does not exist", listener.Port, lbName)
+	if !infra.Repair {
+		return nil
+	}
+	_, err := clients.elb.CreateListener(&elbv2.CreateListenerInput{
+		Port:            aws.Int64(listener.Port),
+		LoadBalancerArn: aws.String(lbARN),
+		Protocol:        aws.String(listener.Protocol),
+		DefaultActions: []*elbv2.Action{
+			{
+				TargetGroupArn: aws.String(tgARN),
+				Type:           aws.String(elbv2.ActionTypeEnumForward),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	v.Log.Info("Re-created listener", "loadBalancer", lbName, "port", listener.Port)
+	return nil
+}
+
+// verifyDNSRecord verifies that a CNAME record exists and points to the DNS name of
+// its load balancer.
+func (v *InfraVerifier) verifyDNSRecord(clients *awsClients, infra *InfraConfig, record DNSRecord, lbDNSName string) error {
+	recordName := fqdn(record.Name)
+	output, err := clients.route53.ListResourceRecordSets(&route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(infra.DNSZoneID),
+		StartRecordName: aws.String(recordName),
+		StartRecordType: aws.String(route53.RRTypeCname),
+		MaxItems:        aws.String("1"),
+	})
+	if err != nil {
+		return err
+	}
+	value := ""
+	found := false
+	if len(output.ResourceRecordSets) > 0 {
+		rrs := output.ResourceRecordSets[0]
+		if unescapeRecordName(aws.StringValue(rrs.Name)) == recordName && aws.StringValue(rrs.Type) == route53.RRTypeCname {
+			found = true
+			if len(rrs.ResourceRecords) > 0 {
+				value = aws.StringValue(rrs.ResourceRecords[0].Value)
+			}
+		}
+	}
+	if !found {
+		v.reportDrift("DNSRecordMissing", "DNS record %s does not exist", record.Name)
+	} else if len(lbDNSName) > 0 && strings.TrimSuffix(value, ".") != strings.TrimSuffix(lbDNSName, ".") {
+		v.reportDrift("DNSRecordMisconfigured", "DNS record %s points to %s instead of %s", record.Name, value, lbDNSName)
+	} else {
+		return nil
+	}
+	if !infra.Repair || len(lbDNSName) == 0 {
+		return nil
+	}
+	_, err = clients.route53.ChangeResourceRecordSets(&route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(infra.DNSZoneID),
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: []*route53.Change{
+				{
+					Action: aws.String(route53.ChangeActionUpsert),
+					ResourceRecordSet: &route53.ResourceRecordSet{
+						Name: aws.String(record.Name),
+						TTL:  aws.Int64(30),
+						Type: aws.String(route53.RRTypeCname),
+						ResourceRecords: []*route53.ResourceRecord{
+							{
+								Value: aws.String(lbDNSName),
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	v.Log.Info("Updated DNS record", "name", record.Name, "value", lbDNSName)
+	return nil
+}
+
+// verifyIgnitionObject verifies that the worker ignition file exists in its bucket.
+// A missing ignition file cannot be re-created by the operator and is only reported.
+func (v *InfraVerifier) verifyIgnitionObject(clients *awsClients, infra *InfraConfig) error {
+	_, err := clients.s3.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(infra.IgnitionBucket),
+		Key:    aws.String(infra.IgnitionKey),
+	})
+	if err == nil {
+		return nil
+	}
+	if awsErr, ok := err.(awserr.RequestFailure); ok && awsErr.StatusCode() == 404 {
+		v.reportDrift("IgnitionObjectMissing", "Ignition file s3://%s/%s does not exist", infra.IgnitionBucket, infra.IgnitionKey)
+		return nil
+	}
+	return err
+}
+
+// reportDrift logs the drift and records it as a warning event on the infrastructure configmap
+func (v *InfraVerifier) reportDrift(reason, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	v.Log.Info("AWS infrastructure drift detected", "reason", reason, "message", message)
+	configMap := &corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Name:       InfraConfigMapName,
+		Namespace:  v.Namespace,
+	}
+	v.Recorder.Event(configMap, corev1.EventTypeWarning, reason, message)
+}
+
+func createTargetGroup(clients *awsClients, infra *InfraConfig, tg TargetGroup) (string, error) {
+	input := &elbv2.CreateTargetGroupInput{
+		Name:                       aws.String(tg.Name),
+		Port:                       aws.Int64(tg.Port),
+		VpcId:                      aws.String(infra.VPC),
+		Protocol:                   aws.String(tg.Protocol),
+		TargetType:                 aws.String(tg.TargetType),
+		HealthCheckProtocol:        aws.String(elbv2.ProtocolEnumTcp),
+		HealthCheckEnabled:         aws.Bool(true),
+		HealthCheckIntervalSeconds: aws.Int64(10),
+		HealthCheckTimeoutSeconds:  aws.Int64(10),
+		HealthyThresholdCount:      aws.Int64(2),
+		UnhealthyThresholdCount:    aws.Int64(2),
+	}
+	if len(tg.HealthCheckPort) > 0 {
+		input.HealthCheckPort = aws.String(tg.HealthCheckPort)
+	}
+	output, err := clients.elb.CreateTargetGroup(input)
+	if err != nil {
+		return "", err
+	}
+	arn := output.TargetGroups[0].TargetGroupArn
+	if len(infra.Tags) > 0 {
+		tags := []*elbv2.Tag{}
+		for key, value := range infra.Tags {
+			tags = append(tags, &elbv2.Tag{Key: aws.String(key), Value: aws.String(value)})
+		}
+		if _, err := clients.elb.AddTags(&elbv2.AddTagsInput{ResourceArns: []*string{arn}, Tags: tags}); err != nil {
+			return "", err
+		}
+	}
+	return aws.StringValue(arn), nil
+}
+
+func isAWSErrorCode(err error, code string) bool {
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code() == code
+	}
+	return false
+}
+
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// unescapeRecordName replaces the octal escape that Route53 uses for wildcards
+func unescapeRecordName(name string) string {
+	return strings.Replace(name, `\052`, "*", -1)
+}
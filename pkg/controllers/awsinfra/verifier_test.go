@@ -0,0 +1,304 @@
+package awsinfra
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	testZoneID = "Z123"
+	testLBDNS  = "api-lb.elb.us-east-1.amazonaws.com"
+)
+
+// fakeAWS is a fake of the Route53 and S3 endpoints used by the verifier
+type fakeAWS struct {
+	sync.Mutex
+	// records maps record names to the CNAME value returned for them
+	records map[string]string
+	// ignitionStatus is the status returned for the ignition object
+	ignitionStatus int
+	// changes are the record names that were upserted
+	changes []string
+	// targets are the targets registered with the target group
+	targets []string
+	// registered and deregistered are the targets passed to register and deregister calls
+	registered   []string
+	deregistered []string
+}
+
+const elbResponse = `<?xml version="1.0"?><%[1]sResponse xmlns="http://elasticloadbalancing.amazonaws.com/doc/2015-12-01/"><%[1]sResult>%[2]s</%[1]sResult></%[1]sResponse>`
+
+// targetIDs returns the target ids of a register or deregister request
+func targetIDs(r *http.Request) []string {
+	ids := []string{}
+	for i := 1; len(r.PostFormValue(fmt.Sprintf("Targets.member.%d.Id", i))) > 0; i++ {
+		ids = append(ids, r.PostFormValue(fmt.Sprintf("Targets.member.%d.Id", i)))
+	}
+	return ids
+}
+
+func (f *fakeAWS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	switch {
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/rrset"):
+		name := r.URL.Query().Get("name")
+		fmt.Fprint(w, `<?xml version="1.0"?><ListResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/"><ResourceRecordSets>`)
+		if value, ok := f.records[name]; ok {
+			fmt.Fprintf(w, `<ResourceRecordSet><Name>%s</Name><Type>CNAME</Type><TTL>30</TTL><ResourceRecords><ResourceRecord><Value>%s</Value></ResourceRecord></ResourceRecords></ResourceRecordSet>`, strings.Replace(name, "*", `\052`, -1), value)
+		}
+		fmt.Fprint(w, `</ResourceRecordSets><IsTruncated>false</IsTruncated><MaxItems>1</MaxItems></ListResourceRecordSetsResponse>`)
+	case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/rrset"):
+		data, _ := ioutil.ReadAll(r.Body)
+		body := string(data)
+		start := strings.Index(body, "<Name>") + len("<Name>")
+		f.changes = append(f.changes, body[start:strings.Index(body, "</Name>")])
+		fmt.Fprint(w, `<?xml version="1.0"?><ChangeResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/"><ChangeInfo><Id>/change/C1</Id><Status>PENDING</Status><SubmittedAt>2020-01-01T00:00:00Z</SubmittedAt></ChangeInfo></ChangeResourceRecordSetsResponse>`)
+	case r.Method == http.MethodPost && r.URL.Path == "/":
+		action := r.PostFormValue("Action")
+		switch action {
+		case "DescribeTargetGroups":
+			fmt.Fprintf(w, elbResponse, action, `<TargetGroups><member><TargetGroupArn>arn:tg</TargetGroupArn></member></TargetGroups>`)
+		case "DescribeTargetHealth":
+			descriptions := ""
+			for _, target := range f.targets {
+				descriptions += fmt.Sprintf(`<member><Target><Id>%s</Id></Target><TargetHealth><State>healthy</State></TargetHealth></member>`, target)
+			}
+			fmt.Fprintf(w, elbResponse, action, "<TargetHealthDescriptions>"+descriptions+"</TargetHealthDescriptions>")
+		case "RegisterTargets":
+			f.registered = append(f.registered, targetIDs(r)...)
+			fmt.Fprintf(w, elbResponse, action, "")
+		case "DeregisterTargets":
+			f.deregistered = append(f.deregistered, targetIDs(r)...)
+			fmt.Fprintf(w, elbResponse, action, "")
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	case r.Method == http.MethodHead:
+		w.WriteHeader(f.ignitionStatus)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func newTestVerifier(fake *fakeAWS) (*InfraVerifier, *awsClients, *record.FakeRecorder, *httptest.Server) {
+	server := httptest.NewServer(fake)
+	s := session.Must(session.NewSession(&aws.Config{
+		Region:           aws.String("us-east-1"),
+		Endpoint:         aws.String(server.URL),
+		Credentials:      credentials.NewStaticCredentials("key", "secret", ""),
+		S3ForcePathStyle: aws.Bool(true),
+		MaxRetries:       aws.Int(0),
+	}))
+	recorder := record.NewFakeRecorder(10)
+	verifier := &InfraVerifier{
+		Namespace: "test",
+		Recorder:  recorder,
+		Log:       ctrl.Log.WithName("test"),
+	}
+	return verifier, &awsClients{elb: elbv2.New(s), route53: route53.New(s), s3: s3.New(s)}, recorder, server
+}
+
+func events(recorder *record.FakeRecorder) []string {
+	result := []string{}
+	for {
+		select {
+		case event := <-recorder.Events:
+			result = append(result, event)
+		default:
+			return result
+		}
+	}
+}
+
+func TestVerifyDNSRecord(t *testing.T) {
+	tests := []struct {
+		name            string
+		record          string
+		records         map[string]string
+		repair          bool
+		expectedEvent   string
+		expectedChanges int
+	}{
+		{
+			name:    "record matches",
+			record:  "api.test.example.com",
+			records: map[string]string{"api.test.example.com.": testLBDNS + "."},
+		},
+		{
+			name:    "wildcard record matches",
+			record:  "*.apps.test.example.com",
+			records: map[string]string{"*.apps.test.example.com.": testLBDNS},
+		},
+		{
+			name:            "record missing",
+			record:          "api.test.example.com",
+			records:         map[string]string{},
+			repair:          true,
+			expectedEvent:   "Warning DNSRecordMissing",
+			expectedChanges: 1,
+		},
+		{
+			name:          "record missing without repair",
+			record:        "api.test.example.com",
+			records:       map[string]string{},
+			expectedEvent: "Warning DNSRecordMissing",
+		},
+		{
+			name:            "record misconfigured",
+			record:          "api.test.example.com",
+			records:         map[string]string{"api.test.example.com.": "other.elb.amazonaws.com"},
+			repair:          true,
+			expectedEvent:   "Warning DNSRecordMisconfigured",
+			expectedChanges: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := &fakeAWS{records: test.records}
+			verifier, clients, recorder, server := newTestVerifier(fake)
+			defer server.Close()
+			infra := &InfraConfig{DNSZoneID: testZoneID, Repair: test.repair}
+			if err := verifier.verifyDNSRecord(clients, infra, DNSRecord{Name: test.record}, testLBDNS); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			recorded := events(recorder)
+			if test.expectedEvent == "" && len(recorded) > 0 {
+				t.Errorf("expected no events, got %v", recorded)
+			}
+			if test.expectedEvent != "" && (len(recorded) != 1 || !strings.HasPrefix(recorded[0], test.expectedEvent)) {
+				t.Errorf("expected event %q, got %v", test.expectedEvent, recorded)
+			}
+			if len(fake.changes) != test.expectedChanges {
+				t.Errorf("expected %d record changes, got %v", test.expectedChanges, fake.changes)
+			}
+		})
+	}
+}
+
+func TestVerifyTargetGroup(t *testing.T) {
+	tests := []struct {
+		name                 string
+		registered           []string
+		expected             []string
+		repair               bool
+		expectedEvents       int
+		expectedRegistered   []string
+		expectedDeregistered []string
+	}{
+		{
+			name:       "targets match",
+			registered: []string{"10.0.0.1", "10.0.0.2"},
+			expected:   []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name:       "targets managed by others",
+			registered: []string{"10.0.0.1"},
+		},
+		{
+			name:               "target missing",
+			registered:         []string{"10.0.0.1"},
+			expected:           []string{"10.0.0.1", "10.0.0.2"},
+			repair:             true,
+			expectedEvents:     1,
+			expectedRegistered: []string{"10.0.0.2"},
+		},
+		{
+			name:                 "machine replaced",
+			registered:           []string{"10.0.0.1", "10.0.0.3"},
+			expected:             []string{"10.0.0.1", "10.0.0.2"},
+			repair:               true,
+			expectedEvents:       2,
+			expectedRegistered:   []string{"10.0.0.2"},
+			expectedDeregistered: []string{"10.0.0.3"},
+		},
+		{
+			name:           "machine replaced without repair",
+			registered:     []string{"10.0.0.1", "10.0.0.3"},
+			expected:       []string{"10.0.0.1", "10.0.0.2"},
+			expectedEvents: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := &fakeAWS{targets: test.registered}
+			verifier, clients, recorder, server := newTestVerifier(fake)
+			defer server.Close()
+			infra := &InfraConfig{Repair: test.repair}
+			tgARN, err := verifier.verifyTargetGroup(clients, infra, TargetGroup{Name: "api", Targets: test.expected})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tgARN != "arn:tg" {
+				t.Errorf("unexpected target group ARN %s", tgARN)
+			}
+			if recorded := events(recorder); len(recorded) != test.expectedEvents {
+				t.Errorf("expected %d events, got %v", test.expectedEvents, recorded)
+			}
+			if strings.Join(fake.registered, ",") != strings.Join(test.expectedRegistered, ",") {
+				t.Errorf("expected registered targets %v, got %v", test.expectedRegistered, fake.registered)
+			}
+			if strings.Join(fake.deregistered, ",") != strings.Join(test.expectedDeregistered, ",") {
+				t.Errorf("expected deregistered targets %v, got %v", test.expectedDeregistered, fake.deregistered)
+			}
+		})
+	}
+}
+
+func TestVerifyIgnitionObject(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		expectedEvent string
+		expectError   bool
+	}{
+		{name: "exists", status: http.StatusOK},
+		{name: "missing", status: http.StatusNotFound, expectedEvent: "Warning IgnitionObjectMissing"},
+		{name: "server error", status: http.StatusForbidden, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier, clients, recorder, server := newTestVerifier(&fakeAWS{ignitionStatus: test.status})
+			defer server.Close()
+			infra := &InfraConfig{IgnitionBucket: "bucket", IgnitionKey: "worker.ign"}
+			err := verifier.verifyIgnitionObject(clients, infra)
+			if test.expectError != (err != nil) {
+				t.Fatalf("expected error %t, got %v", test.expectError, err)
+			}
+			recorded := events(recorder)
+			if test.expectedEvent == "" && len(recorded) > 0 {
+				t.Errorf("expected no events, got %v", recorded)
+			}
+			if test.expectedEvent != "" && (len(recorded) != 1 || !strings.HasPrefix(recorded[0], test.expectedEvent)) {
+				t.Errorf("expected event %q, got %v", test.expectedEvent, recorded)
+			}
+		})
+	}
+}
+
+func TestRecordNames(t *testing.T) {
+	if actual := fqdn("api.example.com"); actual != "api.example.com." {
+		t.Errorf("unexpected fqdn %s", actual)
+	}
+	if actual := fqdn("api.example.com."); actual != "api.example.com." {
+		t.Errorf("unexpected fqdn %s", actual)
+	}
+	if actual := unescapeRecordName(`\052.apps.example.com.`); actual != "*.apps.example.com." {
+		t.Errorf("unexpected record name %s", actual)
+	}
+}
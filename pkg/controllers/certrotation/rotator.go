@@ -0,0 +1,433 @@
+package certrotation
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeclient "k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/pki/util"
+)
+
+// rotationThreshold is how long before expiry a certificate is regenerated
+var rotationThreshold = 30 * 24 * time.Hour
+
+const (
+	// signersSecret is the secret that holds the CA that signs the control plane certificates
+	signersSecret = "pki-ca"
+
+	// vpnSignerSecret is the secret that holds the CA that signs the VPN certificates. It
+	// only exists when the cluster has a VPN.
+	vpnSignerSecret = "openvpn-ca"
+
+	// vpnClientConfigMap holds the VPN client secret of the workers, which is applied to
+	// the target cluster as kube-system/openvpn-client
+	vpnClientConfigMap = "user-manifest-openvpn-client-secret"
+
+	// vpnClientNamespace and vpnClientName are the namespace and name of the secret and
+	// deployment of the VPN client in the target cluster
+	vpnClientNamespace = "kube-system"
+	vpnClientName      = "openvpn-client"
+)
+
+// rotatedSecrets maps the PKI secrets whose certificates are rotated to the deployments
+// that must be restarted to load new certificates. The etcd server and peer certificates
+// are not rotated, because the etcd operator does not reload them in running members.
+var rotatedSecrets = map[string][]string{
+	"kube-apiserver":                   {"kube-apiserver"},
+	"kube-apiserver-vpnclient-secret":  {"kube-apiserver"},
+	"kube-controller-manager":          {"kube-controller-manager"},
+	"openshift-apiserver":              {"openshift-apiserver"},
+	"openshift-controller-manager":     {"openshift-controller-manager", "cluster-policy-controller"},
+	"oauth-openshift":                  {"oauth-openshift"},
+	"ignition-server":                  {"ignition-server"},
+	"openvpn-server":                   {"openvpn-server"},
+	"service-network-admin-kubeconfig": {"cluster-version-operator", "kube-scheduler", "control-plane-operator"},
+	"etcd-client-tls":                  {"etcd-operator"},
+	"metrics-client":                   {"kube-apiserver", "kube-controller-manager"},
+}
+
+// CertRotator regenerates the certificates in the PKI secrets of a control plane
+// when they approach expiry and restarts the deployments that use them.
+type CertRotator struct {
+	// Client is a client of the management cluster
+	Client kubeclient.Interface
+
+	// TargetClient is a client of the target cluster
+	TargetClient kubeclient.Interface
+
+	// Lister lists secrets in the control plane namespace
+	Lister corelisters.SecretLister
+
+	// ConfigMapLister lists configmaps in the control plane namespace
+	ConfigMapLister corelisters.ConfigMapLister
+
+	// Namespace is the namespace where the control plane of the cluster
+	// lives on the management server
+	Namespace string
+
+	// Validity is the validity of regenerated certificates
+	Validity time.Duration
+
+	// Log is the logger for this controller
+	Log logr.Logger
+}
+
+func (r *CertRotator) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	if req.Namespace != r.Namespace {
+		return ctrl.Result{}, nil
+	}
+	if req.Name == vpnClientConfigMap {
+		return r.reconcileVPNClient()
+	}
+	deployments, managed := rotatedSecrets[req.Name]
+	if !managed {
+		return ctrl.Result{}, nil
+	}
+	controllerLog := r.Log.WithValues("secret", req.NamespacedName.String())
+
+	secret, err := r.Lister.Secrets(r.Namespace).Get(req.Name)
+	if errors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	signers, err := r.signers()
+	if errors.IsNotFound(err) {
+		controllerLog.Info("Signers secret not found, skipping rotation")
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	secret = secret.DeepCopy()
+	now := time.Now()
+	rotated, nextRotation, err := rotateSecret(secret, signers, r.Validity, now)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(rotated) > 0 {
+		controllerLog.Info("Rotating certificates", "keys", rotated)
+		if secret, err = r.Client.CoreV1().Secrets(r.Namespace).Update(secret); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	checksum := secretChecksum(secret)
+	for _, name := range deployments {
+		if err := r.restartDeployment(r.Client, r.Namespace, name, secret.Name, checksum, len(rotated) > 0); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return requeueAt(nextRotation, now), nil
+}
+
+// reconcileVPNClient rotates the worker VPN client certificate, which is kept in a
+// configmap that holds the secret applied to the target cluster. The rotated secret is
+// copied to the target cluster and the VPN client there is restarted.
+func (r *CertRotator) reconcileVPNClient() (ctrl.Result, error) {
+	controllerLog := r.Log.WithValues("configmap", vpnClientConfigMap)
+	cm, err := r.ConfigMapLister.ConfigMaps(r.Namespace).Get(vpnClientConfigMap)
+	if errors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	signers, err := r.signers()
+	if errors.IsNotFound(err) {
+		controllerLog.Info("Signers secret not found, skipping rotation")
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	secret := &corev1.Secret{}
+	if err := yaml.Unmarshal([]byte(cm.Data["data"]), secret); err != nil {
+		return ctrl.Result{}, fmt.Errorf("cannot parse VPN client secret: %v", err)
+	}
+	now := time.Now()
+	rotated, nextRotation, err := rotateSecret(secret, signers, r.Validity, now)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(rotated) > 0 {
+		controllerLog.Info("Rotating certificates", "keys", rotated)
+		secretBytes, err := yaml.Marshal(secret)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		cm = cm.DeepCopy()
+		cm.Data["data"] = string(secretBytes)
+		if _, err = r.Client.CoreV1().ConfigMaps(r.Namespace).Update(cm); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if err := r.syncTargetVPNClient(secret); err != nil {
+		return ctrl.Result{}, err
+	}
+	return requeueAt(nextRotation, now), nil
+}
+
+// syncTargetVPNClient updates the VPN client secret of the target cluster when it differs
+// from the given secret and restarts the VPN client to load it
+func (r *CertRotator) syncTargetVPNClient(secret *corev1.Secret) error {
+	current, err := r.TargetClient.CoreV1().Secrets(vpnClientNamespace).Get(vpnClientName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		// The secret is created with the other user manifests when the cluster is bootstrapped
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	checksum := secretChecksum(secret)
+	changed := secretChecksum(current) != checksum
+	if changed {
+		r.Log.Info("Updating VPN client secret in the target cluster")
+		current.Data = secret.Data
+		if _, err = r.TargetClient.CoreV1().Secrets(vpnClientNamespace).Update(current); err != nil {
+			return err
+		}
+	}
+	return r.restartDeployment(r.TargetClient, vpnClientNamespace, vpnClientName, vpnClientName, checksum, changed)
+}
+
+// signers returns the CAs that sign the certificates of the control plane. The VPN CA
+// is only included if the cluster has a VPN.
+func (r *CertRotator) signers() ([]*util.CA, error) {
+	secret, err := r.Lister.Secrets(r.Namespace).Get(signersSecret)
+	if err != nil {
+		return nil, err
+	}
+	rootCA, err := signerFromSecret(secret, "root-ca")
+	if err != nil {
+		return nil, err
+	}
+	signers := []*util.CA{rootCA}
+	secret, err = r.Lister.Secrets(r.Namespace).Get(vpnSignerSecret)
+	if errors.IsNotFound(err) {
+		return signers, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	vpnCA, err := signerFromSecret(secret, "openvpn-ca")
+	if err != nil {
+		return nil, err
+	}
+	return append(signers, vpnCA), nil
+}
+
+func signerFromSecret(secret *corev1.Secret, name string) (*util.CA, error) {
+	cert, err := util.PemToCertificate(secret.Data[name+".crt"])
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s certificate: %v", name, err)
+	}
+	key, err := util.PemToPrivateKey(secret.Data[name+".key"])
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s key: %v", name, err)
+	}
+	return &util.CA{Cert: cert, Key: key}, nil
+}
+
+// restartDeployment records the checksum of a secret in the pod template of a deployment
+// when its certificates were rotated or the recorded checksum is out of date
+func (r *CertRotator) restartDeployment(client kubeclient.Interface, namespace, name, secretName, checksum string, rotated bool) error {
+	deployment, err := client.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	annotation := fmt.Sprintf("hypershift.openshift.io/%s-checksum", secretName)
+	current, recorded := deployment.Spec.Template.ObjectMeta.Annotations[annotation]
+	if current == checksum || (!rotated && !recorded) {
+		return nil
+	}
+	if deployment.Spec.Template.ObjectMeta.Annotations == nil {
+		deployment.Spec.Template.ObjectMeta.Annotations = map[string]string{}
+	}
+	deployment.Spec.Template.ObjectMeta.Annotations[annotation] = checksum
+	r.Log.Info("Restarting deployment to load rotated certificates", "deployment", name, "secret", secretName)
+	_, err = client.AppsV1().Deployments(namespace).Update(deployment)
+	return err
+}
+
+// requeueAt returns a result that requeues at the time of the next rotation, if any
+func requeueAt(nextRotation, now time.Time) ctrl.Result {
+	result := ctrl.Result{}
+	if !nextRotation.IsZero() {
+		result.RequeueAfter = nextRotation.Sub(now)
+	}
+	return result
+}
+
+// rotateSecret regenerates the certificates in the secret that expire within the rotation
+// threshold. It returns the keys that were rotated and the time of the next rotation.
+func rotateSecret(secret *corev1.Secret, signers []*util.CA, validity time.Duration, now time.Time) ([]string, time.Time, error) {
+	var rotated []string
+	var nextRotation time.Time
+	updateNext := func(rotateAt time.Time) {
+		if nextRotation.IsZero() || rotateAt.Before(nextRotation) {
+			nextRotation = rotateAt
+		}
+	}
+	for _, key := range sortedKeys(secret.Data) {
+		switch {
+		case key == "kubeconfig":
+			kubeconfig, rotateAt, changed, err := rotateKubeconfig(secret.Data[key], signers, validity, now)
+			if err != nil {
+				return nil, time.Time{}, fmt.Errorf("cannot rotate kubeconfig in secret %s: %v", secret.Name, err)
+			}
+			if changed {
+				secret.Data[key] = kubeconfig
+				rotated = append(rotated, key)
+			}
+			if !rotateAt.IsZero() {
+				updateNext(rotateAt)
+			}
+		case strings.HasSuffix(key, ".crt"):
+			keyName := strings.TrimSuffix(key, ".crt") + ".key"
+			if _, hasKey := secret.Data[keyName]; !hasKey {
+				continue
+			}
+			certPEM, keyPEM, rotateAt, changed, err := rotateCert(secret.Data[key], signers, validity, now)
+			if err != nil {
+				return nil, time.Time{}, fmt.Errorf("cannot rotate %s in secret %s: %v", key, secret.Name, err)
+			}
+			if changed {
+				secret.Data[key] = certPEM
+				secret.Data[keyName] = keyPEM
+				rotated = append(rotated, key)
+			}
+			if !rotateAt.IsZero() {
+				updateNext(rotateAt)
+			}
+		}
+	}
+	return rotated, nextRotation, nil
+}
+
+// rotateKubeconfig regenerates the client certificates of a kubeconfig that expire
+// within the rotation threshold
+func rotateKubeconfig(data []byte, signers []*util.CA, validity time.Duration, now time.Time) ([]byte, time.Time, bool, error) {
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+	var nextRotation time.Time
+	changed := false
+	for _, authInfo := range config.AuthInfos {
+		if len(authInfo.ClientCertificateData) == 0 {
+			continue
+		}
+		certPEM, keyPEM, rotateAt, rotated, err := rotateCert(authInfo.ClientCertificateData, signers, validity, now)
+		if err != nil {
+			return nil, time.Time{}, false, err
+		}
+		if rotated {
+			authInfo.ClientCertificateData = certPEM
+			authInfo.ClientKeyData = keyPEM
+			changed = true
+		}
+		if !rotateAt.IsZero() && (nextRotation.IsZero() || rotateAt.Before(nextRotation)) {
+			nextRotation = rotateAt
+		}
+	}
+	if !changed {
+		return data, nextRotation, false, nil
+	}
+	result, err := clientcmd.Write(*config)
+	return result, nextRotation, true, err
+}
+
+// rotateCert regenerates a certificate with a new key if it expires within the rotation
+// threshold. The new certificate has the given validity and keeps the key type, key
+// size and signature algorithm of the original. It returns the time at which the resulting
+// certificate should be rotated. CA certificates are never rotated.
+func rotateCert(data []byte, signers []*util.CA, validity time.Duration, now time.Time) ([]byte, []byte, time.Time, bool, error) {
+	cert, err := util.PemToCertificate(data)
+	if err != nil {
+		return nil, nil, time.Time{}, false, err
+	}
+	if cert.IsCA {
+		return nil, nil, time.Time{}, false, nil
+	}
+	threshold := rotationThresholdFor(validity)
+	rotateAt := cert.NotAfter.Add(-threshold)
+	if now.Before(rotateAt) {
+		return nil, nil, rotateAt, false, nil
+	}
+	signer := signerFor(cert, signers)
+	if signer == nil {
+		return nil, nil, time.Time{}, false, fmt.Errorf("cannot find the signer of certificate %s", cert.Subject.CommonName)
+	}
+	cfg := &util.CertCfg{
+		Subject:            cert.Subject,
+		KeyUsages:          cert.KeyUsage,
+		ExtKeyUsages:       cert.ExtKeyUsage,
+		Validity:           validity,
+		DNSNames:           cert.DNSNames,
+		IPAddresses:        cert.IPAddresses,
+		SignatureAlgorithm: cert.SignatureAlgorithm,
+	}
+	if cfg.KeyType, cfg.KeySize, err = util.KeyTypeOf(cert.PublicKey); err != nil {
+		return nil, nil, time.Time{}, false, err
+	}
+	key, newCert, err := util.GenerateSignedCertificate(signer.Key, signer.Cert, cfg)
+	if err != nil {
+		return nil, nil, time.Time{}, false, err
+	}
+	return util.CertToPem(newCert), util.PrivateKeyToPem(key), newCert.NotAfter.Add(-threshold), true, nil
+}
+
+// rotationThresholdFor returns how long before expiry certificates with the given
+// validity are rotated, so that short lived certificates are not rotated continuously
+func rotationThresholdFor(validity time.Duration) time.Duration {
+	if validity/5 < rotationThreshold {
+		return validity / 5
+	}
+	return rotationThreshold
+}
+
+func signerFor(cert *x509.Certificate, signers []*util.CA) *util.CA {
+	for _, signer := range signers {
+		if cert.CheckSignatureFrom(signer.Cert) == nil {
+			return signer
+		}
+	}
+	return nil
+}
+
+func secretChecksum(secret *corev1.Secret) string {
+	hash := sha256.New()
+	for _, key := range sortedKeys(secret.Data) {
+		hash.Write([]byte(key))
+		hash.Write(secret.Data[key])
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+func sortedKeys(data map[string][]byte) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
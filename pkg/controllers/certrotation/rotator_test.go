@@ -0,0 +1,225 @@
+package certrotation
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/hypershift-toolkit/pkg/pki/util"
+)
+
+func generateTestCA(t *testing.T, name string) *util.CA {
+	ca, err := util.GenerateCA(name, "test", util.CertOptions{KeySize: 1024})
+	if err != nil {
+		t.Fatalf("cannot generate CA: %v", err)
+	}
+	return ca
+}
+
+func generateTestCert(t *testing.T, ca *util.CA, validity time.Duration) *util.Cert {
+	cert, err := util.GenerateCert("test", "test", []string{"test.example.com"}, nil, ca, util.CertOptions{
+		Validity:           validity,
+		KeySize:            1024,
+		SignatureAlgorithm: x509.SHA384WithRSA,
+	})
+	if err != nil {
+		t.Fatalf("cannot generate certificate: %v", err)
+	}
+	return cert
+}
+
+func TestRotateCert(t *testing.T) {
+	rootCA := generateTestCA(t, "root-ca")
+	vpnCA := generateTestCA(t, "openvpn-ca")
+	otherCA := generateTestCA(t, "other-ca")
+	signers := []*util.CA{rootCA, vpnCA}
+	validity := 10 * util.ValidityOneDay
+
+	tests := []struct {
+		name          string
+		cert          []byte
+		now           time.Time
+		expectRotated bool
+		expectError   bool
+		expectSigner  *util.CA
+	}{
+		{
+			name: "not expiring",
+			cert: util.CertToPem(generateTestCert(t, rootCA, validity).Cert),
+			now:  time.Now(),
+		},
+		{
+			name:          "within threshold",
+			cert:          util.CertToPem(generateTestCert(t, rootCA, validity).Cert),
+			now:           time.Now().Add(9 * util.ValidityOneDay),
+			expectRotated: true,
+			expectSigner:  rootCA,
+		},
+		{
+			name:          "expired",
+			cert:          util.CertToPem(generateTestCert(t, vpnCA, validity).Cert),
+			now:           time.Now().Add(11 * util.ValidityOneDay),
+			expectRotated: true,
+			expectSigner:  vpnCA,
+		},
+		{
+			name: "CA certificate",
+			cert: util.CertToPem(rootCA.Cert),
+			now:  time.Now().Add(20 * util.ValidityOneYear),
+		},
+		{
+			name:        "unknown signer",
+			cert:        util.CertToPem(generateTestCert(t, otherCA, validity).Cert),
+			now:         time.Now().Add(11 * util.ValidityOneDay),
+			expectError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			original, err := util.PemToCertificate(test.cert)
+			if err != nil {
+				t.Fatalf("cannot parse certificate: %v", err)
+			}
+			certPEM, keyPEM, rotateAt, rotated, err := rotateCert(test.cert, signers, validity, test.now)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rotated != test.expectRotated {
+				t.Fatalf("expected rotated %t, got %t", test.expectRotated, rotated)
+			}
+			if original.IsCA {
+				if !rotateAt.IsZero() {
+					t.Errorf("expected no rotation time for a CA certificate, got %v", rotateAt)
+				}
+				return
+			}
+			if !rotated {
+				if expected := original.NotAfter.Add(-2 * util.ValidityOneDay); !rotateAt.Equal(expected) {
+					t.Errorf("expected rotation at %v, got %v", expected, rotateAt)
+				}
+				return
+			}
+			cert, err := util.PemToCertificate(certPEM)
+			if err != nil {
+				t.Fatalf("cannot parse rotated certificate: %v", err)
+			}
+			key, err := util.PemToPrivateKey(keyPEM)
+			if err != nil {
+				t.Fatalf("cannot parse rotated key: %v", err)
+			}
+			if err := cert.CheckSignatureFrom(test.expectSigner.Cert); err != nil {
+				t.Errorf("rotated certificate is not signed by the original CA: %v", err)
+			}
+			rsaKey := key.(*rsa.PrivateKey)
+			if cert.PublicKey.(*rsa.PublicKey).N.Cmp(rsaKey.N) != 0 {
+				t.Errorf("rotated key does not match the rotated certificate")
+			}
+			if rsaKey.N.Cmp(original.PublicKey.(*rsa.PublicKey).N) == 0 {
+				t.Errorf("expected a new key")
+			}
+			if rsaKey.N.BitLen() != 1024 || cert.SignatureAlgorithm != x509.SHA384WithRSA {
+				t.Errorf("expected key size and signature algorithm to be kept, got %d and %v", rsaKey.N.BitLen(), cert.SignatureAlgorithm)
+			}
+			if cert.Subject.CommonName != original.Subject.CommonName || len(cert.DNSNames) != 1 || cert.DNSNames[0] != "test.example.com" {
+				t.Errorf("expected subject and DNS names to be kept, got %v and %v", cert.Subject, cert.DNSNames)
+			}
+			if expected := cert.NotAfter.Add(-2 * util.ValidityOneDay); !rotateAt.Equal(expected) {
+				t.Errorf("expected rotation at %v, got %v", expected, rotateAt)
+			}
+		})
+	}
+}
+
+func TestRotateECDSACert(t *testing.T) {
+	rootCA, err := util.GenerateCA("root-ca", "test", util.CertOptions{KeyType: util.KeyTypeECDSAP384})
+	if err != nil {
+		t.Fatalf("cannot generate CA: %v", err)
+	}
+	validity := 10 * util.ValidityOneDay
+	original, err := util.GenerateCert("test", "test", nil, nil, rootCA, util.CertOptions{Validity: validity, KeyType: util.KeyTypeECDSAP256})
+	if err != nil {
+		t.Fatalf("cannot generate certificate: %v", err)
+	}
+	certPEM, keyPEM, _, rotated, err := rotateCert(util.CertToPem(original.Cert), []*util.CA{rootCA}, validity, time.Now().Add(9*util.ValidityOneDay))
+	if err != nil || !rotated {
+		t.Fatalf("expected the certificate to be rotated, got %t and %v", rotated, err)
+	}
+	cert, err := util.PemToCertificate(certPEM)
+	if err != nil {
+		t.Fatalf("cannot parse rotated certificate: %v", err)
+	}
+	if err := cert.CheckSignatureFrom(rootCA.Cert); err != nil {
+		t.Errorf("rotated certificate is not signed by the CA: %v", err)
+	}
+	key, err := util.PemToPrivateKey(keyPEM)
+	if err != nil {
+		t.Fatalf("cannot parse rotated key: %v", err)
+	}
+	if keyType, _, _ := util.KeyTypeOf(key.Public()); keyType != util.KeyTypeECDSAP256 {
+		t.Errorf("expected the key type to be kept, got %s", keyType)
+	}
+}
+
+func TestRotateSecret(t *testing.T) {
+	rootCA := generateTestCA(t, "root-ca")
+	validity := 10 * util.ValidityOneDay
+	shortLived := generateTestCert(t, rootCA, 5*util.ValidityOneDay)
+	longLived := generateTestCert(t, rootCA, 100*util.ValidityOneDay)
+	secret := &corev1.Secret{
+		Data: map[string][]byte{
+			"server.crt": util.CertToPem(shortLived.Cert),
+			"server.key": util.PrivateKeyToPem(shortLived.Key),
+			"client.crt": util.CertToPem(longLived.Cert),
+			"client.key": util.PrivateKeyToPem(longLived.Key),
+			"ca.crt":     util.CertToPem(rootCA.Cert),
+		},
+	}
+	now := time.Now().Add(6 * util.ValidityOneDay)
+	rotated, nextRotation, err := rotateSecret(secret, []*util.CA{rootCA}, validity, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rotated) != 1 || rotated[0] != "server.crt" {
+		t.Fatalf("expected only server.crt to be rotated, got %v", rotated)
+	}
+	if string(secret.Data["client.crt"]) != string(util.CertToPem(longLived.Cert)) {
+		t.Errorf("expected client.crt to be kept")
+	}
+	if string(secret.Data["ca.crt"]) != string(util.CertToPem(rootCA.Cert)) {
+		t.Errorf("expected the CA certificate to be kept")
+	}
+	cert, err := util.PemToCertificate(secret.Data["server.crt"])
+	if err != nil {
+		t.Fatalf("cannot parse rotated certificate: %v", err)
+	}
+	if err := cert.CheckSignatureFrom(rootCA.Cert); err != nil {
+		t.Errorf("rotated certificate is not signed by the CA: %v", err)
+	}
+	if expected := cert.NotAfter.Add(-2 * util.ValidityOneDay); !nextRotation.Equal(expected) {
+		t.Errorf("expected next rotation at %v, got %v", expected, nextRotation)
+	}
+}
+
+func TestRotationThresholdFor(t *testing.T) {
+	tests := []struct {
+		validity time.Duration
+		expected time.Duration
+	}{
+		{validity: util.ValidityOneYear, expected: rotationThreshold},
+		{validity: 10 * util.ValidityOneDay, expected: 2 * util.ValidityOneDay},
+	}
+	for _, test := range tests {
+		if actual := rotationThresholdFor(test.validity); actual != test.expected {
+			t.Errorf("expected threshold %v for validity %v, got %v", test.expected, test.validity, actual)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package certrotation
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/openshift/hypershift-toolkit/pkg/cmd/cpoperator"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers"
+)
+
+func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
+	secrets := cfg.KubeInformers().Core().V1().Secrets()
+	configMaps := cfg.KubeInformers().Core().V1().ConfigMaps()
+	reconciler := &CertRotator{
+		Client:          cfg.KubeClient(),
+		TargetClient:    cfg.TargetKubeClient(),
+		Lister:          secrets.Lister(),
+		ConfigMapLister: configMaps.Lister(),
+		Namespace:       cfg.Namespace(),
+		Validity:        cfg.CertValidity(),
+		Log:             cfg.Logger().WithName("CertRotator"),
+	}
+	c, err := controllers.NewController("cert-rotation", cfg.Manager(), reconciler, cfg.Logger().WithName("cert-rotation"))
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for name := range rotatedSecrets {
+		names = append(names, name)
+	}
+	if err := c.Watch(&source.Informer{Informer: secrets.Informer()}, controllers.NamedResourceHandler(names...)); err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Informer{Informer: configMaps.Informer()}, controllers.NamedResourceHandler(vpnClientConfigMap)); err != nil {
+		return err
+	}
+	return nil
+}
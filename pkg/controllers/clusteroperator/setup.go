@@ -1,11 +1,11 @@
 package clusteroperator
 
 import (
-	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/openshift/hypershift-toolkit/pkg/cmd/cpoperator"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers"
 )
 
 func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
@@ -16,7 +16,7 @@ func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
 		Lister:   clusterOperators.Lister(),
 		Log:      cfg.Logger().WithName("ControlPlaneClusterOperatorSyncer"),
 	}
-	c, err := controller.New("cluster-operator-syncer", cfg.Manager(), controller.Options{Reconciler: reconciler})
+	c, err := controllers.NewController("cluster-operator-syncer", cfg.Manager(), reconciler, cfg.Logger().WithName("cluster-operator-syncer"))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,395 @@
+package clusterstatus
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/yaml"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configclient "github.com/openshift/client-go/config/clientset/versioned"
+)
+
+const (
+	// StatusConfigMap is the configmap in the control plane namespace that holds the
+	// conditions of the hosted cluster
+	StatusConfigMap = "hosted-cluster-status"
+
+	// conditionsKey is the key of the conditions in StatusConfigMap
+	conditionsKey = "conditions"
+
+	// syncInterval is the amount of time between status checks
+	syncInterval = time.Minute
+
+	// openshiftAPIServerService is the service of the target cluster that the APIServices
+	// of the OpenShift APIs point to
+	openshiftAPIServerService = "openshift-apiserver"
+
+	// openshiftControllerManagerDeployment is the deployment of the
+	// openshift-controller-manager in the control plane namespace
+	openshiftControllerManagerDeployment = "openshift-controller-manager"
+)
+
+// apiServiceResource is the resource of the APIServices of the target cluster
+var apiServiceResource = schema.GroupVersionResource{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"}
+
+// ConditionType is the type of a hosted cluster condition
+type ConditionType string
+
+const (
+	// APIServerReachable is true when the kube-apiserver of the cluster is healthy
+	APIServerReachable ConditionType = "APIServerReachable"
+
+	// EtcdHealthy is true when the kube-apiserver can reach etcd
+	EtcdHealthy ConditionType = "EtcdHealthy"
+
+	// NodesReady is true when the cluster has nodes and all of them are ready
+	NodesReady ConditionType = "NodesReady"
+
+	// ClusterOperatorsAvailable is true when every cluster operator is available
+	ClusterOperatorsAvailable ConditionType = "ClusterOperatorsAvailable"
+
+	// OpenShiftAPIServerAvailable is true when the APIServices of the OpenShift APIs, which
+	// the openshift-apiserver of the control plane serves, are available
+	OpenShiftAPIServerAvailable ConditionType = "OpenShiftAPIServerAvailable"
+
+	// OpenShiftControllerManagerAvailable is true when the openshift-controller-manager
+	// deployment of the control plane is available
+	OpenShiftControllerManagerAvailable ConditionType = "OpenShiftControllerManagerAvailable"
+
+	// Available is true when all other conditions are true
+	Available ConditionType = "Available"
+
+	// TunnelHealthy is true when the kube-apiserver reaches the kubelets of the cluster
+	// through its VPN or konnectivity tunnel. It is reported by the tunnel-health controller
+	// and not summarized by Available.
+	TunnelHealthy ConditionType = "TunnelHealthy"
+)
+
+// Condition is an aspect of the health of a hosted cluster
+type Condition struct {
+	Type               ConditionType          `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime"`
+}
+
+// StatusReporter periodically checks the health of the components of a hosted cluster
+// and records it as conditions in StatusConfigMap on the management cluster.
+type StatusReporter struct {
+	// Client is a client of the management cluster
+	Client kubeclient.Interface
+
+	// TargetClient is a client of the target cluster
+	TargetClient kubeclient.Interface
+
+	// TargetConfigClient is a config client of the target cluster
+	TargetConfigClient configclient.Interface
+
+	// TargetDynamicClient is a dynamic client of the target cluster, which reads its
+	// APIServices
+	TargetDynamicClient dynamic.Interface
+
+	// Namespace is the namespace where the control plane of the cluster
+	// lives on the management server
+	Namespace string
+
+	// Log is the logger for this controller
+	Log logr.Logger
+}
+
+// Run performs a single status check, logging any error
+func (r *StatusReporter) Run() {
+	if err := r.Report(); err != nil {
+		r.Log.Error(err, "failed to report hosted cluster status")
+	}
+}
+
+// Report checks the health of the cluster and updates its conditions
+func (r *StatusReporter) Report() error {
+	cm, err := r.Client.CoreV1().ConfigMaps(r.Namespace).Get(StatusConfigMap, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+	if !exists {
+		cm = &corev1.ConfigMap{}
+		cm.Name = StatusConfigMap
+		cm.Namespace = r.Namespace
+	}
+	var conditions []Condition
+	if exists && len(cm.Data[conditionsKey]) > 0 {
+		if err := yaml.Unmarshal([]byte(cm.Data[conditionsKey]), &conditions); err != nil {
+			r.Log.Error(err, "ignoring invalid conditions", "configmap", StatusConfigMap)
+			conditions = nil
+		}
+	}
+
+	now := metav1.Now()
+	checks := []Condition{r.apiServerReachable()}
+	if checks[0].Status == corev1.ConditionTrue {
+		checks = append(checks, r.etcdHealthy(), r.nodesReady(), r.clusterOperatorsAvailable(), r.openShiftAPIServerAvailable())
+	} else {
+		// The other components cannot be checked without the kube-apiserver
+		for _, conditionType := range []ConditionType{EtcdHealthy, NodesReady, ClusterOperatorsAvailable, OpenShiftAPIServerAvailable} {
+			checks = append(checks, Condition{Type: conditionType, Status: corev1.ConditionUnknown, Reason: "APIServerUnreachable"})
+		}
+	}
+	checks = append(checks, r.openShiftControllerManagerAvailable())
+	checks = append(checks, available(checks))
+	for _, condition := range checks {
+		conditions = setCondition(conditions, condition, now)
+	}
+
+	b, err := yaml.Marshal(conditions)
+	if err != nil {
+		return err
+	}
+	if exists && cm.Data[conditionsKey] == string(b) {
+		return nil
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[conditionsKey] = string(b)
+	if !exists {
+		_, err = r.Client.CoreV1().ConfigMaps(r.Namespace).Create(cm)
+		return err
+	}
+	_, err = r.Client.CoreV1().ConfigMaps(r.Namespace).Update(cm)
+	return err
+}
+
+func (r *StatusReporter) apiServerReachable() Condition {
+	return r.healthCheck(APIServerReachable, "/healthz")
+}
+
+func (r *StatusReporter) etcdHealthy() Condition {
+	return r.healthCheck(EtcdHealthy, "/healthz/etcd")
+}
+
+func (r *StatusReporter) healthCheck(conditionType ConditionType, path string) Condition {
+	err := r.TargetClient.Discovery().RESTClient().Get().AbsPath(path).Do().Error()
+	if err != nil {
+		return Condition{Type: conditionType, Status: corev1.ConditionFalse, Reason: "HealthCheckFailed", Message: err.Error()}
+	}
+	return Condition{Type: conditionType, Status: corev1.ConditionTrue, Reason: "HealthCheckSucceeded"}
+}
+
+func (r *StatusReporter) nodesReady() Condition {
+	nodes, err := r.TargetClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return Condition{Type: NodesReady, Status: corev1.ConditionUnknown, Reason: "ListFailed", Message: err.Error()}
+	}
+	return nodesReadyCondition(nodes.Items)
+}
+
+func (r *StatusReporter) clusterOperatorsAvailable() Condition {
+	operators, err := r.TargetConfigClient.ConfigV1().ClusterOperators().List(metav1.ListOptions{})
+	if err != nil {
+		return Condition{Type: ClusterOperatorsAvailable, Status: corev1.ConditionUnknown, Reason: "ListFailed", Message: err.Error()}
+	}
+	return clusterOperatorsCondition(operators.Items)
+}
+
+func (r *StatusReporter) openShiftAPIServerAvailable() Condition {
+	apiServices, err := r.TargetDynamicClient.Resource(apiServiceResource).List(metav1.ListOptions{})
+	if err != nil {
+		return Condition{Type: OpenShiftAPIServerAvailable, Status: corev1.ConditionUnknown, Reason: "ListFailed", Message: err.Error()}
+	}
+	return openShiftAPIServicesCondition(apiServices.Items)
+}
+
+func (r *StatusReporter) openShiftControllerManagerAvailable() Condition {
+	deployment, err := r.Client.AppsV1().Deployments(r.Namespace).Get(openshiftControllerManagerDeployment, metav1.GetOptions{})
+	if err != nil {
+		return Condition{Type: OpenShiftControllerManagerAvailable, Status: corev1.ConditionUnknown, Reason: "GetFailed", Message: err.Error()}
+	}
+	return deploymentCondition(OpenShiftControllerManagerAvailable, deployment)
+}
+
+func nodesReadyCondition(nodes []corev1.Node) Condition {
+	if len(nodes) == 0 {
+		return Condition{Type: NodesReady, Status: corev1.ConditionFalse, Reason: "NoNodes", Message: "The cluster has no nodes"}
+	}
+	var notReady []string
+	for _, node := range nodes {
+		ready := false
+		for _, c := range node.Status.Conditions {
+			if c.Type == corev1.NodeReady && c.Status == corev1.ConditionTrue {
+				ready = true
+			}
+		}
+		if !ready {
+			notReady = append(notReady, node.Name)
+		}
+	}
+	if len(notReady) > 0 {
+		sort.Strings(notReady)
+		return Condition{Type: NodesReady, Status: corev1.ConditionFalse, Reason: "NodesNotReady",
+			Message: fmt.Sprintf("%d of %d nodes are not ready: %s", len(notReady), len(nodes), strings.Join(notReady, ", "))}
+	}
+	return Condition{Type: NodesReady, Status: corev1.ConditionTrue, Reason: "AllNodesReady",
+		Message: fmt.Sprintf("%d nodes are ready", len(nodes))}
+}
+
+func clusterOperatorsCondition(operators []configv1.ClusterOperator) Condition {
+	var unavailable []string
+	for _, co := range operators {
+		isAvailable := false
+		for _, c := range co.Status.Conditions {
+			if c.Type == configv1.OperatorAvailable && c.Status == configv1.ConditionTrue {
+				isAvailable = true
+			}
+		}
+		if !isAvailable {
+			unavailable = append(unavailable, co.Name)
+		}
+	}
+	if len(unavailable) > 0 {
+		sort.Strings(unavailable)
+		return Condition{Type: ClusterOperatorsAvailable, Status: corev1.ConditionFalse, Reason: "ClusterOperatorsUnavailable",
+			Message: fmt.Sprintf("Unavailable cluster operators: %s", strings.Join(unavailable, ", "))}
+	}
+	return Condition{Type: ClusterOperatorsAvailable, Status: corev1.ConditionTrue, Reason: "AllClusterOperatorsAvailable",
+		Message: fmt.Sprintf("%d cluster operators are available", len(operators))}
+}
+
+// openShiftAPIServicesCondition checks the APIServices that the openshift-apiserver of the
+// control plane serves through the openshift-apiserver service of the target cluster
+func openShiftAPIServicesCondition(apiServices []unstructured.Unstructured) Condition {
+	var unavailable []string
+	count := 0
+	for _, apiService := range apiServices {
+		namespace, _, _ := unstructured.NestedString(apiService.Object, "spec", "service", "namespace")
+		name, _, _ := unstructured.NestedString(apiService.Object, "spec", "service", "name")
+		if namespace != "default" || name != openshiftAPIServerService {
+			continue
+		}
+		count++
+		conditions, _, _ := unstructured.NestedSlice(apiService.Object, "status", "conditions")
+		isAvailable := false
+		for _, item := range conditions {
+			c, ok := item.(map[string]interface{})
+			if ok && c["type"] == "Available" && c["status"] == string(corev1.ConditionTrue) {
+				isAvailable = true
+			}
+		}
+		if !isAvailable {
+			unavailable = append(unavailable, apiService.GetName())
+		}
+	}
+	if count == 0 {
+		return Condition{Type: OpenShiftAPIServerAvailable, Status: corev1.ConditionFalse, Reason: "NoAPIServices", Message: "The cluster has no APIServices of the OpenShift APIs"}
+	}
+	if len(unavailable) > 0 {
+		sort.Strings(unavailable)
+		return Condition{Type: OpenShiftAPIServerAvailable, Status: corev1.ConditionFalse, Reason: "APIServicesUnavailable",
+			Message: fmt.Sprintf("Unavailable APIServices: %s", strings.Join(unavailable, ", "))}
+	}
+	return Condition{Type: OpenShiftAPIServerAvailable, Status: corev1.ConditionTrue, Reason: "AllAPIServicesAvailable",
+		Message: fmt.Sprintf("%d APIServices are available", count)}
+}
+
+// deploymentCondition checks the Available condition of a control plane deployment
+func deploymentCondition(conditionType ConditionType, deployment *appsv1.Deployment) Condition {
+	for _, c := range deployment.Status.Conditions {
+		if c.Type != appsv1.DeploymentAvailable {
+			continue
+		}
+		if c.Status == corev1.ConditionTrue {
+			return Condition{Type: conditionType, Status: corev1.ConditionTrue, Reason: "DeploymentAvailable",
+				Message: fmt.Sprintf("%d of %d replicas are available", deployment.Status.AvailableReplicas, deployment.Status.Replicas)}
+		}
+		return Condition{Type: conditionType, Status: corev1.ConditionFalse, Reason: "DeploymentUnavailable", Message: c.Message}
+	}
+	return Condition{Type: conditionType, Status: corev1.ConditionFalse, Reason: "DeploymentUnavailable",
+		Message: fmt.Sprintf("Deployment %s has no Available condition", deployment.Name)}
+}
+
+// available summarizes the other conditions of the cluster
+func available(conditions []Condition) Condition {
+	var failing []string
+	for _, c := range conditions {
+		if c.Status != corev1.ConditionTrue {
+			failing = append(failing, string(c.Type))
+		}
+	}
+	if len(failing) > 0 {
+		return Condition{Type: Available, Status: corev1.ConditionFalse, Reason: "ComponentsUnhealthy",
+			Message: fmt.Sprintf("Conditions not met: %s", strings.Join(failing, ", "))}
+	}
+	return Condition{Type: Available, Status: corev1.ConditionTrue, Reason: "AllComponentsHealthy"}
+}
+
+// UpdateCondition sets a condition reported by another controller than the status reporter
+// in StatusConfigMap, which the status reporter keeps
+func UpdateCondition(client kubeclient.Interface, namespace string, condition Condition) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := client.CoreV1().ConfigMaps(namespace).Get(StatusConfigMap, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		exists := err == nil
+		if !exists {
+			cm = &corev1.ConfigMap{}
+			cm.Name = StatusConfigMap
+			cm.Namespace = namespace
+		}
+		var conditions []Condition
+		if exists && len(cm.Data[conditionsKey]) > 0 {
+			if err := yaml.Unmarshal([]byte(cm.Data[conditionsKey]), &conditions); err != nil {
+				conditions = nil
+			}
+		}
+		conditions = setCondition(conditions, condition, metav1.Now())
+		b, err := yaml.Marshal(conditions)
+		if err != nil {
+			return err
+		}
+		if exists && cm.Data[conditionsKey] == string(b) {
+			return nil
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[conditionsKey] = string(b)
+		if !exists {
+			_, err = client.CoreV1().ConfigMaps(namespace).Create(cm)
+			return err
+		}
+		_, err = client.CoreV1().ConfigMaps(namespace).Update(cm)
+		return err
+	})
+}
+
+// setCondition adds or replaces the condition of the same type, keeping its last
+// transition time when the status did not change
+func setCondition(conditions []Condition, condition Condition, now metav1.Time) []Condition {
+	condition.LastTransitionTime = now
+	for i, existing := range conditions {
+		if existing.Type != condition.Type {
+			continue
+		}
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+		conditions[i] = condition
+		return conditions
+	}
+	return append(conditions, condition)
+}
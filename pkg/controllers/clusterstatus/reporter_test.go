@@ -0,0 +1,125 @@
+package clusterstatus
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func TestSetCondition(t *testing.T) {
+	first := metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	second := metav1.NewTime(first.Add(time.Minute))
+
+	conditions := setCondition(nil, Condition{Type: NodesReady, Status: corev1.ConditionFalse}, first)
+	conditions = setCondition(conditions, Condition{Type: NodesReady, Status: corev1.ConditionFalse, Message: "changed"}, second)
+	if len(conditions) != 1 || !conditions[0].LastTransitionTime.Equal(&first) || conditions[0].Message != "changed" {
+		t.Errorf("expected the transition time to be kept without a status change, got %v", conditions)
+	}
+	conditions = setCondition(conditions, Condition{Type: NodesReady, Status: corev1.ConditionTrue}, second)
+	if len(conditions) != 1 || !conditions[0].LastTransitionTime.Equal(&second) {
+		t.Errorf("expected the transition time to be updated with a status change, got %v", conditions)
+	}
+	conditions = setCondition(conditions, Condition{Type: Available, Status: corev1.ConditionTrue}, second)
+	if len(conditions) != 2 {
+		t.Errorf("expected a new condition to be added, got %v", conditions)
+	}
+}
+
+func TestNodesReadyCondition(t *testing.T) {
+	node := func(name string, ready corev1.ConditionStatus) corev1.Node {
+		n := corev1.Node{}
+		n.Name = name
+		n.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}
+		return n
+	}
+	tests := []struct {
+		name   string
+		nodes  []corev1.Node
+		status corev1.ConditionStatus
+	}{
+		{name: "no nodes", status: corev1.ConditionFalse},
+		{name: "ready", nodes: []corev1.Node{node("a", corev1.ConditionTrue), node("b", corev1.ConditionTrue)}, status: corev1.ConditionTrue},
+		{name: "not ready", nodes: []corev1.Node{node("a", corev1.ConditionTrue), node("b", corev1.ConditionUnknown)}, status: corev1.ConditionFalse},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if c := nodesReadyCondition(test.nodes); c.Status != test.status {
+				t.Errorf("expected status %s, got %s: %s", test.status, c.Status, c.Message)
+			}
+		})
+	}
+}
+
+func TestClusterOperatorsCondition(t *testing.T) {
+	operator := func(name string, status configv1.ConditionStatus) configv1.ClusterOperator {
+		co := configv1.ClusterOperator{}
+		co.Name = name
+		co.Status.Conditions = []configv1.ClusterOperatorStatusCondition{{Type: configv1.OperatorAvailable, Status: status}}
+		return co
+	}
+	c := clusterOperatorsCondition([]configv1.ClusterOperator{operator("dns", configv1.ConditionTrue), operator("ingress", configv1.ConditionFalse)})
+	if c.Status != corev1.ConditionFalse || c.Message != "Unavailable cluster operators: ingress" {
+		t.Errorf("unexpected condition %v", c)
+	}
+	c = clusterOperatorsCondition([]configv1.ClusterOperator{operator("dns", configv1.ConditionTrue)})
+	if c.Status != corev1.ConditionTrue {
+		t.Errorf("unexpected condition %v", c)
+	}
+}
+
+func TestOpenShiftAPIServicesCondition(t *testing.T) {
+	apiService := func(name, service string, available string) unstructured.Unstructured {
+		a := unstructured.Unstructured{Object: map[string]interface{}{}}
+		a.SetName(name)
+		if len(service) > 0 {
+			unstructured.SetNestedField(a.Object, "default", "spec", "service", "namespace")
+			unstructured.SetNestedField(a.Object, service, "spec", "service", "name")
+		}
+		unstructured.SetNestedSlice(a.Object, []interface{}{
+			map[string]interface{}{"type": "Available", "status": available},
+		}, "status", "conditions")
+		return a
+	}
+	tests := []struct {
+		name        string
+		apiServices []unstructured.Unstructured
+		status      corev1.ConditionStatus
+	}{
+		{name: "no APIServices", apiServices: []unstructured.Unstructured{apiService("v1.", "", "True")}, status: corev1.ConditionFalse},
+		{
+			name:        "available",
+			apiServices: []unstructured.Unstructured{apiService("v1.apps.openshift.io", "openshift-apiserver", "True"), apiService("v1beta1.metrics.k8s.io", "prometheus-adapter", "False")},
+			status:      corev1.ConditionTrue,
+		},
+		{
+			name:        "unavailable",
+			apiServices: []unstructured.Unstructured{apiService("v1.apps.openshift.io", "openshift-apiserver", "True"), apiService("v1.route.openshift.io", "openshift-apiserver", "False")},
+			status:      corev1.ConditionFalse,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if c := openShiftAPIServicesCondition(test.apiServices); c.Status != test.status {
+				t.Errorf("expected status %s, got %s: %s", test.status, c.Status, c.Message)
+			}
+		})
+	}
+}
+
+func TestDeploymentCondition(t *testing.T) {
+	deployment := &appsv1.Deployment{}
+	deployment.Name = "openshift-controller-manager"
+	if c := deploymentCondition(OpenShiftControllerManagerAvailable, deployment); c.Status != corev1.ConditionFalse {
+		t.Errorf("expected a deployment without conditions to be unavailable, got %s", c.Status)
+	}
+	deployment.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}}
+	if c := deploymentCondition(OpenShiftControllerManagerAvailable, deployment); c.Status != corev1.ConditionTrue {
+		t.Errorf("expected an available deployment, got %s: %s", c.Status, c.Message)
+	}
+}
@@ -0,0 +1,28 @@
+package clusterstatus
+
+import (
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/openshift/hypershift-toolkit/pkg/cmd/cpoperator"
+)
+
+func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
+	dynamicClient, err := dynamic.NewForConfig(cfg.TargetConfig())
+	if err != nil {
+		return err
+	}
+	reporter := &StatusReporter{
+		Client:              cfg.KubeClient(),
+		TargetClient:        cfg.TargetKubeClient(),
+		TargetConfigClient:  cfg.TargetConfigClient(),
+		TargetDynamicClient: dynamicClient,
+		Namespace:           cfg.Namespace(),
+		Log:                 cfg.Logger().WithName("StatusReporter"),
+	}
+	return cfg.Manager().Add(manager.RunnableFunc(func(stopCh <-chan struct{}) error {
+		wait.Until(reporter.Run, syncInterval, stopCh)
+		return nil
+	}))
+}
@@ -5,43 +5,141 @@ import (
 
 	"github.com/go-logr/logr"
 
+	"k8s.io/apimachinery/pkg/api/errors"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/yaml"
 
+	configv1 "github.com/openshift/api/config/v1"
 	configclient "github.com/openshift/client-go/config/clientset/versioned"
 	configlister "github.com/openshift/client-go/config/listers/config/v1"
 )
 
+const (
+	// ClusterVersionName is the name of the ClusterVersion of the target cluster
+	ClusterVersionName = "version"
+
+	// ConfigMapName is the name of the configmap in the control plane namespace with the
+	// settings of the ClusterVersion of the target cluster
+	ConfigMapName = "cluster-version"
+)
+
+// Settings are the fields of the ClusterVersion of the target cluster that are managed from
+// the control plane namespace
+type Settings struct {
+	// ReleaseImage is the release of the control plane, which the cluster is updated to
+	ReleaseImage string
+	Channel      string
+	Upstream     string
+	Overrides    []configv1.ComponentOverride
+}
+
 type ClusterVersionReconciler struct {
-	Client configclient.Interface
-	Lister configlister.ClusterVersionLister
-	Log    logr.Logger
+	Client          configclient.Interface
+	Lister          configlister.ClusterVersionLister
+	ConfigMapLister corelisters.ConfigMapLister
+	Namespace       string
+	Log             logr.Logger
 }
 
 func (r *ClusterVersionReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
-	clusterVersion, err := r.Lister.Get(req.Name)
+	clusterVersion, err := r.Lister.Get(ClusterVersionName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("cannot fetch cluster version %s: %v", ClusterVersionName, err)
+	}
+	settings, err := r.settings()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	clusterVersion = clusterVersion.DeepCopy()
+	if updateClusterVersion(clusterVersion, settings) {
+		r.Log.Info("Updating clusterversion resource to desired values", "channel", settings.Channel, "releaseImage", settings.ReleaseImage)
+		_, err := r.Client.ConfigV1().ClusterVersions().Update(clusterVersion)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// settings reads the settings of the ClusterVersion from the configmap in the control plane
+// namespace. Control planes rendered before the configmap existed get empty settings, which
+// clear the channel, upstream and desired update of the cluster.
+func (r *ClusterVersionReconciler) settings() (*Settings, error) {
+	cm, err := r.ConfigMapLister.ConfigMaps(r.Namespace).Get(ConfigMapName)
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("cannot fetch cluster version %s: %v", req.Name, err)
+		if errors.IsNotFound(err) {
+			return &Settings{}, nil
+		}
+		return nil, fmt.Errorf("cannot fetch configmap %s: %v", ConfigMapName, err)
+	}
+	return parseSettings(cm.Data)
+}
+
+func parseSettings(data map[string]string) (*Settings, error) {
+	settings := &Settings{
+		ReleaseImage: data["release-image"],
+		Channel:      data["channel"],
+		Upstream:     data["upstream"],
 	}
+	if overrides := data["overrides"]; len(overrides) > 0 {
+		if err := yaml.Unmarshal([]byte(overrides), &settings.Overrides); err != nil {
+			return nil, fmt.Errorf("invalid overrides in configmap %s: %v", ConfigMapName, err)
+		}
+	}
+	for i := range settings.Overrides {
+		settings.Overrides[i].Unmanaged = true
+	}
+	return settings, nil
+}
+
+// updateClusterVersion sets the managed fields of a ClusterVersion to the settings and
+// returns whether it changed. Overrides that tenants added for other components are kept.
+func updateClusterVersion(clusterVersion *configv1.ClusterVersion, settings *Settings) bool {
 	updateNeeded := false
-	// Always default to empty upstream
-	if len(clusterVersion.Spec.Upstream) > 0 {
-		clusterVersion.Spec.Upstream = ""
+	if clusterVersion.Spec.Upstream != configv1.URL(settings.Upstream) {
+		clusterVersion.Spec.Upstream = configv1.URL(settings.Upstream)
 		updateNeeded = true
 	}
-	// Always default to empty channel
-	if len(clusterVersion.Spec.Channel) > 0 {
-		clusterVersion.Spec.Channel = ""
+	if clusterVersion.Spec.Channel != settings.Channel {
+		clusterVersion.Spec.Channel = settings.Channel
 		updateNeeded = true
 	}
-	// Remove any attempt at changing the clusterVersion
-	if clusterVersion.Spec.DesiredUpdate != nil {
-		clusterVersion.Spec.DesiredUpdate = nil
+	// The cluster is only updated to the release of its control plane. The release image is
+	// chosen by the provider and already runs in the control plane, so its signature is not
+	// verified again.
+	var desiredUpdate *configv1.Update
+	if len(settings.ReleaseImage) > 0 {
+		desiredUpdate = &configv1.Update{Image: settings.ReleaseImage, Force: true}
+	}
+	if !equalUpdates(clusterVersion.Spec.DesiredUpdate, desiredUpdate) {
+		clusterVersion.Spec.DesiredUpdate = desiredUpdate
 		updateNeeded = true
 	}
-	if updateNeeded {
-		r.Log.Info("Updating clusterversion resource to desired values")
-		_, err := r.Client.ConfigV1().ClusterVersions().Update(clusterVersion)
-		return ctrl.Result{}, err
+	for _, override := range settings.Overrides {
+		found := false
+		for i, existing := range clusterVersion.Spec.Overrides {
+			if existing.Kind != override.Kind || existing.Group != override.Group || existing.Namespace != override.Namespace || existing.Name != override.Name {
+				continue
+			}
+			found = true
+			if !existing.Unmanaged {
+				clusterVersion.Spec.Overrides[i].Unmanaged = true
+				updateNeeded = true
+			}
+		}
+		if !found {
+			clusterVersion.Spec.Overrides = append(clusterVersion.Spec.Overrides, override)
+			updateNeeded = true
+		}
 	}
-	return ctrl.Result{}, nil
+	return updateNeeded
+}
+
+func equalUpdates(a, b *configv1.Update) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
@@ -0,0 +1,109 @@
+package clusterversion
+
+import (
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func TestParseSettings(t *testing.T) {
+	settings, err := parseSettings(map[string]string{
+		"release-image": "quay.io/openshift-release-dev/ocp-release:4.5.0-x86_64",
+		"channel":       "stable-4.5",
+		"upstream":      "",
+		"overrides":     "- kind: Deployment\n  group: apps\n  namespace: openshift-console\n  name: console\n",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &Settings{
+		ReleaseImage: "quay.io/openshift-release-dev/ocp-release:4.5.0-x86_64",
+		Channel:      "stable-4.5",
+		Overrides:    []configv1.ComponentOverride{{Kind: "Deployment", Group: "apps", Namespace: "openshift-console", Name: "console", Unmanaged: true}},
+	}
+	if !reflect.DeepEqual(settings, expected) {
+		t.Errorf("expected %+v, got %+v", expected, settings)
+	}
+	if _, err = parseSettings(map[string]string{"overrides": "kind: Deployment"}); err == nil {
+		t.Errorf("expected an error for overrides that are not a list")
+	}
+}
+
+func TestUpdateClusterVersion(t *testing.T) {
+	const releaseImage = "quay.io/openshift-release-dev/ocp-release:4.5.0-x86_64"
+	console := configv1.ComponentOverride{Kind: "Deployment", Group: "apps", Namespace: "openshift-console", Name: "console", Unmanaged: true}
+	tenant := configv1.ComponentOverride{Kind: "Deployment", Group: "apps", Namespace: "openshift-monitoring", Name: "cluster-monitoring-operator", Unmanaged: true}
+	settings := &Settings{ReleaseImage: releaseImage, Channel: "stable-4.5", Overrides: []configv1.ComponentOverride{console}}
+	tests := []struct {
+		name     string
+		spec     configv1.ClusterVersionSpec
+		settings *Settings
+		expected configv1.ClusterVersionSpec
+		updated  bool
+	}{
+		{
+			name:     "defaults",
+			spec:     configv1.ClusterVersionSpec{},
+			settings: settings,
+			expected: configv1.ClusterVersionSpec{
+				Channel:       "stable-4.5",
+				DesiredUpdate: &configv1.Update{Image: releaseImage, Force: true},
+				Overrides:     []configv1.ComponentOverride{console},
+			},
+			updated: true,
+		},
+		{
+			name: "tenant changes",
+			spec: configv1.ClusterVersionSpec{
+				Channel:       "fast-4.6",
+				Upstream:      "https://updates.example.com/graph",
+				DesiredUpdate: &configv1.Update{Version: "4.6.1"},
+				Overrides:     []configv1.ComponentOverride{tenant, {Kind: "Deployment", Group: "apps", Namespace: "openshift-console", Name: "console"}},
+			},
+			settings: settings,
+			expected: configv1.ClusterVersionSpec{
+				Channel:       "stable-4.5",
+				DesiredUpdate: &configv1.Update{Image: releaseImage, Force: true},
+				Overrides:     []configv1.ComponentOverride{tenant, console},
+			},
+			updated: true,
+		},
+		{
+			name: "up to date",
+			spec: configv1.ClusterVersionSpec{
+				Channel:       "stable-4.5",
+				DesiredUpdate: &configv1.Update{Image: releaseImage, Force: true},
+				Overrides:     []configv1.ComponentOverride{console, tenant},
+			},
+			settings: settings,
+			expected: configv1.ClusterVersionSpec{
+				Channel:       "stable-4.5",
+				DesiredUpdate: &configv1.Update{Image: releaseImage, Force: true},
+				Overrides:     []configv1.ComponentOverride{console, tenant},
+			},
+		},
+		{
+			name: "without settings",
+			spec: configv1.ClusterVersionSpec{
+				Channel:       "stable-4.5",
+				DesiredUpdate: &configv1.Update{Version: "4.5.1"},
+			},
+			settings: &Settings{},
+			expected: configv1.ClusterVersionSpec{},
+			updated:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clusterVersion := &configv1.ClusterVersion{Spec: test.spec}
+			updated := updateClusterVersion(clusterVersion, test.settings)
+			if updated != test.updated {
+				t.Errorf("expected update %t, got %t", test.updated, updated)
+			}
+			if !reflect.DeepEqual(clusterVersion.Spec, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, clusterVersion.Spec)
+			}
+		})
+	}
+}
@@ -1,8 +1,6 @@
 package clusterversion
 
 import (
-	"sigs.k8s.io/controller-runtime/pkg/controller"
-	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
@@ -24,16 +22,22 @@ func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
 		return nil
 	}))
 	clusterVersions := informerFactory.Config().V1().ClusterVersions()
+	configMaps := cfg.KubeInformers().Core().V1().ConfigMaps()
 	reconciler := &ClusterVersionReconciler{
-		Client: openshiftClient,
-		Lister: clusterVersions.Lister(),
-		Log:    cfg.Logger().WithName("ClusterVersion"),
+		Client:          openshiftClient,
+		Lister:          clusterVersions.Lister(),
+		ConfigMapLister: configMaps.Lister(),
+		Namespace:       cfg.Namespace(),
+		Log:             cfg.Logger().WithName("ClusterVersion"),
 	}
-	c, err := controller.New("cluster-version", cfg.Manager(), controller.Options{Reconciler: reconciler})
+	c, err := controllers.NewController("cluster-version", cfg.Manager(), reconciler, cfg.Logger().WithName("cluster-version"))
 	if err != nil {
 		return err
 	}
-	if err := c.Watch(&source.Informer{Informer: clusterVersions.Informer()}, &handler.EnqueueRequestForObject{}); err != nil {
+	if err := c.Watch(&source.Informer{Informer: clusterVersions.Informer()}, controllers.NamedResourceHandler(ClusterVersionName)); err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Informer{Informer: configMaps.Informer()}, controllers.NamedResourceHandler(ConfigMapName)); err != nil {
 		return err
 	}
 	return nil
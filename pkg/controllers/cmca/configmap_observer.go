@@ -7,26 +7,35 @@ import (
 	"fmt"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeclient "k8s.io/client-go/kubernetes"
 	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/openshift/hypershift-toolkit/pkg/controllers"
 )
 
 const (
 	RouterCAConfigMap               = "router-ca"
 	ServiceCAConfigMap              = "service-ca"
+	DefaultIngressCertConfigMap     = "default-ingress-cert"
 	destConfigMap                   = "kube-controller-manager"
 	kubeControllerManagerDeployment = "kube-controller-manager"
 )
 
-// ManagedCAObserver watches 2 CA configmaps in the target cluster:
+// ManagedCAObserver watches 3 CA configmaps in the target cluster:
 // - openshift-managed-config/router-ca
 // - openshift-managed-config/service-ca
+// - openshift-managed-config/default-ingress-cert
 // It populates a configmap on the management cluster with their content.
 // A separate controller uses that content to adjust the configmap for
-// the Kube controller manager CA.
+// the Kube controller manager CA. The default ingress certificate is
+// included so that pods keep trusting the routes of the cluster, such as
+// the OAuth route that consoles use, when the default wildcard certificate
+// of the routers is replaced by one that the router CA did not sign.
 type ManagedCAObserver struct {
 
 	// Client is a client that allows access to the management cluster
@@ -42,6 +51,9 @@ type ManagedCAObserver struct {
 	// InitialCA is the initial CA for the controller manager
 	InitialCA string
 
+	// Recorder records the syncs of the CAs on the control plane namespace
+	Recorder record.EventRecorder
+
 	// Log is the logger for this controller
 	Log logr.Logger
 }
@@ -83,6 +95,7 @@ func (r *ManagedCAObserver) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		destinationCM.Data["service-ca.crt"] = ca.String()
 		r.Log.Info("Updating controller manager configmap")
 		if _, err = r.Client.CoreV1().ConfigMaps(r.Namespace).Update(destinationCM); err != nil {
+			r.Recorder.Eventf(controllers.NamespaceReference(r.Namespace), corev1.EventTypeWarning, "ControllerManagerCASyncFailed", "Cannot update the CAs of the kube-controller-manager: %v", err)
 			return ctrl.Result{}, err
 		}
 	}
@@ -93,6 +106,7 @@ func (r *ManagedCAObserver) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	}
 	if cmDeployment.Spec.Template.ObjectMeta.Annotations != nil &&
 		cmDeployment.Spec.Template.ObjectMeta.Annotations["ca-checksum"] == hash {
+		caLastSync.SetToCurrentTime()
 		return ctrl.Result{}, nil
 	}
 
@@ -102,8 +116,11 @@ func (r *ManagedCAObserver) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	}
 	cmDeployment.Spec.Template.ObjectMeta.Annotations["ca-checksum"] = hash
 	if _, err = r.Client.AppsV1().Deployments(r.Namespace).Update(cmDeployment); err != nil {
+		r.Recorder.Eventf(controllers.NamespaceReference(r.Namespace), corev1.EventTypeWarning, "ControllerManagerCASyncFailed", "Cannot restart the kube-controller-manager with updated CAs: %v", err)
 		return ctrl.Result{}, err
 	}
+	r.Recorder.Event(controllers.NamespaceReference(r.Namespace), corev1.EventTypeNormal, "ControllerManagerCASynced", "Restarting the kube-controller-manager with the updated router, service and ingress CAs of the cluster")
+	caLastSync.SetToCurrentTime()
 	return ctrl.Result{}, nil
 }
 
@@ -125,6 +142,13 @@ func (r *ManagedCAObserver) getAdditionalCAs(ctx context.Context, logger logr.Lo
 	if err == nil {
 		additionalCAs = append(additionalCAs, []byte(cm.Data["ca-bundle.crt"]))
 	}
+	cm, err = r.TargetCMLister.ConfigMaps(ManagedConfigNamespace).Get(DefaultIngressCertConfigMap)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to fetch default ingress cert configmap: %v", err)
+	}
+	if err == nil {
+		additionalCAs = append(additionalCAs, []byte(cm.Data["ca-bundle.crt"]))
+	}
 
 	return additionalCAs, nil
 }
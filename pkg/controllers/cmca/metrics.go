@@ -0,0 +1,15 @@
+package cmca
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var caLastSync = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "hypershift_control_plane_operator_ca_last_sync_timestamp_seconds",
+	Help: "Time of the last successful sync of the target cluster's CAs to the kube-controller-manager",
+})
+
+func init() {
+	metrics.Registry.MustRegister(caLastSync)
+}
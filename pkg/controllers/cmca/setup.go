@@ -1,7 +1,6 @@
 package cmca
 
 import (
-	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/openshift/hypershift-toolkit/pkg/cmd/cpoperator"
@@ -28,13 +27,14 @@ func setupConfigMapObserver(cfg *cpoperator.ControlPlaneOperatorConfig) error {
 		Client:         cfg.KubeClient(),
 		TargetCMLister: configMaps.Lister(),
 		Namespace:      cfg.Namespace(),
+		Recorder:       cfg.EventRecorder(),
 		Log:            cfg.Logger().WithName("ManagedCAObserver"),
 	}
-	c, err := controller.New("ca-configmap-observer", cfg.Manager(), controller.Options{Reconciler: reconciler})
+	c, err := controllers.NewController("ca-configmap-observer", cfg.Manager(), reconciler, cfg.Logger().WithName("ca-configmap-observer"))
 	if err != nil {
 		return err
 	}
-	if err := c.Watch(&source.Informer{Informer: configMaps.Informer()}, controllers.NamedResourceHandler(RouterCAConfigMap, ServiceCAConfigMap)); err != nil {
+	if err := c.Watch(&source.Informer{Informer: configMaps.Informer()}, controllers.NamedResourceHandler(RouterCAConfigMap, ServiceCAConfigMap, DefaultIngressCertConfigMap)); err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NamespaceReference returns a reference to the namespace of a control plane on the
+// management cluster. Events about the control plane that are not about a single object of
+// the management cluster, such as the approval of a CSR of the target cluster, are recorded
+// on it, so that they are listed by `oc get events` in the namespace.
+func NamespaceReference(namespace string) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Namespace",
+		Name:       namespace,
+		Namespace:  namespace,
+	}
+}
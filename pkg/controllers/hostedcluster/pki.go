@@ -0,0 +1,149 @@
+package hostedcluster
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/pki"
+	"github.com/openshift/hypershift-toolkit/pkg/render"
+)
+
+// pkiSecretName is the name of the secret in the control plane namespace that
+// holds the generated PKI files, so that they are only generated once.
+const pkiSecretName = "hosted-cluster-pki"
+
+// ensurePKI writes the PKI files of the cluster to pkiDir, generating them and
+// storing them in the PKI secret if they do not exist yet. CAs in the secret
+// referenced by caSecret are used instead of generating them.
+func (r *HostedClusterReconciler) ensurePKI(params *api.ClusterParams, caSecret *corev1.SecretReference, pkiDir string) error {
+	secret, err := r.Client.CoreV1().Secrets(params.Namespace).Get(pkiSecretName, metav1.GetOptions{})
+	if err == nil {
+		for name, content := range secret.Data {
+			if err := ioutil.WriteFile(filepath.Join(pkiDir, name), content, 0644); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+	if caSecret != nil {
+		caDir, err := ioutil.TempDir("", "hostedcluster-ca")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(caDir)
+		if err := r.writeCASecret(caSecret, caDir); err != nil {
+			return err
+		}
+		params.PKI.CADirectory = caDir
+	}
+	if err := pki.GeneratePKI(params, pkiDir); err != nil {
+		return err
+	}
+	files, err := ioutil.ReadDir(pkiDir)
+	if err != nil {
+		return err
+	}
+	secret = &corev1.Secret{}
+	secret.Name = pkiSecretName
+	secret.Data = map[string][]byte{}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(pkiDir, f.Name()))
+		if err != nil {
+			return err
+		}
+		secret.Data[f.Name()] = content
+	}
+	_, err = r.Client.CoreV1().Secrets(params.Namespace).Create(secret)
+	return err
+}
+
+// writeCASecret writes the CA key pairs in the referenced secret to caDir
+func (r *HostedClusterReconciler) writeCASecret(ref *corev1.SecretReference, caDir string) error {
+	secret, err := r.Client.CoreV1().Secrets(ref.Namespace).Get(ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	for name, content := range secret.Data {
+		if filepath.Base(name) != name {
+			continue
+		}
+		if err := ioutil.WriteFile(filepath.Join(caDir, name), content, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderPKISecrets renders the PKI secrets of the cluster to manifestsDir. Secrets and
+// configmaps that already exist in the control plane namespace are skipped, because
+// the certificates they hold may have been rotated since they were first applied.
+func (r *HostedClusterReconciler) renderPKISecrets(params *api.ClusterParams, pkiDir, manifestsDir string, etcd, vpn, konnectivity, externalOauth bool) error {
+	renderDir, err := ioutil.TempDir("", "hostedcluster-pki")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(renderDir)
+	if err := render.RenderPKISecrets(pkiDir, renderDir, etcd, vpn, konnectivity, externalOauth, render.CertRotationEnabled(params), params.ExternalIgnitionPort != 0, params.EtcdEncryption.Provider != "", params.Monitoring.Enabled); err != nil {
+		return err
+	}
+	if err := render.RenderNamedCertsSecret(params, pkiDir, renderDir); err != nil {
+		return err
+	}
+	files, err := ioutil.ReadDir(renderDir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		fileName := filepath.Join(renderDir, f.Name())
+		exists, err := r.manifestExists(params.Namespace, fileName)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if err := os.Rename(fileName, filepath.Join(manifestsDir, f.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manifestExists returns whether the secret or configmap in fileName exists in namespace
+func (r *HostedClusterReconciler) manifestExists(namespace, fileName string) (bool, error) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return false, err
+	}
+	obj := &unstructured.Unstructured{}
+	if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 4096).Decode(&obj.Object); err != nil {
+		return false, err
+	}
+	switch obj.GetKind() {
+	case "Secret":
+		_, err = r.Client.CoreV1().Secrets(namespace).Get(obj.GetName(), metav1.GetOptions{})
+	case "ConfigMap":
+		_, err = r.Client.CoreV1().ConfigMaps(namespace).Get(obj.GetName(), metav1.GetOptions{})
+	default:
+		return false, nil
+	}
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
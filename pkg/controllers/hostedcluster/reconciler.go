@@ -0,0 +1,285 @@
+package hostedcluster
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/api/v1alpha1"
+	"github.com/openshift/hypershift-toolkit/pkg/applier"
+	"github.com/openshift/hypershift-toolkit/pkg/render"
+)
+
+const (
+	// finalizer ensures that the control plane namespace is removed with the HostedCluster
+	finalizer = "hypershift.openshift.io/hosted-cluster"
+
+	// hostedClusterLabel identifies the namespace that belongs to a HostedCluster
+	hostedClusterLabel = "hypershift.openshift.io/hosted-cluster"
+
+	pullSecretName = "pull-secret"
+
+	// brandingManifest is too large to be applied and is created directly
+	brandingManifest = "v4-0-config-system-branding.yaml"
+
+	// namespaceDeletionInterval is how often the removal of the control plane
+	// namespace is checked while a HostedCluster is deleted
+	namespaceDeletionInterval = 5 * time.Second
+)
+
+type HostedClusterReconciler struct {
+	// Client is a client of the management cluster
+	Client kubeclient.Interface
+
+	// DynamicClient is a dynamic client of the management cluster
+	DynamicClient dynamic.Interface
+
+	// Config is the rest config of the management cluster
+	Config *rest.Config
+
+	// Recorder records the reconciles of HostedClusters on them
+	Recorder record.EventRecorder
+
+	// Log is the logger for this controller
+	Log logr.Logger
+}
+
+func (r *HostedClusterReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	controllerLog := r.Log.WithValues("hostedcluster", req.Name)
+	obj, err := r.DynamicClient.Resource(v1alpha1.HostedClusterResource).Get(req.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	hc := &v1alpha1.HostedCluster{}
+	if err := fromUnstructured(obj, hc); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if hc.DeletionTimestamp != nil {
+		return r.delete(controllerLog, obj, hc)
+	}
+	if !sets.NewString(obj.GetFinalizers()...).Has(finalizer) {
+		obj.SetFinalizers(append(obj.GetFinalizers(), finalizer))
+		_, err = r.DynamicClient.Resource(v1alpha1.HostedClusterResource).Update(obj, metav1.UpdateOptions{})
+		return ctrl.Result{}, err
+	}
+	if hc.Status.ObservedGeneration == hc.Generation && hc.Status.Phase == v1alpha1.HostedClusterAvailable {
+		return ctrl.Result{}, nil
+	}
+
+	controllerLog.Info("Reconciling hosted cluster", "generation", hc.Generation)
+	if err := r.reconcileControlPlane(hc); err != nil {
+		controllerLog.Error(err, "failed to reconcile control plane")
+		r.Recorder.Eventf(obj, corev1.EventTypeWarning, "ReconcileFailed", "Cannot reconcile the control plane: %v", err)
+		if statusErr := r.updateStatus(obj, hc.Status.ObservedGeneration, v1alpha1.HostedClusterFailed, err.Error()); statusErr != nil {
+			controllerLog.Error(statusErr, "failed to update status")
+		}
+		return ctrl.Result{}, err
+	}
+	controllerLog.Info("Control plane manifests applied")
+	r.Recorder.Eventf(obj, corev1.EventTypeNormal, "ControlPlaneApplied", "Applied the control plane manifests of generation %d", hc.Generation)
+	return ctrl.Result{}, r.updateStatus(obj, hc.Generation, v1alpha1.HostedClusterAvailable, "Control plane manifests applied")
+}
+
+// reconcileControlPlane ensures the control plane namespace, pull secret and PKI exist,
+// then renders and applies the control plane manifests.
+func (r *HostedClusterReconciler) reconcileControlPlane(hc *v1alpha1.HostedCluster) error {
+	namespace := hc.Name
+	if hc.Spec.IncludeVPN && hc.Spec.IncludeKonnectivity {
+		return fmt.Errorf("includeVPN and includeKonnectivity cannot be used together")
+	}
+	params := api.NewClusterParams()
+	paramBytes, err := json.Marshal(hc.Spec.Params)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(paramBytes, params); err != nil {
+		return err
+	}
+	params.Namespace = namespace
+
+	if err := r.ensureNamespace(hc); err != nil {
+		return fmt.Errorf("cannot ensure namespace %s: %v", namespace, err)
+	}
+	if !params.PodSecurity.SkipPrivilegedSCC {
+		if err := installer.EnsurePrivilegedSCC(r.DynamicClient, namespace, render.PrivilegedServiceAccounts(hc.Spec.IncludeVPN)...); err != nil {
+			return fmt.Errorf("cannot ensure privileged SCC for namespace %s: %v", namespace, err)
+		}
+	}
+	pullSecret, err := r.ensurePullSecret(hc)
+	if err != nil {
+		return fmt.Errorf("cannot ensure pull secret: %v", err)
+	}
+
+	workingDir, err := ioutil.TempDir("", "hostedcluster")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workingDir)
+	pkiDir := filepath.Join(workingDir, "pki")
+	manifestsDir := filepath.Join(workingDir, "manifests")
+	for _, dir := range []string{pkiDir, manifestsDir} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			return err
+		}
+	}
+	if err := r.ensurePKI(params, hc.Spec.CASecret, pkiDir); err != nil {
+		return fmt.Errorf("cannot ensure PKI: %v", err)
+	}
+	pullSecretFile := filepath.Join(workingDir, "pull-secret")
+	if err := ioutil.WriteFile(pullSecretFile, pullSecret, 0644); err != nil {
+		return err
+	}
+
+	externalOauth := params.ExternalOauthPort != 0
+	if err := r.renderPKISecrets(params, pkiDir, manifestsDir, hc.Spec.IncludeEtcd, hc.Spec.IncludeVPN, hc.Spec.IncludeKonnectivity, externalOauth); err != nil {
+		return fmt.Errorf("cannot render PKI secrets: %v", err)
+	}
+	caBytes, err := ioutil.ReadFile(filepath.Join(pkiDir, "combined-ca.crt"))
+	if err != nil {
+		return err
+	}
+	params.OpenshiftAPIServerCABundle = base64.StdEncoding.EncodeToString(caBytes)
+	if err := render.RenderClusterManifests(params, pullSecretFile, manifestsDir, hc.Spec.IncludeEtcd, hc.Spec.IncludeVPN, hc.Spec.IncludeKonnectivity, externalOauth, hc.Spec.IncludeRegistry); err != nil {
+		return fmt.Errorf("cannot render manifests: %v", err)
+	}
+	brandingFile := filepath.Join(manifestsDir, brandingManifest)
+	if err := installer.CreateBrandingSecret(r.Client, namespace, brandingFile); err != nil {
+		return fmt.Errorf("cannot create branding secret: %v", err)
+	}
+	if err := os.Remove(brandingFile); err != nil {
+		return err
+	}
+	// Manifests that the release of the cluster no longer renders are pruned on upgrades
+	a := applier.NewApplier(r.Config, namespace)
+	a.Prune = true
+	return a.ApplyFile(manifestsDir)
+}
+
+func (r *HostedClusterReconciler) ensureNamespace(hc *v1alpha1.HostedCluster) error {
+	ns, err := r.Client.CoreV1().Namespaces().Get(hc.Name, metav1.GetOptions{})
+	if err == nil {
+		if ns.Labels[hostedClusterLabel] != hc.Name {
+			return fmt.Errorf("namespace %s exists and does not belong to the hosted cluster", hc.Name)
+		}
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+	ns = &corev1.Namespace{}
+	ns.Name = hc.Name
+	ns.Labels = map[string]string{hostedClusterLabel: hc.Name}
+	_, err = r.Client.CoreV1().Namespaces().Create(ns)
+	return err
+}
+
+// ensurePullSecret copies the pull secret referenced by the HostedCluster into the
+// control plane namespace and returns its contents.
+func (r *HostedClusterReconciler) ensurePullSecret(hc *v1alpha1.HostedCluster) ([]byte, error) {
+	source, err := r.Client.CoreV1().Secrets(hc.Spec.PullSecret.Namespace).Get(hc.Spec.PullSecret.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	data, ok := source.Data[corev1.DockerConfigJsonKey]
+	if !ok {
+		return nil, fmt.Errorf("did not find pull secret data in secret %s/%s", source.Namespace, source.Name)
+	}
+	secret, err := r.Client.CoreV1().Secrets(hc.Name).Get(pullSecretName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		secret = &corev1.Secret{}
+		secret.Name = pullSecretName
+		secret.Type = corev1.SecretTypeDockerConfigJson
+		secret.Data = map[string][]byte{corev1.DockerConfigJsonKey: data}
+		_, err = r.Client.CoreV1().Secrets(hc.Name).Create(secret)
+		return data, err
+	}
+	secret.Data = map[string][]byte{corev1.DockerConfigJsonKey: data}
+	_, err = r.Client.CoreV1().Secrets(hc.Name).Update(secret)
+	return data, err
+}
+
+// delete removes the control plane namespace of the HostedCluster and drops the finalizer
+// once the namespace is gone, requeueing while the namespace is still terminating.
+func (r *HostedClusterReconciler) delete(log logr.Logger, obj *unstructured.Unstructured, hc *v1alpha1.HostedCluster) (ctrl.Result, error) {
+	finalizers := sets.NewString(obj.GetFinalizers()...)
+	if !finalizers.Has(finalizer) {
+		return ctrl.Result{}, nil
+	}
+	if hc.Status.Phase != v1alpha1.HostedClusterDeleting {
+		if err := r.updateStatus(obj, hc.Status.ObservedGeneration, v1alpha1.HostedClusterDeleting, "Removing control plane namespace"); err != nil {
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(obj, corev1.EventTypeNormal, "DeletingControlPlane", "Removing control plane namespace %s", hc.Name)
+	}
+	ns, err := r.Client.CoreV1().Namespaces().Get(hc.Name, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	if err == nil && ns.Labels[hostedClusterLabel] == hc.Name {
+		if ns.DeletionTimestamp == nil {
+			log.Info("Deleting control plane namespace")
+			if err := r.Client.CoreV1().Namespaces().Delete(hc.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+		}
+		log.Info("Waiting for control plane namespace to be removed")
+		return ctrl.Result{RequeueAfter: namespaceDeletionInterval}, nil
+	}
+	obj, err = r.DynamicClient.Resource(v1alpha1.HostedClusterResource).Get(hc.Name, metav1.GetOptions{})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	finalizers = sets.NewString(obj.GetFinalizers()...)
+	finalizers.Delete(finalizer)
+	obj.SetFinalizers(finalizers.List())
+	_, err = r.DynamicClient.Resource(v1alpha1.HostedClusterResource).Update(obj, metav1.UpdateOptions{})
+	return ctrl.Result{}, err
+}
+
+func (r *HostedClusterReconciler) updateStatus(obj *unstructured.Unstructured, generation int64, phase v1alpha1.HostedClusterPhase, message string) error {
+	obj = obj.DeepCopy()
+	status := map[string]interface{}{
+		"observedGeneration": generation,
+		"phase":              string(phase),
+		"message":            message,
+	}
+	if err := unstructured.SetNestedField(obj.Object, status, "status"); err != nil {
+		return err
+	}
+	_, err := r.DynamicClient.Resource(v1alpha1.HostedClusterResource).UpdateStatus(obj, metav1.UpdateOptions{})
+	return err
+}
+
+func fromUnstructured(obj *unstructured.Unstructured, hc *v1alpha1.HostedCluster) error {
+	b, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, hc)
+}
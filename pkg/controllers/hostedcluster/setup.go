@@ -0,0 +1,60 @@
+package hostedcluster
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api/v1alpha1"
+	"github.com/openshift/hypershift-toolkit/pkg/cmd/cpoperator"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers"
+)
+
+func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
+	dynamicClient, err := dynamic.NewForConfig(cfg.Config())
+	if err != nil {
+		return err
+	}
+	hostedClusters := dynamicClient.Resource(v1alpha1.HostedClusterResource)
+	informer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return hostedClusters.List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return hostedClusters.Watch(options)
+			},
+		},
+		&unstructured.Unstructured{},
+		controllers.DefaultResync,
+		cache.Indexers{},
+	)
+	if err := cfg.ManagementManager().Add(manager.RunnableFunc(func(stopCh <-chan struct{}) error {
+		informer.Run(stopCh)
+		return nil
+	})); err != nil {
+		return err
+	}
+
+	reconciler := &HostedClusterReconciler{
+		Client:        cfg.KubeClient(),
+		DynamicClient: dynamicClient,
+		Config:        cfg.Config(),
+		Recorder:      cfg.EventRecorder(),
+		Log:           cfg.Logger().WithName("HostedCluster"),
+	}
+	c, err := controllers.NewController("hosted-cluster", cfg.ManagementManager(), reconciler, cfg.Logger().WithName("hosted-cluster"))
+	if err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Informer{Informer: informer}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+	return nil
+}
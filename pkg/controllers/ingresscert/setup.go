@@ -0,0 +1,36 @@
+package ingresscert
+
+import (
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/openshift/hypershift-toolkit/pkg/cmd/cpoperator"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers"
+)
+
+func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
+	secrets := cfg.KubeInformers().Core().V1().Secrets()
+	targetSecrets := cfg.TargetKubeInformersForNamespace(TargetNamespace).Core().V1().Secrets()
+	dynamicClient, err := dynamic.NewForConfig(cfg.TargetConfig())
+	if err != nil {
+		return err
+	}
+	reconciler := &DefaultCertSyncer{
+		Lister:              secrets.Lister(),
+		Namespace:           cfg.Namespace(),
+		TargetClient:        cfg.TargetKubeClient(),
+		TargetDynamicClient: dynamicClient,
+		Log:                 cfg.Logger().WithName("IngressDefaultCert"),
+	}
+	c, err := controllers.NewController("ingress-default-cert", cfg.Manager(), reconciler, cfg.Logger().WithName("ingress-default-cert"))
+	if err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Informer{Informer: secrets.Informer()}, controllers.NamedResourceHandler(SourceSecretName)); err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Informer{Informer: targetSecrets.Informer()}, controllers.NamedResourceHandler(TargetSecretName)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package ingresscert
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// SourceSecretName is the name of the TLS secret in the control plane namespace with a
+	// default certificate for the routers of the target cluster
+	SourceSecretName = "ingress-default-cert"
+
+	// TargetNamespace and TargetSecretName are the namespace and name of the copy of the
+	// certificate in the target cluster
+	TargetNamespace  = "openshift-ingress"
+	TargetSecretName = "hypershift-default-cert"
+
+	// ingressControllerNamespace and ingressControllerName are the namespace and name of the
+	// default ingress controller of the target cluster
+	ingressControllerNamespace = "openshift-ingress-operator"
+	ingressControllerName      = "default"
+
+	// ingressControllerRetryInterval is how often the default certificate is set again
+	// until the ingress operator has created the default ingress controller
+	ingressControllerRetryInterval = time.Minute
+)
+
+var ingressControllerResource = schema.GroupVersionResource{Group: "operator.openshift.io", Version: "v1", Resource: "ingresscontrollers"}
+
+// DefaultCertSyncer copies a default certificate for the routers of the target cluster from
+// the control plane namespace and makes it the default certificate of its default ingress
+// controller. Clusters without the secret keep the certificate their ingress controller has.
+type DefaultCertSyncer struct {
+	// Lister lists secrets in the control plane namespace
+	Lister corelisters.SecretLister
+
+	// Namespace is the namespace where the control plane of the cluster lives on the
+	// management server
+	Namespace string
+
+	// TargetClient and TargetDynamicClient are clients of the target cluster
+	TargetClient        kubeclient.Interface
+	TargetDynamicClient dynamic.Interface
+
+	Log logr.Logger
+}
+
+func (s *DefaultCertSyncer) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	source, err := s.Lister.Secrets(s.Namespace).Get(SourceSecretName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if err = s.ensureTargetSecret(source); err != nil {
+		return ctrl.Result{}, err
+	}
+	ingressControllers := s.TargetDynamicClient.Resource(ingressControllerResource).Namespace(ingressControllerNamespace)
+	ic, err := ingressControllers.Get(ingressControllerName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			s.Log.Info("Default ingress controller not found yet")
+			return ctrl.Result{RequeueAfter: ingressControllerRetryInterval}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if name, _, _ := unstructured.NestedString(ic.Object, "spec", "defaultCertificate", "name"); name == TargetSecretName {
+		return ctrl.Result{}, nil
+	}
+	if err = unstructured.SetNestedField(ic.Object, TargetSecretName, "spec", "defaultCertificate", "name"); err != nil {
+		return ctrl.Result{}, err
+	}
+	s.Log.Info("Setting the default certificate of the default ingress controller", "secret", TargetSecretName)
+	_, err = ingressControllers.Update(ic, metav1.UpdateOptions{})
+	return ctrl.Result{}, err
+}
+
+// ensureTargetSecret creates or updates the copy of the certificate in the target cluster
+func (s *DefaultCertSyncer) ensureTargetSecret(source *corev1.Secret) error {
+	secrets := s.TargetClient.CoreV1().Secrets(TargetNamespace)
+	target, err := secrets.Get(TargetSecretName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		target = &corev1.Secret{}
+		target.Name = TargetSecretName
+		target.Namespace = TargetNamespace
+		target.Type = corev1.SecretTypeTLS
+		target.Data = tlsData(source)
+		s.Log.Info("Creating default ingress certificate secret")
+		_, err = secrets.Create(target)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if secretDataEqual(target.Data, tlsData(source)) {
+		return nil
+	}
+	target.Data = tlsData(source)
+	s.Log.Info("Updating default ingress certificate secret")
+	_, err = secrets.Update(target)
+	return err
+}
+
+// tlsData returns the certificate and key of a TLS secret
+func tlsData(secret *corev1.Secret) map[string][]byte {
+	return map[string][]byte{
+		corev1.TLSCertKey:       secret.Data[corev1.TLSCertKey],
+		corev1.TLSPrivateKeyKey: secret.Data[corev1.TLSPrivateKeyKey],
+	}
+}
+
+func secretDataEqual(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		other, ok := b[key]
+		if !ok || !bytes.Equal(value, other) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,24 @@
+package ingresscert
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSecretDataEqual(t *testing.T) {
+	source := &corev1.Secret{Data: map[string][]byte{
+		corev1.TLSCertKey:       []byte("cert"),
+		corev1.TLSPrivateKeyKey: []byte("key"),
+		"ca.crt":                []byte("ca"),
+	}}
+	if !secretDataEqual(tlsData(source), map[string][]byte{corev1.TLSCertKey: []byte("cert"), corev1.TLSPrivateKeyKey: []byte("key")}) {
+		t.Errorf("expected the certificate and key of a secret to be copied without other keys")
+	}
+	if secretDataEqual(tlsData(source), map[string][]byte{corev1.TLSCertKey: []byte("cert"), corev1.TLSPrivateKeyKey: []byte("old")}) {
+		t.Errorf("expected a different key to be updated")
+	}
+	if secretDataEqual(tlsData(source), map[string][]byte{corev1.TLSCertKey: []byte("cert")}) {
+		t.Errorf("expected a missing key to be updated")
+	}
+}
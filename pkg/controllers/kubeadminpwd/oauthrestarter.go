@@ -8,8 +8,11 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/hypershift-toolkit/pkg/controllers"
 )
 
 const (
@@ -26,6 +29,9 @@ type OAuthRestarter struct {
 	// Namespace is the namespace where the control plane of the cluster
 	// lives on the management server
 	Namespace string
+
+	// Recorder records the restarts of the OAuth server on the control plane namespace
+	Recorder record.EventRecorder
 }
 
 func (o *OAuthRestarter) Reconcile(req ctrl.Request) (ctrl.Result, error) {
@@ -66,8 +72,10 @@ func (o *OAuthRestarter) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	oauthDeployment.Spec.Template.ObjectMeta.Annotations["bootstrap-pod-resource-version"] = pod.ResourceVersion
 
 	if err := o.Update(ctx, oauthDeployment); err != nil {
+		o.Recorder.Eventf(controllers.NamespaceReference(o.Namespace), corev1.EventTypeWarning, "KubeadminPasswordSyncFailed", "Cannot restart the OAuth server to load the kubeadmin password: %v", err)
 		return ctrl.Result{}, err
 	}
+	o.Recorder.Event(controllers.NamespaceReference(o.Namespace), corev1.EventTypeNormal, "KubeadminPasswordSynced", "Restarting the OAuth server to load the kubeadmin password created by the manifests bootstrapper")
 	return ctrl.Result{}, nil
 }
 
@@ -2,7 +2,6 @@ package kubeadminpwd
 
 import (
 	corev1 "k8s.io/api/core/v1"
-	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/openshift/hypershift-toolkit/pkg/cmd/cpoperator"
@@ -17,9 +16,10 @@ func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
 	reconciler := &OAuthRestarter{
 		Client:    cfg.Manager().GetClient(),
 		Namespace: cfg.Namespace(),
+		Recorder:  cfg.EventRecorder(),
 		Log:       cfg.Logger().WithName("OAuthRestarter"),
 	}
-	c, err := controller.New("oauth-restarter", cfg.Manager(), controller.Options{Reconciler: reconciler})
+	c, err := controllers.NewController("oauth-restarter", cfg.Manager(), reconciler, cfg.Logger().WithName("oauth-restarter"))
 	if err != nil {
 		return err
 	}
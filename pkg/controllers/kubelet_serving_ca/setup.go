@@ -1,11 +1,10 @@
 package kubelet_serving_ca
 
 import (
-	"sigs.k8s.io/controller-runtime/pkg/controller"
-	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/openshift/hypershift-toolkit/pkg/cmd/cpoperator"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers"
 )
 
 const (
@@ -16,17 +15,24 @@ func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
 
 	informerFactory := cfg.TargetKubeInformersForNamespace(ManagedConfigNamespace)
 	configMaps := informerFactory.Core().V1().ConfigMaps()
+	sourceConfigMaps := cfg.KubeInformers().Core().V1().ConfigMaps()
 
 	reconciler := &KubeletServingCASyncer{
-		InitialCA:    cfg.InitialCA(),
-		TargetClient: cfg.TargetKubeClient(),
-		Log:          cfg.Logger().WithName("KubeletServingCA"),
+		InitialCA:       cfg.InitialCA(),
+		TargetClient:    cfg.TargetKubeClient(),
+		ConfigMapLister: sourceConfigMaps.Lister(),
+		Namespace:       cfg.Namespace(),
+		SyncInterval:    cfg.KubeletServingCASyncInterval(),
+		Log:             cfg.Logger().WithName("KubeletServingCA"),
 	}
-	c, err := controller.New("kubelet-serving-ca", cfg.Manager(), controller.Options{Reconciler: reconciler})
+	c, err := controllers.NewController("kubelet-serving-ca", cfg.Manager(), reconciler, cfg.Logger().WithName("kubelet-serving-ca"))
 	if err != nil {
 		return err
 	}
-	if err := c.Watch(&source.Informer{Informer: configMaps.Informer()}, &handler.EnqueueRequestForObject{}); err != nil {
+	if err := c.Watch(&source.Informer{Informer: configMaps.Informer()}, controllers.NamedResourceHandler(targetConfigMap)); err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Informer{Informer: sourceConfigMaps.Informer()}, controllers.NamedResourceHandler(controlPlaneOperatorConfig)); err != nil {
 		return err
 	}
 	return nil
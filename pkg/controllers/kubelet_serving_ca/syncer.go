@@ -1,6 +1,10 @@
 package kubelet_serving_ca
 
 import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -9,53 +13,164 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeclient "k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
-// syncInterval is the amount of time to use between checks
-var syncInterval = 20 * time.Minute
+// DefaultSyncInterval is the default amount of time to use between checks
+const DefaultSyncInterval = 20 * time.Minute
 
-// controlPlaneOperatorConfig is the name of the source configmap on the management cluster
-const controlPlaneOperatorConfig = "control-plane-operator"
+const (
+	// controlPlaneOperatorConfig is the name of the source configmap on the management cluster
+	controlPlaneOperatorConfig = "control-plane-operator"
 
+	// sourceCAKey is the key of the CA bundle in the source configmap
+	sourceCAKey = "initial-ca.crt"
+
+	// targetConfigMap is the name of the kubelet serving CA configmap in the target cluster
+	targetConfigMap = "kubelet-serving-ca"
+
+	// targetCAKey is the key of the CA bundle in the target configmap
+	targetCAKey = "ca-bundle.crt"
+)
+
+// KubeletServingCASyncer keeps the kubelet serving CA bundle of the target cluster in sync
+// with the CAs of the control plane, which sign the serving certificates of the kubelets.
+// CAs that were rotated out of the control plane stay in the bundle until they expire, so
+// that kubelets keep being trusted until they renew their serving certificates.
 type KubeletServingCASyncer struct {
 	TargetClient kubeclient.Interface
 	Log          logr.Logger
 	InitialCA    string
+
+	// ConfigMapLister lists configmaps in the control plane namespace on the management
+	// cluster. The CAs are read from its control-plane-operator configmap, or are the initial
+	// CA if it does not exist.
+	ConfigMapLister corelisters.ConfigMapLister
+
+	// Namespace is the namespace where the control plane of the cluster lives on the
+	// management server
+	Namespace string
+
+	// SyncInterval is the amount of time between checks of the target configmap
+	SyncInterval time.Duration
 }
 
 func (s *KubeletServingCASyncer) Reconcile(req ctrl.Request) (ctrl.Result, error) {
-	targetConfigMap, err := s.TargetClient.CoreV1().ConfigMaps("openshift-config-managed").Get("kubelet-serving-ca", metav1.GetOptions{})
+	sourceCA, err := s.sourceCA()
+	if err != nil {
+		return s.result(err)
+	}
+	targetConfigMap, err := s.TargetClient.CoreV1().ConfigMaps(ManagedConfigNamespace).Get(targetConfigMap, metav1.GetOptions{})
 	if err != nil && !errors.IsNotFound(err) {
-		return result(err)
+		return s.result(err)
 	}
-	expectedConfigMap := s.expectedConfigMap()
 	if err != nil {
 		s.Log.Info("target configmap not found, creating it")
-		_, err = s.TargetClient.CoreV1().ConfigMaps("openshift-config-managed").Create(expectedConfigMap)
-		return result(err)
+		_, err = s.TargetClient.CoreV1().ConfigMaps(ManagedConfigNamespace).Create(s.expectedConfigMap(sourceCA))
+		return s.result(err)
+	}
+	bundle, err := caBundle(sourceCA, targetConfigMap.Data[targetCAKey], time.Now())
+	if err != nil {
+		return s.result(err)
 	}
-	if targetConfigMap.Data["ca-bundle.crt"] != expectedConfigMap.Data["ca-bundle.crt"] {
-		targetConfigMap.Data["ca-bundle.crt"] = expectedConfigMap.Data["ca-bundle.crt"]
-		_, err = s.TargetClient.CoreV1().ConfigMaps("openshift-config-managed").Update(targetConfigMap)
-		return result(err)
+	if targetConfigMap.Data[targetCAKey] != bundle {
+		s.Log.Info("Updating kubelet serving CA bundle")
+		if targetConfigMap.Data == nil {
+			targetConfigMap.Data = map[string]string{}
+		}
+		targetConfigMap.Data[targetCAKey] = bundle
+		_, err = s.TargetClient.CoreV1().ConfigMaps(ManagedConfigNamespace).Update(targetConfigMap)
+		return s.result(err)
 	}
-	return result(nil)
+	return s.result(nil)
 }
 
-func result(err error) (ctrl.Result, error) {
+func (s *KubeletServingCASyncer) result(err error) (ctrl.Result, error) {
 	if err != nil {
 		return ctrl.Result{}, err
 	}
-	return ctrl.Result{RequeueAfter: syncInterval}, nil
+	interval := s.SyncInterval
+	if interval == 0 {
+		interval = DefaultSyncInterval
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
 }
 
-func (s *KubeletServingCASyncer) expectedConfigMap() *corev1.ConfigMap {
+// sourceCA returns the current CAs of the control plane
+func (s *KubeletServingCASyncer) sourceCA() (string, error) {
+	if s.ConfigMapLister == nil {
+		return s.InitialCA, nil
+	}
+	cm, err := s.ConfigMapLister.ConfigMaps(s.Namespace).Get(controlPlaneOperatorConfig)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return s.InitialCA, nil
+		}
+		return "", err
+	}
+	if ca := cm.Data[sourceCAKey]; len(ca) > 0 {
+		return ca, nil
+	}
+	return s.InitialCA, nil
+}
+
+func (s *KubeletServingCASyncer) expectedConfigMap(ca string) *corev1.ConfigMap {
 	cm := &corev1.ConfigMap{}
-	cm.Name = "kubelet-serving-ca"
-	cm.Namespace = "openshift-config-managed"
+	cm.Name = targetConfigMap
+	cm.Namespace = ManagedConfigNamespace
 	cm.Data = map[string]string{
-		"ca-bundle.crt": s.InitialCA,
+		targetCAKey: ca,
 	}
 	return cm
 }
+
+// caBundle returns the source CAs followed by the CAs of the current bundle that are not in
+// the source and have not expired yet
+func caBundle(source, current string, now time.Time) (string, error) {
+	sourceCerts, err := parseCerts([]byte(source))
+	if err != nil {
+		return "", fmt.Errorf("cannot parse the CAs of the control plane: %v", err)
+	}
+	// A current bundle that cannot be parsed is replaced
+	currentCerts, _ := parseCerts([]byte(current))
+	bundle := bytes.NewBufferString(source)
+	for _, cert := range currentCerts {
+		if !cert.IsCA || now.After(cert.NotAfter) || contains(sourceCerts, cert) {
+			continue
+		}
+		if bundle.Len() > 0 && !bytes.HasSuffix(bundle.Bytes(), []byte("\n")) {
+			bundle.WriteString("\n")
+		}
+		pem.Encode(bundle, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	return bundle.String(), nil
+}
+
+func parseCerts(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+}
+
+func contains(certs []*x509.Certificate, cert *x509.Certificate) bool {
+	for _, c := range certs {
+		if c.Equal(cert) {
+			return true
+		}
+	}
+	return false
+}
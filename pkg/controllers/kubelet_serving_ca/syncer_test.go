@@ -0,0 +1,38 @@
+package kubelet_serving_ca
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openshift/hypershift-toolkit/pkg/pki/util"
+)
+
+func TestCABundle(t *testing.T) {
+	now := time.Now()
+	ca := func(name string, validity time.Duration) string {
+		c, err := util.GenerateCA(name, "openshift", util.CertOptions{CAValidity: validity, KeyType: util.KeyTypeECDSAP256})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(util.CertToPem(c.Cert))
+	}
+	rootCA, signerCA := ca("root-ca", time.Hour), ca("cluster-signer", time.Hour)
+	oldSignerCA, expiredCA := ca("old-cluster-signer", time.Hour), ca("expired", time.Hour)
+	source := rootCA + signerCA
+
+	bundle, err := caBundle(source, "", now)
+	if err != nil || bundle != source {
+		t.Errorf("expected the source CAs for an empty bundle, got %v", err)
+	}
+	bundle, err = caBundle(source, source, now)
+	if err != nil || bundle != source {
+		t.Errorf("expected an up to date bundle to be kept, got %v", err)
+	}
+	bundle, err = caBundle(source, rootCA+oldSignerCA, now)
+	if err != nil || bundle != source+oldSignerCA {
+		t.Errorf("expected a rotated CA to stay in the bundle until it expires, got %v", err)
+	}
+	if bundle, err = caBundle(source, rootCA+oldSignerCA+expiredCA, now.Add(2*time.Hour)); err != nil || bundle != source {
+		t.Errorf("expected expired CAs to be removed from the bundle, got %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package nodegc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubeclient "k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// syncInterval is how often the nodes are compared with the machines regardless of
+	// machine events, since the node of a deleted machine is only removed once it is not ready
+	syncInterval = time.Minute
+
+	// machineSetLabel is the label with the machineset of a machine
+	machineSetLabel = "machine.openshift.io/cluster-api-machineset"
+)
+
+// NodeGC deletes the nodes of the target cluster whose machines were removed from the
+// management cluster, which would otherwise stay NotReady after a scale-down since the
+// machine controller of the management cluster cannot reach them
+type NodeGC struct {
+	// Nodes lists the nodes of the target cluster
+	Nodes corelisters.NodeLister
+
+	// KubeClient is a client of the target cluster
+	KubeClient kubeclient.Interface
+
+	// MachineSets and Machines are stores of the worker machinesets of the cluster and of
+	// the machines of the management cluster
+	MachineSets cache.Store
+	Machines    cache.Store
+
+	Log logr.Logger
+
+	trigger chan struct{}
+}
+
+// NewNodeGC returns a node garbage collector for the given listers and stores
+func NewNodeGC(nodes corelisters.NodeLister, client kubeclient.Interface, machineSets, machines cache.Store, log logr.Logger) *NodeGC {
+	return &NodeGC{
+		Nodes:       nodes,
+		KubeClient:  client,
+		MachineSets: machineSets,
+		Machines:    machines,
+		Log:         log,
+		trigger:     make(chan struct{}, 1),
+	}
+}
+
+// Trigger requests a sync without blocking. Requests made while a sync is pending are merged.
+func (gc *NodeGC) Trigger() {
+	select {
+	case gc.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Triggered returns the channel that receives sync requests
+func (gc *NodeGC) Triggered() <-chan struct{} {
+	return gc.trigger
+}
+
+// Run performs a single sync, logging any error
+func (gc *NodeGC) Run() {
+	if err := gc.Sync(); err != nil {
+		gc.Log.Error(err, "Node garbage collection failed")
+	}
+}
+
+// Sync deletes the orphaned nodes of the cluster
+func (gc *NodeGC) Sync() error {
+	nodes, err := gc.Nodes.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	var errs []string
+	for _, node := range orphanedNodes(nodes, gc.workerMachines(), gc.Machines.List()) {
+		gc.Log.Info("Deleting node of a removed machine", "node", node.Name, "providerID", node.Spec.ProviderID)
+		uid := node.UID
+		err := gc.KubeClient.CoreV1().Nodes().Delete(node.Name, &metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+		if err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, fmt.Sprintf("cannot delete node %s: %v", node.Name, err))
+			continue
+		}
+		nodesDeleted.Inc()
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// workerMachines returns the machines of the worker machinesets of the cluster
+func (gc *NodeGC) workerMachines() []interface{} {
+	machineSetNames := sets.NewString()
+	for _, obj := range gc.MachineSets.List() {
+		if machineSet, ok := obj.(*unstructured.Unstructured); ok {
+			machineSetNames.Insert(machineSet.GetName())
+		}
+	}
+	var workers []interface{}
+	for _, obj := range gc.Machines.List() {
+		if machine, ok := obj.(*unstructured.Unstructured); ok && machineSetNames.Has(machine.GetLabels()[machineSetLabel]) {
+			workers = append(workers, machine)
+		}
+	}
+	return workers
+}
+
+// orphanedNodes returns the nodes that were machines of the cluster and whose machines no
+// longer exist. A node is only orphaned if its provider ID is of the same provider as the
+// current worker machines of the cluster, no machine of the management cluster has it, and it
+// is not ready, so that nodes that are not machines of the management cluster are kept. Nodes
+// of clusters without worker machines are never orphaned.
+func orphanedNodes(nodes []*corev1.Node, workers, machines []interface{}) []*corev1.Node {
+	providers := sets.NewString()
+	for _, obj := range workers {
+		if provider, _ := splitProviderID(machineProviderID(obj)); len(provider) > 0 {
+			providers.Insert(provider)
+		}
+	}
+	if providers.Len() == 0 {
+		return nil
+	}
+	instances := sets.NewString()
+	for _, obj := range machines {
+		if provider, instance := splitProviderID(machineProviderID(obj)); len(provider) > 0 {
+			instances.Insert(provider + "/" + instance)
+		}
+	}
+	var orphaned []*corev1.Node
+	for _, node := range nodes {
+		provider, instance := splitProviderID(node.Spec.ProviderID)
+		if !providers.Has(provider) || instances.Has(provider+"/"+instance) {
+			continue
+		}
+		if node.DeletionTimestamp != nil || isReady(node) {
+			continue
+		}
+		orphaned = append(orphaned, node)
+	}
+	return orphaned
+}
+
+func machineProviderID(obj interface{}) string {
+	machine, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return ""
+	}
+	providerID, _, _ := unstructured.NestedString(machine.Object, "spec", "providerID")
+	return providerID
+}
+
+// splitProviderID returns the provider and the instance of a provider ID, such as aws and
+// i-0123456789abcdef0 for aws:///us-east-1a/i-0123456789abcdef0. Machines and nodes may
+// have the zone in their provider IDs or not.
+func splitProviderID(providerID string) (string, string) {
+	parts := strings.SplitN(providerID, "://", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 {
+		return "", ""
+	}
+	path := strings.TrimRight(parts[1], "/")
+	instance := path[strings.LastIndex(path, "/")+1:]
+	if len(instance) == 0 {
+		return "", ""
+	}
+	return parts[0], instance
+}
+
+func isReady(node *corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
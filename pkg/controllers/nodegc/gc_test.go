@@ -0,0 +1,65 @@
+package nodegc
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestOrphanedNodes(t *testing.T) {
+	machine := func(name, providerID string) interface{} {
+		m := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		m.SetName(name)
+		unstructured.SetNestedField(m.Object, providerID, "spec", "providerID")
+		return m
+	}
+	node := func(name, providerID string, ready corev1.ConditionStatus) *corev1.Node {
+		n := &corev1.Node{}
+		n.Name = name
+		n.Spec.ProviderID = providerID
+		n.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}
+		return n
+	}
+	worker := machine("mgmt-example-worker-1", "aws:///us-east-1a/i-0000000000000001")
+	workers := []interface{}{worker}
+	machines := []interface{}{worker, machine("mgmt-worker-1", "aws:///us-east-1a/i-0000000000000002")}
+	nodes := []*corev1.Node{
+		node("existing", "aws:///us-east-1a/i-0000000000000001", corev1.ConditionTrue),
+		node("existing-without-zone", "aws:///i-0000000000000001", corev1.ConditionUnknown),
+		node("other-cluster-machine", "aws:///us-east-1a/i-0000000000000002", corev1.ConditionUnknown),
+		node("removed", "aws:///us-east-1a/i-0000000000000003", corev1.ConditionUnknown),
+		node("removed-ready", "aws:///us-east-1a/i-0000000000000004", corev1.ConditionTrue),
+		node("other-provider", "kubevirt://worker-1", corev1.ConditionFalse),
+		node("no-provider", "", corev1.ConditionFalse),
+	}
+
+	orphaned := orphanedNodes(nodes, workers, machines)
+	if len(orphaned) != 1 || orphaned[0].Name != "removed" {
+		t.Errorf("expected only the not ready node of a removed machine to be orphaned, got %v", orphaned)
+	}
+	if orphaned := orphanedNodes(nodes, nil, machines); len(orphaned) > 0 {
+		t.Errorf("expected no orphaned nodes without worker machines, got %v", orphaned)
+	}
+}
+
+func TestSplitProviderID(t *testing.T) {
+	tests := []struct {
+		providerID string
+		provider   string
+		instance   string
+	}{
+		{"aws:///us-east-1a/i-0123456789abcdef0", "aws", "i-0123456789abcdef0"},
+		{"aws:///i-0123456789abcdef0", "aws", "i-0123456789abcdef0"},
+		{"gce://project/us-central1-a/worker-1", "gce", "worker-1"},
+		{"i-0123456789abcdef0", "", ""},
+		{"aws:///", "", ""},
+		{"", "", ""},
+	}
+	for _, test := range tests {
+		provider, instance := splitProviderID(test.providerID)
+		if provider != test.provider || instance != test.instance {
+			t.Errorf("%q: expected %q, %q, got %q, %q", test.providerID, test.provider, test.instance, provider, instance)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package nodegc
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var nodesDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "hypershift_control_plane_operator_orphaned_nodes_deleted_total",
+	Help: "Number of nodes of the target cluster that were deleted because their machines were removed",
+})
+
+func init() {
+	metrics.Registry.MustRegister(nodesDeleted)
+}
@@ -0,0 +1,72 @@
+package nodegc
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/openshift/hypershift-toolkit/pkg/cmd/cpoperator"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/autoapprover"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/awsinfra"
+)
+
+// Setup sets up a controller that watches the machines of the management cluster and
+// deletes the nodes of the target cluster whose machines were removed. It does nothing if
+// the control plane operator is not allowed to read the machines.
+func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
+	logger := cfg.Logger().WithName("NodeGC")
+	client, err := dynamic.NewForConfig(cfg.Config())
+	if err != nil {
+		return err
+	}
+	selector := fmt.Sprintf("%s=%s", autoapprover.MachineSetClusterLabel, cfg.Namespace())
+	machineSets := client.Resource(awsinfra.MachineResource.GroupVersion().WithResource("machinesets")).Namespace(awsinfra.MachineNamespace)
+	machines := client.Resource(awsinfra.MachineResource).Namespace(awsinfra.MachineNamespace)
+	_, err = machineSets.List(metav1.ListOptions{LabelSelector: selector, Limit: 1})
+	if err == nil {
+		_, err = machines.List(metav1.ListOptions{Limit: 1})
+	}
+	if err != nil {
+		if errors.IsForbidden(err) || errors.IsNotFound(err) {
+			logger.Info("The machines of the management cluster cannot be read, nodes of removed machines are not deleted", "error", err.Error())
+			return nil
+		}
+		return err
+	}
+
+	informerFactory := informers.NewSharedInformerFactory(cfg.TargetKubeClient(), controllers.DefaultResync)
+	nodeInformer := informerFactory.Core().V1().Nodes()
+	machineSetInformer := controllers.NewUnstructuredInformer(machineSets, selector, controllers.DefaultResync)
+	machineInformer := controllers.NewUnstructuredInformer(machines, "", controllers.DefaultResync)
+	gc := NewNodeGC(nodeInformer.Lister(), cfg.TargetKubeClient(), machineSetInformer.GetStore(), machineInformer.GetStore(), logger)
+	machineInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(interface{}) { gc.Trigger() },
+	})
+	return cfg.Manager().Add(manager.RunnableFunc(func(stopCh <-chan struct{}) error {
+		informerFactory.Start(stopCh)
+		go machineSetInformer.Run(stopCh)
+		go machineInformer.Run(stopCh)
+		if !cache.WaitForCacheSync(stopCh, nodeInformer.Informer().HasSynced, machineSetInformer.HasSynced, machineInformer.HasSynced) {
+			return nil
+		}
+		ticker := time.NewTicker(syncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return nil
+			case <-gc.Triggered():
+				gc.Run()
+			case <-ticker.C:
+				gc.Run()
+			}
+		}
+	}))
+}
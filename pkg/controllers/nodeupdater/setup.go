@@ -0,0 +1,52 @@
+package nodeupdater
+
+import (
+	"time"
+
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/openshift/hypershift-toolkit/pkg/cmd/cpoperator"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers"
+)
+
+// Setup sets up a controller that updates the workers of the target cluster to the OS image
+// of the node-updater configmap in the control plane namespace. It does nothing for clusters
+// without the configmap.
+func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
+	informerFactory := informers.NewSharedInformerFactory(cfg.TargetKubeClient(), controllers.DefaultResync)
+	nodeInformer := informerFactory.Core().V1().Nodes()
+	configMapInformer := cfg.KubeInformers().Core().V1().ConfigMaps()
+	updater := NewNodeUpdater(nodeInformer.Lister(), configMapInformer.Lister(), cfg.TargetKubeClient(), cfg.Namespace(), cfg.Logger().WithName("NodeUpdater"))
+	// Nodes update their status all the time, so their changes are only seen by the
+	// periodic syncs, which a drain needs anyway
+	configMapInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			accessor, ok := obj.(interface{ GetName() string })
+			return ok && accessor.GetName() == ConfigMapName
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(interface{}) { updater.Trigger() },
+			UpdateFunc: func(interface{}, interface{}) { updater.Trigger() },
+		},
+	})
+	return cfg.Manager().Add(manager.RunnableFunc(func(stopCh <-chan struct{}) error {
+		informerFactory.Start(stopCh)
+		if !cache.WaitForCacheSync(stopCh, nodeInformer.Informer().HasSynced, configMapInformer.Informer().HasSynced) {
+			return nil
+		}
+		ticker := time.NewTicker(syncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return nil
+			case <-updater.Triggered():
+				updater.Run()
+			case <-ticker.C:
+				updater.Run()
+			}
+		}
+	}))
+}
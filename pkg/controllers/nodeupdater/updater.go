@@ -0,0 +1,299 @@
+package nodeupdater
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	kubeclient "k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	// ConfigMapName is the name of the configmap in the control plane namespace with the OS
+	// image that the workers are updated to
+	ConfigMapName = "node-updater"
+
+	// DesiredOSImageAnnotation is set by the node updater on a drained node to the OS image
+	// that the node updater daemon of the node rebases it to
+	DesiredOSImageAnnotation = "hypershift.openshift.io/desired-os-image"
+
+	// CurrentOSImageAnnotation is set by the node updater daemon of a node to the OS image
+	// that it booted, which is empty for the boot image of the node
+	CurrentOSImageAnnotation = "hypershift.openshift.io/current-os-image"
+
+	// StateAnnotation is set by the node updater daemon of a node to Done, Working or
+	// Degraded
+	StateAnnotation = "hypershift.openshift.io/os-update-state"
+
+	// CordonedAnnotation marks the nodes cordoned by the node updater, which it uncordons
+	// once they are updated
+	CordonedAnnotation = "hypershift.openshift.io/node-updater-cordoned"
+
+	StateDone     = "Done"
+	StateWorking  = "Working"
+	StateDegraded = "Degraded"
+
+	// syncInterval is how often the nodes are compared with the OS image regardless of node
+	// events, since a drain waits for pods to be evicted
+	syncInterval = 30 * time.Second
+
+	mirrorPodAnnotation = "kubernetes.io/config.mirror"
+)
+
+// Settings are the OS image of the workers and the number of workers updated at a time
+type Settings struct {
+	OSImageURL     string
+	MaxUnavailable int
+}
+
+// NodeUpdater updates the workers of the target cluster to the OS image of the release a few
+// at a time. It cordons and drains a worker, then sets its desired OS image, which the node
+// updater daemon of the worker rebases it to before it reboots, and uncordons it once the
+// daemon reports the image as booted.
+type NodeUpdater struct {
+	// Nodes lists the nodes of the target cluster
+	Nodes corelisters.NodeLister
+
+	// ConfigMaps lists the configmaps of the control plane namespace
+	ConfigMaps corelisters.ConfigMapLister
+
+	// KubeClient is a client of the target cluster
+	KubeClient kubeclient.Interface
+
+	Namespace string
+	Log       logr.Logger
+
+	trigger chan struct{}
+}
+
+// NewNodeUpdater returns a node updater for the given listers
+func NewNodeUpdater(nodes corelisters.NodeLister, configMaps corelisters.ConfigMapLister, client kubeclient.Interface, namespace string, log logr.Logger) *NodeUpdater {
+	return &NodeUpdater{
+		Nodes:      nodes,
+		ConfigMaps: configMaps,
+		KubeClient: client,
+		Namespace:  namespace,
+		Log:        log,
+		trigger:    make(chan struct{}, 1),
+	}
+}
+
+// Trigger requests a sync without blocking. Requests made while a sync is pending are merged.
+func (u *NodeUpdater) Trigger() {
+	select {
+	case u.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Triggered returns the channel that receives sync requests
+func (u *NodeUpdater) Triggered() <-chan struct{} {
+	return u.trigger
+}
+
+// Run performs a single sync, logging any error
+func (u *NodeUpdater) Run() {
+	if err := u.Sync(); err != nil {
+		u.Log.Error(err, "Node update failed")
+	}
+}
+
+// Sync uncordons the updated nodes, drains the nodes being updated and starts the update of
+// more nodes if fewer than the maximum are unavailable. Nothing is updated without the
+// configmap, which is only rendered for clusters with node updates enabled.
+func (u *NodeUpdater) Sync() error {
+	settings, err := u.settings()
+	if err != nil || settings == nil {
+		return err
+	}
+	nodes, err := u.Nodes.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	updated, updating, next := planUpdates(nodes, settings)
+	var errs []string
+	for _, node := range updated {
+		u.Log.Info("Uncordoning updated node", "node", node.Name, "osImage", settings.OSImageURL)
+		if err := u.patchNode(node.Name, map[string]interface{}{
+			"metadata": map[string]interface{}{"annotations": map[string]interface{}{CordonedAnnotation: nil}},
+			"spec":     map[string]interface{}{"unschedulable": false},
+		}); err != nil {
+			errs = append(errs, fmt.Sprintf("cannot uncordon node %s: %v", node.Name, err))
+		}
+	}
+	for _, node := range next {
+		u.Log.Info("Cordoning node to update", "node", node.Name, "osImage", settings.OSImageURL)
+		if err := u.patchNode(node.Name, map[string]interface{}{
+			"metadata": map[string]interface{}{"annotations": map[string]interface{}{CordonedAnnotation: "true"}},
+			"spec":     map[string]interface{}{"unschedulable": true},
+		}); err != nil {
+			errs = append(errs, fmt.Sprintf("cannot cordon node %s: %v", node.Name, err))
+			continue
+		}
+		updating = append(updating, node)
+	}
+	for _, node := range updating {
+		if node.Annotations[DesiredOSImageAnnotation] == settings.OSImageURL {
+			continue
+		}
+		drained, err := u.drain(node.Name)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("cannot drain node %s: %v", node.Name, err))
+			continue
+		}
+		if !drained {
+			continue
+		}
+		u.Log.Info("Updating drained node", "node", node.Name, "osImage", settings.OSImageURL)
+		if err := u.patchNode(node.Name, map[string]interface{}{
+			"metadata": map[string]interface{}{"annotations": map[string]interface{}{DesiredOSImageAnnotation: settings.OSImageURL}},
+		}); err != nil {
+			errs = append(errs, fmt.Sprintf("cannot set the desired OS image of node %s: %v", node.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// settings reads the settings from the configmap in the control plane namespace, or returns
+// nothing if there is no configmap
+func (u *NodeUpdater) settings() (*Settings, error) {
+	cm, err := u.ConfigMaps.ConfigMaps(u.Namespace).Get(ConfigMapName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot fetch configmap %s: %v", ConfigMapName, err)
+	}
+	return parseSettings(cm.Data)
+}
+
+func parseSettings(data map[string]string) (*Settings, error) {
+	settings := &Settings{OSImageURL: data["os-image-url"], MaxUnavailable: 1}
+	if len(settings.OSImageURL) == 0 {
+		return nil, fmt.Errorf("configmap %s has no os-image-url", ConfigMapName)
+	}
+	if value := data["max-unavailable"]; len(value) > 0 && value != "0" {
+		maxUnavailable, err := strconv.Atoi(value)
+		if err != nil || maxUnavailable < 0 {
+			return nil, fmt.Errorf("invalid max-unavailable %q in configmap %s", value, ConfigMapName)
+		}
+		settings.MaxUnavailable = maxUnavailable
+	}
+	return settings, nil
+}
+
+// planUpdates returns the nodes cordoned by the node updater that booted the OS image and
+// can be uncordoned, those that are still being updated, and the nodes to start updating,
+// in the order of their names. Only nodes whose daemon reported their OS image are updated,
+// and no update is started while a node is degraded, so that a bad OS image does not make
+// more nodes unavailable. Nodes cordoned by others are left alone.
+func planUpdates(nodes []*corev1.Node, settings *Settings) (updated, updating, next []*corev1.Node) {
+	sorted := make([]*corev1.Node, len(nodes))
+	copy(sorted, nodes)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	degraded := false
+	var outdated []*corev1.Node
+	for _, node := range sorted {
+		if node.Annotations[StateAnnotation] == StateDegraded {
+			degraded = true
+		}
+		current, reported := node.Annotations[CurrentOSImageAnnotation]
+		if _, cordoned := node.Annotations[CordonedAnnotation]; cordoned {
+			if current == settings.OSImageURL && node.Annotations[StateAnnotation] == StateDone {
+				updated = append(updated, node)
+			} else {
+				updating = append(updating, node)
+			}
+			continue
+		}
+		if reported && current != settings.OSImageURL && !node.Spec.Unschedulable && node.DeletionTimestamp == nil && isReady(node) {
+			outdated = append(outdated, node)
+		}
+	}
+	if degraded {
+		return updated, updating, nil
+	}
+	for _, node := range outdated {
+		if len(updating)+len(next) >= settings.MaxUnavailable {
+			break
+		}
+		next = append(next, node)
+	}
+	return updated, updating, next
+}
+
+// drain evicts the pods of a node that are not mirror or daemonset pods, and returns whether
+// none are left. Evictions refused by disruption budgets are retried by the next sync.
+func (u *NodeUpdater) drain(name string) (bool, error) {
+	pods, err := u.KubeClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", name).String(),
+	})
+	if err != nil {
+		return false, err
+	}
+	remaining := 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !evictable(pod) {
+			continue
+		}
+		remaining++
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		eviction := &policyv1beta1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace}}
+		if err := u.KubeClient.PolicyV1beta1().Evictions(pod.Namespace).Evict(eviction); err != nil && !errors.IsNotFound(err) && !errors.IsTooManyRequests(err) {
+			return false, fmt.Errorf("cannot evict pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	return remaining == 0, nil
+}
+
+func evictable(pod *corev1.Pod) bool {
+	if _, mirror := pod.Annotations[mirrorPodAnnotation]; mirror {
+		return false
+	}
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == "DaemonSet" {
+			return false
+		}
+	}
+	return true
+}
+
+func (u *NodeUpdater) patchNode(name string, patch map[string]interface{}) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = u.KubeClient.CoreV1().Nodes().Patch(name, types.MergePatchType, data)
+	return err
+}
+
+func isReady(node *corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package nodeupdater
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPlanUpdates(t *testing.T) {
+	const image = "quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:0123"
+	node := func(name string, annotations map[string]string, unschedulable bool) *corev1.Node {
+		n := &corev1.Node{}
+		n.Name = name
+		n.Annotations = annotations
+		n.Spec.Unschedulable = unschedulable
+		n.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+		return n
+	}
+	outdated := map[string]string{CurrentOSImageAnnotation: "", StateAnnotation: StateDone}
+	nodes := []*corev1.Node{
+		node("worker-5", outdated, false),
+		node("worker-4", outdated, false),
+		node("worker-3", map[string]string{CurrentOSImageAnnotation: image, StateAnnotation: StateDone}, false),
+		node("worker-2", map[string]string{CurrentOSImageAnnotation: image, StateAnnotation: StateDone, CordonedAnnotation: "true"}, true),
+		node("worker-1", map[string]string{CurrentOSImageAnnotation: "", DesiredOSImageAnnotation: image, StateAnnotation: StateWorking, CordonedAnnotation: "true"}, true),
+		node("cordoned", outdated, true),
+		node("unreported", nil, false),
+	}
+
+	updated, updating, next := planUpdates(nodes, &Settings{OSImageURL: image, MaxUnavailable: 2})
+	if len(updated) != 1 || updated[0].Name != "worker-2" {
+		t.Errorf("expected worker-2 to be updated, got %v", names(updated))
+	}
+	if len(updating) != 1 || updating[0].Name != "worker-1" {
+		t.Errorf("expected worker-1 to be updating, got %v", names(updating))
+	}
+	if len(next) != 1 || next[0].Name != "worker-4" {
+		t.Errorf("expected worker-4 to be updated next, got %v", names(next))
+	}
+
+	nodes[0].Annotations = map[string]string{CurrentOSImageAnnotation: "", StateAnnotation: StateDegraded}
+	if _, _, next = planUpdates(nodes, &Settings{OSImageURL: image, MaxUnavailable: 2}); len(next) > 0 {
+		t.Errorf("expected no update to start while a node is degraded, got %v", names(next))
+	}
+}
+
+func TestParseSettings(t *testing.T) {
+	settings, err := parseSettings(map[string]string{"os-image-url": "quay.io/os", "max-unavailable": "0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if settings.OSImageURL != "quay.io/os" || settings.MaxUnavailable != 1 {
+		t.Errorf("unexpected settings: %+v", settings)
+	}
+	if _, err = parseSettings(map[string]string{"os-image-url": "quay.io/os", "max-unavailable": "all"}); err == nil {
+		t.Errorf("expected an error for an invalid max-unavailable")
+	}
+	if _, err = parseSettings(map[string]string{}); err == nil {
+		t.Errorf("expected an error without an OS image")
+	}
+}
+
+func names(nodes []*corev1.Node) []string {
+	var result []string
+	for _, node := range nodes {
+		result = append(result, node.Name)
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package oauthendpoint
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+	"github.com/openshift/hypershift-toolkit/pkg/cmd/cpoperator"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers"
+)
+
+// routeResource is the resource of the routes of the management cluster
+var routeResource = schema.GroupVersionResource{Group: "route.openshift.io", Version: "v1", Resource: "routes"}
+
+func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
+	configMaps := cfg.KubeInformers().Core().V1().ConfigMaps()
+	secrets := cfg.KubeInformers().Core().V1().Secrets()
+	reconciler := &OAuthEndpointSyncer{
+		Client:          cfg.KubeClient(),
+		ConfigMapLister: configMaps.Lister(),
+		Namespace:       cfg.Namespace(),
+		Log:             cfg.Logger().WithName("OAuthEndpoint"),
+	}
+	c, err := controllers.NewController("oauth-endpoint", cfg.Manager(), reconciler, cfg.Logger().WithName("oauth-endpoint"))
+	if err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Informer{Informer: configMaps.Informer()}, controllers.NamedResourceHandler(oauthMetadataConfigMap, oauthConfigConfigMap)); err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Informer{Informer: secrets.Informer()}, controllers.NamedResourceHandler(installer.ClusterParamsSecretName)); err != nil {
+		return err
+	}
+
+	client, err := dynamic.NewForConfig(cfg.Config())
+	if err != nil {
+		return err
+	}
+	routes := client.Resource(routeResource).Namespace(cfg.Namespace())
+	if _, err = routes.List(metav1.ListOptions{Limit: 1}); err != nil {
+		if errors.IsNotFound(err) || errors.IsForbidden(err) {
+			reconciler.Log.Info("The routes of the management cluster cannot be read, the external OAuth endpoint is taken from the cluster parameters", "error", err.Error())
+			return nil
+		}
+		return err
+	}
+	routeInformer := controllers.NewUnstructuredInformer(routes, "", controllers.DefaultResync)
+	reconciler.Routes = routeInformer.GetStore()
+	reconciler.RoutesSynced = routeInformer.HasSynced
+	if err := cfg.Manager().Add(manager.RunnableFunc(func(stopCh <-chan struct{}) error {
+		routeInformer.Run(stopCh)
+		return nil
+	})); err != nil {
+		return err
+	}
+	return c.Watch(&source.Informer{Informer: routeInformer}, controllers.NamedResourceHandler(RouteName))
+}
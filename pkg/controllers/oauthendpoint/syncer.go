@@ -0,0 +1,227 @@
+package oauthendpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kubeclient "k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/contrib/pkg/installer"
+)
+
+const (
+	// RouteName is the name of the passthrough route of the management cluster that exposes
+	// the OAuth server of clusters installed with routes
+	RouteName = "oauth"
+
+	// oauthMetadataConfigMap holds the OAuth metadata that the kube-apiserver serves
+	oauthMetadataConfigMap = "kube-apiserver-oauth-metadata"
+	oauthMetadataKey       = "oauthMetadata.json"
+
+	// oauthConfigConfigMap holds the configuration of the OAuth server
+	oauthConfigConfigMap = "oauth-openshift-config"
+	oauthConfigKey       = "config.yaml"
+
+	kubeAPIServerDeployment = "kube-apiserver"
+	oauthServerDeployment   = "oauth-openshift"
+
+	// hostAnnotation records the external OAuth host in the pod templates of the deployments
+	// that were restarted to load it
+	hostAnnotation = "hypershift.openshift.io/oauth-host"
+
+	// routesSyncRetryInterval is how often a sync is retried until the routes are known
+	routesSyncRetryInterval = 5 * time.Second
+)
+
+// OAuthEndpointSyncer keeps the OAuth metadata of the kube-apiserver and the public URL of the
+// OAuth server consistent with the external endpoint of the OAuth server, and restarts them
+// when it changes. The endpoint is the host of the OAuth route of the management cluster if
+// there is one, and otherwise the external OAuth name or port of the cluster parameters.
+type OAuthEndpointSyncer struct {
+	// Client is a client of the management cluster
+	Client kubeclient.Interface
+
+	// ConfigMapLister lists configmaps in the control plane namespace
+	ConfigMapLister corelisters.ConfigMapLister
+
+	// Routes is a store of the routes in the control plane namespace, or nil if the
+	// management cluster has no routes
+	Routes       cache.Store
+	RoutesSynced cache.InformerSynced
+
+	// Namespace is the namespace where the control plane of the cluster lives on the
+	// management server
+	Namespace string
+
+	Log logr.Logger
+}
+
+func (s *OAuthEndpointSyncer) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	// Without the route, the endpoint of the cluster parameters would replace its host
+	if s.RoutesSynced != nil && !s.RoutesSynced() {
+		return ctrl.Result{RequeueAfter: routesSyncRetryInterval}, nil
+	}
+	host, err := s.externalHost()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(host) == 0 {
+		s.Log.V(1).Info("No external OAuth endpoint found")
+		return ctrl.Result{}, nil
+	}
+	metadataChanged, err := s.updateConfigMap(oauthMetadataConfigMap, oauthMetadataKey, host, updateOAuthMetadata)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	configChanged, err := s.updateConfigMap(oauthConfigConfigMap, oauthConfigKey, host, updateOAuthConfig)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if err = s.restartDeployment(kubeAPIServerDeployment, host, metadataChanged); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err = s.restartDeployment(oauthServerDeployment, host, configChanged); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// externalHost returns the host and, if it is not 443, the port of the external endpoint of
+// the OAuth server
+func (s *OAuthEndpointSyncer) externalHost() (string, error) {
+	if s.Routes != nil {
+		obj, exists, err := s.Routes.GetByKey(s.Namespace + "/" + RouteName)
+		if err != nil {
+			return "", err
+		}
+		if route, ok := obj.(*unstructured.Unstructured); exists && ok {
+			if host, _, _ := unstructured.NestedString(route.Object, "spec", "host"); len(host) > 0 {
+				return host, nil
+			}
+		}
+	}
+	params, err := installer.GetClusterParams(s.Client, s.Namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("cannot get cluster parameters: %v", err)
+	}
+	if len(params.ExternalOauthDNSName) == 0 && (len(params.ExternalAPIDNSName) == 0 || params.ExternalOauthPort == 0) {
+		return "", nil
+	}
+	return params.ExternalOauthHost(), nil
+}
+
+// updateConfigMap updates a key of a configmap of the control plane for the external host
+// and returns whether it changed
+func (s *OAuthEndpointSyncer) updateConfigMap(name, key, host string, update func(string, string) (string, bool, error)) (bool, error) {
+	cm, err := s.ConfigMapLister.ConfigMaps(s.Namespace).Get(name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	data, changed, err := update(cm.Data[key], host)
+	if err != nil {
+		return false, fmt.Errorf("cannot update %s of configmap %s: %v", key, name, err)
+	}
+	if !changed {
+		return false, nil
+	}
+	cm = cm.DeepCopy()
+	cm.Data[key] = data
+	s.Log.Info("Updating external OAuth endpoint", "configmap", name, "host", host)
+	if _, err = s.Client.CoreV1().ConfigMaps(s.Namespace).Update(cm); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// restartDeployment records the external host in the pod template of a deployment when
+// its configuration changed or the recorded host is out of date
+func (s *OAuthEndpointSyncer) restartDeployment(name, host string, changed bool) error {
+	deployment, err := s.Client.AppsV1().Deployments(s.Namespace).Get(name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	current, recorded := deployment.Spec.Template.ObjectMeta.Annotations[hostAnnotation]
+	if current == host || (!changed && !recorded) {
+		return nil
+	}
+	if deployment.Spec.Template.ObjectMeta.Annotations == nil {
+		deployment.Spec.Template.ObjectMeta.Annotations = map[string]string{}
+	}
+	deployment.Spec.Template.ObjectMeta.Annotations[hostAnnotation] = host
+	s.Log.Info("Restarting deployment to load the external OAuth endpoint", "deployment", name, "host", host)
+	_, err = s.Client.AppsV1().Deployments(s.Namespace).Update(deployment)
+	return err
+}
+
+// updateOAuthMetadata sets the issuer and endpoints of the OAuth metadata to the host
+func updateOAuthMetadata(data, host string) (string, bool, error) {
+	metadata := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(data), &metadata); err != nil {
+		return "", false, err
+	}
+	issuer := "https://" + host
+	expected := map[string]string{
+		"issuer":                 issuer,
+		"authorization_endpoint": issuer + "/oauth/authorize",
+		"token_endpoint":         issuer + "/oauth/token",
+	}
+	changed := false
+	for key, value := range expected {
+		if metadata[key] != value {
+			metadata[key] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return data, false, nil
+	}
+	b, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return "", false, err
+	}
+	return string(b), true, nil
+}
+
+// updateOAuthConfig sets the public and master URLs of the OAuth server config to the host
+func updateOAuthConfig(data, host string) (string, bool, error) {
+	config := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		return "", false, err
+	}
+	url := "https://" + host
+	publicURL, _, _ := unstructured.NestedString(config, "oauthConfig", "masterPublicURL")
+	masterURL, _, _ := unstructured.NestedString(config, "oauthConfig", "masterURL")
+	if publicURL == url && masterURL == url {
+		return data, false, nil
+	}
+	if err := unstructured.SetNestedField(config, url, "oauthConfig", "masterPublicURL"); err != nil {
+		return "", false, err
+	}
+	if err := unstructured.SetNestedField(config, url, "oauthConfig", "masterURL"); err != nil {
+		return "", false, err
+	}
+	b, err := yaml.Marshal(config)
+	if err != nil {
+		return "", false, err
+	}
+	return string(b), true, nil
+}
@@ -0,0 +1,64 @@
+package oauthendpoint
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestUpdateOAuthMetadata(t *testing.T) {
+	data := `{
+"issuer": "https://oauth-old.apps.example.com",
+"authorization_endpoint": "https://oauth-old.apps.example.com/oauth/authorize",
+"token_endpoint": "https://oauth-old.apps.example.com/oauth/token",
+  "response_types_supported": ["code", "token"]
+}`
+	updated, changed, err := updateOAuthMetadata(data, "oauth-new.apps.example.com")
+	if err != nil || !changed {
+		t.Fatalf("expected the metadata to change, got %t, %v", changed, err)
+	}
+	metadata := map[string]interface{}{}
+	if err = json.Unmarshal([]byte(updated), &metadata); err != nil {
+		t.Fatal(err)
+	}
+	if metadata["issuer"] != "https://oauth-new.apps.example.com" || metadata["token_endpoint"] != "https://oauth-new.apps.example.com/oauth/token" {
+		t.Errorf("unexpected metadata %s", updated)
+	}
+	if _, ok := metadata["response_types_supported"]; !ok {
+		t.Errorf("expected other metadata to be kept, got %s", updated)
+	}
+	if again, changed, err := updateOAuthMetadata(updated, "oauth-new.apps.example.com"); err != nil || changed || again != updated {
+		t.Errorf("expected up to date metadata to be kept, got %t, %v", changed, err)
+	}
+}
+
+func TestUpdateOAuthConfig(t *testing.T) {
+	data := `apiVersion: osin.config.openshift.io/v1
+kind: OsinServerConfig
+oauthConfig:
+  loginURL: https://api.example.com:6443
+  masterPublicURL: https://api.example.com:8443
+  masterURL: https://api.example.com:8443
+`
+	updated, changed, err := updateOAuthConfig(data, "oauth.example.com")
+	if err != nil || !changed {
+		t.Fatalf("expected the config to change, got %t, %v", changed, err)
+	}
+	config := struct {
+		OAuthConfig map[string]string `json:"oauthConfig"`
+	}{}
+	if err = yaml.Unmarshal([]byte(updated), &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.OAuthConfig["masterPublicURL"] != "https://oauth.example.com" || config.OAuthConfig["masterURL"] != "https://oauth.example.com" {
+		t.Errorf("unexpected config %s", updated)
+	}
+	if config.OAuthConfig["loginURL"] != "https://api.example.com:6443" || !strings.Contains(updated, "OsinServerConfig") {
+		t.Errorf("expected the rest of the config to be kept, got %s", updated)
+	}
+	if _, changed, err := updateOAuthConfig(updated, "oauth.example.com"); err != nil || changed {
+		t.Errorf("expected an up to date config to be kept, got %t, %v", changed, err)
+	}
+}
@@ -0,0 +1,166 @@
+package openshift_apiserver
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// ServiceName is the name of the openshift-apiserver service in the control plane
+	// namespace and of the service and endpoints in the target cluster that the APIServices
+	// of the OpenShift APIs point to
+	ServiceName = "openshift-apiserver"
+
+	// TargetNamespace is the namespace of the openshift-apiserver service in the target cluster
+	TargetNamespace = "default"
+
+	// ControlPlaneOperatorConfig is the configmap in the control plane namespace with the
+	// CA bundle of the control plane
+	ControlPlaneOperatorConfig = "control-plane-operator"
+
+	// caKey is the key of the CA bundle in ControlPlaneOperatorConfig
+	caKey = "initial-ca.crt"
+
+	// apiServiceSyncInterval is how often the APIServices are checked, since they are not
+	// watched
+	apiServiceSyncInterval = 10 * time.Minute
+)
+
+// APIServiceResource is the resource of the APIServices of the target cluster
+var APIServiceResource = schema.GroupVersionResource{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"}
+
+// APIServiceSyncer keeps the endpoints of the openshift-apiserver service of the target
+// cluster at the address of the openshift-apiserver service of the control plane, and the
+// CA bundle of the APIServices of the OpenShift APIs in sync with the CAs of the control
+// plane, which sign the serving certificate of the openshift-apiserver.
+type APIServiceSyncer struct {
+	// ServiceLister and ConfigMapLister list services and configmaps in the control plane
+	// namespace on the management cluster
+	ServiceLister   corelisters.ServiceLister
+	ConfigMapLister corelisters.ConfigMapLister
+
+	// Namespace is the namespace where the control plane of the cluster lives on the
+	// management server
+	Namespace string
+
+	TargetClient        kubeclient.Interface
+	TargetDynamicClient dynamic.Interface
+	Log                 logr.Logger
+}
+
+func (s *APIServiceSyncer) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	if err := s.syncEndpoints(); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := s.syncCABundles(); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: apiServiceSyncInterval}, nil
+}
+
+func (s *APIServiceSyncer) syncEndpoints() error {
+	service, err := s.ServiceLister.Services(s.Namespace).Get(ServiceName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("cannot get service %s: %v", ServiceName, err)
+	}
+	if len(service.Spec.ClusterIP) == 0 || service.Spec.ClusterIP == corev1.ClusterIPNone {
+		return nil
+	}
+	subsets := endpointSubsets(service.Spec.ClusterIP)
+	endpoints, err := s.TargetClient.CoreV1().Endpoints(TargetNamespace).Get(ServiceName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		s.Log.Info("Creating the openshift-apiserver endpoints", "address", service.Spec.ClusterIP)
+		endpoints = &corev1.Endpoints{}
+		endpoints.Name = ServiceName
+		endpoints.Namespace = TargetNamespace
+		endpoints.Subsets = subsets
+		_, err = s.TargetClient.CoreV1().Endpoints(TargetNamespace).Create(endpoints)
+		return err
+	}
+	if equality.Semantic.DeepEqual(endpoints.Subsets, subsets) {
+		return nil
+	}
+	s.Log.Info("Updating the openshift-apiserver endpoints", "address", service.Spec.ClusterIP)
+	endpoints = endpoints.DeepCopy()
+	endpoints.Subsets = subsets
+	_, err = s.TargetClient.CoreV1().Endpoints(TargetNamespace).Update(endpoints)
+	return err
+}
+
+// endpointSubsets returns the endpoints of the openshift-apiserver service of the target
+// cluster, the address of the service in the control plane
+func endpointSubsets(address string) []corev1.EndpointSubset {
+	return []corev1.EndpointSubset{
+		{
+			Addresses: []corev1.EndpointAddress{{IP: address}},
+			Ports:     []corev1.EndpointPort{{Name: "https", Port: 443, Protocol: corev1.ProtocolTCP}},
+		},
+	}
+}
+
+func (s *APIServiceSyncer) syncCABundles() error {
+	cm, err := s.ConfigMapLister.ConfigMaps(s.Namespace).Get(ControlPlaneOperatorConfig)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("cannot get configmap %s: %v", ControlPlaneOperatorConfig, err)
+	}
+	caBundle := []byte(cm.Data[caKey])
+	if len(caBundle) == 0 {
+		return nil
+	}
+	apiServices, err := s.TargetDynamicClient.Resource(APIServiceResource).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range apiServices.Items {
+		apiService := &apiServices.Items[i]
+		if !isOpenShiftAPIService(apiService) {
+			continue
+		}
+		// The CA bundle is base64 encoded in unstructured APIServices
+		current, _, _ := unstructured.NestedString(apiService.Object, "spec", "caBundle")
+		if decoded, err := base64.StdEncoding.DecodeString(current); err == nil && bytes.Equal(decoded, caBundle) {
+			continue
+		}
+		s.Log.Info("Updating the CA bundle of APIService", "apiservice", apiService.GetName())
+		apiService = apiService.DeepCopy()
+		if err = unstructured.SetNestedField(apiService.Object, base64.StdEncoding.EncodeToString(caBundle), "spec", "caBundle"); err != nil {
+			return err
+		}
+		if _, err = s.TargetDynamicClient.Resource(APIServiceResource).Update(apiService, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isOpenShiftAPIService returns whether an APIService is served by the openshift-apiserver
+// of the control plane
+func isOpenShiftAPIService(apiService *unstructured.Unstructured) bool {
+	namespace, _, _ := unstructured.NestedString(apiService.Object, "spec", "service", "namespace")
+	name, _, _ := unstructured.NestedString(apiService.Object, "spec", "service", "name")
+	return namespace == TargetNamespace && name == ServiceName
+}
@@ -12,10 +12,15 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
 	kubeinformers "k8s.io/client-go/informers"
 	kubeclient "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 	"sigs.k8s.io/yaml"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
@@ -87,6 +92,47 @@ func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
 	configInformers.Config().V1().Ingresses().Informer().AddEventHandler(c.EventHandler())
 	configInformers.Config().V1().Projects().Informer().AddEventHandler(c.EventHandler())
 	configInformers.Config().V1().Proxies().Informer().AddEventHandler(c.EventHandler())
+	return setupAPIServiceSyncer(cfg)
+}
+
+// setupAPIServiceSyncer watches the openshift-apiserver service and the CAs of the control
+// plane and the openshift-apiserver endpoints of the target cluster
+func setupAPIServiceSyncer(cfg *cpoperator.ControlPlaneOperatorConfig) error {
+	dynamicClient, err := dynamic.NewForConfig(cfg.TargetConfig())
+	if err != nil {
+		return err
+	}
+	services := cfg.KubeInformers().Core().V1().Services()
+	configMaps := cfg.KubeInformers().Core().V1().ConfigMaps()
+	endpoints := cfg.TargetKubeInformersForNamespace(TargetNamespace).Core().V1().Endpoints()
+	reconciler := &APIServiceSyncer{
+		ServiceLister:       services.Lister(),
+		ConfigMapLister:     configMaps.Lister(),
+		Namespace:           cfg.Namespace(),
+		TargetClient:        cfg.TargetKubeClient(),
+		TargetDynamicClient: dynamicClient,
+		Log:                 cfg.Logger().WithName("OpenShiftAPIServices"),
+	}
+	c, err := controllers.NewController("openshift-apiserver-apiservices", cfg.Manager(), reconciler, cfg.Logger().WithName("openshift-apiserver-apiservices"))
+	if err != nil {
+		return err
+	}
+	// All events reconcile the same endpoints and APIServices
+	enqueue := &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+		if name := obj.Meta.GetName(); name != ServiceName && name != ControlPlaneOperatorConfig {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: ServiceName}}}
+	})}
+	if err := c.Watch(&source.Informer{Informer: services.Informer()}, enqueue); err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Informer{Informer: configMaps.Informer()}, enqueue); err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Informer{Informer: endpoints.Informer()}, enqueue); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// retryBaseDelay and retryMaxDelay bound the exponential backoff of failed reconciles
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 5 * time.Minute
+)
+
+var (
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hypershift_control_plane_operator_reconcile_errors_total",
+		Help: "Number of failed reconciles of a controller of the control plane operator",
+	}, []string{"controller"})
+
+	reconcileLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hypershift_control_plane_operator_reconcile_last_success_timestamp_seconds",
+		Help: "The last time that a reconcile of a controller of the control plane operator succeeded",
+	}, []string{"controller"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(reconcileErrors, reconcileLastSuccess)
+}
+
+// NewController returns a controller of a manager whose reconciles are retried and
+// measured the same way as those of the other controllers of the operator
+func NewController(name string, mgr manager.Manager, reconciler reconcile.Reconciler, log logr.Logger) (controller.Controller, error) {
+	return controller.New(name, mgr, controller.Options{Reconciler: NewRetryingReconciler(name, reconciler, log)})
+}
+
+// RetryingReconciler retries the failed reconciles of a request with an exponential backoff
+// from retryBaseDelay to retryMaxDelay, and records the failures and the last success of
+// the controller in metrics
+type RetryingReconciler struct {
+	name       string
+	reconciler reconcile.Reconciler
+	backoff    workqueue.RateLimiter
+	log        logr.Logger
+}
+
+func NewRetryingReconciler(name string, reconciler reconcile.Reconciler, log logr.Logger) *RetryingReconciler {
+	return &RetryingReconciler{
+		name:       name,
+		reconciler: reconciler,
+		backoff:    workqueue.NewItemExponentialFailureRateLimiter(retryBaseDelay, retryMaxDelay),
+		log:        log,
+	}
+}
+
+func (r *RetryingReconciler) Reconcile(req reconcile.Request) (reconcile.Result, error) {
+	result, err := r.reconciler.Reconcile(req)
+	if err != nil {
+		reconcileErrors.WithLabelValues(r.name).Inc()
+		delay := r.backoff.When(req)
+		r.log.Error(err, "Reconcile failed", "request", req.NamespacedName.String(), "retries", r.backoff.NumRequeues(req), "retryAfter", delay.String())
+		return reconcile.Result{RequeueAfter: delay}, nil
+	}
+	r.backoff.Forget(req)
+	reconcileLastSuccess.WithLabelValues(r.name).SetToCurrentTime()
+	return result, nil
+}
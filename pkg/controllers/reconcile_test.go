@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type testReconciler struct {
+	errs   []error
+	result reconcile.Result
+}
+
+func (r *testReconciler) Reconcile(req reconcile.Request) (reconcile.Result, error) {
+	err := r.errs[0]
+	r.errs = r.errs[1:]
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	return r.result, nil
+}
+
+func TestRetryingReconciler(t *testing.T) {
+	failure := fmt.Errorf("failure")
+	inner := &testReconciler{
+		errs:   []error{failure, failure, failure, nil, failure},
+		result: reconcile.Result{RequeueAfter: time.Hour},
+	}
+	r := NewRetryingReconciler("test", inner, ctrl.Log)
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: "test"}}
+	for i, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, time.Hour, time.Second} {
+		result, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile %d: unexpected error: %v", i, err)
+		}
+		if result.RequeueAfter != expected {
+			t.Errorf("reconcile %d: expected to requeue after %s, got %s", i, expected, result.RequeueAfter)
+		}
+	}
+}
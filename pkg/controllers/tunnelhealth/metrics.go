@@ -0,0 +1,22 @@
+package tunnelhealth
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	tunnelHealthy = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "hypershift_control_plane_operator_tunnel_healthy",
+		Help: "Whether the last probe of the kubelets of the target cluster through the tunnel of the kube-apiserver succeeded",
+	})
+
+	tunnelRepairs = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hypershift_control_plane_operator_tunnel_repairs_total",
+		Help: "Number of times the tunnel to the target cluster was restarted after failed probes",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(tunnelHealthy, tunnelRepairs)
+}
@@ -0,0 +1,270 @@
+package tunnelhealth
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubeclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/controllers"
+	"github.com/openshift/hypershift-toolkit/pkg/controllers/clusterstatus"
+)
+
+const (
+	// probeInterval is the amount of time between probes of the tunnel
+	probeInterval = 30 * time.Second
+
+	// failureThreshold is the number of consecutive failed probes after which the tunnel
+	// is repaired
+	failureThreshold = 3
+
+	// repairInterval is the minimum amount of time between repairs, which gives the
+	// restarted tunnel time to reconnect
+	repairInterval = 5 * time.Minute
+
+	// probedNodes is the number of ready nodes that are probed before the tunnel is
+	// considered broken, so that a single unhealthy kubelet does not restart the tunnel
+	probedNodes = 3
+
+	// repairedAtAnnotation is set on the pod templates of the tunnel to restart them
+	repairedAtAnnotation = "hypershift.openshift.io/tunnel-repaired-at"
+
+	openVPNServerDeployment = "openvpn-server"
+	openVPNClientDeployment = "openvpn-client"
+	konnectivityAgent       = "konnectivity-agent"
+
+	// openVPNClientConfigManifest is the configmap of the control plane namespace with the
+	// rendered configmap of the VPN client, which the user manifests bootstrapper applied
+	openVPNClientConfigManifest = "user-manifest-openvpn-client-configmap"
+)
+
+// TunnelProber probes the tunnel from the kube-apiserver of the control plane to the workers
+// of the target cluster by proxying requests to the kubelets through the kube-apiserver,
+// which is how logs, exec and port forwarding reach the nodes. It records the result as the
+// TunnelHealthy condition of the cluster, and repairs the tunnel when it stays broken: the
+// VPN client configuration of the target cluster is applied again and the VPN server and
+// client are restarted, or the konnectivity agents are restarted.
+type TunnelProber struct {
+	// Client is a client of the management cluster
+	Client kubeclient.Interface
+
+	// TargetClient is a client of the target cluster
+	TargetClient kubeclient.Interface
+
+	// Namespace is the namespace of the control plane on the management cluster
+	Namespace string
+
+	// Recorder records the repairs of the tunnel on the control plane namespace
+	Recorder record.EventRecorder
+
+	Log logr.Logger
+
+	failures   int
+	lastRepair time.Time
+}
+
+// Run performs a single probe, logging any error
+func (p *TunnelProber) Run() {
+	if err := p.Probe(); err != nil {
+		p.Log.Error(err, "Tunnel probe failed")
+	}
+}
+
+// Probe checks the tunnel, records its condition and repairs it if it failed too many
+// consecutive probes
+func (p *TunnelProber) Probe() error {
+	condition := p.probe()
+	switch condition.Status {
+	case corev1.ConditionTrue:
+		p.failures = 0
+		tunnelHealthy.Set(1)
+	case corev1.ConditionFalse:
+		p.failures++
+		tunnelHealthy.Set(0)
+		p.Log.Info("Tunnel is unhealthy", "failures", p.failures, "message", condition.Message)
+	}
+	if err := clusterstatus.UpdateCondition(p.Client, p.Namespace, condition); err != nil {
+		return fmt.Errorf("cannot update the tunnel condition: %v", err)
+	}
+	if !needsRepair(p.failures, p.lastRepair, time.Now()) {
+		return nil
+	}
+	p.lastRepair = time.Now()
+	repaired, err := p.repair()
+	if err != nil {
+		p.Recorder.Eventf(controllers.NamespaceReference(p.Namespace), corev1.EventTypeWarning, "TunnelRepairFailed", "Cannot repair the tunnel to the cluster: %v", err)
+		return err
+	}
+	if len(repaired) > 0 {
+		tunnelRepairs.Inc()
+		p.Recorder.Eventf(controllers.NamespaceReference(p.Namespace), corev1.EventTypeNormal, "TunnelRepaired", "Restarted %s after %d failed probes of the tunnel to the cluster", strings.Join(repaired, ", "), p.failures)
+	}
+	return nil
+}
+
+// needsRepair returns whether the tunnel failed enough consecutive probes to be repaired,
+// and was not repaired too recently
+func needsRepair(failures int, lastRepair, now time.Time) bool {
+	return failures >= failureThreshold && now.Sub(lastRepair) >= repairInterval
+}
+
+func (p *TunnelProber) probe() clusterstatus.Condition {
+	condition := clusterstatus.Condition{Type: clusterstatus.TunnelHealthy}
+	// The tunnel cannot be told apart from the kube-apiserver when the latter is down
+	if err := p.TargetClient.Discovery().RESTClient().Get().AbsPath("/healthz").Do().Error(); err != nil {
+		condition.Status, condition.Reason, condition.Message = corev1.ConditionUnknown, "APIServerUnreachable", err.Error()
+		return condition
+	}
+	nodes, err := p.TargetClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		condition.Status, condition.Reason, condition.Message = corev1.ConditionUnknown, "ListFailed", err.Error()
+		return condition
+	}
+	ready := readyNodes(nodes.Items, p.failures)
+	if len(ready) == 0 {
+		condition.Status, condition.Reason, condition.Message = corev1.ConditionUnknown, "NoReadyNodes", "The cluster has no ready nodes to probe"
+		return condition
+	}
+	var failed []string
+	for _, name := range ready {
+		err := p.TargetClient.CoreV1().RESTClient().Get().Resource("nodes").Name(name).SubResource("proxy").Suffix("healthz").Do().Error()
+		if err == nil {
+			condition.Status, condition.Reason, condition.Message = corev1.ConditionTrue, "ProbeSucceeded", fmt.Sprintf("The kubelet of node %s is reachable", name)
+			return condition
+		}
+		failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+	}
+	condition.Status, condition.Reason = corev1.ConditionFalse, "ProbeFailed"
+	condition.Message = fmt.Sprintf("The kubelets of the ready nodes are not reachable: %s", strings.Join(failed, "; "))
+	return condition
+}
+
+// readyNodes returns the names of up to probedNodes ready nodes, starting at a different node
+// after each failed probe so that a broken kubelet is not probed every time
+func readyNodes(nodes []corev1.Node, offset int) []string {
+	var ready []string
+	for _, node := range nodes {
+		for _, c := range node.Status.Conditions {
+			if c.Type == corev1.NodeReady && c.Status == corev1.ConditionTrue {
+				ready = append(ready, node.Name)
+			}
+		}
+	}
+	if len(ready) == 0 {
+		return nil
+	}
+	sort.Strings(ready)
+	offset %= len(ready)
+	ready = append(ready[offset:], ready[:offset]...)
+	if len(ready) > probedNodes {
+		ready = ready[:probedNodes]
+	}
+	return ready
+}
+
+// repair restarts the tunnel of the cluster and returns what was restarted. Clusters without
+// a VPN server or konnectivity agents route to their workers directly and have nothing to
+// restart.
+func (p *TunnelProber) repair() ([]string, error) {
+	_, err := p.Client.AppsV1().Deployments(p.Namespace).Get(openVPNServerDeployment, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	if errors.IsNotFound(err) {
+		restarted, err := p.restart(p.TargetClient, metav1.NamespaceSystem, "daemonset", konnectivityAgent)
+		if err != nil || !restarted {
+			return nil, err
+		}
+		return []string{"the konnectivity agents"}, nil
+	}
+	if err := p.syncOpenVPNClientConfig(); err != nil {
+		return nil, err
+	}
+	var repaired []string
+	restarted, err := p.restart(p.TargetClient, metav1.NamespaceSystem, "deployment", openVPNClientDeployment)
+	if err != nil {
+		return nil, err
+	}
+	if restarted {
+		repaired = append(repaired, "the VPN client")
+	}
+	if _, err := p.restart(p.Client, p.Namespace, "deployment", openVPNServerDeployment); err != nil {
+		return repaired, err
+	}
+	return append(repaired, "the VPN server"), nil
+}
+
+// syncOpenVPNClientConfig applies the rendered configmap of the VPN client to the target
+// cluster again, in case it was changed or deleted
+func (p *TunnelProber) syncOpenVPNClientConfig() error {
+	manifest, err := p.Client.CoreV1().ConfigMaps(p.Namespace).Get(openVPNClientConfigManifest, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	expected := &corev1.ConfigMap{}
+	if err := yaml.Unmarshal([]byte(manifest.Data["data"]), expected); err != nil {
+		return fmt.Errorf("invalid VPN client configmap in %s: %v", openVPNClientConfigManifest, err)
+	}
+	configMaps := p.TargetClient.CoreV1().ConfigMaps(metav1.NamespaceSystem)
+	current, err := configMaps.Get(expected.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		p.Log.Info("Creating missing VPN client configmap", "configmap", expected.Name)
+		_, err = configMaps.Create(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: expected.Name, Namespace: metav1.NamespaceSystem}, Data: expected.Data})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(current.Data, expected.Data) {
+		return nil
+	}
+	p.Log.Info("Restoring VPN client configmap", "configmap", expected.Name)
+	current.Data = expected.Data
+	_, err = configMaps.Update(current)
+	return err
+}
+
+// restart sets the repair time on the pod template of a deployment or daemonset, which
+// replaces its pods, and returns whether it exists
+func (p *TunnelProber) restart(client kubeclient.Interface, namespace, kind, name string) (bool, error) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{repairedAtAnnotation: time.Now().UTC().Format(time.RFC3339)},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	if kind == "daemonset" {
+		_, err = client.AppsV1().DaemonSets(namespace).Patch(name, types.MergePatchType, patch)
+	} else {
+		_, err = client.AppsV1().Deployments(namespace).Patch(name, types.MergePatchType, patch)
+	}
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("cannot restart %s %s/%s: %v", kind, namespace, name, err)
+	}
+	p.Log.Info("Restarted tunnel component", "kind", kind, "namespace", namespace, "name", name)
+	return true, nil
+}
@@ -0,0 +1,50 @@
+package tunnelhealth
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestReadyNodes(t *testing.T) {
+	node := func(name string, ready corev1.ConditionStatus) corev1.Node {
+		n := corev1.Node{}
+		n.Name = name
+		n.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}
+		return n
+	}
+	nodes := []corev1.Node{
+		node("worker-4", corev1.ConditionTrue),
+		node("worker-3", corev1.ConditionTrue),
+		node("worker-2", corev1.ConditionUnknown),
+		node("worker-1", corev1.ConditionTrue),
+		node("worker-0", corev1.ConditionTrue),
+	}
+	if ready := readyNodes(nodes, 0); !reflect.DeepEqual(ready, []string{"worker-0", "worker-1", "worker-3"}) {
+		t.Errorf("unexpected nodes to probe: %v", ready)
+	}
+	if ready := readyNodes(nodes, 6); !reflect.DeepEqual(ready, []string{"worker-3", "worker-4", "worker-0"}) {
+		t.Errorf("expected the nodes to probe to rotate after failures, got %v", ready)
+	}
+	if ready := readyNodes(nodes[2:3], 0); len(ready) > 0 {
+		t.Errorf("expected no nodes to probe, got %v", ready)
+	}
+}
+
+func TestNeedsRepair(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if needsRepair(failureThreshold-1, time.Time{}, now) {
+		t.Errorf("expected no repair before the failure threshold")
+	}
+	if !needsRepair(failureThreshold, time.Time{}, now) {
+		t.Errorf("expected a repair at the failure threshold")
+	}
+	if needsRepair(failureThreshold+5, now.Add(-time.Minute), now) {
+		t.Errorf("expected no repair right after a repair")
+	}
+	if !needsRepair(failureThreshold+5, now.Add(-repairInterval), now) {
+		t.Errorf("expected a repair once the repair interval passed")
+	}
+}
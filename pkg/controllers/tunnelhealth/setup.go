@@ -0,0 +1,24 @@
+package tunnelhealth
+
+import (
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/openshift/hypershift-toolkit/pkg/cmd/cpoperator"
+)
+
+// Setup sets up a controller that probes the tunnel from the kube-apiserver to the workers
+// of the target cluster and restarts it when it stays broken
+func Setup(cfg *cpoperator.ControlPlaneOperatorConfig) error {
+	prober := &TunnelProber{
+		Client:       cfg.KubeClient(),
+		TargetClient: cfg.TargetKubeClient(),
+		Namespace:    cfg.Namespace(),
+		Recorder:     cfg.EventRecorder(),
+		Log:          cfg.Logger().WithName("TunnelProber"),
+	}
+	return cfg.Manager().Add(manager.RunnableFunc(func(stopCh <-chan struct{}) error {
+		wait.Until(prober.Run, probeInterval, stopCh)
+		return nil
+	}))
+}
@@ -0,0 +1,132 @@
+package frontend
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// APIHostPrefix is the first label of the API names of the clusters behind a frontend
+	APIHostPrefix = "api"
+
+	// clientHelloTimeout is how long a client may take to send its TLS client hello
+	clientHelloTimeout = 10 * time.Second
+
+	// dialTimeout is how long a connection to a backend may take
+	dialTimeout = 10 * time.Second
+)
+
+// Route is the service of the control plane namespaces that a listener of the frontend
+// forwards connections to
+type Route struct {
+	// Service is the name of the service in each control plane namespace
+	Service string
+	// Port is the port of the service
+	Port int
+}
+
+// Proxy forwards the TLS connections of the clusters of a management cluster to their
+// control plane namespaces by the server name that clients send with SNI. The server name
+// of a cluster is api.NAMESPACE.DOMAIN, so the namespace of a connection is found without
+// configuring the proxy for each cluster. TLS is not terminated, so clients verify the
+// certificates of the control planes themselves.
+type Proxy struct {
+	// Domain is the parent domain of the API names of the clusters
+	Domain string
+
+	// Dial connects to a backend. It defaults to a TCP dialer with a timeout.
+	Dial func(network, address string) (net.Conn, error)
+}
+
+// NewProxy returns a proxy for the clusters whose API names are in domain
+func NewProxy(domain string) *Proxy {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	return &Proxy{
+		Domain: strings.ToLower(strings.Trim(domain, ".")),
+		Dial:   dialer.Dial,
+	}
+}
+
+// Serve forwards the connections of listener along route until the listener fails
+func (p *Proxy) Serve(listener net.Listener, route Route) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go p.handle(conn, route)
+	}
+}
+
+// Backend returns the address of the service of a route in the control plane namespace of
+// the cluster whose API name is serverName
+func (p *Proxy) Backend(serverName string, route Route) (string, error) {
+	name := strings.ToLower(strings.TrimSuffix(serverName, "."))
+	prefix, suffix := APIHostPrefix+".", "."+p.Domain
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) || len(name) <= len(prefix)+len(suffix) {
+		return "", fmt.Errorf("server name %s is not the API name of a cluster in %s", serverName, p.Domain)
+	}
+	namespace := name[len(prefix) : len(name)-len(suffix)]
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return "", fmt.Errorf("server name %s does not name a cluster: %s", serverName, strings.Join(errs, ", "))
+	}
+	return fmt.Sprintf("%s.%s.svc:%d", route.Service, namespace, route.Port), nil
+}
+
+// handle forwards a connection to the backend of its server name
+func (p *Proxy) handle(conn net.Conn, route Route) {
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(clientHelloTimeout))
+	serverName, hello, err := readServerName(conn)
+	if err != nil {
+		log.Debugf("Cannot read the server name of a connection from %s: %v", conn.RemoteAddr(), err)
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+	address, err := p.Backend(serverName, route)
+	if err != nil {
+		log.Debugf("Rejected a connection from %s: %v", conn.RemoteAddr(), err)
+		return
+	}
+	backend, err := p.Dial("tcp", address)
+	if err != nil {
+		log.Debugf("Cannot connect to %s for %s: %v", address, serverName, err)
+		return
+	}
+	defer backend.Close()
+	if _, err = backend.Write(hello); err != nil {
+		log.Debugf("Cannot forward the client hello of %s to %s: %v", serverName, address, err)
+		return
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(backend, conn)
+		closeWrite(backend)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(conn, backend)
+		closeWrite(conn)
+	}()
+	wg.Wait()
+}
+
+// closeWrite shuts down the writing side of a TCP connection, so that the other end reads
+// the end of the stream while it can still write
+func closeWrite(conn net.Conn) {
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.CloseWrite()
+		return
+	}
+	conn.Close()
+}
@@ -0,0 +1,99 @@
+package frontend
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+)
+
+func TestBackend(t *testing.T) {
+	proxy := NewProxy("example.com.")
+	route := Route{Service: "kube-apiserver", Port: 6443}
+	tests := []struct {
+		serverName  string
+		expected    string
+		expectError bool
+	}{
+		{serverName: "api.dev.example.com", expected: "kube-apiserver.dev.svc:6443"},
+		{serverName: "API.Dev.Example.com.", expected: "kube-apiserver.dev.svc:6443"},
+		{serverName: "oauth.dev.example.com", expectError: true},
+		{serverName: "api.dev.example.org", expectError: true},
+		{serverName: "api.example.com", expectError: true},
+		{serverName: "api.a.b.example.com", expectError: true},
+		{serverName: "api.dev.notexample.com", expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.serverName, func(t *testing.T) {
+			address, err := proxy.Backend(test.serverName, route)
+			if test.expectError {
+				if err == nil {
+					t.Errorf("expected an error, got %s", address)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if address != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, address)
+			}
+		})
+	}
+}
+
+func TestProxyForwardsClientHello(t *testing.T) {
+	backend, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	proxy := NewProxy("example.com")
+	dialed := make(chan string, 1)
+	proxy.Dial = func(network, address string) (net.Conn, error) {
+		dialed <- address
+		return net.Dial(network, backend.Addr().String())
+	}
+	go proxy.Serve(listener, Route{Service: "kube-apiserver", Port: 6443})
+
+	go func() {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// The backend is not a TLS server, so the handshake fails once it closes
+		tls.Client(conn, &tls.Config{ServerName: "api.dev.example.com", InsecureSkipVerify: true}).Handshake()
+	}()
+
+	conn, err := backend.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address := <-dialed; address != "kube-apiserver.dev.svc:6443" {
+		t.Errorf("expected a connection to kube-apiserver.dev.svc:6443, got %s", address)
+	}
+	serverName, _, err := readServerName(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serverName != "api.dev.example.com" {
+		t.Errorf("expected the client hello of api.dev.example.com, got %s", serverName)
+	}
+	conn.Close()
+}
+
+func TestReadServerNameWithoutSNI(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go tls.Client(client, &tls.Config{InsecureSkipVerify: true}).Handshake()
+	if _, _, err := readServerName(server); err == nil {
+		t.Errorf("expected an error without a server name")
+	}
+	server.Close()
+}
@@ -0,0 +1,49 @@
+package frontend
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"time"
+)
+
+// errClientHelloRead stops the handshake of readServerName once the client hello is read
+var errClientHelloRead = errors.New("client hello read")
+
+// readServerName reads the TLS client hello of a connection and returns the server name
+// that the client sent with SNI, and the bytes that were read from the connection, which
+// must be sent to the backend before the rest of the connection
+func readServerName(conn io.Reader) (string, []byte, error) {
+	read := &bytes.Buffer{}
+	serverName := ""
+	err := tls.Server(readOnlyConn{reader: io.TeeReader(conn, read)}, &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverName = hello.ServerName
+			return nil, errClientHelloRead
+		},
+	}).Handshake()
+	if len(serverName) == 0 {
+		if err == errClientHelloRead {
+			return "", read.Bytes(), errors.New("the client did not send a server name")
+		}
+		return "", read.Bytes(), err
+	}
+	return serverName, read.Bytes(), nil
+}
+
+// readOnlyConn is a connection that only reads from reader, so that the TLS server of
+// readServerName does not write to the client
+type readOnlyConn struct {
+	reader io.Reader
+}
+
+func (c readOnlyConn) Read(p []byte) (int, error)         { return c.reader.Read(p) }
+func (c readOnlyConn) Write(p []byte) (int, error)        { return 0, io.ErrClosedPipe }
+func (c readOnlyConn) Close() error                       { return nil }
+func (c readOnlyConn) LocalAddr() net.Addr                { return nil }
+func (c readOnlyConn) RemoteAddr() net.Addr               { return nil }
+func (c readOnlyConn) SetDeadline(t time.Time) error      { return nil }
+func (c readOnlyConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c readOnlyConn) SetWriteDeadline(t time.Time) error { return nil }
@@ -2,7 +2,6 @@ package ignition
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -18,6 +17,8 @@ import (
 	"github.com/openshift/hypershift-toolkit/pkg/assets"
 )
 
+// GenerateIgnition writes the bootstrap ignition config of the workers of a cluster to
+// bootstrap.ign in outputDir, in the Ignition spec version of the cluster parameters
 func GenerateIgnition(params *api.ClusterParams, sshPublicKey []byte, pullSecretFile, pkiDir, outputDir string) error {
 
 	cfg := &igntypes.Config{
@@ -45,11 +46,50 @@ func GenerateIgnition(params *api.ClusterParams, sshPublicKey []byte, pullSecret
 		return err
 	}
 
+	// RHCOS adds the anchors to the trust store of the host on boot, which CRI-O pulls with
+	if len(params.AdditionalTrustBundle) > 0 {
+		addFileBytes(cfg, []byte(params.AdditionalTrustBundle), "/etc/pki/ca-trust/source/anchors/openshift-config-user-ca-bundle.crt", 0644)
+	}
+
+	if len(params.ImageContentSources) > 0 {
+		addFileBytes(cfg, registriesConf(params.ImageContentSources), "/etc/containers/registries.conf", 0644)
+	}
+
+	// RHCOS enables FIPS mode on first boot when the machine config embedded in the
+	// ignition config requests it
+	if params.FIPS {
+		addFileBytes(cfg, []byte(`{"metadata":{"name":"hypershift-fips"},"spec":{"fips":true}}`), "/etc/ignition-machine-config-encapsulated.json", 0644)
+	}
+
 	if err := addUnits(cfg, "ignition/units"); err != nil {
 		return err
 	}
 
-	data, err := json.Marshal(cfg)
+	// OVN Kubernetes runs on the Open vSwitch of the host, which RHCOS ships disabled
+	if params.NetworkType == api.NetworkTypeOVNKubernetes {
+		cfg.Systemd.Units = append(cfg.Systemd.Units, igntypes.Unit{
+			Name:    "openvswitch.service",
+			Enabled: func() *bool { t := true; return &t }(),
+		})
+	}
+
+	// The machine configs of the user come last, to customize or replace the files and units
+	// generated above
+	if len(params.MachineConfigsDir) > 0 {
+		kernelArguments, err := mergeMachineConfigs(cfg, params.MachineConfigsDir)
+		if err != nil {
+			return err
+		}
+		if len(kernelArguments) > 0 {
+			setUnit(cfg, igntypes.Unit{
+				Name:     kernelArgumentsUnit,
+				Enabled:  func() *bool { t := true; return &t }(),
+				Contents: kernelArgumentsUnitContents(kernelArguments),
+			})
+		}
+	}
+
+	data, err := marshalConfig(cfg, params.IgnitionVersion)
 	if err != nil {
 		return fmt.Errorf("failed to marshal Ignition config: %v", err)
 	}
@@ -89,6 +129,20 @@ func addAssetFiles(cfg *igntypes.Config, params *api.ClusterParams, prefix strin
 	return nil
 }
 
+// registriesConf returns a containers registries configuration that pulls the images of
+// the image content sources by digest from their mirrors
+func registriesConf(imageContentSources []api.ImageContentSource) []byte {
+	out := &bytes.Buffer{}
+	fmt.Fprintln(out, `unqualified-search-registries = ["registry.access.redhat.com", "docker.io"]`)
+	for _, source := range imageContentSources {
+		fmt.Fprintf(out, "\n[[registry]]\n  prefix = \"\"\n  location = %q\n  mirror-by-digest-only = true\n", source.Source)
+		for _, mirror := range source.Mirrors {
+			fmt.Fprintf(out, "\n  [[registry.mirror]]\n    location = %q\n", mirror)
+		}
+	}
+	return out.Bytes()
+}
+
 func addUnits(cfg *igntypes.Config, filePath string) error {
 	files, err := assets.AssetDir(filePath)
 	if err != nil {
@@ -0,0 +1,221 @@
+package ignition
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"sigs.k8s.io/yaml"
+)
+
+// kernelArgumentsUnit is the unit that sets the kernel arguments of the machine configs and
+// reboots the worker once, before the kubelet starts
+const kernelArgumentsUnit = "hypershift-kernel-arguments.service"
+
+// machineConfig is the part of a MachineConfig that is merged into the worker config
+type machineConfig struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Config          snippetConfig `json:"config"`
+		KernelArguments []string      `json:"kernelArguments"`
+	} `json:"spec"`
+}
+
+// snippetConfig is the part of an Ignition config in spec 2 or 3 that is merged into the
+// worker config: files, and units with their drop-ins
+type snippetConfig struct {
+	Storage struct {
+		Files       []snippetFile     `json:"files"`
+		Directories []json.RawMessage `json:"directories"`
+		Links       []json.RawMessage `json:"links"`
+	} `json:"storage"`
+	Systemd struct {
+		Units []snippetUnit `json:"units"`
+	} `json:"systemd"`
+}
+
+type snippetFile struct {
+	Path string `json:"path"`
+	Mode *int   `json:"mode"`
+	User *struct {
+		Name string `json:"name"`
+	} `json:"user"`
+	Contents struct {
+		Source string `json:"source"`
+	} `json:"contents"`
+}
+
+type snippetUnit struct {
+	Name     string `json:"name"`
+	Enabled  *bool  `json:"enabled"`
+	Enable   bool   `json:"enable"`
+	Contents string `json:"contents"`
+	Dropins  []struct {
+		Name     string `json:"name"`
+		Contents string `json:"contents"`
+	} `json:"dropins"`
+}
+
+// mergeMachineConfigs merges the MachineConfigs (.yaml and .yml files) and Ignition configs
+// (.ign and .json files) of dir into cfg, in the order of their file names. A file or unit
+// replaces the one of cfg with the same path or name. The kernel arguments of the
+// MachineConfigs are returned.
+func mergeMachineConfigs(cfg *igntypes.Config, dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read machine configs: %v", err)
+	}
+	var kernelArguments []string
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		fileName := filepath.Join(dir, f.Name())
+		var snippet snippetConfig
+		switch filepath.Ext(f.Name()) {
+		case ".yaml", ".yml":
+			mc, err := readMachineConfig(fileName)
+			if err != nil {
+				return nil, err
+			}
+			snippet = mc.Spec.Config
+			kernelArguments = append(kernelArguments, mc.Spec.KernelArguments...)
+		case ".ign", ".json":
+			data, err := ioutil.ReadFile(fileName)
+			if err != nil {
+				return nil, err
+			}
+			if err = json.Unmarshal(data, &snippet); err != nil {
+				return nil, fmt.Errorf("cannot parse ignition config %s: %v", fileName, err)
+			}
+		default:
+			continue
+		}
+		if err = mergeSnippet(cfg, snippet); err != nil {
+			return nil, fmt.Errorf("cannot merge %s: %v", fileName, err)
+		}
+	}
+	return kernelArguments, nil
+}
+
+func readMachineConfig(fileName string) (*machineConfig, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	mc := &machineConfig{}
+	if err = yaml.Unmarshal(data, mc); err != nil {
+		return nil, fmt.Errorf("cannot parse machine config %s: %v", fileName, err)
+	}
+	if mc.Kind != "MachineConfig" {
+		return nil, fmt.Errorf("%s is a %q, not a MachineConfig", fileName, mc.Kind)
+	}
+	return mc, nil
+}
+
+func mergeSnippet(cfg *igntypes.Config, snippet snippetConfig) error {
+	if len(snippet.Storage.Directories) > 0 || len(snippet.Storage.Links) > 0 {
+		return fmt.Errorf("only files and units are supported, not directories or links")
+	}
+	for _, f := range snippet.Storage.Files {
+		if !path.IsAbs(f.Path) {
+			return fmt.Errorf("the path of file %q is not absolute", f.Path)
+		}
+		mode := 0644
+		if f.Mode != nil {
+			mode = *f.Mode
+		}
+		user := "root"
+		if f.User != nil && len(f.User.Name) > 0 {
+			user = f.User.Name
+		}
+		file := fileFromBytes(f.Path, user, mode, nil)
+		file.Contents.Source = f.Contents.Source
+		setFile(cfg, file)
+	}
+	for _, u := range snippet.Systemd.Units {
+		if len(u.Name) == 0 {
+			return fmt.Errorf("a unit has no name")
+		}
+		unit := igntypes.Unit{Name: u.Name, Contents: u.Contents, Enabled: u.Enabled}
+		if u.Enable && unit.Enabled == nil {
+			unit.Enabled = func() *bool { t := true; return &t }()
+		}
+		// A unit without contents only enables or disables the unit of cfg, if there is one
+		if len(u.Contents) == 0 {
+			if existing := findUnit(cfg, u.Name); existing != nil {
+				if unit.Enabled != nil {
+					existing.Enabled = unit.Enabled
+				}
+			} else if unit.Enabled != nil {
+				setUnit(cfg, unit)
+			}
+		} else {
+			setUnit(cfg, unit)
+		}
+		// Drop-ins are written as files, which both spec versions of the worker config have
+		for _, dropin := range u.Dropins {
+			setFile(cfg, fileFromBytes(path.Join("/etc/systemd/system", u.Name+".d", dropin.Name), "root", 0644, []byte(dropin.Contents)))
+		}
+	}
+	return nil
+}
+
+// setFile replaces the file of cfg with the path of file, or else adds it
+func setFile(cfg *igntypes.Config, file igntypes.File) {
+	for i := range cfg.Storage.Files {
+		if cfg.Storage.Files[i].Path == file.Path {
+			cfg.Storage.Files[i] = file
+			return
+		}
+	}
+	cfg.Storage.Files = append(cfg.Storage.Files, file)
+}
+
+func findUnit(cfg *igntypes.Config, name string) *igntypes.Unit {
+	for i := range cfg.Systemd.Units {
+		if cfg.Systemd.Units[i].Name == name {
+			return &cfg.Systemd.Units[i]
+		}
+	}
+	return nil
+}
+
+// setUnit replaces the unit of cfg with the name of unit, or else adds it
+func setUnit(cfg *igntypes.Config, unit igntypes.Unit) {
+	if existing := findUnit(cfg, unit.Name); existing != nil {
+		*existing = unit
+		return
+	}
+	cfg.Systemd.Units = append(cfg.Systemd.Units, unit)
+}
+
+// kernelArgumentsUnitContents returns a unit that appends kernelArguments to those of the
+// worker on its first boot and reboots it, which RHCOS workers without the machine config
+// daemon need for kernel arguments to take effect
+func kernelArgumentsUnitContents(kernelArguments []string) string {
+	args := make([]string, 0, len(kernelArguments))
+	for _, arg := range kernelArguments {
+		// Percent signs are systemd specifiers
+		args = append(args, strconv.Quote("--append="+strings.Replace(arg, "%", "%%", -1)))
+	}
+	return fmt.Sprintf(`[Unit]
+Description=Set the kernel arguments of the worker
+ConditionPathExists=!/var/lib/hypershift-kernel-arguments.done
+Before=kubelet.service
+
+[Service]
+Type=oneshot
+ExecStart=/usr/bin/rpm-ostree kargs %s
+ExecStart=/usr/bin/touch /var/lib/hypershift-kernel-arguments.done
+ExecStart=/usr/bin/systemctl --no-block reboot
+
+[Install]
+WantedBy=multi-user.target
+`, strings.Join(args, " "))
+}
@@ -0,0 +1,107 @@
+package ignition
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+)
+
+func TestMergeMachineConfigs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "machineconfigs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"50-registries.yaml": `apiVersion: machineconfiguration.openshift.io/v1
+kind: MachineConfig
+metadata:
+  name: 50-registries
+spec:
+  config:
+    ignition:
+      version: 2.2.0
+    storage:
+      files:
+      - path: /etc/containers/registries.conf
+        mode: 384
+        contents:
+          source: data:,tenant
+  kernelArguments:
+  - nosmt
+`,
+		"60-sysctl.ign": `{
+  "ignition": {"version": "3.1.0"},
+  "storage": {"files": [{"path": "/etc/sysctl.d/99-tenant.conf", "contents": {"source": "data:,vm.swappiness%3D10"}}]},
+  "systemd": {"units": [{"name": "kubelet.service", "dropins": [{"name": "10-tenant.conf", "contents": "[Service]"}]}, {"name": "chronyd.service", "enabled": true}]}
+}`,
+		"README.md": "ignored",
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := &igntypes.Config{}
+	addFileBytes(cfg, []byte("generated"), "/etc/containers/registries.conf", 0644)
+
+	kernelArguments, err := mergeMachineConfigs(cfg, dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(kernelArguments) != 1 || kernelArguments[0] != "nosmt" {
+		t.Errorf("unexpected kernel arguments: %v", kernelArguments)
+	}
+	sources := map[string]string{}
+	for _, f := range cfg.Storage.Files {
+		sources[f.Path] = f.Contents.Source
+	}
+	if len(cfg.Storage.Files) != 3 {
+		t.Errorf("expected the registries configuration to be replaced, got files %v", sources)
+	}
+	if sources["/etc/containers/registries.conf"] != "data:,tenant" || *cfg.Storage.Files[0].Mode != 0600 {
+		t.Errorf("unexpected registries configuration: %+v", cfg.Storage.Files[0])
+	}
+	if sources["/etc/sysctl.d/99-tenant.conf"] != "data:,vm.swappiness%3D10" {
+		t.Errorf("unexpected sysctl configuration: %q", sources["/etc/sysctl.d/99-tenant.conf"])
+	}
+	if _, ok := sources["/etc/systemd/system/kubelet.service.d/10-tenant.conf"]; !ok {
+		t.Errorf("expected the drop-in to be a file, got files %v", sources)
+	}
+	if len(cfg.Systemd.Units) != 1 || cfg.Systemd.Units[0].Name != "chronyd.service" || !*cfg.Systemd.Units[0].Enabled {
+		t.Errorf("expected only chronyd to be enabled, got units %+v", cfg.Systemd.Units)
+	}
+}
+
+func TestMergeMachineConfigsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"not a machine config": "kind: ConfigMap\n",
+		"directory":            "kind: MachineConfig\nspec:\n  config:\n    storage:\n      directories:\n      - path: /etc/tenant\n",
+		"relative path":        "kind: MachineConfig\nspec:\n  config:\n    storage:\n      files:\n      - path: etc/chrony.conf\n",
+	}
+	for name, contents := range tests {
+		dir, err := ioutil.TempDir("", "machineconfigs")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		if err := ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := mergeMachineConfigs(&igntypes.Config{}, dir); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestKernelArgumentsUnitContents(t *testing.T) {
+	contents := kernelArgumentsUnitContents([]string{"nosmt", "console=ttyS0,115200n8", "quiet%"})
+	expected := `ExecStart=/usr/bin/rpm-ostree kargs "--append=nosmt" "--append=console=ttyS0,115200n8" "--append=quiet%%"`
+	if !strings.Contains(contents, expected+"\n") {
+		t.Errorf("expected %s in:\n%s", expected, contents)
+	}
+}
@@ -0,0 +1,56 @@
+package ignition
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ServerConfigPath is the path of the worker ignition config served by the ignition server
+	ServerConfigPath = "/config/worker"
+
+	// ServerTokenParam is the query parameter that holds the token of ignition server requests
+	ServerTokenParam = "token"
+)
+
+// ServerURL returns the URL of the worker ignition config of the ignition server that is
+// reachable at the given host and port
+func ServerURL(host string, port uint, token string) string {
+	return fmt.Sprintf("https://%s:%d%s?%s=%s", host, port, ServerConfigPath, ServerTokenParam, url.QueryEscape(token))
+}
+
+// ServerHandler returns a handler that serves the ignition config in configFile at
+// ServerConfigPath to requests with the given token. The file is read on every request,
+// so that an updated config is served without a restart.
+func ServerHandler(configFile, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ServerConfigPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get(ServerTokenParam)), []byte(token)) != 1 {
+			log.Infof("Rejected ignition request from %s with an invalid token", r.RemoteAddr)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		data, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			log.WithError(err).Error("Cannot read ignition config")
+			http.Error(w, "ignition config unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		log.Infof("Serving ignition config to %s", r.RemoteAddr)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
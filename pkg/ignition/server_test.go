@@ -0,0 +1,52 @@
+package ignition
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServerHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignition-server")
+	if err != nil {
+		t.Fatalf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	configFile := filepath.Join(dir, "worker.ign")
+	if err := ioutil.WriteFile(configFile, []byte(`{"ignition":{"version":"2.2.0"}}`), 0644); err != nil {
+		t.Fatalf("cannot write config: %v", err)
+	}
+	handler := ServerHandler(configFile, "secret")
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		expectedStatus int
+	}{
+		{name: "valid token", method: http.MethodGet, url: ServerURL("api.example.com", 22623, "secret"), expectedStatus: http.StatusOK},
+		{name: "invalid token", method: http.MethodGet, url: ServerURL("api.example.com", 22623, "guess"), expectedStatus: http.StatusForbidden},
+		{name: "no token", method: http.MethodGet, url: "https://api.example.com:22623/config/worker", expectedStatus: http.StatusForbidden},
+		{name: "post", method: http.MethodPost, url: ServerURL("api.example.com", 22623, "secret"), expectedStatus: http.StatusMethodNotAllowed},
+		{name: "health", method: http.MethodGet, url: "https://api.example.com:22623/healthz", expectedStatus: http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(test.method, test.url, nil))
+			if recorder.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, recorder.Code)
+			}
+		})
+	}
+	if err := ioutil.WriteFile(configFile, []byte(`{"ignition":{"version":"3.1.0"}}`), 0644); err != nil {
+		t.Fatalf("cannot write config: %v", err)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ServerURL("api.example.com", 22623, "secret"), nil))
+	if body := recorder.Body.String(); body != `{"ignition":{"version":"3.1.0"}}` {
+		t.Errorf("expected the updated config, got %s", body)
+	}
+}
@@ -0,0 +1,52 @@
+package ignition
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// UserDataSource returns the URL of the config that is appended, or in spec 3 merged, by
+// user data generated with UserData
+func UserDataSource(userData []byte) (string, error) {
+	config := map[string]interface{}{}
+	if err := json.Unmarshal(userData, &config); err != nil {
+		return "", fmt.Errorf("cannot parse user data: %v", err)
+	}
+	reference, err := userDataReference(config)
+	if err != nil {
+		return "", err
+	}
+	source, _ := reference["source"].(string)
+	return source, nil
+}
+
+// SetUserDataSource replaces the URL of the config that is appended, or in spec 3 merged,
+// by user data generated with UserData
+func SetUserDataSource(userData []byte, source string) ([]byte, error) {
+	config := map[string]interface{}{}
+	if err := json.Unmarshal(userData, &config); err != nil {
+		return nil, fmt.Errorf("cannot parse user data: %v", err)
+	}
+	reference, err := userDataReference(config)
+	if err != nil {
+		return nil, err
+	}
+	reference["source"] = source
+	return json.Marshal(config)
+}
+
+// userDataReference returns the single config reference of user data
+func userDataReference(config map[string]interface{}) (map[string]interface{}, error) {
+	ignition, _ := config["ignition"].(map[string]interface{})
+	references, _ := ignition["config"].(map[string]interface{})
+	for _, stanza := range []string{"append", "merge"} {
+		list, _ := references[stanza].([]interface{})
+		if len(list) != 1 {
+			continue
+		}
+		if reference, ok := list[0].(map[string]interface{}); ok {
+			return reference, nil
+		}
+	}
+	return nil, fmt.Errorf("user data does not reference a single config")
+}
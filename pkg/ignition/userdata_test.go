@@ -0,0 +1,29 @@
+package ignition
+
+import "testing"
+
+func TestSetUserDataSource(t *testing.T) {
+	for _, version := range []string{VersionV2, VersionV3} {
+		userData, err := UserData("https://bucket.s3.amazonaws.com/worker.ign?X-Amz-Expires=60", []byte("ca"), version)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		updated, err := SetUserDataSource(userData, "https://bucket.s3.amazonaws.com/worker.ign?X-Amz-Expires=120&X-Amz-Signature=abc")
+		if err != nil {
+			t.Fatalf("unexpected error for version %s: %v", version, err)
+		}
+		source, err := UserDataSource(updated)
+		if err != nil {
+			t.Fatalf("unexpected error for version %s: %v", version, err)
+		}
+		if source != "https://bucket.s3.amazonaws.com/worker.ign?X-Amz-Expires=120&X-Amz-Signature=abc" {
+			t.Errorf("unexpected source %s for version %s", source, version)
+		}
+		if detected, err := VersionOf(updated); err != nil || detected != version {
+			t.Errorf("expected version %s to be kept, got %s, %v", version, detected, err)
+		}
+	}
+	if _, err := UserDataSource([]byte(`{"ignition":{"version":"2.2.0"}}`)); err == nil {
+		t.Errorf("expected an error for user data without a config reference")
+	}
+}
@@ -0,0 +1,177 @@
+package ignition
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/vincent-petithory/dataurl"
+)
+
+const (
+	// VersionV2 is the Ignition spec version of configs for RHCOS boot images before 4.6
+	VersionV2 = "2.2.0"
+
+	// VersionV3 is the Ignition spec version of configs for RHCOS boot images from 4.6
+	VersionV3 = "3.1.0"
+)
+
+// configV3 is the subset of an Ignition spec 3 config that is generated for workers
+type configV3 struct {
+	Ignition ignitionV3 `json:"ignition"`
+	Passwd   passwdV3   `json:"passwd,omitempty"`
+	Storage  storageV3  `json:"storage,omitempty"`
+	Systemd  systemdV3  `json:"systemd,omitempty"`
+}
+
+type ignitionV3 struct {
+	Version  string           `json:"version"`
+	Config   ignitionConfigV3 `json:"config,omitempty"`
+	Security securityV3       `json:"security,omitempty"`
+}
+
+type securityV3 struct {
+	TLS tlsV3 `json:"tls,omitempty"`
+}
+
+type tlsV3 struct {
+	CertificateAuthorities []resourceV3 `json:"certificateAuthorities,omitempty"`
+}
+
+type ignitionConfigV3 struct {
+	Merge []resourceV3 `json:"merge,omitempty"`
+}
+
+type resourceV3 struct {
+	Source string `json:"source"`
+}
+
+type passwdV3 struct {
+	Users []userV3 `json:"users,omitempty"`
+}
+
+type userV3 struct {
+	Name              string   `json:"name"`
+	SSHAuthorizedKeys []string `json:"sshAuthorizedKeys,omitempty"`
+}
+
+type storageV3 struct {
+	Files []fileV3 `json:"files,omitempty"`
+}
+
+type fileV3 struct {
+	Path      string     `json:"path"`
+	Overwrite bool       `json:"overwrite"`
+	User      *nodeUser  `json:"user,omitempty"`
+	Mode      *int       `json:"mode,omitempty"`
+	Contents  resourceV3 `json:"contents"`
+}
+
+type nodeUser struct {
+	Name string `json:"name"`
+}
+
+type systemdV3 struct {
+	Units []unitV3 `json:"units,omitempty"`
+}
+
+type unitV3 struct {
+	Name     string `json:"name"`
+	Enabled  *bool  `json:"enabled,omitempty"`
+	Contents string `json:"contents,omitempty"`
+}
+
+// marshalConfig serializes a config in the given Ignition spec version, which defaults to
+// VersionV2. Spec 3 configs are translated from the spec 2 config.
+func marshalConfig(cfg *igntypes.Config, version string) ([]byte, error) {
+	switch {
+	case version == "" || version == VersionV2:
+		return json.Marshal(cfg)
+	case isV3(version):
+		return json.Marshal(translateV3(cfg, version))
+	}
+	return nil, fmt.Errorf("unsupported ignition version %q", version)
+}
+
+// translateV3 translates a spec 2 config to spec 3. Files overwrite existing files, which
+// spec 2 always does, and their filesystem is the root filesystem, the only one in spec 3.
+func translateV3(cfg *igntypes.Config, version string) *configV3 {
+	result := &configV3{Ignition: ignitionV3{Version: version}}
+	for _, user := range cfg.Passwd.Users {
+		keys := make([]string, 0, len(user.SSHAuthorizedKeys))
+		for _, key := range user.SSHAuthorizedKeys {
+			keys = append(keys, string(key))
+		}
+		result.Passwd.Users = append(result.Passwd.Users, userV3{Name: user.Name, SSHAuthorizedKeys: keys})
+	}
+	for _, file := range cfg.Storage.Files {
+		translated := fileV3{
+			Path:      file.Path,
+			Overwrite: true,
+			Mode:      file.Mode,
+			Contents:  resourceV3{Source: file.Contents.Source},
+		}
+		if file.User != nil {
+			translated.User = &nodeUser{Name: file.User.Name}
+		}
+		result.Storage.Files = append(result.Storage.Files, translated)
+	}
+	for _, unit := range cfg.Systemd.Units {
+		result.Systemd.Units = append(result.Systemd.Units, unitV3{Name: unit.Name, Enabled: unit.Enabled, Contents: unit.Contents})
+	}
+	return result
+}
+
+// UserData returns a config in the given Ignition spec version that appends, or in spec 3
+// merges, the config stored in the given URL. A non-empty CA bundle is trusted for
+// HTTPS URLs in addition to the system CAs. The version defaults to VersionV2.
+func UserData(ignitionURL string, caBundle []byte, version string) ([]byte, error) {
+	switch {
+	case version == "" || version == VersionV2:
+		cfg := &igntypes.Config{Ignition: igntypes.Ignition{
+			Version: VersionV2,
+			Config:  igntypes.IgnitionConfig{Append: []igntypes.ConfigReference{{Source: ignitionURL}}},
+		}}
+		if len(caBundle) > 0 {
+			cfg.Ignition.Security.TLS.CertificateAuthorities = []igntypes.CaReference{{Source: dataurl.EncodeBytes(caBundle)}}
+		}
+		return json.Marshal(cfg)
+	case isV3(version):
+		cfg := &configV3{Ignition: ignitionV3{
+			Version: version,
+			Config:  ignitionConfigV3{Merge: []resourceV3{{Source: ignitionURL}}},
+		}}
+		if len(caBundle) > 0 {
+			cfg.Ignition.Security.TLS.CertificateAuthorities = []resourceV3{{Source: dataurl.EncodeBytes(caBundle)}}
+		}
+		return json.Marshal(cfg)
+	}
+	return nil, fmt.Errorf("unsupported ignition version %q", version)
+}
+
+func isV3(version string) bool {
+	return version == "3.0.0" || version == VersionV3
+}
+
+// VersionOf returns the Ignition spec version of a config
+func VersionOf(config []byte) (string, error) {
+	versioned := struct {
+		Ignition struct {
+			Version string `json:"version"`
+		} `json:"ignition"`
+	}{}
+	if err := json.Unmarshal(config, &versioned); err != nil {
+		return "", fmt.Errorf("cannot parse ignition config: %v", err)
+	}
+	if len(versioned.Ignition.Version) == 0 {
+		return "", fmt.Errorf("ignition config has no version")
+	}
+	if strings.HasPrefix(versioned.Ignition.Version, "2.") {
+		return VersionV2, nil
+	}
+	if !isV3(versioned.Ignition.Version) {
+		return "", fmt.Errorf("unsupported ignition version %q", versioned.Ignition.Version)
+	}
+	return versioned.Ignition.Version, nil
+}
@@ -0,0 +1,122 @@
+package ignition
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/vincent-petithory/dataurl"
+)
+
+func TestTranslateV3(t *testing.T) {
+	mode := 0644
+	enabled := true
+	cfg := &igntypes.Config{
+		Ignition: igntypes.Ignition{Version: VersionV2},
+		Passwd: igntypes.Passwd{
+			Users: []igntypes.PasswdUser{{Name: "core", SSHAuthorizedKeys: []igntypes.SSHAuthorizedKey{"ssh-rsa key"}}},
+		},
+	}
+	file := igntypes.File{Node: igntypes.Node{Filesystem: "root", Path: "/etc/kubernetes/kubeconfig", User: &igntypes.NodeUser{Name: "root"}}}
+	file.Mode = &mode
+	file.Contents.Source = "data:,kubeconfig"
+	cfg.Storage.Files = []igntypes.File{file}
+	cfg.Systemd.Units = []igntypes.Unit{{Name: "kubelet.service", Enabled: &enabled, Contents: "[Unit]"}}
+
+	expected := &configV3{
+		Ignition: ignitionV3{Version: VersionV3},
+		Passwd:   passwdV3{Users: []userV3{{Name: "core", SSHAuthorizedKeys: []string{"ssh-rsa key"}}}},
+		Storage: storageV3{Files: []fileV3{{
+			Path:      "/etc/kubernetes/kubeconfig",
+			Overwrite: true,
+			User:      &nodeUser{Name: "root"},
+			Mode:      &mode,
+			Contents:  resourceV3{Source: "data:,kubeconfig"},
+		}}},
+		Systemd: systemdV3{Units: []unitV3{{Name: "kubelet.service", Enabled: &enabled, Contents: "[Unit]"}}},
+	}
+	if actual := translateV3(cfg, VersionV3); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %#v, got %#v", expected, actual)
+	}
+}
+
+func TestUserData(t *testing.T) {
+	url := "https://bucket.s3.amazonaws.com/worker.ign"
+	for _, version := range []string{"", VersionV2, "3.0.0", VersionV3} {
+		data, err := UserData(url, nil, version)
+		if err != nil {
+			t.Fatalf("unexpected error for version %q: %v", version, err)
+		}
+		detected, err := VersionOf(data)
+		if err != nil {
+			t.Fatalf("unexpected error for version %q: %v", version, err)
+		}
+		expected := version
+		if expected == "" {
+			expected = VersionV2
+		}
+		if detected != expected {
+			t.Errorf("expected version %s, got %s", expected, detected)
+		}
+		config := map[string]interface{}{}
+		if err := json.Unmarshal(data, &config); err != nil {
+			t.Fatalf("cannot parse user data: %v", err)
+		}
+		stanza := "append"
+		if expected != VersionV2 {
+			stanza = "merge"
+		}
+		sources := config["ignition"].(map[string]interface{})["config"].(map[string]interface{})[stanza].([]interface{})
+		if source := sources[0].(map[string]interface{})["source"]; source != url {
+			t.Errorf("expected %s source %s, got %v", stanza, url, source)
+		}
+	}
+	if _, err := UserData(url, nil, "4.0.0"); err == nil {
+		t.Errorf("expected an error for an unsupported version")
+	}
+}
+
+func TestUserDataCA(t *testing.T) {
+	url := ServerURL("api.example.com", 22623, "token")
+	for _, version := range []string{VersionV2, VersionV3} {
+		data, err := UserData(url, []byte("ca"), version)
+		if err != nil {
+			t.Fatalf("unexpected error for version %s: %v", version, err)
+		}
+		config := map[string]interface{}{}
+		if err := json.Unmarshal(data, &config); err != nil {
+			t.Fatalf("cannot parse user data: %v", err)
+		}
+		cas := config["ignition"].(map[string]interface{})["security"].(map[string]interface{})["tls"].(map[string]interface{})["certificateAuthorities"].([]interface{})
+		if source := cas[0].(map[string]interface{})["source"]; source != dataurl.EncodeBytes([]byte("ca")) {
+			t.Errorf("expected the CA as source for version %s, got %v", version, source)
+		}
+	}
+}
+
+func TestVersionOf(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		expected    string
+		expectError bool
+	}{
+		{name: "spec 2.1", config: `{"ignition":{"version":"2.1.0"}}`, expected: VersionV2},
+		{name: "spec 3.1", config: `{"ignition":{"version":"3.1.0"}}`, expected: VersionV3},
+		{name: "unsupported version", config: `{"ignition":{"version":"3.2.0"}}`, expectError: true},
+		{name: "no version", config: `{"ignition":{}}`, expectError: true},
+		{name: "invalid config", config: `ignition`, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			version, err := VersionOf([]byte(test.config))
+			if test.expectError != (err != nil) {
+				t.Fatalf("expected error %t, got %v", test.expectError, err)
+			}
+			if version != test.expected {
+				t.Errorf("expected version %q, got %q", test.expected, version)
+			}
+		})
+	}
+}
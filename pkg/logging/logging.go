@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	ctrlzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+const (
+	// FormatText logs human readable lines
+	FormatText = "text"
+	// FormatJSON logs a JSON object per line
+	FormatJSON = "json"
+)
+
+var levels = map[string]zapcore.Level{
+	"debug": zapcore.DebugLevel,
+	"info":  zapcore.InfoLevel,
+	"warn":  zapcore.WarnLevel,
+	"error": zapcore.ErrorLevel,
+}
+
+// Options are the logging flags shared by the binaries of the toolkit. They configure
+// both logrus, which commands log with, and the logger of controller-runtime, which
+// controllers log with, so that all the logs of a binary have the same level and format.
+type Options struct {
+	Level  string
+	Format string
+}
+
+// NewOptions returns the default logging options
+func NewOptions() *Options {
+	return &Options{
+		Level:  "info",
+		Format: FormatText,
+	}
+}
+
+// AddFlags adds the --log-level and --log-format flags
+func (o *Options) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.Level, "log-level", o.Level, "Level of the logs, one of: debug, info, warn, error")
+	flags.StringVar(&o.Format, "log-format", o.Format, "Format of the logs, one of: text, json")
+}
+
+// Validate verifies that the level and format of the logs are supported
+func (o *Options) Validate() error {
+	if _, ok := levels[strings.ToLower(o.Level)]; !ok {
+		return fmt.Errorf("unsupported log level %q, must be one of: debug, info, warn, error", o.Level)
+	}
+	if o.Format != FormatText && o.Format != FormatJSON {
+		return fmt.Errorf("unsupported log format %q, must be one of: text, json", o.Format)
+	}
+	return nil
+}
+
+// Apply configures logrus and the logger of controller-runtime
+func (o *Options) Apply() error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	level := levels[strings.ToLower(o.Level)]
+	logrusLevel, err := logrus.ParseLevel(level.String())
+	if err != nil {
+		return err
+	}
+	logrus.SetLevel(logrusLevel)
+
+	zapLevel := zap.NewAtomicLevelAt(level)
+	zapOpts := []ctrlzap.Opts{ctrlzap.Level(&zapLevel)}
+	if o.Format == FormatJSON {
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		zapOpts = append(zapOpts, ctrlzap.Encoder(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())))
+	} else {
+		logrus.SetFormatter(&logrus.TextFormatter{})
+		zapOpts = append(zapOpts, ctrlzap.UseDevMode(true))
+	}
+	ctrllog.SetLogger(ctrlzap.New(zapOpts...))
+	return nil
+}
+
+// AddPersistentFlags adds the logging flags to a root command and its subcommands, and
+// configures logging before any of them runs
+func AddPersistentFlags(cmd *cobra.Command) {
+	o := NewOptions()
+	o.AddFlags(cmd.PersistentFlags())
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return o.Apply()
+	}
+}
@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     Options
+		expectError bool
+	}{
+		{name: "defaults", options: *NewOptions()},
+		{name: "json", options: Options{Level: "debug", Format: FormatJSON}},
+		{name: "upper case level", options: Options{Level: "WARN", Format: FormatText}},
+		{name: "unsupported level", options: Options{Level: "trace", Format: FormatText}, expectError: true},
+		{name: "unsupported format", options: Options{Level: "info", Format: "logfmt"}, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.options.Validate(); test.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", test.expectError, err)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	defer logrus.SetFormatter(&logrus.TextFormatter{})
+	defer logrus.SetLevel(logrus.GetLevel())
+	if err := (&Options{Level: "debug", Format: FormatJSON}).Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if logrus.GetLevel() != logrus.DebugLevel {
+		t.Errorf("expected debug level, got %s", logrus.GetLevel())
+	}
+	if _, ok := logrus.StandardLogger().Formatter.(*logrus.JSONFormatter); !ok {
+		t.Errorf("expected JSON formatter, got %T", logrus.StandardLogger().Formatter)
+	}
+}
@@ -0,0 +1,130 @@
+package pki
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/pki/util"
+)
+
+const (
+	// EncryptionProviderAESCBC encrypts resources with a generated AES key
+	EncryptionProviderAESCBC = "aescbc"
+
+	// EncryptionProviderKMS encrypts resources with a KMS plugin
+	EncryptionProviderKMS = "kms"
+
+	// KMSPluginEndpoint is the socket that the KMS plugin listens on
+	KMSPluginEndpoint = "unix:///var/run/kmsplugin/socket.sock"
+
+	encryptionKeySize = 32
+)
+
+// encryptedResources are the resources encrypted in etcd, served by either the
+// kube-apiserver or the openshift-apiserver
+var encryptedResources = []string{
+	"secrets",
+	"configmaps",
+	"routes.route.openshift.io",
+	"oauthaccesstokens.oauth.openshift.io",
+	"oauthauthorizetokens.oauth.openshift.io",
+}
+
+// ValidateEncryption returns an error when the etcd encryption parameters are invalid
+func ValidateEncryption(params api.EtcdEncryptionParams) error {
+	switch params.Provider {
+	case "", EncryptionProviderAESCBC:
+	case EncryptionProviderKMS:
+		if len(params.KMSPluginImage) == 0 {
+			return fmt.Errorf("a KMS plugin image is required with the %s encryption provider", EncryptionProviderKMS)
+		}
+	default:
+		return fmt.Errorf("unsupported encryption provider %q, must be %s or %s", params.Provider, EncryptionProviderAESCBC, EncryptionProviderKMS)
+	}
+	return nil
+}
+
+// writeEncryptionConfig writes the EncryptionConfiguration of the API servers. The AES key
+// is kept across runs and stays in the configuration with the kms provider, so that
+// resources encrypted before a switch to kms can still be read.
+func writeEncryptionConfig(params api.EtcdEncryptionParams, outputDir, name string) error {
+	if len(params.Provider) == 0 {
+		return nil
+	}
+	key, err := encryptionKey(outputDir, name)
+	if err != nil {
+		return err
+	}
+	aescbc := map[string]interface{}{
+		"aescbc": map[string]interface{}{
+			"keys": []interface{}{
+				map[string]interface{}{"name": "key1", "secret": key},
+			},
+		},
+	}
+	providers := []interface{}{aescbc}
+	if params.Provider == EncryptionProviderKMS {
+		kms := map[string]interface{}{
+			"kms": map[string]interface{}{
+				"name":      "kmsplugin",
+				"endpoint":  KMSPluginEndpoint,
+				"cachesize": 1000,
+				"timeout":   "3s",
+			},
+		}
+		providers = []interface{}{kms, aescbc}
+	}
+	// The identity provider reads the resources stored before encryption was enabled
+	providers = append(providers, map[string]interface{}{"identity": map[string]interface{}{}})
+	config := map[string]interface{}{
+		"apiVersion": "apiserver.config.k8s.io/v1",
+		"kind":       "EncryptionConfiguration",
+		"resources": []interface{}{
+			map[string]interface{}{
+				"resources": encryptedResources,
+				"providers": providers,
+			},
+		},
+	}
+	b, err := yaml.Marshal(config)
+	if err != nil {
+		return errors.Wrap(err, "cannot serialize encryption config")
+	}
+	fileName := filepath.Join(outputDir, name+".yaml")
+	log.Infof("Writing encryption config %s", fileName)
+	if err := ioutil.WriteFile(fileName, b, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write encryption config %s", fileName)
+	}
+	return nil
+}
+
+// encryptionKey returns the base64 encoded AES key in <name>.key, generating it when
+// the file does not exist
+func encryptionKey(outputDir, name string) (string, error) {
+	fileName := filepath.Join(outputDir, name+".key")
+	if util.FileExists(fileName) {
+		b, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read encryption key %s", fileName)
+		}
+		return string(b), nil
+	}
+	key := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", errors.Wrap(err, "cannot generate encryption key")
+	}
+	encoded := base64.StdEncoding.EncodeToString(key)
+	log.Infof("Writing encryption key %s", fileName)
+	if err := ioutil.WriteFile(fileName, []byte(encoded), 0600); err != nil {
+		return "", errors.Wrapf(err, "failed to write encryption key %s", fileName)
+	}
+	return encoded, nil
+}
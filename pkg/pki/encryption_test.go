@@ -0,0 +1,82 @@
+package pki
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestValidateEncryption(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      api.EtcdEncryptionParams
+		expectError bool
+	}{
+		{name: "disabled", params: api.EtcdEncryptionParams{}},
+		{name: "aescbc", params: api.EtcdEncryptionParams{Provider: "aescbc"}},
+		{name: "kms", params: api.EtcdEncryptionParams{Provider: "kms", KMSPluginImage: "quay.io/example/kms-plugin"}},
+		{name: "kms without plugin", params: api.EtcdEncryptionParams{Provider: "kms"}, expectError: true},
+		{name: "unsupported provider", params: api.EtcdEncryptionParams{Provider: "secretbox"}, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ValidateEncryption(test.params); test.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", test.expectError, err)
+			}
+		})
+	}
+}
+
+func TestWriteEncryptionConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "encryption")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := writeEncryptionConfig(api.EtcdEncryptionParams{}, dir, "etcd-encryption"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "etcd-encryption.yaml")); !os.IsNotExist(err) {
+		t.Fatalf("expected no encryption config without a provider")
+	}
+
+	providers := func(params api.EtcdEncryptionParams) []map[string]interface{} {
+		if err := writeEncryptionConfig(params, dir, "etcd-encryption"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, "etcd-encryption.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		config := struct {
+			Resources []struct {
+				Providers []map[string]interface{} `json:"providers"`
+			} `json:"resources"`
+		}{}
+		if err := yaml.Unmarshal(b, &config); err != nil {
+			t.Fatalf("cannot parse encryption config: %v", err)
+		}
+		return config.Resources[0].Providers
+	}
+	keyOf := func(provider map[string]interface{}) interface{} {
+		return provider["aescbc"].(map[string]interface{})["keys"].([]interface{})[0].(map[string]interface{})["secret"]
+	}
+
+	aescbc := providers(api.EtcdEncryptionParams{Provider: "aescbc"})
+	if len(aescbc) != 2 || aescbc[0]["aescbc"] == nil || aescbc[1]["identity"] == nil {
+		t.Fatalf("expected aescbc and identity providers, got %v", aescbc)
+	}
+	kms := providers(api.EtcdEncryptionParams{Provider: "kms", KMSPluginImage: "quay.io/example/kms-plugin"})
+	if len(kms) != 3 || kms[0]["kms"] == nil || kms[1]["aescbc"] == nil || kms[2]["identity"] == nil {
+		t.Fatalf("expected kms, aescbc and identity providers, got %v", kms)
+	}
+	if keyOf(aescbc[0]) != keyOf(kms[1]) {
+		t.Errorf("expected the encryption key to be kept across runs")
+	}
+}
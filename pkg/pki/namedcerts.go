@@ -0,0 +1,102 @@
+package pki
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+// namedCertSpecs returns the specs of the named certificates that are generated rather than
+// provided. Named certificates cannot replace the other PKI artifacts.
+func namedCertSpecs(namedCerts []api.NamedCert, cas []caSpec, kubeconfigs []kubeconfigSpec, certs []certSpec) ([]certSpec, error) {
+	reserved := map[string]bool{}
+	for _, spec := range cas {
+		reserved[spec.name] = true
+	}
+	for _, spec := range kubeconfigs {
+		reserved[spec.name] = true
+	}
+	for _, spec := range certs {
+		reserved[spec.name] = true
+	}
+	var specs []certSpec
+	for _, namedCert := range namedCerts {
+		if reserved[namedCert.NamedCertPrefix] {
+			return nil, errors.Errorf("named certificate %s would replace another certificate of the cluster", namedCert.NamedCertPrefix)
+		}
+		reserved[namedCert.NamedCertPrefix] = true
+		if len(namedCert.NamedCertFile) > 0 {
+			continue
+		}
+		specs = append(specs, cert(namedCert.NamedCertPrefix, "root-ca", namedCert.NamedCertDomain, "openshift", []string{namedCert.NamedCertDomain}, nil))
+	}
+	return specs, nil
+}
+
+// writeProvidedNamedCerts copies the certificates and keys of the named certificates that
+// are provided to the output directory, after verifying that they are valid for their domains
+func writeProvidedNamedCerts(namedCerts []api.NamedCert, outputDir string) error {
+	for _, namedCert := range namedCerts {
+		if len(namedCert.NamedCertFile) == 0 {
+			continue
+		}
+		certBytes, keyBytes, err := loadNamedCert(namedCert, time.Now())
+		if err != nil {
+			return err
+		}
+		fileName := filepath.Join(outputDir, namedCert.NamedCertPrefix)
+		log.Infof("Writing named certificate and key to %s", fileName)
+		if err := ioutil.WriteFile(fileName+".crt", certBytes, 0644); err != nil {
+			return errors.Wrapf(err, "failed to write named certificate %s", fileName)
+		}
+		if err := ioutil.WriteFile(fileName+".key", keyBytes, 0644); err != nil {
+			return errors.Wrapf(err, "failed to write key of named certificate %s", fileName)
+		}
+	}
+	return nil
+}
+
+// loadNamedCert reads the certificate and key files of a named certificate and verifies
+// that the key matches the certificate, which is valid now and for its domain
+func loadNamedCert(namedCert api.NamedCert, now time.Time) ([]byte, []byte, error) {
+	name := namedCert.NamedCertPrefix
+	if len(namedCert.NamedKeyFile) == 0 {
+		return nil, nil, errors.Errorf("named certificate %s has a certificate file but no key file", name)
+	}
+	certBytes, err := ioutil.ReadFile(namedCert.NamedCertFile)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to read named certificate %s", name)
+	}
+	keyBytes, err := ioutil.ReadFile(namedCert.NamedKeyFile)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to read key of named certificate %s", name)
+	}
+	pair, err := tls.X509KeyPair(certBytes, keyBytes)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid named certificate %s", name)
+	}
+	crt, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid named certificate %s", name)
+	}
+	if now.Before(crt.NotBefore) || now.After(crt.NotAfter) {
+		return nil, nil, errors.Errorf("named certificate %s is only valid from %v to %v", name, crt.NotBefore, crt.NotAfter)
+	}
+	// A host in a wildcard domain must match the certificate
+	host := namedCert.NamedCertDomain
+	if strings.HasPrefix(host, "*.") {
+		host = "host" + host[1:]
+	}
+	if err := crt.VerifyHostname(host); err != nil {
+		return nil, nil, errors.Wrapf(err, "named certificate %s is not valid for %s", name, namedCert.NamedCertDomain)
+	}
+	return certBytes, keyBytes, nil
+}
@@ -0,0 +1,98 @@
+package pki
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/pki/util"
+)
+
+func TestNamedCertSpecs(t *testing.T) {
+	cas := []caSpec{ca("root-ca", "root-ca", "openshift")}
+	certs := []certSpec{cert("kube-apiserver-server", "root-ca", "kubernetes", "kubernetes", nil, nil)}
+	namedCerts := []api.NamedCert{
+		{NamedCertPrefix: "api-custom", NamedCertDomain: "api.example.com"},
+		{NamedCertPrefix: "api-provided", NamedCertDomain: "*.example.org", NamedCertFile: "api.crt", NamedKeyFile: "api.key"},
+	}
+	specs, err := namedCertSpecs(namedCerts, cas, nil, certs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(specs) != 1 || specs[0].name != "api-custom" || specs[0].ca != "root-ca" || len(specs[0].hostNames) != 1 || specs[0].hostNames[0] != "api.example.com" {
+		t.Errorf("expected only a certificate for the generated named certificate, got %+v", specs)
+	}
+	for _, prefix := range []string{"root-ca", "kube-apiserver-server"} {
+		if _, err = namedCertSpecs([]api.NamedCert{{NamedCertPrefix: prefix, NamedCertDomain: "api.example.com"}}, cas, nil, certs); err == nil {
+			t.Errorf("expected an error for named certificate %s", prefix)
+		}
+	}
+}
+
+func TestLoadNamedCert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "namedcerts")
+	if err != nil {
+		t.Fatalf("cannot create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts := util.CertOptions{KeySize: 1024}
+	ca, err := util.GenerateCA("corporate-ca", "corporate", opts)
+	if err != nil {
+		t.Fatalf("cannot generate CA: %v", err)
+	}
+	for name, hostName := range map[string]string{"api": "api.example.com", "wildcard": "*.example.com"} {
+		cert, err := util.GenerateCert(hostName, "corporate", []string{hostName}, nil, ca, opts)
+		if err != nil {
+			t.Fatalf("cannot generate certificate: %v", err)
+		}
+		if err = cert.WriteTo(filepath.Join(dir, name), true); err != nil {
+			t.Fatalf("cannot write certificate: %v", err)
+		}
+	}
+	namedCert := func(name, domain string) api.NamedCert {
+		return api.NamedCert{
+			NamedCertPrefix: "api-custom",
+			NamedCertDomain: domain,
+			NamedCertFile:   filepath.Join(dir, name+".crt"),
+			NamedKeyFile:    filepath.Join(dir, name+".key"),
+		}
+	}
+	mismatched := namedCert("api", "api.example.com")
+	mismatched.NamedKeyFile = filepath.Join(dir, "wildcard.key")
+	tests := []struct {
+		name        string
+		namedCert   api.NamedCert
+		now         time.Time
+		expectError bool
+	}{
+		{name: "valid", namedCert: namedCert("api", "api.example.com"), now: time.Now()},
+		{name: "wildcard certificate", namedCert: namedCert("wildcard", "api.example.com"), now: time.Now()},
+		{name: "wildcard domain", namedCert: namedCert("wildcard", "*.example.com"), now: time.Now()},
+		{name: "other domain", namedCert: namedCert("api", "console.example.com"), now: time.Now(), expectError: true},
+		{name: "wildcard certificate of another domain", namedCert: namedCert("wildcard", "*.apps.example.com"), now: time.Now(), expectError: true},
+		{name: "expired", namedCert: namedCert("api", "api.example.com"), now: time.Now().AddDate(20, 0, 0), expectError: true},
+		{name: "mismatched key", namedCert: mismatched, now: time.Now(), expectError: true},
+		{name: "missing file", namedCert: namedCert("missing", "api.example.com"), now: time.Now(), expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			certBytes, keyBytes, err := loadNamedCert(test.namedCert, test.now)
+			if test.expectError {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(certBytes) == 0 || len(keyBytes) == 0 {
+				t.Errorf("expected the certificate and key")
+			}
+		})
+	}
+}
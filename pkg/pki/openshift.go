@@ -12,10 +12,22 @@ import (
 func GeneratePKI(params *api.ClusterParams, outputDir string) error {
 	log.Info("Generating PKI artifacts")
 
+	opts, err := certOptions(params.PKI)
+	if err != nil {
+		return err
+	}
+	if err := ValidateEncryption(params.EtcdEncryption); err != nil {
+		return err
+	}
+	if len(params.EtcdCAFile) > 0 && len(params.EtcdEndpoints) == 0 {
+		return errors.New("an etcd CA file requires external etcd endpoints")
+	}
+
 	cas := []caSpec{
 		ca("root-ca", "root-ca", "openshift"),
 		ca("cluster-signer", "cluster-signer", "openshift"),
 		ca("openvpn-ca", "openvpn-ca", "openshift"),
+		ca("konnectivity-ca", "konnectivity-ca", "openshift"),
 	}
 
 	externalAPIServerAddress := fmt.Sprintf("https://%s:%d", params.ExternalAPIDNSName, params.ExternalAPIPort)
@@ -42,10 +54,12 @@ func GeneratePKI(params *api.ClusterParams, outputDir string) error {
 				fmt.Sprintf("kube-apiserver.%s.svc", params.Namespace),
 				fmt.Sprintf("kube-apiserver.%s.svc.cluster.local", params.Namespace),
 				params.ExternalAPIDNSName,
+				"localhost",
 			},
 			[]string{
 				kubeIP.String(),
 				params.ExternalAPIIPAddress,
+				"127.0.0.1",
 			}),
 		cert("kube-apiserver-kubelet", "root-ca", "system:kube-apiserver", "kubernetes", nil, nil),
 		cert("kube-apiserver-aggregator-proxy-client", "root-ca", "system:openshift-aggregator", "kubernetes", nil, nil),
@@ -66,6 +80,16 @@ func GeneratePKI(params *api.ClusterParams, outputDir string) error {
 				fmt.Sprintf("*.etcd.%s.svc.cluster.local", params.Namespace),
 			}, nil),
 
+		// kube-controller-manager
+		cert("kube-controller-manager-server", "root-ca", "kube-controller-manager", "kubernetes",
+			[]string{
+				"kube-controller-manager",
+				"localhost",
+			}, []string{"127.0.0.1"}),
+
+		// metrics proxies
+		cert("metrics-client", "root-ca", "hypershift:metrics", "kubernetes", nil, nil),
+
 		// openshift-apiserver
 		cert("openshift-apiserver-server", "root-ca", "openshift-apiserver", "openshift",
 			[]string{
@@ -93,21 +117,71 @@ func GeneratePKI(params *api.ClusterParams, outputDir string) error {
 			}, nil),
 		// oauth server
 		cert("oauth-openshift", "root-ca", "openshift-oauth", "openshift",
-			[]string{
+			nonEmpty(
 				params.ExternalAPIDNSName,
-			}, nil),
+				params.ExternalOauthDNSName,
+			), nil),
+		// ignition server
+		cert("ignition-server", "root-ca", "ignition-server", "openshift",
+			nonEmpty(
+				"ignition-server",
+				fmt.Sprintf("ignition-server.%s.svc", params.Namespace),
+				params.ExternalAPIDNSName,
+				params.ExternalIgnitionDNSName,
+			), nil),
 		cert("openvpn-kube-apiserver-client", "openvpn-ca", "kube-apiserver", "kubernetes", nil, nil),
 		cert("openvpn-worker-client", "openvpn-ca", "worker", "kubernetes", nil, nil),
+
+		// konnectivity
+		cert("konnectivity-server", "konnectivity-ca", "konnectivity-server", "kubernetes",
+			nonEmpty(
+				"konnectivity-server",
+				fmt.Sprintf("konnectivity-server.%s.svc", params.Namespace),
+				params.ExternalKonnectivityDNSName,
+			), nil),
+		cert("konnectivity-agent", "konnectivity-ca", "konnectivity-agent", "kubernetes", nil, nil),
 	}
-	caMap, err := generateCAs(cas)
+	namedCerts, err := namedCertSpecs(params.NamedCerts, cas, kubeconfigs, certs)
 	if err != nil {
 		return err
 	}
-	kubeconfigMap, err := generateKubeconfigs(kubeconfigs, caMap)
+	certs = append(certs, namedCerts...)
+	overrides, err := keyTypes(params.PKI)
 	if err != nil {
 		return err
 	}
-	certMap, err := generateCerts(certs, caMap)
+	if err := applyKeyTypes(overrides, cas, kubeconfigs, certs); err != nil {
+		return err
+	}
+	loaded, err := loadCAs(cas, params.PKI.CADirectory)
+	if err != nil {
+		return err
+	}
+	if err := validateLoadedCAs(loaded, opts); err != nil {
+		return err
+	}
+	if params.FIPS {
+		if err := validateFIPS(opts, loaded); err != nil {
+			return err
+		}
+	}
+	for _, ca := range cas {
+		if _, ok := loaded[ca.name]; ok {
+			continue
+		}
+		if err := validateSignatureAlgorithm(opts.SignatureAlgorithm, ca.name, withKeyType(opts, ca.keyType).KeyType); err != nil {
+			return err
+		}
+	}
+	caMap, err := generateCAs(cas, loaded, opts)
+	if err != nil {
+		return err
+	}
+	kubeconfigMap, err := generateKubeconfigs(kubeconfigs, caMap, opts)
+	if err != nil {
+		return err
+	}
+	certMap, err := generateCerts(certs, caMap, opts)
 	if err != nil {
 		return err
 	}
@@ -121,16 +195,28 @@ func GeneratePKI(params *api.ClusterParams, outputDir string) error {
 	if err := writeCerts(certMap, outputDir); err != nil {
 		return err
 	}
+	if err := writeProvidedNamedCerts(params.NamedCerts, outputDir); err != nil {
+		return err
+	}
 
 	// Miscellaneous PKI artifacts
 	if err := writeCombinedCA([]string{"root-ca", "cluster-signer"}, caMap, outputDir, "combined-ca"); err != nil {
 		return err
 	}
-	if err := writeRSAKey(outputDir, "service-account"); err != nil {
+	if err := writeEtcdCA(params.EtcdCAFile, caMap, outputDir, "etcd-ca"); err != nil {
+		return err
+	}
+	if err := writeRSAKey(outputDir, "service-account", opts.KeySize); err != nil {
 		return err
 	}
 	if err := writeDHParams(outputDir, "openvpn-dh"); err != nil {
 		return err
 	}
+	if err := writeCRL(outputDir, "openvpn-ca", "openvpn-crl"); err != nil {
+		return err
+	}
+	if err := writeEncryptionConfig(params.EtcdEncryption, outputDir, "etcd-encryption"); err != nil {
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,109 @@
+package pki
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/pki/util"
+)
+
+// RevocableCerts are the OpenVPN client certificates that can be revoked. The OpenVPN
+// server verifies clients with the CRL of the OpenVPN CA.
+var RevocableCerts = []string{"openvpn-kube-apiserver-client", "openvpn-worker-client"}
+
+// RevokeVPNCerts adds OpenVPN client certificates of the PKI in pkiDir to the CRL of the
+// OpenVPN CA, and replaces them with new certificates with the same subject. The secrets of
+// the cluster must be rendered and applied again for the server to reject the revoked
+// certificates and for the clients to use the new ones.
+func RevokeVPNCerts(params *api.ClusterParams, pkiDir string, names []string) error {
+	opts, err := certOptions(params.PKI)
+	if err != nil {
+		return err
+	}
+	revocable := map[string]bool{}
+	for _, name := range RevocableCerts {
+		revocable[name] = true
+	}
+	for _, name := range names {
+		if !revocable[name] {
+			return errors.Errorf("cannot revoke %s, only %v can be revoked", name, RevocableCerts)
+		}
+	}
+
+	ca, err := util.LoadCA(filepath.Join(pkiDir, "openvpn-ca"))
+	if err != nil {
+		return err
+	}
+	crlFile := filepath.Join(pkiDir, "openvpn-crl.pem")
+	b, err := ioutil.ReadFile(crlFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read CRL %s", crlFile)
+	}
+	crl, err := util.PemToCRL(b, ca)
+	if err != nil {
+		return err
+	}
+
+	// The CRL is written before the certificates are replaced, so that a failure leaves
+	// revoked certificates behind that are reissued when the command is run again
+	certs := make(map[string]*x509.Certificate, len(names))
+	for _, name := range names {
+		fileName := filepath.Join(pkiDir, name)
+		b, err := ioutil.ReadFile(fileName + ".crt")
+		if err != nil {
+			return errors.Wrapf(err, "failed to read certificate %s", fileName)
+		}
+		cert, err := util.PemToCertificate(b)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse certificate %s", fileName)
+		}
+		if err = cert.CheckSignatureFrom(ca.Cert); err != nil {
+			return errors.Wrapf(err, "certificate %s is not signed by the OpenVPN CA", name)
+		}
+		if util.RevokeCertificate(crl, cert) {
+			log.Infof("Revoking certificate %s (serial %s)", name, cert.SerialNumber)
+		}
+		certs[name] = cert
+	}
+	b, err = util.GenerateCRL(ca, crl.TBSCertList.RevokedCertificates)
+	if err != nil {
+		return err
+	}
+	log.Infof("Writing CRL %s", crlFile)
+	if err := ioutil.WriteFile(crlFile, b, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write CRL %s", crlFile)
+	}
+
+	for _, name := range names {
+		cert := certs[name]
+		fileName := filepath.Join(pkiDir, name)
+		log.Infof("Generating certificate %s (cn=%s)", name, cert.Subject.CommonName)
+		organization := ""
+		if len(cert.Subject.Organization) > 0 {
+			organization = cert.Subject.Organization[0]
+		}
+		var ips []string
+		for _, ip := range cert.IPAddresses {
+			ips = append(ips, ip.String())
+		}
+		reissued, err := util.GenerateCert(cert.Subject.CommonName, organization, cert.DNSNames, ips, ca, opts)
+		if err != nil {
+			return err
+		}
+		for _, ext := range []string{".crt", ".key"} {
+			if err := os.Remove(fileName + ext); err != nil && !os.IsNotExist(err) {
+				return errors.Wrapf(err, "failed to remove %s", fileName+ext)
+			}
+		}
+		if err := reissued.WriteTo(fileName, false); err != nil {
+			return errors.Wrapf(err, "failed to write certificate to file %s", fileName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package pki
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/pki/util"
+)
+
+func TestRevokeVPNCerts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pki")
+	if err != nil {
+		t.Fatalf("cannot create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts := util.CertOptions{KeySize: 1024}
+	ca, err := util.GenerateCA("openvpn-ca", "openshift", opts)
+	if err != nil {
+		t.Fatalf("cannot generate CA: %v", err)
+	}
+	if err = ca.WriteTo(filepath.Join(dir, "openvpn-ca")); err != nil {
+		t.Fatalf("cannot write CA: %v", err)
+	}
+	cert, err := util.GenerateCert("worker", "kubernetes", nil, nil, ca, opts)
+	if err != nil {
+		t.Fatalf("cannot generate certificate: %v", err)
+	}
+	if err = cert.WriteTo(filepath.Join(dir, "openvpn-worker-client"), false); err != nil {
+		t.Fatalf("cannot write certificate: %v", err)
+	}
+	if err = writeCRL(dir, "openvpn-ca", "openvpn-crl"); err != nil {
+		t.Fatalf("cannot write CRL: %v", err)
+	}
+
+	params := &api.ClusterParams{}
+	if err = RevokeVPNCerts(params, dir, []string{"openvpn-server"}); err == nil {
+		t.Fatalf("expected an error revoking the server certificate")
+	}
+	if err = RevokeVPNCerts(params, dir, []string{"openvpn-worker-client"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "openvpn-crl.pem"))
+	if err != nil {
+		t.Fatalf("cannot read CRL: %v", err)
+	}
+	crl, err := util.PemToCRL(b, ca)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !util.IsRevoked(crl, cert.Cert.SerialNumber) {
+		t.Errorf("expected the certificate to be revoked")
+	}
+	b, err = ioutil.ReadFile(filepath.Join(dir, "openvpn-worker-client.crt"))
+	if err != nil {
+		t.Fatalf("cannot read certificate: %v", err)
+	}
+	reissued, err := util.PemToCertificate(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reissued.SerialNumber.Cmp(cert.Cert.SerialNumber) == 0 || reissued.Subject.CommonName != "worker" {
+		t.Errorf("expected a new certificate for worker, got %s (serial %s)", reissued.Subject.CommonName, reissued.SerialNumber)
+	}
+	if util.IsRevoked(crl, reissued.SerialNumber) {
+		t.Errorf("expected the new certificate not to be revoked")
+	}
+	// Generating the PKI again keeps the CRL of the CA
+	if err = writeCRL(dir, "openvpn-ca", "openvpn-crl"); err != nil {
+		t.Fatalf("cannot write CRL: %v", err)
+	}
+	if b, err = ioutil.ReadFile(filepath.Join(dir, "openvpn-crl.pem")); err != nil {
+		t.Fatalf("cannot read CRL: %v", err)
+	}
+	if crl, err = util.PemToCRL(b, ca); err != nil || !util.IsRevoked(crl, cert.Cert.SerialNumber) {
+		t.Errorf("expected the CRL to be kept, got error %v", err)
+	}
+}
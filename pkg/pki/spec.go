@@ -1,13 +1,16 @@
 package pki
 
 import (
+	"crypto/x509"
 	"io/ioutil"
 	"net"
 	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/openshift/hypershift-toolkit/pkg/api"
 	"github.com/openshift/hypershift-toolkit/pkg/pki/util"
 )
 
@@ -15,6 +18,7 @@ type caSpec struct {
 	name               string
 	commonName         string
 	organizationalUnit string
+	keyType            util.KeyType
 }
 
 type certSpec struct {
@@ -24,6 +28,45 @@ type certSpec struct {
 	organization string
 	hostNames    []string
 	ips          []string
+	keyType      util.KeyType
+}
+
+// withKeyType returns the options with the key type replaced by the one of a spec, if set
+func withKeyType(opts util.CertOptions, keyType util.KeyType) util.CertOptions {
+	if keyType != "" {
+		opts.KeyType = keyType
+	}
+	return opts
+}
+
+// applyKeyTypes sets the key types of the specs that are overridden in keyTypes
+func applyKeyTypes(keyTypes map[string]util.KeyType, cas []caSpec, kubeconfigs []kubeconfigSpec, certs []certSpec) error {
+	remaining := make(map[string]util.KeyType, len(keyTypes))
+	for name, keyType := range keyTypes {
+		remaining[name] = keyType
+	}
+	for i := range cas {
+		if keyType, ok := remaining[cas[i].name]; ok {
+			cas[i].keyType = keyType
+			delete(remaining, cas[i].name)
+		}
+	}
+	for i := range kubeconfigs {
+		if keyType, ok := remaining[kubeconfigs[i].name]; ok {
+			kubeconfigs[i].keyType = keyType
+			delete(remaining, kubeconfigs[i].name)
+		}
+	}
+	for i := range certs {
+		if keyType, ok := remaining[certs[i].name]; ok {
+			certs[i].keyType = keyType
+			delete(remaining, certs[i].name)
+		}
+	}
+	for name := range remaining {
+		return errors.Errorf("cannot set key type of %s: no CA, certificate or kubeconfig with that name", name)
+	}
+	return nil
 }
 
 type kubeconfigSpec struct {
@@ -31,11 +74,79 @@ type kubeconfigSpec struct {
 	serverAddress string
 }
 
-func generateCAs(caSpecs []caSpec) (map[string]*util.CA, error) {
+// loadCAs reads the key pairs of the CAs that exist in caDir. CAs that are not found
+// there are left out of the result.
+func loadCAs(caSpecs []caSpec, caDir string) (map[string]*util.CA, error) {
+	result := make(map[string]*util.CA)
+	if caDir == "" {
+		return result, nil
+	}
+	for _, caSpec := range caSpecs {
+		fileName := filepath.Join(caDir, caSpec.name)
+		if !util.CertExists(fileName) && !util.FileExists(fileName+".key") {
+			continue
+		}
+		log.Infof("Loading CA %s from %s", caSpec.name, caDir)
+		ca, err := util.LoadCA(fileName)
+		if err != nil {
+			return nil, err
+		}
+		result[caSpec.name] = ca
+	}
+	return result, nil
+}
+
+// validateLoadedCAs verifies that the certificates signed by the loaded CAs do not
+// outlive them and that the CA keys can be used with the signature algorithm.
+func validateLoadedCAs(loaded map[string]*util.CA, opts util.CertOptions) error {
+	notAfter := time.Now().Add(opts.GetValidity())
+	for name, ca := range loaded {
+		if ca.Cert.NotAfter.Before(notAfter) {
+			return errors.Errorf("CA %s expires at %v, before certificates with a validity of %v", name, ca.Cert.NotAfter, opts.GetValidity())
+		}
+		keyType, _, err := util.KeyTypeOf(ca.Key.Public())
+		if err != nil {
+			return errors.Wrapf(err, "unsupported key of CA %s", name)
+		}
+		if err := validateSignatureAlgorithm(opts.SignatureAlgorithm, name, keyType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fipsRSAKeySizes are the RSA key sizes approved by FIPS 186-4
+var fipsRSAKeySizes = map[int]bool{2048: true, 3072: true, 4096: true}
+
+// validateFIPS verifies that the keys generated with opts and the keys of the loaded CAs can
+// be used in FIPS mode. All supported key types, curves and signature algorithms are FIPS
+// approved, but only some RSA key sizes are.
+func validateFIPS(opts util.CertOptions, loaded map[string]*util.CA) error {
+	if opts.KeySize != 0 && !fipsRSAKeySizes[opts.KeySize] {
+		return errors.Errorf("key size %d is not allowed in FIPS mode, it must be 2048, 3072 or 4096", opts.KeySize)
+	}
+	for name, ca := range loaded {
+		keyType, size, err := util.KeyTypeOf(ca.Key.Public())
+		if err != nil {
+			return errors.Wrapf(err, "unsupported key of CA %s", name)
+		}
+		if keyType == util.KeyTypeRSA && !fipsRSAKeySizes[size] {
+			return errors.Errorf("the %d bit RSA key of CA %s is not allowed in FIPS mode", size, name)
+		}
+	}
+	return nil
+}
+
+// generateCAs generates the CAs that are not in existing and returns all of them
+func generateCAs(caSpecs []caSpec, existing map[string]*util.CA, opts util.CertOptions) (map[string]*util.CA, error) {
 	result := make(map[string]*util.CA)
 	for _, caSpec := range caSpecs {
+		if ca, ok := existing[caSpec.name]; ok {
+			result[caSpec.name] = ca
+			continue
+		}
 		log.Infof("Generating CA %s (cn=%s,ou=%s)", caSpec.name, caSpec.commonName, caSpec.organizationalUnit)
-		ca, err := util.GenerateCA(caSpec.commonName, caSpec.organizationalUnit)
+		ca, err := util.GenerateCA(caSpec.commonName, caSpec.organizationalUnit, withKeyType(opts, caSpec.keyType))
 		if err != nil {
 			return nil, err
 		}
@@ -44,7 +155,7 @@ func generateCAs(caSpecs []caSpec) (map[string]*util.CA, error) {
 	return result, nil
 }
 
-func generateKubeconfigs(kubeconfigSpecs []kubeconfigSpec, cas map[string]*util.CA) (map[string]*util.Kubeconfig, error) {
+func generateKubeconfigs(kubeconfigSpecs []kubeconfigSpec, cas map[string]*util.CA, opts util.CertOptions) (map[string]*util.Kubeconfig, error) {
 	result := make(map[string]*util.Kubeconfig)
 	for _, spec := range kubeconfigSpecs {
 		log.Infof("Generating kubeconfig %s (cn=%s,o=%s)", spec.name, spec.commonName, spec.organization)
@@ -52,7 +163,7 @@ func generateKubeconfigs(kubeconfigSpecs []kubeconfigSpec, cas map[string]*util.
 		if ca == nil {
 			return nil, errors.Errorf("CA %s for kubeconfig %s not found", spec.ca, spec.name)
 		}
-		kubeconfig, err := util.GenerateKubeconfig(spec.serverAddress, spec.commonName, spec.organization, cas["root-ca"], ca)
+		kubeconfig, err := util.GenerateKubeconfig(spec.serverAddress, spec.commonName, spec.organization, cas["root-ca"], ca, withKeyType(opts, spec.keyType))
 		if err != nil {
 			return nil, err
 		}
@@ -61,7 +172,7 @@ func generateKubeconfigs(kubeconfigSpecs []kubeconfigSpec, cas map[string]*util.
 	return result, nil
 }
 
-func generateCerts(certSpecs []certSpec, cas map[string]*util.CA) (map[string]*util.Cert, error) {
+func generateCerts(certSpecs []certSpec, cas map[string]*util.CA, opts util.CertOptions) (map[string]*util.Cert, error) {
 	result := make(map[string]*util.Cert)
 	for _, spec := range certSpecs {
 		log.Infof("Generating certificate %s (cn=%s,o=%s)", spec.name, spec.commonName, spec.organization)
@@ -69,7 +180,7 @@ func generateCerts(certSpecs []certSpec, cas map[string]*util.CA) (map[string]*u
 		if ca == nil {
 			return nil, errors.Errorf("CA %s for certificate %s not found", spec.ca, spec.name)
 		}
-		cert, err := util.GenerateCert(spec.commonName, spec.organization, spec.hostNames, spec.ips, ca)
+		cert, err := util.GenerateCert(spec.commonName, spec.organization, spec.hostNames, spec.ips, ca, withKeyType(opts, spec.keyType))
 		if err != nil {
 			return nil, err
 		}
@@ -151,14 +262,42 @@ func writeCombinedCA(cas []string, caMap map[string]*util.CA, outputDir, fileNam
 	return nil
 }
 
-func writeRSAKey(outputDir, name string) error {
+// writeEtcdCA writes the CA bundle that the API servers trust for etcd, which is the root
+// CA unless the cluster uses an external etcd with its own CA. The bundle is rewritten on
+// every run, so that it follows changes of the cluster parameters.
+func writeEtcdCA(caFile string, caMap map[string]*util.CA, outputDir, name string) error {
+	var b []byte
+	if len(caFile) > 0 {
+		var err error
+		if b, err = ioutil.ReadFile(caFile); err != nil {
+			return errors.Wrapf(err, "failed to read etcd CA file %s", caFile)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(b) {
+			return errors.Errorf("etcd CA file %s does not contain a PEM encoded certificate", caFile)
+		}
+	} else {
+		ca := caMap["root-ca"]
+		if ca == nil {
+			return errors.New("failed to write etcd CA. CA not found: root-ca")
+		}
+		b = util.CertToPem(ca.Cert)
+	}
+	fileName := filepath.Join(outputDir, name+".crt")
+	log.Infof("Writing etcd CA file %s", fileName)
+	if err := ioutil.WriteFile(fileName, b, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write etcd CA file %s", fileName)
+	}
+	return nil
+}
+
+func writeRSAKey(outputDir, name string, size int) error {
 	privateFilename := filepath.Join(outputDir, name+".key")
 	publicFilename := filepath.Join(outputDir, name+".pub")
 	if util.FileExists(privateFilename) && util.FileExists(publicFilename) {
 		log.Infof("Skipping RSA key %s because it already exists", name)
 		return nil
 	}
-	key, err := util.PrivateKey()
+	key, err := util.PrivateKeyWithSize(size)
 	if err != nil {
 		return err
 	}
@@ -195,6 +334,46 @@ func writeDHParams(outputDir, name string) error {
 	return nil
 }
 
+// writeCRL writes an empty certificate revocation list of the CA written to the output
+// directory, unless a CRL of that CA already exists
+func writeCRL(outputDir, caName, name string) error {
+	fileName := filepath.Join(outputDir, name+".pem")
+	ca, err := util.LoadCA(filepath.Join(outputDir, caName))
+	if err != nil {
+		return err
+	}
+	if b, err := ioutil.ReadFile(fileName); err == nil {
+		if _, err = util.PemToCRL(b, ca); err == nil {
+			log.Infof("Skipping CRL %s because it already exists", fileName)
+			return nil
+		}
+		log.WithError(err).Warnf("Replacing CRL %s", fileName)
+	}
+	if !ca.CanSignCRL() {
+		return errors.Errorf("CA %s cannot sign certificate revocation lists, remove it to generate a new CA", caName)
+	}
+	b, err := util.GenerateCRL(ca, nil)
+	if err != nil {
+		return err
+	}
+	log.Infof("Writing CRL %s", fileName)
+	if err := ioutil.WriteFile(fileName, b, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write CRL %s", fileName)
+	}
+	return nil
+}
+
+// nonEmpty returns the values that are not empty
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, value := range values {
+		if len(value) > 0 {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
 func nextIP(ip net.IP) net.IP {
 	nextIP := net.IP(make([]byte, len(ip)))
 	copy(nextIP, ip)
@@ -210,3 +389,99 @@ func nextIP(ip net.IP) net.IP {
 func firstIP(network *net.IPNet) net.IP {
 	return nextIP(network.IP)
 }
+
+// certOptions parses the PKI parameters of a cluster
+func certOptions(params api.PKIParams) (util.CertOptions, error) {
+	opts := util.CertOptions{
+		KeySize: params.KeySize,
+	}
+	var err error
+	if params.CAValidity != "" {
+		if opts.CAValidity, err = time.ParseDuration(params.CAValidity); err != nil {
+			return opts, errors.Wrapf(err, "invalid CA validity %q", params.CAValidity)
+		}
+	}
+	if params.CertValidity != "" {
+		if opts.Validity, err = time.ParseDuration(params.CertValidity); err != nil {
+			return opts, errors.Wrapf(err, "invalid certificate validity %q", params.CertValidity)
+		}
+	}
+	if opts.CAValidity < 0 || opts.Validity < 0 {
+		return opts, errors.Errorf("certificate validity must be positive")
+	}
+	if opts.GetValidity() > opts.GetCAValidity() {
+		return opts, errors.Errorf("certificate validity %v exceeds CA validity %v", opts.GetValidity(), opts.GetCAValidity())
+	}
+	if opts.KeySize != 0 && opts.KeySize < 2048 {
+		return opts, errors.Errorf("key size %d is too small, it must be at least 2048", opts.KeySize)
+	}
+	if opts.KeyType, err = parseKeyType(params.KeyType); err != nil {
+		return opts, err
+	}
+	if params.SignatureAlgorithm != "" {
+		algorithm, supported := signatureAlgorithms[params.SignatureAlgorithm]
+		if !supported {
+			return opts, errors.Errorf("unsupported signature algorithm %q", params.SignatureAlgorithm)
+		}
+		opts.SignatureAlgorithm = algorithm
+	}
+	return opts, nil
+}
+
+// keyTypes parses the key types of individual CAs, certificates and kubeconfigs
+func keyTypes(params api.PKIParams) (map[string]util.KeyType, error) {
+	result := make(map[string]util.KeyType, len(params.CertKeyTypes))
+	for name, value := range params.CertKeyTypes {
+		keyType, err := parseKeyType(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key type for %s", name)
+		}
+		result[name] = keyType
+	}
+	return result, nil
+}
+
+// validateSignatureAlgorithm verifies that a signature algorithm can be used by a CA key.
+// Because CAs sign certificates with the signature algorithm of the cluster, the key type
+// of every CA must match the signature algorithm if one is set.
+func validateSignatureAlgorithm(algorithm x509.SignatureAlgorithm, caName string, keyType util.KeyType) error {
+	if algorithm == x509.UnknownSignatureAlgorithm {
+		return nil
+	}
+	isECDSA := keyType == util.KeyTypeECDSAP256 || keyType == util.KeyTypeECDSAP384
+	if isECDSA != ecdsaSignatureAlgorithms[algorithm] {
+		return errors.Errorf("signature algorithm %s cannot be used with the %s key of CA %s", algorithm, keyTypeOrDefault(keyType), caName)
+	}
+	return nil
+}
+
+func parseKeyType(value string) (util.KeyType, error) {
+	keyType := util.KeyType(value)
+	switch keyType {
+	case "", util.KeyTypeRSA, util.KeyTypeECDSAP256, util.KeyTypeECDSAP384:
+		return keyType, nil
+	}
+	return "", errors.Errorf("unsupported key type %q", value)
+}
+
+func keyTypeOrDefault(keyType util.KeyType) util.KeyType {
+	if keyType == "" {
+		return util.KeyTypeRSA
+	}
+	return keyType
+}
+
+var signatureAlgorithms = map[string]x509.SignatureAlgorithm{
+	x509.SHA256WithRSA.String():   x509.SHA256WithRSA,
+	x509.SHA384WithRSA.String():   x509.SHA384WithRSA,
+	x509.SHA512WithRSA.String():   x509.SHA512WithRSA,
+	x509.ECDSAWithSHA256.String(): x509.ECDSAWithSHA256,
+	x509.ECDSAWithSHA384.String(): x509.ECDSAWithSHA384,
+	x509.ECDSAWithSHA512.String(): x509.ECDSAWithSHA512,
+}
+
+var ecdsaSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
+	x509.ECDSAWithSHA256: true,
+	x509.ECDSAWithSHA384: true,
+	x509.ECDSAWithSHA512: true,
+}
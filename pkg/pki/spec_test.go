@@ -0,0 +1,402 @@
+package pki
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+	"github.com/openshift/hypershift-toolkit/pkg/pki/util"
+)
+
+func TestCertOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      api.PKIParams
+		expected    util.CertOptions
+		expectError bool
+	}{
+		{
+			name:     "defaults",
+			params:   api.PKIParams{},
+			expected: util.CertOptions{},
+		},
+		{
+			name: "all parameters",
+			params: api.PKIParams{
+				CAValidity:         "87600h",
+				CertValidity:       "720h",
+				KeySize:            4096,
+				SignatureAlgorithm: "SHA384-RSA",
+			},
+			expected: util.CertOptions{
+				CAValidity:         87600 * time.Hour,
+				Validity:           720 * time.Hour,
+				KeySize:            4096,
+				SignatureAlgorithm: x509.SHA384WithRSA,
+			},
+		},
+		{
+			name:     "SHA256 signature algorithm",
+			params:   api.PKIParams{SignatureAlgorithm: "SHA256-RSA"},
+			expected: util.CertOptions{SignatureAlgorithm: x509.SHA256WithRSA},
+		},
+		{
+			name:     "SHA512 signature algorithm",
+			params:   api.PKIParams{SignatureAlgorithm: "SHA512-RSA"},
+			expected: util.CertOptions{SignatureAlgorithm: x509.SHA512WithRSA},
+		},
+		{
+			name:     "certificate validity within default CA validity",
+			params:   api.PKIParams{CertValidity: "43800h"},
+			expected: util.CertOptions{Validity: 43800 * time.Hour},
+		},
+		{
+			name:        "invalid CA validity",
+			params:      api.PKIParams{CAValidity: "ten years"},
+			expectError: true,
+		},
+		{
+			name:        "invalid certificate validity",
+			params:      api.PKIParams{CertValidity: "1y"},
+			expectError: true,
+		},
+		{
+			name:        "negative certificate validity",
+			params:      api.PKIParams{CertValidity: "-720h"},
+			expectError: true,
+		},
+		{
+			name:        "certificate validity exceeds CA validity",
+			params:      api.PKIParams{CAValidity: "720h", CertValidity: "1440h"},
+			expectError: true,
+		},
+		{
+			name:        "CA validity below default certificate validity",
+			params:      api.PKIParams{CAValidity: "720h"},
+			expectError: true,
+		},
+		{
+			name:        "certificate validity exceeds default CA validity",
+			params:      api.PKIParams{CertValidity: "175200h"},
+			expectError: true,
+		},
+		{
+			name:        "key size too small",
+			params:      api.PKIParams{KeySize: 1024},
+			expectError: true,
+		},
+		{
+			name:        "unsupported signature algorithm",
+			params:      api.PKIParams{SignatureAlgorithm: "SHA1-RSA"},
+			expectError: true,
+		},
+		{
+			name:     "ECDSA keys",
+			params:   api.PKIParams{KeyType: "ECDSA-P384", SignatureAlgorithm: "ECDSA-SHA384"},
+			expected: util.CertOptions{KeyType: util.KeyTypeECDSAP384, SignatureAlgorithm: x509.ECDSAWithSHA384},
+		},
+		{
+			name:        "unsupported key type",
+			params:      api.PKIParams{KeyType: "ECDSA-P521"},
+			expectError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts, err := certOptions(test.params)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", opts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if opts != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, opts)
+			}
+		})
+	}
+}
+
+func TestKeyTypes(t *testing.T) {
+	cas := []caSpec{ca("root-ca", "root-ca", "openshift"), ca("openvpn-ca", "openvpn-ca", "openshift")}
+	kubeconfigs := []kubeconfigSpec{kubeconfig("admin", "https://api:6443", "root-ca", "system:admin", "system:masters")}
+	certs := []certSpec{cert("openvpn-server", "openvpn-ca", "server", "kubernetes", nil, nil)}
+	overrides, err := keyTypes(api.PKIParams{CertKeyTypes: map[string]string{
+		"root-ca":        "ECDSA-P384",
+		"admin":          "ECDSA-P256",
+		"openvpn-server": "RSA",
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := applyKeyTypes(overrides, cas, kubeconfigs, certs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cas[0].keyType != util.KeyTypeECDSAP384 || cas[1].keyType != "" || kubeconfigs[0].keyType != util.KeyTypeECDSAP256 || certs[0].keyType != util.KeyTypeRSA {
+		t.Errorf("unexpected key types: %v %v %v", cas, kubeconfigs, certs)
+	}
+	if _, err := keyTypes(api.PKIParams{CertKeyTypes: map[string]string{"root-ca": "DSA"}}); err == nil {
+		t.Errorf("expected an error for an unsupported key type")
+	}
+	if err := applyKeyTypes(map[string]util.KeyType{"unknown": util.KeyTypeRSA}, cas, kubeconfigs, certs); err == nil {
+		t.Errorf("expected an error for an unknown name")
+	}
+}
+
+func TestValidateSignatureAlgorithm(t *testing.T) {
+	tests := []struct {
+		name        string
+		algorithm   x509.SignatureAlgorithm
+		keyType     util.KeyType
+		expectError bool
+	}{
+		{name: "default algorithm", algorithm: x509.UnknownSignatureAlgorithm, keyType: util.KeyTypeECDSAP256},
+		{name: "RSA with default key", algorithm: x509.SHA512WithRSA},
+		{name: "ECDSA with ECDSA key", algorithm: x509.ECDSAWithSHA256, keyType: util.KeyTypeECDSAP384},
+		{name: "ECDSA with RSA key", algorithm: x509.ECDSAWithSHA256, keyType: util.KeyTypeRSA, expectError: true},
+		{name: "RSA with ECDSA key", algorithm: x509.SHA256WithRSA, keyType: util.KeyTypeECDSAP256, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateSignatureAlgorithm(test.algorithm, "root-ca", test.keyType)
+			if test.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", test.expectError, err)
+			}
+		})
+	}
+}
+
+func writeTestCA(t *testing.T, dir, name string, ca *util.CA) {
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".crt"), util.CertToPem(ca.Cert), 0644); err != nil {
+		t.Fatalf("cannot write certificate: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".key"), util.PrivateKeyToPem(ca.Key), 0644); err != nil {
+		t.Fatalf("cannot write key: %v", err)
+	}
+}
+
+func TestLoadCAs(t *testing.T) {
+	intermediate, err := util.GenerateCA("intermediate", "corporate", util.CertOptions{KeySize: 1024})
+	if err != nil {
+		t.Fatalf("cannot generate CA: %v", err)
+	}
+	other, err := util.GenerateCA("other", "corporate", util.CertOptions{KeyType: util.KeyTypeECDSAP256})
+	if err != nil {
+		t.Fatalf("cannot generate CA: %v", err)
+	}
+	leaf, err := util.GenerateCert("leaf", "corporate", nil, nil, intermediate, util.CertOptions{KeySize: 1024})
+	if err != nil {
+		t.Fatalf("cannot generate certificate: %v", err)
+	}
+	cas := []caSpec{ca("root-ca", "root-ca", "openshift"), ca("cluster-signer", "cluster-signer", "openshift")}
+	tests := []struct {
+		name        string
+		setup       func(dir string)
+		expected    []string
+		expectError bool
+	}{
+		{
+			name:  "no CAs",
+			setup: func(string) {},
+		},
+		{
+			name:     "root CA",
+			setup:    func(dir string) { writeTestCA(t, dir, "root-ca", intermediate) },
+			expected: []string{"root-ca"},
+		},
+		{
+			name: "all CAs",
+			setup: func(dir string) {
+				writeTestCA(t, dir, "root-ca", intermediate)
+				writeTestCA(t, dir, "cluster-signer", other)
+			},
+			expected: []string{"root-ca", "cluster-signer"},
+		},
+		{
+			name: "missing key",
+			setup: func(dir string) {
+				writeTestCA(t, dir, "root-ca", intermediate)
+				os.Remove(filepath.Join(dir, "root-ca.key"))
+			},
+			expectError: true,
+		},
+		{
+			name: "mismatched key",
+			setup: func(dir string) {
+				writeTestCA(t, dir, "root-ca", intermediate)
+				ioutil.WriteFile(filepath.Join(dir, "root-ca.key"), util.PrivateKeyToPem(other.Key), 0644)
+			},
+			expectError: true,
+		},
+		{
+			name: "not a CA",
+			setup: func(dir string) {
+				writeTestCA(t, dir, "root-ca", &util.CA{Key: leaf.Key, Cert: leaf.Cert})
+			},
+			expectError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "pki")
+			if err != nil {
+				t.Fatalf("cannot create directory: %v", err)
+			}
+			defer os.RemoveAll(dir)
+			test.setup(dir)
+			loaded, err := loadCAs(cas, dir)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(loaded) != len(test.expected) {
+				t.Fatalf("expected CAs %v, got %v", test.expected, loaded)
+			}
+			for _, name := range test.expected {
+				if loaded[name] == nil {
+					t.Errorf("expected CA %s to be loaded", name)
+				}
+			}
+			result, err := generateCAs(cas, loaded, util.CertOptions{KeySize: 1024})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for name, ca := range loaded {
+				if result[name] != ca {
+					t.Errorf("expected loaded CA %s to be used", name)
+				}
+			}
+			if len(result) != len(cas) {
+				t.Errorf("expected %d CAs, got %d", len(cas), len(result))
+			}
+		})
+	}
+}
+
+func TestValidateLoadedCAs(t *testing.T) {
+	shortLived, err := util.GenerateCA("intermediate", "corporate", util.CertOptions{KeySize: 1024, CAValidity: 30 * util.ValidityOneDay})
+	if err != nil {
+		t.Fatalf("cannot generate CA: %v", err)
+	}
+	ecdsa, err := util.GenerateCA("intermediate", "corporate", util.CertOptions{KeyType: util.KeyTypeECDSAP256})
+	if err != nil {
+		t.Fatalf("cannot generate CA: %v", err)
+	}
+	tests := []struct {
+		name        string
+		ca          *util.CA
+		opts        util.CertOptions
+		expectError bool
+	}{
+		{name: "valid for longer than certificates", ca: shortLived, opts: util.CertOptions{Validity: 10 * util.ValidityOneDay}},
+		{name: "expires before certificates", ca: shortLived, opts: util.CertOptions{}, expectError: true},
+		{name: "ECDSA key with default algorithm", ca: ecdsa},
+		{name: "ECDSA key with RSA algorithm", ca: ecdsa, opts: util.CertOptions{SignatureAlgorithm: x509.SHA256WithRSA}, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateLoadedCAs(map[string]*util.CA{"root-ca": test.ca}, test.opts)
+			if test.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", test.expectError, err)
+			}
+		})
+	}
+}
+
+func TestValidateFIPS(t *testing.T) {
+	small, err := util.GenerateCA("intermediate", "corporate", util.CertOptions{KeySize: 1024})
+	if err != nil {
+		t.Fatalf("cannot generate CA: %v", err)
+	}
+	ecdsa, err := util.GenerateCA("intermediate", "corporate", util.CertOptions{KeyType: util.KeyTypeECDSAP384})
+	if err != nil {
+		t.Fatalf("cannot generate CA: %v", err)
+	}
+	tests := []struct {
+		name        string
+		opts        util.CertOptions
+		loaded      map[string]*util.CA
+		expectError bool
+	}{
+		{name: "defaults"},
+		{name: "approved key size", opts: util.CertOptions{KeySize: 3072}},
+		{name: "unapproved key size", opts: util.CertOptions{KeySize: 2560}, expectError: true},
+		{name: "ECDSA CA", loaded: map[string]*util.CA{"root-ca": ecdsa}},
+		{name: "small RSA CA", loaded: map[string]*util.CA{"root-ca": small}, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateFIPS(test.opts, test.loaded)
+			if test.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", test.expectError, err)
+			}
+		})
+	}
+}
+
+func TestWriteEtcdCA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "etcd-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	root, err := util.GenerateCA("root-ca", "openshift", util.CertOptions{KeySize: 1024})
+	if err != nil {
+		t.Fatalf("cannot generate CA: %v", err)
+	}
+	external, err := util.GenerateCA("etcd", "corporate", util.CertOptions{KeySize: 1024})
+	if err != nil {
+		t.Fatalf("cannot generate CA: %v", err)
+	}
+	externalFile := filepath.Join(dir, "external-ca.pem")
+	if err := ioutil.WriteFile(externalFile, util.CertToPem(external.Cert), 0644); err != nil {
+		t.Fatal(err)
+	}
+	invalidFile := filepath.Join(dir, "invalid.pem")
+	if err := ioutil.WriteFile(invalidFile, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	caMap := map[string]*util.CA{"root-ca": root}
+
+	tests := []struct {
+		name        string
+		caFile      string
+		expected    []byte
+		expectError bool
+	}{
+		{name: "root CA", expected: util.CertToPem(root.Cert)},
+		{name: "external CA", caFile: externalFile, expected: util.CertToPem(external.Cert)},
+		{name: "missing file", caFile: filepath.Join(dir, "missing.pem"), expectError: true},
+		{name: "invalid file", caFile: invalidFile, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := writeEtcdCA(test.caFile, caMap, dir, "etcd-ca")
+			if test.expectError != (err != nil) {
+				t.Fatalf("expected error %t, got %v", test.expectError, err)
+			}
+			if test.expectError {
+				return
+			}
+			b, err := ioutil.ReadFile(filepath.Join(dir, "etcd-ca.crt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != string(test.expected) {
+				t.Errorf("unexpected etcd CA:\n%s", b)
+			}
+		})
+	}
+}
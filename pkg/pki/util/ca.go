@@ -2,30 +2,34 @@ package util
 
 import (
 	"bytes"
-	"crypto/rsa"
+	"crypto"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"io/ioutil"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
 type CA struct {
-	Key  *rsa.PrivateKey
+	Key  crypto.Signer
 	Cert *x509.Certificate
 }
 
 type CAList []*CA
 
 // GenerateCA generates a CA key pair with the given filename
-func GenerateCA(commonName, organizationalUnit string) (*CA, error) {
+func GenerateCA(commonName, organizationalUnit string, opts CertOptions) (*CA, error) {
 	cfg := &CertCfg{
-		Subject:      pkix.Name{CommonName: commonName, OrganizationalUnit: []string{organizationalUnit}},
-		KeyUsages:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
-		ExtKeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
-		Validity:     ValidityTenYears,
-		IsCA:         true,
+		Subject:            pkix.Name{CommonName: commonName, OrganizationalUnit: []string{organizationalUnit}},
+		KeyUsages:          x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		ExtKeyUsages:       []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		Validity:           opts.GetCAValidity(),
+		IsCA:               true,
+		KeyType:            opts.KeyType,
+		KeySize:            opts.KeySize,
+		SignatureAlgorithm: opts.SignatureAlgorithm,
 	}
 
 	key, crt, err := GenerateSelfSignedCertificate(cfg)
@@ -35,6 +39,50 @@ func GenerateCA(commonName, organizationalUnit string) (*CA, error) {
 	return &CA{Key: key, Cert: crt}, nil
 }
 
+// LoadCA reads an existing CA key pair from the .crt and .key files with the given
+// filename. The certificate must be a CA that is currently valid and the key must
+// match it. Only the first certificate of the .crt file is used.
+func LoadCA(fileName string) (*CA, error) {
+	certBytes, err := ioutil.ReadFile(fileName + ".crt")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read certificate of CA %s", fileName)
+	}
+	crt, err := PemToCertificate(certBytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse certificate of CA %s", fileName)
+	}
+	keyBytes, err := ioutil.ReadFile(fileName + ".key")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read key of CA %s", fileName)
+	}
+	key, err := PemToPrivateKey(keyBytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse key of CA %s", fileName)
+	}
+	if !crt.IsCA {
+		return nil, errors.Errorf("certificate of CA %s is not a CA certificate", fileName)
+	}
+	if crt.KeyUsage != 0 && crt.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return nil, errors.Errorf("certificate of CA %s cannot sign certificates", fileName)
+	}
+	now := time.Now()
+	if now.Before(crt.NotBefore) || now.After(crt.NotAfter) {
+		return nil, errors.Errorf("certificate of CA %s is only valid from %v to %v", fileName, crt.NotBefore, crt.NotAfter)
+	}
+	certPub, err := x509.MarshalPKIXPublicKey(crt.PublicKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unsupported public key in certificate of CA %s", fileName)
+	}
+	keyPub, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, errors.Wrapf(err, "unsupported key of CA %s", fileName)
+	}
+	if !bytes.Equal(certPub, keyPub) {
+		return nil, errors.Errorf("key of CA %s does not match its certificate", fileName)
+	}
+	return &CA{Key: key, Cert: crt}, nil
+}
+
 func (c *CA) WriteTo(fileName string) error {
 	if CertAndKeyExists(fileName) {
 		log.Infof("Skipping CA file %s because it already exists", fileName)
@@ -2,7 +2,7 @@ package util
 
 import (
 	"bytes"
-	"crypto/rsa"
+	"crypto"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"io/ioutil"
@@ -12,18 +12,21 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func GenerateCert(commonName, organization string, hostNames, addresses []string, ca *CA) (*Cert, error) {
+func GenerateCert(commonName, organization string, hostNames, addresses []string, ca *CA, opts CertOptions) (*Cert, error) {
 	ipAddr := []net.IP{}
 	for _, ip := range addresses {
 		ipAddr = append(ipAddr, net.ParseIP(ip))
 	}
 	cfg := &CertCfg{
-		Subject:      pkix.Name{CommonName: commonName, Organization: []string{organization}},
-		KeyUsages:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-		ExtKeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
-		Validity:     ValidityOneYear,
-		DNSNames:     hostNames,
-		IPAddresses:  ipAddr,
+		Subject:            pkix.Name{CommonName: commonName, Organization: []string{organization}},
+		KeyUsages:          x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsages:       []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		Validity:           opts.GetValidity(),
+		DNSNames:           hostNames,
+		IPAddresses:        ipAddr,
+		KeyType:            opts.KeyType,
+		KeySize:            opts.KeySize,
+		SignatureAlgorithm: opts.SignatureAlgorithm,
 	}
 	key, crt, err := GenerateSignedCertificate(ca.Key, ca.Cert, cfg)
 	if err != nil {
@@ -38,7 +41,7 @@ func GenerateCert(commonName, organization string, hostNames, addresses []string
 
 type Cert struct {
 	Parent *CA
-	Key    *rsa.PrivateKey
+	Key    crypto.Signer
 	Cert   *x509.Certificate
 }
 
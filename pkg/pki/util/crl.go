@@ -0,0 +1,68 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CanSignCRL returns whether the certificate of a CA allows it to sign certificate
+// revocation lists. OpenSSL rejects the CRLs of CAs whose key usage does not include it.
+func (c *CA) CanSignCRL() bool {
+	return c.Cert.KeyUsage == 0 || c.Cert.KeyUsage&x509.KeyUsageCRLSign != 0
+}
+
+// GenerateCRL returns a PEM encoded certificate revocation list of a CA that revokes the
+// certificates in revoked. The CRL is valid until the CA expires, because OpenVPN rejects
+// every client once the CRL it verifies clients with has expired.
+func GenerateCRL(ca *CA, revoked []pkix.RevokedCertificate) ([]byte, error) {
+	if !ca.CanSignCRL() {
+		return nil, errors.Errorf("CA %s cannot sign certificate revocation lists", ca.Cert.Subject.CommonName)
+	}
+	crl, err := ca.Cert.CreateCRL(rand.Reader, ca.Key, revoked, time.Now(), ca.Cert.NotAfter)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to generate CRL of CA %s", ca.Cert.Subject.CommonName)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl}), nil
+}
+
+// PemToCRL parses a PEM encoded certificate revocation list and verifies that it is signed
+// by a CA
+func PemToCRL(data []byte, ca *CA) (*pkix.CertificateList, error) {
+	crl, err := x509.ParseCRL(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse CRL")
+	}
+	if err = ca.Cert.CheckCRLSignature(crl); err != nil {
+		return nil, errors.Wrapf(err, "CRL is not signed by CA %s", ca.Cert.Subject.CommonName)
+	}
+	return crl, nil
+}
+
+// RevokeCertificate adds a certificate to a certificate revocation list, unless it is
+// already revoked, and returns whether it was added
+func RevokeCertificate(crl *pkix.CertificateList, cert *x509.Certificate) bool {
+	if IsRevoked(crl, cert.SerialNumber) {
+		return false
+	}
+	crl.TBSCertList.RevokedCertificates = append(crl.TBSCertList.RevokedCertificates, pkix.RevokedCertificate{
+		SerialNumber:   cert.SerialNumber,
+		RevocationTime: time.Now(),
+	})
+	return true
+}
+
+// IsRevoked returns whether a certificate revocation list revokes a serial number
+func IsRevoked(crl *pkix.CertificateList, serial *big.Int) bool {
+	for _, revoked := range crl.TBSCertList.RevokedCertificates {
+		if revoked.SerialNumber.Cmp(serial) == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -8,8 +8,8 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func GenerateKubeconfig(serverAddress, commonName, organization string, rootCA, signingCA *CA) (*Kubeconfig, error) {
-	cert, err := GenerateCert(commonName, organization, nil, nil, signingCA)
+func GenerateKubeconfig(serverAddress, commonName, organization string, rootCA, signingCA *CA, opts CertOptions) (*Kubeconfig, error) {
+	cert, err := GenerateCert(commonName, organization, nil, nil, signingCA, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -20,6 +20,18 @@ import (
 	"github.com/pkg/errors"
 )
 
+// KeyType is the type of the private key of a certificate
+type KeyType string
+
+const (
+	// KeyTypeRSA selects RSA keys of the configured size
+	KeyTypeRSA KeyType = "RSA"
+	// KeyTypeECDSAP256 selects ECDSA keys on the P-256 curve
+	KeyTypeECDSAP256 KeyType = "ECDSA-P256"
+	// KeyTypeECDSAP384 selects ECDSA keys on the P-384 curve
+	KeyTypeECDSAP384 KeyType = "ECDSA-P384"
+)
+
 const (
 	keySize = 2048
 
@@ -30,13 +42,51 @@ const (
 
 // CertCfg contains all needed fields to configure a new certificate
 type CertCfg struct {
-	DNSNames     []string
-	ExtKeyUsages []x509.ExtKeyUsage
-	IPAddresses  []net.IP
-	KeyUsages    x509.KeyUsage
-	Subject      pkix.Name
-	Validity     time.Duration
-	IsCA         bool
+	DNSNames           []string
+	ExtKeyUsages       []x509.ExtKeyUsage
+	IPAddresses        []net.IP
+	KeyUsages          x509.KeyUsage
+	Subject            pkix.Name
+	Validity           time.Duration
+	IsCA               bool
+	KeyType            KeyType
+	KeySize            int
+	SignatureAlgorithm x509.SignatureAlgorithm
+}
+
+// CertOptions customizes the keys and certificates generated for a cluster.
+// Zero values select the defaults.
+type CertOptions struct {
+	// CAValidity is the validity of CA certificates
+	CAValidity time.Duration
+
+	// Validity is the validity of certificates signed by a CA
+	Validity time.Duration
+
+	// KeyType is the type of generated keys, RSA by default
+	KeyType KeyType
+
+	// KeySize is the size in bits of RSA keys
+	KeySize int
+
+	// SignatureAlgorithm is the algorithm used to sign certificates
+	SignatureAlgorithm x509.SignatureAlgorithm
+}
+
+// GetCAValidity returns the validity of CA certificates, ten years by default
+func (o CertOptions) GetCAValidity() time.Duration {
+	if o.CAValidity == 0 {
+		return ValidityTenYears
+	}
+	return o.CAValidity
+}
+
+// GetValidity returns the validity of signed certificates, one year by default
+func (o CertOptions) GetValidity() time.Duration {
+	if o.Validity == 0 {
+		return ValidityOneYear
+	}
+	return o.Validity
 }
 
 // rsaPublicKey reflects the ASN.1 structure of a PKCS#1 public key.
@@ -46,8 +96,8 @@ type rsaPublicKey struct {
 }
 
 // GenerateSelfSignedCertificate generates a key/cert pair defined by CertCfg.
-func GenerateSelfSignedCertificate(cfg *CertCfg) (*rsa.PrivateKey, *x509.Certificate, error) {
-	key, err := PrivateKey()
+func GenerateSelfSignedCertificate(cfg *CertCfg) (crypto.Signer, *x509.Certificate, error) {
+	key, err := GeneratePrivateKey(cfg.KeyType, cfg.KeySize)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to generate private key")
 	}
@@ -60,11 +110,11 @@ func GenerateSelfSignedCertificate(cfg *CertCfg) (*rsa.PrivateKey, *x509.Certifi
 }
 
 // GenerateSignedCertificate generate a key and cert defined by CertCfg and signed by CA.
-func GenerateSignedCertificate(caKey *rsa.PrivateKey, caCert *x509.Certificate,
-	cfg *CertCfg) (*rsa.PrivateKey, *x509.Certificate, error) {
+func GenerateSignedCertificate(caKey crypto.Signer, caCert *x509.Certificate,
+	cfg *CertCfg) (crypto.Signer, *x509.Certificate, error) {
 
 	// create a private key
-	key, err := PrivateKey()
+	key, err := GeneratePrivateKey(cfg.KeyType, cfg.KeySize)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to generate private key")
 	}
@@ -88,9 +138,58 @@ func GenerateSignedCertificate(caKey *rsa.PrivateKey, caCert *x509.Certificate,
 	return key, cert, nil
 }
 
+// GeneratePrivateKey generates a private key of the given type. The size only
+// applies to RSA keys, which are generated when the type is empty.
+func GeneratePrivateKey(keyType KeyType, size int) (crypto.Signer, error) {
+	switch keyType {
+	case "", KeyTypeRSA:
+		return PrivateKeyWithSize(size)
+	case KeyTypeECDSAP256:
+		return ecdsaPrivateKey(elliptic.P256())
+	case KeyTypeECDSAP384:
+		return ecdsaPrivateKey(elliptic.P384())
+	default:
+		return nil, errors.Errorf("unsupported key type %q", keyType)
+	}
+}
+
+func ecdsaPrivateKey(curve elliptic.Curve) (*ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating ECDSA private key")
+	}
+	return key, nil
+}
+
+// KeyTypeOf returns the key type and size of a public key
+func KeyTypeOf(pub crypto.PublicKey) (KeyType, int, error) {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		return KeyTypeRSA, pub.N.BitLen(), nil
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			return KeyTypeECDSAP256, 0, nil
+		case elliptic.P384():
+			return KeyTypeECDSAP384, 0, nil
+		}
+		return "", 0, errors.Errorf("unsupported ECDSA curve %s", pub.Curve.Params().Name)
+	}
+	return "", 0, errors.New("only RSA and ECDSA public keys supported")
+}
+
 // PrivateKey generates an RSA Private key and returns the value
 func PrivateKey() (*rsa.PrivateKey, error) {
-	rsaKey, err := rsa.GenerateKey(rand.Reader, keySize)
+	return PrivateKeyWithSize(keySize)
+}
+
+// PrivateKeyWithSize generates an RSA Private key of the given size in bits,
+// or of the default size if size is 0
+func PrivateKeyWithSize(size int) (*rsa.PrivateKey, error) {
+	if size == 0 {
+		size = keySize
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, size)
 	if err != nil {
 		return nil, errors.Wrap(err, "error generating RSA private key")
 	}
@@ -99,7 +198,7 @@ func PrivateKey() (*rsa.PrivateKey, error) {
 }
 
 // SelfSignedCertificate creates a self signed certificate
-func SelfSignedCertificate(cfg *CertCfg, key *rsa.PrivateKey) (*x509.Certificate, error) {
+func SelfSignedCertificate(cfg *CertCfg, key crypto.Signer) (*x509.Certificate, error) {
 	serial, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
 	if err != nil {
 		return nil, err
@@ -112,6 +211,7 @@ func SelfSignedCertificate(cfg *CertCfg, key *rsa.PrivateKey) (*x509.Certificate
 		NotBefore:             time.Now(),
 		SerialNumber:          serial,
 		Subject:               cfg.Subject,
+		SignatureAlgorithm:    cfg.SignatureAlgorithm,
 	}
 	// verifies that the CN and/or OU for the cert is set
 	if len(cfg.Subject.CommonName) == 0 || len(cfg.Subject.OrganizationalUnit) == 0 {
@@ -133,9 +233,9 @@ func SelfSignedCertificate(cfg *CertCfg, key *rsa.PrivateKey) (*x509.Certificate
 func SignedCertificate(
 	cfg *CertCfg,
 	csr *x509.CertificateRequest,
-	key *rsa.PrivateKey,
+	key crypto.Signer,
 	caCert *x509.Certificate,
-	caKey *rsa.PrivateKey,
+	caKey crypto.Signer,
 ) (*x509.Certificate, error) {
 	serial, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
 	if err != nil {
@@ -154,9 +254,9 @@ func SignedCertificate(
 		IsCA:                  cfg.IsCA,
 		Version:               3,
 		BasicConstraintsValid: true,
+		SignatureAlgorithm:    cfg.SignatureAlgorithm,
 	}
-	pub := caCert.PublicKey.(*rsa.PublicKey)
-	certTmpl.SubjectKeyId, err = generateSubjectKeyID(pub)
+	certTmpl.SubjectKeyId, err = generateSubjectKeyID(key.Public())
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to set subject key identifier")
 	}
@@ -188,16 +288,19 @@ func generateSubjectKeyID(pub crypto.PublicKey) ([]byte, error) {
 	return hash[:], nil
 }
 
-// PrivateKeyToPem converts an rsa.PrivateKey object to pem string
-func PrivateKeyToPem(key *rsa.PrivateKey) []byte {
-	keyInBytes := x509.MarshalPKCS1PrivateKey(key)
-	keyinPem := pem.EncodeToMemory(
-		&pem.Block{
-			Type:  "RSA PRIVATE KEY",
-			Bytes: keyInBytes,
-		},
-	)
-	return keyinPem
+// PrivateKeyToPem converts an RSA or ECDSA private key to pem string
+func PrivateKeyToPem(key crypto.Signer) []byte {
+	block := &pem.Block{}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		block.Type = "RSA PRIVATE KEY"
+		block.Bytes = x509.MarshalPKCS1PrivateKey(key)
+	case *ecdsa.PrivateKey:
+		// Marshaling only fails for unknown curves, which are never generated
+		block.Type = "EC PRIVATE KEY"
+		block.Bytes, _ = x509.MarshalECPrivateKey(key)
+	}
+	return pem.EncodeToMemory(block)
 }
 
 // CertToPem converts an x509.Certificate object to a pem string
@@ -237,13 +340,27 @@ func PublicKeyToPem(key *rsa.PublicKey) ([]byte, error) {
 	return keyinPem, nil
 }
 
-// PemToPrivateKey converts a data block to rsa.PrivateKey.
-func PemToPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+// PemToPrivateKey converts a data block to an RSA or ECDSA private key.
+func PemToPrivateKey(data []byte) (crypto.Signer, error) {
 	block, _ := pem.Decode(data)
 	if block == nil {
 		return nil, errors.Errorf("could not find a PEM block in the private key")
 	}
-	return x509.ParsePKCS1PrivateKey(block.Bytes)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
 }
 
 // PemToCertificate converts a data block to x509.Certificate.
@@ -0,0 +1,66 @@
+package release
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	imagereference "github.com/openshift/library-go/pkg/image/reference"
+	imagemanifest "github.com/openshift/oc/pkg/cli/image/manifest"
+)
+
+// DefaultArch is the architecture of clusters that do not specify one
+const DefaultArch = "amd64"
+
+// Archs are the architectures of OpenShift release images
+var Archs = []string{"amd64", "arm64", "ppc64le", "s390x"}
+
+// ValidateArch verifies that an architecture is one of the architectures of releases
+func ValidateArch(arch string) error {
+	if len(arch) == 0 {
+		return nil
+	}
+	for _, a := range Archs {
+		if a == arch {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported architecture %q, must be one of: %v", arch, Archs)
+}
+
+// resolveArchImage returns the reference by digest of the release image of an architecture,
+// if the release image is a manifest list. Release images of a single architecture are
+// returned as they are. The images that a multi-arch release references are manifest lists
+// themselves, which the nodes of each architecture pull their own images from.
+func resolveArchImage(image, arch, pullSecretFile string) (string, error) {
+	ref, err := imagereference.Parse(image)
+	if err != nil {
+		return "", err
+	}
+	filter := imagemanifest.FilterOptions{FilterByOS: fmt.Sprintf("^linux/%s$", arch)}
+	if err = filter.Validate(); err != nil {
+		return "", err
+	}
+	security := imagemanifest.SecurityOptions{RegistryConfig: pullSecretFile}
+	registryContext, err := security.Context()
+	if err != nil {
+		return "", err
+	}
+	ctx := context.Background()
+	from := ref.DockerClientDefaults()
+	repo, err := registryContext.Repository(ctx, from.RegistryURL(), from.RepositoryName(), false)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot connect to the repository of %s", image)
+	}
+	_, location, err := imagemanifest.FirstManifest(ctx, from, repo, filter.Include)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot find the %s image of release %s", arch, image)
+	}
+	if !location.IsList() {
+		return image, nil
+	}
+	ref.Tag = ""
+	ref.ID = location.Manifest.String()
+	return ref.Exact(), nil
+}
@@ -0,0 +1,102 @@
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// bootImagesManifest is the manifest of the boot images of a release, a ConfigMap whose
+// stream key holds the CoreOS stream metadata of the release. Releases before 4.8 do not
+// have it.
+const bootImagesManifest = "0000_50_installer_coreos-bootimages.yaml"
+
+// streamArchs are the architectures of CoreOS streams by the architectures of images
+var streamArchs = map[string]string{
+	"amd64":   "x86_64",
+	"arm64":   "aarch64",
+	"ppc64le": "ppc64le",
+	"s390x":   "s390x",
+}
+
+// coreOSStream is the part of CoreOS stream metadata that lists the AMIs of the boot images.
+// The rhcos.json metadata of the installer of releases before 4.8 only lists the AMIs of
+// x86_64 boot images, in amis.
+type coreOSStream struct {
+	Architectures map[string]struct {
+		Images struct {
+			AWS *struct {
+				Regions map[string]struct {
+					Image string `json:"image"`
+				} `json:"regions"`
+			} `json:"aws"`
+		} `json:"images"`
+	} `json:"architectures"`
+	AMIs map[string]struct {
+		HVM string `json:"hvm"`
+	} `json:"amis"`
+}
+
+// bootImages returns the CoreOS stream metadata in the manifests of a release, or nothing if
+// the release has none
+func bootImages(manifests map[string][]byte) (string, error) {
+	data, ok := manifests[bootImagesManifest]
+	if !ok {
+		return "", nil
+	}
+	cm := struct {
+		Data map[string]string `json:"data"`
+	}{}
+	if err := yaml.Unmarshal(data, &cm); err != nil {
+		return "", errors.Wrapf(err, "cannot decode %s", bootImagesManifest)
+	}
+	return cm.Data["stream"], nil
+}
+
+// GetCoreOSStream fetches CoreOS stream metadata, or the rhcos.json of the installer, from a URL
+func GetCoreOSStream(url string) (string, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("cannot fetch %s: %s", url, resp.Status)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// AWSBootImage returns the AMI of the boot image of an architecture in a region, from CoreOS
+// stream metadata
+func AWSBootImage(stream, arch, region string) (string, error) {
+	if len(arch) == 0 {
+		arch = DefaultArch
+	}
+	metadata := &coreOSStream{}
+	if err := json.Unmarshal([]byte(stream), metadata); err != nil {
+		return "", errors.Wrap(err, "cannot decode CoreOS stream metadata")
+	}
+	if len(metadata.Architectures) == 0 && arch == DefaultArch {
+		if ami := metadata.AMIs[region].HVM; len(ami) > 0 {
+			return ami, nil
+		}
+		return "", fmt.Errorf("there is no boot image in region %s", region)
+	}
+	streamArch, ok := metadata.Architectures[streamArchs[arch]]
+	if !ok || streamArch.Images.AWS == nil {
+		return "", fmt.Errorf("there are no AWS boot images for %s", arch)
+	}
+	ami := streamArch.Images.AWS.Regions[region].Image
+	if len(ami) == 0 {
+		return "", fmt.Errorf("there is no %s boot image in region %s", arch, region)
+	}
+	return ami, nil
+}
@@ -0,0 +1,59 @@
+package release
+
+import (
+	"testing"
+)
+
+func TestAWSBootImage(t *testing.T) {
+	stream := `{
+  "stream": "rhcos-4.8",
+  "architectures": {
+    "x86_64": {"images": {"aws": {"regions": {"us-east-1": {"release": "48.84.202105190318-0", "image": "ami-0123"}}}}},
+    "aarch64": {"images": {"aws": {"regions": {"us-east-1": {"release": "48.84.202105190318-0", "image": "ami-4567"}}}}},
+    "s390x": {"images": {}}
+  }
+}`
+	legacy := `{"amis": {"us-east-1": {"hvm": "ami-89ab"}}, "buildid": "44.81.202003110027-0"}`
+	tests := []struct {
+		name        string
+		stream      string
+		arch        string
+		region      string
+		expected    string
+		expectError bool
+	}{
+		{name: "default arch", stream: stream, region: "us-east-1", expected: "ami-0123"},
+		{name: "arm64", stream: stream, arch: "arm64", region: "us-east-1", expected: "ami-4567"},
+		{name: "missing region", stream: stream, arch: "arm64", region: "eu-west-1", expectError: true},
+		{name: "no AWS images", stream: stream, arch: "s390x", region: "us-east-1", expectError: true},
+		{name: "missing arch", stream: stream, arch: "ppc64le", region: "us-east-1", expectError: true},
+		{name: "rhcos.json", stream: legacy, region: "us-east-1", expected: "ami-89ab"},
+		{name: "rhcos.json arm64", stream: legacy, arch: "arm64", region: "us-east-1", expectError: true},
+		{name: "invalid", stream: "not json", region: "us-east-1", expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ami, err := AWSBootImage(test.stream, test.arch, test.region)
+			if test.expectError != (err != nil) {
+				t.Fatalf("expected error %t, got %v", test.expectError, err)
+			}
+			if ami != test.expected {
+				t.Errorf("expected AMI %q, got %q", test.expected, ami)
+			}
+		})
+	}
+}
+
+func TestBootImages(t *testing.T) {
+	manifest := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: coreos-bootimages\ndata:\n  stream: '{\"stream\": \"rhcos-4.8\"}'\n"
+	stream, err := bootImages(map[string][]byte{bootImagesManifest: []byte(manifest)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stream != `{"stream": "rhcos-4.8"}` {
+		t.Errorf("unexpected stream: %s", stream)
+	}
+	if stream, err = bootImages(map[string][]byte{}); err != nil || len(stream) > 0 {
+		t.Errorf("expected no stream, got %q, error %v", stream, err)
+	}
+}
@@ -0,0 +1,113 @@
+package release
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+// DefaultCacheTTL is how long the info of a release image referenced by tag is cached
+const DefaultCacheTTL = 12 * time.Hour
+
+// InfoCache caches the info of release images on disk, so that rendering a cluster again
+// does not pull the metadata of its release image. The info of an image referenced by
+// digest never changes and does not expire; the tag of an image may be moved to another
+// release, so its info expires after the TTL of the cache.
+type InfoCache struct {
+	Dir string
+	TTL time.Duration
+}
+
+// Cache is the cache of GetReleaseInfo, or nil if release info is not cached. By default
+// it is in the user cache directory; commands replace it to disable it or change its TTL.
+var Cache = NewInfoCache(defaultCacheDir(), DefaultCacheTTL)
+
+// NewInfoCache returns a cache in dir, or nil if dir is empty
+func NewInfoCache(dir string, ttl time.Duration) *InfoCache {
+	if len(dir) == 0 {
+		return nil
+	}
+	return &InfoCache{Dir: dir, TTL: ttl}
+}
+
+// defaultCacheDir returns the release info directory of the user cache directory, or
+// nothing if the user has none, as in a pod without a home directory
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "hypershift", "release-info")
+}
+
+// Get returns the cached info of a release image, or nil if it is not cached or expired
+func (c *InfoCache) Get(image, originReleasePrefix string, imageContentSources []api.ImageContentSource) *ReleaseInfo {
+	fileName := c.fileName(image, originReleasePrefix, imageContentSources)
+	stat, err := os.Stat(fileName)
+	if err != nil {
+		return nil
+	}
+	if !isDigest(image) && time.Since(stat.ModTime()) > c.TTL {
+		log.Debugf("Cached info of release image %s has expired", image)
+		return nil
+	}
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		log.WithError(err).Debugf("Cannot read cached info of release image %s", image)
+		return nil
+	}
+	info := &ReleaseInfo{}
+	if err = json.Unmarshal(b, info); err != nil {
+		log.WithError(err).Debugf("Cannot decode cached info of release image %s", image)
+		return nil
+	}
+	return info
+}
+
+// Put caches the info of a release image
+func (c *InfoCache) Put(image, originReleasePrefix string, imageContentSources []api.ImageContentSource, info *ReleaseInfo) error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	// Write and rename, so that concurrent renders never read a partial entry
+	fileName := c.fileName(image, originReleasePrefix, imageContentSources)
+	tmp, err := ioutil.TempFile(c.Dir, filepath.Base(fileName))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fileName)
+}
+
+// fileName returns the file of the info of a release image. The image references of the
+// info depend on the origin release prefix and the mirrors of the images as well.
+func (c *InfoCache) fileName(image, originReleasePrefix string, imageContentSources []api.ImageContentSource) string {
+	sources, _ := json.Marshal(imageContentSources)
+	key := sha256.Sum256([]byte(fmt.Sprintf("%s\n%s\n%s", image, originReleasePrefix, sources)))
+	return filepath.Join(c.Dir, fmt.Sprintf("%x.json", key))
+}
+
+// isDigest returns whether an image is referenced by digest
+func isDigest(image string) bool {
+	return strings.Contains(image, "@sha256:")
+}
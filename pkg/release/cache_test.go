@@ -0,0 +1,58 @@
+package release
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestInfoCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache := NewInfoCache(filepath.Join(dir, "cache"), time.Hour)
+	info := &ReleaseInfo{
+		Images:   map[string]string{"cli": "quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:0123"},
+		Versions: map[string]string{"release": "4.4.0"},
+	}
+	tag := "quay.io/openshift-release-dev/ocp-release:4.4.0-x86_64"
+	digest := "quay.io/openshift-release-dev/ocp-release@sha256:4567"
+	prefix := "quay.io/openshift-release-dev"
+	sources := []api.ImageContentSource{{Source: "quay.io/openshift-release-dev/ocp-release", Mirrors: []string{"mirror.example.com:5000/ocp/release"}}}
+
+	if cache.Get(tag, prefix, nil) != nil {
+		t.Fatalf("expected empty cache")
+	}
+	for _, image := range []string{tag, digest} {
+		if err = cache.Put(image, prefix, nil, info); err != nil {
+			t.Fatal(err)
+		}
+		if cached := cache.Get(image, prefix, nil); !reflect.DeepEqual(cached, info) {
+			t.Errorf("expected cached info of %s, got %#v", image, cached)
+		}
+	}
+	if cache.Get(tag, prefix, sources) != nil {
+		t.Errorf("expected no cached info for other image content sources")
+	}
+
+	// The info of an image referenced by tag expires, that of an image referenced by digest does not
+	old := time.Now().Add(-2 * time.Hour)
+	for _, image := range []string{tag, digest} {
+		if err = os.Chtimes(cache.fileName(image, prefix, nil), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cache.Get(tag, prefix, nil) != nil {
+		t.Errorf("expected cached info of %s to expire", tag)
+	}
+	if cache.Get(digest, prefix, nil) == nil {
+		t.Errorf("expected cached info of %s not to expire", digest)
+	}
+}
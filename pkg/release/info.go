@@ -1,30 +1,74 @@
 package release
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 
-	"fmt"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	"os"
-	"strings"
 
 	"github.com/openshift/oc/pkg/cli/admin/release"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
 )
 
 // ReleaseInfo includes image references and versions for a given release
 type ReleaseInfo struct {
 	Images   map[string]string
 	Versions map[string]string
+	// BootImages is the CoreOS stream metadata of the boot images of the release, if the
+	// release has it
+	BootImages string
+}
+
+// GetReleaseInfo returns the image references and versions of a release image. The release
+// image and the images it references are pulled from the first mirror of the image content
+// source that contains them, if any. If the release image is a manifest list, the info is that
+// of the release of the architecture, amd64 by default. The info is read from Cache when it
+// has it.
+func GetReleaseInfo(image, arch string, originReleasePrefix string, pullSecretFile string, imageContentSources []api.ImageContentSource) (*ReleaseInfo, error) {
+	if len(arch) == 0 {
+		arch = DefaultArch
+	}
+	key := image + "#" + arch
+	if Cache != nil {
+		if info := Cache.Get(key, originReleasePrefix, imageContentSources); info != nil {
+			log.Debugf("Using cached info of %s release image %s", arch, image)
+			return info, nil
+		}
+	}
+	archImage, err := resolveArchImage(MirrorImage(image, imageContentSources), arch, pullSecretFile)
+	if err != nil {
+		return nil, err
+	}
+	info, err := loadReleaseInfo(archImage, image, originReleasePrefix, pullSecretFile, imageContentSources)
+	if err != nil {
+		return nil, err
+	}
+	if Cache != nil {
+		if err = Cache.Put(key, originReleasePrefix, imageContentSources, info); err != nil {
+			log.WithError(err).Debugf("Cannot cache info of release image %s", image)
+		}
+	}
+	return info, nil
 }
 
-func GetReleaseInfo(image string, originReleasePrefix string, pullSecretFile string) (*ReleaseInfo, error) {
+// loadReleaseInfo pulls the metadata of a release image from pullSpec, the (mirrored)
+// reference of the image of a single architecture
+func loadReleaseInfo(pullSpec, image string, originReleasePrefix string, pullSecretFile string, imageContentSources []api.ImageContentSource) (*ReleaseInfo, error) {
+	// Release info output goes to the debug log rather than stdout, which may carry the
+	// rendered manifests
+	out := log.StandardLogger().WriterLevel(log.DebugLevel)
+	defer out.Close()
 	streams := genericclioptions.IOStreams{
-		Out:    os.Stdout,
-		ErrOut: os.Stderr,
+		Out:    out,
+		ErrOut: out,
 	}
 	options := release.NewInfoOptions(streams)
 	options.SecurityOptions.RegistryConfig = pullSecretFile
-	info, err := options.LoadReleaseInfo(image, false)
+	info, err := options.LoadReleaseInfo(pullSpec, false)
 	if err != nil {
 		return nil, err
 	}
@@ -42,7 +86,7 @@ func GetReleaseInfo(image string, originReleasePrefix string, pullSecretFile str
 		if len(newImagePrefix) > 0 {
 			name = fmt.Sprintf("%s@%s", newImagePrefix, strings.Split(tag.From.Name, "@")[1])
 		}
-		images[tag.Name] = name
+		images[tag.Name] = MirrorImage(name, imageContentSources)
 	}
 
 	versions := make(map[string]string)
@@ -53,8 +97,64 @@ func GetReleaseInfo(image string, originReleasePrefix string, pullSecretFile str
 		versions[component] = version
 	}
 
+	bootImages, err := bootImages(info.ManifestFiles)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ReleaseInfo{
-		Images:   images,
-		Versions: versions,
+		Images:     images,
+		Versions:   versions,
+		BootImages: bootImages,
 	}, nil
 }
+
+// MirrorImage returns the image reference in the first mirror of the image content source
+// whose repository contains the image, or the image itself if there is none
+func MirrorImage(image string, imageContentSources []api.ImageContentSource) string {
+	repository, suffix := splitImage(image)
+	for _, source := range imageContentSources {
+		if len(source.Mirrors) == 0 {
+			continue
+		}
+		if repository == source.Source {
+			return source.Mirrors[0] + suffix
+		}
+		if strings.HasPrefix(repository, source.Source+"/") {
+			return source.Mirrors[0] + strings.TrimPrefix(repository, source.Source) + suffix
+		}
+	}
+	return image
+}
+
+// splitImage splits an image reference into its repository and its digest or tag,
+// including the separator
+func splitImage(image string) (string, string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		return image[:i], image[i:]
+	}
+	// A colon after the last slash separates the tag, any other is the port of the registry
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i:]
+	}
+	return image, ""
+}
+
+// ValidateImageContentSources verifies that each image content source has a repository
+// and at least one mirror
+func ValidateImageContentSources(imageContentSources []api.ImageContentSource) error {
+	for _, source := range imageContentSources {
+		if len(source.Source) == 0 {
+			return errors.New("an image content source must specify a source repository")
+		}
+		if len(source.Mirrors) == 0 {
+			return errors.Errorf("image content source %s must specify at least one mirror", source.Source)
+		}
+		for _, mirror := range source.Mirrors {
+			if len(mirror) == 0 || strings.ContainsAny(mirror, "@ ") {
+				return errors.Errorf("invalid mirror %q of image content source %s", mirror, source.Source)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package release
+
+import (
+	"testing"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestMirrorImage(t *testing.T) {
+	sources := []api.ImageContentSource{
+		{Source: "quay.io/openshift-release-dev/ocp-release", Mirrors: []string{"mirror.example.com:5000/ocp/release", "backup.example.com/ocp/release"}},
+		{Source: "quay.io/openshift-release-dev/ocp-v4.0-art-dev", Mirrors: []string{"mirror.example.com:5000/ocp/release"}},
+		{Source: "registry.svc.ci.openshift.org", Mirrors: []string{"mirror.example.com:5000/ci"}},
+	}
+	tests := []struct {
+		image    string
+		expected string
+	}{
+		{
+			image:    "quay.io/openshift-release-dev/ocp-release:4.4.0-x86_64",
+			expected: "mirror.example.com:5000/ocp/release:4.4.0-x86_64",
+		},
+		{
+			image:    "quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:0123",
+			expected: "mirror.example.com:5000/ocp/release@sha256:0123",
+		},
+		{
+			image:    "registry.svc.ci.openshift.org/ocp/4.4@sha256:4567",
+			expected: "mirror.example.com:5000/ci/ocp/4.4@sha256:4567",
+		},
+		{
+			image:    "quay.io/openshift-release-dev/ocp-release-nightly@sha256:89ab",
+			expected: "quay.io/openshift-release-dev/ocp-release-nightly@sha256:89ab",
+		},
+		{
+			image:    "localhost:5000/ocp/release",
+			expected: "localhost:5000/ocp/release",
+		},
+	}
+	for _, test := range tests {
+		if actual := MirrorImage(test.image, sources); actual != test.expected {
+			t.Errorf("expected %s to be mirrored as %s, got %s", test.image, test.expected, actual)
+		}
+	}
+	if actual := MirrorImage("quay.io/openshift-release-dev/ocp-release:4.4.0", nil); actual != "quay.io/openshift-release-dev/ocp-release:4.4.0" {
+		t.Errorf("expected the image to be kept without image content sources, got %s", actual)
+	}
+}
+
+func TestValidateImageContentSources(t *testing.T) {
+	tests := []struct {
+		name        string
+		sources     []api.ImageContentSource
+		expectError bool
+	}{
+		{name: "none"},
+		{name: "valid", sources: []api.ImageContentSource{{Source: "quay.io/openshift-release-dev/ocp-release", Mirrors: []string{"mirror.example.com/ocp/release"}}}},
+		{name: "no source", sources: []api.ImageContentSource{{Mirrors: []string{"mirror.example.com/ocp/release"}}}, expectError: true},
+		{name: "no mirrors", sources: []api.ImageContentSource{{Source: "quay.io/openshift-release-dev/ocp-release"}}, expectError: true},
+		{name: "mirror with digest", sources: []api.ImageContentSource{{Source: "quay.io/openshift-release-dev/ocp-release", Mirrors: []string{"mirror.example.com/ocp/release@sha256:0123"}}}, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ValidateImageContentSources(test.sources); test.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", test.expectError, err)
+			}
+		})
+	}
+}
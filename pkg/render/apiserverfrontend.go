@@ -0,0 +1,28 @@
+package render
+
+import (
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+// RenderAPIServerFrontend renders the manifests of the API server frontend of a management
+// cluster, which are applied once for all of the clusters that share it
+func RenderAPIServerFrontend(params *api.APIServerFrontendParams, outputDir string) error {
+	if len(params.Namespace) == 0 || len(params.Image) == 0 || len(params.Domain) == 0 {
+		return errors.New("the namespace, image and domain of the API server frontend are required")
+	}
+	ctx := newRenderContext(params, outputDir)
+	ctx.setFuncs(template.FuncMap{
+		"include": includeFileFunc(params, ctx),
+	})
+	ctx.addManifestFiles(
+		"apiserver-frontend/apiserver-frontend-namespace.yaml",
+		"apiserver-frontend/apiserver-frontend-deployment.yaml",
+		"apiserver-frontend/apiserver-frontend-service.yaml",
+		"apiserver-frontend/apiserver-frontend-pdb.yaml",
+	)
+	return ctx.renderManifests()
+}
@@ -0,0 +1,71 @@
+package render
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestRenderAPIServerFrontend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	params := &api.APIServerFrontendParams{
+		Namespace: "hypershift-apiserver-frontend",
+		Image:     "quay.io/example/control-plane-operator:latest",
+		Replicas:  2,
+		Domain:    "clusters.example.com",
+		ServiceAnnotations: map[string]string{
+			"service.beta.kubernetes.io/aws-load-balancer-type": "nlb",
+		},
+	}
+	if err = RenderAPIServerFrontend(params, dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "apiserver-frontend-deployment.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployment := &appsv1.Deployment{}
+	if err = yaml.Unmarshal(bytes.Split(b, []byte("\n---"))[1], deployment); err != nil {
+		t.Fatalf("invalid deployment: %v", err)
+	}
+	if *deployment.Spec.Replicas != 2 || deployment.Namespace != params.Namespace {
+		t.Errorf("unexpected deployment %s/%s with %d replicas", deployment.Namespace, deployment.Name, *deployment.Spec.Replicas)
+	}
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if container.Image != params.Image || container.Command[2] != "--domain=clusters.example.com" {
+		t.Errorf("unexpected container image %s and command %v", container.Image, container.Command)
+	}
+
+	b, err = ioutil.ReadFile(filepath.Join(dir, "apiserver-frontend-service.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := &corev1.Service{}
+	if err = yaml.Unmarshal(b, service); err != nil {
+		t.Fatalf("invalid service: %v", err)
+	}
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer || len(service.Spec.Ports) != 3 {
+		t.Errorf("unexpected service type %s with %d ports", service.Spec.Type, len(service.Spec.Ports))
+	}
+	if service.Annotations["service.beta.kubernetes.io/aws-load-balancer-type"] != "nlb" {
+		t.Errorf("expected the annotations of the service, got %v", service.Annotations)
+	}
+
+	if err = RenderAPIServerFrontend(&api.APIServerFrontendParams{Namespace: "frontend"}, dir); err == nil {
+		t.Errorf("expected an error without an image and domain")
+	}
+}
@@ -0,0 +1,145 @@
+package render
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	assets "github.com/openshift/hypershift-toolkit/pkg/assets"
+)
+
+// assetSet is a set of manifest templates for the releases of an OpenShift minor version
+// and later, until the version of the next set. The assets of a new set are generated into
+// a bindata package of their own and registered in assetSets.
+type assetSet struct {
+	version  minorVersion
+	asset    func(name string) ([]byte, error)
+	assetDir func(name string) ([]string, error)
+}
+
+type minorVersion struct {
+	major, minor int
+}
+
+func (v minorVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+func (v minorVersion) less(o minorVersion) bool {
+	return v.major < o.major || (v.major == o.major && v.minor < o.minor)
+}
+
+// assetSets are the manifest sets by the minor version they start at, oldest first
+var assetSets = []*assetSet{
+	{version: minorVersion{4, 2}, asset: assets.Asset, assetDir: assets.AssetDir},
+}
+
+var minorVersionRegexp = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+// parseMinorVersion returns the minor version of a release version such as 4.4.3 or
+// 4.5.0-0.nightly-2020-05-21-042450
+func parseMinorVersion(version string) (minorVersion, error) {
+	m := minorVersionRegexp.FindStringSubmatch(version)
+	if m == nil {
+		return minorVersion{}, errors.Errorf("%q is not an OpenShift version", version)
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	return minorVersion{major: major, minor: minor}, nil
+}
+
+// AssetVersions returns the minor versions that manifest sets start at
+func AssetVersions() []string {
+	var versions []string
+	for _, set := range assetSets {
+		versions = append(versions, set.version.String())
+	}
+	return versions
+}
+
+// defaultAssetSet is the newest set, used for version independent manifests such as the
+// PKI secrets
+func defaultAssetSet() *assetSet {
+	return assetSets[len(assetSets)-1]
+}
+
+// selectAssetSet returns the newest manifest set whose version is not newer than the minor
+// version of a release
+func selectAssetSet(version string) (*assetSet, error) {
+	v, err := parseMinorVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	i := sort.Search(len(assetSets), func(i int) bool { return v.less(assetSets[i].version) })
+	if i == 0 {
+		return nil, errors.Errorf("OpenShift %s is older than the oldest manifest set (%s)", v, assetSets[0].version)
+	}
+	return assetSets[i-1], nil
+}
+
+// overlay returns a set whose templates are read from a directory first, so that templates
+// of the set can be replaced without rebuilding. Templates are found in the directory by
+// their asset names, such as kube-apiserver/kube-apiserver-deployment.yaml.
+func (s *assetSet) overlay(dir string) *assetSet {
+	return &assetSet{
+		version: s.version,
+		asset: func(name string) ([]byte, error) {
+			b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+			if err == nil {
+				return b, nil
+			}
+			if !os.IsNotExist(err) {
+				return nil, err
+			}
+			return s.asset(name)
+		},
+		assetDir: func(name string) ([]string, error) {
+			names, err := s.assetDir(name)
+			infos, dirErr := ioutil.ReadDir(filepath.Join(dir, filepath.FromSlash(name)))
+			if err != nil && dirErr != nil {
+				return nil, err
+			}
+			result := sets.NewString(names...)
+			for _, info := range infos {
+				result.Insert(info.Name())
+			}
+			return result.List(), nil
+		},
+	}
+}
+
+// addedAssets returns the names of the templates of an overlay directory that are not in
+// the set. They are rendered as additional manifests of the control plane.
+func (s *assetSet) addedAssets(dir string) ([]string, error) {
+	var added []string
+	err := filepath.Walk(dir, func(fileName string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".yaml") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, fileName)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if _, err = s.asset(name); err != nil {
+			added = append(added, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read the templates of %s", dir)
+	}
+	return added, nil
+}
@@ -0,0 +1,103 @@
+package render
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestSelectAssetSet(t *testing.T) {
+	sets := assetSets
+	defer func() { assetSets = sets }()
+	assetSets = []*assetSet{
+		{version: minorVersion{4, 2}},
+		{version: minorVersion{4, 4}},
+		{version: minorVersion{4, 5}},
+	}
+	tests := []struct {
+		version     string
+		expected    string
+		expectError bool
+	}{
+		{version: "4.2.0", expected: "4.2"},
+		{version: "4.3.18", expected: "4.2"},
+		{version: "4.4", expected: "4.4"},
+		{version: "4.5.0-0.nightly-2020-05-21-042450", expected: "4.5"},
+		{version: "4.10.3", expected: "4.5"},
+		{version: "5.0.0", expected: "4.5"},
+		{version: "4.1.0", expectError: true},
+		{version: "", expectError: true},
+		{version: "latest", expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.version, func(t *testing.T) {
+			set, err := selectAssetSet(test.version)
+			if test.expectError {
+				if err == nil {
+					t.Errorf("expected an error, got set %s", set.version)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if set.version.String() != test.expected {
+				t.Errorf("expected set %s, got %s", test.expected, set.version)
+			}
+		})
+	}
+}
+
+func TestAssetSetOverlay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"kube-apiserver/config.yaml":   "replaced",
+		"cluster-bootstrap/extra.yaml": "added to a directory",
+		"extra/extra-configmap.yaml":   "added",
+		"kube-apiserver/README.md":     "not a template",
+	}
+	for name, content := range files {
+		fileName := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(fileName, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	base := defaultAssetSet()
+	added, err := base.addedAssets(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"cluster-bootstrap/extra.yaml", "extra/extra-configmap.yaml"}; !reflect.DeepEqual(added, expected) {
+		t.Errorf("expected added assets %v, got %v", expected, added)
+	}
+
+	set := base.overlay(dir)
+	if b, err := set.asset("kube-apiserver/config.yaml"); err != nil || string(b) != "replaced" {
+		t.Errorf("expected replaced template, got %q, error %v", b, err)
+	}
+	expected, err := base.asset("kube-scheduler/config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := set.asset("kube-scheduler/config.yaml"); err != nil || string(b) != string(expected) {
+		t.Errorf("expected built-in template, got error %v", err)
+	}
+	names, err := set.assetDir("cluster-bootstrap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sets.NewString(names...).Has("extra.yaml") {
+		t.Errorf("expected added template in directory, got %v", names)
+	}
+}
@@ -0,0 +1,83 @@
+package render
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+// validateAudit checks the audit policy and webhook of the kube-apiserver
+func validateAudit(params *api.ClusterParams) error {
+	if params.APIServerAuditEnabled && (len(params.AuditPolicy) > 0 || len(params.AuditWebhook.URL) > 0) {
+		return errors.New("apiServerAuditEnabled cannot be combined with an audit policy or webhook")
+	}
+	if len(params.AuditPolicy) > 0 {
+		policy := struct {
+			Kind string `json:"kind"`
+		}{}
+		if err := yaml.Unmarshal([]byte(params.AuditPolicy), &policy); err != nil {
+			return errors.Wrap(err, "cannot parse audit policy")
+		}
+		if policy.Kind != "Policy" {
+			return errors.Errorf("audit policy has kind %q, must be Policy", policy.Kind)
+		}
+	}
+	webhook := params.AuditWebhook
+	if len(webhook.URL) == 0 {
+		if len(webhook.CAFile) > 0 || len(webhook.Mode) > 0 {
+			return errors.New("the audit webhook requires a URL")
+		}
+		return nil
+	}
+	if u, err := url.Parse(webhook.URL); err != nil || u.Scheme != "https" || len(u.Host) == 0 {
+		return errors.Errorf("invalid audit webhook URL %q, must be an https URL", webhook.URL)
+	}
+	switch webhook.Mode {
+	case "", "batch", "blocking":
+	default:
+		return errors.Errorf("unsupported audit webhook mode %q, must be batch or blocking", webhook.Mode)
+	}
+	if _, err := auditWebhookCA(webhook); err != nil {
+		return err
+	}
+	return nil
+}
+
+// auditWebhookCA returns the base64 encoded CA bundle of the audit webhook
+func auditWebhookCA(webhook api.AuditWebhook) (string, error) {
+	if len(webhook.CAFile) == 0 {
+		return "", nil
+	}
+	b, err := ioutil.ReadFile(webhook.CAFile)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read audit webhook CA file %s", webhook.CAFile)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// audit adds the audit policy of the kube-apiserver and the kubeconfig of its audit webhook
+func (c *clusterManifestContext) audit() {
+	params := c.params.(*api.ClusterParams)
+	if len(params.AuditPolicy) > 0 {
+		c.addManifestFiles(
+			"kube-apiserver/kube-apiserver-audit-policy-configmap.yaml",
+		)
+	}
+	if len(params.AuditWebhook.URL) > 0 {
+		ca, err := auditWebhookCA(params.AuditWebhook)
+		if err != nil {
+			c.setError(err, "kube-apiserver-audit-webhook-secret.yaml")
+			return
+		}
+		manifest := c.renderTemplate(map[string]interface{}{
+			"URL": params.AuditWebhook.URL,
+			"CA":  ca,
+		}, "kube-apiserver/kube-apiserver-audit-webhook-secret.yaml")
+		c.addManifest("kube-apiserver-audit-webhook-secret.yaml", manifest)
+	}
+}
@@ -0,0 +1,34 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestValidateAudit(t *testing.T) {
+	policy := "apiVersion: audit.k8s.io/v1\nkind: Policy\nrules:\n- level: Metadata\n"
+	tests := []struct {
+		name        string
+		params      api.ClusterParams
+		expectError bool
+	}{
+		{name: "defaults", params: api.ClusterParams{}},
+		{name: "policy and webhook", params: api.ClusterParams{AuditPolicy: policy, AuditWebhook: api.AuditWebhook{URL: "https://audit.example.com", Mode: "blocking"}}},
+		{name: "legacy audit", params: api.ClusterParams{APIServerAuditEnabled: true}},
+		{name: "legacy audit with policy", params: api.ClusterParams{APIServerAuditEnabled: true, AuditPolicy: policy}, expectError: true},
+		{name: "invalid policy", params: api.ClusterParams{AuditPolicy: "kind: ["}, expectError: true},
+		{name: "policy of another kind", params: api.ClusterParams{AuditPolicy: "kind: ConfigMap"}, expectError: true},
+		{name: "http webhook", params: api.ClusterParams{AuditWebhook: api.AuditWebhook{URL: "http://audit.example.com"}}, expectError: true},
+		{name: "unsupported mode", params: api.ClusterParams{AuditWebhook: api.AuditWebhook{URL: "https://audit.example.com", Mode: "async"}}, expectError: true},
+		{name: "mode without URL", params: api.ClusterParams{AuditWebhook: api.AuditWebhook{Mode: "batch"}}, expectError: true},
+		{name: "missing CA file", params: api.ClusterParams{AuditWebhook: api.AuditWebhook{URL: "https://audit.example.com", CAFile: "/nonexistent/ca.crt"}}, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := validateAudit(&test.params); test.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", test.expectError, err)
+			}
+		})
+	}
+}
@@ -8,10 +8,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
-	"os"
 	"path/filepath"
 	"strings"
 	"unicode"
+
+	"github.com/pkg/errors"
 )
 
 func includeVPNFunc(includeVPN bool) func() bool {
@@ -20,6 +21,12 @@ func includeVPNFunc(includeVPN bool) func() bool {
 	}
 }
 
+func includeKonnectivityFunc(includeKonnectivity bool) func() bool {
+	return func() bool {
+		return includeKonnectivity
+	}
+}
+
 func imageFunc(images map[string]string) func(string) string {
 	return func(imageName string) string {
 		return images[imageName]
@@ -32,29 +39,21 @@ func versionFunc(versions map[string]string) func(string) string {
 	}
 }
 
-func pkiFunc(pkiDir string) func(string) string {
-	return func(fileName string) string {
-		file := filepath.Join(pkiDir, fileName)
-		if _, err := os.Stat(file); err != nil {
-			panic(err.Error())
-		}
-		b, err := ioutil.ReadFile(file)
+func pkiFunc(pkiDir string) func(string) (string, error) {
+	return func(fileName string) (string, error) {
+		b, err := ioutil.ReadFile(filepath.Join(pkiDir, fileName))
 		if err != nil {
-			panic(err.Error())
+			return "", errors.Wrapf(err, "cannot read PKI file %s", fileName)
 		}
-		return base64.StdEncoding.EncodeToString(b)
+		return base64.StdEncoding.EncodeToString(b), nil
 	}
 }
 
-func includePKIFunc(pkiDir string) func(string, int) string {
-	return func(fileName string, indent int) string {
-		file := filepath.Join(pkiDir, fileName)
-		if _, err := os.Stat(file); err != nil {
-			panic(err.Error())
-		}
-		b, err := ioutil.ReadFile(file)
+func includePKIFunc(pkiDir string) func(string, int) (string, error) {
+	return func(fileName string, indent int) (string, error) {
+		b, err := ioutil.ReadFile(filepath.Join(pkiDir, fileName))
 		if err != nil {
-			panic(err.Error())
+			return "", errors.Wrapf(err, "cannot read PKI file %s", fileName)
 		}
 		input := bytes.NewBuffer(b)
 		output := &bytes.Buffer{}
@@ -62,17 +61,17 @@ func includePKIFunc(pkiDir string) func(string, int) string {
 		for scanner.Scan() {
 			fmt.Fprintf(output, "%s%s\n", strings.Repeat(" ", indent), scanner.Text())
 		}
-		return output.String()
+		return output.String(), nil
 	}
 }
 
-func base64Func(params interface{}, rc *renderContext) func(string) string {
-	return func(fileName string) string {
+func base64Func(params interface{}, rc *renderContext) func(string) (string, error) {
+	return func(fileName string) (string, error) {
 		result, err := rc.substituteParams(params, fileName)
 		if err != nil {
-			panic(err.Error())
+			return "", err
 		}
-		return base64.StdEncoding.EncodeToString([]byte(result))
+		return base64.StdEncoding.EncodeToString([]byte(result)), nil
 	}
 }
 
@@ -88,35 +87,35 @@ func includeDataFunc() func(string, int) string {
 	}
 }
 
-func includeFileFunc(params interface{}, rc *renderContext) func(string, int) string {
-	return func(fileName string, indent int) string {
+func includeFileFunc(params interface{}, rc *renderContext) func(string, int) (string, error) {
+	return func(fileName string, indent int) (string, error) {
 		result, err := rc.substituteParams(params, fileName)
 		if err != nil {
-			panic(err.Error())
+			return "", err
 		}
 		includeFn := includeDataFunc()
-		return includeFn(result, indent)
+		return includeFn(result, indent), nil
 	}
 }
 
-func cidrAddress(cidr string) string {
+func cidrAddress(cidr string) (string, error) {
 	ip, _, err := net.ParseCIDR(cidr)
 	if err != nil {
-		panic(err.Error())
+		return "", err
 	}
-	return ip.String()
+	return ip.String(), nil
 }
 
-func cidrMask(cidr string) string {
+func cidrMask(cidr string) (string, error) {
 	_, ipNet, err := net.ParseCIDR(cidr)
 	if err != nil {
-		panic(err.Error())
+		return "", err
 	}
 	m := ipNet.Mask
 	if len(m) != 4 {
-		panic("Expecting a 4-byte mask")
+		return "", errors.Errorf("%s is not an IPv4 CIDR", cidr)
 	}
-	return fmt.Sprintf("%d.%d.%d.%d", m[0], m[1], m[2], m[3])
+	return fmt.Sprintf("%d.%d.%d.%d", m[0], m[1], m[2], m[3]), nil
 }
 
 // randomString uses RawURLEncoding to ensure we do not get / characters or trailing ='s
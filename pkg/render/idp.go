@@ -0,0 +1,252 @@
+package render
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+const (
+	// identityProvidersSecret holds the files of the typed identity providers
+	identityProvidersSecret = "oauth-openshift-identity-providers"
+
+	// identityProvidersDir is where the OAuth server mounts identityProvidersSecret
+	identityProvidersDir = "/etc/oauth-openshift-identity-providers"
+)
+
+var identityProviderName = regexp.MustCompile(`^[a-zA-Z0-9][-._a-zA-Z0-9]*$`)
+
+// identityProviderFiles collects the files of identity providers as the data of
+// identityProvidersSecret
+type identityProviderFiles map[string]string
+
+// add reads a file of a provider and returns the path of its key in the OAuth server
+func (f identityProviderFiles) add(provider, name, fileName string) (string, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read file of identity provider %s", provider)
+	}
+	key := provider + "-" + name
+	f[key] = base64.StdEncoding.EncodeToString(b)
+	return path.Join(identityProvidersDir, key), nil
+}
+
+// identityProviders returns the identity providers of the OAuth server config and the
+// data of identityProvidersSecret
+func identityProviders(providers []api.IdentityProvider) ([]interface{}, identityProviderFiles, error) {
+	files := identityProviderFiles{}
+	names := map[string]bool{}
+	var result []interface{}
+	for _, p := range providers {
+		if !identityProviderName.MatchString(p.Name) {
+			return nil, nil, errors.Errorf("invalid identity provider name %q", p.Name)
+		}
+		if names[p.Name] {
+			return nil, nil, errors.Errorf("duplicate identity provider %s", p.Name)
+		}
+		names[p.Name] = true
+		var provider map[string]interface{}
+		var err error
+		challenge := true
+		switch {
+		case p.HTPasswd != nil && p.LDAP == nil && p.OpenID == nil && p.GitHub == nil:
+			provider, err = htpasswdProvider(p.Name, p.HTPasswd, files)
+		case p.LDAP != nil && p.HTPasswd == nil && p.OpenID == nil && p.GitHub == nil:
+			provider, err = ldapProvider(p.Name, p.LDAP, files)
+		case p.OpenID != nil && p.HTPasswd == nil && p.LDAP == nil && p.GitHub == nil:
+			provider, err = openIDProvider(p.Name, p.OpenID, files)
+		case p.GitHub != nil && p.HTPasswd == nil && p.LDAP == nil && p.OpenID == nil:
+			// GitHub does not support the challenge flow of command line clients
+			challenge = false
+			provider, err = gitHubProvider(p.Name, p.GitHub, files)
+		default:
+			return nil, nil, errors.Errorf("identity provider %s must have exactly one provider type", p.Name)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		mappingMethod := p.MappingMethod
+		if len(mappingMethod) == 0 {
+			mappingMethod = "claim"
+		}
+		omitEmpty(provider)
+		provider["apiVersion"] = "v1"
+		result = append(result, map[string]interface{}{
+			"name":          p.Name,
+			"challenge":     challenge,
+			"login":         true,
+			"mappingMethod": mappingMethod,
+			"provider":      provider,
+		})
+	}
+	return result, files, nil
+}
+
+func htpasswdProvider(name string, p *api.HTPasswdIdentityProvider, files identityProviderFiles) (map[string]interface{}, error) {
+	file, err := files.add(name, "htpasswd", p.File)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"kind": "HTPasswdPasswordIdentityProvider",
+		"file": file,
+	}, nil
+}
+
+func ldapProvider(name string, p *api.LDAPIdentityProvider, files identityProviderFiles) (map[string]interface{}, error) {
+	if len(p.URL) == 0 {
+		return nil, errors.Errorf("LDAP identity provider %s requires a URL", name)
+	}
+	provider := map[string]interface{}{
+		"kind":     "LDAPPasswordIdentityProvider",
+		"url":      p.URL,
+		"insecure": p.Insecure,
+		"attributes": map[string]interface{}{
+			"id":                defaultStrings(p.ID, "dn"),
+			"preferredUsername": defaultStrings(p.PreferredUsername, "uid"),
+			"name":              p.Name,
+			"email":             p.Email,
+		},
+	}
+	if len(p.BindDN) > 0 {
+		provider["bindDN"] = p.BindDN
+	}
+	if len(p.BindPasswordFile) > 0 {
+		file, err := files.add(name, "bind-password", p.BindPasswordFile)
+		if err != nil {
+			return nil, err
+		}
+		provider["bindPassword"] = map[string]interface{}{"file": file}
+	}
+	if len(p.CAFile) > 0 {
+		file, err := files.add(name, "ca.crt", p.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		provider["ca"] = file
+	}
+	return provider, nil
+}
+
+func openIDProvider(name string, p *api.OpenIDIdentityProvider, files identityProviderFiles) (map[string]interface{}, error) {
+	if len(p.ClientID) == 0 || len(p.AuthorizeURL) == 0 || len(p.TokenURL) == 0 {
+		return nil, errors.Errorf("OpenID identity provider %s requires a client ID, an authorize URL and a token URL", name)
+	}
+	secret, err := files.add(name, "client-secret", p.ClientSecretFile)
+	if err != nil {
+		return nil, err
+	}
+	urls := map[string]interface{}{
+		"authorize": p.AuthorizeURL,
+		"token":     p.TokenURL,
+	}
+	if len(p.UserInfoURL) > 0 {
+		urls["userInfo"] = p.UserInfoURL
+	}
+	provider := map[string]interface{}{
+		"kind":         "OpenIDIdentityProvider",
+		"clientID":     p.ClientID,
+		"clientSecret": map[string]interface{}{"file": secret},
+		"extraScopes":  p.ExtraScopes,
+		"urls":         urls,
+		"claims": map[string]interface{}{
+			"id":                []string{"sub"},
+			"preferredUsername": defaultStrings(p.PreferredUsername, "preferred_username"),
+			"name":              p.Name,
+			"email":             p.Email,
+		},
+	}
+	if len(p.CAFile) > 0 {
+		file, err := files.add(name, "ca.crt", p.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		provider["ca"] = file
+	}
+	return provider, nil
+}
+
+func gitHubProvider(name string, p *api.GitHubIdentityProvider, files identityProviderFiles) (map[string]interface{}, error) {
+	if len(p.ClientID) == 0 {
+		return nil, errors.Errorf("GitHub identity provider %s requires a client ID", name)
+	}
+	secret, err := files.add(name, "client-secret", p.ClientSecretFile)
+	if err != nil {
+		return nil, err
+	}
+	provider := map[string]interface{}{
+		"kind":          "GitHubIdentityProvider",
+		"clientID":      p.ClientID,
+		"clientSecret":  map[string]interface{}{"file": secret},
+		"organizations": p.Organizations,
+		"teams":         p.Teams,
+		"hostname":      p.Hostname,
+	}
+	if len(p.CAFile) > 0 {
+		file, err := files.add(name, "ca.crt", p.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		provider["ca"] = file
+	}
+	return provider, nil
+}
+
+// omitEmpty removes the empty strings and lists of a provider and its nested maps
+func omitEmpty(m map[string]interface{}) {
+	for key, value := range m {
+		switch v := value.(type) {
+		case string:
+			if len(v) == 0 {
+				delete(m, key)
+			}
+		case []string:
+			if len(v) == 0 {
+				delete(m, key)
+			}
+		case map[string]interface{}:
+			omitEmpty(v)
+		}
+	}
+}
+
+func defaultStrings(values []string, defaultValue string) []string {
+	if len(values) == 0 {
+		return []string{defaultValue}
+	}
+	return values
+}
+
+// identityProvidersFunc returns the identity providers of the OAuth server config, which
+// are the providers given as YAML followed by the typed providers
+func identityProvidersFunc(params interface{}) func() (string, error) {
+	return func() (string, error) {
+		clusterParams, ok := params.(*api.ClusterParams)
+		if !ok {
+			return "", nil
+		}
+		var parts []string
+		if raw := trimTrailingSpace(clusterParams.IdentityProviders); len(raw) > 0 {
+			parts = append(parts, raw)
+		}
+		providers, _, err := identityProviders(clusterParams.OAuthIdentityProviders)
+		if err != nil {
+			return "", err
+		}
+		if len(providers) > 0 {
+			b, err := yaml.Marshal(providers)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, trimTrailingSpace(string(b)))
+		}
+		return strings.Join(parts, "\n"), nil
+	}
+}
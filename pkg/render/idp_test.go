@@ -0,0 +1,125 @@
+package render
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestIdentityProviders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	secretFile := filepath.Join(dir, "secret")
+	if err := ioutil.WriteFile(secretFile, []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missingFile := filepath.Join(dir, "missing")
+
+	tests := []struct {
+		name        string
+		providers   []api.IdentityProvider
+		expected    []interface{}
+		expectedKey string
+		expectError bool
+	}{
+		{
+			name:      "htpasswd",
+			providers: []api.IdentityProvider{{Name: "local", HTPasswd: &api.HTPasswdIdentityProvider{File: secretFile}}},
+			expected: []interface{}{map[string]interface{}{
+				"name":          "local",
+				"challenge":     true,
+				"login":         true,
+				"mappingMethod": "claim",
+				"provider": map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "HTPasswdPasswordIdentityProvider",
+					"file":       "/etc/oauth-openshift-identity-providers/local-htpasswd",
+				},
+			}},
+			expectedKey: "local-htpasswd",
+		},
+		{
+			name: "github",
+			providers: []api.IdentityProvider{{Name: "github", MappingMethod: "lookup", GitHub: &api.GitHubIdentityProvider{
+				ClientID:         "client",
+				ClientSecretFile: secretFile,
+				Organizations:    []string{"openshift"},
+			}}},
+			expected: []interface{}{map[string]interface{}{
+				"name":          "github",
+				"challenge":     false,
+				"login":         true,
+				"mappingMethod": "lookup",
+				"provider": map[string]interface{}{
+					"apiVersion":    "v1",
+					"kind":          "GitHubIdentityProvider",
+					"clientID":      "client",
+					"clientSecret":  map[string]interface{}{"file": "/etc/oauth-openshift-identity-providers/github-client-secret"},
+					"organizations": []string{"openshift"},
+				},
+			}},
+			expectedKey: "github-client-secret",
+		},
+		{
+			name:        "no provider type",
+			providers:   []api.IdentityProvider{{Name: "none"}},
+			expectError: true,
+		},
+		{
+			name: "several provider types",
+			providers: []api.IdentityProvider{{
+				Name:     "both",
+				HTPasswd: &api.HTPasswdIdentityProvider{File: secretFile},
+				LDAP:     &api.LDAPIdentityProvider{URL: "ldap://ldap.example.com"},
+			}},
+			expectError: true,
+		},
+		{
+			name: "duplicate name",
+			providers: []api.IdentityProvider{
+				{Name: "local", HTPasswd: &api.HTPasswdIdentityProvider{File: secretFile}},
+				{Name: "local", HTPasswd: &api.HTPasswdIdentityProvider{File: secretFile}},
+			},
+			expectError: true,
+		},
+		{
+			name:        "invalid name",
+			providers:   []api.IdentityProvider{{Name: "my idp", HTPasswd: &api.HTPasswdIdentityProvider{File: secretFile}}},
+			expectError: true,
+		},
+		{
+			name:        "missing file",
+			providers:   []api.IdentityProvider{{Name: "local", HTPasswd: &api.HTPasswdIdentityProvider{File: missingFile}}},
+			expectError: true,
+		},
+		{
+			name:        "OpenID without URLs",
+			providers:   []api.IdentityProvider{{Name: "oidc", OpenID: &api.OpenIDIdentityProvider{ClientID: "client", ClientSecretFile: secretFile}}},
+			expectError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			providers, files, err := identityProviders(test.providers)
+			if test.expectError != (err != nil) {
+				t.Fatalf("expected error %t, got %v", test.expectError, err)
+			}
+			if test.expectError {
+				return
+			}
+			if !reflect.DeepEqual(providers, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, providers)
+			}
+			if _, ok := files[test.expectedKey]; !ok {
+				t.Errorf("expected secret key %s, got %v", test.expectedKey, files)
+			}
+		})
+	}
+}
@@ -2,58 +2,146 @@ package render
 
 import (
 	"bytes"
+	"io/ioutil"
+	"net/url"
 	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
 
+	"github.com/pkg/errors"
+
 	"github.com/openshift/hypershift-toolkit/pkg/api"
-	assets "github.com/openshift/hypershift-toolkit/pkg/assets"
+	"github.com/openshift/hypershift-toolkit/pkg/pki"
 	"github.com/openshift/hypershift-toolkit/pkg/release"
 )
 
-// RenderClusterManifests renders manifests for a hosted control plane cluster
-func RenderClusterManifests(params *api.ClusterParams, pullSecretFile, outputDir string, etcd bool, vpn bool, externalOauth bool, includeRegistry bool) error {
-	releaseInfo, err := release.GetReleaseInfo(params.ReleaseImage, params.OriginReleasePrefix, pullSecretFile)
+const (
+	// EtcdModeOperator deploys etcd as an EtcdCluster of the etcd operator
+	EtcdModeOperator = "operator"
+
+	// EtcdModeSimple deploys etcd as a StatefulSet
+	EtcdModeSimple = "simple"
+
+	defaultEtcdStorageSize    = "4Gi"
+	defaultEtcdDefragSchedule = "0 2 * * *"
+)
+
+// RenderClusterManifests renders manifests for a hosted control plane cluster. The kube-apiserver
+// reaches the cluster network through either a VPN or konnectivity, but not both.
+func RenderClusterManifests(params *api.ClusterParams, pullSecretFile, outputDir string, etcd bool, vpn bool, konnectivity bool, externalOauth bool, includeRegistry bool) error {
+	if vpn && konnectivity {
+		return errors.New("a cluster cannot use both a VPN and konnectivity")
+	}
+	if err := validateAutoscaling(params.Autoscaling); err != nil {
+		return err
+	}
+	if err := validateEtcd(params.Etcd); err != nil {
+		return err
+	}
+	if err := validateEtcdEndpoints(params.EtcdEndpoints, etcd); err != nil {
+		return err
+	}
+	if _, _, err := identityProviders(params.OAuthIdentityProviders); err != nil {
+		return err
+	}
+	if err := validateAudit(params); err != nil {
+		return err
+	}
+	if err := validateMonitoring(params.Monitoring); err != nil {
+		return err
+	}
+	if err := validateVerticalPodAutoscaling(params.VerticalPodAutoscaling); err != nil {
+		return err
+	}
+	params, err := api.WithSizingProfile(params)
+	if err != nil {
+		return err
+	}
+	if params.ExternalIgnitionPort != 0 && len(params.IgnitionServerToken) == 0 {
+		return errors.New("the ignition server requires a token")
+	}
+	if err := release.ValidateImageContentSources(params.ImageContentSources); err != nil {
+		return err
+	}
+	if err := pki.ValidateEncryption(params.EtcdEncryption); err != nil {
+		return err
+	}
+	releaseInfo, err := release.GetReleaseInfo(params.ReleaseImage, params.Arch, params.OriginReleasePrefix, pullSecretFile, params.ImageContentSources)
 	if err != nil {
 		return err
 	}
-	ctx := newClusterManifestContext(releaseInfo.Images, releaseInfo.Versions, params, outputDir, vpn)
-	ctx.setupManifests(etcd, vpn, externalOauth, includeRegistry)
+	if err := validateNodeUpdates(params.NodeUpdates, releaseInfo.Images); err != nil {
+		return err
+	}
+	assetVersion := params.AssetVersion
+	if len(assetVersion) == 0 {
+		assetVersion = releaseInfo.Versions["release"]
+	}
+	assetSet, err := selectAssetSet(assetVersion)
+	if err != nil {
+		return errors.Wrap(err, "cannot select the manifests of the release (set assetVersion to override)")
+	}
+	var addedAssets []string
+	if len(params.AssetsDir) > 0 {
+		if addedAssets, err = assetSet.addedAssets(params.AssetsDir); err != nil {
+			return err
+		}
+		assetSet = assetSet.overlay(params.AssetsDir)
+	}
+	ctx := newClusterManifestContext(releaseInfo.Images, releaseInfo.Versions, params, outputDir, vpn, konnectivity)
+	ctx.assets = assetSet
+	ctx.setupManifests(etcd, vpn, konnectivity, externalOauth, includeRegistry)
+	ctx.addManifestFiles(addedAssets...)
 	return ctx.renderManifests()
 }
 
+// PrivilegedServiceAccounts returns the service accounts of the control plane pods with
+// privileged containers, which must be allowed to use the privileged SCC. Only the VPN
+// server and client need to be privileged, to create their tunnel devices.
+func PrivilegedServiceAccounts(vpn bool) []string {
+	if !vpn {
+		return nil
+	}
+	return []string{"kube-apiserver", "openvpn-server"}
+}
+
 type clusterManifestContext struct {
 	*renderContext
 	userManifestFiles []string
 	userManifests     map[string]string
 }
 
-func newClusterManifestContext(images, versions map[string]string, params interface{}, outputDir string, includeVPN bool) *clusterManifestContext {
+func newClusterManifestContext(images, versions map[string]string, params interface{}, outputDir string, includeVPN, includeKonnectivity bool) *clusterManifestContext {
 	ctx := &clusterManifestContext{
 		renderContext: newRenderContext(params, outputDir),
 		userManifests: make(map[string]string),
 	}
 	ctx.setFuncs(template.FuncMap{
-		"version":           versionFunc(versions),
-		"imageFor":          imageFunc(images),
-		"base64String":      base64StringEncode,
-		"indent":            indent,
-		"address":           cidrAddress,
-		"mask":              cidrMask,
-		"include":           includeFileFunc(params, ctx.renderContext),
-		"includeVPN":        includeVPNFunc(includeVPN),
-		"randomString":      randomString,
-		"includeData":       includeDataFunc(),
-		"trimTrailingSpace": trimTrailingSpace,
+		"version":             versionFunc(versions),
+		"imageFor":            imageFunc(images),
+		"base64String":        base64StringEncode,
+		"indent":              indent,
+		"address":             cidrAddress,
+		"mask":                cidrMask,
+		"include":             includeFileFunc(params, ctx.renderContext),
+		"includeVPN":          includeVPNFunc(includeVPN),
+		"includeKonnectivity": includeKonnectivityFunc(includeKonnectivity),
+		"randomString":        randomString,
+		"includeData":         includeDataFunc(),
+		"trimTrailingSpace":   trimTrailingSpace,
+		"identityProviders":   identityProvidersFunc(params),
 	})
 	return ctx
 }
 
-func (c *clusterManifestContext) setupManifests(etcd bool, vpn bool, externalOauth bool, includeRegistry bool) {
+func (c *clusterManifestContext) setupManifests(etcd bool, vpn bool, konnectivity bool, externalOauth bool, includeRegistry bool) {
 	if etcd {
 		c.etcd()
 	}
-	c.kubeAPIServer(vpn)
+	c.kubeAPIServer(vpn, konnectivity)
+	c.audit()
 	c.kubeControllerManager()
 	c.kubeScheduler()
 	c.clusterBootstrap()
@@ -65,15 +153,43 @@ func (c *clusterManifestContext) setupManifests(etcd bool, vpn bool, externalOau
 	if vpn {
 		c.openVPN()
 	}
+	if konnectivity {
+		c.konnectivity()
+	}
 	c.clusterVersionOperator()
 	if includeRegistry {
 		c.registry()
 	}
+	if len(c.params.(*api.ClusterParams).ImageContentSources) > 0 {
+		c.imageContentSources()
+	}
+	if len(c.params.(*api.ClusterParams).Autoscaling.Pools) > 0 {
+		c.clusterAutoscaler()
+	}
+	if c.params.(*api.ClusterParams).ExternalIgnitionPort != 0 {
+		c.ignitionServer()
+	}
+	if c.params.(*api.ClusterParams).Monitoring.Enabled {
+		c.monitoring(etcd)
+	}
+	if !c.params.(*api.ClusterParams).NetworkPolicy.Disabled {
+		c.networkPolicies(etcd, vpn, externalOauth)
+	}
+	if c.params.(*api.ClusterParams).VerticalPodAutoscaling.Enabled {
+		c.verticalPodAutoscalers(etcd, vpn, externalOauth)
+	}
+	if c.params.(*api.ClusterParams).NodeUpdates.Enabled {
+		c.nodeUpdater()
+	}
 	c.userManifestsBootstrapper()
 	c.controlPlaneOperator()
 }
 
 func (c *clusterManifestContext) etcd() {
+	if c.params.(*api.ClusterParams).Etcd.Mode == EtcdModeSimple {
+		c.simpleEtcd()
+		return
+	}
 	c.addManifestFiles(
 		"etcd/etcd-cluster-crd.yaml",
 		"etcd/etcd-cluster.yaml",
@@ -81,7 +197,42 @@ func (c *clusterManifestContext) etcd() {
 		"etcd/etcd-operator-cluster-role.yaml",
 		"etcd/etcd-operator.yaml",
 	)
+	c.podDisruptionBudget("etcd")
+}
 
+// simpleEtcd adds an etcd StatefulSet with a volume per member and a CronJob that
+// defragments the members, which unlike the etcd operator needs no cluster-scoped
+// CRDs or roles
+func (c *clusterManifestContext) simpleEtcd() {
+	params := c.params.(*api.ClusterParams)
+	storageSize := params.Etcd.StorageSize
+	if len(storageSize) == 0 {
+		storageSize = defaultEtcdStorageSize
+	}
+	schedule := params.Etcd.DefragSchedule
+	if len(schedule) == 0 {
+		schedule = defaultEtcdDefragSchedule
+	}
+	c.addManifestFiles(
+		"etcd/etcd-services.yaml",
+	)
+	for name, file := range map[string]string{
+		"etcd-statefulset.yaml":    "etcd/etcd-statefulset-template.yaml",
+		"etcd-defrag-cronjob.yaml": "etcd/etcd-defrag-cronjob-template.yaml",
+	} {
+		manifest := c.renderTemplate(map[string]interface{}{
+			"Namespace":      params.Namespace,
+			"Replicas":       params.Replicas,
+			"StorageSize":    storageSize,
+			"StorageClass":   params.Etcd.StorageClass,
+			"Schedule":       schedule,
+			"Monitoring":     params.Monitoring,
+			"SeccompProfile": params.PodSecurity.SeccompProfile,
+			"Resources":      params.EtcdResources,
+		}, file)
+		c.addManifest(name, manifest)
+	}
+	c.podDisruptionBudget("etcd")
 }
 
 func (c *clusterManifestContext) oauthOpenshiftServer() {
@@ -94,20 +245,44 @@ func (c *clusterManifestContext) oauthOpenshiftServer() {
 		"oauth-openshift/v4-0-config-system-branding.yaml",
 		"oauth-openshift/oauth-server-sessionsecret-secret.yaml",
 	)
+	c.podDisruptionBudget("oauth-openshift")
+	if len(c.params.(*api.ClusterParams).OAuthIdentityProviders) > 0 {
+		c.identityProvidersSecret()
+	}
+}
+
+// identityProvidersSecret adds the secret with the files of the typed identity providers
+func (c *clusterManifestContext) identityProvidersSecret() {
+	_, files, err := identityProviders(c.params.(*api.ClusterParams).OAuthIdentityProviders)
+	if err != nil {
+		c.setError(err, identityProvidersSecret+"-secret.yaml")
+		return
+	}
+	manifest := c.renderTemplate(map[string]interface{}{
+		"Name": identityProvidersSecret,
+		"Data": files,
+	}, "oauth-openshift/oauth-server-identity-providers-secret.yaml")
+	c.addManifest(identityProvidersSecret+"-secret.yaml", manifest)
 }
 
-func (c *clusterManifestContext) kubeAPIServer(includeVPN bool) {
+func (c *clusterManifestContext) kubeAPIServer(includeVPN, includeKonnectivity bool) {
 	c.addManifestFiles(
 		"kube-apiserver/kube-apiserver-deployment.yaml",
 		"kube-apiserver/kube-apiserver-service.yaml",
 		"kube-apiserver/kube-apiserver-config-configmap.yaml",
 		"kube-apiserver/kube-apiserver-oauth-metadata-configmap.yaml",
 	)
+	c.podDisruptionBudget("kube-apiserver")
 	if includeVPN {
 		c.addManifestFiles(
 			"kube-apiserver/kube-apiserver-vpnclient-config.yaml",
 		)
 	}
+	if includeKonnectivity {
+		c.addManifestFiles(
+			"kube-apiserver/kube-apiserver-egress-selector-configmap.yaml",
+		)
+	}
 }
 
 func (c *clusterManifestContext) kubeControllerManager() {
@@ -115,6 +290,7 @@ func (c *clusterManifestContext) kubeControllerManager() {
 		"kube-controller-manager/kube-controller-manager-deployment.yaml",
 		"kube-controller-manager/kube-controller-manager-config-configmap.yaml",
 	)
+	c.podDisruptionBudget("kube-controller-manager")
 }
 
 func (c *clusterManifestContext) kubeScheduler() {
@@ -122,20 +298,82 @@ func (c *clusterManifestContext) kubeScheduler() {
 		"kube-scheduler/kube-scheduler-deployment.yaml",
 		"kube-scheduler/kube-scheduler-config-configmap.yaml",
 	)
+	c.podDisruptionBudget("kube-scheduler")
+}
+
+// ignitionServer adds a server for the worker ignition config of the cluster. The config
+// itself is stored in the ignition-server-config secret, which is not rendered.
+func (c *clusterManifestContext) ignitionServer() {
+	c.addManifestFiles(
+		"ignition-server/ignition-server-deployment.yaml",
+		"ignition-server/ignition-server-service.yaml",
+		"ignition-server/ignition-server-token-secret.yaml",
+	)
 }
 
 func (c *clusterManifestContext) registry() {
 	c.addUserManifestFiles("registry/cluster-imageregistry-config.yaml")
 }
 
+// imageContentSources adds a policy that makes the cluster pull the images of the
+// image content sources from their mirrors
+func (c *clusterManifestContext) imageContentSources() {
+	c.addUserManifestFiles("image-content-sources/image-content-source-policy.yaml")
+}
+
+const (
+	// networkOperatorConfig is the configuration of the cluster network operator, which only
+	// configures the network plugins that the operator deploys
+	networkOperatorConfig = "cluster-network-03-config.yaml"
+	// userCABundleConfig is the additional trust bundle that the proxy configuration of the
+	// cluster refers to
+	userCABundleConfig = "cluster-proxy-02-user-ca-bundle.yaml"
+)
+
 func (c *clusterManifestContext) clusterBootstrap() {
-	manifests, err := assets.AssetDir("cluster-bootstrap")
+	manifests, err := c.assets.assetDir("cluster-bootstrap")
 	if err != nil {
-		panic(err.Error())
+		c.setError(err, "cluster-bootstrap")
+		return
 	}
+	params := c.params.(*api.ClusterParams)
 	for _, m := range manifests {
+		if m == networkOperatorConfig && params.NetworkType == api.NetworkTypeNone {
+			continue
+		}
+		if m == userCABundleConfig && len(params.AdditionalTrustBundle) == 0 {
+			continue
+		}
 		c.addUserManifestFiles("cluster-bootstrap/" + m)
 	}
+	if len(params.UserManifestsDir) > 0 {
+		c.userManifestsDir(params.UserManifestsDir)
+	}
+}
+
+// userManifestsDir adds the YAML files of a directory as they are, without rendering them as
+// templates, to the manifests that the user manifests bootstrapper applies to the cluster,
+// such as those of a third-party network plugin
+func (c *clusterManifestContext) userManifestsDir(dir string) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		c.setError(err, dir)
+		return
+	}
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		if f.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			c.setError(err, f.Name())
+			return
+		}
+		// The name of the config map of a manifest ends at the first dot of its file name
+		name := strings.ToLower(strings.Replace(strings.TrimSuffix(f.Name(), ext), ".", "-", -1))
+		c.addUserManifest("extra-"+name+".yaml", string(data))
+	}
 }
 
 func (c *clusterManifestContext) openshiftAPIServer() {
@@ -144,6 +382,7 @@ func (c *clusterManifestContext) openshiftAPIServer() {
 		"openshift-apiserver/openshift-apiserver-service.yaml",
 		"openshift-apiserver/openshift-apiserver-config-configmap.yaml",
 	)
+	c.podDisruptionBudget("openshift-apiserver")
 	c.addUserManifestFiles(
 		"openshift-apiserver/openshift-apiserver-user-service.yaml",
 		"openshift-apiserver/openshift-apiserver-user-endpoint.yaml",
@@ -167,10 +406,7 @@ func (c *clusterManifestContext) openshiftAPIServer() {
 			"APIServiceGroup":            trimFirstSegment(apiService),
 			"OpenshiftAPIServerCABundle": c.params.(*api.ClusterParams).OpenshiftAPIServerCABundle,
 		}
-		entry, err := c.substituteParams(params, "openshift-apiserver/service-template.yaml")
-		if err != nil {
-			panic(err.Error())
-		}
+		entry := c.renderTemplate(params, "openshift-apiserver/service-template.yaml")
 		apiServices.WriteString(entry)
 	}
 	c.addUserManifest("openshift-apiserver-apiservices.yaml", apiServices.String())
@@ -182,6 +418,8 @@ func (c *clusterManifestContext) openshiftControllerManager() {
 		"openshift-controller-manager/openshift-controller-manager-config-configmap.yaml",
 		"openshift-controller-manager/cluster-policy-controller-deployment.yaml",
 	)
+	c.podDisruptionBudget("openshift-controller-manager")
+	c.podDisruptionBudget("cluster-policy-controller")
 	c.addUserManifestFiles(
 		"openshift-controller-manager/00-openshift-controller-manager-namespace.yaml",
 		"openshift-controller-manager/openshift-controller-manager-service-ca.yaml",
@@ -192,6 +430,7 @@ func (c *clusterManifestContext) controlPlaneOperator() {
 	c.addManifestFiles(
 		"control-plane-operator/cp-operator-deployment.yaml",
 	)
+	c.podDisruptionBudget("control-plane-operator")
 }
 
 func (c *clusterManifestContext) openVPN() {
@@ -207,8 +446,46 @@ func (c *clusterManifestContext) openVPN() {
 	)
 }
 
+func (c *clusterManifestContext) konnectivity() {
+	c.addManifestFiles(
+		"konnectivity/konnectivity-server-service.yaml",
+	)
+	c.addUserManifestFiles(
+		"konnectivity/konnectivity-agent-daemonset.yaml",
+	)
+}
+
+// networkPolicies adds network policies that deny the ingress traffic of the pods in the
+// control plane namespace, except to the endpoints exposed outside of the namespace (the
+// API, VPN, OAuth and ignition servers), to etcd from the API servers and to the
+// OpenShift API server from the Kubernetes API server
+func (c *clusterManifestContext) networkPolicies(etcd, vpn, externalOauth bool) {
+	params := c.params.(*api.ClusterParams)
+	c.addManifestFiles(
+		"network-policy/default-deny-network-policy.yaml",
+		"network-policy/kube-apiserver-network-policy.yaml",
+		"network-policy/openshift-apiserver-network-policy.yaml",
+	)
+	if etcd {
+		c.addManifestFiles("network-policy/etcd-network-policy.yaml")
+	}
+	if vpn {
+		c.addManifestFiles("network-policy/openvpn-server-network-policy.yaml")
+	}
+	if externalOauth {
+		c.addManifestFiles("network-policy/oauth-openshift-network-policy.yaml")
+	}
+	if params.ExternalIgnitionPort != 0 {
+		c.addManifestFiles("network-policy/ignition-server-network-policy.yaml")
+	}
+	if params.Monitoring.Enabled {
+		c.addManifestFiles("network-policy/metrics-network-policy.yaml")
+	}
+}
+
 func (c *clusterManifestContext) clusterVersionOperator() {
 	c.addManifestFiles(
+		"cluster-version-operator/cluster-version-configmap.yaml",
 		"cluster-version-operator/cluster-version-operator-deployment.yaml",
 	)
 }
@@ -218,19 +495,13 @@ func (c *clusterManifestContext) userManifestsBootstrapper() {
 		"user-manifests-bootstrapper/user-manifests-bootstrapper-pod.yaml",
 	)
 	for _, file := range c.userManifestFiles {
-		data, err := c.substituteParams(c.params, file)
-		if err != nil {
-			panic(err.Error())
-		}
+		data := c.renderTemplate(c.params, file)
 		name := path.Base(file)
 		params := map[string]string{
 			"data": data,
 			"name": userConfigMapName(name),
 		}
-		manifest, err := c.substituteParams(params, "user-manifests-bootstrapper/user-manifest-template.yaml")
-		if err != nil {
-			panic(err.Error())
-		}
+		manifest := c.renderTemplate(params, "user-manifests-bootstrapper/user-manifest-template.yaml")
 		c.addManifest("user-manifest-"+name, manifest)
 	}
 
@@ -239,14 +510,89 @@ func (c *clusterManifestContext) userManifestsBootstrapper() {
 			"data": data,
 			"name": userConfigMapName(name),
 		}
-		manifest, err := c.substituteParams(params, "user-manifests-bootstrapper/user-manifest-template.yaml")
-		if err != nil {
-			panic(err.Error())
-		}
+		manifest := c.renderTemplate(params, "user-manifests-bootstrapper/user-manifest-template.yaml")
 		c.addManifest("user-manifest-"+name, manifest)
 	}
 }
 
+// clusterAutoscaler adds the cluster autoscaler and a MachineAutoscaler for each pool.
+// The autoscaler discovers the machinesets of the cluster through the MachineAutoscalers.
+func (c *clusterManifestContext) clusterAutoscaler() {
+	c.addManifestFiles(
+		"cluster-autoscaler/cluster-autoscaler-rbac.yaml",
+		"cluster-autoscaler/cluster-autoscaler-deployment.yaml",
+	)
+	params := c.params.(*api.ClusterParams)
+	for _, pool := range params.Autoscaling.Pools {
+		manifest := c.renderTemplate(map[string]interface{}{
+			"Name":        pool.MachineSet,
+			"Cluster":     params.Namespace,
+			"MachineSet":  pool.MachineSet,
+			"MinReplicas": pool.MinReplicas,
+			"MaxReplicas": pool.MaxReplicas,
+		}, "cluster-autoscaler/machine-autoscaler-template.yaml")
+		c.addManifest(pool.MachineSet+"-machine-autoscaler.yaml", manifest)
+	}
+}
+
+func validateEtcd(params api.EtcdParams) error {
+	switch params.Mode {
+	case "", EtcdModeOperator, EtcdModeSimple:
+	default:
+		return errors.Errorf("unsupported etcd mode %q, must be %s or %s", params.Mode, EtcdModeOperator, EtcdModeSimple)
+	}
+	return nil
+}
+
+// validateEtcdEndpoints checks the endpoints of an external etcd, which replaces the etcd
+// deployed with the control plane
+func validateEtcdEndpoints(endpoints []string, etcd bool) error {
+	if len(endpoints) == 0 {
+		return nil
+	}
+	if etcd {
+		return errors.New("a cluster cannot both deploy etcd and use external etcd endpoints")
+	}
+	for _, endpoint := range endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Scheme != "https" || len(u.Host) == 0 {
+			return errors.Errorf("invalid etcd endpoint %q, must be an https URL", endpoint)
+		}
+	}
+	return nil
+}
+
+func validateAutoscaling(params api.AutoscalingParams) error {
+	names := map[string]bool{}
+	for _, pool := range params.Pools {
+		if len(pool.MachineSet) == 0 {
+			return errors.New("an autoscaling pool must specify a machineset")
+		}
+		if names[pool.MachineSet] {
+			return errors.Errorf("duplicate autoscaling pool for machineset %s", pool.MachineSet)
+		}
+		names[pool.MachineSet] = true
+		if pool.MinReplicas < 0 || pool.MaxReplicas < 1 || pool.MinReplicas > pool.MaxReplicas {
+			return errors.Errorf("invalid replicas for autoscaling pool %s: min %d, max %d", pool.MachineSet, pool.MinReplicas, pool.MaxReplicas)
+		}
+	}
+	return nil
+}
+
+// podDisruptionBudget adds a PodDisruptionBudget for the pods with the given app label
+// when the control plane runs more than one replica of each component
+func (c *clusterManifestContext) podDisruptionBudget(app string) {
+	replicas, err := strconv.Atoi(c.params.(*api.ClusterParams).Replicas)
+	if err != nil || replicas < 2 {
+		return
+	}
+	params := map[string]string{
+		"Name": app,
+	}
+	manifest := c.renderTemplate(params, "common/pod-disruption-budget-template.yaml")
+	c.addManifest(app+"-pdb.yaml", manifest)
+}
+
 func (c *clusterManifestContext) addUserManifestFiles(name ...string) {
 	c.userManifestFiles = append(c.userManifestFiles, name...)
 }
@@ -0,0 +1,109 @@
+package render
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestClusterVersionConfigMap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	params := &api.ClusterParams{
+		ReleaseImage: "quay.io/openshift-release-dev/ocp-release:4.5.0-x86_64",
+		ClusterVersion: api.ClusterVersionParams{
+			Channel: "stable-4.5",
+			Overrides: []api.ClusterVersionOverride{
+				{Kind: "Deployment", Group: "apps", Namespace: "openshift-console", Name: "console"},
+				{Kind: "ClusterOperator", Group: "config.openshift.io", Name: "console"},
+			},
+		},
+	}
+	ctx := newClusterManifestContext(nil, nil, params, dir, false, false)
+	ctx.addManifestFiles("cluster-version-operator/cluster-version-configmap.yaml")
+	if err = ctx.renderManifests(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "cluster-version-configmap.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm := &corev1.ConfigMap{}
+	if err = yaml.UnmarshalStrict(b, cm); err != nil {
+		t.Fatalf("invalid configmap: %v\n%s", err, b)
+	}
+	if cm.Data["release-image"] != params.ReleaseImage || cm.Data["channel"] != "stable-4.5" || cm.Data["upstream"] != "" {
+		t.Errorf("unexpected settings: %v", cm.Data)
+	}
+	var overrides []api.ClusterVersionOverride
+	if err = yaml.UnmarshalStrict([]byte(cm.Data["overrides"]), &overrides); err != nil {
+		t.Fatalf("invalid overrides: %v", err)
+	}
+	if len(overrides) != 2 || overrides[1] != params.ClusterVersion.Overrides[1] {
+		t.Errorf("unexpected overrides: %+v", overrides)
+	}
+}
+
+func TestUserManifestsDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "user-manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"calico-v3.17.yaml": "kind: Namespace",
+		"README.md":         "# Calico",
+	}
+	for name, data := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := newClusterManifestContext(nil, nil, &api.ClusterParams{}, dir, false, false)
+	ctx.userManifestsDir(dir)
+	if ctx.err != nil {
+		t.Fatalf("unexpected error: %v", ctx.err)
+	}
+	if len(ctx.userManifests) != 1 || ctx.userManifests["extra-calico-v3-17.yaml"] != "kind: Namespace" {
+		t.Errorf("unexpected user manifests: %v", ctx.userManifests)
+	}
+	if name := userConfigMapName("extra-calico-v3-17.yaml"); name != "user-manifest-extra-calico-v3-17" {
+		t.Errorf("unexpected config map name %s", name)
+	}
+}
+
+func TestUserCABundleConfigMap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bundle := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	params := &api.ClusterParams{AdditionalTrustBundle: bundle}
+	ctx := newClusterManifestContext(nil, nil, params, dir, false, false)
+	ctx.addManifestFiles("cluster-bootstrap/" + userCABundleConfig)
+	if err = ctx.renderManifests(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, userCABundleConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm := &corev1.ConfigMap{}
+	if err = yaml.UnmarshalStrict(b, cm); err != nil {
+		t.Fatalf("invalid configmap: %v\n%s", err, b)
+	}
+	if cm.Namespace != "openshift-config" || strings.TrimSpace(cm.Data["ca-bundle.crt"]) != strings.TrimSpace(bundle) {
+		t.Errorf("unexpected configmap:\n%s", b)
+	}
+}
@@ -0,0 +1,54 @@
+package render
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+var metricLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func validateMonitoring(params api.MonitoringParams) error {
+	for name := range params.Labels {
+		if !metricLabelName.MatchString(name) {
+			return errors.Errorf("invalid metric label name %q", name)
+		}
+		if name == "hosted_cluster" {
+			return errors.New("the hosted_cluster metric label is reserved")
+		}
+	}
+	return nil
+}
+
+// monitoring adds a metrics service and a ServiceMonitor for every control plane component
+// that runs a metrics proxy, and allows the proxies to read metrics in the target cluster.
+// The proxies authenticate the monitoring stack against the management cluster.
+func (c *clusterManifestContext) monitoring(etcd bool) {
+	params := c.params.(*api.ClusterParams)
+	c.addManifestFiles(
+		"monitoring/metrics-proxy-rbac.yaml",
+	)
+	c.addUserManifestFiles(
+		"monitoring/metrics-reader-rbac.yaml",
+	)
+	components := []string{"kube-apiserver", "kube-controller-manager", "control-plane-operator"}
+	if etcd && params.Etcd.Mode == EtcdModeSimple {
+		components = append(components, "etcd")
+	}
+	for _, name := range components {
+		monitorParams := map[string]interface{}{
+			"Name":      name,
+			"Namespace": params.Namespace,
+			"Labels":    params.Monitoring.Labels,
+		}
+		for suffix, file := range map[string]string{
+			"-metrics-service.yaml": "monitoring/metrics-service-template.yaml",
+			"-service-monitor.yaml": "monitoring/service-monitor-template.yaml",
+		} {
+			manifest := c.renderTemplate(monitorParams, file)
+			c.addManifest(name+suffix, manifest)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestValidateMonitoring(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		expectError bool
+	}{
+		{name: "no labels"},
+		{name: "valid labels", labels: map[string]string{"tenant": "acme", "_region": "us-east-1"}},
+		{name: "invalid label", labels: map[string]string{"tenant-name": "acme"}, expectError: true},
+		{name: "reserved label", labels: map[string]string{"hosted_cluster": "acme"}, expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateMonitoring(api.MonitoringParams{Enabled: true, Labels: test.labels})
+			if test.expectError != (err != nil) {
+				t.Errorf("expected error %t, got %v", test.expectError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package render
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestNetworkPolicies(t *testing.T) {
+	tests := []struct {
+		name         string
+		params       api.ClusterParams
+		etcd         bool
+		vpn          bool
+		konnectivity bool
+		oauth        bool
+		expected     []string
+	}{
+		{
+			name:     "minimal",
+			params:   api.ClusterParams{InternalAPIPort: 6443},
+			expected: []string{"default-deny", "kube-apiserver", "openshift-apiserver"},
+		},
+		{
+			name: "all components",
+			params: api.ClusterParams{
+				InternalAPIPort:      6443,
+				ExternalIgnitionPort: 443,
+				Monitoring:           api.MonitoringParams{Enabled: true},
+			},
+			etcd:     true,
+			vpn:      true,
+			oauth:    true,
+			expected: []string{"default-deny", "etcd", "ignition-server", "kube-apiserver", "metrics", "oauth-openshift", "openshift-apiserver", "openvpn-server"},
+		},
+		{
+			name:         "konnectivity",
+			params:       api.ClusterParams{InternalAPIPort: 6443},
+			konnectivity: true,
+			expected:     []string{"default-deny", "kube-apiserver", "openshift-apiserver"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "manifests")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			ctx := newClusterManifestContext(nil, nil, &test.params, dir, test.vpn, test.konnectivity)
+			ctx.networkPolicies(test.etcd, test.vpn, test.oauth)
+			if err = ctx.renderManifests(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			files, err := filepath.Glob(filepath.Join(dir, "*-network-policy.yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, file := range files {
+				b, err := ioutil.ReadFile(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				policy := &networkingv1.NetworkPolicy{}
+				if err = yaml.UnmarshalStrict(b, policy); err != nil {
+					t.Fatalf("invalid network policy %s: %v", file, err)
+				}
+				names = append(names, policy.Name)
+				if policy.Name != "kube-apiserver" {
+					continue
+				}
+				ports := len(policy.Spec.Ingress[0].Ports)
+				if (test.konnectivity && ports != 2) || (!test.konnectivity && ports != 1) {
+					t.Errorf("unexpected API server ports: %v", policy.Spec.Ingress[0].Ports)
+				}
+			}
+			sort.Strings(names)
+			if strings.Join(names, ",") != strings.Join(test.expected, ",") {
+				t.Errorf("expected network policies %v, got %v", test.expected, names)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package render
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+// osImageName is the image of a release with the ostree commit of its RHCOS
+const osImageName = "machine-os-content"
+
+func validateNodeUpdates(params api.NodeUpdatesParams, images map[string]string) error {
+	if !params.Enabled {
+		return nil
+	}
+	if params.MaxUnavailable < 0 {
+		return errors.Errorf("invalid node updates max unavailable %d, must not be negative", params.MaxUnavailable)
+	}
+	if len(images[osImageName]) == 0 {
+		return errors.Errorf("the release has no %s image to update the nodes to", osImageName)
+	}
+	return nil
+}
+
+// nodeUpdater adds the node updater daemonset of the target cluster, which rebases the
+// workers to the OS image that the node-updater controller of the control plane operator
+// sets on them, and the configmap with the OS image of the release that the controller
+// reads from the control plane namespace
+func (c *clusterManifestContext) nodeUpdater() {
+	c.addManifestFiles(
+		"node-updater/node-updater-configmap.yaml",
+	)
+	c.addUserManifestFiles(
+		"node-updater/node-updater-namespace.yaml",
+		"node-updater/node-updater-rbac.yaml",
+		"node-updater/node-updater-daemonset.yaml",
+	)
+}
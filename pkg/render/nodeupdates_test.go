@@ -0,0 +1,61 @@
+package render
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestNodeUpdater(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	images := map[string]string{
+		osImageName: "quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:0123",
+		"cli":       "quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:4567",
+	}
+	params := &api.ClusterParams{NodeUpdates: api.NodeUpdatesParams{Enabled: true, MaxUnavailable: 2}}
+	ctx := newClusterManifestContext(images, nil, params, dir, false, false)
+	ctx.nodeUpdater()
+	ctx.addManifestFiles(ctx.userManifestFiles...)
+	if err = ctx.renderManifests(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "node-updater-configmap.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm := &corev1.ConfigMap{}
+	if err = yaml.UnmarshalStrict(b, cm); err != nil {
+		t.Fatalf("invalid configmap: %v\n%s", err, b)
+	}
+	if cm.Data["os-image-url"] != images[osImageName] || cm.Data["max-unavailable"] != "2" {
+		t.Errorf("unexpected settings: %v", cm.Data)
+	}
+	if b, err = ioutil.ReadFile(filepath.Join(dir, "node-updater-daemonset.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	ds := &appsv1.DaemonSet{}
+	if err = yaml.UnmarshalStrict(b, ds); err != nil {
+		t.Fatalf("invalid daemonset: %v\n%s", err, b)
+	}
+	if image := ds.Spec.Template.Spec.Containers[0].Image; image != images["cli"] {
+		t.Errorf("unexpected image %s", image)
+	}
+
+	if err = validateNodeUpdates(params.NodeUpdates, map[string]string{}); err == nil {
+		t.Errorf("expected an error for a release without an OS image")
+	}
+	if err = validateNodeUpdates(api.NodeUpdatesParams{Enabled: true, MaxUnavailable: -1}, images); err == nil {
+		t.Errorf("expected an error for a negative max unavailable")
+	}
+}
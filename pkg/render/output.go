@@ -0,0 +1,174 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+const (
+	// OutputFormatDir leaves each rendered manifest in a file of the output directory
+	OutputFormatDir = "dir"
+	// OutputFormatStream writes the rendered manifests as a single multi-document YAML stream
+	OutputFormatStream = "stream"
+	// OutputFormatKustomize adds a kustomization.yaml that lists the rendered manifests as the
+	// resources of a kustomize base
+	OutputFormatKustomize = "kustomize"
+	// OutputFormatHelm turns the output directory into a Helm chart whose templates are the
+	// rendered manifests
+	OutputFormatHelm = "helm"
+
+	kustomizationFile = "kustomization.yaml"
+)
+
+// OutputFormats are the supported formats of rendered manifests
+var OutputFormats = []string{OutputFormatDir, OutputFormatStream, OutputFormatKustomize, OutputFormatHelm}
+
+// helmTemplateEscaper escapes the template actions of rendered manifests, such as those of
+// alerting rules, so that Helm leaves them as they are
+var helmTemplateEscaper = strings.NewReplacer("{{", `{{ "{{" }}`)
+
+type helmChart struct {
+	APIVersion  string `json:"apiVersion"`
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	AppVersion  string `json:"appVersion,omitempty"`
+	Description string `json:"description"`
+}
+
+type kustomization struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Resources  []string `json:"resources"`
+}
+
+// WriteManifestStream writes the manifests rendered in dir to w as a multi-document YAML
+// stream, in the order of their file names
+func WriteManifestStream(dir string, w io.Writer) error {
+	files, err := renderedManifests(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		content, err := ioutil.ReadFile(filepath.Join(dir, f))
+		if err != nil {
+			return err
+		}
+		content = bytes.TrimSpace(bytes.TrimPrefix(bytes.TrimSpace(content), []byte("---")))
+		if _, err = fmt.Fprintf(w, "---\n# Source: %s\n%s\n", f, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteKustomization writes a kustomization.yaml to dir that lists the manifests rendered
+// in it as its resources, so that dir can be used as a kustomize base
+func WriteKustomization(dir string) error {
+	files, err := renderedManifests(dir)
+	if err != nil {
+		return err
+	}
+	return writeYAML(filepath.Join(dir, kustomizationFile), &kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  files,
+	})
+}
+
+// renderedManifests returns the sorted names of the manifest files in dir. Templates whose
+// content is conditional may render to empty files, which are skipped.
+func renderedManifests(dir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || name == kustomizationFile || !strings.HasSuffix(name, ".yaml") {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(content)) == 0 {
+			continue
+		}
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// WriteHelmChart turns the manifests rendered in dir into a Helm chart of the control plane
+// of a cluster: the manifests are moved to the templates directory of the chart, and the
+// parameters they were rendered from are its values.yaml. The templates do not reference
+// the values; a new version of the chart is rendered when the parameters change.
+func WriteHelmChart(dir string, params *api.ClusterParams, version string) error {
+	files, err := renderedManifests(dir)
+	if err != nil {
+		return err
+	}
+	templatesDir := filepath.Join(dir, "templates")
+	if err = os.MkdirAll(templatesDir, 0755); err != nil {
+		return err
+	}
+	for _, f := range files {
+		content, err := ioutil.ReadFile(filepath.Join(dir, f))
+		if err != nil {
+			return err
+		}
+		fileName := filepath.Join(templatesDir, f)
+		if err = ioutil.WriteFile(fileName, []byte(helmTemplateEscaper.Replace(string(content))), 0644); err != nil {
+			return errors.Wrapf(err, "cannot write %s", fileName)
+		}
+		if err = os.Remove(filepath.Join(dir, f)); err != nil {
+			return err
+		}
+	}
+	chart := &helmChart{
+		APIVersion:  "v1",
+		Name:        params.Namespace,
+		Version:     version,
+		AppVersion:  releaseTag(params.ReleaseImage),
+		Description: fmt.Sprintf("Control plane of hosted cluster %s", params.Namespace),
+	}
+	if err = writeYAML(filepath.Join(dir, "Chart.yaml"), chart); err != nil {
+		return err
+	}
+	return writeYAML(filepath.Join(dir, "values.yaml"), params)
+}
+
+// releaseTag returns the tag of a release image, or nothing if it is referenced by digest
+func releaseTag(image string) string {
+	if strings.Contains(image, "@") {
+		return ""
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return ""
+}
+
+func writeYAML(fileName string, obj interface{}) error {
+	b, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(fileName, b, 0644); err != nil {
+		return errors.Wrapf(err, "cannot write %s", fileName)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package render
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestRenderedManifestOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"b-service.yaml":   "kind: Service\n",
+		"a-configmap.yaml": "---\nkind: ConfigMap\n",
+		"empty.yaml":       "\n\n",
+		"notes.txt":        "not a manifest",
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	if err = WriteManifestStream(dir, out); err != nil {
+		t.Fatal(err)
+	}
+	expected := "---\n# Source: a-configmap.yaml\nkind: ConfigMap\n---\n# Source: b-service.yaml\nkind: Service\n"
+	if out.String() != expected {
+		t.Errorf("unexpected stream:\n%s", out.String())
+	}
+
+	if err = WriteKustomization(dir); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, kustomizationFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := &kustomization{}
+	if err = yaml.Unmarshal(b, k); err != nil {
+		t.Fatal(err)
+	}
+	if len(k.Resources) != 2 || k.Resources[0] != "a-configmap.yaml" || k.Resources[1] != "b-service.yaml" {
+		t.Errorf("unexpected resources: %v", k.Resources)
+	}
+	// Writing the kustomization again does not list it as a resource of its own
+	if err = WriteKustomization(dir); err != nil {
+		t.Fatal(err)
+	}
+	if files, err := renderedManifests(dir); err != nil || len(files) != 2 {
+		t.Errorf("unexpected manifests %v, error %v", files, err)
+	}
+}
+
+func TestWriteHelmChart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rule := "summary: {{ $labels.instance }} is down\n"
+	if err = ioutil.WriteFile(filepath.Join(dir, "rules.yaml"), []byte(rule), 0644); err != nil {
+		t.Fatal(err)
+	}
+	params := &api.ClusterParams{Namespace: "example", ReleaseImage: "quay.io/openshift-release-dev/ocp-release:4.4.0-x86_64"}
+	if err = WriteHelmChart(dir, params, "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "rules.yaml")); !os.IsNotExist(err) {
+		t.Errorf("expected manifest to be moved to the templates, got %v", err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "templates", "rules.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "summary: {{ \"{{\" }} $labels.instance }} is down\n"; string(b) != expected {
+		t.Errorf("unexpected template: %s", b)
+	}
+	b, err = ioutil.ReadFile(filepath.Join(dir, "Chart.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chart := &helmChart{}
+	if err = yaml.Unmarshal(b, chart); err != nil {
+		t.Fatal(err)
+	}
+	if chart.Name != "example" || chart.Version != "1.0.0" || chart.AppVersion != "4.4.0-x86_64" {
+		t.Errorf("unexpected chart: %#v", chart)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "values.yaml")); err != nil {
+		t.Errorf("expected values: %v", err)
+	}
+}
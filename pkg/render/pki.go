@@ -2,12 +2,44 @@ package render
 
 import (
 	"text/template"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
 )
 
-func RenderPKISecrets(pkiDir, outputDir string, etcd, vpn bool, externalOauth bool) {
+// certRotationController is the control plane operator controller that rotates certificates
+const certRotationController = "cert-rotation"
+
+// RenderPKISecrets renders the secrets that hold the PKI of the cluster. The CA keys are
+// only rendered when certRotation is true, because they are only needed in the control
+// plane namespace to sign rotated certificates.
+func RenderPKISecrets(pkiDir, outputDir string, etcd, vpn, konnectivity bool, externalOauth bool, certRotation bool, ignitionServer bool, etcdEncryption bool, monitoring bool) error {
+	ctx := newPKIRenderContext(pkiDir, outputDir)
+	ctx.setupManifests(etcd, vpn, konnectivity, externalOauth, certRotation, ignitionServer, etcdEncryption, monitoring)
+	return ctx.renderManifests()
+}
+
+// RenderNamedCertsSecret renders the secret with the named serving certificates of the
+// kube-apiserver, if the cluster has any
+func RenderNamedCertsSecret(params *api.ClusterParams, pkiDir, outputDir string) error {
+	if len(params.NamedCerts) == 0 {
+		return nil
+	}
 	ctx := newPKIRenderContext(pkiDir, outputDir)
-	ctx.setupManifests(etcd, vpn, externalOauth)
-	ctx.renderManifests()
+	ctx.params = params
+	ctx.addManifestFiles(
+		"kube-apiserver/kube-apiserver-named-certs-secret.yaml",
+	)
+	return ctx.renderManifests()
+}
+
+// CertRotationEnabled returns whether the control plane operator of the cluster rotates certificates
+func CertRotationEnabled(params *api.ClusterParams) bool {
+	for _, controller := range params.ControlPlaneOperatorControllers {
+		if controller == certRotationController {
+			return true
+		}
+	}
+	return false
 }
 
 type pkiRenderContext struct {
@@ -25,15 +57,27 @@ func newPKIRenderContext(pkiDir, outputDir string) *pkiRenderContext {
 	return ctx
 }
 
-func (c *pkiRenderContext) setupManifests(etcd bool, vpn bool, externalOauth bool) {
+func (c *pkiRenderContext) setupManifests(etcd bool, vpn bool, konnectivity bool, externalOauth bool, certRotation bool, ignitionServer bool, etcdEncryption bool, monitoring bool) {
 	c.serviceAdminKubeconfig()
+	if certRotation {
+		c.pkiCA(vpn)
+	}
 	c.kubeAPIServer(vpn)
+	if etcdEncryption {
+		c.etcdEncryption()
+	}
+	if monitoring {
+		c.metricsClient()
+	}
 	if etcd {
 		c.etcd()
 	}
 	if externalOauth {
 		c.oauthOpenshiftServer()
 	}
+	if ignitionServer {
+		c.ignitionServer()
+	}
 	c.kubeControllerManager()
 	c.openshiftAPIServer()
 	c.openshiftControllerManager()
@@ -41,6 +85,9 @@ func (c *pkiRenderContext) setupManifests(etcd bool, vpn bool, externalOauth boo
 	if vpn {
 		c.openVPN()
 	}
+	if konnectivity {
+		c.konnectivity()
+	}
 }
 
 func (c *pkiRenderContext) etcd() {
@@ -53,10 +100,7 @@ func (c *pkiRenderContext) etcd() {
 			"secret": secret,
 			"file":   file,
 		}
-		content, err := c.substituteParams(params, "etcd/etcd-secret-template.yaml")
-		if err != nil {
-			panic(err.Error())
-		}
+		content := c.renderTemplate(params, "etcd/etcd-secret-template.yaml")
 		c.addManifest(file+"-tls-secret.yaml", content)
 	}
 }
@@ -68,6 +112,12 @@ func (c *pkiRenderContext) oauthOpenshiftServer() {
 	)
 }
 
+func (c *pkiRenderContext) ignitionServer() {
+	c.addManifestFiles(
+		"ignition-server/ignition-server-secret.yaml",
+	)
+}
+
 func (c *pkiRenderContext) kubeAPIServer(includeVPN bool) {
 	c.addManifestFiles(
 		"kube-apiserver/kube-apiserver-secret.yaml",
@@ -80,6 +130,18 @@ func (c *pkiRenderContext) kubeAPIServer(includeVPN bool) {
 	}
 }
 
+func (c *pkiRenderContext) etcdEncryption() {
+	c.addManifestFiles(
+		"kube-apiserver/etcd-encryption-secret.yaml",
+	)
+}
+
+func (c *pkiRenderContext) metricsClient() {
+	c.addManifestFiles(
+		"monitoring/metrics-client-secret.yaml",
+	)
+}
+
 func (c *pkiRenderContext) kubeControllerManager() {
 	c.addManifestFiles(
 		"kube-controller-manager/kube-controller-manager-secret.yaml",
@@ -120,8 +182,26 @@ func (c *pkiRenderContext) openVPN() {
 	)
 }
 
+func (c *pkiRenderContext) konnectivity() {
+	c.addManifestFiles(
+		"konnectivity/konnectivity-server-secret.yaml",
+		"konnectivity/konnectivity-agent-secret.yaml",
+	)
+}
+
 func (c *pkiRenderContext) serviceAdminKubeconfig() {
 	c.addManifestFiles(
 		"common/service-network-admin-kubeconfig-secret.yaml",
 	)
 }
+
+func (c *pkiRenderContext) pkiCA(vpn bool) {
+	c.addManifestFiles(
+		"common/pki-ca-secret.yaml",
+	)
+	if vpn {
+		c.addManifestFiles(
+			"openvpn/openvpn-ca-secret.yaml",
+		)
+	}
+}
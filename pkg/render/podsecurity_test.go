@@ -0,0 +1,110 @@
+package render
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestPodSecurity(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	params := api.NewClusterParams()
+	params.Namespace = "example"
+	params.ServiceCIDR = "172.30.0.0/16"
+	params.PodCIDR = "10.128.0.0/14"
+	params.InternalAPIPort = 6443
+	params.Replicas = "1"
+	params.Etcd.Mode = EtcdModeSimple
+	params.Monitoring.Enabled = true
+	params.PodSecurity.SeccompProfile = "runtime/default"
+	ctx := newClusterManifestContext(map[string]string{}, map[string]string{}, params, dir, true, false)
+	ctx.setupManifests(true, true, false, true, false)
+	if err = ctx.renderManifests(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	serviceAccounts := map[string]bool{}
+	pods := map[string]corev1.PodTemplateSpec{}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, doc := range bytes.Split(b, []byte("\n---")) {
+			meta := struct {
+				Kind     string `json:"kind"`
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+			}{}
+			if err = yaml.Unmarshal(doc, &meta); err != nil {
+				t.Fatalf("invalid manifest %s: %v", file, err)
+			}
+			switch meta.Kind {
+			case "ServiceAccount":
+				serviceAccounts[meta.Metadata.Name] = true
+			case "Deployment":
+				deployment := &appsv1.Deployment{}
+				if err = yaml.Unmarshal(doc, deployment); err != nil {
+					t.Fatalf("invalid deployment %s: %v", file, err)
+				}
+				pods[deployment.Name] = deployment.Spec.Template
+			case "StatefulSet":
+				statefulSet := &appsv1.StatefulSet{}
+				if err = yaml.Unmarshal(doc, statefulSet); err != nil {
+					t.Fatalf("invalid statefulset %s: %v", file, err)
+				}
+				pods[statefulSet.Name] = statefulSet.Spec.Template
+			}
+		}
+	}
+	for _, name := range []string{"kube-apiserver", "etcd", "openvpn-server", "control-plane-operator"} {
+		if _, ok := pods[name]; !ok {
+			t.Fatalf("expected pods of %s", name)
+		}
+	}
+
+	privileged := map[string]bool{}
+	for _, name := range PrivilegedServiceAccounts(true) {
+		privileged[name] = true
+	}
+	for name, pod := range pods {
+		sa := pod.Spec.ServiceAccountName
+		if len(sa) == 0 || !serviceAccounts[sa] {
+			t.Errorf("%s: expected a service account of its own, got %q", name, sa)
+		}
+		if pod.Annotations["seccomp.security.alpha.kubernetes.io/pod"] != "runtime/default" {
+			t.Errorf("%s: expected the seccomp profile annotation, got %v", name, pod.Annotations)
+		}
+		for _, c := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+			sc := c.SecurityContext
+			switch {
+			case sc != nil && sc.Privileged != nil && *sc.Privileged:
+				if !strings.HasPrefix(c.Name, "openvpn-") || !privileged[sa] {
+					t.Errorf("%s: unexpected privileged container %s with service account %s", name, c.Name, sa)
+				}
+			case name == "control-plane-operator" && c.Name == "control-plane-operator":
+			case sc == nil || sc.RunAsNonRoot == nil || !*sc.RunAsNonRoot || sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation:
+				t.Errorf("%s: container %s is not restricted: %+v", name, c.Name, sc)
+			}
+		}
+	}
+}
@@ -8,8 +8,6 @@ import (
 	"text/template"
 
 	"github.com/pkg/errors"
-
-	assets "github.com/openshift/hypershift-toolkit/pkg/assets"
 )
 
 type renderContext struct {
@@ -18,6 +16,11 @@ type renderContext struct {
 	funcs         template.FuncMap
 	manifestFiles []string
 	manifests     map[string]string
+	assets        *assetSet
+
+	// err is the first error of the manifests that are rendered while they are set up,
+	// which renderManifests returns
+	err error
 }
 
 func newRenderContext(params interface{}, outputDir string) *renderContext {
@@ -25,6 +28,7 @@ func newRenderContext(params interface{}, outputDir string) *renderContext {
 		params:    params,
 		outputDir: outputDir,
 		manifests: make(map[string]string),
+		assets:    defaultAssetSet(),
 	}
 	return renderContext
 }
@@ -34,18 +38,25 @@ func (c *renderContext) setFuncs(f template.FuncMap) {
 }
 
 func (c *renderContext) renderManifests() error {
+	if c.err != nil {
+		return c.err
+	}
 	for _, f := range c.manifestFiles {
 		outputFile := filepath.Join(c.outputDir, path.Base(f))
 		content, err := c.substituteParams(c.params, f)
 		if err != nil {
 			return errors.Wrapf(err, "cannot render %s", f)
 		}
-		ioutil.WriteFile(outputFile, []byte(content), 0644)
+		if err = ioutil.WriteFile(outputFile, []byte(content), 0644); err != nil {
+			return errors.Wrapf(err, "cannot write %s", outputFile)
+		}
 	}
 
 	for name, content := range c.manifests {
 		outputFile := filepath.Join(c.outputDir, name)
-		ioutil.WriteFile(outputFile, []byte(content), 0644)
+		if err := ioutil.WriteFile(outputFile, []byte(content), 0644); err != nil {
+			return errors.Wrapf(err, "cannot write %s", outputFile)
+		}
 	}
 
 	return nil
@@ -59,13 +70,38 @@ func (c *renderContext) addManifest(name, content string) {
 	c.manifests[name] = content
 }
 
+// setError records the first error of the manifests that are set up, annotated with the
+// manifest it occurred in
+func (c *renderContext) setError(err error, manifest string) {
+	if c.err == nil {
+		c.err = errors.Wrapf(err, "cannot render %s", manifest)
+	}
+}
+
+// renderTemplate renders a template while the manifests are set up. Its error is recorded
+// and returned by renderManifests.
+func (c *renderContext) renderTemplate(data interface{}, fileName string) string {
+	content, err := c.substituteParams(data, fileName)
+	if err != nil {
+		c.setError(err, fileName)
+	}
+	return content
+}
+
+// substituteParams executes the template in the asset fileName. The template is named
+// after the asset, so that its errors identify the asset and the line they occurred on.
 func (c *renderContext) substituteParams(data interface{}, fileName string) (string, error) {
-	out := &bytes.Buffer{}
-	asset := assets.MustAsset(fileName)
-	t := template.Must(template.New("template").Funcs(c.funcs).Parse(string(asset)))
-	err := t.Execute(out, data)
+	asset, err := c.assets.asset(fileName)
 	if err != nil {
-		panic(err.Error())
+		return "", errors.Wrapf(err, "cannot find template %s", fileName)
+	}
+	t, err := template.New(fileName).Funcs(c.funcs).Parse(string(asset))
+	if err != nil {
+		return "", err
+	}
+	out := &bytes.Buffer{}
+	if err = t.Execute(out, data); err != nil {
+		return "", err
 	}
 	return out.String(), nil
 }
@@ -0,0 +1,94 @@
+package render
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestRenderPKISecretsMissingFile(t *testing.T) {
+	pkiDir, err := ioutil.TempDir("", "pki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(pkiDir)
+	outputDir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outputDir)
+
+	err = RenderPKISecrets(pkiDir, outputDir, false, false, false, false, false, false, false, false)
+	if err == nil {
+		t.Fatal("expected an error for an empty PKI directory")
+	}
+	// The error identifies the template, the line and the missing file
+	for _, s := range []string{"cannot render", ".yaml:", "cannot read PKI file"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("expected error to contain %q, got: %v", s, err)
+		}
+	}
+}
+
+func TestCIDRFuncs(t *testing.T) {
+	if mask, err := cidrMask("10.0.0.0/16"); err != nil || mask != "255.255.0.0" {
+		t.Errorf("unexpected mask %q, error %v", mask, err)
+	}
+	if _, err := cidrMask("fd00::/64"); err == nil {
+		t.Errorf("expected an error for an IPv6 CIDR")
+	}
+	if _, err := cidrAddress("10.0.0.0"); err == nil {
+		t.Errorf("expected an error for an address without a prefix length")
+	}
+}
+
+func TestRenderNamedCertsSecret(t *testing.T) {
+	pkiDir, err := ioutil.TempDir("", "pki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(pkiDir)
+	outputDir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outputDir)
+
+	params := &api.ClusterParams{}
+	if err = RenderNamedCertsSecret(params, pkiDir, outputDir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if files, _ := ioutil.ReadDir(outputDir); len(files) > 0 {
+		t.Fatalf("expected no secret without named certificates")
+	}
+
+	params.NamedCerts = []api.NamedCert{{NamedCertPrefix: "api-custom", NamedCertDomain: "api.example.com"}}
+	for _, file := range []string{"api-custom.crt", "api-custom.key"} {
+		if err = ioutil.WriteFile(filepath.Join(pkiDir, file), []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = RenderNamedCertsSecret(params, pkiDir, outputDir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(outputDir, "kube-apiserver-named-certs-secret.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := &corev1.Secret{}
+	if err = yaml.Unmarshal(b, secret); err != nil {
+		t.Fatalf("cannot parse the secret: %v\n%s", err, b)
+	}
+	for _, file := range []string{"api-custom.crt", "api-custom.key"} {
+		if string(secret.Data[file]) != file {
+			t.Errorf("expected %s in the secret, got %q", file, secret.Data[file])
+		}
+	}
+}
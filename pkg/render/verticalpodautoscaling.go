@@ -0,0 +1,65 @@
+package render
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+const defaultVPAUpdateMode = "Auto"
+
+var vpaUpdateModes = sets.NewString("Off", "Initial", "Recreate", "Auto")
+
+func validateVerticalPodAutoscaling(params api.VerticalPodAutoscalingParams) error {
+	if len(params.UpdateMode) > 0 && !vpaUpdateModes.Has(params.UpdateMode) {
+		return errors.Errorf("unsupported vertical pod autoscaler update mode %q, must be one of %v", params.UpdateMode, vpaUpdateModes.List())
+	}
+	return nil
+}
+
+// verticalPodAutoscalers adds a VerticalPodAutoscaler for every control plane deployment
+// and for the etcd statefulset in simple mode. The autoscalers only set the requests of
+// the containers, so the memory limits of the sizing profile still apply. The members of
+// an EtcdCluster are pods of the etcd operator, which cannot be autoscaled.
+func (c *clusterManifestContext) verticalPodAutoscalers(etcd, vpn, externalOauth bool) {
+	params := c.params.(*api.ClusterParams)
+	updateMode := params.VerticalPodAutoscaling.UpdateMode
+	if len(updateMode) == 0 {
+		updateMode = defaultVPAUpdateMode
+	}
+	deployments := []string{
+		"kube-apiserver",
+		"kube-controller-manager",
+		"kube-scheduler",
+		"openshift-apiserver",
+		"openshift-controller-manager",
+		"cluster-policy-controller",
+		"cluster-version-operator",
+		"control-plane-operator",
+	}
+	if externalOauth {
+		deployments = append(deployments, "oauth-openshift")
+	}
+	if vpn {
+		deployments = append(deployments, "openvpn-server")
+	}
+	if params.ExternalIgnitionPort != 0 {
+		deployments = append(deployments, "ignition-server")
+	}
+	targets := map[string]string{}
+	for _, name := range deployments {
+		targets[name] = "Deployment"
+	}
+	if etcd && params.Etcd.Mode == EtcdModeSimple {
+		targets["etcd"] = "StatefulSet"
+	}
+	for name, kind := range targets {
+		manifest := c.renderTemplate(map[string]string{
+			"Name":       name,
+			"Kind":       kind,
+			"UpdateMode": updateMode,
+		}, "common/vertical-pod-autoscaler-template.yaml")
+		c.addManifest(name+"-vpa.yaml", manifest)
+	}
+}
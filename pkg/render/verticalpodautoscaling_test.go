@@ -0,0 +1,114 @@
+package render
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/hypershift-toolkit/pkg/api"
+)
+
+func TestVerticalPodAutoscalers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	params := &api.ClusterParams{
+		Etcd:                   api.EtcdParams{Mode: EtcdModeSimple},
+		VerticalPodAutoscaling: api.VerticalPodAutoscalingParams{Enabled: true},
+	}
+	ctx := newClusterManifestContext(nil, nil, params, dir, false, false)
+	ctx.verticalPodAutoscalers(true, false, true)
+	if err = ctx.renderManifests(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*-vpa.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 10 {
+		t.Errorf("expected 10 vertical pod autoscalers, got %d", len(files))
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "etcd-vpa.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vpa := map[string]interface{}{}
+	if err = yaml.Unmarshal(b, &vpa); err != nil {
+		t.Fatalf("invalid vertical pod autoscaler: %v", err)
+	}
+	spec := vpa["spec"].(map[string]interface{})
+	if kind := spec["targetRef"].(map[string]interface{})["kind"]; kind != "StatefulSet" {
+		t.Errorf("expected etcd to be a StatefulSet, got %v", kind)
+	}
+	if mode := spec["updatePolicy"].(map[string]interface{})["updateMode"]; mode != defaultVPAUpdateMode {
+		t.Errorf("expected update mode %s, got %v", defaultVPAUpdateMode, mode)
+	}
+
+	if err = validateVerticalPodAutoscaling(api.VerticalPodAutoscalingParams{UpdateMode: "Always"}); err == nil {
+		t.Errorf("expected an error for an unsupported update mode")
+	}
+}
+
+func TestSizingProfileResources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	params := api.NewClusterParams()
+	params.Namespace = "example"
+	params.ServiceCIDR = "172.30.0.0/16"
+	params.PodCIDR = "10.128.0.0/14"
+	params.InternalAPIPort = 6443
+	params.Replicas = "1"
+	params.Etcd.Mode = EtcdModeSimple
+	params.ExternalIgnitionPort = 443
+	params.SizingProfile = api.SizingProfileSmall
+	params, err = api.WithSizingProfile(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := newClusterManifestContext(map[string]string{}, map[string]string{}, params, dir, true, false)
+	ctx.setupManifests(true, true, false, true, false)
+	if err = ctx.renderManifests(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sized := 0
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, doc := range bytes.Split(b, []byte("\n---")) {
+			// Deployments and StatefulSets share the fields that are checked
+			workload := &appsv1.Deployment{}
+			if err = yaml.Unmarshal(doc, workload); err != nil {
+				t.Fatalf("invalid manifest %s: %v", file, err)
+			}
+			if workload.Kind != "Deployment" && workload.Kind != "StatefulSet" {
+				continue
+			}
+			sized++
+			container := workload.Spec.Template.Spec.Containers[0]
+			if container.Resources.Requests.Memory().IsZero() || container.Resources.Limits.Memory().IsZero() {
+				t.Errorf("%s: expected memory requests and limits, got %v", workload.Name, container.Resources)
+			}
+		}
+	}
+	if sized < 12 {
+		t.Errorf("expected at least 12 sized workloads, got %d", sized)
+	}
+}